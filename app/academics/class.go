@@ -26,6 +26,13 @@ func (h *Handler) CreateClassPeriod(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, period.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	period.InstituteID = instID
+
 	data, err := h.service.CreateClassPeriod(r.Context(), period)
 	if err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create class period: "+err.Error())
@@ -41,15 +48,9 @@ func (h *Handler) ListClassPeriods(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instituteIDStr := r.URL.Query().Get("institute_id")
-	if instituteIDStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id is required")
-		return
-	}
-
-	instituteID, err := uuid.Parse(instituteIDStr)
+	instituteID, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -40,6 +40,13 @@ func (h *Handler) CreateSubject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, subject.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	subject.InstituteID = instID
+
 	data, err := h.service.CreateSubject(r.Context(), subject)
 	if err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create subject: "+err.Error())
@@ -56,7 +63,6 @@ func (h *Handler) CreateSubject(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieve all subjects for an institute
 // @Tags Academics - Subjects
 // @Produce json
-// @Param institute_id query string true "Institute ID"
 // @Success 200 {object} dto.SuccessResponse{data=[]dto.SubjectResponse}
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -68,15 +74,9 @@ func (h *Handler) ListSubjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instituteIDStr := r.URL.Query().Get("institute_id")
-	if instituteIDStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id is required")
-		return
-	}
-
-	instituteID, err := uuid.Parse(instituteIDStr)
+	instituteID, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
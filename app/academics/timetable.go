@@ -40,6 +40,13 @@ func (h *Handler) CreateTimetableEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, entry.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	entry.InstituteID = instID
+
 	data, err := h.service.CreateTimetableEntry(r.Context(), entry)
 	if err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create timetable entry: "+err.Error())
@@ -68,18 +75,17 @@ func (h *Handler) GetClassTimetable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instituteIDStr := r.URL.Query().Get("institute_id")
 	classIDStr := r.URL.Query().Get("class_id")
 	dayStr := r.URL.Query().Get("day")
 
-	if instituteIDStr == "" || classIDStr == "" || dayStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id, class_id and day are required")
+	if classIDStr == "" || dayStr == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "class_id and day are required")
 		return
 	}
 
-	instituteID, err := uuid.Parse(instituteIDStr)
+	instituteID, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
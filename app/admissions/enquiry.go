@@ -40,6 +40,13 @@ func (h *Handler) CreateEnquiry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, enquiry.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	enquiry.InstituteID = instID
+
 	data, err := h.service.CreateEnquiry(r.Context(), enquiry)
 	if err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create enquiry: "+err.Error())
@@ -56,7 +63,6 @@ func (h *Handler) CreateEnquiry(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieve all admission enquiries for an institute
 // @Tags Admissions - Enquiries
 // @Produce json
-// @Param institute_id query string true "Institute ID"
 // @Success 200 {object} dto.SuccessResponse{data=[]dto.EnquiryResponse}
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -68,15 +74,9 @@ func (h *Handler) ListEnquiries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instituteIDStr := r.URL.Query().Get("institute_id")
-	if instituteIDStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id is required")
-		return
-	}
-
-	instituteID, err := uuid.Parse(instituteIDStr)
+	instituteID, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -126,9 +126,17 @@ func (h *Handler) UpdateEnquiryStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instituteID, err := uuid.Parse(req.InstituteID)
+	var claimed uuid.UUID
+	if req.InstituteID != "" {
+		if claimed, err = uuid.Parse(req.InstituteID); err != nil {
+			helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute id: "+err.Error())
+			return
+		}
+	}
+
+	instituteID, err := helper.BindInstituteID(r, claimed)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute id: "+err.Error())
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
 		return
 	}
 
@@ -0,0 +1,84 @@
+package attendance
+
+import (
+	"context"
+	"swiftschool/domain"
+	"swiftschool/internal/database"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//////////////////////////////////////////////////////
+//                     HANDLER                      //
+//////////////////////////////////////////////////////
+
+type Handler struct {
+	service ServiceInterface
+}
+
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+//////////////////////////////////////////////////////
+//                    REPOSITORY                    //
+//////////////////////////////////////////////////////
+
+type Repository struct {
+	db *database.Database
+}
+
+func NewRepository(db *database.Database) *Repository {
+	return &Repository{db: db}
+}
+
+//////////////////////////////////////////////////////
+//                     SERVICE                      //
+//////////////////////////////////////////////////////
+
+type Service struct {
+	repo RepositoryInterface
+	// editWindow is how far back teachers may mark or correct attendance
+	editWindow time.Duration
+}
+
+func NewService(db *database.Database, editWindow time.Duration) *Service {
+	return &Service{
+		repo:       NewRepository(db),
+		editWindow: editWindow,
+	}
+}
+
+//////////////////////////////////////////////////////
+//               REPOSITORY INTERFACE               //
+//////////////////////////////////////////////////////
+
+type RepositoryInterface interface {
+	// ========================= MARKING =========================
+	ListStudentsByClass(ctx context.Context, instituteID, classID uuid.UUID) ([]*domain.Student, error)
+	ListHolidays(ctx context.Context, instituteID uuid.UUID, from, to time.Time) ([]*domain.CalendarEvent, error)
+	SaveAttendance(ctx context.Context, records []domain.StudentAttendance) ([]*domain.StudentAttendance, error)
+	ListClassAttendance(ctx context.Context, instituteID, classID uuid.UUID, date time.Time, periodID *uuid.UUID) ([]*domain.StudentAttendance, error)
+
+	// ========================= REPORTS =========================
+	ListStudentDailyAttendance(ctx context.Context, instituteID, studentID uuid.UUID, from, to time.Time) ([]*domain.StudentAttendance, error)
+	SummariseStudentAttendance(ctx context.Context, instituteID, studentID uuid.UUID, from, to time.Time) (*domain.AttendanceSummary, error)
+	SummariseClassAttendance(ctx context.Context, instituteID, classID uuid.UUID, from, to time.Time) ([]*domain.AttendanceSummary, error)
+	CountClassAttendanceDays(ctx context.Context, instituteID, classID uuid.UUID, from, to time.Time) (int, error)
+}
+
+//////////////////////////////////////////////////////
+//                 SERVICE INTERFACE                //
+//////////////////////////////////////////////////////
+
+type ServiceInterface interface {
+	// ========================= MARKING =========================
+	MarkAttendance(ctx context.Context, arg ClassMarking) ([]*domain.StudentAttendance, error)
+	GetClassRegister(ctx context.Context, instituteID, classID uuid.UUID, date time.Time, periodID *uuid.UUID) (*domain.ClassRegister, error)
+
+	// ========================= REPORTS =========================
+	StudentMonthlyReport(ctx context.Context, instituteID, studentID uuid.UUID, month time.Time) (*domain.StudentAttendanceReport, error)
+	ClassMonthlyReport(ctx context.Context, instituteID, classID uuid.UUID, month time.Time) (*domain.ClassAttendanceReport, error)
+	ClassPercentageReport(ctx context.Context, instituteID, classID uuid.UUID, from, to time.Time, below float64) (*domain.ClassAttendanceReport, error)
+}
@@ -0,0 +1,167 @@
+package attendance
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"swiftschool/domain"
+	"swiftschool/dto"
+	"swiftschool/helper"
+
+	"github.com/google/uuid"
+)
+
+// MarkAttendance godoc
+// @Summary Mark class attendance
+// @Description Record the daily or period-wise register for a whole class. Students not listed get default_status. Dates older than the edit window can only be corrected by administrators.
+// @Tags Attendance
+// @Accept json
+// @Produce json
+// @Param register body dto.MarkAttendanceRequest true "Class register"
+// @Success 201 {object} dto.SuccessResponse{data=[]dto.AttendanceResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /attendance/mark [post]
+func (h *Handler) MarkAttendance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req dto.MarkAttendanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if req.ClassID == uuid.Nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "class_id is required")
+		return
+	}
+
+	date, err := time.Parse(helper.DateLayout, req.Date)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "date must be in YYYY-MM-DD format")
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	marks := make([]AttendanceMark, 0, len(req.Entries))
+	for _, e := range req.Entries {
+		marks = append(marks, AttendanceMark{
+			StudentID: e.StudentID,
+			Status:    domain.AttendanceStatus(e.Status),
+			Remarks:   e.Remarks,
+		})
+	}
+
+	data, err := h.service.MarkAttendance(r.Context(), ClassMarking{
+		InstituteID:   instID,
+		ClassID:       req.ClassID,
+		Date:          date,
+		PeriodID:      req.PeriodID,
+		DefaultStatus: domain.AttendanceStatus(req.DefaultStatus),
+		Marks:         marks,
+		MarkedBy:      helper.GetSessionUserID(r),
+		Override:      canOverrideEditWindow(r),
+	})
+	if err != nil {
+		helper.NewErrorResponse(w, attendanceErrorStatus(err), "failed to mark attendance: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "attendance marked successfully", data)
+}
+
+// GetClassRegister godoc
+// @Summary Get a class register
+// @Description Retrieve the class roster with the statuses marked for a day, or for one period when period_id is given
+// @Tags Attendance
+// @Produce json
+// @Param class_id query string true "Class ID"
+// @Param date query string true "Date (YYYY-MM-DD)"
+// @Param period_id query string false "Class period ID"
+// @Success 200 {object} dto.SuccessResponse{data=dto.ClassRegisterResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /attendance/register [get]
+func (h *Handler) GetClassRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	classID, err := helper.ParseRequiredUUIDFromQuery(r, "class_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid class id: "+err.Error())
+		return
+	}
+
+	date, err := helper.ParseRequiredDateFromQuery(r, "date")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid date: "+err.Error())
+		return
+	}
+
+	periodID, err := helper.ParseUUIDFromQuery(r, "period_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid period id: "+err.Error())
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var period *uuid.UUID
+	if periodID != uuid.Nil {
+		period = &periodID
+	}
+
+	data, err := h.service.GetClassRegister(r.Context(), instID, classID, date, period)
+	if err != nil {
+		helper.NewErrorResponse(w, attendanceErrorStatus(err), "failed to get class register: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "class register retrieved successfully", data)
+}
+
+// canOverrideEditWindow reports whether the session may correct attendance
+// outside the edit window
+func canOverrideEditWindow(r *http.Request) bool {
+	session, ok := helper.SessionFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	role := domain.UserRole(session.Role)
+	return role == domain.RoleAdmin || role == domain.RoleSuperAdmin
+}
+
+func attendanceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidStatus), errors.Is(err, ErrStudentNotInClass),
+		errors.Is(err, ErrEmptyClass), errors.Is(err, ErrNothingToMark),
+		errors.Is(err, ErrFutureDate), errors.Is(err, ErrInvalidRange):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrEditWindowClosed):
+		return http.StatusForbidden
+	case errors.Is(err, ErrHoliday):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
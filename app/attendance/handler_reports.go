@@ -0,0 +1,157 @@
+package attendance
+
+import (
+	"net/http"
+	"strconv"
+
+	"swiftschool/helper"
+)
+
+// StudentMonthlyReport godoc
+// @Summary Student monthly attendance
+// @Description Retrieve a student's daily attendance, totals, percentage and holidays for a month
+// @Tags Attendance - Reports
+// @Produce json
+// @Param student_id query string true "Student ID"
+// @Param month query string true "Month (YYYY-MM)"
+// @Success 200 {object} dto.SuccessResponse{data=dto.StudentAttendanceReportResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /attendance/reports/student_monthly [get]
+func (h *Handler) StudentMonthlyReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	studentID, err := helper.ParseRequiredUUIDFromQuery(r, "student_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid student id: "+err.Error())
+		return
+	}
+
+	month, err := helper.ParseRequiredMonthFromQuery(r, "month")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid month: "+err.Error())
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.StudentMonthlyReport(r.Context(), instID, studentID, month)
+	if err != nil {
+		helper.NewErrorResponse(w, attendanceErrorStatus(err), "failed to build attendance report: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "attendance report retrieved successfully", data)
+}
+
+// ClassMonthlyReport godoc
+// @Summary Class monthly attendance
+// @Description Retrieve every student's attendance totals and percentage in a class for a month
+// @Tags Attendance - Reports
+// @Produce json
+// @Param class_id query string true "Class ID"
+// @Param month query string true "Month (YYYY-MM)"
+// @Success 200 {object} dto.SuccessResponse{data=dto.ClassAttendanceReportResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /attendance/reports/class_monthly [get]
+func (h *Handler) ClassMonthlyReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	classID, err := helper.ParseRequiredUUIDFromQuery(r, "class_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid class id: "+err.Error())
+		return
+	}
+
+	month, err := helper.ParseRequiredMonthFromQuery(r, "month")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid month: "+err.Error())
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ClassMonthlyReport(r.Context(), instID, classID, month)
+	if err != nil {
+		helper.NewErrorResponse(w, attendanceErrorStatus(err), "failed to build attendance report: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "attendance report retrieved successfully", data)
+}
+
+// ClassPercentageReport godoc
+// @Summary Class attendance percentage report
+// @Description Retrieve attendance percentages for a class over a date range of up to a year. With below, only students under that percentage are listed.
+// @Tags Attendance - Reports
+// @Produce json
+// @Param class_id query string true "Class ID"
+// @Param from query string true "From date (YYYY-MM-DD)"
+// @Param to query string true "To date (YYYY-MM-DD)"
+// @Param below query number false "Only students below this percentage"
+// @Success 200 {object} dto.SuccessResponse{data=dto.ClassAttendanceReportResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /attendance/reports/class [get]
+func (h *Handler) ClassPercentageReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	classID, err := helper.ParseRequiredUUIDFromQuery(r, "class_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid class id: "+err.Error())
+		return
+	}
+
+	from, err := helper.ParseRequiredDateFromQuery(r, "from")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid from date: "+err.Error())
+		return
+	}
+
+	to, err := helper.ParseRequiredDateFromQuery(r, "to")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid to date: "+err.Error())
+		return
+	}
+
+	below, err := strconv.ParseFloat(helper.GetQueryParam(r, "below", "0"), 64)
+	if err != nil || below < 0 || below > 100 {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "below must be a percentage between 0 and 100")
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ClassPercentageReport(r.Context(), instID, classID, from, to, below)
+	if err != nil {
+		helper.NewErrorResponse(w, attendanceErrorStatus(err), "failed to build attendance report: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "attendance report retrieved successfully", data)
+}
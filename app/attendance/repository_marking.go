@@ -0,0 +1,127 @@
+package attendance
+
+import (
+	"context"
+	"time"
+
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+
+	"github.com/google/uuid"
+)
+
+// ListStudentsByClass retrieves the class roster the register is taken from
+func (r *Repository) ListStudentsByClass(ctx context.Context, instituteID, classID uuid.UUID) ([]*domain.Student, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListStudentsByClass(ctx, db.ListStudentsByClassParams{
+		InstituteID:    instituteID,
+		CurrentClassID: helper.ToNullUUID(classID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	students := make([]*domain.Student, 0, len(rows))
+	for _, row := range rows {
+		s := mapper.MapStudentRowToDomain(row)
+		students = append(students, &s)
+	}
+	return students, nil
+}
+
+// ListHolidays retrieves the student holidays overlapping a date range
+func (r *Repository) ListHolidays(ctx context.Context, instituteID uuid.UUID, from, to time.Time) ([]*domain.CalendarEvent, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListStudentHolidays(ctx, db.ListStudentHolidaysParams{
+		InstituteID: instituteID,
+		FromDate:    from,
+		ToDate:      to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := make([]*domain.CalendarEvent, 0, len(rows))
+	for _, row := range rows {
+		e := mapper.MapDBCalendarEventToDomain(row)
+		holidays = append(holidays, &e)
+	}
+	return holidays, nil
+}
+
+// SaveAttendance upserts a class register in one transaction, so a
+// partially saved register is never left behind
+func (r *Repository) SaveAttendance(ctx context.Context, records []domain.StudentAttendance) ([]*domain.StudentAttendance, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	saved := make([]*domain.StudentAttendance, 0, len(records))
+	for _, rec := range records {
+		var row db.AcademicsStudentAttendance
+		if rec.PeriodID == nil {
+			row, err = q.UpsertDailyAttendance(ctx, mapper.MapDomainAttendanceToDailyParams(rec))
+		} else {
+			row, err = q.UpsertPeriodAttendance(ctx, mapper.MapDomainAttendanceToPeriodParams(rec))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		a := mapper.MapDBStudentAttendanceToDomain(row)
+		saved = append(saved, &a)
+	}
+
+	return saved, tx.Commit()
+}
+
+// ListClassAttendance retrieves what has been marked for a class on a day or period
+func (r *Repository) ListClassAttendance(ctx context.Context, instituteID, classID uuid.UUID, date time.Time, periodID *uuid.UUID) ([]*domain.StudentAttendance, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListClassAttendance(ctx, db.ListClassAttendanceParams{
+		InstituteID:    instituteID,
+		ClassID:        classID,
+		AttendanceDate: date,
+		PeriodID:       helper.ToNullUUID(helper.DerefUUID(periodID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*domain.StudentAttendance, 0, len(rows))
+	for _, row := range rows {
+		a := mapper.MapDBStudentAttendanceToDomain(row)
+		records = append(records, &a)
+	}
+	return records, nil
+}
@@ -0,0 +1,123 @@
+package attendance
+
+import (
+	"context"
+	"time"
+
+	"swiftschool/domain"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+
+	"github.com/google/uuid"
+)
+
+// ListStudentDailyAttendance retrieves a student's daily register entries in a date range
+func (r *Repository) ListStudentDailyAttendance(ctx context.Context, instituteID, studentID uuid.UUID, from, to time.Time) ([]*domain.StudentAttendance, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListStudentDailyAttendance(ctx, db.ListStudentDailyAttendanceParams{
+		InstituteID: instituteID,
+		StudentID:   studentID,
+		FromDate:    from,
+		ToDate:      to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*domain.StudentAttendance, 0, len(rows))
+	for _, row := range rows {
+		a := mapper.MapDBStudentAttendanceToDomain(row)
+		records = append(records, &a)
+	}
+	return records, nil
+}
+
+// SummariseStudentAttendance counts a student's daily statuses in a date range
+func (r *Repository) SummariseStudentAttendance(ctx context.Context, instituteID, studentID uuid.UUID, from, to time.Time) (*domain.AttendanceSummary, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.SummariseStudentAttendance(ctx, db.SummariseStudentAttendanceParams{
+		InstituteID: instituteID,
+		StudentID:   studentID,
+		FromDate:    from,
+		ToDate:      to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AttendanceSummary{
+		StudentID: studentID,
+		Present:   int(row.Present),
+		Absent:    int(row.Absent),
+		Late:      int(row.Late),
+		HalfDay:   int(row.HalfDay),
+		OnLeave:   int(row.OnLeave),
+	}, nil
+}
+
+// SummariseClassAttendance counts the daily statuses of each student marked in a class
+func (r *Repository) SummariseClassAttendance(ctx context.Context, instituteID, classID uuid.UUID, from, to time.Time) ([]*domain.AttendanceSummary, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.SummariseClassAttendance(ctx, db.SummariseClassAttendanceParams{
+		InstituteID: instituteID,
+		ClassID:     classID,
+		FromDate:    from,
+		ToDate:      to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*domain.AttendanceSummary, 0, len(rows))
+	for _, row := range rows {
+		summaries = append(summaries, &domain.AttendanceSummary{
+			StudentID: row.StudentID,
+			Present:   int(row.Present),
+			Absent:    int(row.Absent),
+			Late:      int(row.Late),
+			HalfDay:   int(row.HalfDay),
+			OnLeave:   int(row.OnLeave),
+		})
+	}
+	return summaries, nil
+}
+
+// CountClassAttendanceDays counts the days a class register was taken in a date range
+func (r *Repository) CountClassAttendanceDays(ctx context.Context, instituteID, classID uuid.UUID, from, to time.Time) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := q.CountClassAttendanceDays(ctx, db.CountClassAttendanceDaysParams{
+		InstituteID: instituteID,
+		ClassID:     classID,
+		FromDate:    from,
+		ToDate:      to,
+	})
+	return int(n), err
+}
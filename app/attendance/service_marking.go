@@ -0,0 +1,215 @@
+package attendance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"swiftschool/domain"
+	"swiftschool/helper"
+
+	"github.com/google/uuid"
+)
+
+var logger = helper.GetLogger()
+
+var (
+	ErrInvalidStatus     = errors.New("attendance status must be present, absent, late, half_day or on_leave")
+	ErrStudentNotInClass = errors.New("student is not in this class")
+	ErrEmptyClass        = errors.New("class has no students in this institute")
+	ErrNothingToMark     = errors.New("no attendance entries to record")
+	ErrFutureDate        = errors.New("attendance cannot be marked for a future date")
+	ErrEditWindowClosed  = errors.New("the attendance edit window for this date has closed; ask an administrator to correct it")
+	ErrHoliday           = errors.New("the date is a holiday in the institute calendar")
+)
+
+var attendanceStatuses = []domain.AttendanceStatus{
+	domain.StatusPresent,
+	domain.StatusAbsent,
+	domain.StatusLate,
+	domain.StatusHalfDay,
+	domain.StatusOnLeave,
+}
+
+// AttendanceMark is the status recorded for one student
+type AttendanceMark struct {
+	StudentID uuid.UUID
+	Status    domain.AttendanceStatus
+	Remarks   *string
+}
+
+// ClassMarking is a register taken for a whole class. Students without an
+// explicit mark get DefaultStatus; with no default they are left unmarked.
+// A nil PeriodID marks the daily register.
+type ClassMarking struct {
+	InstituteID   uuid.UUID
+	ClassID       uuid.UUID
+	Date          time.Time
+	PeriodID      *uuid.UUID
+	DefaultStatus domain.AttendanceStatus
+	Marks         []AttendanceMark
+	MarkedBy      *uuid.UUID
+	// Override lets administrators correct dates outside the edit window
+	Override bool
+}
+
+// MarkAttendance records a class register. Re-marking the same day or
+// period updates the earlier entries.
+func (s *Service) MarkAttendance(ctx context.Context, arg ClassMarking) ([]*domain.StudentAttendance, error) {
+	date := dateOnly(arg.Date)
+	if err := s.checkEditWindow(date, arg.Override); err != nil {
+		return nil, err
+	}
+	if arg.DefaultStatus != "" && !helper.Contains(attendanceStatuses, arg.DefaultStatus) {
+		return nil, ErrInvalidStatus
+	}
+
+	if err := s.checkHoliday(ctx, arg.InstituteID, date); err != nil {
+		return nil, err
+	}
+
+	roster, err := s.repo.ListStudentsByClass(ctx, arg.InstituteID, arg.ClassID)
+	if err != nil {
+		return nil, err
+	}
+	if len(roster) == 0 {
+		return nil, ErrEmptyClass
+	}
+
+	inClass := make(map[uuid.UUID]bool, len(roster))
+	for _, st := range roster {
+		inClass[st.ID] = true
+	}
+
+	marks := make(map[uuid.UUID]AttendanceMark, len(arg.Marks))
+	for _, m := range arg.Marks {
+		if !inClass[m.StudentID] {
+			return nil, fmt.Errorf("%w: %s", ErrStudentNotInClass, m.StudentID)
+		}
+		if !helper.Contains(attendanceStatuses, m.Status) {
+			return nil, ErrInvalidStatus
+		}
+		marks[m.StudentID] = m
+	}
+
+	records := make([]domain.StudentAttendance, 0, len(roster))
+	for _, st := range roster {
+		m, ok := marks[st.ID]
+		if !ok {
+			if arg.DefaultStatus == "" {
+				continue
+			}
+			m = AttendanceMark{StudentID: st.ID, Status: arg.DefaultStatus}
+		}
+
+		rec := domain.StudentAttendance{
+			ClassID:        arg.ClassID,
+			StudentID:      st.ID,
+			AttendanceDate: date,
+			PeriodID:       arg.PeriodID,
+			Status:         m.Status,
+			Remarks:        m.Remarks,
+		}
+		rec.InstituteID = arg.InstituteID
+		rec.CreatedBy = arg.MarkedBy
+		records = append(records, rec)
+	}
+	if len(records) == 0 {
+		return nil, ErrNothingToMark
+	}
+
+	saved, err := s.repo.SaveAttendance(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("attendance marked for class %s on %s (%d students)",
+		arg.ClassID, date.Format(helper.DateLayout), len(saved))
+	return saved, nil
+}
+
+// GetClassRegister returns the class roster with what has been marked for
+// the day or period, so the register can be taken or reviewed
+func (s *Service) GetClassRegister(ctx context.Context, instituteID, classID uuid.UUID, date time.Time, periodID *uuid.UUID) (*domain.ClassRegister, error) {
+	date = dateOnly(date)
+
+	roster, err := s.repo.ListStudentsByClass(ctx, instituteID, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.repo.ListClassAttendance(ctx, instituteID, classID, date, periodID)
+	if err != nil {
+		return nil, err
+	}
+	byStudent := make(map[uuid.UUID]*domain.StudentAttendance, len(records))
+	for _, rec := range records {
+		byStudent[rec.StudentID] = rec
+	}
+
+	holidays, err := s.repo.ListHolidays(ctx, instituteID, date, date)
+	if err != nil {
+		return nil, err
+	}
+
+	register := &domain.ClassRegister{
+		ClassID:  classID,
+		Date:     date,
+		PeriodID: periodID,
+		Entries:  make([]*domain.AttendanceRegisterEntry, 0, len(roster)),
+	}
+	if len(holidays) > 0 {
+		register.Holiday = &holidays[0].Title
+	}
+
+	for _, st := range roster {
+		entry := &domain.AttendanceRegisterEntry{
+			StudentID:   st.ID,
+			AdmissionNo: st.AdmissionNo,
+			StudentName: studentName(st),
+		}
+		if rec, ok := byStudent[st.ID]; ok {
+			entry.Status = &rec.Status
+			entry.Remarks = rec.Remarks
+		}
+		register.Entries = append(register.Entries, entry)
+	}
+
+	return register, nil
+}
+
+// checkEditWindow allows marking today and the days still inside the edit
+// window. Administrators may correct older dates.
+func (s *Service) checkEditWindow(date time.Time, override bool) error {
+	today := dateOnly(time.Now())
+	if date.After(today) {
+		return ErrFutureDate
+	}
+	if !override && date.Before(today.Add(-s.editWindow)) {
+		return ErrEditWindowClosed
+	}
+	return nil
+}
+
+func (s *Service) checkHoliday(ctx context.Context, instituteID uuid.UUID, date time.Time) error {
+	holidays, err := s.repo.ListHolidays(ctx, instituteID, date, date)
+	if err != nil {
+		return err
+	}
+	if len(holidays) > 0 {
+		return fmt.Errorf("%w: %s", ErrHoliday, holidays[0].Title)
+	}
+	return nil
+}
+
+// dateOnly drops the time of day; attendance dates are calendar dates
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func studentName(st *domain.Student) string {
+	return strings.TrimSpace(st.FirstName + " " + helper.StrOrEmpty(st.LastName))
+}
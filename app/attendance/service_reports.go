@@ -0,0 +1,151 @@
+package attendance
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"swiftschool/domain"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidRange = errors.New("report range must start on or before its end and span at most a year")
+
+// maxReportDays bounds percentage reports to an academic year
+const maxReportDays = 366
+
+// StudentMonthlyReport returns a student's daily register for a month with
+// the month's totals and holidays
+func (s *Service) StudentMonthlyReport(ctx context.Context, instituteID, studentID uuid.UUID, month time.Time) (*domain.StudentAttendanceReport, error) {
+	from, to := monthRange(month)
+
+	days, err := s.repo.ListStudentDailyAttendance(ctx, instituteID, studentID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.repo.SummariseStudentAttendance(ctx, instituteID, studentID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	applyPercentage(summary)
+
+	holidays, err := s.repo.ListHolidays(ctx, instituteID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.StudentAttendanceReport{
+		StudentID: studentID,
+		From:      from,
+		To:        to,
+		Summary:   *summary,
+		Days:      days,
+		Holidays:  holidays,
+	}, nil
+}
+
+// ClassMonthlyReport summarises every student of a class for a month
+func (s *Service) ClassMonthlyReport(ctx context.Context, instituteID, classID uuid.UUID, month time.Time) (*domain.ClassAttendanceReport, error) {
+	from, to := monthRange(month)
+	return s.classReport(ctx, instituteID, classID, from, to, 0)
+}
+
+// ClassPercentageReport summarises a class over any range up to a year.
+// A positive below keeps only students whose attendance is under it.
+func (s *Service) ClassPercentageReport(ctx context.Context, instituteID, classID uuid.UUID, from, to time.Time, below float64) (*domain.ClassAttendanceReport, error) {
+	from, to = dateOnly(from), dateOnly(to)
+	if to.Before(from) || to.Sub(from) > maxReportDays*24*time.Hour {
+		return nil, ErrInvalidRange
+	}
+	return s.classReport(ctx, instituteID, classID, from, to, below)
+}
+
+func (s *Service) classReport(ctx context.Context, instituteID, classID uuid.UUID, from, to time.Time, below float64) (*domain.ClassAttendanceReport, error) {
+	roster, err := s.repo.ListStudentsByClass(ctx, instituteID, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.repo.SummariseClassAttendance(ctx, instituteID, classID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byStudent := make(map[uuid.UUID]*domain.AttendanceSummary, len(summaries))
+	for _, sum := range summaries {
+		byStudent[sum.StudentID] = sum
+	}
+
+	registerDays, err := s.repo.CountClassAttendanceDays(ctx, instituteID, classID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays, err := s.repo.ListHolidays(ctx, instituteID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.ClassAttendanceReport{
+		ClassID:      classID,
+		From:         from,
+		To:           to,
+		RegisterDays: registerDays,
+		Holidays:     holidays,
+		Students:     make([]*domain.AttendanceSummary, 0, len(roster)),
+	}
+
+	var total float64
+	var counted int
+	for _, st := range roster {
+		sum, ok := byStudent[st.ID]
+		if !ok {
+			sum = &domain.AttendanceSummary{StudentID: st.ID}
+		}
+		sum.AdmissionNo = st.AdmissionNo
+		sum.StudentName = studentName(st)
+		applyPercentage(sum)
+
+		if sum.MarkedDays > 0 {
+			total += sum.Percentage
+			counted++
+		}
+		if below > 0 && (sum.MarkedDays == 0 || sum.Percentage >= below) {
+			continue
+		}
+		report.Students = append(report.Students, sum)
+	}
+
+	if counted > 0 {
+		report.AveragePercentage = round2(total / float64(counted))
+	}
+	return report, nil
+}
+
+// applyPercentage fills MarkedDays and Percentage. Late counts as present and
+// a half day as half; leave is excused and left out of the denominator.
+func applyPercentage(sum *domain.AttendanceSummary) {
+	sum.MarkedDays = sum.Present + sum.Absent + sum.Late + sum.HalfDay + sum.OnLeave
+
+	counted := sum.MarkedDays - sum.OnLeave
+	if counted <= 0 {
+		sum.Percentage = 0
+		return
+	}
+
+	attended := float64(sum.Present+sum.Late) + 0.5*float64(sum.HalfDay)
+	sum.Percentage = round2(attended / float64(counted) * 100)
+}
+
+// monthRange returns the first and last day of month
+func monthRange(month time.Time) (time.Time, time.Time) {
+	y, m, _ := month.Date()
+	from := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, -1)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
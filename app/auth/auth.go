@@ -2,12 +2,17 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"time"
 
 	"swiftschool/domain"
+	"swiftschool/dto"
 	"swiftschool/helper"
+	"swiftschool/internal/db"
+
+	"github.com/google/uuid"
 )
 
 var logger = helper.GetLogger()
@@ -26,6 +31,7 @@ var logger = helper.GetLogger()
 // @Param role formData string true "User role"
 // @Success 200 {object} dto.LoginResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
 // @Router /auth/login [post]
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	if !isPost(r, w) {
@@ -45,7 +51,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.Login(ctx, identifier, userType); err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		helper.NewErrorResponse(w, otpErrorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
@@ -58,14 +64,14 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	helper.NewSuccessResponse(
 		w,
 		http.StatusOK,
-		"OTP sent successfully. Valid for 5 minutes. Never share it with anyone.",
+		fmt.Sprintf("OTP sent successfully. Valid for %s. Never share it with anyone.", otpValidityText()),
 		data,
 	)
 }
 
 // VerifyOTP godoc
 // @Summary Verify OTP
-// @Description Verify OTP and complete login process
+// @Description Verify OTP and complete login process. Users with TOTP enabled receive an mfa_token for /auth/totp_verification instead of a session.
 // @Tags Auth
 // @Accept x-www-form-urlencoded
 // @Produce json
@@ -75,6 +81,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} dto.VerifyOTPResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
 // @Router /auth/verification [post]
 func (h *Handler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 	if !isPost(r, w) {
@@ -99,102 +106,338 @@ func (h *Handler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.service.VerifyOTP(ctx, identifier, userType, otp)
+	user, mfaToken, err := h.service.VerifyOTP(ctx, identifier, userType, otp)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusUnauthorized, err.Error())
+		helper.NewErrorResponse(w, otpErrorStatus(err, http.StatusUnauthorized), err.Error())
 		return
 	}
 
-	if err := helper.CreateSession(
-		w,
-		user.ID.String(),
-		user.Username,
-		string(user.RoleType),
-	); err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, err.Error())
+	if mfaToken != "" {
+		helper.NewSuccessResponse(w, http.StatusOK, "enter the code from your authenticator app", dto.PasswordLoginResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
 		return
 	}
 
-	data := map[string]any{
-		"redirect": getDashboardRedirect(user.RoleType),
+	h.startSession(w, r, user, "OTP verified successfully")
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current session and clear the session cookie
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/logout [post]
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	session, ok := helper.SessionFromContext(r.Context())
+	if !ok {
+		helper.NewErrorResponse(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	if err := helper.DeleteSession(r.Context(), session.ID); err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to logout: "+err.Error())
+		return
+	}
+
+	helper.ClearSessionCookie(w)
+	helper.NewSuccessResponse(w, http.StatusOK, "logged out successfully", map[string]any{"redirect": "/"})
+}
+
+// LogoutAllDevices godoc
+// @Summary Logout from all devices
+// @Description Revoke every session of the logged-in user, including the current one
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse{data=dto.LogoutAllResponse}
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/logout_all [post]
+func (h *Handler) LogoutAllDevices(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	session, ok := helper.SessionFromContext(r.Context())
+	if !ok {
+		helper.NewErrorResponse(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	revoked, err := helper.DeleteUserSessions(r.Context(), session.UserID)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to logout devices: "+err.Error())
+		return
 	}
 
-	helper.NewSuccessResponse(w, http.StatusOK, "OTP verified successfully", data)
+	helper.ClearSessionCookie(w)
+	helper.NewSuccessResponse(w, http.StatusOK, "logged out from all devices", map[string]any{
+		"revoked_sessions": revoked,
+		"redirect":         "/",
+	})
+}
+
+// ForceLogout godoc
+// @Summary Force logout a user
+// @Description Admin action that revokes every session of the given user
+// @Tags Auth - Users
+// @Accept json
+// @Produce json
+// @Param request body dto.ForceLogoutRequest true "User to logout"
+// @Success 200 {object} dto.SuccessResponse{data=dto.LogoutAllResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/users/force_logout [post]
+func (h *Handler) ForceLogout(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	session, ok := helper.SessionFromContext(r.Context())
+	if !ok || !isAdminRole(domain.UserRole(session.Role)) {
+		helper.NewErrorResponse(w, http.StatusForbidden, "only administrators can force logout users")
+		return
+	}
+
+	var req struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid user_id: "+err.Error())
+		return
+	}
+
+	if !h.authorizeUser(w, r, userID) {
+		return
+	}
+
+	revoked, err := helper.DeleteUserSessions(r.Context(), userID.String())
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to logout user: "+err.Error())
+		return
+	}
+
+	logger.Infof("user %s force-logged out %s (%d sessions)", session.UserID, userID, revoked)
+
+	helper.NewSuccessResponse(w, http.StatusOK, "user logged out from all devices", map[string]any{
+		"revoked_sessions": revoked,
+	})
+}
+
+// SwitchInstitute godoc
+// @Summary Switch institute
+// @Description Select the institute the current session operates on. Super admins may select any institute; other users only one they belong to.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.SwitchInstituteRequest true "Institute to switch to"
+// @Success 200 {object} dto.SuccessResponse{data=dto.SwitchInstituteResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/switch_institute [post]
+func (h *Handler) SwitchInstitute(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	session, ok := helper.SessionFromContext(r.Context())
+	if !ok {
+		helper.NewErrorResponse(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	var req dto.SwitchInstituteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if req.InstituteID == uuid.Nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id is required")
+		return
+	}
+
+	instituteID := req.InstituteID.String()
+	if !helper.SessionHasInstitute(session, instituteID) {
+		logger.Warnf("cross-tenant switch rejected: user=%s role=%s requested=%s",
+			session.UserID, session.Role, instituteID)
+		helper.NewErrorResponse(w, http.StatusForbidden, helper.ErrNotPermitted.Error())
+		return
+	}
+
+	if err := helper.SetActiveInstitute(r.Context(), session.ID, instituteID); err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to switch institute: "+err.Error())
+		return
+	}
+
+	if helper.IsSuperAdmin(session) {
+		logger.Infof("super admin %s switched to institute %s", session.UserID, instituteID)
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "institute switched successfully", dto.SwitchInstituteResponse{
+		InstituteID: req.InstituteID,
+	})
 }
 
 // ==========================
 // SERVICE LAYER
 // ==========================
 
+// Login sends a login code to identifier. Unknown or inactive accounts get
+// the same response without a code, so the form does not reveal which
+// emails and phone numbers are registered.
 func (s *Service) Login(ctx context.Context, identifier, userType string) error {
 	if err := s.repo.Login(ctx, identifier, userType); err != nil {
-		return err
+		if errors.Is(err, ErrNoActiveAccount) {
+			logger.Infof("login requested for unknown or inactive %s account %s", userType, identifier)
+			return nil
+		}
+		logger.Errorf("login lookup for %s failed: %v", identifier, err)
+		return fmt.Errorf("unable to start login, please try again")
 	}
 
-	otp := helper.GenerateRandomOTP(6)
-	logger.Infof("OTP generated for %s: %s", identifier, otp)
-
-	if err := helper.StoreOTP(identifier, otp, 5*time.Minute); err != nil {
+	otp, err := helper.IssueOTP(ctx, identifier)
+	if err != nil {
+		if isOTPThrottled(err) {
+			logger.Warnf("OTP request for %s rejected: %v", identifier, err)
+			return err
+		}
+		logger.Errorf("failed to store OTP for %s: %v", identifier, err)
 		return fmt.Errorf("failed to store OTP")
 	}
 
-	if err := s.SendOTP(ctx, identifier, userType, otp); err != nil {
+	// Delivery logs are attributed to the user's institute when known
+	instituteID := uuid.Nil
+	if user, err := s.repo.GetUserByUsername(ctx, identifier); err == nil && user != nil && user.InstituteID != nil {
+		instituteID = *user.InstituteID
+	}
+
+	if err := s.SendOTP(ctx, instituteID, identifier, otp); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (s *Service) SendOTP(ctx context.Context, identifier, role, otp string) error {
+// SendOTP delivers the login code by email or SMS depending on the identifier.
+// The code itself is never logged.
+func (s *Service) SendOTP(ctx context.Context, instituteID uuid.UUID, identifier, otp string) error {
 	loginType := helper.ValidateLoginType(identifier)
 	if loginType == helper.LoginInvalid {
 		return fmt.Errorf("invalid username format")
 	}
 
-	logger.Infof("Sending OTP to %s via %s", identifier, loginType)
-
+	var err error
 	switch loginType {
 	case helper.LoginEmail:
-		logger.Infof("OTP sent via email to %s", identifier)
+		err = helper.Notify(ctx, helper.ChannelEmail, helper.Notification{
+			InstituteID: instituteID,
+			To:          identifier,
+			Subject:     "Your SwiftSchool login code",
+			Template:    helper.OTPEmail,
+			Data:        map[string]string{"otp": otp},
+		})
 	case helper.LoginPhone:
-		logger.Infof("OTP sent via SMS to %s", identifier)
+		err = helper.Notify(ctx, helper.ChannelSMS, helper.Notification{
+			InstituteID: instituteID,
+			To:          identifier,
+			Body:        fmt.Sprintf("%s is your SwiftSchool login code. It is valid for %s. Never share it with anyone.", otp, otpValidityText()),
+			LogBody:     "SwiftSchool login code (redacted)",
+		})
 	default:
 		return fmt.Errorf("unsupported login type")
 	}
 
+	if err != nil {
+		logger.Errorf("OTP delivery via %s to %s failed: %v", loginType, identifier, err)
+		return fmt.Errorf("failed to send OTP")
+	}
+
+	logger.Infof("OTP sent via %s to %s", loginType, identifier)
 	return nil
 }
 
-func (s *Service) VerifyOTP(ctx context.Context, identifier, userType, otp string) (*domain.User, error) {
-	storedOTP, _, err := helper.GetStoredOTP(identifier)
-	if err != nil || storedOTP != otp {
-		return nil, fmt.Errorf("invalid or expired OTP")
+// VerifyOTP checks a login code. Like PasswordLogin, it returns an
+// mfa_token instead of finishing the login when the user has TOTP enabled,
+// so the code alone never opens a session.
+func (s *Service) VerifyOTP(ctx context.Context, identifier, userType, otp string) (*domain.User, string, error) {
+	if err := helper.VerifyOTP(ctx, identifier, otp); err != nil {
+		if isOTPThrottled(err) || errors.Is(err, helper.ErrOTPInvalid) {
+			return nil, "", err
+		}
+		logger.Errorf("OTP verification for %s failed: %v", identifier, err)
+		return nil, "", helper.ErrOTPInvalid
 	}
 
-	helper.DeleteOTP(identifier)
-
 	user, err := s.repo.GetUserByUsername(ctx, identifier)
-	if err != nil {
-		return nil, fmt.Errorf("user not found")
+	if err != nil || user == nil {
+		return nil, "", fmt.Errorf("user not found")
 	}
 
 	if string(user.RoleType) != userType {
-		return nil, fmt.Errorf("user role mismatch")
+		return nil, "", fmt.Errorf("user role mismatch")
 	}
 
 	if !user.IsActive {
-		return nil, fmt.Errorf("user account is inactive")
+		return nil, "", fmt.Errorf("user account is inactive")
 	}
 
-	return user, nil
+	creds, err := s.repo.GetUserCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := s.mfaChallenge(ctx, user.ID, creds)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
 }
 
 // ==========================
 // REPOSITORY
 // ==========================
 
+// Login checks that an active account with the given role owns the identifier
 func (r *Repository) Login(ctx context.Context, identifier, userType string) error {
-	// Validate user existence based on identifier & role
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return err
+	}
+
+	exists, err := q.ActiveUserExistsForLogin(ctx, db.ActiveUserExistsForLoginParams{
+		RoleType:   helper.ToNullString(userType),
+		LoginType:  string(helper.ValidateLoginType(identifier)),
+		LookupHash: helper.LookupHash(identifier),
+	})
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNoActiveAccount
+	}
 	return nil
 }
 
@@ -232,6 +475,42 @@ func extractLoginFields(r *http.Request) (string, string, error) {
 	return identifier, userType, nil
 }
 
+// sessionInstitutes returns the tenants a user's session is bound to.
+// Super admins are not bound to any and select one explicitly.
+func sessionInstitutes(user *domain.User) []string {
+	if user.InstituteID == nil || user.RoleType == domain.RoleSuperAdmin {
+		return nil
+	}
+	return []string{user.InstituteID.String()}
+}
+
+// isOTPThrottled reports whether err is an OTP lockout or rate limit.
+func isOTPThrottled(err error) bool {
+	return errors.Is(err, helper.ErrOTPLocked) ||
+		errors.Is(err, helper.ErrOTPCooldown) ||
+		errors.Is(err, helper.ErrOTPDailyLimit)
+}
+
+func otpErrorStatus(err error, fallback int) int {
+	if isOTPThrottled(err) {
+		return http.StatusTooManyRequests
+	}
+	return fallback
+}
+
+// otpValidityText renders the OTP lifetime for user-facing messages, e.g. "5 minutes".
+func otpValidityText() string {
+	minutes := int(helper.OTPValidity().Minutes())
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+func isAdminRole(role domain.UserRole) bool {
+	return role == domain.RoleSuperAdmin || role == domain.RoleAdmin
+}
+
 func getDashboardRedirect(role domain.UserRole) string {
 	switch role {
 	case domain.RoleSuperAdmin:
@@ -4,6 +4,7 @@ import (
 	"context"
 	"swiftschool/domain"
 	"swiftschool/internal/database"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -58,6 +59,26 @@ type RepositoryInterface interface {
 	UpdateUserPassword(ctx context.Context, id uuid.UUID, passwordHash string) error
 	UpdateUserStatus(ctx context.Context, id uuid.UUID, isActive bool) error
 	ListUsersByRole(ctx context.Context, instituteID uuid.UUID, roleType domain.UserRole) ([]*domain.User, error)
+
+	// ===== CREDENTIALS =====
+	GetUserCredentials(ctx context.Context, userID uuid.UUID) (*domain.UserCredentials, error)
+	RecordLoginFailure(ctx context.Context, userID uuid.UUID, maxAttempts int, lockedUntil time.Time) (*domain.UserCredentials, error)
+	ResetLoginFailures(ctx context.Context, userID uuid.UUID) error
+	MarkPasswordChanged(ctx context.Context, userID uuid.UUID) error
+	CreatePasswordResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (uuid.UUID, error)
+
+	// ===== TWO-FACTOR =====
+	SetPendingTOTPSecret(ctx context.Context, userID uuid.UUID, sealedSecret string) error
+	EnableTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, userID uuid.UUID) error
+	AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error)
+	CreateMFAChallenge(ctx context.Context, tokenHash string, userID uuid.UUID, expiresAt time.Time) error
+	GetMFAChallengeUser(ctx context.Context, tokenHash string) (uuid.UUID, error)
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) error
 }
 
 //////////////////////////////////////////////////////
@@ -66,11 +87,24 @@ type RepositoryInterface interface {
 
 type ServiceInterface interface {
 	Login(ctx context.Context, identifier string, userType string) error
-	VerifyOTP(ctx context.Context, identifier string, userType string, otp string) (*domain.User, error)
-	CreateUser(ctx context.Context, arg domain.User) (*domain.User, error)
+	VerifyOTP(ctx context.Context, identifier string, userType string, otp string) (*domain.User, string, error)
+	CreateUser(ctx context.Context, arg domain.User, password string) (*domain.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*domain.User, error)
 	GetUserById(ctx context.Context, id uuid.UUID) (*domain.User, error)
-	UpdateUserPassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	UpdateUserPassword(ctx context.Context, id uuid.UUID, password string) error
 	UpdateUserStatus(ctx context.Context, id uuid.UUID, isActive bool) error
 	ListUsersByRole(ctx context.Context, instituteID uuid.UUID, roleType domain.UserRole) ([]*domain.User, error)
+
+	// ===== PASSWORD =====
+	PasswordLogin(ctx context.Context, identifier, userType, password string) (*domain.User, string, error)
+	ForgotPassword(ctx context.Context, identifier string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	ChangePassword(ctx context.Context, userID uuid.UUID, sessionID, currentPassword, newPassword string) error
+
+	// ===== TWO-FACTOR =====
+	VerifyTOTPLogin(ctx context.Context, challenge, code string) (*domain.User, error)
+	EnrollTOTP(ctx context.Context, userID uuid.UUID) (secret, provisioningURI string, err error)
+	ActivateTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID uuid.UUID, password string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
 }
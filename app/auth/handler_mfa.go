@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"swiftschool/dto"
+	"swiftschool/helper"
+)
+
+// VerifyTOTPLogin godoc
+// @Summary Verify TOTP login
+// @Description Complete a password login with a code from the authenticator app or a recovery code
+// @Tags Auth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param mfa_token formData string true "Token returned by /auth/password_login"
+// @Param code formData string true "6-digit TOTP code or recovery code"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Router /auth/totp_verification [post]
+func (h *Handler) VerifyTOTPLogin(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid form submission")
+		return
+	}
+
+	token := r.FormValue("mfa_token")
+	code := r.FormValue("code")
+	if token == "" || code == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "mfa_token and code are required")
+		return
+	}
+
+	user, err := h.service.VerifyTOTPLogin(r.Context(), token, code)
+	if err != nil {
+		helper.NewErrorResponse(w, loginErrorStatus(err), err.Error())
+		return
+	}
+
+	h.startSession(w, r, user, "login successful")
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrolment
+// @Description Generate a TOTP secret for the signed-in administrator or accountant. Confirm it with /auth/totp/activate.
+// @Tags Auth - Two-Factor
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse{data=dto.TOTPEnrollmentResponse}
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/totp/enroll [post]
+func (h *Handler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	secret, uri, err := h.service.EnrollTOTP(r.Context(), userID)
+	if err != nil {
+		helper.NewErrorResponse(w, mfaErrorStatus(err), err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "scan the code with your authenticator app", dto.TOTPEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: uri,
+	})
+}
+
+// ActivateTOTP godoc
+// @Summary Activate TOTP
+// @Description Confirm the enrolled secret with a current code. Returns recovery codes that are shown only once.
+// @Tags Auth - Two-Factor
+// @Accept json
+// @Produce json
+// @Param request body dto.TOTPCodeRequest true "Current TOTP code"
+// @Success 200 {object} dto.SuccessResponse{data=dto.RecoveryCodesResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/totp/activate [post]
+func (h *Handler) ActivateTOTP(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	codes, err := h.service.ActivateTOTP(r.Context(), userID, req.Code)
+	if err != nil {
+		helper.NewErrorResponse(w, mfaErrorStatus(err), err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "two-factor authentication enabled", dto.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	})
+}
+
+// DisableTOTP godoc
+// @Summary Disable TOTP
+// @Description Turn off two-factor authentication after confirming the password
+// @Tags Auth - Two-Factor
+// @Accept json
+// @Produce json
+// @Param request body dto.DisableTOTPRequest true "Current password"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/totp/disable [post]
+func (h *Handler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.DisableTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.service.DisableTOTP(r.Context(), userID, req.Password); err != nil {
+		helper.NewErrorResponse(w, mfaErrorStatus(err), err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes after confirming a current TOTP code
+// @Tags Auth - Two-Factor
+// @Accept json
+// @Produce json
+// @Param request body dto.TOTPCodeRequest true "Current TOTP code"
+// @Success 200 {object} dto.SuccessResponse{data=dto.RecoveryCodesResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/totp/recovery_codes [post]
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		helper.NewErrorResponse(w, mfaErrorStatus(err), err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "recovery codes regenerated", dto.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	})
+}
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrMFANotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, ErrMFAAlreadyEnabled), errors.Is(err, ErrMFANotEnrolled):
+		return http.StatusConflict
+	case errors.Is(err, helper.ErrInvalidTOTP):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, helper.ErrMFANotConfigured):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"swiftschool/domain"
+	"swiftschool/dto"
+	"swiftschool/helper"
+
+	"github.com/google/uuid"
+)
+
+// PasswordLogin godoc
+// @Summary Password login
+// @Description Sign in with username and password. Users with TOTP enabled receive an mfa_token for /auth/totp_verification instead of a session.
+// @Tags Auth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param username formData string true "Username, email, or phone"
+// @Param password formData string true "Password"
+// @Param role formData string true "User role"
+// @Success 200 {object} dto.SuccessResponse{data=dto.PasswordLoginResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Router /auth/password_login [post]
+func (h *Handler) PasswordLogin(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid form submission")
+		return
+	}
+
+	identifier, userType, err := extractLoginFields(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	password := r.FormValue("password")
+	if password == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "password is required")
+		return
+	}
+
+	user, mfaToken, err := h.service.PasswordLogin(r.Context(), identifier, userType, password)
+	if err != nil {
+		helper.NewErrorResponse(w, loginErrorStatus(err), err.Error())
+		return
+	}
+
+	if mfaToken != "" {
+		helper.NewSuccessResponse(w, http.StatusOK, "enter the code from your authenticator app", dto.PasswordLoginResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
+		return
+	}
+
+	h.startSession(w, r, user, "login successful")
+}
+
+// ForgotPassword godoc
+// @Summary Forgot password
+// @Description Send a password reset link to a registered email or phone. Always succeeds for unknown accounts.
+// @Tags Auth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param username formData string true "Registered email or phone"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /auth/password/forgot [post]
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid form submission")
+		return
+	}
+
+	identifier := r.FormValue("username")
+	if identifier == "" {
+		identifier = r.FormValue("identifier")
+	}
+	if identifier == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "email or phone is required")
+		return
+	}
+
+	if err := h.service.ForgotPassword(r.Context(), identifier); err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "if the account exists, a reset link has been sent", nil)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with a reset token. Signs the user out of every device.
+// @Tags Auth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Reset token from the link"
+// @Param password formData string true "New password"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /auth/password/reset [post]
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid form submission")
+		return
+	}
+
+	token := r.FormValue("token")
+	password := r.FormValue("password")
+	if token == "" || password == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "token and password are required")
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), token, password); err != nil {
+		if errors.Is(err, helper.ErrWeakPassword) || errors.Is(err, ErrInvalidResetToken) {
+			helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to reset password: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "password reset successfully", map[string]any{"redirect": "/"})
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Change the signed-in user's password and sign out the user's other sessions
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/password/change [post]
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if !isPost(r, w) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	// The session making the change stays signed in; all others are revoked
+	session, _ := helper.SessionFromContext(r.Context())
+	if err := h.service.ChangePassword(r.Context(), userID, session.ID, req.CurrentPassword, req.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			helper.NewErrorResponse(w, http.StatusUnauthorized, "current password is incorrect")
+		case errors.Is(err, helper.ErrWeakPassword):
+			helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to change password: "+err.Error())
+		}
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "password changed successfully", nil)
+}
+
+// startSession creates the login session and returns the dashboard redirect
+func (h *Handler) startSession(w http.ResponseWriter, r *http.Request, user *domain.User, message string) {
+	if err := helper.CreateSession(
+		w,
+		r,
+		user.ID.String(),
+		user.Username,
+		string(user.RoleType),
+		sessionInstitutes(user),
+	); err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, message, map[string]any{
+		"redirect": getDashboardRedirect(user.RoleType),
+	})
+}
+
+// sessionUserID returns the signed-in user's ID
+func sessionUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	session, ok := helper.SessionFromContext(r.Context())
+	if !ok {
+		helper.NewErrorResponse(w, http.StatusUnauthorized, "not logged in")
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(session.UserID)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusUnauthorized, "not logged in")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func loginErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, helper.ErrAccountLocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, helper.ErrInvalidTOTP), errors.Is(err, ErrMFAChallengeExpired):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -2,8 +2,10 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"swiftschool/domain"
+	"swiftschool/dto"
 	"swiftschool/helper"
 
 	"github.com/google/uuid"
@@ -18,6 +20,8 @@ import (
 // @Param user body dto.CreateUserRequest true "User details"
 // @Success 201 {object} dto.SuccessResponse{data=dto.UserResponse}
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security SessionAuth
 // @Router /auth/users/register [post]
@@ -27,15 +31,47 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var user domain.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+	var req dto.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
 
-	data, err := h.service.CreateUser(r.Context(), user)
+	if req.Username == "" || req.RoleType == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "username and role_type are required")
+		return
+	}
+
+	user := domain.User{
+		Username:       req.Username,
+		Email:          req.Email,
+		PhoneNumber:    req.PhoneNumber,
+		RoleType:       domain.UserRole(req.RoleType),
+		LinkedEntityID: req.LinkedEntityID,
+		InstituteID:    req.InstituteID,
+		IsActive:       req.IsActive,
+	}
+	user.CreatedBy = helper.GetSessionUserID(r)
+
+	// Only super admins may create accounts outside their own institute
+	session, _ := helper.SessionFromContext(r.Context())
+	if !helper.IsSuperAdmin(session) {
+		if user.RoleType == domain.RoleSuperAdmin {
+			helper.NewErrorResponse(w, http.StatusForbidden, "only super admins can create super admin accounts")
+			return
+		}
+
+		instID, err := helper.BindInstituteID(r, userInstitute(&user))
+		if err != nil {
+			helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+			return
+		}
+		user.InstituteID = &instID
+	}
+
+	data, err := h.service.CreateUser(r.Context(), user, req.Password)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create user: "+err.Error())
+		helper.NewErrorResponse(w, userErrorStatus(err), "failed to create user: "+err.Error())
 		return
 	}
 
@@ -50,6 +86,7 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 // @Param username query string true "Username"
 // @Success 200 {object} dto.SuccessResponse{data=dto.UserResponse}
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security SessionAuth
 // @Router /auth/users/get_by_username [get]
@@ -67,7 +104,12 @@ func (h *Handler) GetUserByUsername(w http.ResponseWriter, r *http.Request) {
 
 	data, err := h.service.GetUserByUsername(r.Context(), username)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch user: "+err.Error())
+		helper.NewErrorResponse(w, userErrorStatus(err), "failed to fetch user: "+err.Error())
+		return
+	}
+
+	if err := helper.AuthorizeInstitute(r, userInstitute(data)); err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
 		return
 	}
 
@@ -82,6 +124,7 @@ func (h *Handler) GetUserByUsername(w http.ResponseWriter, r *http.Request) {
 // @Param id query string true "User ID"
 // @Success 200 {object} dto.SuccessResponse{data=dto.UserResponse}
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security SessionAuth
 // @Router /auth/users/get_by_id [get]
@@ -99,7 +142,12 @@ func (h *Handler) GetUserById(w http.ResponseWriter, r *http.Request) {
 
 	data, err := h.service.GetUserById(r.Context(), id)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch user: "+err.Error())
+		helper.NewErrorResponse(w, userErrorStatus(err), "failed to fetch user: "+err.Error())
+		return
+	}
+
+	if err := helper.AuthorizeInstitute(r, userInstitute(data)); err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
 		return
 	}
 
@@ -125,8 +173,8 @@ func (h *Handler) UpdateUserPassword(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		ID           string `json:"id"`
-		PasswordHash string `json:"password_hash"`
+		ID       string `json:"id"`
+		Password string `json:"password"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -140,8 +188,12 @@ func (h *Handler) UpdateUserPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.UpdateUserPassword(r.Context(), id, req.PasswordHash); err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to update password: "+err.Error())
+	if !h.authorizeUser(w, r, id) {
+		return
+	}
+
+	if err := h.service.UpdateUserPassword(r.Context(), id, req.Password); err != nil {
+		helper.NewErrorResponse(w, userErrorStatus(err), "failed to update password: "+err.Error())
 		return
 	}
 
@@ -182,8 +234,12 @@ func (h *Handler) UpdateUserStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizeUser(w, r, id) {
+		return
+	}
+
 	if err := h.service.UpdateUserStatus(r.Context(), id, req.IsActive); err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to update user status: "+err.Error())
+		helper.NewErrorResponse(w, userErrorStatus(err), "failed to update user status: "+err.Error())
 		return
 	}
 
@@ -195,7 +251,6 @@ func (h *Handler) UpdateUserStatus(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieve all users with a specific role in an institute
 // @Tags Auth - Users
 // @Produce json
-// @Param institute_id query string true "Institute ID"
 // @Param role query string true "User role"
 // @Success 200 {object} dto.SuccessResponse{data=[]dto.UserResponse}
 // @Failure 400 {object} dto.ErrorResponse
@@ -208,9 +263,9 @@ func (h *Handler) ListUsersByRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instituteID, err := helper.ParseRequiredUUIDFromQuery(r, "institute_id")
+	instituteID, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -229,3 +284,38 @@ func (h *Handler) ListUsersByRole(w http.ResponseWriter, r *http.Request) {
 
 	helper.NewSuccessResponse(w, http.StatusOK, "users fetched successfully", data)
 }
+
+// authorizeUser rejects access to a user account outside the caller's institute.
+func (h *Handler) authorizeUser(w http.ResponseWriter, r *http.Request, id uuid.UUID) bool {
+	user, err := h.service.GetUserById(r.Context(), id)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusNotFound, "user not found")
+		return false
+	}
+
+	if err := helper.AuthorizeInstitute(r, userInstitute(user)); err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return false
+	}
+	return true
+}
+
+func userErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrUserExists):
+		return http.StatusConflict
+	case errors.Is(err, helper.ErrWeakPassword):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func userInstitute(user *domain.User) uuid.UUID {
+	if user == nil || user.InstituteID == nil {
+		return uuid.Nil
+	}
+	return *user.InstituteID
+}
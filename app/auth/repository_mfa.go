@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SetPendingTOTPSecret stores a new, not yet activated TOTP secret
+func (r *Repository) SetPendingTOTPSecret(ctx context.Context, userID uuid.UUID, sealedSecret string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return err
+	}
+
+	return q.SetPendingTOTPSecret(ctx, db.SetPendingTOTPSecretParams{
+		UserID:     userID,
+		TotpSecret: helper.ToNullString(sealedSecret),
+	})
+}
+
+// EnableTOTP activates the pending secret and stores the initial recovery codes
+func (r *Repository) EnableTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	n, err := q.EnableTOTP(ctx, db.EnableTOTPParams{
+		TotpLastStep: step,
+		UserID:       userID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrMFANotEnrolled
+	}
+
+	if err := replaceRecoveryCodes(ctx, q, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTOTP removes the TOTP secret and all recovery codes
+func (r *Repository) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if err := q.DisableTOTP(ctx, userID); err != nil {
+		return err
+	}
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AdvanceTOTPStep accepts a TOTP time step once; false means the code was already used
+func (r *Repository) AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return false, err
+	}
+
+	n, err := q.AdvanceTOTPStep(ctx, db.AdvanceTOTPStepParams{
+		Step:   step,
+		UserID: userID,
+	})
+	return n > 0, err
+}
+
+// ReplaceRecoveryCodes swaps all recovery codes for a new set
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, r.db.QueriesWithTx(tx), userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeRecoveryCode marks a recovery code used; false when it is unknown or spent
+func (r *Repository) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return false, err
+	}
+
+	n, err := q.ConsumeRecoveryCode(ctx, db.ConsumeRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: codeHash,
+	})
+	return n > 0, err
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes remain
+func (r *Repository) CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return 0, err
+	}
+
+	return q.CountUnusedRecoveryCodes(ctx, userID)
+}
+
+// CreateMFAChallenge stores the pending second step of a password login
+func (r *Repository) CreateMFAChallenge(ctx context.Context, tokenHash string, userID uuid.UUID, expiresAt time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return err
+	}
+
+	return q.CreateMFAChallenge(ctx, db.CreateMFAChallengeParams{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// GetMFAChallengeUser returns the user of an unexpired login challenge
+func (r *Repository) GetMFAChallengeUser(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := q.GetMFAChallengeUser(ctx, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrMFAChallengeExpired
+	}
+	return userID, err
+}
+
+// DeleteMFAChallenge removes a login challenge once used
+func (r *Repository) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return err
+	}
+
+	return q.DeleteMFAChallenge(ctx, tokenHash)
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *db.Queries, userID uuid.UUID, codeHashes []string) error {
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		if err := q.CreateRecoveryCode(ctx, db.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: hash,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"swiftschool/domain"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetUserCredentials retrieves lockout and TOTP state; nil when the user has none yet
+func (r *Repository) GetUserCredentials(ctx context.Context, userID uuid.UUID) (*domain.UserCredentials, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetUserCredentials(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapDBUserCredentialsToDomain(row), nil
+}
+
+// RecordLoginFailure counts a failed attempt and locks the account at maxAttempts
+func (r *Repository) RecordLoginFailure(ctx context.Context, userID uuid.UUID, maxAttempts int, lockedUntil time.Time) (*domain.UserCredentials, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		UserID:      userID,
+		MaxAttempts: int32(maxAttempts),
+		LockedUntil: lockedUntil,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.MapDBUserCredentialsToDomain(row), nil
+}
+
+// ResetLoginFailures clears the failed attempt counter after a successful login
+func (r *Repository) ResetLoginFailures(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return err
+	}
+
+	return q.ResetLoginFailures(ctx, userID)
+}
+
+// MarkPasswordChanged records the password change time and lifts any lockout
+func (r *Repository) MarkPasswordChanged(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return err
+	}
+
+	return q.MarkPasswordChanged(ctx, userID)
+}
+
+// CreatePasswordResetToken stores a reset token and invalidates older ones for the user
+func (r *Repository) CreatePasswordResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if err := q.InvalidatePasswordResetTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := q.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConsumePasswordResetToken marks an unexpired token used and returns its user
+func (r *Repository) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := q.ConsumePasswordResetToken(ctx, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrInvalidResetToken
+	}
+	return userID, err
+}
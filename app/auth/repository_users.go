@@ -2,43 +2,159 @@ package auth
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("a user with this username, email or phone already exists")
+
+	// ErrNoActiveAccount stays inside the service; Login answers it like a
+	// successful request so identifiers cannot be enumerated.
+	ErrNoActiveAccount = errors.New("no active account found for these details")
+)
+
 // CreateUser inserts a new user record into the database
 func (r *Repository) CreateUser(ctx context.Context, arg domain.User) (*domain.User, error) {
-	// TODO: implement DB logic here
-	return nil, nil
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := q.CreateUser(ctx, mapper.MapDomainUserToDBParams(arg))
+	if err != nil {
+		if helper.IsPgUniqueViolation(err) {
+			return nil, ErrUserExists
+		}
+		return nil, err
+	}
+
+	return mapper.MapDBUserToDomain(user), nil
 }
 
-// GetUserByUsername retrieves a user by username from the database
+// GetUserByUsername retrieves a user by email or phone when the identifier
+// is one, and by username otherwise
 func (r *Repository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
-	// TODO: implement DB logic here
-	return nil, nil
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := q.GetUserByLoginIdentifier(ctx, db.GetUserByLoginIdentifierParams{
+		LoginType:  string(helper.ValidateLoginType(username)),
+		LookupHash: helper.LookupHash(username),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return mapper.MapDBUserToDomain(user), nil
 }
 
 // GetUserById retrieves a user by ID from the database
 func (r *Repository) GetUserById(ctx context.Context, id uuid.UUID) (*domain.User, error) {
-	// TODO: implement DB logic here
-	return nil, nil
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := q.GetUserByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return mapper.MapDBUserToDomain(user), nil
 }
 
-// UpdateUserPassword updates a user's password in the database
+// UpdateUserPassword stores a new password hash in the database
 func (r *Repository) UpdateUserPassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
-	// TODO: implement DB logic here
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return err
+	}
+
+	n, err := q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:           id,
+		PasswordHash: passwordHash,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
 	return nil
 }
 
 // UpdateUserStatus updates a user's active status in the database
 func (r *Repository) UpdateUserStatus(ctx context.Context, id uuid.UUID, isActive bool) error {
-	// TODO: implement DB logic here
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return err
+	}
+
+	n, err := q.UpdateUserStatus(ctx, db.UpdateUserStatusParams{
+		ID:       id,
+		IsActive: helper.ToNullBool(isActive),
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
 	return nil
 }
 
 // ListUsersByRole retrieves all users with a specific role from the database
 func (r *Repository) ListUsersByRole(ctx context.Context, instituteID uuid.UUID, roleType domain.UserRole) ([]*domain.User, error) {
-	// TODO: implement DB logic here
-	return nil, nil
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListUsersByRole(ctx, db.ListUsersByRoleParams{
+		InstituteID: helper.ToNullUUID(instituteID),
+		RoleType:    helper.ToNullString(string(roleType)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*domain.User, 0, len(rows))
+	for _, u := range rows {
+		users = append(users, mapper.MapDBUserToDomain(u))
+	}
+	return users, nil
 }
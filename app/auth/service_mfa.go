@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const totpIssuer = "SwiftSchool"
+
+var (
+	ErrMFAChallengeExpired = errors.New("login session expired, please sign in again")
+	ErrMFANotAllowed       = errors.New("two-factor authentication is only available to administrators and accountants")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled      = errors.New("two-factor authentication is not enabled")
+)
+
+// mfaRoles may enrol a TOTP second factor
+var mfaRoles = []domain.UserRole{domain.RoleSuperAdmin, domain.RoleAdmin, domain.RoleAccountant}
+
+// VerifyTOTPLogin completes a password login with a TOTP or recovery code
+func (s *Service) VerifyTOTPLogin(ctx context.Context, challenge, code string) (*domain.User, error) {
+	tokenHash := helper.HashToken(challenge)
+
+	userID, err := s.repo.GetMFAChallengeUser(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserById(ctx, userID)
+	if err != nil || user == nil || !user.IsActive {
+		return nil, ErrMFAChallengeExpired
+	}
+
+	creds, err := s.repo.GetUserCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if creds == nil || !creds.TOTPEnabled {
+		return nil, ErrMFAChallengeExpired
+	}
+	if creds.IsLocked(time.Now()) {
+		return nil, helper.ErrAccountLocked
+	}
+
+	ok, err := s.checkSecondFactor(ctx, creds, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, s.loginFailed(ctx, userID, helper.ErrInvalidTOTP)
+	}
+
+	if err := s.repo.DeleteMFAChallenge(ctx, tokenHash); err != nil {
+		logger.Warnf("failed to delete MFA challenge for %s: %v", userID, err)
+	}
+	if err := s.repo.ResetLoginFailures(ctx, userID); err != nil {
+		logger.Warnf("failed to reset login failures for %s: %v", userID, err)
+	}
+
+	return user, nil
+}
+
+// EnrollTOTP generates a new secret for the user to scan. It is not used
+// for logins until ActivateTOTP confirms a code from the authenticator.
+func (s *Service) EnrollTOTP(ctx context.Context, userID uuid.UUID) (secret, provisioningURI string, err error) {
+	user, err := s.mfaUser(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	creds, err := s.repo.GetUserCredentials(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if creds != nil && creds.TOTPEnabled {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+
+	secret, err = helper.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	sealed, err := helper.SealTOTPSecret(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.repo.SetPendingTOTPSecret(ctx, userID, sealed); err != nil {
+		return "", "", err
+	}
+
+	return secret, helper.TOTPProvisioningURI(totpIssuer, user.Username, secret), nil
+}
+
+// ActivateTOTP turns on TOTP after the user proves their authenticator
+// works, and returns the one-time list of recovery codes.
+func (s *Service) ActivateTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if _, err := s.mfaUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	creds, err := s.repo.GetUserCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if creds == nil || creds.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+	if creds.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := helper.OpenTOTPSecret(creds.TOTPSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := helper.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, helper.ErrInvalidTOTP
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.EnableTOTP(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("TOTP enabled for user %s", userID)
+	return codes, nil
+}
+
+// DisableTOTP turns off TOTP after re-checking the user's password
+func (s *Service) DisableTOTP(ctx context.Context, userID uuid.UUID, password string) error {
+	user, err := s.repo.GetUserById(ctx, userID)
+	if err != nil || user == nil {
+		return fmt.Errorf("user not found")
+	}
+
+	if !helper.CheckPassword(user.PasswordHash, password) {
+		return ErrInvalidCredentials
+	}
+
+	if err := s.repo.DisableTOTP(ctx, userID); err != nil {
+		return err
+	}
+
+	logger.Infof("TOTP disabled for user %s", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after a valid TOTP code
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	creds, err := s.repo.GetUserCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if creds == nil || !creds.TOTPEnabled {
+		return nil, ErrMFANotEnrolled
+	}
+
+	ok, err := s.checkTOTP(ctx, creds, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, helper.ErrInvalidTOTP
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkSecondFactor accepts either a current TOTP code or an unused recovery code
+func (s *Service) checkSecondFactor(ctx context.Context, creds *domain.UserCredentials, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == 6 && !strings.Contains(code, "-") {
+		return s.checkTOTP(ctx, creds, code)
+	}
+
+	ok, err := s.repo.ConsumeRecoveryCode(ctx, creds.UserID, helper.HashRecoveryCode(code))
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if remaining, err := s.repo.CountUnusedRecoveryCodes(ctx, creds.UserID); err == nil && remaining <= 2 {
+		logger.Warnf("user %s has %d recovery codes left", creds.UserID, remaining)
+	}
+	return true, nil
+}
+
+// checkTOTP validates a code and records its time step so it cannot be reused
+func (s *Service) checkTOTP(ctx context.Context, creds *domain.UserCredentials, code string) (bool, error) {
+	secret, err := helper.OpenTOTPSecret(creds.TOTPSecret)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := helper.ValidateTOTP(secret, code, time.Now())
+	if !ok || step <= creds.TOTPLastStep {
+		return false, nil
+	}
+
+	return s.repo.AdvanceTOTPStep(ctx, creds.UserID, step)
+}
+
+// mfaUser loads the user and checks their role may use TOTP
+func (s *Service) mfaUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.repo.GetUserById(ctx, userID)
+	if err != nil || user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	if !helper.Contains(mfaRoles, user.RoleType) {
+		return nil, ErrMFANotAllowed
+	}
+	return user, nil
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := helper.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = helper.HashRecoveryCode(c)
+	}
+	return codes, hashes, nil
+}
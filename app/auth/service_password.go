@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidResetToken  = errors.New("reset link is invalid or has expired")
+)
+
+// PasswordLogin verifies a username and password. When the user has TOTP
+// enabled no session may be created yet; a challenge token for the second
+// step is returned instead.
+func (s *Service) PasswordLogin(ctx context.Context, identifier, userType, password string) (*domain.User, string, error) {
+	user, err := s.repo.GetUserByUsername(ctx, identifier)
+	if err != nil || user == nil {
+		helper.BurnPasswordCheck(password)
+		return nil, "", ErrInvalidCredentials
+	}
+
+	creds, err := s.repo.GetUserCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if creds != nil && creds.IsLocked(time.Now()) {
+		return nil, "", helper.ErrAccountLocked
+	}
+
+	if !helper.CheckPassword(user.PasswordHash, password) {
+		return nil, "", s.loginFailed(ctx, user.ID, ErrInvalidCredentials)
+	}
+
+	if string(user.RoleType) != userType || !user.IsActive {
+		return nil, "", ErrInvalidCredentials
+	}
+
+	if err := s.repo.ResetLoginFailures(ctx, user.ID); err != nil {
+		logger.Warnf("failed to reset login failures for %s: %v", user.ID, err)
+	}
+
+	token, err := s.mfaChallenge(ctx, user.ID, creds)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
+
+// mfaChallenge returns an mfa_token for /auth/totp_verification when the
+// user has TOTP enabled, and "" when the first factor is enough
+func (s *Service) mfaChallenge(ctx context.Context, userID uuid.UUID, creds *domain.UserCredentials) (string, error) {
+	if creds == nil || !creds.TOTPEnabled {
+		return "", nil
+	}
+
+	token, err := helper.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(helper.GetAuthPolicy().MFAChallengeTTL)
+	if err := s.repo.CreateMFAChallenge(ctx, helper.HashToken(token), userID, expiresAt); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ForgotPassword sends a reset link to the identifier if it belongs to an
+// active user. Unknown identifiers succeed silently so accounts cannot be
+// enumerated.
+func (s *Service) ForgotPassword(ctx context.Context, identifier string) error {
+	loginType := helper.ValidateLoginType(identifier)
+	if loginType == helper.LoginInvalid {
+		return fmt.Errorf("a registered email or phone number is required")
+	}
+
+	user, err := s.repo.GetUserByUsername(ctx, identifier)
+	if err != nil || user == nil || !user.IsActive {
+		logger.Infof("password reset requested for unknown or inactive account %s", identifier)
+		return nil
+	}
+
+	token, err := helper.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	policy := helper.GetAuthPolicy()
+	if err := s.repo.CreatePasswordResetToken(ctx, user.ID, helper.HashToken(token), time.Now().Add(policy.ResetTokenTTL)); err != nil {
+		return err
+	}
+
+	link := policy.ResetURL + "?token=" + token
+	minutes := int(policy.ResetTokenTTL.Minutes())
+
+	n := helper.Notification{
+		InstituteID: userInstitute(user),
+		To:          identifier,
+	}
+	channel := helper.ChannelEmail
+	if loginType == helper.LoginEmail {
+		n.Subject = "Reset your SwiftSchool password"
+		n.Template = helper.PasswordResetEmail
+		n.Data = map[string]string{"link": link, "minutes": fmt.Sprint(minutes)}
+	} else {
+		channel = helper.ChannelSMS
+		n.Body = fmt.Sprintf("Reset your SwiftSchool password within %d minutes: %s", minutes, link)
+		n.LogBody = "SwiftSchool password reset link (redacted)"
+	}
+
+	if err := helper.Notify(ctx, channel, n); err != nil {
+		logger.Errorf("password reset delivery via %s to %s failed: %v", channel, identifier, err)
+		return fmt.Errorf("failed to send reset link")
+	}
+
+	logger.Infof("password reset link sent via %s to %s", channel, identifier)
+	return nil
+}
+
+// ResetPassword sets a new password using a forgot-password token and
+// signs the user out everywhere.
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	hash, err := helper.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	userID, err := s.repo.ConsumePasswordResetToken(ctx, helper.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, userID, hash); err != nil {
+		return err
+	}
+
+	if n, err := helper.DeleteUserSessions(ctx, userID.String()); err != nil {
+		logger.Warnf("failed to revoke sessions after password reset for %s: %v", userID, err)
+	} else {
+		logger.Infof("password reset for %s revoked %d sessions", userID, n)
+	}
+	return nil
+}
+
+// ChangePassword replaces the signed-in user's password after checking the
+// current one and signs out every other session of the user.
+func (s *Service) ChangePassword(ctx context.Context, userID uuid.UUID, sessionID, currentPassword, newPassword string) error {
+	user, err := s.repo.GetUserById(ctx, userID)
+	if err != nil || user == nil {
+		return fmt.Errorf("user not found")
+	}
+
+	if !helper.CheckPassword(user.PasswordHash, currentPassword) {
+		return ErrInvalidCredentials
+	}
+
+	hash, err := helper.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, userID, hash); err != nil {
+		return err
+	}
+
+	if n, err := helper.DeleteOtherUserSessions(ctx, userID.String(), sessionID); err != nil {
+		logger.Warnf("failed to revoke other sessions after password change for %s: %v", userID, err)
+	} else {
+		logger.Infof("password change for %s revoked %d other sessions", userID, n)
+	}
+	return nil
+}
+
+func (s *Service) setPassword(ctx context.Context, userID uuid.UUID, hash string) error {
+	if err := s.repo.UpdateUserPassword(ctx, userID, hash); err != nil {
+		return err
+	}
+	return s.repo.MarkPasswordChanged(ctx, userID)
+}
+
+// loginFailed counts a wrong password or TOTP code towards the lockout and
+// returns failErr, or ErrAccountLocked once the limit is reached
+func (s *Service) loginFailed(ctx context.Context, userID uuid.UUID, failErr error) error {
+	policy := helper.GetAuthPolicy()
+
+	creds, err := s.repo.RecordLoginFailure(ctx, userID, policy.MaxLoginAttempts, time.Now().Add(policy.LoginLockout))
+	if err != nil {
+		logger.Errorf("failed to record login failure for %s: %v", userID, err)
+		return failErr
+	}
+
+	if creds.IsLocked(time.Now()) {
+		logger.Warnf("account %s locked after %d failed login attempts", userID, policy.MaxLoginAttempts)
+		return helper.ErrAccountLocked
+	}
+	return failErr
+}
@@ -3,12 +3,24 @@ package auth
 import (
 	"context"
 	"swiftschool/domain"
+	"swiftschool/helper"
 
 	"github.com/google/uuid"
 )
 
-// CreateUser creates a new user in the system
-func (s *Service) CreateUser(ctx context.Context, arg domain.User) (*domain.User, error) {
+// CreateUser creates a new user in the system. Without a password a random
+// one is set; the user signs in with an OTP or resets it.
+func (s *Service) CreateUser(ctx context.Context, arg domain.User, password string) (*domain.User, error) {
+	if password == "" {
+		password = helper.GenerateRandomPassword()
+	}
+
+	hash, err := helper.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	arg.PasswordHash = hash
+
 	return s.repo.CreateUser(ctx, arg)
 }
 
@@ -22,9 +34,13 @@ func (s *Service) GetUserById(ctx context.Context, id uuid.UUID) (*domain.User,
 	return s.repo.GetUserById(ctx, id)
 }
 
-// UpdateUserPassword updates a user's password
-func (s *Service) UpdateUserPassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
-	return s.repo.UpdateUserPassword(ctx, id, passwordHash)
+// UpdateUserPassword sets a new password for a user after a strength check
+func (s *Service) UpdateUserPassword(ctx context.Context, id uuid.UUID, password string) error {
+	hash, err := helper.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.setPassword(ctx, id, hash)
 }
 
 // UpdateUserStatus updates a user's active status
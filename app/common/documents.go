@@ -40,6 +40,13 @@ func (h *Handler) CreateDocument(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, doc.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	doc.InstituteID = instID
+
 	data, err := h.service.CreateDocument(r.Context(), doc)
 	if err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create document: "+err.Error())
@@ -56,7 +63,6 @@ func (h *Handler) CreateDocument(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieve all documents for a specific owner
 // @Tags Common - Documents
 // @Produce json
-// @Param institute_id query string true "Institute ID"
 // @Param owner_id query string true "Owner ID"
 // @Success 200 {object} dto.SuccessResponse{data=[]dto.DocumentResponse}
 // @Failure 400 {object} dto.ErrorResponse
@@ -69,17 +75,15 @@ func (h *Handler) ListDocuments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instituteIDStr := r.URL.Query().Get("institute_id")
 	ownerIDStr := r.URL.Query().Get("owner_id")
-
-	if instituteIDStr == "" || ownerIDStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id and owner_id are required")
+	if ownerIDStr == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "owner_id is required")
 		return
 	}
 
-	instituteID, err := uuid.Parse(instituteIDStr)
+	instituteID, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
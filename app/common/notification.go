@@ -38,6 +38,13 @@ func (h *Handler) CreateNotification(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, notif.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	notif.InstituteID = instID
+
 	data, err := h.service.CreateNotification(r.Context(), notif)
 	if err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create notification: "+err.Error())
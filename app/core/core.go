@@ -62,7 +62,7 @@ type RepositoryInterface interface {
 	// ========================= CLASS =========================
 	CreateClass(ctx context.Context, arg domain.Class) (*domain.Class, error)
 	UpdateClass(ctx context.Context, arg domain.Class) (*domain.Class, error)
-	DeleteClass(ctx context.Context, id uuid.UUID) error
+	DeleteClass(ctx context.Context, instituteID, id uuid.UUID) error
 	ListClasses(ctx context.Context, instituteID uuid.UUID) ([]*domain.Class, error)
 
 	// ========================= ACADEMIC SESSION =========================
@@ -99,6 +99,14 @@ type RepositoryInterface interface {
 
 	// ========================= ADDRESS =========================
 	CreateAddress(ctx context.Context, arg domain.Address) (*domain.Address, error)
+
+	// ========================= LOGIN PROVISIONING =========================
+	CreateLinkedUser(ctx context.Context, arg domain.User) (*domain.User, error)
+	GetLinkedUser(ctx context.Context, entityID uuid.UUID) (*domain.User, error)
+	GetInstituteGuardian(ctx context.Context, instituteID, guardianID uuid.UUID) (*domain.Guardian, error)
+	ListClassGuardians(ctx context.Context, instituteID, classID uuid.UUID) ([]*domain.Guardian, error)
+	SetStudentActive(ctx context.Context, instituteID, id uuid.UUID, isActive bool, updatedBy *uuid.UUID) ([]uuid.UUID, error)
+	SetEmployeeActive(ctx context.Context, instituteID, id uuid.UUID, isActive bool, updatedBy *uuid.UUID) ([]uuid.UUID, error)
 }
 
 //////////////////////////////////////////////////////
@@ -117,7 +125,7 @@ type ServiceInterface interface {
 	// ========================= CLASS =========================
 	CreateClass(ctx context.Context, arg domain.Class) (*domain.Class, error)
 	UpdateClass(ctx context.Context, arg domain.Class) (*domain.Class, error)
-	DeleteClass(ctx context.Context, id uuid.UUID) error
+	DeleteClass(ctx context.Context, instituteID, id uuid.UUID) error
 	ListClasses(ctx context.Context, instituteID uuid.UUID) ([]*domain.Class, error)
 	ListStudentsByClass(ctx context.Context, instituteID, classID uuid.UUID) ([]*domain.Student, error)
 
@@ -150,8 +158,17 @@ type ServiceInterface interface {
 
 	// ========================= GUARDIAN =========================
 	CreateGuardian(ctx context.Context, arg domain.Guardian) (*domain.Guardian, error)
-	LinkStudentGuardian(ctx context.Context, studentID, guardianID uuid.UUID, relationship string, isPrimary bool) error
+	LinkStudentGuardian(ctx context.Context, instituteID, studentID, guardianID uuid.UUID, relationship string, isPrimary bool) error
 
 	// ========================= ADDRESS =========================
 	CreateAddress(ctx context.Context, arg domain.Address) (*domain.Address, error)
+
+	// ========================= LOGIN PROVISIONING =========================
+	ProvisionStudentLogin(ctx context.Context, instituteID, studentID uuid.UUID, contact LoginContact, createdBy *uuid.UUID) (*ProvisionedLogin, error)
+	ProvisionEmployeeLogin(ctx context.Context, instituteID, employeeID uuid.UUID, role domain.UserRole, contact LoginContact, createdBy *uuid.UUID) (*ProvisionedLogin, error)
+	ProvisionGuardianLogin(ctx context.Context, instituteID, guardianID uuid.UUID, createdBy *uuid.UUID) (*ProvisionedLogin, error)
+	ProvisionNewGuardianLogin(ctx context.Context, instituteID uuid.UUID, guardian *domain.Guardian, createdBy *uuid.UUID) (*ProvisionedLogin, error)
+	ProvisionClassLogins(ctx context.Context, instituteID, classID uuid.UUID, includeGuardians bool, createdBy *uuid.UUID) (*ClassProvisioning, error)
+	SetStudentActive(ctx context.Context, instituteID, id uuid.UUID, isActive bool, updatedBy *uuid.UUID) error
+	SetEmployeeActive(ctx context.Context, instituteID, id uuid.UUID, isActive bool, updatedBy *uuid.UUID) error
 }
@@ -31,15 +31,12 @@ func (h *Handler) CreateAcademicSession(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	instID, err := helper.GetInstituteID(r)
-	if err == nil {
-		session.InstituteID = instID
-	}
-
-	if session.InstituteID == [16]byte{} {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute ID is required")
+	instID, err := helper.BindInstituteID(r, session.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
 		return
 	}
+	session.InstituteID = instID
 
 	data, err := h.service.CreateAcademicSession(r.Context(), session)
 	if err != nil {
@@ -136,10 +133,12 @@ func (h *Handler) UpdateAcademicSession(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	instID, err := helper.GetInstituteID(r)
-	if err == nil {
-		session.InstituteID = instID
+	instID, err := helper.BindInstituteID(r, session.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
 	}
+	session.InstituteID = instID
 
 	data, err := h.service.UpdateAcademicSession(r.Context(), session)
 	if err != nil {
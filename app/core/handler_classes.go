@@ -31,15 +31,12 @@ func (h *Handler) CreateClass(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instID, err := helper.GetInstituteID(r)
-	if err == nil {
-		class.InstituteID = instID
-	}
-
-	if class.InstituteID == [16]byte{} {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute ID is required")
+	instID, err := helper.BindInstituteID(r, class.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
 		return
 	}
+	class.InstituteID = instID
 
 	data, err := h.service.CreateClass(r.Context(), class)
 	if err != nil {
@@ -73,13 +70,13 @@ func (h *Handler) DeleteClass(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// DeleteClass service currently accepts only ID.
-	// We might want to verify tenant ownership first?
-	// But service interface is DeleteClass(ctx, id).
-	// For strictness, if service changes, we need to pass instituteID.
-	// Current service: func (s *Service) DeleteClass(ctx context.Context, id uuid.UUID) error
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	if err := h.service.DeleteClass(r.Context(), id); err != nil {
+	if err := h.service.DeleteClass(r.Context(), instID, id); err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to delete class: "+err.Error())
 		return
 	}
@@ -142,10 +139,12 @@ func (h *Handler) UpdateClass(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instID, err := helper.GetInstituteID(r)
-	if err == nil {
-		class.InstituteID = instID
+	instID, err := helper.BindInstituteID(r, class.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
 	}
+	class.InstituteID = instID
 
 	data, err := h.service.UpdateClass(r.Context(), class)
 	if err != nil {
@@ -31,15 +31,12 @@ func (h *Handler) CreateDepartment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instID, err := helper.GetInstituteID(r)
-	if err == nil {
-		department.InstituteID = instID
-	}
-
-	if department.InstituteID == [16]byte{} {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute ID is required")
+	instID, err := helper.BindInstituteID(r, department.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
 		return
 	}
+	department.InstituteID = instID
 
 	data, err := h.service.CreateDepartment(r.Context(), department)
 	if err != nil {
@@ -142,10 +139,12 @@ func (h *Handler) UpdateDepartment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instID, err := helper.GetInstituteID(r)
-	if err == nil {
-		department.InstituteID = instID
+	instID, err := helper.BindInstituteID(r, department.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
 	}
+	department.InstituteID = instID
 
 	data, err := h.service.UpdateDepartment(r.Context(), department)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"swiftschool/domain"
+	"swiftschool/dto"
 	"swiftschool/helper"
 )
 
@@ -25,21 +26,22 @@ func (h *Handler) CreateEmployee(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var employee domain.Employee
-	if err := json.NewDecoder(r.Body).Decode(&employee); err != nil {
+	var req struct {
+		domain.Employee
+		ProvisionLogin *dto.ProvisionLoginRequest `json:"provision_login,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
+	employee := req.Employee
 
-	instID, err := helper.GetInstituteID(r)
-	if err == nil {
-		employee.InstituteID = instID
-	}
-
-	if employee.InstituteID == [16]byte{} {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute ID is required")
+	instID, err := helper.BindInstituteID(r, employee.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
 		return
 	}
+	employee.InstituteID = instID
 
 	data, err := h.service.CreateEmployee(r.Context(), employee)
 	if err != nil {
@@ -47,7 +49,15 @@ func (h *Handler) CreateEmployee(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	helper.NewSuccessResponse(w, http.StatusCreated, "employee created successfully", data)
+	login, message := provisionOnCreate("employee created successfully", req.ProvisionLogin, func(contact LoginContact) (*ProvisionedLogin, error) {
+		role := domain.UserRole(req.ProvisionLogin.RoleType)
+		return h.service.ProvisionEmployeeLogin(r.Context(), instID, data.ID, role, contact, helper.GetSessionUserID(r))
+	})
+
+	helper.NewSuccessResponse(w, http.StatusCreated, message, struct {
+		*domain.Employee
+		Login *dto.ProvisionedLoginResponse `json:"login,omitempty"`
+	}{data, login})
 }
 
 // DeleteEmployee godoc
@@ -218,10 +228,12 @@ func (h *Handler) UpdateEmployee(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instID, err := helper.GetInstituteID(r)
-	if err == nil {
-		employee.InstituteID = instID
+	instID, err := helper.BindInstituteID(r, employee.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
 	}
+	employee.InstituteID = instID
 
 	data, err := h.service.UpdateEmployee(r.Context(), employee)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"swiftschool/domain"
+	"swiftschool/dto"
 	"swiftschool/helper"
 
 	"github.com/google/uuid"
@@ -27,23 +28,35 @@ func (h *Handler) CreateGuardian(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var guardian domain.Guardian
-	if err := json.NewDecoder(r.Body).Decode(&guardian); err != nil {
+	var req struct {
+		domain.Guardian
+		ProvisionLogin *dto.ProvisionLoginRequest `json:"provision_login,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
 
-	// Guardian is global/shared, doesn't need InstituteID from header for creation in DB
-	// But logically we might want to check permissions.
-	// For now, proceed.
-
-	data, err := h.service.CreateGuardian(r.Context(), guardian)
+	data, err := h.service.CreateGuardian(r.Context(), req.Guardian)
 	if err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create guardian: "+err.Error())
 		return
 	}
 
-	helper.NewSuccessResponse(w, http.StatusCreated, "guardian created successfully", data)
+	// Guardians sign in with their own email or phone, so any contact in
+	// provision_login is ignored
+	login, message := provisionOnCreate("guardian created successfully", req.ProvisionLogin, func(LoginContact) (*ProvisionedLogin, error) {
+		instID, err := helper.GetInstituteID(r)
+		if err != nil {
+			return nil, err
+		}
+		return h.service.ProvisionNewGuardianLogin(r.Context(), instID, data, helper.GetSessionUserID(r))
+	})
+
+	helper.NewSuccessResponse(w, http.StatusCreated, message, struct {
+		*domain.Guardian
+		Login *dto.ProvisionedLoginResponse `json:"login,omitempty"`
+	}{data, login})
 }
 
 // LinkStudentGuardian godoc
@@ -88,7 +101,13 @@ func (h *Handler) LinkStudentGuardian(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.LinkStudentGuardian(r.Context(), studentID, guardianID, req.Relationship, req.IsPrimary); err != nil {
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.LinkStudentGuardian(r.Context(), instID, studentID, guardianID, req.Relationship, req.IsPrimary); err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to link guardian: "+err.Error())
 		return
 	}
@@ -63,6 +63,11 @@ func (h *Handler) DeleteInstitute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := helper.AuthorizeInstitute(r, id); err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+
 	if err := h.service.DeleteInstitute(r.Context(), id); err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to delete institute: "+err.Error())
 		return
@@ -94,6 +99,11 @@ func (h *Handler) GetInstituteById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := helper.AuthorizeInstitute(r, id); err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+
 	data, err := h.service.GetInstituteById(r.Context(), id)
 	if err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to get institute: "+err.Error())
@@ -122,6 +132,11 @@ func (h *Handler) GetInstituteByCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := helper.AuthorizeInstitute(r, data.ID); err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+
 	helper.NewSuccessResponse(w, http.StatusOK, "institute retrieved successfully", data)
 }
 
@@ -140,6 +155,24 @@ func (h *Handler) ListInstitutes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Institute users only ever see their own institute
+	if session, _ := helper.SessionFromContext(r.Context()); !helper.IsSuperAdmin(session) {
+		instID, err := helper.GetInstituteID(r)
+		if err != nil {
+			helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		institute, err := h.service.GetInstituteById(r.Context(), instID)
+		if err != nil {
+			helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to list institutes: "+err.Error())
+			return
+		}
+
+		helper.NewSuccessResponse(w, http.StatusOK, "institutes retrieved successfully", []*domain.Institute{institute})
+		return
+	}
+
 	data, err := h.service.ListInstitutes(r.Context())
 	if err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to list institutes: "+err.Error())
@@ -173,6 +206,11 @@ func (h *Handler) UpdateInstitute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := helper.AuthorizeInstitute(r, institute.ID); err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+
 	data, err := h.service.UpdateInstitute(r.Context(), institute)
 	if err != nil {
 		helper.NewErrorResponse(w, http.StatusInternalServerError, "institute update failed: "+err.Error())
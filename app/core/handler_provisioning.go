@@ -0,0 +1,322 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"swiftschool/domain"
+	"swiftschool/dto"
+	"swiftschool/helper"
+	"time"
+)
+
+// classProvisioningTimeout replaces the server write timeout for bulk
+// provisioning, which hashes a password for every login it creates
+const classProvisioningTimeout = 5 * time.Minute
+
+// ProvisionStudentLogin godoc
+// @Summary Provision a student login
+// @Description Create the login for an existing student. The username is the institute code and admission number; a contact, when given, receives the welcome message; without one the initial password is returned.
+// @Tags Core - Login Provisioning
+// @Accept json
+// @Produce json
+// @Param request body dto.ProvisionStudentLoginRequest true "Student and optional contact"
+// @Success 201 {object} dto.SuccessResponse{data=dto.ProvisionedLoginResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /students/provision_login [post]
+func (h *Handler) ProvisionStudentLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req dto.ProvisionStudentLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	contact := loginContact(req.Email, req.PhoneNumber)
+	login, err := h.service.ProvisionStudentLogin(r.Context(), instID, req.StudentID, contact, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, provisioningErrorStatus(err), "failed to provision login: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "login provisioned successfully", provisionedLoginResponse(login))
+}
+
+// ProvisionEmployeeLogin godoc
+// @Summary Provision an employee login
+// @Description Create the login for an existing employee with a staff role. The username is the institute code and employee code.
+// @Tags Core - Login Provisioning
+// @Accept json
+// @Produce json
+// @Param request body dto.ProvisionEmployeeLoginRequest true "Employee, role and optional contact"
+// @Success 201 {object} dto.SuccessResponse{data=dto.ProvisionedLoginResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /employees/provision_login [post]
+func (h *Handler) ProvisionEmployeeLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req dto.ProvisionEmployeeLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	contact := loginContact(req.Email, req.PhoneNumber)
+	login, err := h.service.ProvisionEmployeeLogin(r.Context(), instID, req.EmployeeID, domain.UserRole(req.RoleType), contact, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, provisioningErrorStatus(err), "failed to provision login: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "login provisioned successfully", provisionedLoginResponse(login))
+}
+
+// ProvisionGuardianLogin godoc
+// @Summary Provision a guardian login
+// @Description Create the login for a guardian of one of the institute's students. The guardian signs in with their email or phone.
+// @Tags Core - Login Provisioning
+// @Accept json
+// @Produce json
+// @Param request body dto.ProvisionGuardianLoginRequest true "Guardian"
+// @Success 201 {object} dto.SuccessResponse{data=dto.ProvisionedLoginResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /guardians/provision_login [post]
+func (h *Handler) ProvisionGuardianLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req dto.ProvisionGuardianLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	login, err := h.service.ProvisionGuardianLogin(r.Context(), instID, req.GuardianID, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, provisioningErrorStatus(err), "failed to provision login: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "login provisioned successfully", provisionedLoginResponse(login))
+}
+
+// ProvisionClassLogins godoc
+// @Summary Provision logins for a class
+// @Description Create logins for every student in a class that has none yet, and optionally for their guardians. Existing logins are skipped; student logins are returned with their initial password.
+// @Tags Core - Login Provisioning
+// @Accept json
+// @Produce json
+// @Param request body dto.ProvisionClassLoginsRequest true "Class"
+// @Success 200 {object} dto.SuccessResponse{data=dto.ProvisionClassLoginsResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /classes/provision_logins [post]
+func (h *Handler) ProvisionClassLogins(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req dto.ProvisionClassLoginsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(classProvisioningTimeout)); err != nil {
+		logger.Warnf("could not extend write deadline for class provisioning: %v", err)
+	}
+
+	result, err := h.service.ProvisionClassLogins(r.Context(), instID, req.ClassID, req.IncludeGuardians, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to provision logins: "+err.Error())
+		return
+	}
+
+	resp := dto.ProvisionClassLoginsResponse{
+		Created: make([]dto.ProvisionedLoginResponse, 0, len(result.Created)),
+		Skipped: result.Skipped,
+	}
+	for _, login := range result.Created {
+		resp.Created = append(resp.Created, provisionedLoginResponse(login))
+	}
+	for _, f := range result.Failed {
+		resp.Failed = append(resp.Failed, dto.ProvisioningFailureResponse{
+			EntityID: f.EntityID,
+			RoleType: string(f.RoleType),
+			Error:    f.Err.Error(),
+		})
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "logins provisioned", resp)
+}
+
+// UpdateStudentStatus godoc
+// @Summary Update student status
+// @Description Activate or deactivate a student. Deactivation also disables the student's login.
+// @Tags Core - Students
+// @Accept json
+// @Produce json
+// @Param request body dto.UpdateActiveStatusRequest true "Status update details"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /students/update_status [patch]
+func (h *Handler) UpdateStudentStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req dto.UpdateActiveStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.SetStudentActive(r.Context(), instID, req.ID, req.IsActive, helper.GetSessionUserID(r)); err != nil {
+		helper.NewErrorResponse(w, provisioningErrorStatus(err), "failed to update student status: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "student status updated successfully", nil)
+}
+
+// UpdateEmployeeStatus godoc
+// @Summary Update employee status
+// @Description Activate or deactivate an employee. Deactivation also disables the employee's login.
+// @Tags Core - Employees
+// @Accept json
+// @Produce json
+// @Param request body dto.UpdateActiveStatusRequest true "Status update details"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /employees/update_status [patch]
+func (h *Handler) UpdateEmployeeStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req dto.UpdateActiveStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.SetEmployeeActive(r.Context(), instID, req.ID, req.IsActive, helper.GetSessionUserID(r)); err != nil {
+		helper.NewErrorResponse(w, provisioningErrorStatus(err), "failed to update employee status: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "employee status updated successfully", nil)
+}
+
+// provisionOnCreate creates the optional login requested with a new record
+// and returns the response message. The record is kept when provisioning
+// fails; the message then says why.
+func provisionOnCreate(message string, req *dto.ProvisionLoginRequest, provision func(LoginContact) (*ProvisionedLogin, error)) (*dto.ProvisionedLoginResponse, string) {
+	if req == nil {
+		return nil, message
+	}
+
+	login, err := provision(loginContact(req.Email, req.PhoneNumber))
+	if err != nil {
+		return nil, message + ", but the login could not be provisioned: " + err.Error()
+	}
+
+	resp := provisionedLoginResponse(login)
+	return &resp, message
+}
+
+func provisionedLoginResponse(login *ProvisionedLogin) dto.ProvisionedLoginResponse {
+	return dto.ProvisionedLoginResponse{
+		UserID:          login.User.ID,
+		Username:        login.User.Username,
+		RoleType:        string(login.User.RoleType),
+		LinkedEntityID:  login.User.LinkedEntityID,
+		WelcomeSent:     login.WelcomeSent,
+		InitialPassword: login.InitialPassword,
+	}
+}
+
+func loginContact(email, phone *string) LoginContact {
+	return LoginContact{
+		Email: helper.StrOrEmpty(email),
+		Phone: helper.StrOrEmpty(phone),
+	}
+}
+
+func provisioningErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrLoginExists), errors.Is(err, ErrLoginTaken):
+		return http.StatusConflict
+	case errors.Is(err, ErrNoLoginContact), errors.Is(err, ErrInvalidLoginContact), errors.Is(err, ErrInvalidLoginRole):
+		return http.StatusBadRequest
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
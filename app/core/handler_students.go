@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"swiftschool/domain"
+	"swiftschool/dto"
 	"swiftschool/helper"
 )
 
@@ -25,22 +26,22 @@ func (h *Handler) CreateStudent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var student domain.Student
-	if err := json.NewDecoder(r.Body).Decode(&student); err != nil {
+	var req struct {
+		domain.Student
+		ProvisionLogin *dto.ProvisionLoginRequest `json:"provision_login,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
+	student := req.Student
 
-	// We can trust the InstituteID in the body OR override it from the request context/header
-	// For security, it should ideally come from the authenticated session.
-	// For now, let's override/ensure it's set if we have it in header.
-	instID, err := helper.GetInstituteID(r)
-	if err == nil {
-		student.InstituteID = instID
-	} else if student.InstituteID == [16]byte{} { // Check if empty UUID
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute ID is required")
+	instID, err := helper.BindInstituteID(r, student.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
 		return
 	}
+	student.InstituteID = instID
 
 	data, err := h.service.CreateStudent(r.Context(), student)
 	if err != nil {
@@ -48,7 +49,14 @@ func (h *Handler) CreateStudent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	helper.NewSuccessResponse(w, http.StatusCreated, "student created successfully", data)
+	login, message := provisionOnCreate("student created successfully", req.ProvisionLogin, func(contact LoginContact) (*ProvisionedLogin, error) {
+		return h.service.ProvisionStudentLogin(r.Context(), instID, data.ID, contact, helper.GetSessionUserID(r))
+	})
+
+	helper.NewSuccessResponse(w, http.StatusCreated, message, struct {
+		*domain.Student
+		Login *dto.ProvisionedLoginResponse `json:"login,omitempty"`
+	}{data, login})
 }
 
 // DeleteStudent godoc
@@ -226,10 +234,12 @@ func (h *Handler) UpdateStudent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instID, err := helper.GetInstituteID(r)
-	if err == nil {
-		student.InstituteID = instID
+	instID, err := helper.BindInstituteID(r, student.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
 	}
+	student.InstituteID = instID
 
 	data, err := h.service.UpdateStudent(r.Context(), student)
 	if err != nil {
@@ -31,7 +31,7 @@ func (r *Repository) CreateClass(ctx context.Context, arg domain.Class) (*domain
 }
 
 // DeleteClass removes a class record from the database
-func (r *Repository) DeleteClass(ctx context.Context, id uuid.UUID) error {
+func (r *Repository) DeleteClass(ctx context.Context, instituteID, id uuid.UUID) error {
 	// DeleteClass query not generated by SQLC
 	return errors.New("delete class not implemented")
 }
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+
+	"github.com/google/uuid"
+)
+
+// CreateLinkedUser inserts the login account for a student, guardian or employee
+func (r *Repository) CreateLinkedUser(ctx context.Context, arg domain.User) (*domain.User, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreateUser(ctx, mapper.MapDomainUserToDBParams(arg))
+	if err != nil {
+		if helper.IsPgUniqueViolation(err) {
+			return nil, ErrLoginTaken
+		}
+		return nil, err
+	}
+
+	return mapper.MapDBUserToDomain(row), nil
+}
+
+// GetLinkedUser returns the login linked to a record, or nil when there is none
+func (r *Repository) GetLinkedUser(ctx context.Context, entityID uuid.UUID) (*domain.User, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetUserByLinkedEntity(ctx, entityID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return mapper.MapDBUserToDomain(row), nil
+}
+
+// GetInstituteGuardian retrieves a guardian linked to a student of the institute
+func (r *Repository) GetInstituteGuardian(ctx context.Context, instituteID, guardianID uuid.UUID) (*domain.Guardian, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetInstituteGuardian(ctx, db.GetInstituteGuardianParams{
+		GuardianID:  guardianID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	g := mapper.MapDBGuardianToDomain(row)
+	return &g, nil
+}
+
+// ListClassGuardians retrieves the guardians of every student in a class
+func (r *Repository) ListClassGuardians(ctx context.Context, instituteID, classID uuid.UUID) ([]*domain.Guardian, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListClassGuardians(ctx, db.ListClassGuardiansParams{
+		InstituteID:    instituteID,
+		CurrentClassID: helper.ToNullUUID(classID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	guardians := make([]*domain.Guardian, 0, len(rows))
+	for _, row := range rows {
+		g := mapper.MapDBGuardianToDomain(row)
+		guardians = append(guardians, &g)
+	}
+	return guardians, nil
+}
+
+// SetStudentActive updates a student's status. Deactivation also disables
+// the linked logins; their IDs are returned so sessions can be revoked.
+func (r *Repository) SetStudentActive(ctx context.Context, instituteID, id uuid.UUID, isActive bool, updatedBy *uuid.UUID) ([]uuid.UUID, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	n, err := q.SetStudentActive(ctx, db.SetStudentActiveParams{
+		ID:          id,
+		InstituteID: instituteID,
+		IsActive:    helper.ToNullBool(isActive),
+		UpdatedBy:   helper.ToNullUUID(helper.DerefUUID(updatedBy)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	userIDs, err := deactivateLinkedUsers(ctx, q, instituteID, id, isActive, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return userIDs, tx.Commit()
+}
+
+// SetEmployeeActive updates an employee's status, cascading deactivation to
+// the linked logins like SetStudentActive.
+func (r *Repository) SetEmployeeActive(ctx context.Context, instituteID, id uuid.UUID, isActive bool, updatedBy *uuid.UUID) ([]uuid.UUID, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	n, err := q.SetEmployeeActive(ctx, db.SetEmployeeActiveParams{
+		ID:          id,
+		InstituteID: instituteID,
+		IsActive:    helper.ToNullBool(isActive),
+		UpdatedBy:   helper.ToNullUUID(helper.DerefUUID(updatedBy)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	userIDs, err := deactivateLinkedUsers(ctx, q, instituteID, id, isActive, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return userIDs, tx.Commit()
+}
+
+func deactivateLinkedUsers(ctx context.Context, q *db.Queries, instituteID, entityID uuid.UUID, isActive bool, updatedBy *uuid.UUID) ([]uuid.UUID, error) {
+	// Reactivating a record leaves its login disabled until an admin re-enables it
+	if isActive {
+		return nil, nil
+	}
+
+	return q.DeactivateLinkedUsers(ctx, db.DeactivateLinkedUsersParams{
+		LinkedEntityID: entityID,
+		InstituteID:    helper.ToNullUUID(instituteID),
+		UpdatedBy:      helper.ToNullUUID(helper.DerefUUID(updatedBy)),
+	})
+}
@@ -13,8 +13,8 @@ func (s *Service) CreateClass(ctx context.Context, arg domain.Class) (*domain.Cl
 }
 
 // DeleteClass removes a class
-func (s *Service) DeleteClass(ctx context.Context, id uuid.UUID) error {
-	return s.repo.DeleteClass(ctx, id)
+func (s *Service) DeleteClass(ctx context.Context, instituteID, id uuid.UUID) error {
+	return s.repo.DeleteClass(ctx, instituteID, id)
 }
 
 // ListClasses retrieves all classes for an institute
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"swiftschool/domain"
 
 	"github.com/google/uuid"
@@ -12,7 +13,11 @@ func (s *Service) CreateGuardian(ctx context.Context, arg domain.Guardian) (*dom
 	return s.repo.CreateGuardian(ctx, arg)
 }
 
-// LinkStudentGuardian links a student to a guardian
-func (s *Service) LinkStudentGuardian(ctx context.Context, studentID, guardianID uuid.UUID, relationship string, isPrimary bool) error {
+// LinkStudentGuardian links a student of the institute to a guardian
+func (s *Service) LinkStudentGuardian(ctx context.Context, instituteID, studentID, guardianID uuid.UUID, relationship string, isPrimary bool) error {
+	// Guardians are shared across institutes, so ownership is checked on the student
+	if _, err := s.repo.GetStudentFullProfile(ctx, instituteID, studentID); err != nil {
+		return fmt.Errorf("student not found in this institute: %w", err)
+	}
 	return s.repo.LinkStudentGuardian(ctx, studentID, guardianID, relationship, isPrimary)
 }
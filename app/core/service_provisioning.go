@@ -0,0 +1,437 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"swiftschool/domain"
+	"swiftschool/helper"
+
+	"github.com/google/uuid"
+)
+
+var logger = helper.GetLogger()
+
+var (
+	ErrLoginExists         = errors.New("a login already exists for this record")
+	ErrLoginTaken          = errors.New("the username, email or phone number is already used by another login")
+	ErrNoLoginContact      = errors.New("the guardian needs an email or phone number to receive a login")
+	ErrInvalidLoginContact = errors.New("login email or phone number is not valid")
+	ErrInvalidLoginRole    = errors.New("employee logins must use a staff role; create administrators through /auth/users/register")
+)
+
+// staffLoginRoles may be given to an employee login
+var staffLoginRoles = []domain.UserRole{
+	domain.RoleTeacher,
+	domain.RoleAccountant,
+	domain.RoleLibrarian,
+	domain.RoleDriver,
+	domain.RoleNurse,
+	domain.RoleEmployee,
+}
+
+// LoginContact receives the welcome message and is stored on the login, so
+// the user can sign in with a one-time code sent to it.
+type LoginContact struct {
+	Email string
+	Phone string
+}
+
+// ProvisionedLogin is a login created for a student, guardian or employee.
+// A login without a contact cannot receive one-time codes, so its initial
+// password is returned for the office to hand over.
+type ProvisionedLogin struct {
+	User            *domain.User
+	WelcomeSent     bool
+	InitialPassword string
+}
+
+// ProvisioningFailure records a record whose login could not be created
+type ProvisioningFailure struct {
+	EntityID uuid.UUID
+	RoleType domain.UserRole
+	Err      error
+}
+
+// ClassProvisioning summarises a bulk provisioning run for a class
+type ClassProvisioning struct {
+	Created []*ProvisionedLogin
+	Skipped int
+	Failed  []ProvisioningFailure
+}
+
+// ProvisionStudentLogin creates the login for a student. The username is
+// the institute code and admission number, e.g. "gvis-blr.a1024".
+func (s *Service) ProvisionStudentLogin(ctx context.Context, instituteID, studentID uuid.UUID, contact LoginContact, createdBy *uuid.UUID) (*ProvisionedLogin, error) {
+	student, err := s.repo.GetStudentFullProfile(ctx, instituteID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("student not found in this institute: %w", err)
+	}
+
+	inst, err := s.repo.GetInstituteById(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.provisionStudent(ctx, inst, student, contact, createdBy)
+}
+
+// ProvisionEmployeeLogin creates the login for an employee with a staff role.
+// The username is the institute code and employee code.
+func (s *Service) ProvisionEmployeeLogin(ctx context.Context, instituteID, employeeID uuid.UUID, role domain.UserRole, contact LoginContact, createdBy *uuid.UUID) (*ProvisionedLogin, error) {
+	if role == "" {
+		role = domain.RoleEmployee
+	}
+	if !helper.Contains(staffLoginRoles, role) {
+		return nil, ErrInvalidLoginRole
+	}
+
+	employee, err := s.repo.GetEmployeeById(ctx, instituteID, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("employee not found in this institute: %w", err)
+	}
+
+	inst, err := s.repo.GetInstituteById(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	user := domain.User{
+		Username:       loginUsername(inst.Code, employee.EmployeeCode),
+		RoleType:       role,
+		LinkedEntityID: employee.ID,
+		InstituteID:    &instituteID,
+	}
+	user.CreatedBy = createdBy
+
+	return s.provision(ctx, user, contact, fullName(employee.FirstName, employee.LastName), inst.Name)
+}
+
+// ProvisionGuardianLogin creates the login for a guardian of one of the
+// institute's students. Guardians sign in with their email or phone.
+func (s *Service) ProvisionGuardianLogin(ctx context.Context, instituteID, guardianID uuid.UUID, createdBy *uuid.UUID) (*ProvisionedLogin, error) {
+	guardian, err := s.repo.GetInstituteGuardian(ctx, instituteID, guardianID)
+	if err != nil {
+		return nil, fmt.Errorf("guardian not found in this institute: %w", err)
+	}
+
+	inst, err := s.repo.GetInstituteById(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.provisionGuardian(ctx, inst, guardian, createdBy)
+}
+
+// ProvisionNewGuardianLogin creates the login for a guardian the institute
+// has just registered, before the guardian is linked to a student.
+func (s *Service) ProvisionNewGuardianLogin(ctx context.Context, instituteID uuid.UUID, guardian *domain.Guardian, createdBy *uuid.UUID) (*ProvisionedLogin, error) {
+	inst, err := s.repo.GetInstituteById(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.provisionGuardian(ctx, inst, guardian, createdBy)
+}
+
+// ProvisionClassLogins creates logins for every student in a class that has
+// none yet, and optionally for their guardians. Students have no contact
+// details of their own, so their logins come back with an initial password
+// for signing in with the username; only guardians receive welcome messages.
+// The passwords of the whole class are hashed up front across all CPUs, so
+// a large class does not run one slow hash after another.
+func (s *Service) ProvisionClassLogins(ctx context.Context, instituteID, classID uuid.UUID, includeGuardians bool, createdBy *uuid.UUID) (*ClassProvisioning, error) {
+	inst, err := s.repo.GetInstituteById(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := s.repo.ListStudentsByClass(ctx, instituteID, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ClassProvisioning{}
+	record := func(entityID uuid.UUID, role domain.UserRole, login *ProvisionedLogin, err error) {
+		switch {
+		case errors.Is(err, ErrLoginExists):
+			result.Skipped++
+		case err != nil:
+			result.Failed = append(result.Failed, ProvisioningFailure{EntityID: entityID, RoleType: role, Err: err})
+		default:
+			result.Created = append(result.Created, login)
+		}
+	}
+
+	type pendingLogin struct {
+		user    domain.User
+		contact LoginContact
+		name    string
+	}
+	var pending []pendingLogin
+	queue := func(user domain.User, contact LoginContact, name string) {
+		if err := s.checkProvisionable(ctx, user, contact); err != nil {
+			record(user.LinkedEntityID, user.RoleType, nil, err)
+			return
+		}
+		pending = append(pending, pendingLogin{user: user, contact: contact, name: name})
+	}
+
+	for _, student := range students {
+		queue(studentLogin(inst, student, createdBy), LoginContact{}, fullName(student.FirstName, student.LastName))
+	}
+
+	if includeGuardians {
+		guardians, err := s.repo.ListClassGuardians(ctx, instituteID, classID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, guardian := range guardians {
+			user, contact, err := guardianLogin(inst, guardian, createdBy)
+			if err != nil {
+				record(guardian.ID, domain.RoleGuardian, nil, err)
+				continue
+			}
+			queue(user, contact, fullName(guardian.FirstName, guardian.LastName))
+		}
+	}
+
+	passwords, err := newInitialPasswords(len(pending))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range pending {
+		login, err := s.createLogin(ctx, p.user, p.contact, p.name, inst.Name, passwords[i])
+		record(p.user.LinkedEntityID, p.user.RoleType, login, err)
+	}
+
+	logger.Infof("provisioned %d logins for class %s (%d skipped, %d failed)",
+		len(result.Created), classID, result.Skipped, len(result.Failed))
+	return result, nil
+}
+
+// SetStudentActive activates or deactivates a student. Deactivation also
+// disables the student's login and signs it out everywhere.
+func (s *Service) SetStudentActive(ctx context.Context, instituteID, id uuid.UUID, isActive bool, updatedBy *uuid.UUID) error {
+	userIDs, err := s.repo.SetStudentActive(ctx, instituteID, id, isActive, updatedBy)
+	if err != nil {
+		return err
+	}
+	revokeSessions(ctx, userIDs)
+	return nil
+}
+
+// SetEmployeeActive activates or deactivates an employee, cascading
+// deactivation to the employee's login like SetStudentActive.
+func (s *Service) SetEmployeeActive(ctx context.Context, instituteID, id uuid.UUID, isActive bool, updatedBy *uuid.UUID) error {
+	userIDs, err := s.repo.SetEmployeeActive(ctx, instituteID, id, isActive, updatedBy)
+	if err != nil {
+		return err
+	}
+	revokeSessions(ctx, userIDs)
+	return nil
+}
+
+func (s *Service) provisionStudent(ctx context.Context, inst *domain.Institute, student *domain.Student, contact LoginContact, createdBy *uuid.UUID) (*ProvisionedLogin, error) {
+	return s.provision(ctx, studentLogin(inst, student, createdBy), contact, fullName(student.FirstName, student.LastName), inst.Name)
+}
+
+func (s *Service) provisionGuardian(ctx context.Context, inst *domain.Institute, guardian *domain.Guardian, createdBy *uuid.UUID) (*ProvisionedLogin, error) {
+	user, contact, err := guardianLogin(inst, guardian, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	return s.provision(ctx, user, contact, fullName(guardian.FirstName, guardian.LastName), inst.Name)
+}
+
+// studentLogin is the login a student is provisioned with
+func studentLogin(inst *domain.Institute, student *domain.Student, createdBy *uuid.UUID) domain.User {
+	user := domain.User{
+		Username:       loginUsername(inst.Code, student.AdmissionNo),
+		RoleType:       domain.RoleStudent,
+		LinkedEntityID: student.ID,
+		InstituteID:    &inst.ID,
+	}
+	user.CreatedBy = createdBy
+	return user
+}
+
+// guardianLogin is the login a guardian is provisioned with, named after
+// the guardian's email or else phone number
+func guardianLogin(inst *domain.Institute, guardian *domain.Guardian, createdBy *uuid.UUID) (domain.User, LoginContact, error) {
+	contact := LoginContact{
+		Email: helper.StrOrEmpty(guardian.Email),
+		Phone: helper.StrOrEmpty(guardian.Phone),
+	}
+
+	username := contact.Email
+	if username == "" {
+		username = contact.Phone
+	}
+	if username == "" {
+		return domain.User{}, contact, ErrNoLoginContact
+	}
+
+	user := domain.User{
+		Username:       username,
+		RoleType:       domain.RoleGuardian,
+		LinkedEntityID: guardian.ID,
+		InstituteID:    &inst.ID,
+	}
+	user.CreatedBy = createdBy
+	return user, contact, nil
+}
+
+// provision creates an active login with a random password and sends the
+// welcome message. Users with a contact sign in with a one-time code or
+// reset the password; the others sign in with the username and the initial
+// password returned here.
+func (s *Service) provision(ctx context.Context, user domain.User, contact LoginContact, name, instituteName string) (*ProvisionedLogin, error) {
+	if err := s.checkProvisionable(ctx, user, contact); err != nil {
+		return nil, err
+	}
+
+	passwords, err := newInitialPasswords(1)
+	if err != nil {
+		return nil, err
+	}
+	return s.createLogin(ctx, user, contact, name, instituteName, passwords[0])
+}
+
+// checkProvisionable rejects invalid contacts and records that already
+// have a login
+func (s *Service) checkProvisionable(ctx context.Context, user domain.User, contact LoginContact) error {
+	if err := contact.validate(); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.GetLinkedUser(ctx, user.LinkedEntityID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrLoginExists
+	}
+	return nil
+}
+
+// createLogin stores the login with its initial password and sends the
+// welcome message
+func (s *Service) createLogin(ctx context.Context, user domain.User, contact LoginContact, name, instituteName string, password initialPassword) (*ProvisionedLogin, error) {
+	user.PasswordHash = password.hash
+	user.IsActive = true
+	if contact.Email != "" {
+		user.Email = &contact.Email
+	}
+	if contact.Phone != "" {
+		user.PhoneNumber = &contact.Phone
+	}
+
+	created, err := s.repo.CreateLinkedUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("provisioned %s login %s for %s", created.RoleType, created.Username, created.LinkedEntityID)
+
+	login := &ProvisionedLogin{
+		User:        created,
+		WelcomeSent: sendWelcome(ctx, created, contact, name, instituteName),
+	}
+	if contact.Email == "" && contact.Phone == "" {
+		login.InitialPassword = password.plain
+	}
+	return login, nil
+}
+
+// initialPassword is a generated password and its bcrypt hash
+type initialPassword struct {
+	plain string
+	hash  string
+}
+
+// newInitialPasswords generates and hashes n passwords, running up to one
+// bcrypt hash per CPU at a time
+func newInitialPasswords(n int) ([]initialPassword, error) {
+	passwords := make([]initialPassword, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i := range passwords {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			plain := helper.GenerateRandomPassword()
+			hash, err := helper.HashPassword(plain)
+			passwords[i], errs[i] = initialPassword{plain: plain, hash: hash}, err
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return passwords, nil
+}
+
+// sendWelcome emails or texts the first-login instructions. A failed
+// delivery does not undo the login; the office can share the username.
+func sendWelcome(ctx context.Context, user *domain.User, contact LoginContact, name, instituteName string) bool {
+	n := helper.Notification{InstituteID: helper.DerefUUID(user.InstituteID)}
+	channel := helper.ChannelEmail
+
+	switch {
+	case contact.Email != "":
+		n.To = contact.Email
+		n.Subject = "Welcome to " + instituteName
+		n.Template = helper.WelcomeEmail
+		n.Data = map[string]string{"institute": instituteName, "name": name, "username": user.Username}
+	case contact.Phone != "":
+		channel = helper.ChannelSMS
+		n.To = contact.Phone
+		n.Body = fmt.Sprintf("Welcome to %s on SwiftSchool. Your username is %s. Sign in with a one-time code sent to this number.",
+			instituteName, user.Username)
+	default:
+		return false
+	}
+
+	if err := helper.Notify(ctx, channel, n); err != nil {
+		logger.Errorf("welcome delivery via %s to %s failed: %v", channel, n.To, err)
+		return false
+	}
+	return true
+}
+
+func revokeSessions(ctx context.Context, userIDs []uuid.UUID) {
+	for _, id := range userIDs {
+		if _, err := helper.DeleteUserSessions(ctx, id.String()); err != nil {
+			logger.Warnf("failed to revoke sessions for deactivated user %s: %v", id, err)
+		}
+	}
+}
+
+func (c LoginContact) validate() error {
+	if c.Email != "" && helper.ValidateLoginType(c.Email) != helper.LoginEmail {
+		return ErrInvalidLoginContact
+	}
+	if c.Phone != "" && helper.ValidateLoginType(c.Phone) != helper.LoginPhone {
+		return ErrInvalidLoginContact
+	}
+	return nil
+}
+
+func loginUsername(instituteCode, recordCode string) string {
+	return strings.ToLower(strings.TrimSpace(instituteCode) + "." + strings.TrimSpace(recordCode))
+}
+
+func fullName(first string, last *string) string {
+	return strings.TrimSpace(first + " " + helper.StrOrEmpty(last))
+}
@@ -2,14 +2,56 @@ package finance
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"swiftschool/domain"
 	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var logger = helper.GetLogger()
+
+var (
+	ErrInvalidAccount       = errors.New("account name and code are required")
+	ErrInvalidAccountType   = errors.New("account type must be asset, liability, equity, income or expense")
+	ErrInvalidParentAccount = errors.New("parent account must exist in this institute and have the same type")
+	ErrAccountNotFound      = errors.New("account not found")
+	ErrAccountInactive      = errors.New("cannot post to an inactive account")
+	ErrParentAccount        = errors.New("cannot post to a parent account; post to one of its sub-accounts")
+	ErrJournalTooFewItems   = errors.New("a journal needs at least two items")
+	ErrInvalidJournalItem   = errors.New("each journal item needs an account and either a debit or a credit greater than zero")
+	ErrUnbalancedJournal    = errors.New("journal debits and credits must be equal")
+	ErrJournalNotFound      = errors.New("journal entry not found")
+	ErrJournalPosted        = errors.New("journal entry is posted and cannot be changed; post a reversal instead")
+	ErrJournalNotPosted     = errors.New("only posted journal entries can be reversed")
+	ErrJournalReversed      = errors.New("journal entry has already been reversed")
+	ErrReverseReversal      = errors.New("a reversal entry cannot itself be reversed; post a new journal instead")
+	ErrPeriodClosed         = errors.New("the fiscal year of this date has been closed")
+
+	ErrInvalidPeriod           = errors.New("statement period must start on or before its end")
+	ErrYearClosed              = errors.New("this fiscal year, or a later one, has already been closed")
+	ErrYearNotEnded            = errors.New("a fiscal year can only be closed after it has ended")
+	ErrInvalidRetainedEarnings = errors.New("retained earnings must be an equity account")
+)
+
+var accountTypes = []domain.AccountType{
+	domain.AccAsset,
+	domain.AccLiability,
+	domain.AccEquity,
+	domain.AccIncome,
+	domain.AccExpense,
+}
+
 // =================================================================================
 // HANDLERS
 // =================================================================================
@@ -26,9 +68,17 @@ func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
 	data, err := h.service.CreateAccount(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create account: "+err.Error())
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to create account: "+err.Error())
 		return
 	}
 
@@ -41,15 +91,9 @@ func (h *Handler) ListAccounts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	idStr := r.URL.Query().Get("institute_id")
-	if idStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id is required")
-		return
-	}
-
-	id, err := uuid.Parse(idStr)
+	id, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -74,9 +118,17 @@ func (h *Handler) CreateJournalEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
 	data, err := h.service.CreateJournalEntry(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create journal entry: "+err.Error())
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to create journal entry: "+err.Error())
 		return
 	}
 
@@ -95,32 +147,155 @@ func (h *Handler) CreateJournalItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
 	data, err := h.service.CreateJournalItem(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create journal item: "+err.Error())
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to create journal item: "+err.Error())
 		return
 	}
 
 	helper.NewSuccessResponse(w, http.StatusCreated, "journal item created successfully", data)
 }
 
+func (h *Handler) PostJournal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.JournalEntry
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.PostJournal(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to post journal: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "journal posted successfully", data)
+}
+
+func (h *Handler) PostDraftJournal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "journal_entry_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid journal_entry_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.PostDraftJournal(r.Context(), inst, id, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to post journal: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "journal posted successfully", data)
+}
+
+func (h *Handler) ReverseJournal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.JournalEntry
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if req.ReversalOfID == nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "reversal_of_id is required")
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.ReverseJournal(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to reverse journal: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "journal reversed successfully", data)
+}
+
+func (h *Handler) GetJournalEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "journal_entry_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid journal_entry_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetJournalEntry(r.Context(), inst, id)
+	if err != nil {
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to fetch journal entry: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "journal entry fetched successfully", data)
+}
+
 func (h *Handler) GetAccountBalance(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	instStr := r.URL.Query().Get("institute_id")
 	accStr := r.URL.Query().Get("account_id")
-
-	if instStr == "" || accStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id and account_id are required")
+	if accStr == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "account_id is required")
 		return
 	}
 
-	inst, err := uuid.Parse(instStr)
+	inst, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -132,24 +307,104 @@ func (h *Handler) GetAccountBalance(w http.ResponseWriter, r *http.Request) {
 
 	balance, err := h.service.GetAccountBalance(r.Context(), inst, acc)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch balance: "+err.Error())
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to fetch balance: "+err.Error())
 		return
 	}
 
-	helper.NewSuccessResponse(w, http.StatusOK, "account balance fetched successfully", map[string]float64{"balance": balance})
+	helper.NewSuccessResponse(w, http.StatusOK, "account balance fetched successfully", balance)
+}
+
+func accountingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidAccount), errors.Is(err, ErrInvalidAccountType),
+		errors.Is(err, ErrInvalidParentAccount), errors.Is(err, ErrAccountInactive),
+		errors.Is(err, ErrParentAccount), errors.Is(err, ErrJournalTooFewItems),
+		errors.Is(err, ErrInvalidJournalItem), errors.Is(err, ErrUnbalancedJournal),
+		errors.Is(err, ErrInvalidPeriod), errors.Is(err, ErrInvalidRetainedEarnings):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrAccountNotFound), errors.Is(err, ErrJournalNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrJournalPosted), errors.Is(err, ErrJournalNotPosted),
+		errors.Is(err, ErrJournalReversed), errors.Is(err, ErrReverseReversal),
+		errors.Is(err, ErrPeriodClosed), errors.Is(err, ErrYearClosed), errors.Is(err, ErrYearNotEnded):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // ========================= CREATE ACCOUNT =========================
 
 // SERVICE
 func (s *Service) CreateAccount(ctx context.Context, arg domain.Account) (*domain.Account, error) {
+	arg.Name = strings.TrimSpace(arg.Name)
+	arg.Code = strings.TrimSpace(arg.Code)
+	if arg.Name == "" || arg.Code == "" {
+		return nil, ErrInvalidAccount
+	}
+	if !helper.Contains(accountTypes, arg.Type) {
+		return nil, ErrInvalidAccountType
+	}
+
+	// Sub-accounts roll up into their parent, so both sides must agree
+	// on which side of the ledger is normal
+	if arg.ParentAccountID != nil {
+		parent, err := s.repo.GetAccount(ctx, arg.InstituteID, *arg.ParentAccountID)
+		if errors.Is(err, ErrAccountNotFound) {
+			return nil, ErrInvalidParentAccount
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parent.Type != arg.Type {
+			return nil, ErrInvalidParentAccount
+		}
+	}
+
 	return s.repo.CreateAccount(ctx, arg)
 }
 
 // REPOSITORY
 func (r *Repository) CreateAccount(ctx context.Context, arg domain.Account) (*domain.Account, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreateAccount(ctx, mapper.MapAccountDomainToParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	acc := mapper.MapAccountRowToDomain(row)
+	return &acc, nil
+}
+
+// ========================= GET ACCOUNT =========================
+
+// REPOSITORY
+func (r *Repository) GetAccount(ctx context.Context, instituteID, id uuid.UUID) (*domain.Account, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
 
-	return &arg, nil
+	row, err := q.GetAccount(ctx, db.GetAccountParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+
+	acc := mapper.MapAccountRowToDomain(row)
+	return &acc, nil
 }
 
 // ========================= LIST ACCOUNTS =========================
@@ -161,45 +416,626 @@ func (s *Service) ListAccounts(ctx context.Context, instituteID uuid.UUID) ([]*d
 
 // REPOSITORY
 func (r *Repository) ListAccounts(ctx context.Context, instituteID uuid.UUID) ([]*domain.Account, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
 
-	return nil, nil
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListAccounts(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*domain.Account, 0, len(rows))
+	for _, row := range rows {
+		acc := mapper.MapAccountRowToDomain(row)
+		accounts = append(accounts, &acc)
+	}
+	return accounts, nil
 }
 
 // ========================= CREATE JOURNAL ENTRY =========================
 
 // SERVICE
+// CreateJournalEntry saves a draft journal. Its items need not balance yet;
+// they are checked when the draft is posted.
 func (s *Service) CreateJournalEntry(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error) {
+	for i, item := range arg.Items {
+		if err := checkJournalItem(item); err != nil {
+			return nil, fmt.Errorf("%w (item %d)", err, i+1)
+		}
+	}
+	arg.IsPosted = false
+	arg.PostedAt = nil
+	arg.ReversalOfID = nil
+	arg.SourceType, arg.SourceID = nil, nil
+	if arg.TransactionDate.IsZero() {
+		arg.TransactionDate = time.Now()
+	}
+
 	return s.repo.CreateJournalEntry(ctx, arg)
 }
 
 // REPOSITORY
 func (r *Repository) CreateJournalEntry(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if len(arg.Items) > 0 {
+		if err := checkPostingAccounts(ctx, q, arg.InstituteID, arg.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := insertJournal(ctx, q, arg)
+	if err != nil {
+		return nil, err
+	}
 
-	return &arg, nil
+	return entry, tx.Commit()
 }
 
 // ========================= CREATE JOURNAL ITEM =========================
 
 // SERVICE
+// CreateJournalItem adds an item to a draft journal; posted journals are locked
 func (s *Service) CreateJournalItem(ctx context.Context, arg domain.JournalItem) (*domain.JournalItem, error) {
+	if err := checkJournalItem(arg); err != nil {
+		return nil, err
+	}
 	return s.repo.CreateJournalItem(ctx, arg)
 }
 
 // REPOSITORY
 func (r *Repository) CreateJournalItem(ctx context.Context, arg domain.JournalItem) (*domain.JournalItem, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	entry, err := lockJournalEntry(ctx, q, arg.InstituteID, arg.JournalEntryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.IsPosted.Bool {
+		return nil, ErrJournalPosted
+	}
+
+	if err := checkPostingAccounts(ctx, q, arg.InstituteID, []domain.JournalItem{arg}); err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreateJournalItem(ctx, mapper.MapJournalItemDomainToParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	item := mapper.MapJournalItemRowToDomain(row)
+	return &item, tx.Commit()
+}
+
+// ========================= POST JOURNAL =========================
+
+// SERVICE
+// PostJournal writes a balanced journal and its items in one transaction
+// and posts it. Posted journals can only be undone with ReverseJournal.
+func (s *Service) PostJournal(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error) {
+	if err := checkBalanced(arg.Items); err != nil {
+		return nil, err
+	}
+	arg.ReversalOfID = nil
+	arg.SourceType, arg.SourceID = nil, nil
+	if arg.TransactionDate.IsZero() {
+		arg.TransactionDate = time.Now()
+	}
+
+	entry, err := s.repo.PostJournal(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("journal %s posted with %d items", entry.ID, len(entry.Items))
+	return entry, nil
+}
+
+// REPOSITORY
+func (r *Repository) PostJournal(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if err := checkOpenPeriod(ctx, q, arg.InstituteID, arg.TransactionDate); err != nil {
+		return nil, err
+	}
+	if err := checkPostingAccounts(ctx, q, arg.InstituteID, arg.Items); err != nil {
+		return nil, err
+	}
+
+	entry, err := insertJournal(ctx, q, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := markPosted(ctx, q, entry, arg.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	return entry, tx.Commit()
+}
+
+// ========================= POST DRAFT JOURNAL =========================
+
+// SERVICE
+// PostDraftJournal posts a draft once its items balance
+func (s *Service) PostDraftJournal(ctx context.Context, instituteID, id uuid.UUID, postedBy *uuid.UUID) (*domain.JournalEntry, error) {
+	entry, err := s.repo.PostDraftJournal(ctx, instituteID, id, postedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("draft journal %s posted with %d items", entry.ID, len(entry.Items))
+	return entry, nil
+}
+
+// REPOSITORY
+func (r *Repository) PostDraftJournal(ctx context.Context, instituteID, id uuid.UUID, postedBy *uuid.UUID) (*domain.JournalEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	// The lock keeps items from being added between the checks and posting
+	row, err := lockJournalEntry(ctx, q, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	if row.IsPosted.Bool {
+		return nil, ErrJournalPosted
+	}
+	if err := checkOpenPeriod(ctx, q, instituteID, row.TransactionDate.Time); err != nil {
+		return nil, err
+	}
+
+	items, err := listJournalItems(ctx, q, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBalanced(items); err != nil {
+		return nil, err
+	}
+	if err := checkPostingAccounts(ctx, q, instituteID, items); err != nil {
+		return nil, err
+	}
+
+	entry := mapper.MapJournalEntryRowToDomain(row)
+	entry.Items = items
+	if err := markPosted(ctx, q, &entry, postedBy); err != nil {
+		return nil, err
+	}
+
+	return &entry, tx.Commit()
+}
+
+// ========================= REVERSE JOURNAL =========================
+
+// SERVICE
+// ReverseJournal posts a new entry that mirrors a posted one, swapping every
+// debit and credit. arg.ReversalOfID names the entry being reversed.
+func (s *Service) ReverseJournal(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error) {
+	if arg.TransactionDate.IsZero() {
+		arg.TransactionDate = time.Now()
+	}
+
+	entry, err := s.repo.ReverseJournal(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("journal %s reversed by %s", *arg.ReversalOfID, entry.ID)
+	return entry, nil
+}
+
+// REPOSITORY
+func (r *Repository) ReverseJournal(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	// Locking the original serialises concurrent reversals of it
+	orig, err := lockJournalEntry(ctx, q, arg.InstituteID, *arg.ReversalOfID)
+	if err != nil {
+		return nil, err
+	}
+	if !orig.IsPosted.Bool {
+		return nil, ErrJournalNotPosted
+	}
+	if orig.ReversalOfID.Valid {
+		return nil, ErrReverseReversal
+	}
+
+	reversed, err := q.JournalEntryIsReversed(ctx, helper.ToNullUUID(orig.ID))
+	if err != nil {
+		return nil, err
+	}
+	if reversed {
+		return nil, ErrJournalReversed
+	}
+	if err := checkOpenPeriod(ctx, q, arg.InstituteID, arg.TransactionDate); err != nil {
+		return nil, err
+	}
+
+	items, err := listJournalItems(ctx, q, arg.InstituteID, orig.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if arg.ReferenceNo == "" && orig.ReferenceNo.String != "" {
+		arg.ReferenceNo = "REV-" + orig.ReferenceNo.String
+	}
+	if arg.Description == nil {
+		desc := "Reversal of journal " + orig.ID.String()
+		arg.Description = &desc
+	}
+
+	// The reversal stays linked to the document the original was posted for
+	arg.SourceType = nil
+	if orig.SourceType.Valid {
+		source := domain.JournalSource(orig.SourceType.String)
+		arg.SourceType = &source
+	}
+	arg.SourceID = helper.NullUUIDToPtr(orig.SourceID)
+
+	// Reversals skip the account checks so that entries against accounts
+	// deactivated since can still be corrected
+	arg.Items = make([]domain.JournalItem, 0, len(items))
+	for _, item := range items {
+		arg.Items = append(arg.Items, domain.JournalItem{
+			AccountID:    item.AccountID,
+			Debit:        item.Credit,
+			Credit:       item.Debit,
+			Description:  item.Description,
+			DepartmentID: item.DepartmentID,
+		})
+	}
+
+	entry, err := insertJournal(ctx, q, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := markPosted(ctx, q, entry, arg.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	return entry, tx.Commit()
+}
+
+// ========================= GET JOURNAL ENTRY =========================
+
+// SERVICE
+func (s *Service) GetJournalEntry(ctx context.Context, instituteID, id uuid.UUID) (*domain.JournalEntry, error) {
+	return s.repo.GetJournalEntry(ctx, instituteID, id)
+}
+
+// REPOSITORY
+func (r *Repository) GetJournalEntry(ctx context.Context, instituteID, id uuid.UUID) (*domain.JournalEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetJournalEntry(ctx, db.GetJournalEntryParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrJournalNotFound
+		}
+		return nil, err
+	}
 
-	return &arg, nil
+	entry := mapper.MapJournalEntryRowToDomain(row)
+	entry.Items, err = listJournalItems(ctx, q, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
 }
 
 // ========================= GET ACCOUNT BALANCE =========================
 
 // SERVICE
-func (s *Service) GetAccountBalance(ctx context.Context, instituteID, accountID uuid.UUID) (float64, error) {
-	return s.repo.GetAccountBalance(ctx, instituteID, accountID)
+// GetAccountBalance totals the posted items of an account and of every
+// account below it in the chart of accounts
+func (s *Service) GetAccountBalance(ctx context.Context, instituteID, accountID uuid.UUID) (*domain.AccountBalance, error) {
+	accounts, err := s.repo.ListAccounts(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.repo.ListPostedTotals(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *domain.Account
+	for _, acc := range accounts {
+		if acc.ID == accountID {
+			target = acc
+		}
+	}
+	if target == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	t := newAccountTree(accounts).rollup(newLedger(totals))[accountID]
+
+	return &domain.AccountBalance{
+		AccountID: target.ID,
+		Code:      target.Code,
+		Name:      target.Name,
+		Type:      target.Type,
+		Debit:     fromPaise(t.debit),
+		Credit:    fromPaise(t.credit),
+		Balance:   fromPaise(normalBalance(target.Type, t)),
+	}, nil
 }
 
 // REPOSITORY
-func (r *Repository) GetAccountBalance(ctx context.Context, instituteID, accountID uuid.UUID) (float64, error) {
-	var balance float64
-	return balance, nil
+func (r *Repository) ListPostedTotals(ctx context.Context, instituteID uuid.UUID) ([]*domain.AccountBalance, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.SumPostedJournalItemsByAccount(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make([]*domain.AccountBalance, 0, len(rows))
+	for _, row := range rows {
+		t := mapper.MapPostedTotalsRowToDomain(row)
+		totals = append(totals, &t)
+	}
+	return totals, nil
+}
+
+// ========================= LEDGER HELPERS =========================
+
+// checkJournalItem requires an account and exactly one positive side
+func checkJournalItem(item domain.JournalItem) error {
+	debit, credit := toPaise(item.Debit), toPaise(item.Credit)
+	if item.AccountID == uuid.Nil || debit < 0 || credit < 0 || (debit > 0) == (credit > 0) {
+		return ErrInvalidJournalItem
+	}
+	return nil
+}
+
+// checkBalanced validates every item and requires equal debits and credits
+func checkBalanced(items []domain.JournalItem) error {
+	if len(items) < 2 {
+		return ErrJournalTooFewItems
+	}
+
+	var debit, credit int64
+	for i, item := range items {
+		if err := checkJournalItem(item); err != nil {
+			return fmt.Errorf("%w (item %d)", err, i+1)
+		}
+		debit += toPaise(item.Debit)
+		credit += toPaise(item.Credit)
+	}
+	if debit != credit {
+		return fmt.Errorf("%w: debits %.2f, credits %.2f", ErrUnbalancedJournal, fromPaise(debit), fromPaise(credit))
+	}
+	return nil
+}
+
+// checkPostingAccounts locks the accounts items post to and rejects
+// unknown, inactive and parent accounts
+func checkPostingAccounts(ctx context.Context, q *db.Queries, instituteID uuid.UUID, items []domain.JournalItem) error {
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		if !helper.Contains(ids, item.AccountID) {
+			ids = append(ids, item.AccountID)
+		}
+	}
+
+	rows, err := q.GetPostingAccounts(ctx, db.GetPostingAccountsParams{
+		InstituteID: instituteID,
+		AccountIds:  ids,
+	})
+	if err != nil {
+		return err
+	}
+
+	found := make(map[uuid.UUID]bool, len(rows))
+	for _, row := range rows {
+		acc := row.FinanceAccount
+		if !acc.IsActive.Bool {
+			return fmt.Errorf("%w: %s", ErrAccountInactive, acc.Code)
+		}
+		if row.HasChildren {
+			return fmt.Errorf("%w: %s", ErrParentAccount, acc.Code)
+		}
+		found[acc.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, id)
+		}
+	}
+	return nil
+}
+
+// insertJournal writes an entry as a draft together with its items.
+// Items can only be inserted while the entry is unposted.
+func insertJournal(ctx context.Context, q *db.Queries, arg domain.JournalEntry) (*domain.JournalEntry, error) {
+	arg.IsPosted = false
+
+	row, err := q.CreateJournalEntry(ctx, mapper.MapJournalEntryDomainToParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	entry := mapper.MapJournalEntryRowToDomain(row)
+	entry.Items = make([]domain.JournalItem, 0, len(arg.Items))
+	for _, item := range arg.Items {
+		item.InstituteID = entry.InstituteID
+		item.JournalEntryID = entry.ID
+		item.CreatedBy = arg.CreatedBy
+		item.Debit = fromPaise(toPaise(item.Debit))
+		item.Credit = fromPaise(toPaise(item.Credit))
+
+		itemRow, err := q.CreateJournalItem(ctx, mapper.MapJournalItemDomainToParams(item))
+		if err != nil {
+			return nil, err
+		}
+		entry.Items = append(entry.Items, mapper.MapJournalItemRowToDomain(itemRow))
+	}
+	return &entry, nil
+}
+
+// markPosted locks entry; the database refuses later changes to it or its items.
+// Expense lines carrying a department count against its budget from here.
+func markPosted(ctx context.Context, q *db.Queries, entry *domain.JournalEntry, postedBy *uuid.UUID) error {
+	row, err := q.MarkJournalEntryPosted(ctx, db.MarkJournalEntryPostedParams{
+		PostedBy:    helper.ToNullUUID(helper.DerefUUID(postedBy)),
+		ID:          entry.ID,
+		InstituteID: entry.InstituteID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrJournalPosted
+		}
+		return err
+	}
+
+	entry.IsPosted = row.IsPosted.Bool
+	entry.PostedAt = helper.NullTimeToPtr(row.PostedAt)
+	return recordJournalSpend(ctx, q, entry)
+}
+
+// checkOpenPeriod rejects dates in a closed fiscal year. The share lock it
+// takes keeps a year from being closed until the posting commits.
+func checkOpenPeriod(ctx context.Context, q *db.Queries, instituteID uuid.UUID, date time.Time) error {
+	if err := q.LockLedgerForPosting(ctx, instituteID); err != nil {
+		return err
+	}
+
+	closedTo, err := q.GetLastClosedFiscalYearEnd(ctx, instituteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return checkAfterClose(date, closedTo)
+}
+
+// checkAfterClose requires date to fall after the last closed year end
+func checkAfterClose(date, closedTo time.Time) error {
+	if !dateOnly(date).After(closedTo) {
+		return fmt.Errorf("%w: books are closed up to %s", ErrPeriodClosed, closedTo.Format(helper.DateLayout))
+	}
+	return nil
+}
+
+func lockJournalEntry(ctx context.Context, q *db.Queries, instituteID, id uuid.UUID) (db.FinanceJournalEntry, error) {
+	row, err := q.GetJournalEntryForUpdate(ctx, db.GetJournalEntryForUpdateParams{ID: id, InstituteID: instituteID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return row, ErrJournalNotFound
+	}
+	return row, err
+}
+
+func listJournalItems(ctx context.Context, q *db.Queries, instituteID, entryID uuid.UUID) ([]domain.JournalItem, error) {
+	rows, err := q.ListJournalItems(ctx, db.ListJournalItemsParams{
+		JournalEntryID: entryID,
+		InstituteID:    instituteID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]domain.JournalItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, mapper.MapJournalItemRowToDomain(row))
+	}
+	return items, nil
+}
+
+// toPaise converts a rupee amount to whole paise for exact comparisons
+func toPaise(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func fromPaise(v int64) float64 {
+	return float64(v) / 100
+}
+
+// sumPaise reads an amount scanned as text, such as a SUM(...)::text
+// column, in paise. NULL reads as zero.
+func sumPaise(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", v, err)
+	}
+	return toPaise(amount), nil
+}
+
+// paiseReader reads several amounts with sumPaise, keeping the first error
+type paiseReader struct{ err error }
+
+func (r *paiseReader) read(v string) int64 {
+	p, err := sumPaise(v)
+	if r.err == nil {
+		r.err = err
+	}
+	return p
 }
@@ -0,0 +1,82 @@
+package finance
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"swiftschool/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestCheckBalanced(t *testing.T) {
+	cash, fees := uuid.New(), uuid.New()
+	line := func(account uuid.UUID, debit, credit float64) domain.JournalItem {
+		return domain.JournalItem{AccountID: account, Debit: debit, Credit: credit}
+	}
+
+	tests := []struct {
+		name    string
+		items   []domain.JournalItem
+		wantErr error
+	}{
+		{"balanced", []domain.JournalItem{line(cash, 1000, 0), line(fees, 0, 1000)}, nil},
+		{"balanced after rounding", []domain.JournalItem{line(cash, 0.1, 0), line(cash, 0.2, 0), line(fees, 0, 0.3)}, nil},
+		{"unbalanced", []domain.JournalItem{line(cash, 1000, 0), line(fees, 0, 999.99)}, ErrUnbalancedJournal},
+		{"single item", []domain.JournalItem{line(cash, 1000, 0)}, ErrJournalTooFewItems},
+		{"both sides on one item", []domain.JournalItem{line(cash, 10, 10), line(fees, 0, 0)}, ErrInvalidJournalItem},
+		{"negative amount", []domain.JournalItem{line(cash, -10, 0), line(fees, 0, -10)}, ErrInvalidJournalItem},
+		{"missing account", []domain.JournalItem{line(uuid.Nil, 10, 0), line(fees, 0, 10)}, ErrInvalidJournalItem},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkBalanced(tt.items); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkBalanced error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckAfterClose(t *testing.T) {
+	closedTo := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		date    time.Time
+		wantErr error
+	}{
+		{"inside closed year", time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), ErrPeriodClosed},
+		{"last closed day", closedTo, ErrPeriodClosed},
+		{"last closed day with time", time.Date(2026, 3, 31, 18, 30, 0, 0, time.UTC), ErrPeriodClosed},
+		{"first open day", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkAfterClose(tt.date, closedTo); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkAfterClose error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSumPaise(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"1250.50", 125050, false},
+		{"-12.345", -1235, false},
+		{"0.005", 1, false},
+		{"12,50", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := sumPaise(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("sumPaise(%q) = (%d, %v), want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,1059 @@
+package finance
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidBankAccount       = errors.New("bank account needs an account name and an account number")
+	ErrBankAccountNotFound      = errors.New("bank account not found")
+	ErrInvalidStatementImport   = errors.New("statement import needs a bank account, a format of csv, mt940 or camt053 and the file content")
+	ErrStatementEntryNotFound   = errors.New("statement line not found")
+	ErrStatementEntryReconciled = errors.New("statement line is already reconciled")
+	ErrStatementEntryOpen       = errors.New("statement line is not reconciled")
+	ErrInvalidStatementMatch    = errors.New("a statement line reconciled without a transaction needs remarks")
+	ErrTransactionNotBankable   = errors.New("transaction does not reach the bank or is already matched to a statement line")
+	ErrMatchAmountMismatch      = errors.New("a transaction can only be matched to a deposit of the same amount")
+)
+
+var statementFormats = []helper.StatementFormat{
+	helper.StatementCSV,
+	helper.StatementMT940,
+	helper.StatementCAMT053,
+}
+
+// Deposits are matched to fee payments made up to matchLagDays before the
+// line, cheques and gateway settlements taking a few days to reach the bank,
+// or up to matchLeadDays after it, for payments entered late
+const (
+	matchLagDays  = 7
+	matchLeadDays = 2
+)
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) CreateBankAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.BankAccount
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.CreateBankAccount(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create bank account: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "bank account created successfully", data)
+}
+
+func (h *Handler) ListBankAccounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListBankAccounts(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to list bank accounts: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "bank accounts fetched successfully", data)
+}
+
+func (h *Handler) ImportBankStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.BankStatementImport
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.ImportBankStatement(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to import statement: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "statement imported", data)
+}
+
+func (h *Handler) ListBankStatementImports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	accountID, err := helper.ParseRequiredUUIDFromQuery(r, "bank_account_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid bank_account_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListBankStatementImports(r.Context(), inst, accountID)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to list statement imports: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "statement imports fetched successfully", data)
+}
+
+func (h *Handler) ListBankStatementEntries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	accountID, err := helper.ParseRequiredUUIDFromQuery(r, "bank_account_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid bank_account_id: "+err.Error())
+		return
+	}
+
+	var f StatementEntryFilter
+	if f.From, err = helper.ParseDateFromQuery(r, "from"); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid from: "+err.Error())
+		return
+	}
+	if f.To, err = helper.ParseDateFromQuery(r, "to"); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid to: "+err.Error())
+		return
+	}
+	switch r.URL.Query().Get("reconciled") {
+	case "":
+	case "true", "false":
+		reconciled := r.URL.Query().Get("reconciled") == "true"
+		f.Reconciled = &reconciled
+	default:
+		helper.NewErrorResponse(w, http.StatusBadRequest, "reconciled must be true or false")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListBankStatementEntries(r.Context(), inst, accountID, f)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to list statement lines: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "statement lines fetched successfully", data)
+}
+
+func (h *Handler) AutoMatchBankStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	accountID, err := helper.ParseRequiredUUIDFromQuery(r, "bank_account_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid bank_account_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	matched, err := h.service.AutoMatchBankStatement(r.Context(), inst, accountID)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to match statement: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "statement matched", map[string]int{"lines_matched": matched})
+}
+
+func (h *Handler) MatchStatementEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.StatementMatch
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.MatchedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.MatchStatementEntry(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to match statement line: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "statement line reconciled", data)
+}
+
+func (h *Handler) UnmatchStatementEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "entry_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid entry_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.UnmatchStatementEntry(r.Context(), inst, id)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to unmatch statement line: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "statement line unmatched", data)
+}
+
+func (h *Handler) BankReconciliation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	accountID, err := helper.ParseRequiredUUIDFromQuery(r, "bank_account_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid bank_account_id: "+err.Error())
+		return
+	}
+
+	asOf, err := helper.ParseDateFromQuery(r, "as_of")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid as_of: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.BankReconciliation(r.Context(), inst, accountID, asOf)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to reconcile bank account: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "bank reconciliation generated", data)
+}
+
+// ========================= BANK ACCOUNTS =========================
+
+// SERVICE
+func (s *Service) CreateBankAccount(ctx context.Context, arg domain.BankAccount) (*domain.BankAccount, error) {
+	if helper.StrOrEmpty(arg.AccountName) == "" || helper.StrOrEmpty(arg.AccountNumber) == "" {
+		return nil, ErrInvalidBankAccount
+	}
+	return s.repo.CreateBankAccount(ctx, arg)
+}
+
+func (s *Service) ListBankAccounts(ctx context.Context, instituteID uuid.UUID) ([]*domain.BankAccount, error) {
+	return s.repo.ListBankAccounts(ctx, instituteID)
+}
+
+// REPOSITORY
+func (r *Repository) CreateBankAccount(ctx context.Context, arg domain.BankAccount) (*domain.BankAccount, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	if arg.LedgerAccountID != nil {
+		acc, err := q.GetAccount(ctx, db.GetAccountParams{ID: *arg.LedgerAccountID, InstituteID: arg.InstituteID})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: ledger account %s", ErrAccountNotFound, *arg.LedgerAccountID)
+			}
+			return nil, err
+		}
+		if domain.AccountType(acc.Type) != domain.AccAsset {
+			return nil, fmt.Errorf("%w: bank account %s is %s", ErrLedgerAccountType, acc.Code, acc.Type)
+		}
+	}
+
+	row, err := q.CreateBankAccount(ctx, mapper.MapBankAccountDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bank account: %w", err)
+	}
+
+	account := mapper.MapBankAccountRowToDomain(row)
+	return &account, nil
+}
+
+func (r *Repository) ListBankAccounts(ctx context.Context, instituteID uuid.UUID) ([]*domain.BankAccount, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListBankAccounts(ctx, instituteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bank accounts: %w", err)
+	}
+
+	accounts := make([]*domain.BankAccount, 0, len(rows))
+	for _, row := range rows {
+		acc := mapper.MapBankAccountRowToDomain(row)
+		accounts = append(accounts, &acc)
+	}
+	return accounts, nil
+}
+
+// bankLedger resolves the ledger account a bank account posts to and
+// whether fee payments are banked there. Payments post to the ledger
+// settings' bank account, so only the bank accounts behind it can be
+// matched against them.
+func bankLedger(ctx context.Context, q *db.Queries, account db.FinanceBankAccount) (uuid.UUID, bool, error) {
+	settings, err := q.GetLedgerSettings(ctx, account.InstituteID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, false, err
+	}
+	configured := err == nil
+
+	switch {
+	case account.LedgerAccountID.Valid:
+		return account.LedgerAccountID.UUID, configured && account.LedgerAccountID.UUID == settings.BankAccountID, nil
+	case configured:
+		return settings.BankAccountID, true, nil
+	default:
+		return uuid.Nil, false, ErrLedgerNotConfigured
+	}
+}
+
+// ========================= STATEMENT IMPORT =========================
+
+type StatementEntryFilter struct {
+	From       *time.Time
+	To         *time.Time
+	Reconciled *bool
+}
+
+// SERVICE
+// ImportBankStatement reads a statement file and adds its lines to the
+// bank account, skipping lines imported before, then matches the new
+// deposits to fee payments where it can
+func (s *Service) ImportBankStatement(ctx context.Context, arg domain.BankStatementImport) (*domain.BankStatementImport, error) {
+	format := helper.StatementFormat(arg.Format)
+	if arg.BankAccountID == uuid.Nil || !helper.Contains(statementFormats, format) || strings.TrimSpace(arg.Content) == "" {
+		return nil, ErrInvalidStatementImport
+	}
+
+	lines, err := helper.ParseBankStatement(format, arg.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	imp, err := s.repo.ImportBankStatement(ctx, arg, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("statement %s for bank account %s: %d lines, %d new, %d duplicate, %d matched",
+		imp.ID, imp.BankAccountID, imp.LinesTotal, imp.LinesImported, imp.LinesDuplicate, imp.LinesMatched)
+	return imp, nil
+}
+
+func (s *Service) ListBankStatementImports(ctx context.Context, instituteID, bankAccountID uuid.UUID) ([]*domain.BankStatementImport, error) {
+	return s.repo.ListBankStatementImports(ctx, instituteID, bankAccountID)
+}
+
+func (s *Service) ListBankStatementEntries(ctx context.Context, instituteID, bankAccountID uuid.UUID, f StatementEntryFilter) ([]*domain.BankStatementEntry, error) {
+	return s.repo.ListBankStatementEntries(ctx, instituteID, bankAccountID, f)
+}
+
+// REPOSITORY
+func (r *Repository) ImportBankStatement(ctx context.Context, arg domain.BankStatementImport, lines []helper.StatementLine) (*domain.BankStatementImport, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	account, err := lockBankAccount(ctx, q, arg.InstituteID, arg.BankAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	imp, err := q.CreateBankStatementImport(ctx, db.CreateBankStatementImportParams{
+		InstituteID:   arg.InstituteID,
+		BankAccountID: arg.BankAccountID,
+		Format:        arg.Format,
+		FileName:      helper.ToNullString(helper.StrOrEmpty(arg.FileName)),
+		LinesTotal:    int32(len(lines)),
+		CreatedBy:     helper.ToNullUUID(helper.DerefUUID(arg.CreatedBy)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record import: %w", err)
+	}
+
+	var imported, duplicate int32
+	for i, hash := range statementLineHashes(lines) {
+		line := lines[i]
+		params := db.CreateBankStatementEntryParams{
+			InstituteID:      arg.InstituteID,
+			BankAccountID:    helper.ToNullUUID(arg.BankAccountID),
+			ImportID:         helper.ToNullUUID(imp.ID),
+			LineNo:           int32(i + 1),
+			TransactionDate:  helper.ToNullTime(line.Date),
+			ValueDate:        helper.ToNullTime(helper.TimeOrZero(line.ValueDate)),
+			Description:      helper.ToNullString(line.Description),
+			Reference:        helper.ToNullString(line.Reference),
+			WithdrawalAmount: sql.NullString{String: fmt.Sprintf("%.2f", line.Withdrawal), Valid: true},
+			DepositAmount:    sql.NullString{String: fmt.Sprintf("%.2f", line.Deposit), Valid: true},
+			LineHash:         helper.ToNullString(hash),
+		}
+		if line.Balance != nil {
+			params.Balance = sql.NullString{String: fmt.Sprintf("%.2f", *line.Balance), Valid: true}
+		}
+
+		if _, err := q.CreateBankStatementEntry(ctx, params); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				duplicate++
+				continue
+			}
+			return nil, fmt.Errorf("failed to save statement line %d: %w", i+1, err)
+		}
+		imported++
+	}
+
+	matched, err := autoMatchStatement(ctx, q, account)
+	if err != nil {
+		return nil, err
+	}
+	if err := refreshBankBalance(ctx, q, account); err != nil {
+		return nil, err
+	}
+
+	imp, err = q.SetBankStatementImportCounts(ctx, db.SetBankStatementImportCountsParams{
+		LinesImported:  imported,
+		LinesDuplicate: duplicate,
+		LinesMatched:   int32(matched),
+		ID:             imp.ID,
+		InstituteID:    arg.InstituteID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := mapper.MapBankStatementImportRowToDomain(imp)
+	return &result, tx.Commit()
+}
+
+func (r *Repository) ListBankStatementImports(ctx context.Context, instituteID, bankAccountID uuid.UUID) ([]*domain.BankStatementImport, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListBankStatementImports(ctx, db.ListBankStatementImportsParams{BankAccountID: bankAccountID, InstituteID: instituteID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement imports: %w", err)
+	}
+
+	imports := make([]*domain.BankStatementImport, 0, len(rows))
+	for _, row := range rows {
+		imp := mapper.MapBankStatementImportRowToDomain(row)
+		imports = append(imports, &imp)
+	}
+	return imports, nil
+}
+
+func (r *Repository) ListBankStatementEntries(ctx context.Context, instituteID, bankAccountID uuid.UUID, f StatementEntryFilter) ([]*domain.BankStatementEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := listStatementEntries(ctx, q, instituteID, bankAccountID, f)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.BankStatementEntry, 0, len(entries))
+	for i := range entries {
+		result = append(result, &entries[i])
+	}
+	return result, nil
+}
+
+func listStatementEntries(ctx context.Context, q *db.Queries, instituteID, bankAccountID uuid.UUID, f StatementEntryFilter) ([]domain.BankStatementEntry, error) {
+	params := db.ListBankStatementEntriesParams{
+		BankAccountID: helper.ToNullUUID(bankAccountID),
+		InstituteID:   instituteID,
+		FromDate:      helper.ToNullTime(helper.TimeOrZero(f.From)),
+		ToDate:        helper.ToNullTime(helper.TimeOrZero(f.To)),
+	}
+	if f.Reconciled != nil {
+		params.IsReconciled = sql.NullBool{Bool: *f.Reconciled, Valid: true}
+	}
+
+	rows, err := q.ListBankStatementEntries(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement lines: %w", err)
+	}
+
+	entries := make([]domain.BankStatementEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, mapper.MapBankStatementEntryRowToDomain(row))
+	}
+	return entries, nil
+}
+
+func lockBankAccount(ctx context.Context, q *db.Queries, instituteID, id uuid.UUID) (db.FinanceBankAccount, error) {
+	row, err := q.LockBankAccount(ctx, db.LockBankAccountParams{ID: id, InstituteID: instituteID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return row, ErrBankAccountNotFound
+	}
+	return row, err
+}
+
+// statementLineHashes identifies each line by its contents and by how many
+// identical lines precede it in the file, so that two equal deposits on
+// one day stay two lines while a re-imported statement adds nothing
+func statementLineHashes(lines []helper.StatementLine) []string {
+	seen := make(map[string]int, len(lines))
+	hashes := make([]string, len(lines))
+	for i, l := range lines {
+		key := strings.Join([]string{
+			l.Date.Format(helper.DateLayout),
+			fmt.Sprint(toPaise(l.Withdrawal)),
+			fmt.Sprint(toPaise(l.Deposit)),
+			strings.ToUpper(strings.TrimSpace(l.Reference)),
+			strings.ToUpper(strings.Join(strings.Fields(l.Description), " ")),
+		}, "|")
+		seen[key]++
+
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, seen[key])))
+		hashes[i] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
+// statementBalance is the bank balance after the last of lines, which are
+// in statement order: the last balance printed by the bank moved by the
+// lines after it, or the opening balance moved by every line
+func statementBalance(opening float64, lines []domain.BankStatementEntry) float64 {
+	bal := toPaise(opening)
+	for _, l := range lines {
+		if l.Balance != nil {
+			bal = toPaise(*l.Balance)
+			continue
+		}
+		bal += toPaise(l.DepositAmount) - toPaise(l.WithdrawalAmount)
+	}
+	return fromPaise(bal)
+}
+
+func refreshBankBalance(ctx context.Context, q *db.Queries, account db.FinanceBankAccount) error {
+	lines, err := listStatementEntries(ctx, q, account.InstituteID, account.ID, StatementEntryFilter{})
+	if err != nil {
+		return err
+	}
+
+	return q.SetBankAccountBalance(ctx, db.SetBankAccountBalanceParams{
+		CurrentBalance: sql.NullString{String: fmt.Sprintf("%.2f", statementBalance(helper.NullNumericToValue(account.OpeningBalance), lines)), Valid: true},
+		ID:             account.ID,
+		InstituteID:    account.InstituteID,
+	})
+}
+
+// ========================= MATCHING =========================
+
+// SERVICE
+// AutoMatchBankStatement matches the bank account's open deposits to fee
+// payments again, e.g. after payments were entered late
+func (s *Service) AutoMatchBankStatement(ctx context.Context, instituteID, bankAccountID uuid.UUID) (int, error) {
+	matched, err := s.repo.AutoMatchBankStatement(ctx, instituteID, bankAccountID)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Infof("bank account %s: %d statement lines matched", bankAccountID, matched)
+	return matched, nil
+}
+
+// MatchStatementEntry reconciles a line the matcher left open, against a
+// fee payment of the same amount or, with remarks, on its own
+func (s *Service) MatchStatementEntry(ctx context.Context, arg domain.StatementMatch) (*domain.BankStatementEntry, error) {
+	if arg.EntryID == uuid.Nil || (arg.TransactionID == nil && strings.TrimSpace(helper.StrOrEmpty(arg.Remarks)) == "") {
+		return nil, ErrInvalidStatementMatch
+	}
+	return s.repo.MatchStatementEntry(ctx, arg)
+}
+
+func (s *Service) UnmatchStatementEntry(ctx context.Context, instituteID, id uuid.UUID) (*domain.BankStatementEntry, error) {
+	return s.repo.UnmatchStatementEntry(ctx, instituteID, id)
+}
+
+// REPOSITORY
+func (r *Repository) AutoMatchBankStatement(ctx context.Context, instituteID, bankAccountID uuid.UUID) (int, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	account, err := lockBankAccount(ctx, q, instituteID, bankAccountID)
+	if err != nil {
+		return 0, err
+	}
+
+	matched, err := autoMatchStatement(ctx, q, account)
+	if err != nil {
+		return 0, err
+	}
+	return matched, tx.Commit()
+}
+
+func (r *Repository) MatchStatementEntry(ctx context.Context, arg domain.StatementMatch) (*domain.BankStatementEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	row, err := q.GetBankStatementEntryForUpdate(ctx, db.GetBankStatementEntryForUpdateParams{ID: arg.EntryID, InstituteID: arg.InstituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrStatementEntryNotFound
+		}
+		return nil, err
+	}
+	if row.IsReconciled.Bool {
+		return nil, ErrStatementEntryReconciled
+	}
+
+	if arg.TransactionID != nil {
+		txn, err := q.GetBankTransactionForMatch(ctx, db.GetBankTransactionForMatchParams{ID: *arg.TransactionID, InstituteID: arg.InstituteID})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrTransactionNotBankable
+			}
+			return nil, err
+		}
+		entry := mapper.MapBankStatementEntryRowToDomain(row)
+		if toPaise(entry.DepositAmount) != toPaise(mapper.MapTransactionRowToDomain(txn).Amount) {
+			return nil, ErrMatchAmountMismatch
+		}
+	}
+
+	row, err = q.ReconcileBankStatementEntry(ctx, db.ReconcileBankStatementEntryParams{
+		TransactionID: helper.ToNullUUID(helper.DerefUUID(arg.TransactionID)),
+		MatchMethod:   helper.ToNullString(string(domain.MatchManual)),
+		MatchRemarks:  helper.ToNullString(helper.StrOrEmpty(arg.Remarks)),
+		MatchedBy:     helper.ToNullUUID(helper.DerefUUID(arg.MatchedBy)),
+		ID:            arg.EntryID,
+		InstituteID:   arg.InstituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile statement line: %w", err)
+	}
+
+	entry := mapper.MapBankStatementEntryRowToDomain(row)
+	return &entry, tx.Commit()
+}
+
+func (r *Repository) UnmatchStatementEntry(ctx context.Context, instituteID, id uuid.UUID) (*domain.BankStatementEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	row, err := q.GetBankStatementEntryForUpdate(ctx, db.GetBankStatementEntryForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrStatementEntryNotFound
+		}
+		return nil, err
+	}
+	if !row.IsReconciled.Bool {
+		return nil, ErrStatementEntryOpen
+	}
+
+	row, err = q.UnreconcileBankStatementEntry(ctx, db.UnreconcileBankStatementEntryParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmatch statement line: %w", err)
+	}
+
+	entry := mapper.MapBankStatementEntryRowToDomain(row)
+	return &entry, tx.Commit()
+}
+
+// autoMatchStatement reconciles the open deposits of a locked bank account
+// that matchStatement pairs with fee payments, and returns how many
+func autoMatchStatement(ctx context.Context, q *db.Queries, account db.FinanceBankAccount) (int, error) {
+	_, feesBanked, err := bankLedger(ctx, q, account)
+	if errors.Is(err, ErrLedgerNotConfigured) || (err == nil && !feesBanked) {
+		// Fee payments are not banked here, so there is nothing to match
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	open := false
+	lines, err := listStatementEntries(ctx, q, account.InstituteID, account.ID, StatementEntryFilter{Reconciled: &open})
+	if err != nil {
+		return 0, err
+	}
+
+	var from, to time.Time
+	for _, l := range lines {
+		if l.TransactionDate == nil {
+			continue
+		}
+		if from.IsZero() || l.TransactionDate.Before(from) {
+			from = *l.TransactionDate
+		}
+		if l.TransactionDate.After(to) {
+			to = *l.TransactionDate
+		}
+	}
+	if from.IsZero() {
+		return 0, nil
+	}
+	rows, err := q.ListUnreconciledBankTransactions(ctx, db.ListUnreconciledBankTransactionsParams{
+		InstituteID: account.InstituteID,
+		FromDate:    from.AddDate(0, 0, -matchLagDays),
+		ToDate:      to.AddDate(0, 0, matchLeadDays),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list payments: %w", err)
+	}
+	txns := make([]domain.Transaction, 0, len(rows))
+	for _, row := range rows {
+		txns = append(txns, mapper.MapTransactionRowToDomain(row))
+	}
+
+	matches := matchStatement(lines, txns)
+	for _, m := range matches {
+		if _, err := q.ReconcileBankStatementEntry(ctx, db.ReconcileBankStatementEntryParams{
+			TransactionID: helper.ToNullUUID(m.transactionID),
+			MatchMethod:   helper.ToNullString(string(domain.MatchAuto)),
+			ID:            m.entryID,
+			InstituteID:   account.InstituteID,
+		}); err != nil {
+			return 0, fmt.Errorf("failed to reconcile statement line: %w", err)
+		}
+	}
+	return len(matches), nil
+}
+
+type statementMatch struct {
+	entryID       uuid.UUID
+	transactionID uuid.UUID
+}
+
+// matchStatement pairs open deposits with fee payments of the same amount
+// made within the matching window. A payment whose cheque number or
+// reference shows on the line is taken first, the closest in date when
+// there are several. Otherwise a line and a payment are paired only when
+// each is the other's sole candidate, leaving ambiguous ones to be matched
+// by hand.
+func matchStatement(lines []domain.BankStatementEntry, txns []domain.Transaction) []statementMatch {
+	taken := make(map[int]bool, len(txns))
+	candidates := func(line domain.BankStatementEntry) []int {
+		var found []int
+		for i, t := range txns {
+			if !taken[i] && toPaise(t.Amount) == toPaise(line.DepositAmount) && inMatchWindow(*line.TransactionDate, paidOn(t)) {
+				found = append(found, i)
+			}
+		}
+		return found
+	}
+
+	var open []int
+	for i, l := range lines {
+		if !l.IsReconciled && l.TransactionDate != nil && toPaise(l.DepositAmount) > 0 {
+			open = append(open, i)
+		}
+	}
+
+	var matches []statementMatch
+	done := make(map[int]bool, len(open))
+	for _, li := range open {
+		line := lines[li]
+		best := -1
+		for _, ti := range candidates(line) {
+			if !referenceOnLine(line, txns[ti]) {
+				continue
+			}
+			if best < 0 || dayGap(*line.TransactionDate, paidOn(txns[ti])) < dayGap(*line.TransactionDate, paidOn(txns[best])) {
+				best = ti
+			}
+		}
+		if best >= 0 {
+			taken[best], done[li] = true, true
+			matches = append(matches, statementMatch{entryID: line.ID, transactionID: txns[best].ID})
+		}
+	}
+
+	byLine := make(map[int][]int)
+	byTxn := make(map[int]int)
+	for _, li := range open {
+		if done[li] {
+			continue
+		}
+		byLine[li] = candidates(lines[li])
+		for _, ti := range byLine[li] {
+			byTxn[ti]++
+		}
+	}
+	for _, li := range open {
+		if c := byLine[li]; len(c) == 1 && byTxn[c[0]] == 1 {
+			matches = append(matches, statementMatch{entryID: lines[li].ID, transactionID: txns[c[0]].ID})
+		}
+	}
+	return matches
+}
+
+// referenceOnLine reports whether the payment's cheque number or reference
+// appears in the line's reference or description. Short references are
+// ignored as they turn up by chance.
+func referenceOnLine(line domain.BankStatementEntry, t domain.Transaction) bool {
+	text := strings.ToUpper(helper.StrOrEmpty(line.Reference) + " " + helper.StrOrEmpty(line.Description))
+	for _, ref := range []*string{t.ChequeNo, t.TransactionRefNo} {
+		if r := strings.ToUpper(strings.TrimSpace(helper.StrOrEmpty(ref))); len(r) >= 4 && strings.Contains(text, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func paidOn(t domain.Transaction) time.Time {
+	if t.PaymentDate != nil {
+		return dateOnly(*t.PaymentDate)
+	}
+	return dateOnly(t.CreatedAt)
+}
+
+func inMatchWindow(lineDate, paid time.Time) bool {
+	d := dateOnly(lineDate)
+	return !d.Before(paid.AddDate(0, 0, -matchLeadDays)) && !d.After(paid.AddDate(0, 0, matchLagDays))
+}
+
+func dayGap(a, b time.Time) time.Duration {
+	gap := dateOnly(a).Sub(dateOnly(b))
+	if gap < 0 {
+		return -gap
+	}
+	return gap
+}
+
+// ========================= RECONCILIATION REPORT =========================
+
+// SERVICE
+// BankReconciliation compares the statement and ledger balances of a bank
+// account on a date, today when none is given
+func (s *Service) BankReconciliation(ctx context.Context, instituteID, bankAccountID uuid.UUID, asOf *time.Time) (*domain.BankReconciliation, error) {
+	date := dateOnly(time.Now())
+	if asOf != nil {
+		date = dateOnly(*asOf)
+	}
+	return s.repo.BankReconciliation(ctx, instituteID, bankAccountID, date)
+}
+
+// REPOSITORY
+func (r *Repository) BankReconciliation(ctx context.Context, instituteID, bankAccountID uuid.UUID, asOf time.Time) (*domain.BankReconciliation, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := q.GetBankAccount(ctx, db.GetBankAccountParams{ID: bankAccountID, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBankAccountNotFound
+		}
+		return nil, err
+	}
+	ledgerID, feesBanked, err := bankLedger(ctx, q, account)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &domain.BankReconciliation{
+		BankAccountID:     bankAccountID,
+		LedgerAccountID:   ledgerID,
+		AsOf:              asOf,
+		DepositsInTransit: []domain.Transaction{},
+		UnrecordedLines:   []domain.BankStatementEntry{},
+	}
+
+	lines, err := listStatementEntries(ctx, q, instituteID, bankAccountID, StatementEntryFilter{To: &asOf})
+	if err != nil {
+		return nil, err
+	}
+	statement := toPaise(statementBalance(helper.NullNumericToValue(account.OpeningBalance), lines))
+
+	totals, err := q.SumPostedJournalItemsInRange(ctx, db.SumPostedJournalItemsInRangeParams{
+		InstituteID:    instituteID,
+		ToDate:         asOf,
+		IncludeClosing: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var book int64
+	for _, row := range totals {
+		if row.AccountID == ledgerID {
+			t := mapper.MapRangeTotalsRowToDomain(row)
+			book = toPaise(t.Debit) - toPaise(t.Credit)
+		}
+	}
+
+	var transit int64
+	if feesBanked {
+		rows, err := q.ListBankTransactionsInTransit(ctx, db.ListBankTransactionsInTransitParams{InstituteID: instituteID, AsOf: asOf})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			t := mapper.MapTransactionRowToDomain(row)
+			transit += toPaise(t.Amount)
+			rec.DepositsInTransit = append(rec.DepositsInTransit, t)
+		}
+	}
+
+	rows, err := q.ListOutstandingStatementEntries(ctx, db.ListOutstandingStatementEntriesParams{
+		BankAccountID: helper.ToNullUUID(bankAccountID),
+		InstituteID:   instituteID,
+		AsOf:          asOf,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var deposits, withdrawals int64
+	for _, row := range rows {
+		e := mapper.MapBankStatementEntryRowToDomain(row)
+		deposits += toPaise(e.DepositAmount)
+		withdrawals += toPaise(e.WithdrawalAmount)
+		rec.UnrecordedLines = append(rec.UnrecordedLines, e)
+	}
+
+	rec.StatementBalance = fromPaise(statement)
+	rec.BookBalance = fromPaise(book)
+	rec.TotalInTransit = fromPaise(transit)
+	rec.UnrecordedDeposits = fromPaise(deposits)
+	rec.UnrecordedWithdrawals = fromPaise(withdrawals)
+	rec.AdjustedStatement = fromPaise(statement + transit)
+	rec.AdjustedBook = fromPaise(book + deposits - withdrawals)
+	rec.Difference = fromPaise(statement + transit - (book + deposits - withdrawals))
+	return rec, nil
+}
@@ -0,0 +1,393 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidInvoiceRun       = errors.New("an invoice run needs an academic session and a billing period in YYYY-MM format")
+	ErrAcademicSessionNotFound = errors.New("academic session not found")
+	ErrPeriodOutsideSession    = errors.New("billing period is outside the academic session")
+)
+
+// billedFee is a generated invoice item of a student with the period its
+// invoice billed
+type billedFee struct {
+	StudentID      uuid.UUID
+	FeeStructureID uuid.UUID
+	FeeHeadID      uuid.UUID
+	BillingPeriod  time.Time
+}
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) GenerateInvoices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.InvoiceRun
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.GenerateInvoices(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to generate invoices: "+err.Error())
+		return
+	}
+
+	if req.DryRun {
+		helper.NewSuccessResponse(w, http.StatusOK, "invoice preview generated successfully", data)
+		return
+	}
+	helper.NewSuccessResponse(w, http.StatusCreated, "invoices generated successfully", data)
+}
+
+// ========================= GENERATE INVOICES =========================
+
+// SERVICE
+// GenerateInvoices bills every active student, or those of one class, for
+// a month of an academic session. Each student gets one invoice holding the
+// session's fee structures that fall due: monthly fees every month,
+// quarterly fees once in each quarter counted from the session start,
+// yearly fees once in the session and one-time fees once per fee head.
+// Items are discounted by the concessions the student holds for the month.
+// Students already invoiced for the month are skipped, so a run that
+// stopped on an error can simply be repeated. A dry run returns the same
+// invoices without saving them.
+func (s *Service) GenerateInvoices(ctx context.Context, arg domain.InvoiceRun) (*domain.InvoiceRunResult, error) {
+	period, err := time.Parse("2006-01", strings.TrimSpace(arg.BillingPeriod))
+	if err != nil || arg.AcademicSessionID == uuid.Nil {
+		return nil, ErrInvalidInvoiceRun
+	}
+
+	session, err := s.repo.GetAcademicSession(ctx, arg.InstituteID, arg.AcademicSessionID)
+	if err != nil {
+		return nil, err
+	}
+	plan := billingPlan{
+		session: session,
+		start:   monthStart(session.StartDate),
+		end:     monthStart(session.EndDate).AddDate(0, 1, 0),
+		period:  period,
+		heads:   map[uuid.UUID]string{},
+	}
+	if period.Before(plan.start) || !period.Before(plan.end) {
+		return nil, ErrPeriodOutsideSession
+	}
+
+	if plan.structures, err = s.repo.ListFeeStructures(ctx, arg.InstituteID, arg.AcademicSessionID); err != nil {
+		return nil, err
+	}
+	for _, fs := range plan.structures {
+		if !helper.Contains(feeFrequencies, fs.Frequency) {
+			return nil, fmt.Errorf("%w (fee structure %s)", ErrInvalidFeeStructure, fs.ID)
+		}
+	}
+
+	heads, err := s.repo.ListFeeHeads(ctx, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+	for _, fh := range heads {
+		plan.heads[fh.ID] = fh.Name
+	}
+
+	students, err := s.repo.ListStudentsForBilling(ctx, arg.InstituteID, arg.ClassID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(students))
+	for _, st := range students {
+		ids = append(ids, st.ID)
+	}
+	fees, err := s.repo.ListBilledFees(ctx, arg.InstituteID, ids)
+	if err != nil {
+		return nil, err
+	}
+	history := make(map[uuid.UUID][]billedFee, len(students))
+	for _, f := range fees {
+		history[f.StudentID] = append(history[f.StudentID], f)
+	}
+
+	from, to := concessionPeriod(&period)
+	concessions, err := s.studentConcessions(ctx, arg.InstituteID, ids, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	dueDate := period
+	if arg.DueDate != nil {
+		dueDate = *arg.DueDate
+	}
+
+	result := &domain.InvoiceRunResult{
+		AcademicSessionID: arg.AcademicSessionID,
+		BillingPeriod:     period,
+		DryRun:            arg.DryRun,
+		Invoices:          []*domain.Invoice{},
+		Skipped:           []domain.InvoiceRunSkip{},
+	}
+	skip := func(studentID uuid.UUID, reason string) {
+		result.Skipped = append(result.Skipped, domain.InvoiceRunSkip{StudentID: studentID, Reason: reason})
+	}
+
+	var total int64
+	for _, st := range students {
+		if plan.billedInPeriod(history[st.ID]) {
+			skip(st.ID, ErrPeriodBilled.Error())
+			continue
+		}
+		items := plan.items(st, history[st.ID])
+		if len(items) == 0 {
+			skip(st.ID, "no fees fall due in this billing period")
+			continue
+		}
+
+		inv := domain.Invoice{
+			TenantUUIDModel: domain.TenantUUIDModel{
+				BaseUUIDModel: domain.BaseUUIDModel{CreatedBy: arg.CreatedBy},
+				InstituteID:   arg.InstituteID,
+			},
+			StudentID:         st.ID,
+			AcademicSessionID: &arg.AcademicSessionID,
+			DueDate:           &dueDate,
+			BillingPeriod:     &period,
+			Items:             items,
+		}
+		applyConcessions(inv.Items, concessions[st.ID])
+		if err := priceInvoice(&inv); err != nil {
+			return nil, fmt.Errorf("student %s: %w", st.AdmissionNo, err)
+		}
+
+		if !arg.DryRun {
+			saved, err := s.repo.CreateInvoice(ctx, inv)
+			if errors.Is(err, ErrPeriodBilled) {
+				skip(st.ID, err.Error())
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invoice run stopped after %d invoices at student %s: %w", result.InvoiceCount, st.AdmissionNo, err)
+			}
+			inv = *saved
+		}
+
+		result.Invoices = append(result.Invoices, &inv)
+		result.InvoiceCount++
+		total += invoiceDue(inv)
+	}
+	result.TotalAmount = fromPaise(total)
+
+	if !arg.DryRun {
+		logger.Infof("invoice run for %s raised %d invoices, skipped %d students",
+			period.Format("2006-01"), result.InvoiceCount, len(result.Skipped))
+	}
+	return result, nil
+}
+
+// REPOSITORY
+func (r *Repository) GetAcademicSession(ctx context.Context, instituteID, id uuid.UUID) (*domain.AcademicSession, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetAcademicSession(ctx, db.GetAcademicSessionParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAcademicSessionNotFound
+		}
+		return nil, err
+	}
+
+	result := mapper.MapDBAcademicSessionToDomain(row)
+	return &result, nil
+}
+
+// ListStudentsForBilling lists the active students of the institute, or of
+// one class when classID is set
+func (r *Repository) ListStudentsForBilling(ctx context.Context, instituteID uuid.UUID, classID *uuid.UUID) ([]*domain.Student, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListStudentsForBilling(ctx, db.ListStudentsForBillingParams{
+		InstituteID: instituteID,
+		ClassID:     helper.ToNullUUID(helper.DerefUUID(classID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list students: %w", err)
+	}
+
+	students := make([]*domain.Student, 0, len(rows))
+	for _, row := range rows {
+		st := mapper.MapStudentRowToDomain(row)
+		students = append(students, &st)
+	}
+	return students, nil
+}
+
+func (r *Repository) ListBilledFees(ctx context.Context, instituteID uuid.UUID, studentIDs []uuid.UUID) ([]billedFee, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListBilledFeeStructures(ctx, db.ListBilledFeeStructuresParams{
+		InstituteID: instituteID,
+		StudentIds:  studentIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list billed fees: %w", err)
+	}
+
+	fees := make([]billedFee, 0, len(rows))
+	for _, row := range rows {
+		fees = append(fees, billedFee{
+			StudentID:      row.StudentID,
+			FeeStructureID: row.FeeStructureID.UUID,
+			FeeHeadID:      row.FeeHeadID.UUID,
+			BillingPeriod:  row.BillingPeriod.Time,
+		})
+	}
+	return fees, nil
+}
+
+// ========================= BILLING PLAN =========================
+
+// billingPlan expands the fee structures of a session into the invoice
+// items due in one billing period. start and end bound the session's
+// months, end being exclusive.
+type billingPlan struct {
+	session    *domain.AcademicSession
+	start, end time.Time
+	period     time.Time
+	structures []*domain.FeeStructure
+	heads      map[uuid.UUID]string
+}
+
+func (p billingPlan) billedInPeriod(history []billedFee) bool {
+	for _, b := range history {
+		if b.BillingPeriod.Equal(p.period) {
+			return true
+		}
+	}
+	return false
+}
+
+// items lists the fee structures that apply to the student's class and
+// have not been billed yet in the cycle containing the billing period
+func (p billingPlan) items(st *domain.Student, history []billedFee) []domain.InvoiceItem {
+	var items []domain.InvoiceItem
+	for _, fs := range p.structures {
+		if fs.ClassID != nil && (st.CurrentClassID == nil || *fs.ClassID != *st.CurrentClassID) {
+			continue
+		}
+		if p.billed(fs, history) {
+			continue
+		}
+
+		description := p.heads[fs.FeeHeadID]
+		if label := p.label(fs.Frequency); label != "" {
+			description += " - " + label
+		}
+		items = append(items, domain.InvoiceItem{
+			FeeHeadID:      &fs.FeeHeadID,
+			Amount:         fs.Amount,
+			Description:    &description,
+			FeeStructureID: &fs.ID,
+		})
+	}
+	return items
+}
+
+// billed reports whether the student has been billed for fs in its current
+// cycle. One-time fees are matched on the fee head so that they are not
+// billed again in later sessions.
+func (p billingPlan) billed(fs *domain.FeeStructure, history []billedFee) bool {
+	from, to := p.cycle(fs.Frequency)
+	for _, b := range history {
+		if fs.Frequency == domain.FeeOneTime {
+			if b.FeeHeadID == fs.FeeHeadID {
+				return true
+			}
+			continue
+		}
+		if b.FeeStructureID == fs.ID && !b.BillingPeriod.Before(from) && b.BillingPeriod.Before(to) {
+			return true
+		}
+	}
+	return false
+}
+
+// cycle returns the months, end exclusive, that one charge of a fee with
+// the given frequency covers around the billing period
+func (p billingPlan) cycle(freq domain.FeeFrequency) (time.Time, time.Time) {
+	switch freq {
+	case domain.FeeMonthly:
+		return p.period, p.period.AddDate(0, 1, 0)
+	case domain.FeeQuarterly:
+		from := p.start.AddDate(0, monthsBetween(p.start, p.period)/3*3, 0)
+		return from, from.AddDate(0, 3, 0)
+	default:
+		return p.start, p.end
+	}
+}
+
+func (p billingPlan) label(freq domain.FeeFrequency) string {
+	switch freq {
+	case domain.FeeMonthly:
+		return p.period.Format("Jan 2006")
+	case domain.FeeQuarterly:
+		from, to := p.cycle(freq)
+		return from.Format("Jan 2006") + " to " + to.AddDate(0, -1, 0).Format("Jan 2006")
+	case domain.FeeYearly:
+		return p.session.Name
+	default:
+		return ""
+	}
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
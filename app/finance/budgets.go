@@ -0,0 +1,757 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidBudget       = errors.New("a budget needs an academic session, a department, an expense account and an allocation greater than zero")
+	ErrInvalidBudgetLimits = errors.New("budget limits must be between 0 and 1000 percent and the hard limit cannot be below the soft limit")
+	ErrExpenseAccountType  = errors.New("budgets and purchase orders can only be charged to expense accounts")
+	ErrBudgetExists        = errors.New("the department already has a budget for this expense account in the session")
+	ErrBudgetNotFound      = errors.New("budget not found")
+	ErrBudgetHardLimit     = errors.New("purchase order would take the budget past its hard limit")
+)
+
+// Sources of finance.budget_utilisations rows
+const (
+	budgetSourcePurchaseOrder = "purchase_order"
+	budgetSourceJournal       = "journal"
+)
+
+// purchaseApprovalModule identifies purchase orders over a soft limit in core.approvals
+const purchaseApprovalModule = "finance/purchase_orders"
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.Budget
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.CreateBudget(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create budget: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "budget created successfully", data)
+}
+
+func (h *Handler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "budget_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid budget_id: "+err.Error())
+		return
+	}
+
+	var req domain.Budget
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ID = id
+	req.InstituteID = inst
+
+	data, err := h.service.UpdateBudget(r.Context(), req, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to update budget: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "budget updated successfully", data)
+}
+
+func (h *Handler) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	sessionID, departmentID, err := parseBudgetScope(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListBudgets(r.Context(), inst, sessionID, departmentID)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to list budgets: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "budgets fetched successfully", data)
+}
+
+func (h *Handler) BudgetReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	sessionID, departmentID, err := parseBudgetScope(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.BudgetReport(r.Context(), inst, sessionID, departmentID)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to build budget report: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "budget report generated successfully", data)
+}
+
+// parseBudgetScope reads the required academic_session_id and the optional
+// department_id that budget listings are narrowed to
+func parseBudgetScope(r *http.Request) (uuid.UUID, *uuid.UUID, error) {
+	sessionID, err := helper.ParseRequiredUUIDFromQuery(r, "academic_session_id")
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("invalid academic_session_id: %w", err)
+	}
+
+	departmentID, err := helper.ParseUUIDFromQuery(r, "department_id")
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("invalid department_id: %w", err)
+	}
+	if departmentID == uuid.Nil {
+		return sessionID, nil, nil
+	}
+	return sessionID, &departmentID, nil
+}
+
+// ========================= CREATE BUDGET =========================
+
+// SERVICE
+// CreateBudget allocates an amount to a department for one expense account in
+// a session. Without a soft limit, approval is needed past the allocation.
+func (s *Service) CreateBudget(ctx context.Context, arg domain.Budget) (*domain.Budget, error) {
+	if arg.AcademicSessionID == nil || arg.DepartmentID == nil || arg.ExpenseCategoryID == nil || toPaise(arg.AllocatedAmount) <= 0 {
+		return nil, ErrInvalidBudget
+	}
+	if arg.SoftLimitPercent == 0 {
+		arg.SoftLimitPercent = 100
+	}
+	if err := checkBudgetLimits(arg); err != nil {
+		return nil, err
+	}
+	if err := s.checkExpenseAccount(ctx, arg.InstituteID, *arg.ExpenseCategoryID); err != nil {
+		return nil, err
+	}
+
+	budget, err := s.repo.CreateBudget(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("budget %s of %.2f created for department %s", budget.ID, budget.AllocatedAmount, *budget.DepartmentID)
+	return budget, nil
+}
+
+// REPOSITORY
+func (r *Repository) CreateBudget(ctx context.Context, arg domain.Budget) (*domain.Budget, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := q.GetAcademicSession(ctx, db.GetAcademicSessionParams{ID: *arg.AcademicSessionID, InstituteID: arg.InstituteID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAcademicSessionNotFound
+		}
+		return nil, err
+	}
+
+	row, err := q.CreateBudget(ctx, mapper.MapBudgetDomainToParams(arg))
+	if err != nil {
+		if helper.IsPgUniqueViolation(err) {
+			return nil, ErrBudgetExists
+		}
+		return nil, fmt.Errorf("failed to create budget: %w", err)
+	}
+
+	result := mapper.MapBudgetRowToDomain(row)
+	return &result, nil
+}
+
+// ========================= UPDATE BUDGET =========================
+
+// SERVICE
+// UpdateBudget changes the allocation and limits of a budget. What it has
+// already used stays; lowering the allocation below it only stops new orders.
+func (s *Service) UpdateBudget(ctx context.Context, arg domain.Budget, updatedBy *uuid.UUID) (*domain.Budget, error) {
+	if toPaise(arg.AllocatedAmount) <= 0 {
+		return nil, ErrInvalidBudget
+	}
+	if arg.SoftLimitPercent == 0 {
+		arg.SoftLimitPercent = 100
+	}
+	if err := checkBudgetLimits(arg); err != nil {
+		return nil, err
+	}
+	return s.repo.UpdateBudget(ctx, arg, updatedBy)
+}
+
+// REPOSITORY
+func (r *Repository) UpdateBudget(ctx context.Context, arg domain.Budget, updatedBy *uuid.UUID) (*domain.Budget, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if _, err := q.GetBudgetForUpdate(ctx, db.GetBudgetForUpdateParams{ID: arg.ID, InstituteID: arg.InstituteID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBudgetNotFound
+		}
+		return nil, err
+	}
+
+	params := db.UpdateBudgetLimitsParams{
+		AllocatedAmount:  fmt.Sprintf("%.2f", arg.AllocatedAmount),
+		SoftLimitPercent: fmt.Sprintf("%.2f", arg.SoftLimitPercent),
+		UpdatedBy:        helper.ToNullUUID(helper.DerefUUID(updatedBy)),
+		ID:               arg.ID,
+		InstituteID:      arg.InstituteID,
+	}
+	if arg.HardLimitPercent != nil {
+		params.HardLimitPercent = helper.ToNullString(fmt.Sprintf("%.2f", *arg.HardLimitPercent))
+	}
+
+	row, err := q.UpdateBudgetLimits(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update budget: %w", err)
+	}
+
+	result := mapper.MapBudgetRowToDomain(row)
+	return &result, tx.Commit()
+}
+
+// ========================= LIST BUDGETS =========================
+
+// SERVICE
+func (s *Service) ListBudgets(ctx context.Context, instituteID, sessionID uuid.UUID, departmentID *uuid.UUID) ([]*domain.Budget, error) {
+	return s.repo.ListBudgets(ctx, instituteID, sessionID, departmentID)
+}
+
+// REPOSITORY
+func (r *Repository) ListBudgets(ctx context.Context, instituteID, sessionID uuid.UUID, departmentID *uuid.UUID) ([]*domain.Budget, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListBudgets(ctx, db.ListBudgetsParams{
+		InstituteID:       instituteID,
+		AcademicSessionID: helper.ToNullUUID(sessionID),
+		DepartmentID:      helper.ToNullUUID(helper.DerefUUID(departmentID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets: %w", err)
+	}
+
+	budgets := make([]*domain.Budget, 0, len(rows))
+	for _, row := range rows {
+		b := mapper.MapBudgetRowToDomain(row)
+		budgets = append(budgets, &b)
+	}
+	return budgets, nil
+}
+
+// ========================= BUDGET REPORT =========================
+
+// SERVICE
+// BudgetReport sets each budget of a session against what is committed to
+// open purchase orders and what has actually been spent, with a month by
+// month burn-down of the allocation across the session.
+func (s *Service) BudgetReport(ctx context.Context, instituteID, sessionID uuid.UUID, departmentID *uuid.UUID) (*domain.BudgetReport, error) {
+	return s.repo.BudgetReport(ctx, instituteID, sessionID, departmentID)
+}
+
+// REPOSITORY
+func (r *Repository) BudgetReport(ctx context.Context, instituteID, sessionID uuid.UUID, departmentID *uuid.UUID) (*domain.BudgetReport, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := q.GetAcademicSession(ctx, db.GetAcademicSessionParams{ID: sessionID, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAcademicSessionNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := q.ListBudgets(ctx, db.ListBudgetsParams{
+		InstituteID:       instituteID,
+		AcademicSessionID: helper.ToNullUUID(sessionID),
+		DepartmentID:      helper.ToNullUUID(helper.DerefUUID(departmentID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets: %w", err)
+	}
+
+	used, err := q.SumBudgetUtilisations(ctx, db.SumBudgetUtilisationsParams{
+		InstituteID:       instituteID,
+		AcademicSessionID: helper.ToNullUUID(sessionID),
+		DepartmentID:      helper.ToNullUUID(helper.DerefUUID(departmentID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum budget utilisation: %w", err)
+	}
+
+	monthly, err := q.SumBudgetUtilisationsByMonth(ctx, db.SumBudgetUtilisationsByMonthParams{
+		InstituteID:       instituteID,
+		AcademicSessionID: helper.ToNullUUID(sessionID),
+		DepartmentID:      helper.ToNullUUID(helper.DerefUUID(departmentID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum budget utilisation by month: %w", err)
+	}
+
+	type usage struct{ committed, actual int64 }
+	var amounts paiseReader
+	byBudget := make(map[uuid.UUID]usage, len(used))
+	for _, u := range used {
+		byBudget[u.BudgetID] = usage{committed: amounts.read(u.Committed), actual: amounts.read(u.Actual)}
+	}
+	if amounts.err != nil {
+		return nil, amounts.err
+	}
+
+	report := &domain.BudgetReport{
+		AcademicSessionID: sessionID,
+		DepartmentID:      departmentID,
+		Lines:             make([]domain.BudgetLine, 0, len(rows)),
+	}
+
+	var allocated, committed, actual int64
+	for _, row := range rows {
+		b := mapper.MapBudgetRowToDomain(row)
+		u := byBudget[b.ID]
+		alloc := toPaise(b.AllocatedAmount)
+
+		report.Lines = append(report.Lines, domain.BudgetLine{
+			Budget:      b,
+			Committed:   fromPaise(u.committed),
+			Actual:      fromPaise(u.actual),
+			Available:   fromPaise(alloc - u.committed - u.actual),
+			UtilisedPct: percentOf(u.committed+u.actual, alloc),
+		})
+		allocated += alloc
+		committed += u.committed
+		actual += u.actual
+	}
+
+	report.Allocated = fromPaise(allocated)
+	report.Committed = fromPaise(committed)
+	report.Actual = fromPaise(actual)
+	report.Available = fromPaise(allocated - committed - actual)
+	report.UtilisedPct = percentOf(committed+actual, allocated)
+	if report.BurnDown, err = burnDown(session.StartDate, session.EndDate, allocated, monthly); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// burnDown walks the months of a session, running the actual spend down from
+// the allocation next to a line that spends it evenly. Utilisation dated
+// outside the session counts in the totals but has no month here.
+func burnDown(start, end time.Time, allocated int64, monthly []db.SumBudgetUtilisationsByMonthRow) ([]domain.BudgetMonth, error) {
+	type usage struct{ committed, actual int64 }
+	var amounts paiseReader
+	byMonth := make(map[time.Time]usage, len(monthly))
+	for _, m := range monthly {
+		byMonth[monthStart(m.Month)] = usage{committed: amounts.read(m.Committed), actual: amounts.read(m.Actual)}
+	}
+	if amounts.err != nil {
+		return nil, amounts.err
+	}
+
+	first, last := monthStart(start), monthStart(end)
+	months := 0
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		months++
+	}
+
+	burn := make([]domain.BudgetMonth, 0, months)
+	var spent int64
+	for i, m := 0, first; i < months; i, m = i+1, m.AddDate(0, 1, 0) {
+		u := byMonth[m]
+		spent += u.actual
+		ideal := allocated * int64(months-i-1) / int64(months)
+
+		burn = append(burn, domain.BudgetMonth{
+			Month:            m,
+			Committed:        fromPaise(u.committed),
+			Actual:           fromPaise(u.actual),
+			CumulativeActual: fromPaise(spent),
+			Remaining:        fromPaise(allocated - spent),
+			IdealRemaining:   fromPaise(ideal),
+		})
+	}
+	return burn, nil
+}
+
+// percentOf is part as a percentage of whole, to two places
+func percentOf(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(whole)) / 100
+}
+
+// =================================================================================
+// UTILISATION
+// =================================================================================
+
+func checkBudgetLimits(b domain.Budget) error {
+	if b.SoftLimitPercent <= 0 || b.SoftLimitPercent >= 1000 {
+		return ErrInvalidBudgetLimits
+	}
+	if b.HardLimitPercent != nil && (*b.HardLimitPercent < b.SoftLimitPercent || *b.HardLimitPercent >= 1000) {
+		return ErrInvalidBudgetLimits
+	}
+	return nil
+}
+
+// checkExpenseAccount rejects budget lines and purchase orders charged to
+// anything but an expense account
+func (s *Service) checkExpenseAccount(ctx context.Context, instituteID, accountID uuid.UUID) error {
+	acc, err := s.repo.GetAccount(ctx, instituteID, accountID)
+	if err != nil {
+		return err
+	}
+	if acc.Type != domain.AccExpense {
+		return fmt.Errorf("%w: %s is %s", ErrExpenseAccountType, acc.Code, acc.Type)
+	}
+	return nil
+}
+
+// budgetCap is the share of the allocation a limit percentage allows, in paise
+func budgetCap(b domain.Budget, percent float64) int64 {
+	return int64(math.Round(float64(toPaise(b.AllocatedAmount)) * percent / 100))
+}
+
+// checkBudgetSpend reports whether adding amount paise to what a budget has
+// used stays within its soft limit, failing outright past its hard limit
+func checkBudgetSpend(b domain.Budget, amount int64) (bool, error) {
+	projected := toPaise(b.UtilizedAmount) + amount
+	if b.HardLimitPercent != nil && projected > budgetCap(b, *b.HardLimitPercent) {
+		return false, fmt.Errorf("%w: %.2f of %.2f allowed", ErrBudgetHardLimit, fromPaise(projected), fromPaise(budgetCap(b, *b.HardLimitPercent)))
+	}
+	return projected <= budgetCap(b, b.SoftLimitPercent), nil
+}
+
+// findBudget locks the budget that spending by a department on an expense
+// account counts against on a date; nil when it has none
+func findBudget(ctx context.Context, q *db.Queries, instituteID uuid.UUID, departmentID, accountID *uuid.UUID, on time.Time) (*domain.Budget, error) {
+	if departmentID == nil || accountID == nil {
+		return nil, nil
+	}
+
+	row, err := q.FindBudgetForSpend(ctx, db.FindBudgetForSpendParams{
+		InstituteID:       instituteID,
+		DepartmentID:      helper.ToNullUUID(*departmentID),
+		ExpenseCategoryID: helper.ToNullUUID(*accountID),
+		SpentOn:           dateOnly(on),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b := mapper.MapBudgetRowToDomain(row)
+	return &b, nil
+}
+
+// lockBudget reads a budget for update by id
+func lockBudget(ctx context.Context, q *db.Queries, instituteID, id uuid.UUID) (domain.Budget, error) {
+	row, err := q.GetBudgetForUpdate(ctx, db.GetBudgetForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Budget{}, ErrBudgetNotFound
+		}
+		return domain.Budget{}, err
+	}
+	return mapper.MapBudgetRowToDomain(row), nil
+}
+
+// useBudget records amount paise of utilisation against a locked budget and
+// adds it to utilized_amount; negative amounts release or reverse it
+func useBudget(ctx context.Context, q *db.Queries, b *domain.Budget, kind domain.BudgetUsage, sourceType string, sourceID uuid.UUID, amount int64, on time.Time) error {
+	if amount == 0 {
+		return nil
+	}
+
+	if _, err := q.CreateBudgetUtilisation(ctx, db.CreateBudgetUtilisationParams{
+		InstituteID: b.InstituteID,
+		BudgetID:    b.ID,
+		Kind:        string(kind),
+		SourceType:  sourceType,
+		SourceID:    sourceID,
+		Amount:      fmt.Sprintf("%.2f", fromPaise(amount)),
+		OccurredOn:  dateOnly(on),
+	}); err != nil {
+		return fmt.Errorf("failed to record budget utilisation: %w", err)
+	}
+
+	row, err := q.AddBudgetUtilised(ctx, db.AddBudgetUtilisedParams{
+		Amount:      fmt.Sprintf("%.2f", fromPaise(amount)),
+		ID:          b.ID,
+		InstituteID: b.InstituteID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update budget utilisation: %w", err)
+	}
+
+	*b = mapper.MapBudgetRowToDomain(row)
+	return nil
+}
+
+// commitPurchase commits an order's total to its budget. Past the soft limit
+// nothing is committed and the order is sent for approval instead, unless it
+// is being approved already; past the hard limit it is refused.
+func commitPurchase(ctx context.Context, q *db.Queries, po domain.PurchaseOrder, approved bool) (domain.PurchaseStatus, error) {
+	budget, err := findBudget(ctx, q, po.InstituteID, po.DepartmentID, po.ExpenseAccountID, po.OrderDate)
+	if err != nil {
+		return "", err
+	}
+	if budget == nil {
+		return domain.PurchaseOrdered, nil
+	}
+
+	amount := toPaise(po.TotalAmount)
+	withinSoft, err := checkBudgetSpend(*budget, amount)
+	if err != nil {
+		return "", err
+	}
+
+	if !withinSoft && !approved {
+		if _, err := q.CreateApproval(ctx, db.CreateApprovalParams{
+			InstituteID: po.InstituteID,
+			Module:      helper.ToNullString(purchaseApprovalModule),
+			ReferenceID: po.ID,
+		}); err != nil {
+			return "", fmt.Errorf("failed to request approval: %w", err)
+		}
+		return domain.PurchasePendingApproval, nil
+	}
+
+	return domain.PurchaseOrdered, useBudget(ctx, q, budget, domain.BudgetCommitted, budgetSourcePurchaseOrder, po.ID, amount, po.OrderDate)
+}
+
+// commitPurchaseItem adds an item put on an order after it was placed to
+// the budget the order is committed to, within the hard limit. An order
+// without an open commitment, because its budget came later or it has been
+// released, commits the item to the budget it falls under now.
+func commitPurchaseItem(ctx context.Context, q *db.Queries, po domain.PurchaseOrder, amount int64) error {
+	open, err := q.SumOpenCommitments(ctx, db.SumOpenCommitmentsParams{
+		InstituteID: po.InstituteID,
+		SourceType:  budgetSourcePurchaseOrder,
+		SourceID:    po.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	var budget *domain.Budget
+	if len(open) == 0 {
+		budget, err = findBudget(ctx, q, po.InstituteID, po.DepartmentID, po.ExpenseAccountID, po.OrderDate)
+		if err != nil || budget == nil {
+			return err
+		}
+	} else {
+		b, err := lockBudget(ctx, q, po.InstituteID, open[0].BudgetID)
+		if err != nil {
+			return err
+		}
+		budget = &b
+	}
+
+	if _, err := checkBudgetSpend(*budget, amount); err != nil {
+		return err
+	}
+	return useBudget(ctx, q, budget, domain.BudgetCommitted, budgetSourcePurchaseOrder, po.ID, amount, time.Now())
+}
+
+// releasePurchase releases what an order still holds committed, when it is
+// cancelled or closed, or once the last of its goods are received
+func releasePurchase(ctx context.Context, q *db.Queries, po domain.PurchaseOrder) error {
+	open, err := q.SumOpenCommitments(ctx, db.SumOpenCommitmentsParams{
+		InstituteID: po.InstituteID,
+		SourceType:  budgetSourcePurchaseOrder,
+		SourceID:    po.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range open {
+		budget, err := lockBudget(ctx, q, po.InstituteID, c.BudgetID)
+		if err != nil {
+			return err
+		}
+		amount, err := sumPaise(c.Amount)
+		if err != nil {
+			return err
+		}
+		if err := useBudget(ctx, q, &budget, domain.BudgetCommitted, budgetSourcePurchaseOrder, po.ID, -amount, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// receivePurchaseBudget moves amount paise of goods received against an
+// order from its commitment to the actual spend. An order received without a
+// commitment, because its budget came later, is charged as it is received.
+func receivePurchaseBudget(ctx context.Context, q *db.Queries, po domain.PurchaseOrder, amount int64, on time.Time) error {
+	open, err := q.SumOpenCommitments(ctx, db.SumOpenCommitmentsParams{
+		InstituteID: po.InstituteID,
+		SourceType:  budgetSourcePurchaseOrder,
+		SourceID:    po.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(open) == 0 {
+		budget, err := findBudget(ctx, q, po.InstituteID, po.DepartmentID, po.ExpenseAccountID, po.OrderDate)
+		if err != nil || budget == nil {
+			return err
+		}
+		return useBudget(ctx, q, budget, domain.BudgetActual, budgetSourcePurchaseOrder, po.ID, amount, on)
+	}
+
+	budget, err := lockBudget(ctx, q, po.InstituteID, open[0].BudgetID)
+	if err != nil {
+		return err
+	}
+	committed, err := sumPaise(open[0].Amount)
+	if err != nil {
+		return err
+	}
+	if err := useBudget(ctx, q, &budget, domain.BudgetCommitted, budgetSourcePurchaseOrder, po.ID, -min(amount, committed), on); err != nil {
+		return err
+	}
+	return useBudget(ctx, q, &budget, domain.BudgetActual, budgetSourcePurchaseOrder, po.ID, amount, on)
+}
+
+// recordJournalSpend counts the expense lines of a posted journal that carry
+// a department against that department's budget for the journal's date.
+// Debits add to the actual spend and credits, as on a reversal, take from it.
+// Lines without a matching budget are left alone.
+func recordJournalSpend(ctx context.Context, q *db.Queries, entry *domain.JournalEntry) error {
+	type line struct{ department, account uuid.UUID }
+	spend := make(map[line]int64)
+	for _, item := range entry.Items {
+		if item.DepartmentID == nil {
+			continue
+		}
+		spend[line{*item.DepartmentID, item.AccountID}] += toPaise(item.Debit) - toPaise(item.Credit)
+	}
+	if len(spend) == 0 {
+		return nil
+	}
+
+	// Lock budgets in a fixed order so concurrent postings cannot deadlock
+	lines := make([]line, 0, len(spend))
+	for l := range spend {
+		lines = append(lines, l)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].department != lines[j].department {
+			return lines[i].department.String() < lines[j].department.String()
+		}
+		return lines[i].account.String() < lines[j].account.String()
+	})
+
+	for _, l := range lines {
+		budget, err := findBudget(ctx, q, entry.InstituteID, &l.department, &l.account, entry.TransactionDate)
+		if err != nil {
+			return err
+		}
+		if budget == nil {
+			continue
+		}
+		if err := useBudget(ctx, q, budget, domain.BudgetActual, budgetSourceJournal, entry.ID, spend[l], entry.TransactionDate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
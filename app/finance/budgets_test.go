@@ -0,0 +1,80 @@
+package finance
+
+import (
+	"errors"
+	"testing"
+
+	"swiftschool/domain"
+)
+
+func TestCheckBudgetSpend(t *testing.T) {
+	hard := func(p float64) *float64 { return &p }
+
+	tests := []struct {
+		name    string
+		budget  domain.Budget
+		amount  int64
+		want    bool
+		wantErr error
+	}{
+		{
+			name:   "within soft limit",
+			budget: domain.Budget{AllocatedAmount: 1000, UtilizedAmount: 500, SoftLimitPercent: 90},
+			amount: 30000,
+			want:   true,
+		},
+		{
+			name:   "exactly at soft limit",
+			budget: domain.Budget{AllocatedAmount: 1000, UtilizedAmount: 500, SoftLimitPercent: 90},
+			amount: 40000,
+			want:   true,
+		},
+		{
+			name:   "past soft limit without hard limit",
+			budget: domain.Budget{AllocatedAmount: 1000, UtilizedAmount: 500, SoftLimitPercent: 90},
+			amount: 70000,
+			want:   false,
+		},
+		{
+			name:   "past soft limit within hard limit",
+			budget: domain.Budget{AllocatedAmount: 1000, UtilizedAmount: 500, SoftLimitPercent: 90, HardLimitPercent: hard(110)},
+			amount: 50000,
+			want:   false,
+		},
+		{
+			name:   "exactly at hard limit",
+			budget: domain.Budget{AllocatedAmount: 1000, UtilizedAmount: 500, SoftLimitPercent: 90, HardLimitPercent: hard(110)},
+			amount: 60000,
+			want:   false,
+		},
+		{
+			name:    "past hard limit",
+			budget:  domain.Budget{AllocatedAmount: 1000, UtilizedAmount: 500, SoftLimitPercent: 90, HardLimitPercent: hard(110)},
+			amount:  60001,
+			wantErr: ErrBudgetHardLimit,
+		},
+		{
+			name:    "hard limit below soft limit",
+			budget:  domain.Budget{AllocatedAmount: 1000, SoftLimitPercent: 100, HardLimitPercent: hard(50)},
+			amount:  50001,
+			wantErr: ErrBudgetHardLimit,
+		},
+		{
+			name:   "fractional allocation",
+			budget: domain.Budget{AllocatedAmount: 333.33, SoftLimitPercent: 50},
+			amount: 16667,
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkBudgetSpend(tt.budget, tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkBudgetSpend error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("checkBudgetSpend = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
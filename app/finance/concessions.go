@@ -0,0 +1,594 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidConcession         = errors.New("a concession needs a name, a type of flat or percentage and a value greater than zero; percentages cannot exceed 100 and sibling ranks start at 2")
+	ErrConcessionNotFound        = errors.New("concession not found")
+	ErrInvalidStudentConcession  = errors.New("a student concession needs a student, a concession and a start date, and cannot end before it starts")
+	ErrSiblingConcessionGranted  = errors.New("sibling concessions apply by themselves and cannot be granted to a student")
+	ErrStudentConcessionNotFound = errors.New("student concession not found")
+	ErrInvalidConcessionDecision = errors.New("decision must be approved or rejected")
+	ErrConcessionDecided         = errors.New("the concession request has already been decided")
+	ErrSelfApproval              = errors.New("a request cannot be decided by the user who made it")
+)
+
+// concessionApprovalModule identifies student concessions in core.approvals
+const concessionApprovalModule = "finance/student_concessions"
+
+var concessionTypes = []domain.ConcessionType{
+	domain.ConcessionFlat,
+	domain.ConcessionPercentage,
+}
+
+// grantedConcession is an approved concession of a student
+type grantedConcession struct {
+	StudentID  uuid.UUID
+	Concession domain.Concession
+}
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) CreateConcession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.Concession
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.CreateConcession(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create concession: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "concession created successfully", data)
+}
+
+func (h *Handler) ListConcessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListConcessions(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch concessions: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "concessions fetched successfully", data)
+}
+
+func (h *Handler) GrantStudentConcession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.StudentConcession
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.GrantStudentConcession(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to grant concession: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "concession submitted for approval", data)
+}
+
+func (h *Handler) DecideStudentConcession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid id: "+err.Error())
+		return
+	}
+
+	var req struct {
+		Status  domain.ApprovalStatus `json:"status"`
+		Remarks *string               `json:"remarks,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.DecideStudentConcession(r.Context(), inst, id, req.Status, req.Remarks, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to decide concession: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "concession "+string(data.Status), data)
+}
+
+func (h *Handler) ListStudentConcessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	student, err := helper.ParseRequiredUUIDFromQuery(r, "student_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid student_id: "+err.Error())
+		return
+	}
+
+	data, err := h.service.ListStudentConcessions(r.Context(), inst, student)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch student concessions: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "student concessions fetched successfully", data)
+}
+
+// ========================= CREATE CONCESSION =========================
+
+// SERVICE
+func (s *Service) CreateConcession(ctx context.Context, arg domain.Concession) (*domain.Concession, error) {
+	if arg.Name == nil || strings.TrimSpace(*arg.Name) == "" || !helper.Contains(concessionTypes, arg.Type) ||
+		arg.Value == nil || toPaise(*arg.Value) <= 0 {
+		return nil, ErrInvalidConcession
+	}
+	if arg.Type == domain.ConcessionPercentage && *arg.Value > 100 {
+		return nil, ErrInvalidConcession
+	}
+	if arg.SiblingRank != nil && *arg.SiblingRank < 2 {
+		return nil, ErrInvalidConcession
+	}
+	return s.repo.CreateConcession(ctx, arg)
+}
+
+// REPOSITORY
+func (r *Repository) CreateConcession(ctx context.Context, arg domain.Concession) (*domain.Concession, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreateConcession(ctx, mapper.MapConcessionDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create concession: %w", err)
+	}
+
+	result := mapper.MapConcessionRowToDomain(row)
+	return &result, nil
+}
+
+// ========================= LIST CONCESSIONS =========================
+
+// SERVICE
+func (s *Service) ListConcessions(ctx context.Context, instituteID uuid.UUID) ([]*domain.Concession, error) {
+	return s.repo.ListConcessions(ctx, instituteID)
+}
+
+// REPOSITORY
+func (r *Repository) ListConcessions(ctx context.Context, instituteID uuid.UUID) ([]*domain.Concession, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListConcessions(ctx, instituteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list concessions: %w", err)
+	}
+
+	concessions := make([]*domain.Concession, 0, len(rows))
+	for _, row := range rows {
+		c := mapper.MapConcessionRowToDomain(row)
+		concessions = append(concessions, &c)
+	}
+	return concessions, nil
+}
+
+// ========================= GRANT STUDENT CONCESSION =========================
+
+// SERVICE
+// GrantStudentConcession records a concession for a student and opens an
+// approval request for it. It is not applied to invoices until approved.
+func (s *Service) GrantStudentConcession(ctx context.Context, arg domain.StudentConcession) (*domain.StudentConcession, error) {
+	if arg.StudentID == uuid.Nil || arg.ConcessionID == uuid.Nil || arg.ValidFrom.IsZero() ||
+		(arg.ValidTo != nil && arg.ValidTo.Before(arg.ValidFrom)) {
+		return nil, ErrInvalidStudentConcession
+	}
+
+	sc, err := s.repo.GrantStudentConcession(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("concession %s requested for student %s", sc.ConcessionID, sc.StudentID)
+	return sc, nil
+}
+
+// REPOSITORY
+func (r *Repository) GrantStudentConcession(ctx context.Context, arg domain.StudentConcession) (*domain.StudentConcession, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	concession, err := q.GetConcession(ctx, db.GetConcessionParams{ID: arg.ConcessionID, InstituteID: arg.InstituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrConcessionNotFound
+		}
+		return nil, err
+	}
+	if concession.SiblingRank.Valid {
+		return nil, ErrSiblingConcessionGranted
+	}
+
+	row, err := q.CreateStudentConcession(ctx, mapper.MapStudentConcessionDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create student concession: %w", err)
+	}
+
+	if _, err := q.CreateApproval(ctx, db.CreateApprovalParams{
+		InstituteID: row.InstituteID,
+		Module:      helper.ToNullString(concessionApprovalModule),
+		ReferenceID: row.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to request approval: %w", err)
+	}
+
+	result := mapper.MapStudentConcessionRowToDomain(row)
+	return &result, tx.Commit()
+}
+
+// ========================= DECIDE STUDENT CONCESSION =========================
+
+// SERVICE
+// DecideStudentConcession approves or rejects a pending concession request.
+// The user who requested a concession cannot decide it.
+func (s *Service) DecideStudentConcession(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.StudentConcession, error) {
+	if status != domain.ApprovalApproved && status != domain.ApprovalRejected {
+		return nil, ErrInvalidConcessionDecision
+	}
+
+	sc, err := s.repo.DecideStudentConcession(ctx, instituteID, id, status, remarks, decidedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("concession request %s %s", sc.ID, sc.Status)
+	return sc, nil
+}
+
+// REPOSITORY
+func (r *Repository) DecideStudentConcession(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.StudentConcession, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	row, err := q.GetStudentConcessionForUpdate(ctx, db.GetStudentConcessionForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrStudentConcessionNotFound
+		}
+		return nil, err
+	}
+	if domain.ApprovalStatus(row.Status) != domain.ApprovalPending {
+		return nil, ErrConcessionDecided
+	}
+	if decidedBy != nil && row.CreatedBy.Valid && row.CreatedBy.UUID == *decidedBy {
+		return nil, ErrSelfApproval
+	}
+
+	_, err = q.DecideApproval(ctx, db.DecideApprovalParams{
+		Status:      helper.ToNullString(string(status)),
+		ApproverID:  helper.ToNullUUID(helper.DerefUUID(decidedBy)),
+		Remarks:     helper.ToNullString(helper.StrOrEmpty(remarks)),
+		InstituteID: instituteID,
+		Module:      helper.ToNullString(concessionApprovalModule),
+		ReferenceID: id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrConcessionDecided
+		}
+		return nil, fmt.Errorf("failed to record decision: %w", err)
+	}
+
+	row, err = q.UpdateStudentConcessionStatus(ctx, db.UpdateStudentConcessionStatusParams{
+		Status:      string(status),
+		UpdatedBy:   helper.ToNullUUID(helper.DerefUUID(decidedBy)),
+		ID:          id,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update student concession: %w", err)
+	}
+
+	result := mapper.MapStudentConcessionRowToDomain(row)
+	return &result, tx.Commit()
+}
+
+// ========================= LIST STUDENT CONCESSIONS =========================
+
+// SERVICE
+func (s *Service) ListStudentConcessions(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.StudentConcession, error) {
+	return s.repo.ListStudentConcessions(ctx, instituteID, studentID)
+}
+
+// REPOSITORY
+func (r *Repository) ListStudentConcessions(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.StudentConcession, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListStudentConcessions(ctx, db.ListStudentConcessionsParams{
+		InstituteID: instituteID,
+		StudentID:   studentID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list student concessions: %w", err)
+	}
+
+	result := make([]*domain.StudentConcession, 0, len(rows))
+	for _, row := range rows {
+		sc := mapper.MapStudentConcessionRowToDomain(row)
+		result = append(result, &sc)
+	}
+	return result, nil
+}
+
+// ========================= APPLYING CONCESSIONS =========================
+
+// SERVICE
+// studentConcessions returns the concessions each student qualifies for in
+// the period from..to: approved grants whose validity overlaps it and the
+// sibling concessions of their sibling rank
+func (s *Service) studentConcessions(ctx context.Context, instituteID uuid.UUID, studentIDs []uuid.UUID, from, to time.Time) (map[uuid.UUID][]domain.Concession, error) {
+	result := make(map[uuid.UUID][]domain.Concession, len(studentIDs))
+	if len(studentIDs) == 0 {
+		return result, nil
+	}
+
+	granted, err := s.repo.ListGrantedConcessions(ctx, instituteID, studentIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range granted {
+		result[g.StudentID] = append(result[g.StudentID], g.Concession)
+	}
+
+	siblings, err := s.repo.ListSiblingConcessions(ctx, instituteID)
+	if err != nil || len(siblings) == 0 {
+		return result, err
+	}
+	ranks, err := s.repo.ListSiblingRanks(ctx, instituteID, studentIDs)
+	if err != nil {
+		return nil, err
+	}
+	for studentID, rank := range ranks {
+		for _, c := range siblings {
+			if rank >= *c.SiblingRank {
+				result[studentID] = append(result[studentID], *c)
+			}
+		}
+	}
+	return result, nil
+}
+
+// REPOSITORY
+func (r *Repository) ListGrantedConcessions(ctx context.Context, instituteID uuid.UUID, studentIDs []uuid.UUID, from, to time.Time) ([]grantedConcession, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListApprovedStudentConcessions(ctx, db.ListApprovedStudentConcessionsParams{
+		InstituteID: instituteID,
+		StudentIds:  studentIDs,
+		PeriodEnd:   to,
+		PeriodStart: from,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list granted concessions: %w", err)
+	}
+
+	granted := make([]grantedConcession, 0, len(rows))
+	for _, row := range rows {
+		granted = append(granted, grantedConcession{
+			StudentID:  row.StudentID,
+			Concession: mapper.MapConcessionRowToDomain(row.FinanceConcession),
+		})
+	}
+	return granted, nil
+}
+
+func (r *Repository) ListSiblingConcessions(ctx context.Context, instituteID uuid.UUID) ([]*domain.Concession, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListSiblingConcessions(ctx, instituteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sibling concessions: %w", err)
+	}
+
+	concessions := make([]*domain.Concession, 0, len(rows))
+	for _, row := range rows {
+		c := mapper.MapConcessionRowToDomain(row)
+		concessions = append(concessions, &c)
+	}
+	return concessions, nil
+}
+
+// ListSiblingRanks returns each student's position among their siblings by
+// admission, 1 for the eldest
+func (r *Repository) ListSiblingRanks(ctx context.Context, instituteID uuid.UUID, studentIDs []uuid.UUID) (map[uuid.UUID]int32, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListSiblingRanks(ctx, db.ListSiblingRanksParams{
+		InstituteID: instituteID,
+		StudentIds:  studentIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank siblings: %w", err)
+	}
+
+	ranks := make(map[uuid.UUID]int32, len(rows))
+	for _, row := range rows {
+		ranks[row.StudentID] = row.SiblingRank
+	}
+	return ranks, nil
+}
+
+// applyConcessions sets the discount of each item from the best of the
+// student's concessions that covers its fee head. Concessions do not
+// stack, and discounts sent by the client are discarded.
+func applyConcessions(items []domain.InvoiceItem, concessions []domain.Concession) {
+	for i := range items {
+		item := &items[i]
+		item.ConcessionID = nil
+		item.DiscountApplied = 0
+		if item.FeeHeadID == nil {
+			continue
+		}
+
+		var best int64
+		for _, c := range concessions {
+			if len(c.FeeHeadIDs) > 0 && !helper.Contains(c.FeeHeadIDs, *item.FeeHeadID) {
+				continue
+			}
+			if d := concessionDiscount(c, toPaise(item.Amount)); d > best {
+				best = d
+				item.ConcessionID = &c.ID
+			}
+		}
+		item.DiscountApplied = fromPaise(best)
+	}
+}
+
+// concessionDiscount is the discount in paise c gives on amount paise
+func concessionDiscount(c domain.Concession, amount int64) int64 {
+	if c.Value == nil || amount <= 0 {
+		return 0
+	}
+
+	var d int64
+	switch c.Type {
+	case domain.ConcessionFlat:
+		d = toPaise(*c.Value)
+	case domain.ConcessionPercentage:
+		d = int64(math.Round(float64(amount) * *c.Value / 100))
+	}
+	return min(d, amount)
+}
+
+// concessionPeriod is the period concessions are matched against: the
+// billed month of a generated invoice, otherwise today
+func concessionPeriod(billingPeriod *time.Time) (time.Time, time.Time) {
+	if billingPeriod != nil {
+		from := monthStart(*billingPeriod)
+		return from, from.AddDate(0, 1, -1)
+	}
+	today := dateOnly(time.Now())
+	return today, today
+}
@@ -0,0 +1,1038 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidDunningStep         = errors.New("each dunning step needs days overdue greater than zero, a subject, a message and at least one of the email, sms or in_app channels")
+	ErrDuplicateDunningStep       = errors.New("two dunning steps cannot fall on the same day overdue")
+	ErrInvalidPaymentPlan         = errors.New("a payment plan needs a student, an amount and instalments greater than zero, a reason and an end date on or after its start date")
+	ErrPaymentPlanNotFound        = errors.New("payment plan not found")
+	ErrPaymentPlanDecided         = errors.New("the payment plan has already been decided")
+	ErrInvalidPaymentPlanDecision = errors.New("a payment plan can only be approved or rejected")
+	ErrInvalidPaymentPlanStatus   = errors.New("payment plan status must be pending, approved or rejected")
+)
+
+var dunningChannels = []domain.DunningChannel{
+	domain.DunningEmail,
+	domain.DunningSMS,
+	domain.DunningInApp,
+}
+
+var paymentPlanStatuses = []domain.ApprovalStatus{
+	domain.ApprovalPending,
+	domain.ApprovalApproved,
+	domain.ApprovalRejected,
+}
+
+// Fee head names the defaulter report uses for what is not under a fee head
+const (
+	otherFeesHead = "Other fees"
+	lateFinesHead = "Late fines"
+)
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) FeeDefaulterAgeing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	asOf, err := helper.ParseDateFromQuery(r, "as_of")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid as_of: "+err.Error())
+		return
+	}
+
+	classID, err := helper.ParseUUIDFromQuery(r, "class_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid class_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.FeeDefaulterAgeing(r.Context(), inst, classID, asOf)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to generate fee defaulter report: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "fee defaulter report generated", data)
+}
+
+func (h *Handler) GetDunningSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetDunningSchedule(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to fetch dunning schedule: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "dunning schedule fetched successfully", data)
+}
+
+func (h *Handler) SetDunningSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req struct {
+		Steps []domain.DunningStep `json:"steps"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.SetDunningSchedule(r.Context(), inst, req.Steps, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to update dunning schedule: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "dunning schedule updated successfully", data)
+}
+
+func (h *Handler) RunDunning(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.RunDunning(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to send fee reminders: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, fmt.Sprintf("%d fee reminders sent", len(data.Reminders)), data)
+}
+
+func (h *Handler) ListDunningReminders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	studentID, err := helper.ParseUUIDFromQuery(r, "student_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid student_id: "+err.Error())
+		return
+	}
+
+	invoiceID, err := helper.ParseUUIDFromQuery(r, "invoice_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid invoice_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListDunningReminders(r.Context(), inst, studentID, invoiceID)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to list fee reminders: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "fee reminders fetched successfully", data)
+}
+
+func (h *Handler) CreatePaymentPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.PaymentPlan
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.CreatePaymentPlan(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create payment plan: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "payment plan created successfully", data)
+}
+
+func (h *Handler) ListPaymentPlans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	studentID, err := helper.ParseUUIDFromQuery(r, "student_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid student_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := domain.ApprovalStatus(r.URL.Query().Get("status"))
+	data, err := h.service.ListPaymentPlans(r.Context(), inst, studentID, status)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to list payment plans: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "payment plans fetched successfully", data)
+}
+
+func (h *Handler) DecidePaymentPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "payment_plan_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid payment_plan_id: "+err.Error())
+		return
+	}
+
+	var req struct {
+		Status  domain.ApprovalStatus `json:"status"`
+		Remarks *string               `json:"remarks,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.DecidePaymentPlan(r.Context(), inst, id, req.Status, req.Remarks, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to decide payment plan: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "payment plan "+string(data.Status), data)
+}
+
+// ========================= FEE DEFAULTER AGEING =========================
+
+// SERVICE
+// FeeDefaulterAgeing buckets the fees overdue as of asOf, today by
+// default, by days past due, per class and fee head. Paid amounts are
+// spread over an invoice's fee heads in proportion to their amounts, and
+// unpaid late fines are shown under a head of their own.
+func (s *Service) FeeDefaulterAgeing(ctx context.Context, instituteID, classID uuid.UUID, asOf *time.Time) (*domain.FeeDefaulterAgeing, error) {
+	date := dateOnly(time.Now())
+	if asOf != nil {
+		date = dateOnly(*asOf)
+	}
+	return s.repo.FeeDefaulterAgeing(ctx, instituteID, classID, date)
+}
+
+// REPOSITORY
+func (r *Repository) FeeDefaulterAgeing(ctx context.Context, instituteID, classID uuid.UUID, asOf time.Time) (*domain.FeeDefaulterAgeing, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListOverdueFeeItems(ctx, db.ListOverdueFeeItemsParams{
+		InstituteID: instituteID,
+		AsOf:        asOf,
+		ClassID:     helper.ToNullUUID(classID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue fees: %w", err)
+	}
+
+	// Rows come grouped by class, then by invoice
+	type classTotals struct {
+		id       uuid.UUID
+		ageing   domain.ClassAgeing
+		buckets  ageingPaise
+		heads    feeHeadTotals
+		students map[uuid.UUID]bool
+		onPlan   map[uuid.UUID]bool
+	}
+	var classes []*classTotals
+	var heads feeHeadTotals
+	var totals ageingPaise
+	defaulters := make(map[uuid.UUID]bool)
+
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && rows[end].InvoiceID == rows[start].InvoiceID {
+			end++
+		}
+		items := rows[start:end]
+		start = end
+		inv := items[0]
+
+		var amounts paiseReader
+		billed := amounts.read(inv.TotalAmount) - amounts.read(inv.DiscountAmount.String)
+		paid := amounts.read(inv.PaidAmount.String)
+		principal := max(billed-paid, 0)
+		fines := max(billed+amounts.read(inv.FineAmount.String)-paid, 0) - principal
+		if amounts.err != nil {
+			return nil, amounts.err
+		}
+		if principal+fines <= 0 {
+			continue
+		}
+		days := int(asOf.Sub(dateOnly(inv.DueDate.Time)).Hours() / 24)
+
+		if len(classes) == 0 || classes[len(classes)-1].id != inv.ClassID.UUID {
+			name := inv.ClassName
+			if name == "" {
+				name = "No class"
+			}
+			classes = append(classes, &classTotals{
+				id:       inv.ClassID.UUID,
+				ageing:   domain.ClassAgeing{ClassID: helper.NullUUIDToPtr(inv.ClassID), ClassName: name},
+				students: make(map[uuid.UUID]bool),
+				onPlan:   make(map[uuid.UUID]bool),
+			})
+		}
+		c := classes[len(classes)-1]
+		c.students[inv.StudentID] = true
+		if inv.OnPaymentPlan {
+			c.onPlan[inv.StudentID] = true
+		}
+		defaulters[inv.StudentID] = true
+
+		add := func(headID *uuid.UUID, name string, amount int64) {
+			if amount == 0 {
+				return
+			}
+			c.heads.add(headID, name, days, amount)
+			heads.add(headID, name, days, amount)
+			c.buckets.add(days, amount)
+			totals.add(days, amount)
+		}
+
+		// Spread what is left of the principal over the items by their
+		// net amounts so that the shares add up to it exactly
+		nets := make([]int64, len(items))
+		var net int64
+		for i, item := range items {
+			nets[i] = amounts.read(item.NetAmount)
+			net += nets[i]
+		}
+		if amounts.err != nil {
+			return nil, amounts.err
+		}
+		var cumulative, spread int64
+		for i, item := range items {
+			cumulative += nets[i]
+			share := principal - spread
+			if net > 0 {
+				share = principal*cumulative/net - spread
+			}
+			spread += share
+
+			name := item.FeeHeadName
+			if !item.FeeHeadID.Valid {
+				name = otherFeesHead
+			}
+			add(helper.NullUUIDToPtr(item.FeeHeadID), name, share)
+		}
+		add(nil, lateFinesHead, fines)
+	}
+
+	report := &domain.FeeDefaulterAgeing{
+		AsOf:       asOf,
+		Classes:    []domain.ClassAgeing{},
+		FeeHeads:   heads.list(),
+		Defaulters: len(defaulters),
+		Totals:     totals.buckets(),
+	}
+	for _, c := range classes {
+		c.ageing.Defaulters = len(c.students)
+		c.ageing.OnPaymentPlan = len(c.onPlan)
+		c.ageing.FeeHeads = c.heads.list()
+		c.ageing.AgeingBuckets = c.buckets.buckets()
+		report.Classes = append(report.Classes, c.ageing)
+	}
+
+	return report, nil
+}
+
+// feeHeadTotals buckets overdue fees by fee head in the order heads are
+// first met. Amounts without a fee head are kept apart by name.
+type feeHeadTotals struct {
+	keys  []string
+	heads map[string]*feeHeadBuckets
+}
+
+type feeHeadBuckets struct {
+	ageing  domain.FeeHeadAgeing
+	buckets ageingPaise
+}
+
+func (t *feeHeadTotals) add(headID *uuid.UUID, name string, daysPastDue int, amount int64) {
+	key := name
+	if headID != nil {
+		key = headID.String()
+	}
+	if t.heads == nil {
+		t.heads = make(map[string]*feeHeadBuckets)
+	}
+	head, ok := t.heads[key]
+	if !ok {
+		head = &feeHeadBuckets{ageing: domain.FeeHeadAgeing{FeeHeadID: headID, FeeHeadName: name}}
+		t.keys = append(t.keys, key)
+		t.heads[key] = head
+	}
+	head.buckets.add(daysPastDue, amount)
+}
+
+func (t *feeHeadTotals) list() []domain.FeeHeadAgeing {
+	list := make([]domain.FeeHeadAgeing, 0, len(t.keys))
+	for _, key := range t.keys {
+		head := t.heads[key]
+		head.ageing.AgeingBuckets = head.buckets.buckets()
+		list = append(list, head.ageing)
+	}
+	return list
+}
+
+// ========================= DUNNING SCHEDULE =========================
+
+// SERVICE
+func (s *Service) GetDunningSchedule(ctx context.Context, instituteID uuid.UUID) ([]*domain.DunningStep, error) {
+	return s.repo.GetDunningSchedule(ctx, instituteID)
+}
+
+// SetDunningSchedule replaces the institute's reminder schedule. A step
+// saved again for the same day overdue is kept, so invoices it was sent
+// for are not reminded twice; steps left out are switched off. An empty
+// schedule stops reminders altogether.
+func (s *Service) SetDunningSchedule(ctx context.Context, instituteID uuid.UUID, steps []domain.DunningStep, updatedBy *uuid.UUID) ([]*domain.DunningStep, error) {
+	days := make(map[int]bool, len(steps))
+	for i := range steps {
+		step := &steps[i]
+		step.Subject = strings.TrimSpace(step.Subject)
+		step.Message = strings.TrimSpace(step.Message)
+		if step.DaysOverdue <= 0 || step.Subject == "" || step.Message == "" || len(step.Channels) == 0 {
+			return nil, ErrInvalidDunningStep
+		}
+		for _, channel := range step.Channels {
+			if !helper.Contains(dunningChannels, channel) {
+				return nil, ErrInvalidDunningStep
+			}
+		}
+		if days[step.DaysOverdue] {
+			return nil, fmt.Errorf("%w: %d days", ErrDuplicateDunningStep, step.DaysOverdue)
+		}
+		days[step.DaysOverdue] = true
+
+		step.InstituteID = instituteID
+		step.UpdatedBy = updatedBy
+	}
+
+	schedule, err := s.repo.SetDunningSchedule(ctx, instituteID, steps, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("dunning schedule of institute %s set to %d steps", instituteID, len(schedule))
+	return schedule, nil
+}
+
+// REPOSITORY
+func (r *Repository) GetDunningSchedule(ctx context.Context, instituteID uuid.UUID) ([]*domain.DunningStep, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	return listDunningSteps(ctx, q, instituteID)
+}
+
+func (r *Repository) SetDunningSchedule(ctx context.Context, instituteID uuid.UUID, steps []domain.DunningStep, updatedBy *uuid.UUID) ([]*domain.DunningStep, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if err := q.DeactivateDunningSteps(ctx, db.DeactivateDunningStepsParams{
+		UpdatedBy:   helper.ToNullUUID(helper.DerefUUID(updatedBy)),
+		InstituteID: instituteID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to clear dunning schedule: %w", err)
+	}
+	for _, step := range steps {
+		if _, err := q.UpsertDunningStep(ctx, mapper.MapDunningStepDomainToParams(step)); err != nil {
+			return nil, fmt.Errorf("failed to save dunning step: %w", err)
+		}
+	}
+
+	schedule, err := listDunningSteps(ctx, q, instituteID)
+	if err != nil {
+		return nil, err
+	}
+	return schedule, tx.Commit()
+}
+
+func listDunningSteps(ctx context.Context, q *db.Queries, instituteID uuid.UUID) ([]*domain.DunningStep, error) {
+	rows, err := q.ListDunningSteps(ctx, instituteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dunning steps: %w", err)
+	}
+
+	steps := make([]*domain.DunningStep, 0, len(rows))
+	for _, row := range rows {
+		step := mapper.MapDunningStepRowToDomain(row)
+		steps = append(steps, &step)
+	}
+	return steps, nil
+}
+
+// ========================= RUN DUNNING =========================
+
+// SERVICE
+// RunDunning reminds the primary guardians of students with overdue
+// invoices. Each invoice gets the latest step of the schedule it has
+// reached, once; steps it skipped past between runs are not sent late.
+// Students on an approved payment plan are left alone while it runs, and
+// invoices whose student has no primary guardian are counted and retried
+// on the next run.
+func (s *Service) RunDunning(ctx context.Context, instituteID uuid.UUID) (*domain.DunningRun, error) {
+	asOf := dateOnly(time.Now())
+	run := &domain.DunningRun{AsOf: asOf, Reminders: []domain.DunningReminder{}}
+
+	notices, err := s.repo.ListDunningDue(ctx, instituteID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range notices {
+		amount := invoiceDue(n.Invoice) - toPaise(n.Invoice.PaidAmount)
+		if amount <= 0 {
+			continue
+		}
+		if len(n.Guardians) == 0 {
+			run.NoContact++
+			continue
+		}
+
+		reminder, err := s.repo.ClaimDunningReminder(ctx, domain.DunningReminder{
+			InstituteID:   instituteID,
+			InvoiceID:     n.Invoice.ID,
+			StudentID:     n.Invoice.StudentID,
+			DunningStepID: n.Step.ID,
+			DaysOverdue:   n.DaysOverdue,
+			AmountDue:     fromPaise(amount),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("dunning stopped at invoice %s: %w", n.Invoice.InvoiceNo, err)
+		}
+		if reminder == nil {
+			continue // sent by a run that overlapped this one
+		}
+
+		reminder.Recipients, reminder.Failures = s.sendDunningNotice(ctx, *n, amount)
+		if reminder, err = s.repo.SetDunningReminderDelivery(ctx, *reminder); err != nil {
+			return nil, fmt.Errorf("dunning stopped at invoice %s: %w", n.Invoice.InvoiceNo, err)
+		}
+		run.Reminders = append(run.Reminders, *reminder)
+	}
+
+	if len(run.Reminders) > 0 {
+		logger.Infof("%d fee reminders sent for institute %s", len(run.Reminders), instituteID)
+	}
+	return run, nil
+}
+
+// sendDunningNotice sends the step's message to each guardian on each of
+// its channels. It returns how many guardians at least one delivery
+// reached and how many deliveries failed.
+func (s *Service) sendDunningNotice(ctx context.Context, n domain.DunningNotice, amount int64) (reached, failures int) {
+	dueDate := ""
+	if n.Invoice.DueDate != nil {
+		dueDate = n.Invoice.DueDate.Format("02 Jan 2006")
+	}
+	fill := strings.NewReplacer(
+		"{student}", n.StudentName,
+		"{invoice_no}", n.Invoice.InvoiceNo,
+		"{amount}", fmt.Sprintf("%.2f", fromPaise(amount)),
+		"{due_date}", dueDate,
+		"{days}", strconv.Itoa(n.DaysOverdue),
+	)
+	subject := fill.Replace(n.Step.Subject)
+	message := fill.Replace(n.Step.Message)
+
+	for _, g := range n.Guardians {
+		delivered := false
+		for _, channel := range n.Step.Channels {
+			var err error
+			switch {
+			case channel == domain.DunningEmail && helper.StrOrEmpty(g.Email) != "":
+				err = helper.Notify(ctx, helper.ChannelEmail, helper.Notification{
+					InstituteID: n.Invoice.InstituteID,
+					To:          *g.Email,
+					Subject:     subject,
+					Template:    helper.NotificationEmail,
+					Data:        map[string]string{"message": html.EscapeString(message)},
+				})
+			case channel == domain.DunningSMS && helper.StrOrEmpty(g.Phone) != "":
+				err = helper.Notify(ctx, helper.ChannelSMS, helper.Notification{
+					InstituteID: n.Invoice.InstituteID,
+					To:          *g.Phone,
+					Body:        message,
+				})
+			case channel == domain.DunningInApp && g.UserID != nil:
+				notification := domain.Notification{UserID: g.UserID, Title: &subject, Message: &message}
+				notification.InstituteID = n.Invoice.InstituteID
+				err = s.repo.CreateNotification(ctx, notification)
+			default:
+				continue // no address for this channel
+			}
+
+			if err != nil {
+				failures++
+				logger.Warnf("fee reminder for invoice %s to guardian %s over %s failed: %v", n.Invoice.InvoiceNo, g.GuardianID, channel, err)
+				continue
+			}
+			delivered = true
+		}
+		if delivered {
+			reached++
+		}
+	}
+	return reached, failures
+}
+
+// runAllDunning runs RunDunning for every institute with a dunning
+// schedule. A failing institute does not stop the others.
+func (s *Service) runAllDunning(ctx context.Context) {
+	institutes, err := s.repo.ListInstitutesWithDunningSteps(ctx)
+	if err != nil {
+		logger.Errorf("dunning job failed to list institutes: %v", err)
+		return
+	}
+	for _, inst := range institutes {
+		if _, err := s.RunDunning(ctx, inst); err != nil {
+			logger.Errorf("dunning job failed for institute %s: %v", inst, err)
+		}
+	}
+}
+
+// StartDunningJob sends fee reminders for all institutes every interval
+// until ctx is done
+func StartDunningJob(ctx context.Context, s *Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runAllDunning(ctx)
+			}
+		}
+	}()
+}
+
+// REPOSITORY
+func (r *Repository) ListInstitutesWithDunningSteps(ctx context.Context) ([]uuid.UUID, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	return q.ListInstitutesWithDunningSteps(ctx)
+}
+
+// ListDunningDue lists the steps overdue invoices have reached but not been
+// sent, with the primary guardians of each invoice's student
+func (r *Repository) ListDunningDue(ctx context.Context, instituteID uuid.UUID, asOf time.Time) ([]*domain.DunningNotice, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListDunningDue(ctx, db.ListDunningDueParams{
+		InstituteID: instituteID,
+		AsOf:        asOf,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoices due a reminder: %w", err)
+	}
+
+	guardians := make(map[uuid.UUID][]domain.GuardianContact)
+	notices := make([]*domain.DunningNotice, 0, len(rows))
+	for _, row := range rows {
+		studentID := row.FinanceInvoice.StudentID
+		contacts, ok := guardians[studentID]
+		if !ok {
+			if contacts, err = listPrimaryGuardians(ctx, q, instituteID, studentID); err != nil {
+				return nil, err
+			}
+			guardians[studentID] = contacts
+		}
+
+		notices = append(notices, &domain.DunningNotice{
+			Invoice:     mapper.MapInvoiceRowToDomain(row.FinanceInvoice),
+			Step:        mapper.MapDunningStepRowToDomain(row.FinanceDunningStep),
+			StudentName: strings.TrimSpace(row.FirstName.String + " " + row.LastName.String),
+			DaysOverdue: int(row.DaysOverdue),
+			Guardians:   contacts,
+		})
+	}
+	return notices, nil
+}
+
+func listPrimaryGuardians(ctx context.Context, q *db.Queries, instituteID, studentID uuid.UUID) ([]domain.GuardianContact, error) {
+	rows, err := q.ListPrimaryGuardianContacts(ctx, db.ListPrimaryGuardianContactsParams{
+		StudentID:   studentID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list primary guardians: %w", err)
+	}
+
+	contacts := make([]domain.GuardianContact, 0, len(rows))
+	for _, row := range rows {
+		contacts = append(contacts, domain.GuardianContact{
+			GuardianID: row.ID,
+			Name:       strings.TrimSpace(row.FirstName.String + " " + row.LastName.String),
+			Email:      helper.NullStringToPtr(row.Email),
+			Phone:      helper.NullStringToPtr(row.Phone),
+			UserID:     helper.NullUUIDToPtr(row.UserID),
+		})
+	}
+	return contacts, nil
+}
+
+// ClaimDunningReminder records that a step is being sent for an invoice.
+// It returns nil when the step has already been claimed.
+func (r *Repository) ClaimDunningReminder(ctx context.Context, arg domain.DunningReminder) (*domain.DunningReminder, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreateDunningReminder(ctx, db.CreateDunningReminderParams{
+		InstituteID:   arg.InstituteID,
+		InvoiceID:     arg.InvoiceID,
+		StudentID:     arg.StudentID,
+		DunningStepID: arg.DunningStepID,
+		DaysOverdue:   int32(arg.DaysOverdue),
+		AmountDue:     fmt.Sprintf("%.2f", arg.AmountDue),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to record fee reminder: %w", err)
+	}
+
+	reminder := mapper.MapDunningReminderRowToDomain(row)
+	return &reminder, nil
+}
+
+func (r *Repository) SetDunningReminderDelivery(ctx context.Context, arg domain.DunningReminder) (*domain.DunningReminder, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.SetDunningReminderDelivery(ctx, db.SetDunningReminderDeliveryParams{
+		Recipients:  int32(arg.Recipients),
+		Failures:    int32(arg.Failures),
+		ID:          arg.ID,
+		InstituteID: arg.InstituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update fee reminder: %w", err)
+	}
+
+	reminder := mapper.MapDunningReminderRowToDomain(row)
+	return &reminder, nil
+}
+
+// CreateNotification adds an in-app notification for a user
+func (r *Repository) CreateNotification(ctx context.Context, arg domain.Notification) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.CreateNotification(ctx, db.CreateNotificationParams{
+		InstituteID: arg.InstituteID,
+		UserID:      helper.ToNullUUID(helper.DerefUUID(arg.UserID)),
+		Title:       helper.ToNullString(helper.StrOrEmpty(arg.Title)),
+		Message:     helper.ToNullString(helper.StrOrEmpty(arg.Message)),
+		CreatedBy:   helper.ToNullUUID(helper.DerefUUID(arg.CreatedBy)),
+	}); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// ========================= LIST DUNNING REMINDERS =========================
+
+// SERVICE
+func (s *Service) ListDunningReminders(ctx context.Context, instituteID, studentID, invoiceID uuid.UUID) ([]*domain.DunningReminder, error) {
+	return s.repo.ListDunningReminders(ctx, instituteID, studentID, invoiceID)
+}
+
+// REPOSITORY
+func (r *Repository) ListDunningReminders(ctx context.Context, instituteID, studentID, invoiceID uuid.UUID) ([]*domain.DunningReminder, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListDunningReminders(ctx, db.ListDunningRemindersParams{
+		InstituteID: instituteID,
+		StudentID:   helper.ToNullUUID(studentID),
+		InvoiceID:   helper.ToNullUUID(invoiceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fee reminders: %w", err)
+	}
+
+	reminders := make([]*domain.DunningReminder, 0, len(rows))
+	for _, row := range rows {
+		reminder := mapper.MapDunningReminderRowToDomain(row)
+		reminders = append(reminders, &reminder)
+	}
+	return reminders, nil
+}
+
+// ========================= PAYMENT PLANS =========================
+
+// SERVICE
+// CreatePaymentPlan records a request to let a student clear overdue fees
+// in instalments. The plan starts today unless a start date is given and
+// pauses fee reminders only once approved.
+func (s *Service) CreatePaymentPlan(ctx context.Context, arg domain.PaymentPlan) (*domain.PaymentPlan, error) {
+	arg.Reason = strings.TrimSpace(arg.Reason)
+	if arg.StartDate.IsZero() {
+		arg.StartDate = time.Now()
+	}
+	arg.StartDate = dateOnly(arg.StartDate)
+	arg.EndDate = dateOnly(arg.EndDate)
+	if arg.StudentID == uuid.Nil || toPaise(arg.Amount) <= 0 || arg.Installments <= 0 ||
+		arg.Reason == "" || arg.EndDate.Before(arg.StartDate) {
+		return nil, ErrInvalidPaymentPlan
+	}
+
+	plan, err := s.repo.CreatePaymentPlan(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("payment plan %s requested for student %s", plan.ID, plan.StudentID)
+	return plan, nil
+}
+
+func (s *Service) ListPaymentPlans(ctx context.Context, instituteID, studentID uuid.UUID, status domain.ApprovalStatus) ([]*domain.PaymentPlan, error) {
+	if status != "" && !helper.Contains(paymentPlanStatuses, status) {
+		return nil, ErrInvalidPaymentPlanStatus
+	}
+	return s.repo.ListPaymentPlans(ctx, instituteID, studentID, status)
+}
+
+// DecidePaymentPlan approves or rejects a pending payment plan. The user
+// who requested the plan cannot decide it.
+func (s *Service) DecidePaymentPlan(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.PaymentPlan, error) {
+	if status != domain.ApprovalApproved && status != domain.ApprovalRejected {
+		return nil, ErrInvalidPaymentPlanDecision
+	}
+
+	plan, err := s.repo.DecidePaymentPlan(ctx, instituteID, id, status, remarks, decidedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("payment plan %s %s", plan.ID, plan.Status)
+	return plan, nil
+}
+
+// REPOSITORY
+func (r *Repository) CreatePaymentPlan(ctx context.Context, arg domain.PaymentPlan) (*domain.PaymentPlan, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreatePaymentPlan(ctx, mapper.MapPaymentPlanDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment plan: %w", err)
+	}
+
+	plan := mapper.MapPaymentPlanRowToDomain(row)
+	return &plan, nil
+}
+
+func (r *Repository) ListPaymentPlans(ctx context.Context, instituteID, studentID uuid.UUID, status domain.ApprovalStatus) ([]*domain.PaymentPlan, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListPaymentPlans(ctx, db.ListPaymentPlansParams{
+		InstituteID: instituteID,
+		StudentID:   helper.ToNullUUID(studentID),
+		Status:      helper.ToNullString(string(status)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment plans: %w", err)
+	}
+
+	plans := make([]*domain.PaymentPlan, 0, len(rows))
+	for _, row := range rows {
+		plan := mapper.MapPaymentPlanRowToDomain(row)
+		plans = append(plans, &plan)
+	}
+	return plans, nil
+}
+
+func (r *Repository) DecidePaymentPlan(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.PaymentPlan, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	row, err := q.GetPaymentPlanForUpdate(ctx, db.GetPaymentPlanForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPaymentPlanNotFound
+		}
+		return nil, err
+	}
+	if domain.ApprovalStatus(row.Status) != domain.ApprovalPending {
+		return nil, ErrPaymentPlanDecided
+	}
+	if decidedBy != nil && row.CreatedBy.Valid && row.CreatedBy.UUID == *decidedBy {
+		return nil, ErrSelfApproval
+	}
+
+	row, err = q.DecidePaymentPlan(ctx, db.DecidePaymentPlanParams{
+		Status:      string(status),
+		DecidedBy:   helper.ToNullUUID(helper.DerefUUID(decidedBy)),
+		Remarks:     helper.ToNullString(helper.StrOrEmpty(remarks)),
+		ID:          id,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decide payment plan: %w", err)
+	}
+
+	plan := mapper.MapPaymentPlanRowToDomain(row)
+	return &plan, tx.Commit()
+}
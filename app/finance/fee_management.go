@@ -3,15 +3,36 @@ package finance
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"swiftschool/domain"
 	"swiftschool/helper"
+	"swiftschool/internal/db"
 	"swiftschool/mapper"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidFeeStructure = errors.New("a fee structure needs a fee head, an amount greater than zero and a frequency of one_time, monthly, quarterly or yearly")
+	ErrInvalidFineRule     = errors.New("a fine rule needs a name, a fee head, a fine type of fixed, daily or percentage and an amount greater than zero; percentages cannot exceed 100 and grace days and caps cannot be negative")
+)
+
+var feeFrequencies = []domain.FeeFrequency{
+	domain.FeeOneTime,
+	domain.FeeMonthly,
+	domain.FeeQuarterly,
+	domain.FeeYearly,
+}
+
+var fineTypes = []domain.FineType{
+	domain.FineFixed,
+	domain.FineDaily,
+	domain.FinePercentage,
+}
+
 // =================================================================================
 // HANDLERS
 // =================================================================================
@@ -28,9 +49,17 @@ func (h *Handler) CreateFeeHead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
 	data, err := h.service.CreateFeeHead(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create fee head: "+err.Error())
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create fee head: "+err.Error())
 		return
 	}
 
@@ -43,15 +72,9 @@ func (h *Handler) ListFeeHeads(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instituteIDStr := r.URL.Query().Get("institute_id")
-	if instituteIDStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id is required")
-		return
-	}
-
-	instituteID, err := uuid.Parse(instituteIDStr)
+	instituteID, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -76,9 +99,17 @@ func (h *Handler) CreateFeeStructure(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
 	data, err := h.service.CreateFeeStructure(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create fee structure: "+err.Error())
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create fee structure: "+err.Error())
 		return
 	}
 
@@ -91,17 +122,15 @@ func (h *Handler) ListFeeStructures(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instituteIDStr := r.URL.Query().Get("institute_id")
 	sessionIDStr := r.URL.Query().Get("session_id")
-
-	if instituteIDStr == "" || sessionIDStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id and session_id are required")
+	if sessionIDStr == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "session_id is required")
 		return
 	}
 
-	instituteID, err := uuid.Parse(instituteIDStr)
+	instituteID, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -132,9 +161,17 @@ func (h *Handler) CreateFineRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
 	data, err := h.service.CreateFineRule(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create fine rule: "+err.Error())
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create fine rule: "+err.Error())
 		return
 	}
 
@@ -144,7 +181,19 @@ func (h *Handler) CreateFineRule(w http.ResponseWriter, r *http.Request) {
 // ========================= CREATE FEE HEAD =========================
 
 // SERVICE
+// CreateFeeHead checks that the linked GL account, which invoices for the
+// fee head are credited to, is an income account or, for deposits, a
+// liability
 func (s *Service) CreateFeeHead(ctx context.Context, arg domain.FeeHead) (*domain.FeeHead, error) {
+	if arg.LinkedGLAccountID != uuid.Nil {
+		acc, err := s.repo.GetAccount(ctx, arg.InstituteID, arg.LinkedGLAccountID)
+		if err != nil {
+			return nil, err
+		}
+		if acc.Type != domain.AccIncome && acc.Type != domain.AccLiability {
+			return nil, ErrFeeHeadAccountType
+		}
+	}
 	return s.repo.CreateFeeHead(ctx, arg)
 }
 
@@ -202,7 +251,13 @@ func (r *Repository) ListFeeHeads(ctx context.Context, instituteID uuid.UUID) ([
 // ========================= CREATE FEE STRUCTURE =========================
 
 // SERVICE
+// CreateFeeStructure prices a fee head for an academic session, optionally
+// for one class only. The frequency decides how often invoice runs bill it.
 func (s *Service) CreateFeeStructure(ctx context.Context, arg domain.FeeStructure) (*domain.FeeStructure, error) {
+	if arg.AcademicSessionID == uuid.Nil || arg.FeeHeadID == uuid.Nil || toPaise(arg.Amount) <= 0 ||
+		!helper.Contains(feeFrequencies, arg.Frequency) {
+		return nil, ErrInvalidFeeStructure
+	}
 	return s.repo.CreateFeeStructure(ctx, arg)
 }
 
@@ -260,7 +315,19 @@ func (r *Repository) ListFeeStructures(ctx context.Context, instituteID, session
 // ========================= CREATE FINE RULE =========================
 
 // SERVICE
+// CreateFineRule sets up a late fee. Fines are credited to the account of
+// the rule's fee head, which must be linked to the ledger.
 func (s *Service) CreateFineRule(ctx context.Context, arg domain.FineRule) (*domain.FineRule, error) {
+	if strings.TrimSpace(arg.Name) == "" || arg.FeeHeadID == uuid.Nil || !helper.Contains(fineTypes, arg.FineType) ||
+		toPaise(arg.FineAmount) <= 0 || arg.GraceDays < 0 {
+		return nil, ErrInvalidFineRule
+	}
+	if arg.FineType == domain.FinePercentage && arg.FineAmount > 100 {
+		return nil, ErrInvalidFineRule
+	}
+	if arg.MaxFineAmount != nil && toPaise(*arg.MaxFineAmount) < 0 {
+		return nil, ErrInvalidFineRule
+	}
 	return s.repo.CreateFineRule(ctx, arg)
 }
 
@@ -269,10 +336,17 @@ func (r *Repository) CreateFineRule(ctx context.Context, arg domain.FineRule) (*
 	ctx, cancel := r.db.WithTimeout(ctx)
 	defer cancel()
 
-	q, err := r.db.Queries()
+	tx, err := r.db.BeginTx(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if _, err := loadFeeHeads(ctx, q, arg.InstituteID, []domain.InvoiceItem{{FeeHeadID: &arg.FeeHeadID}}); err != nil {
+		return nil, err
+	}
 
 	params := mapper.MapFineRuleDomainToParams(arg)
 	row, err := q.CreateFineRule(ctx, params)
@@ -280,6 +354,18 @@ func (r *Repository) CreateFineRule(ctx context.Context, arg domain.FineRule) (*
 		return nil, fmt.Errorf("failed to create fine rule: %w", err)
 	}
 
+	terms := db.SetFineRuleTermsParams{
+		FeeHeadID:   helper.ToNullUUID(arg.FeeHeadID),
+		ID:          row.ID,
+		InstituteID: row.InstituteID,
+	}
+	if arg.MaxFineAmount != nil {
+		terms.MaxFineAmount = helper.ToNullString(fmt.Sprintf("%.2f", *arg.MaxFineAmount))
+	}
+	if row, err = q.SetFineRuleTerms(ctx, terms); err != nil {
+		return nil, fmt.Errorf("failed to create fine rule: %w", err)
+	}
+
 	result := mapper.MapFineRuleRowToDomain(row)
-	return &result, nil
+	return &result, tx.Commit()
 }
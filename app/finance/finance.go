@@ -3,7 +3,9 @@ package finance
 import (
 	"context"
 	"swiftschool/domain"
+	"swiftschool/helper"
 	"swiftschool/internal/database"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -38,11 +40,14 @@ func NewRepository(db *database.Database) *Repository {
 
 type Service struct {
 	repo RepositoryInterface
+	// paymentCallbackURL is where gateways send online payment results
+	paymentCallbackURL string
 }
 
-func NewService(db *database.Database) *Service {
+func NewService(db *database.Database, paymentCallbackURL string) *Service {
 	return &Service{
-		repo: NewRepository(db),
+		repo:               NewRepository(db),
+		paymentCallbackURL: paymentCallbackURL,
 	}
 }
 
@@ -69,22 +74,152 @@ type RepositoryInterface interface {
 	UpdateInvoiceStatus(ctx context.Context, id, instituteID uuid.UUID, amount float64, status domain.SaaSInvoiceStatus) error
 	GetOverdueInvoices(ctx context.Context, instituteID uuid.UUID) ([]*domain.Invoice, error)
 
+	// ========================= INVOICE GENERATION =========================
+	GetAcademicSession(ctx context.Context, instituteID, id uuid.UUID) (*domain.AcademicSession, error)
+	ListStudentsForBilling(ctx context.Context, instituteID uuid.UUID, classID *uuid.UUID) ([]*domain.Student, error)
+	ListBilledFees(ctx context.Context, instituteID uuid.UUID, studentIDs []uuid.UUID) ([]billedFee, error)
+
+	// ========================= CONCESSIONS =========================
+	CreateConcession(ctx context.Context, arg domain.Concession) (*domain.Concession, error)
+	ListConcessions(ctx context.Context, instituteID uuid.UUID) ([]*domain.Concession, error)
+	GrantStudentConcession(ctx context.Context, arg domain.StudentConcession) (*domain.StudentConcession, error)
+	DecideStudentConcession(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.StudentConcession, error)
+	ListStudentConcessions(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.StudentConcession, error)
+	ListGrantedConcessions(ctx context.Context, instituteID uuid.UUID, studentIDs []uuid.UUID, from, to time.Time) ([]grantedConcession, error)
+	ListSiblingConcessions(ctx context.Context, instituteID uuid.UUID) ([]*domain.Concession, error)
+	ListSiblingRanks(ctx context.Context, instituteID uuid.UUID, studentIDs []uuid.UUID) (map[uuid.UUID]int32, error)
+
+	// ========================= LATE FEES =========================
+	ListActiveFineRules(ctx context.Context, instituteID uuid.UUID) ([]*domain.FineRule, error)
+	ListInstitutesWithFineRules(ctx context.Context) ([]uuid.UUID, error)
+	ChargeLateFees(ctx context.Context, instituteID, invoiceID uuid.UUID, rules []*domain.FineRule, asOf time.Time, createdBy *uuid.UUID) ([]domain.InvoiceFine, error)
+	WaiveFine(ctx context.Context, arg domain.FineWaiver) (*domain.FineWaiver, error)
+	ListInvoiceFines(ctx context.Context, instituteID, invoiceID uuid.UUID) (*domain.InvoiceFines, error)
+
+	// ========================= ONLINE PAYMENTS =========================
+	GetPaymentGateway(ctx context.Context, instituteID uuid.UUID) (*storedGateway, error)
+	UpsertPaymentGateway(ctx context.Context, arg domain.PaymentGatewaySettings, secretSealed, merchantKeySealed string) (*domain.PaymentGatewaySettings, error)
+	GetGuardianInvoice(ctx context.Context, instituteID, invoiceID, userID uuid.UUID) (*domain.Invoice, error)
+	CreateOnlinePayment(ctx context.Context, arg domain.OnlinePayment) (*domain.OnlinePayment, error)
+	SetOnlinePaymentGatewayOrder(ctx context.Context, id uuid.UUID, gatewayOrderID string) (*domain.OnlinePayment, error)
+	GetOnlinePayment(ctx context.Context, instituteID, id uuid.UUID) (*domain.OnlinePayment, error)
+	GetOnlinePaymentByRef(ctx context.Context, ref string) (*domain.OnlinePayment, error)
+	CompleteOnlinePayment(ctx context.Context, ref, gatewayPaymentID string) (*domain.OnlinePayment, error)
+	FailOnlinePayment(ctx context.Context, ref, gatewayPaymentID string) (*domain.OnlinePayment, error)
+
+	// ========================= RECEIPTS =========================
+	CollectFees(ctx context.Context, arg domain.Receipt) (*domain.Receipt, error)
+	GetReceipt(ctx context.Context, instituteID, id uuid.UUID) (*domain.Receipt, error)
+	ListStudentReceipts(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.Receipt, error)
+	ListPendingCheques(ctx context.Context, instituteID uuid.UUID) ([]*domain.Receipt, error)
+	ClearCheque(ctx context.Context, instituteID, receiptID uuid.UUID, clearedBy *uuid.UUID) (*domain.Receipt, error)
+	BounceCheque(ctx context.Context, arg domain.ChequeBounce) (*domain.Receipt, error)
+
+	// ========================= STUDENT WALLETS =========================
+	MoveWallet(ctx context.Context, arg domain.WalletEntry) (*domain.WalletEntry, error)
+	GetStudentWallet(ctx context.Context, instituteID, studentID uuid.UUID) (*domain.StudentWallet, error)
+	ListWalletEntries(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.WalletEntry, error)
+	SetWalletAutoApply(ctx context.Context, arg domain.StudentWallet) (*domain.StudentWallet, error)
+
 	// ========================= TRANSACTIONS =========================
 	CreateTransaction(ctx context.Context, arg domain.Transaction) (*domain.Transaction, error)
 
+	// ========================= REFUNDS =========================
+	RequestRefund(ctx context.Context, arg domain.Refund) (*domain.Refund, error)
+	DecideRefund(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.Refund, error)
+	ProcessRefund(ctx context.Context, instituteID, id uuid.UUID, payout domain.RefundPayout, onlinePaymentID *uuid.UUID) (*domain.Refund, error)
+	ClaimGatewayRefund(ctx context.Context, instituteID, id uuid.UUID) (*domain.Refund, *domain.OnlinePayment, error)
+	ReleaseGatewayRefund(ctx context.Context, instituteID, id uuid.UUID) error
+	GetRefund(ctx context.Context, instituteID, id uuid.UUID) (*domain.Refund, error)
+	ListRefunds(ctx context.Context, instituteID uuid.UUID, status *domain.RefundStatus) ([]*domain.Refund, error)
+	GetRefundSettings(ctx context.Context, instituteID uuid.UUID) (*domain.RefundSettings, error)
+	UpsertRefundSettings(ctx context.Context, arg domain.RefundSettings) (*domain.RefundSettings, error)
+
+	// ========================= BANK RECONCILIATION =========================
+	CreateBankAccount(ctx context.Context, arg domain.BankAccount) (*domain.BankAccount, error)
+	ListBankAccounts(ctx context.Context, instituteID uuid.UUID) ([]*domain.BankAccount, error)
+	ImportBankStatement(ctx context.Context, arg domain.BankStatementImport, lines []helper.StatementLine) (*domain.BankStatementImport, error)
+	ListBankStatementImports(ctx context.Context, instituteID, bankAccountID uuid.UUID) ([]*domain.BankStatementImport, error)
+	ListBankStatementEntries(ctx context.Context, instituteID, bankAccountID uuid.UUID, f StatementEntryFilter) ([]*domain.BankStatementEntry, error)
+	AutoMatchBankStatement(ctx context.Context, instituteID, bankAccountID uuid.UUID) (int, error)
+	MatchStatementEntry(ctx context.Context, arg domain.StatementMatch) (*domain.BankStatementEntry, error)
+	UnmatchStatementEntry(ctx context.Context, instituteID, id uuid.UUID) (*domain.BankStatementEntry, error)
+	BankReconciliation(ctx context.Context, instituteID, bankAccountID uuid.UUID, asOf time.Time) (*domain.BankReconciliation, error)
+
 	// ========================= ACCOUNTING (GL) =========================
 	CreateAccount(ctx context.Context, arg domain.Account) (*domain.Account, error)
+	GetAccount(ctx context.Context, instituteID, id uuid.UUID) (*domain.Account, error)
 	ListAccounts(ctx context.Context, instituteID uuid.UUID) ([]*domain.Account, error)
 	CreateJournalEntry(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error)
 	CreateJournalItem(ctx context.Context, arg domain.JournalItem) (*domain.JournalItem, error)
-	GetAccountBalance(ctx context.Context, instituteID, accountID uuid.UUID) (float64, error)
+	PostJournal(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error)
+	PostDraftJournal(ctx context.Context, instituteID, id uuid.UUID, postedBy *uuid.UUID) (*domain.JournalEntry, error)
+	ReverseJournal(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error)
+	GetJournalEntry(ctx context.Context, instituteID, id uuid.UUID) (*domain.JournalEntry, error)
+	ListPostedTotals(ctx context.Context, instituteID uuid.UUID) ([]*domain.AccountBalance, error)
+
+	// ========================= STATEMENTS & YEAR-END =========================
+	GetFiscalYearStartMonth(ctx context.Context, instituteID uuid.UUID) (time.Month, error)
+	ListStatementAccounts(ctx context.Context, instituteID uuid.UUID, from *time.Time, to time.Time) ([]*domain.Account, error)
+	SumPostedTotals(ctx context.Context, instituteID uuid.UUID, from *time.Time, to time.Time, includeClosing bool) ([]*domain.AccountBalance, error)
+	CloseFiscalYear(ctx context.Context, arg domain.FiscalYearClosing) (*domain.FiscalYearClosing, error)
+	ListFiscalYearClosings(ctx context.Context, instituteID uuid.UUID) ([]*domain.FiscalYearClosing, error)
+
+	// ========================= AUTOMATIC POSTINGS =========================
+	GetLedgerSettings(ctx context.Context, instituteID uuid.UUID) (*domain.LedgerSettings, error)
+	UpdateLedgerSettings(ctx context.Context, arg domain.LedgerSettings) (*domain.LedgerSettings, error)
+	ListSourceJournals(ctx context.Context, instituteID uuid.UUID, sourceType domain.JournalSource, sourceID uuid.UUID) ([]*domain.JournalEntry, error)
 
 	// ========================= PROCUREMENT =========================
 	CreateVendor(ctx context.Context, arg domain.Vendor) (*domain.Vendor, error)
 	ListVendors(ctx context.Context, instituteID uuid.UUID) ([]*domain.Vendor, error)
 	CreatePurchaseOrder(ctx context.Context, arg domain.PurchaseOrder) (*domain.PurchaseOrder, error)
 	AddPurchaseItem(ctx context.Context, arg domain.PurchaseItem) (*domain.PurchaseItem, error)
-	UpdatePurchaseStatus(ctx context.Context, id, instituteID uuid.UUID, status domain.PurchaseStatus) error
+	UpdatePurchaseStatus(ctx context.Context, id, instituteID uuid.UUID, status domain.PurchaseStatus, updatedBy *uuid.UUID) (*domain.PurchaseOrder, error)
+	DecidePurchaseOrder(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.PurchaseOrder, error)
+
+	// ========================= BUDGETS =========================
+	CreateBudget(ctx context.Context, arg domain.Budget) (*domain.Budget, error)
+	UpdateBudget(ctx context.Context, arg domain.Budget, updatedBy *uuid.UUID) (*domain.Budget, error)
+	ListBudgets(ctx context.Context, instituteID, sessionID uuid.UUID, departmentID *uuid.UUID) ([]*domain.Budget, error)
+	BudgetReport(ctx context.Context, instituteID, sessionID uuid.UUID, departmentID *uuid.UUID) (*domain.BudgetReport, error)
+
+	// ========================= GST =========================
+	CreateTax(ctx context.Context, arg domain.Tax) (*domain.Tax, error)
+	ListTaxes(ctx context.Context, instituteID uuid.UUID) ([]*domain.Tax, error)
+	GetTaxSettings(ctx context.Context, instituteID uuid.UUID) (*domain.TaxSettings, error)
+	UpdateTaxSettings(ctx context.Context, arg domain.TaxSettings) (*domain.TaxSettings, error)
+	AssignFeeHeadTax(ctx context.Context, instituteID, feeHeadID uuid.UUID, taxID, updatedBy *uuid.UUID) (*domain.FeeHead, error)
+	AssignItemTax(ctx context.Context, instituteID, itemID uuid.UUID, taxID, updatedBy *uuid.UUID) (*domain.InventoryItem, error)
+	ListTaxLines(ctx context.Context, instituteID uuid.UUID, documentType string, documentID uuid.UUID) ([]*domain.TaxLine, error)
+	TaxSummary(ctx context.Context, instituteID uuid.UUID, from, to time.Time) (*domain.TaxSummary, error)
+
+	// ========================= PROCUREMENT CYCLE =========================
+	CreateRequisition(ctx context.Context, arg domain.Requisition) (*domain.Requisition, error)
+	ListRequisitions(ctx context.Context, instituteID uuid.UUID, status domain.RequisitionStatus, departmentID uuid.UUID) ([]*domain.Requisition, error)
+	RejectRequisition(ctx context.Context, instituteID, id uuid.UUID, rejectedBy *uuid.UUID) (*domain.Requisition, error)
+	ConvertRequisition(ctx context.Context, instituteID, id uuid.UUID, expenseAccountID *uuid.UUID, lines []domain.RequisitionOrderLine, convertedBy *uuid.UUID) ([]*domain.PurchaseOrder, error)
+	RecordGoodsReceipt(ctx context.Context, arg domain.GoodsReceipt) (*domain.GoodsReceipt, error)
+	ListGoodsReceipts(ctx context.Context, instituteID, orderID uuid.UUID) ([]*domain.GoodsReceipt, error)
+	CreateVendorBill(ctx context.Context, arg domain.VendorBill) (*domain.VendorBill, error)
+	ListVendorBills(ctx context.Context, instituteID, vendorID uuid.UUID, status domain.VendorBillStatus) ([]*domain.VendorBill, error)
+	DecideVendorBill(ctx context.Context, instituteID, id uuid.UUID, status domain.VendorBillStatus, remarks *string, decidedBy *uuid.UUID) (*domain.VendorBill, error)
+	PayVendorBill(ctx context.Context, arg domain.VendorPayment) (*domain.VendorPayment, error)
+	PayablesAgeing(ctx context.Context, instituteID uuid.UUID, asOf time.Time) (*domain.PayablesAgeing, error)
+
+	// ========================= FEE DUNNING =========================
+	FeeDefaulterAgeing(ctx context.Context, instituteID, classID uuid.UUID, asOf time.Time) (*domain.FeeDefaulterAgeing, error)
+	GetDunningSchedule(ctx context.Context, instituteID uuid.UUID) ([]*domain.DunningStep, error)
+	SetDunningSchedule(ctx context.Context, instituteID uuid.UUID, steps []domain.DunningStep, updatedBy *uuid.UUID) ([]*domain.DunningStep, error)
+	ListInstitutesWithDunningSteps(ctx context.Context) ([]uuid.UUID, error)
+	ListDunningDue(ctx context.Context, instituteID uuid.UUID, asOf time.Time) ([]*domain.DunningNotice, error)
+	ClaimDunningReminder(ctx context.Context, arg domain.DunningReminder) (*domain.DunningReminder, error)
+	SetDunningReminderDelivery(ctx context.Context, arg domain.DunningReminder) (*domain.DunningReminder, error)
+	CreateNotification(ctx context.Context, arg domain.Notification) error
+	ListDunningReminders(ctx context.Context, instituteID, studentID, invoiceID uuid.UUID) ([]*domain.DunningReminder, error)
+	CreatePaymentPlan(ctx context.Context, arg domain.PaymentPlan) (*domain.PaymentPlan, error)
+	ListPaymentPlans(ctx context.Context, instituteID, studentID uuid.UUID, status domain.ApprovalStatus) ([]*domain.PaymentPlan, error)
+	DecidePaymentPlan(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.PaymentPlan, error)
 }
 
 //////////////////////////////////////////////////////
@@ -110,20 +245,133 @@ type ServiceInterface interface {
 	UpdateInvoiceStatus(ctx context.Context, id, instituteID uuid.UUID, amount float64, status domain.SaaSInvoiceStatus) error
 	GetOverdueInvoices(ctx context.Context, instituteID uuid.UUID) ([]*domain.Invoice, error)
 
+	// ========================= INVOICE GENERATION =========================
+	GenerateInvoices(ctx context.Context, arg domain.InvoiceRun) (*domain.InvoiceRunResult, error)
+
+	// ========================= CONCESSIONS =========================
+	CreateConcession(ctx context.Context, arg domain.Concession) (*domain.Concession, error)
+	ListConcessions(ctx context.Context, instituteID uuid.UUID) ([]*domain.Concession, error)
+	GrantStudentConcession(ctx context.Context, arg domain.StudentConcession) (*domain.StudentConcession, error)
+	DecideStudentConcession(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.StudentConcession, error)
+	ListStudentConcessions(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.StudentConcession, error)
+
+	// ========================= LATE FEES =========================
+	ApplyLateFees(ctx context.Context, instituteID uuid.UUID, appliedBy *uuid.UUID) (*domain.LateFeeRun, error)
+	WaiveFine(ctx context.Context, arg domain.FineWaiver) (*domain.FineWaiver, error)
+	ListInvoiceFines(ctx context.Context, instituteID, invoiceID uuid.UUID) (*domain.InvoiceFines, error)
+
+	// ========================= ONLINE PAYMENTS =========================
+	GetPaymentGateway(ctx context.Context, instituteID uuid.UUID) (*domain.PaymentGatewaySettings, error)
+	UpdatePaymentGateway(ctx context.Context, arg domain.PaymentGatewaySettings) (*domain.PaymentGatewaySettings, error)
+	StartCheckout(ctx context.Context, instituteID, invoiceID, userID uuid.UUID) (*domain.OnlinePayment, error)
+	GetCheckout(ctx context.Context, instituteID, id, userID uuid.UUID) (*domain.OnlinePayment, error)
+	HandlePaymentCallback(ctx context.Context, ref string, payload map[string]string) (*domain.OnlinePayment, error)
+
+	// ========================= RECEIPTS =========================
+	CollectFees(ctx context.Context, arg domain.Receipt) (*domain.Receipt, error)
+	GetReceipt(ctx context.Context, instituteID, id uuid.UUID) (*domain.Receipt, error)
+	ListStudentReceipts(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.Receipt, error)
+	ListPendingCheques(ctx context.Context, instituteID uuid.UUID) ([]*domain.Receipt, error)
+	ClearCheque(ctx context.Context, instituteID, receiptID uuid.UUID, clearedBy *uuid.UUID) (*domain.Receipt, error)
+	BounceCheque(ctx context.Context, arg domain.ChequeBounce) (*domain.Receipt, error)
+
+	// ========================= STUDENT WALLETS =========================
+	TopUpWallet(ctx context.Context, arg domain.WalletEntry) (*domain.WalletEntry, error)
+	DebitWallet(ctx context.Context, arg domain.WalletEntry) (*domain.WalletEntry, error)
+	GetStudentWallet(ctx context.Context, instituteID, studentID uuid.UUID) (*domain.StudentWallet, error)
+	ListWalletEntries(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.WalletEntry, error)
+	SetWalletAutoApply(ctx context.Context, arg domain.StudentWallet) (*domain.StudentWallet, error)
+
 	// ========================= TRANSACTIONS =========================
 	CreateTransaction(ctx context.Context, arg domain.Transaction) (*domain.Transaction, error)
 
+	// ========================= REFUNDS =========================
+	RequestRefund(ctx context.Context, arg domain.Refund) (*domain.Refund, error)
+	DecideRefund(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.Refund, error)
+	ProcessRefund(ctx context.Context, instituteID, id uuid.UUID, payout domain.RefundPayout) (*domain.Refund, error)
+	GetRefund(ctx context.Context, instituteID, id uuid.UUID) (*domain.Refund, error)
+	ListRefunds(ctx context.Context, instituteID uuid.UUID, status *domain.RefundStatus) ([]*domain.Refund, error)
+	GetRefundSettings(ctx context.Context, instituteID uuid.UUID) (*domain.RefundSettings, error)
+	UpdateRefundSettings(ctx context.Context, arg domain.RefundSettings) (*domain.RefundSettings, error)
+
+	// ========================= BANK RECONCILIATION =========================
+	CreateBankAccount(ctx context.Context, arg domain.BankAccount) (*domain.BankAccount, error)
+	ListBankAccounts(ctx context.Context, instituteID uuid.UUID) ([]*domain.BankAccount, error)
+	ImportBankStatement(ctx context.Context, arg domain.BankStatementImport) (*domain.BankStatementImport, error)
+	ListBankStatementImports(ctx context.Context, instituteID, bankAccountID uuid.UUID) ([]*domain.BankStatementImport, error)
+	ListBankStatementEntries(ctx context.Context, instituteID, bankAccountID uuid.UUID, f StatementEntryFilter) ([]*domain.BankStatementEntry, error)
+	AutoMatchBankStatement(ctx context.Context, instituteID, bankAccountID uuid.UUID) (int, error)
+	MatchStatementEntry(ctx context.Context, arg domain.StatementMatch) (*domain.BankStatementEntry, error)
+	UnmatchStatementEntry(ctx context.Context, instituteID, id uuid.UUID) (*domain.BankStatementEntry, error)
+	BankReconciliation(ctx context.Context, instituteID, bankAccountID uuid.UUID, asOf *time.Time) (*domain.BankReconciliation, error)
+
 	// ========================= ACCOUNTING (GL) =========================
 	CreateAccount(ctx context.Context, arg domain.Account) (*domain.Account, error)
 	ListAccounts(ctx context.Context, instituteID uuid.UUID) ([]*domain.Account, error)
 	CreateJournalEntry(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error)
 	CreateJournalItem(ctx context.Context, arg domain.JournalItem) (*domain.JournalItem, error)
-	GetAccountBalance(ctx context.Context, instituteID, accountID uuid.UUID) (float64, error)
+	PostJournal(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error)
+	PostDraftJournal(ctx context.Context, instituteID, id uuid.UUID, postedBy *uuid.UUID) (*domain.JournalEntry, error)
+	ReverseJournal(ctx context.Context, arg domain.JournalEntry) (*domain.JournalEntry, error)
+	GetJournalEntry(ctx context.Context, instituteID, id uuid.UUID) (*domain.JournalEntry, error)
+	GetAccountBalance(ctx context.Context, instituteID, accountID uuid.UUID) (*domain.AccountBalance, error)
+
+	// ========================= STATEMENTS & YEAR-END =========================
+	TrialBalance(ctx context.Context, instituteID uuid.UUID, p StatementPeriod) (*domain.TrialBalance, error)
+	IncomeStatement(ctx context.Context, instituteID uuid.UUID, p StatementPeriod) (*domain.IncomeStatement, error)
+	BalanceSheet(ctx context.Context, instituteID uuid.UUID, p StatementPeriod) (*domain.BalanceSheet, error)
+	CloseFiscalYear(ctx context.Context, instituteID uuid.UUID, fiscalYear int, retainedEarningsID uuid.UUID, closedBy *uuid.UUID) (*domain.FiscalYearClosing, error)
+	ListFiscalYearClosings(ctx context.Context, instituteID uuid.UUID) ([]*domain.FiscalYearClosing, error)
+
+	// ========================= AUTOMATIC POSTINGS =========================
+	GetLedgerSettings(ctx context.Context, instituteID uuid.UUID) (*domain.LedgerSettings, error)
+	UpdateLedgerSettings(ctx context.Context, arg domain.LedgerSettings) (*domain.LedgerSettings, error)
+	ListSourceJournals(ctx context.Context, instituteID uuid.UUID, sourceType domain.JournalSource, sourceID uuid.UUID) ([]*domain.JournalEntry, error)
 
 	// ========================= PROCUREMENT =========================
 	CreateVendor(ctx context.Context, arg domain.Vendor) (*domain.Vendor, error)
 	ListVendors(ctx context.Context, instituteID uuid.UUID) ([]*domain.Vendor, error)
 	CreatePurchaseOrder(ctx context.Context, arg domain.PurchaseOrder) (*domain.PurchaseOrder, error)
 	AddPurchaseItem(ctx context.Context, arg domain.PurchaseItem) (*domain.PurchaseItem, error)
-	UpdatePurchaseStatus(ctx context.Context, id, instituteID uuid.UUID, status domain.PurchaseStatus) error
+	UpdatePurchaseStatus(ctx context.Context, id, instituteID uuid.UUID, status domain.PurchaseStatus, updatedBy *uuid.UUID) (*domain.PurchaseOrder, error)
+	DecidePurchaseOrder(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.PurchaseOrder, error)
+
+	// ========================= BUDGETS =========================
+	CreateBudget(ctx context.Context, arg domain.Budget) (*domain.Budget, error)
+	UpdateBudget(ctx context.Context, arg domain.Budget, updatedBy *uuid.UUID) (*domain.Budget, error)
+	ListBudgets(ctx context.Context, instituteID, sessionID uuid.UUID, departmentID *uuid.UUID) ([]*domain.Budget, error)
+	BudgetReport(ctx context.Context, instituteID, sessionID uuid.UUID, departmentID *uuid.UUID) (*domain.BudgetReport, error)
+
+	// ========================= GST =========================
+	CreateTax(ctx context.Context, arg domain.Tax) (*domain.Tax, error)
+	ListTaxes(ctx context.Context, instituteID uuid.UUID) ([]*domain.Tax, error)
+	GetTaxSettings(ctx context.Context, instituteID uuid.UUID) (*domain.TaxSettings, error)
+	UpdateTaxSettings(ctx context.Context, arg domain.TaxSettings) (*domain.TaxSettings, error)
+	AssignFeeHeadTax(ctx context.Context, instituteID, feeHeadID uuid.UUID, taxID, updatedBy *uuid.UUID) (*domain.FeeHead, error)
+	AssignItemTax(ctx context.Context, instituteID, itemID uuid.UUID, taxID, updatedBy *uuid.UUID) (*domain.InventoryItem, error)
+	ListTaxLines(ctx context.Context, instituteID uuid.UUID, documentType string, documentID uuid.UUID) ([]*domain.TaxLine, error)
+	TaxSummary(ctx context.Context, instituteID uuid.UUID, from, to time.Time) (*domain.TaxSummary, error)
+
+	// ========================= PROCUREMENT CYCLE =========================
+	CreateRequisition(ctx context.Context, arg domain.Requisition) (*domain.Requisition, error)
+	ListRequisitions(ctx context.Context, instituteID uuid.UUID, status domain.RequisitionStatus, departmentID uuid.UUID) ([]*domain.Requisition, error)
+	RejectRequisition(ctx context.Context, instituteID, id uuid.UUID, rejectedBy *uuid.UUID) (*domain.Requisition, error)
+	ConvertRequisition(ctx context.Context, instituteID, id uuid.UUID, expenseAccountID *uuid.UUID, lines []domain.RequisitionOrderLine, convertedBy *uuid.UUID) ([]*domain.PurchaseOrder, error)
+	RecordGoodsReceipt(ctx context.Context, arg domain.GoodsReceipt) (*domain.GoodsReceipt, error)
+	ListGoodsReceipts(ctx context.Context, instituteID, orderID uuid.UUID) ([]*domain.GoodsReceipt, error)
+	CreateVendorBill(ctx context.Context, arg domain.VendorBill) (*domain.VendorBill, error)
+	ListVendorBills(ctx context.Context, instituteID, vendorID uuid.UUID, status domain.VendorBillStatus) ([]*domain.VendorBill, error)
+	DecideVendorBill(ctx context.Context, instituteID, id uuid.UUID, status domain.VendorBillStatus, remarks *string, decidedBy *uuid.UUID) (*domain.VendorBill, error)
+	PayVendorBill(ctx context.Context, arg domain.VendorPayment) (*domain.VendorPayment, error)
+	PayablesAgeing(ctx context.Context, instituteID uuid.UUID, asOf *time.Time) (*domain.PayablesAgeing, error)
+
+	// ========================= FEE DUNNING =========================
+	FeeDefaulterAgeing(ctx context.Context, instituteID, classID uuid.UUID, asOf *time.Time) (*domain.FeeDefaulterAgeing, error)
+	GetDunningSchedule(ctx context.Context, instituteID uuid.UUID) ([]*domain.DunningStep, error)
+	SetDunningSchedule(ctx context.Context, instituteID uuid.UUID, steps []domain.DunningStep, updatedBy *uuid.UUID) ([]*domain.DunningStep, error)
+	RunDunning(ctx context.Context, instituteID uuid.UUID) (*domain.DunningRun, error)
+	ListDunningReminders(ctx context.Context, instituteID, studentID, invoiceID uuid.UUID) ([]*domain.DunningReminder, error)
+	CreatePaymentPlan(ctx context.Context, arg domain.PaymentPlan) (*domain.PaymentPlan, error)
+	ListPaymentPlans(ctx context.Context, instituteID, studentID uuid.UUID, status domain.ApprovalStatus) ([]*domain.PaymentPlan, error)
+	DecidePaymentPlan(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.PaymentPlan, error)
 }
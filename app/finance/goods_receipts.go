@@ -0,0 +1,385 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidGoodsReceipt   = errors.New("a goods receipt needs a purchase order, a location and items with a quantity greater than zero")
+	ErrLocationNotFound      = errors.New("stock location not found")
+	ErrPurchaseItemNotFound  = errors.New("item is not on the purchase order")
+	ErrPurchaseNotReceivable = errors.New("goods can only be received against ordered or partially received purchase orders")
+	ErrOverReceipt           = errors.New("received quantity exceeds what is still to be delivered on the order item")
+)
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) RecordGoodsReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.GoodsReceipt
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.RecordGoodsReceipt(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to record goods receipt: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "goods receipt recorded", data)
+}
+
+func (h *Handler) ListGoodsReceipts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	orderID, err := helper.ParseRequiredUUIDFromQuery(r, "purchase_order_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid purchase_order_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListGoodsReceipts(r.Context(), inst, orderID)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to list goods receipts: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "goods receipts fetched successfully", data)
+}
+
+// ========================= RECORD GOODS RECEIPT =========================
+
+// SERVICE
+// RecordGoodsReceipt takes delivery of some or all of the items still due on
+// a placed order into a stock location. Stock levels go up by what arrived;
+// its value at the order's prices, with its share of the order's GST, is
+// debited to the order's expense account, or purchases, and credited to
+// payables, and the GST then moves to the input tax accounts. The budget
+// commitment turns into actual spend as goods arrive. The order becomes
+// received once nothing is left to deliver.
+func (s *Service) RecordGoodsReceipt(ctx context.Context, arg domain.GoodsReceipt) (*domain.GoodsReceipt, error) {
+	if arg.PurchaseOrderID == uuid.Nil || arg.LocationID == uuid.Nil || len(arg.Items) == 0 {
+		return nil, ErrInvalidGoodsReceipt
+	}
+	for _, item := range arg.Items {
+		if item.PurchaseOrderItemID == uuid.Nil || item.Quantity <= 0 {
+			return nil, ErrInvalidGoodsReceipt
+		}
+	}
+	if arg.ReceivedOn.IsZero() {
+		arg.ReceivedOn = time.Now()
+	}
+	arg.ReceivedOn = dateOnly(arg.ReceivedOn)
+
+	receipt, err := s.repo.RecordGoodsReceipt(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("goods receipt %s recorded against purchase order %s for %.2f", receipt.ID, receipt.PurchaseOrderID, receipt.Amount)
+	return receipt, nil
+}
+
+// REPOSITORY
+func (r *Repository) RecordGoodsReceipt(ctx context.Context, arg domain.GoodsReceipt) (*domain.GoodsReceipt, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	po, err := lockPurchaseOrder(ctx, q, arg.InstituteID, arg.PurchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if po.Status != domain.PurchaseOrdered && po.Status != domain.PurchasePartiallyReceived {
+		return nil, ErrPurchaseNotReceivable
+	}
+
+	if _, err := q.GetLocation(ctx, db.GetLocationParams{ID: arg.LocationID, InstituteID: arg.InstituteID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLocationNotFound
+		}
+		return nil, err
+	}
+
+	items, err := lockPurchaseItems(ctx, q, po)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*domain.PurchaseItem, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+
+	settings, err := loadLedgerSettings(ctx, q, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+	expense := settings.PurchasesAccountID
+	if po.ExpenseAccountID != nil {
+		expense = *po.ExpenseAccountID
+	}
+
+	row, err := q.CreateGoodsReceipt(ctx, db.CreateGoodsReceiptParams{
+		InstituteID:     arg.InstituteID,
+		PurchaseOrderID: po.ID,
+		LocationID:      arg.LocationID,
+		ReceivedOn:      arg.ReceivedOn,
+		ReferenceNo:     helper.ToNullString(helper.StrOrEmpty(arg.ReferenceNo)),
+		Remarks:         helper.ToNullString(helper.StrOrEmpty(arg.Remarks)),
+		CreatedBy:       helper.ToNullUUID(helper.DerefUUID(arg.CreatedBy)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create goods receipt: %w", err)
+	}
+	receipt := mapper.MapGoodsReceiptRowToDomain(row)
+
+	lines := newJournalLines()
+	taxes := &receiptTaxes{po: po, receipt: receipt}
+	var value int64
+	for _, in := range arg.Items {
+		item, ok := byID[in.PurchaseOrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPurchaseItemNotFound, in.PurchaseOrderItemID)
+		}
+		if left := item.Quantity - item.ReceivedQuantity; in.Quantity > left {
+			return nil, fmt.Errorf("%w: %d of %d left on %s", ErrOverReceipt, in.Quantity, left, item.ID)
+		}
+
+		net := int64(in.Quantity) * toPaise(item.UnitPrice)
+		tax := lineShare(*item, item.ReceivedQuantity+in.Quantity) - lineShare(*item, item.ReceivedQuantity)
+		item.ReceivedQuantity += in.Quantity
+
+		itemRow, err := q.CreateGoodsReceiptItem(ctx, db.CreateGoodsReceiptItemParams{
+			InstituteID:         arg.InstituteID,
+			GoodsReceiptID:      receipt.ID,
+			PurchaseOrderItemID: item.ID,
+			ItemID:              item.ItemID,
+			Quantity:            int32(in.Quantity),
+			UnitPrice:           fmt.Sprintf("%.2f", item.UnitPrice),
+			TaxAmount:           fmt.Sprintf("%.2f", fromPaise(tax)),
+			Amount:              fmt.Sprintf("%.2f", fromPaise(net+tax)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add goods receipt item: %w", err)
+		}
+		received := mapper.MapGoodsReceiptItemRowToDomain(itemRow)
+		receipt.Items = append(receipt.Items, received)
+
+		if _, err := q.AddPurchaseItemReceived(ctx, db.AddPurchaseItemReceivedParams{
+			Quantity:    int32(in.Quantity),
+			ID:          item.ID,
+			InstituteID: helper.ToNullUUID(receipt.InstituteID),
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update received quantity: %w", err)
+		}
+
+		if err := stockReceivedItem(ctx, q, receipt, received); err != nil {
+			return nil, err
+		}
+
+		lines.debit(expense, net+tax)
+		lines.credit(settings.PayablesAccountID, net+tax)
+		if item.TaxID != nil {
+			if err := taxes.add(ctx, q, received, *item.TaxID, expense, lines); err != nil {
+				return nil, err
+			}
+		}
+		value += net + tax
+	}
+
+	if row, err = q.SetGoodsReceiptAmount(ctx, db.SetGoodsReceiptAmountParams{
+		Amount:      fmt.Sprintf("%.2f", fromPaise(value)),
+		ID:          receipt.ID,
+		InstituteID: receipt.InstituteID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update goods receipt amount: %w", err)
+	}
+	receipt.Amount = mapper.MapGoodsReceiptRowToDomain(row).Amount
+
+	ref := referenceOr(receipt.ReferenceNo, "GRN-"+receipt.ID.String()[:8])
+	poRef := referenceOr(po.ReferenceNo, "PO-"+po.ID.String()[:8])
+	entry := sourceEntry(receipt.InstituteID, domain.JournalSourceGoodsReceipt, receipt.ID, ref, "Goods received against "+poRef, arg.CreatedBy)
+	entry.TransactionDate = receipt.ReceivedOn
+	if _, err := postSourceJournal(ctx, q, entry, lines); err != nil {
+		return nil, err
+	}
+
+	if err := receivePurchaseBudget(ctx, q, po, value, receipt.ReceivedOn); err != nil {
+		return nil, err
+	}
+
+	status := domain.PurchaseReceived
+	for _, item := range items {
+		if item.ReceivedQuantity < item.Quantity {
+			status = domain.PurchasePartiallyReceived
+		}
+	}
+	if status == domain.PurchaseReceived {
+		if err := releasePurchase(ctx, q, po); err != nil {
+			return nil, err
+		}
+	}
+	if status != po.Status {
+		if _, err := q.UpdatePurchaseOrderStatus(ctx, db.UpdatePurchaseOrderStatusParams{
+			Status:      helper.ToNullString(string(status)),
+			UpdatedBy:   helper.ToNullUUID(helper.DerefUUID(arg.CreatedBy)),
+			ID:          po.ID,
+			InstituteID: po.InstituteID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update purchase status: %w", err)
+		}
+	}
+
+	return &receipt, tx.Commit()
+}
+
+// stockReceivedItem adds a received item to the stock level of the receipt's
+// location and records the purchase in the item's stock movements
+func stockReceivedItem(ctx context.Context, q *db.Queries, receipt domain.GoodsReceipt, item domain.GoodsReceiptItem) error {
+	if _, err := q.AddStockLevel(ctx, db.AddStockLevelParams{
+		InstituteID: receipt.InstituteID,
+		ItemID:      helper.ToNullUUID(item.ItemID),
+		LocationID:  helper.ToNullUUID(receipt.LocationID),
+		Quantity:    sql.NullInt32{Int32: int32(item.Quantity), Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to update stock level: %w", err)
+	}
+
+	remarks := "goods receipt " + referenceOr(receipt.ReferenceNo, receipt.ID.String()[:8])
+	if _, err := q.CreatePurchaseStockTransaction(ctx, db.CreatePurchaseStockTransactionParams{
+		InstituteID:     receipt.InstituteID,
+		ItemID:          helper.ToNullUUID(item.ItemID),
+		Quantity:        sql.NullInt32{Int32: int32(item.Quantity), Valid: true},
+		UnitPrice:       helper.ToNullString(fmt.Sprintf("%.2f", item.UnitPrice)),
+		Remarks:         helper.ToNullString(remarks),
+		TransactionDate: helper.ToNullTime(receipt.ReceivedOn),
+		CreatedBy:       helper.ToNullUUID(helper.DerefUUID(receipt.CreatedBy)),
+	}); err != nil {
+		return fmt.Errorf("failed to record stock transaction: %w", err)
+	}
+	return nil
+}
+
+// lineShare is the part of an order item's GST, in paise, that falls on its
+// first quantity units. Shares of successive deliveries add up to the full
+// tax once the item is complete.
+func lineShare(item domain.PurchaseItem, quantity int) int64 {
+	if item.Quantity == 0 {
+		return 0
+	}
+	return toPaise(item.TaxAmount) * int64(quantity) / int64(item.Quantity)
+}
+
+// lockPurchaseItems reads the items of a locked order for update
+func lockPurchaseItems(ctx context.Context, q *db.Queries, po domain.PurchaseOrder) ([]domain.PurchaseItem, error) {
+	rows, err := q.ListPurchaseOrderItemsForUpdate(ctx, db.ListPurchaseOrderItemsForUpdateParams{
+		PurchaseOrderID: helper.ToNullUUID(po.ID),
+		InstituteID:     helper.ToNullUUID(po.InstituteID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list purchase order items: %w", err)
+	}
+
+	items := make([]domain.PurchaseItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, mapper.MapPurchaseItemRowToDomain(row))
+	}
+	return items, nil
+}
+
+// ========================= LIST GOODS RECEIPTS =========================
+
+// SERVICE
+func (s *Service) ListGoodsReceipts(ctx context.Context, instituteID, orderID uuid.UUID) ([]*domain.GoodsReceipt, error) {
+	return s.repo.ListGoodsReceipts(ctx, instituteID, orderID)
+}
+
+// REPOSITORY
+func (r *Repository) ListGoodsReceipts(ctx context.Context, instituteID, orderID uuid.UUID) ([]*domain.GoodsReceipt, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListGoodsReceipts(ctx, db.ListGoodsReceiptsParams{
+		PurchaseOrderID: orderID,
+		InstituteID:     instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list goods receipts: %w", err)
+	}
+
+	receipts := make([]*domain.GoodsReceipt, 0, len(rows))
+	byID := make(map[uuid.UUID]*domain.GoodsReceipt, len(rows))
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		receipt := mapper.MapGoodsReceiptRowToDomain(row)
+		receipts = append(receipts, &receipt)
+		byID[receipt.ID] = &receipt
+		ids = append(ids, receipt.ID)
+	}
+	if len(ids) == 0 {
+		return receipts, nil
+	}
+
+	itemRows, err := q.ListGoodsReceiptItems(ctx, db.ListGoodsReceiptItemsParams{
+		GoodsReceiptIds: ids,
+		InstituteID:     instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list goods receipt items: %w", err)
+	}
+	for _, row := range itemRows {
+		item := mapper.MapGoodsReceiptItemRowToDomain(row)
+		byID[item.GoodsReceiptID].Items = append(byID[item.GoodsReceiptID].Items, item)
+	}
+
+	return receipts, nil
+}
@@ -0,0 +1,554 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrFineRuleNotFound  = errors.New("fine rule not found")
+	ErrInvalidFineWaiver = errors.New("a fine waiver needs an invoice, a fine rule, an amount greater than zero, a reason and an approver")
+	ErrWaiverExceedsFine = errors.New("waiver exceeds the unpaid fine the rule charged on the invoice")
+	ErrNoFinesToWaiveYet = errors.New("the fine rule has not charged this invoice")
+)
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) ApplyLateFees(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ApplyLateFees(r.Context(), inst, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to apply late fees: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "late fees applied successfully", data)
+}
+
+func (h *Handler) ListOverdueInvoices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetOverdueInvoices(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch overdue invoices: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "overdue invoices fetched successfully", data)
+}
+
+func (h *Handler) WaiveFine(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.FineWaiver
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.ApprovedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.WaiveFine(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to waive fine: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "fine waived successfully", data)
+}
+
+func (h *Handler) ListInvoiceFines(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	invoiceID, err := helper.ParseRequiredUUIDFromQuery(r, "invoice_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid invoice_id: "+err.Error())
+		return
+	}
+
+	data, err := h.service.ListInvoiceFines(r.Context(), inst, invoiceID)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to fetch invoice fines: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "invoice fines fetched successfully", data)
+}
+
+// ========================= APPLY LATE FEES =========================
+
+// SERVICE
+// ApplyLateFees charges the institute's active fine rules on its overdue
+// invoices. Each rule's fine on an invoice is worked out from scratch and
+// only the increase since the last run is charged, so the job can run any
+// number of times a day.
+func (s *Service) ApplyLateFees(ctx context.Context, instituteID uuid.UUID, appliedBy *uuid.UUID) (*domain.LateFeeRun, error) {
+	asOf := dateOnly(time.Now())
+	run := &domain.LateFeeRun{AsOf: asOf, Fines: []domain.InvoiceFine{}}
+
+	rules, err := s.repo.ListActiveFineRules(ctx, instituteID)
+	if err != nil || len(rules) == 0 {
+		return run, err
+	}
+	invoices, err := s.repo.GetOverdueInvoices(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, inv := range invoices {
+		fines, err := s.repo.ChargeLateFees(ctx, instituteID, inv.ID, rules, asOf, appliedBy)
+		if err != nil {
+			return nil, fmt.Errorf("late fees stopped at invoice %s: %w", inv.InvoiceNo, err)
+		}
+		if len(fines) > 0 {
+			run.InvoicesFined++
+		}
+		for _, f := range fines {
+			total += toPaise(f.Amount)
+			run.Fines = append(run.Fines, f)
+		}
+	}
+	run.TotalFined = fromPaise(total)
+
+	if run.InvoicesFined > 0 {
+		logger.Infof("late fees of %.2f charged on %d invoices of institute %s", run.TotalFined, run.InvoicesFined, instituteID)
+	}
+	return run, nil
+}
+
+// applyAllLateFees runs ApplyLateFees for every institute with active
+// fine rules. A failing institute does not stop the others.
+func (s *Service) applyAllLateFees(ctx context.Context) {
+	institutes, err := s.repo.ListInstitutesWithFineRules(ctx)
+	if err != nil {
+		logger.Errorf("late fee job failed to list institutes: %v", err)
+		return
+	}
+	for _, inst := range institutes {
+		if _, err := s.ApplyLateFees(ctx, inst, nil); err != nil {
+			logger.Errorf("late fee job failed for institute %s: %v", inst, err)
+		}
+	}
+}
+
+// StartLateFeeJob applies late fees for all institutes at startup and then
+// every interval until ctx is done
+func StartLateFeeJob(ctx context.Context, s *Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		s.applyAllLateFees(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.applyAllLateFees(ctx)
+			}
+		}
+	}()
+}
+
+// REPOSITORY
+func (r *Repository) ListActiveFineRules(ctx context.Context, instituteID uuid.UUID) ([]*domain.FineRule, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListActiveFineRules(ctx, instituteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fine rules: %w", err)
+	}
+
+	rules := make([]*domain.FineRule, 0, len(rows))
+	for _, row := range rows {
+		rule := mapper.MapFineRuleRowToDomain(row)
+		rules = append(rules, &rule)
+	}
+	return rules, nil
+}
+
+func (r *Repository) ListInstitutesWithFineRules(ctx context.Context) ([]uuid.UUID, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	return q.ListInstitutesWithFineRules(ctx)
+}
+
+// ChargeLateFees charges each rule's increase in fine on one invoice. The
+// invoice stays locked while its fines are worked out, so concurrent runs
+// cannot charge the same increase twice.
+func (r *Repository) ChargeLateFees(ctx context.Context, instituteID, invoiceID uuid.UUID, rules []*domain.FineRule, asOf time.Time, createdBy *uuid.UUID) ([]domain.InvoiceFine, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	settings, err := loadLedgerSettings(ctx, q, instituteID)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := lockInvoice(ctx, q, instituteID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	principal := invoicePrincipalDue(invoice)
+	if invoice.DueDate == nil || principal <= 0 {
+		return nil, nil
+	}
+
+	var items []domain.InvoiceItem
+	for _, rule := range rules {
+		items = append(items, domain.InvoiceItem{FeeHeadID: &rule.FeeHeadID})
+	}
+	heads, err := loadFeeHeads(ctx, q, instituteID, items)
+	if err != nil {
+		return nil, err
+	}
+	charged, err := sumFinesByRule(ctx, q, instituteID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	for _, c := range charged {
+		total += c
+	}
+	ceiling, capped := fineCeiling(rules)
+
+	var fines []domain.InvoiceFine
+	for _, rule := range rules {
+		days := int(asOf.Sub(dateOnly(*invoice.DueDate)).Hours()/24) - rule.GraceDays
+		if days <= 0 {
+			continue
+		}
+		increase := fineDue(*rule, days, principal, charged[rule.ID]) - charged[rule.ID]
+		if capped {
+			increase = min(increase, ceiling-total)
+		}
+		if increase <= 0 {
+			continue
+		}
+		total += increase
+
+		row, err := q.CreateInvoiceFine(ctx, db.CreateInvoiceFineParams{
+			InstituteID: instituteID,
+			InvoiceID:   invoiceID,
+			FineRuleID:  rule.ID,
+			AssessedOn:  asOf,
+			DaysOverdue: int32(days),
+			Amount:      fmt.Sprintf("%.2f", fromPaise(increase)),
+			CreatedBy:   helper.ToNullUUID(helper.DerefUUID(createdBy)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to record fine: %w", err)
+		}
+		fine := mapper.MapInvoiceFineRowToDomain(row)
+
+		lines := newJournalLines()
+		lines.debit(settings.ReceivablesAccountID, increase)
+		lines.credit(heads[rule.FeeHeadID].LinkedGlAccountID.UUID, increase)
+
+		entry := sourceEntry(instituteID, domain.JournalSourceInvoiceFine, fine.ID,
+			invoice.InvoiceNo, rule.Name+" on invoice "+invoice.InvoiceNo, createdBy)
+		if _, err := postSourceJournal(ctx, q, entry, lines); err != nil {
+			return nil, err
+		}
+
+		invoice.FineAmount = fromPaise(toPaise(invoice.FineAmount) + increase)
+		fines = append(fines, fine)
+	}
+	if len(fines) == 0 {
+		return nil, nil
+	}
+
+	if _, err := saveInvoiceAmounts(ctx, q, invoice, createdBy); err != nil {
+		return nil, err
+	}
+	return fines, tx.Commit()
+}
+
+// ========================= WAIVE FINE =========================
+
+// SERVICE
+// WaiveFine forgives part or all of the fine a rule charged on an invoice.
+// Only fine that is still unpaid can be waived; the waiver reverses the
+// fine income.
+func (s *Service) WaiveFine(ctx context.Context, arg domain.FineWaiver) (*domain.FineWaiver, error) {
+	arg.Reason = strings.TrimSpace(arg.Reason)
+	if arg.InvoiceID == uuid.Nil || arg.FineRuleID == uuid.Nil || toPaise(arg.Amount) <= 0 ||
+		arg.Reason == "" || arg.ApprovedBy == nil {
+		return nil, ErrInvalidFineWaiver
+	}
+
+	waiver, err := s.repo.WaiveFine(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("fine of %.2f waived on invoice %s", waiver.Amount, waiver.InvoiceID)
+	return waiver, nil
+}
+
+// REPOSITORY
+func (r *Repository) WaiveFine(ctx context.Context, arg domain.FineWaiver) (*domain.FineWaiver, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	settings, err := loadLedgerSettings(ctx, q, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := lockInvoice(ctx, q, arg.InstituteID, arg.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := q.GetFineRule(ctx, db.GetFineRuleParams{ID: arg.FineRuleID, InstituteID: arg.InstituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFineRuleNotFound
+		}
+		return nil, err
+	}
+	heads, err := loadFeeHeads(ctx, q, arg.InstituteID, []domain.InvoiceItem{{FeeHeadID: &rule.FeeHeadID.UUID}})
+	if err != nil {
+		return nil, err
+	}
+
+	charged, err := sumFinesByRule(ctx, q, arg.InstituteID, arg.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if charged[rule.ID] == 0 {
+		return nil, ErrNoFinesToWaiveYet
+	}
+	waived, err := sumWaiversByRule(ctx, q, arg.InstituteID, arg.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := toPaise(arg.Amount)
+	unpaid := invoiceDue(invoice) - toPaise(invoice.PaidAmount)
+	if amount > charged[rule.ID]-waived[rule.ID] || amount > unpaid {
+		return nil, ErrWaiverExceedsFine
+	}
+
+	row, err := q.CreateInvoiceFineWaiver(ctx, mapper.MapFineWaiverDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record waiver: %w", err)
+	}
+	waiver := mapper.MapFineWaiverRowToDomain(row)
+
+	invoice.FineAmount = fromPaise(toPaise(invoice.FineAmount) - amount)
+	if _, err := saveInvoiceAmounts(ctx, q, invoice, arg.ApprovedBy); err != nil {
+		return nil, err
+	}
+
+	lines := newJournalLines()
+	lines.debit(heads[rule.FeeHeadID.UUID].LinkedGlAccountID.UUID, amount)
+	lines.credit(settings.ReceivablesAccountID, amount)
+
+	entry := sourceEntry(arg.InstituteID, domain.JournalSourceFineWaiver, waiver.ID,
+		invoice.InvoiceNo, "Fine waived on invoice "+invoice.InvoiceNo+": "+waiver.Reason, arg.ApprovedBy)
+	if _, err := postSourceJournal(ctx, q, entry, lines); err != nil {
+		return nil, err
+	}
+
+	return &waiver, tx.Commit()
+}
+
+// ========================= LIST INVOICE FINES =========================
+
+// SERVICE
+func (s *Service) ListInvoiceFines(ctx context.Context, instituteID, invoiceID uuid.UUID) (*domain.InvoiceFines, error) {
+	return s.repo.ListInvoiceFines(ctx, instituteID, invoiceID)
+}
+
+// REPOSITORY
+func (r *Repository) ListInvoiceFines(ctx context.Context, instituteID, invoiceID uuid.UUID) (*domain.InvoiceFines, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := q.GetInvoice(ctx, db.GetInvoiceParams{ID: invoiceID, InstituteID: instituteID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+
+	fineRows, err := q.ListInvoiceFines(ctx, db.ListInvoiceFinesParams{InvoiceID: invoiceID, InstituteID: instituteID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fines: %w", err)
+	}
+	waiverRows, err := q.ListInvoiceFineWaivers(ctx, db.ListInvoiceFineWaiversParams{InvoiceID: invoiceID, InstituteID: instituteID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list waivers: %w", err)
+	}
+
+	result := &domain.InvoiceFines{
+		InvoiceID: invoiceID,
+		Fines:     make([]domain.InvoiceFine, 0, len(fineRows)),
+		Waivers:   make([]domain.FineWaiver, 0, len(waiverRows)),
+	}
+	for _, row := range fineRows {
+		result.Fines = append(result.Fines, mapper.MapInvoiceFineRowToDomain(row))
+	}
+	for _, row := range waiverRows {
+		result.Waivers = append(result.Waivers, mapper.MapFineWaiverRowToDomain(row))
+	}
+	return result, nil
+}
+
+// ========================= FINE HELPERS =========================
+
+// fineDue is the total fine in paise a rule has earned on an invoice that
+// is days past its grace period with principal paise of fees unpaid.
+// Fixed and percentage fines are charged once; a percentage fine keeps the
+// amount it was first charged at.
+func fineDue(rule domain.FineRule, days int, principal, charged int64) int64 {
+	switch rule.FineType {
+	case domain.FineFixed:
+		return toPaise(rule.FineAmount)
+	case domain.FineDaily:
+		return toPaise(rule.FineAmount) * int64(days)
+	case domain.FinePercentage:
+		if charged > 0 {
+			return charged
+		}
+		return int64(math.Round(float64(principal) * rule.FineAmount / 100))
+	}
+	return 0
+}
+
+// fineCeiling is the most in paise the fines of all rules on one invoice
+// may add up to, the lowest MaxFineAmount among the rules. capped is false
+// when no rule sets one.
+func fineCeiling(rules []*domain.FineRule) (ceiling int64, capped bool) {
+	for _, rule := range rules {
+		if rule.MaxFineAmount == nil {
+			continue
+		}
+		if limit := toPaise(*rule.MaxFineAmount); !capped || limit < ceiling {
+			ceiling, capped = limit, true
+		}
+	}
+	return ceiling, capped
+}
+
+// invoicePrincipalDue is the unpaid part of an invoice's fees in paise,
+// leaving fines out. Payments settle fees before fines.
+func invoicePrincipalDue(inv domain.Invoice) int64 {
+	return max(toPaise(inv.TotalAmount)-toPaise(inv.DiscountAmount)-toPaise(inv.PaidAmount), 0)
+}
+
+func sumFinesByRule(ctx context.Context, q *db.Queries, instituteID, invoiceID uuid.UUID) (map[uuid.UUID]int64, error) {
+	rows, err := q.SumInvoiceFines(ctx, db.SumInvoiceFinesParams{InvoiceID: invoiceID, InstituteID: instituteID})
+	if err != nil {
+		return nil, err
+	}
+
+	sums := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		if sums[row.FineRuleID], err = sumPaise(row.Amount); err != nil {
+			return nil, err
+		}
+	}
+	return sums, nil
+}
+
+func sumWaiversByRule(ctx context.Context, q *db.Queries, instituteID, invoiceID uuid.UUID) (map[uuid.UUID]int64, error) {
+	rows, err := q.SumInvoiceFineWaivers(ctx, db.SumInvoiceFineWaiversParams{InvoiceID: invoiceID, InstituteID: instituteID})
+	if err != nil {
+		return nil, err
+	}
+
+	sums := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		if sums[row.FineRuleID], err = sumPaise(row.Amount); err != nil {
+			return nil, err
+		}
+	}
+	return sums, nil
+}
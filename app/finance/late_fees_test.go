@@ -0,0 +1,62 @@
+package finance
+
+import (
+	"testing"
+
+	"swiftschool/domain"
+)
+
+func TestFineDue(t *testing.T) {
+	limit := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		rule      domain.FineRule
+		days      int
+		principal int64
+		charged   int64
+		want      int64
+	}{
+		{"fixed", domain.FineRule{FineType: domain.FineFixed, FineAmount: 250}, 10, 500000, 0, 25000},
+		{"fixed ignores days", domain.FineRule{FineType: domain.FineFixed, FineAmount: 250}, 40, 500000, 25000, 25000},
+		{"daily", domain.FineRule{FineType: domain.FineDaily, FineAmount: 10.5}, 3, 500000, 0, 3150},
+		{"daily on first day", domain.FineRule{FineType: domain.FineDaily, FineAmount: 10}, 1, 500000, 0, 1000},
+		{"daily ignores the invoice cap", domain.FineRule{FineType: domain.FineDaily, FineAmount: 10, MaxFineAmount: limit(100)}, 30, 500000, 0, 30000},
+		{"percentage", domain.FineRule{FineType: domain.FinePercentage, FineAmount: 2}, 5, 500000, 0, 10000},
+		{"percentage rounds", domain.FineRule{FineType: domain.FinePercentage, FineAmount: 1.5}, 5, 33333, 0, 500},
+		{"percentage keeps first charge", domain.FineRule{FineType: domain.FinePercentage, FineAmount: 2}, 5, 100000, 10000, 10000},
+		{"nothing unpaid", domain.FineRule{FineType: domain.FinePercentage, FineAmount: 2}, 5, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fineDue(tt.rule, tt.days, tt.principal, tt.charged); got != tt.want {
+				t.Fatalf("fineDue = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFineCeiling(t *testing.T) {
+	limit := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name       string
+		rules      []*domain.FineRule
+		want       int64
+		wantCapped bool
+	}{
+		{"no rules", nil, 0, false},
+		{"no limits", []*domain.FineRule{{FineType: domain.FineFixed}, {FineType: domain.FineDaily}}, 0, false},
+		{"one limit", []*domain.FineRule{{FineType: domain.FineFixed}, {FineType: domain.FineDaily, MaxFineAmount: limit(500)}}, 50000, true},
+		{"lowest limit wins", []*domain.FineRule{{MaxFineAmount: limit(500)}, {MaxFineAmount: limit(120.5)}, {MaxFineAmount: limit(300)}}, 12050, true},
+		{"zero limit", []*domain.FineRule{{MaxFineAmount: limit(500)}, {MaxFineAmount: limit(0)}}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, capped := fineCeiling(tt.rules)
+			if got != tt.want || capped != tt.wantCapped {
+				t.Fatalf("fineCeiling = (%d, %v), want (%d, %v)", got, capped, tt.want, tt.wantCapped)
+			}
+		})
+	}
+}
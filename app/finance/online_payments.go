@@ -0,0 +1,734 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidPaymentGateway  = errors.New("gateway must be razorpay or payumoney, or fake outside production, with a key id and a secret; payumoney also needs its merchant salt")
+	ErrGatewayNotConfigured   = errors.New("online payments are not configured for this institute")
+	ErrGatewayUnavailable     = errors.New("payment gateway could not start the checkout")
+	ErrInvoiceSettled         = errors.New("invoice has nothing left to pay")
+	ErrOnlinePaymentNotFound  = errors.New("online payment not found")
+	ErrPaymentCallbackInvalid = errors.New("payment callback failed verification")
+)
+
+// gatewayFlow describes how a gateway's checkout and callback look
+type gatewayFlow struct {
+	// orderKey is the callback field naming the order being paid
+	orderKey string
+	// paymentKey is the callback field carrying the gateway's payment id
+	paymentKey string
+	// inPage gateways return an order id for a checkout opened in the
+	// page instead of a URL to redirect the payer to
+	inPage bool
+}
+
+// checkoutGateways are the gateways whose callbacks can be verified.
+// The other gateways in helper do not check signatures yet.
+var checkoutGateways = map[helper.PaymentGatewayType]gatewayFlow{
+	helper.Razorpay:  {orderKey: "razorpay_order_id", paymentKey: "razorpay_payment_id", inPage: true},
+	helper.PayUMoney: {orderKey: "txnid", paymentKey: "mihpayid"},
+}
+
+// fakeCheckout is the flow of helper.FakePayment, offered only while the
+// helper has it enabled outside production
+var fakeCheckout = gatewayFlow{orderKey: "order_id", paymentKey: "payment_id"}
+
+// checkoutFlow looks up a gateway institutes may take payments through
+func checkoutFlow(gateway helper.PaymentGatewayType) (gatewayFlow, bool) {
+	if gateway == helper.FakePayment {
+		return fakeCheckout, helper.FakePaymentEnabled()
+	}
+	flow, ok := checkoutGateways[gateway]
+	return flow, ok
+}
+
+// storedGateway is an institute's gateway settings with its sealed secrets
+type storedGateway struct {
+	Settings          domain.PaymentGatewaySettings
+	SecretSealed      string
+	MerchantKeySealed string
+}
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) GetPaymentGateway(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetPaymentGateway(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to fetch payment gateway: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "payment gateway fetched successfully", data)
+}
+
+func (h *Handler) UpdatePaymentGateway(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.PaymentGatewaySettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.UpdatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.UpdatePaymentGateway(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to update payment gateway: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "payment gateway updated successfully", data)
+}
+
+func (h *Handler) StartCheckout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req struct {
+		InvoiceID uuid.UUID `json:"invoice_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userID := helper.GetSessionUserID(r)
+	if userID == nil {
+		helper.NewErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	data, err := h.service.StartCheckout(r.Context(), inst, req.InvoiceID, *userID)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to start checkout: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "checkout started", data)
+}
+
+func (h *Handler) GetCheckout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userID := helper.GetSessionUserID(r)
+	if userID == nil {
+		helper.NewErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	data, err := h.service.GetCheckout(r.Context(), inst, id, *userID)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to fetch checkout: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "checkout fetched successfully", data)
+}
+
+// PaymentCallback is called by the gateway, not by a signed-in user. The
+// ref query parameter was put on the callback URL at checkout; the payload
+// is trusted only once the gateway's signature on it checks out.
+func (h *Handler) PaymentCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ref, err := helper.GetRequiredQueryParam(r, "ref")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	payload, err := callbackPayload(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid callback payload: "+err.Error())
+		return
+	}
+
+	data, err := h.service.HandlePaymentCallback(r.Context(), ref, payload)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "payment callback rejected: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "payment "+string(data.Status), data)
+}
+
+// callbackPayload flattens a JSON or form callback body into strings
+func callbackPayload(r *http.Request) (map[string]string, error) {
+	payload := map[string]string{}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body map[string]any
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+		if err := dec.Decode(&body); err != nil {
+			return nil, err
+		}
+		for k, v := range body {
+			payload[k] = fmt.Sprint(v)
+		}
+		return payload, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	for k := range r.Form {
+		payload[k] = r.Form.Get(k)
+	}
+	return payload, nil
+}
+
+// ========================= GATEWAY SETTINGS =========================
+
+// SERVICE
+func (s *Service) GetPaymentGateway(ctx context.Context, instituteID uuid.UUID) (*domain.PaymentGatewaySettings, error) {
+	stored, err := s.repo.GetPaymentGateway(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+	return &stored.Settings, nil
+}
+
+// UpdatePaymentGateway replaces the institute's gateway credentials. The
+// secrets are sealed here and never leave the service in clear.
+func (s *Service) UpdatePaymentGateway(ctx context.Context, arg domain.PaymentGatewaySettings) (*domain.PaymentGatewaySettings, error) {
+	arg.KeyID = strings.TrimSpace(arg.KeyID)
+	gateway := helper.PaymentGatewayType(arg.Gateway)
+	if _, ok := checkoutFlow(gateway); !ok || arg.KeyID == "" || arg.Secret == "" {
+		return nil, ErrInvalidPaymentGateway
+	}
+	if gateway == helper.PayUMoney && arg.MerchantKey == "" {
+		return nil, ErrInvalidPaymentGateway
+	}
+	if arg.Environment == "" {
+		arg.Environment = "production"
+	}
+
+	secret, err := helper.SealPaymentSecret(arg.Secret)
+	if err != nil {
+		return nil, err
+	}
+	var merchantKey string
+	if arg.MerchantKey != "" {
+		if merchantKey, err = helper.SealPaymentSecret(arg.MerchantKey); err != nil {
+			return nil, err
+		}
+	}
+
+	settings, err := s.repo.UpsertPaymentGateway(ctx, arg, secret, merchantKey)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("payment gateway of institute %s set to %s", settings.InstituteID, settings.Gateway)
+	return settings, nil
+}
+
+// gateway builds the institute's configured gateway with its secrets opened
+func (s *Service) gateway(ctx context.Context, instituteID uuid.UUID, callbackURL string) (helper.PaymentGateway, gatewayFlow, *domain.PaymentGatewaySettings, error) {
+	stored, err := s.repo.GetPaymentGateway(ctx, instituteID)
+	if err != nil {
+		return nil, gatewayFlow{}, nil, err
+	}
+	flow, ok := checkoutFlow(helper.PaymentGatewayType(stored.Settings.Gateway))
+	if !ok || !stored.Settings.IsActive {
+		return nil, gatewayFlow{}, nil, ErrGatewayNotConfigured
+	}
+
+	secret, err := helper.OpenPaymentSecret(stored.SecretSealed)
+	if err != nil {
+		return nil, gatewayFlow{}, nil, err
+	}
+	var merchantKey string
+	if stored.MerchantKeySealed != "" {
+		if merchantKey, err = helper.OpenPaymentSecret(stored.MerchantKeySealed); err != nil {
+			return nil, gatewayFlow{}, nil, err
+		}
+	}
+
+	gw := helper.NewGateway(helper.PaymentConfig{
+		Type:        helper.PaymentGatewayType(stored.Settings.Gateway),
+		Key:         stored.Settings.KeyID,
+		Secret:      secret,
+		MerchantID:  helper.StrOrEmpty(stored.Settings.MerchantID),
+		MerchantKey: merchantKey,
+		CallbackURL: callbackURL,
+		Environment: stored.Settings.Environment,
+		Currency:    helper.DefaultCurrency,
+		Timeout:     time.Duration(helper.DefaultTimeoutSec) * time.Second,
+	})
+	return gw, flow, &stored.Settings, nil
+}
+
+// REPOSITORY
+func (r *Repository) GetPaymentGateway(ctx context.Context, instituteID uuid.UUID) (*storedGateway, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetPaymentGateway(ctx, instituteID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGatewayNotConfigured
+		}
+		return nil, err
+	}
+
+	return &storedGateway{
+		Settings:          mapper.MapPaymentGatewayRowToDomain(row),
+		SecretSealed:      row.SecretSealed,
+		MerchantKeySealed: helper.NullStringToValue(row.MerchantKeySealed),
+	}, nil
+}
+
+func (r *Repository) UpsertPaymentGateway(ctx context.Context, arg domain.PaymentGatewaySettings, secretSealed, merchantKeySealed string) (*domain.PaymentGatewaySettings, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.UpsertPaymentGateway(ctx, mapper.MapPaymentGatewayDomainToParams(arg, secretSealed, merchantKeySealed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save payment gateway: %w", err)
+	}
+
+	result := mapper.MapPaymentGatewayRowToDomain(row)
+	return &result, nil
+}
+
+// ========================= CHECKOUT =========================
+
+// SERVICE
+// StartCheckout opens a gateway checkout for the balance of an invoice of
+// one of the guardian's children. The payment is recorded only when the
+// gateway's callback comes back verified.
+func (s *Service) StartCheckout(ctx context.Context, instituteID, invoiceID, userID uuid.UUID) (*domain.OnlinePayment, error) {
+	invoice, err := s.repo.GetGuardianInvoice(ctx, instituteID, invoiceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	balance := invoiceDue(*invoice) - toPaise(invoice.PaidAmount)
+	if balance <= 0 {
+		return nil, ErrInvoiceSettled
+	}
+
+	ref := "OP" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:20])
+	callbackURL := s.paymentCallbackURL + "?ref=" + url.QueryEscape(ref)
+
+	gw, flow, settings, err := s.gateway(ctx, instituteID, callbackURL)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := s.repo.CreateOnlinePayment(ctx, domain.OnlinePayment{
+		InstituteID: instituteID,
+		InvoiceID:   invoice.ID,
+		StudentID:   invoice.StudentID,
+		Gateway:     settings.Gateway,
+		OrderRef:    ref,
+		Amount:      fromPaise(balance),
+		InitiatedBy: &userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gw.CreatePayment(ctx, helper.PaymentRequest{
+		OrderID:    ref,
+		Amount:     payment.Amount,
+		CustomerID: invoice.StudentID.String(),
+		Product:    "Fees for invoice " + invoice.InvoiceNo,
+	})
+	if err != nil {
+		logger.Errorf("checkout %s failed at %s: %v", ref, settings.Gateway, err)
+		return nil, ErrGatewayUnavailable
+	}
+
+	if flow.inPage {
+		if payment, err = s.repo.SetOnlinePaymentGatewayOrder(ctx, payment.ID, result); err != nil {
+			return nil, err
+		}
+		payment.GatewayKeyID = settings.KeyID
+	} else {
+		payment.RedirectURL = result
+	}
+	return payment, nil
+}
+
+// GetCheckout lets a guardian follow a checkout they can see the invoice of
+func (s *Service) GetCheckout(ctx context.Context, instituteID, id, userID uuid.UUID) (*domain.OnlinePayment, error) {
+	payment, err := s.repo.GetOnlinePayment(ctx, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetGuardianInvoice(ctx, instituteID, payment.InvoiceID, userID); err != nil {
+		if errors.Is(err, ErrInvoiceNotFound) {
+			return nil, ErrOnlinePaymentNotFound
+		}
+		return nil, err
+	}
+	return payment, nil
+}
+
+// REPOSITORY
+// GetGuardianInvoice returns the invoice only if the login is an active
+// guardian of the invoice's student
+func (r *Repository) GetGuardianInvoice(ctx context.Context, instituteID, invoiceID, userID uuid.UUID) (*domain.Invoice, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetGuardianInvoice(ctx, db.GetGuardianInvoiceParams{
+		UserID:      userID,
+		InvoiceID:   invoiceID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+
+	result := mapper.MapInvoiceRowToDomain(row)
+	return &result, nil
+}
+
+func (r *Repository) CreateOnlinePayment(ctx context.Context, arg domain.OnlinePayment) (*domain.OnlinePayment, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreateOnlinePayment(ctx, db.CreateOnlinePaymentParams{
+		InstituteID: arg.InstituteID,
+		InvoiceID:   arg.InvoiceID,
+		StudentID:   arg.StudentID,
+		Gateway:     arg.Gateway,
+		OrderRef:    arg.OrderRef,
+		Amount:      fmt.Sprintf("%.2f", arg.Amount),
+		InitiatedBy: helper.ToNullUUID(helper.DerefUUID(arg.InitiatedBy)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create online payment: %w", err)
+	}
+
+	result := mapper.MapOnlinePaymentRowToDomain(row)
+	return &result, nil
+}
+
+func (r *Repository) SetOnlinePaymentGatewayOrder(ctx context.Context, id uuid.UUID, gatewayOrderID string) (*domain.OnlinePayment, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.SetOnlinePaymentGatewayOrder(ctx, db.SetOnlinePaymentGatewayOrderParams{
+		GatewayOrderID: helper.ToNullString(gatewayOrderID),
+		ID:             id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save gateway order: %w", err)
+	}
+
+	result := mapper.MapOnlinePaymentRowToDomain(row)
+	return &result, nil
+}
+
+func (r *Repository) GetOnlinePayment(ctx context.Context, instituteID, id uuid.UUID) (*domain.OnlinePayment, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetOnlinePayment(ctx, db.GetOnlinePaymentParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOnlinePaymentNotFound
+		}
+		return nil, err
+	}
+
+	result := mapper.MapOnlinePaymentRowToDomain(row)
+	return &result, nil
+}
+
+// ========================= PAYMENT CALLBACK =========================
+
+// SERVICE
+// HandlePaymentCallback settles a checkout from the gateway's callback.
+// The callback must name the checkout's own order and carry a valid
+// signature from the institute's gateway, and gateways that can confirm
+// the amount paid must confirm the checkout's amount. A verified success
+// records the payment exactly once, however often the gateway repeats the
+// callback.
+func (s *Service) HandlePaymentCallback(ctx context.Context, ref string, payload map[string]string) (*domain.OnlinePayment, error) {
+	payment, err := s.repo.GetOnlinePaymentByRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	gw, flow, settings, err := s.gateway(ctx, payment.InstituteID, s.paymentCallbackURL)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Gateway != payment.Gateway {
+		return nil, fmt.Errorf("%w: checkout was started on %s", ErrPaymentCallbackInvalid, payment.Gateway)
+	}
+
+	order := ref
+	if flow.inPage {
+		order = helper.StrOrEmpty(payment.GatewayOrderID)
+	}
+	paymentID := strings.TrimSpace(payload[flow.paymentKey])
+	if payload[flow.orderKey] != order || paymentID == "" {
+		return nil, ErrPaymentCallbackInvalid
+	}
+
+	paid, err := gw.VerifyPayment(ctx, payload)
+	if err != nil {
+		logger.Warnf("callback for checkout %s failed verification: %v", ref, err)
+		return nil, ErrPaymentCallbackInvalid
+	}
+	if !paid {
+		return s.repo.FailOnlinePayment(ctx, ref, paymentID)
+	}
+	if reader, ok := gw.(helper.PaidAmountReader); ok {
+		amount, err := reader.PaidAmount(ctx, payload)
+		if err != nil {
+			logger.Warnf("could not confirm the amount paid for checkout %s: %v", ref, err)
+			return nil, fmt.Errorf("%w: %v", ErrGatewayUnavailable, err)
+		}
+		if toPaise(amount) != toPaise(payment.Amount) {
+			logger.Warnf("checkout %s of %.2f was paid %.2f", ref, payment.Amount, amount)
+			return nil, fmt.Errorf("%w: gateway confirmed %.2f for a checkout of %.2f", ErrPaymentCallbackInvalid, amount, payment.Amount)
+		}
+	}
+
+	result, err := s.repo.CompleteOnlinePayment(ctx, ref, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("online payment %s of %.2f settled for invoice %s", result.OrderRef, result.Amount, result.InvoiceID)
+	return result, nil
+}
+
+// REPOSITORY
+func (r *Repository) GetOnlinePaymentByRef(ctx context.Context, ref string) (*domain.OnlinePayment, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetOnlinePaymentByRef(ctx, ref)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOnlinePaymentNotFound
+		}
+		return nil, err
+	}
+
+	result := mapper.MapOnlinePaymentRowToDomain(row)
+	return &result, nil
+}
+
+// CompleteOnlinePayment records a verified payment against its invoice. A
+// checkout that is already paid is returned as it is. If the invoice was
+// settled some other way meanwhile, the money is kept as a credit on the
+// student's account instead of being applied to the invoice.
+func (r *Repository) CompleteOnlinePayment(ctx context.Context, ref, gatewayPaymentID string) (*domain.OnlinePayment, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	row, err := lockOnlinePayment(ctx, q, ref)
+	if err != nil {
+		return nil, err
+	}
+	payment := mapper.MapOnlinePaymentRowToDomain(row)
+	if payment.Status == domain.OnlinePaymentPaid {
+		return &payment, nil
+	}
+
+	arg := domain.Transaction{
+		InvoiceID:        &payment.InvoiceID,
+		StudentID:        &payment.StudentID,
+		TransactionRefNo: &gatewayPaymentID,
+		PaymentMode:      domain.PaymentOnline,
+		Amount:           payment.Amount,
+	}
+	arg.InstituteID = payment.InstituteID
+	arg.CreatedBy = payment.InitiatedBy
+
+	invoice, err := lockInvoice(ctx, q, payment.InstituteID, payment.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if toPaise(payment.Amount) > invoiceDue(invoice)-toPaise(invoice.PaidAmount) {
+		logger.Warnf("invoice %s was settled before online payment %s, keeping it as student credit", invoice.InvoiceNo, ref)
+		arg.InvoiceID = nil
+	}
+
+	_, txns, err := issueReceipt(ctx, q, receiptForPayment(arg))
+	if err != nil {
+		return nil, err
+	}
+	txn := txns[0]
+
+	row, err = q.SettleOnlinePayment(ctx, db.SettleOnlinePaymentParams{
+		Status:           string(domain.OnlinePaymentPaid),
+		GatewayPaymentID: helper.ToNullString(gatewayPaymentID),
+		TransactionID:    helper.ToNullUUID(txn.ID),
+		ID:               payment.ID,
+	})
+	if err != nil {
+		if helper.IsPgUniqueViolation(err) {
+			return nil, fmt.Errorf("%w: gateway payment %s already settled another checkout", ErrPaymentCallbackInvalid, gatewayPaymentID)
+		}
+		return nil, err
+	}
+
+	result := mapper.MapOnlinePaymentRowToDomain(row)
+	return &result, tx.Commit()
+}
+
+// FailOnlinePayment marks a checkout the gateway reported as failed. A
+// checkout that has already been paid is left alone.
+func (r *Repository) FailOnlinePayment(ctx context.Context, ref, gatewayPaymentID string) (*domain.OnlinePayment, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	row, err := lockOnlinePayment(ctx, q, ref)
+	if err != nil {
+		return nil, err
+	}
+	if row.Status == string(domain.OnlinePaymentCreated) {
+		row, err = q.SettleOnlinePayment(ctx, db.SettleOnlinePaymentParams{
+			Status:           string(domain.OnlinePaymentFailed),
+			GatewayPaymentID: helper.ToNullString(gatewayPaymentID),
+			ID:               row.ID,
+		})
+		if err != nil {
+			if helper.IsPgUniqueViolation(err) {
+				return nil, ErrPaymentCallbackInvalid
+			}
+			return nil, err
+		}
+	}
+
+	result := mapper.MapOnlinePaymentRowToDomain(row)
+	return &result, tx.Commit()
+}
+
+func lockOnlinePayment(ctx context.Context, q *db.Queries, ref string) (db.FinanceOnlinePayment, error) {
+	row, err := q.GetOnlinePaymentByRefForUpdate(ctx, ref)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return row, ErrOnlinePaymentNotFound
+		}
+		return row, err
+	}
+	return row, nil
+}
@@ -0,0 +1,584 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLedgerNotConfigured   = errors.New("ledger posting accounts are not configured for this institute")
+	ErrInvalidLedgerSettings = errors.New("receivables, cash, bank, payables and purchases accounts are required")
+	ErrLedgerAccountType     = errors.New("ledger posting account has the wrong type")
+	ErrAdvancesNotConfigured = errors.New("no student advances account is configured for wallet payments and refunds")
+	ErrInvalidJournalSource  = errors.New("source_type must be invoice, invoice_item, transaction, refund or purchase_order")
+	ErrSourcePosted          = errors.New("this document has already been posted to the ledger")
+	ErrFeeHeadNotFound       = errors.New("fee head not found")
+	ErrFeeHeadNotLinked      = errors.New("fee head has no linked GL account")
+	ErrFeeHeadAccountType    = errors.New("a fee head's GL account must be an income or liability account")
+)
+
+var journalSources = []domain.JournalSource{
+	domain.JournalSourceInvoice,
+	domain.JournalSourceInvoiceItem,
+	domain.JournalSourceTransaction,
+	domain.JournalSourceRefund,
+	domain.JournalSourcePurchaseOrder,
+	domain.JournalSourceInvoiceFine,
+	domain.JournalSourceFineWaiver,
+	domain.JournalSourceChequeBounce,
+	domain.JournalSourceWalletEntry,
+	domain.JournalSourceGoodsReceipt,
+	domain.JournalSourceVendorBill,
+	domain.JournalSourceVendorPayment,
+}
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) GetLedgerSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetLedgerSettings(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to fetch ledger settings: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "ledger settings fetched successfully", data)
+}
+
+func (h *Handler) UpdateLedgerSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.LedgerSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.UpdatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.UpdateLedgerSettings(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to update ledger settings: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "ledger settings updated successfully", data)
+}
+
+func (h *Handler) ListSourceJournals(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	sourceType, err := helper.GetRequiredQueryParam(r, "source_type")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sourceID, err := helper.ParseRequiredUUIDFromQuery(r, "source_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid source_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListSourceJournals(r.Context(), inst, domain.JournalSource(sourceType), sourceID)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to list journals: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "journals fetched successfully", data)
+}
+
+// financeErrorStatus maps billing, payment, procurement and posting errors
+// to status codes and leaves everything else to accountingErrorStatus
+func financeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidLedgerSettings), errors.Is(err, ErrLedgerAccountType),
+		errors.Is(err, ErrInvalidJournalSource), errors.Is(err, ErrFeeHeadNotLinked),
+		errors.Is(err, ErrFeeHeadAccountType), errors.Is(err, ErrInvalidInvoice),
+		errors.Is(err, ErrInvalidInvoiceItem), errors.Is(err, ErrInvalidPayment),
+		errors.Is(err, ErrInvalidPaymentMode), errors.Is(err, ErrPaymentStudentMismatch),
+		errors.Is(err, ErrOverpayment), errors.Is(err, ErrInvalidVendor),
+		errors.Is(err, ErrInvalidPurchaseOrder), errors.Is(err, ErrInvalidPurchaseItem),
+		errors.Is(err, ErrInvalidPurchaseStatus), errors.Is(err, ErrRefundExceedsRefundable),
+		errors.Is(err, ErrInvalidFeeStructure), errors.Is(err, ErrInvalidInvoiceRun),
+		errors.Is(err, ErrPeriodOutsideSession), errors.Is(err, ErrInvalidConcession),
+		errors.Is(err, ErrInvalidStudentConcession), errors.Is(err, ErrSiblingConcessionGranted),
+		errors.Is(err, ErrInvalidConcessionDecision), errors.Is(err, ErrInvalidFineRule),
+		errors.Is(err, ErrInvalidFineWaiver), errors.Is(err, ErrWaiverExceedsFine),
+		errors.Is(err, ErrNoFinesToWaiveYet), errors.Is(err, ErrInvalidPaymentGateway),
+		errors.Is(err, ErrInvalidReceipt), errors.Is(err, ErrChequeDetailsRequired),
+		errors.Is(err, ErrInvalidChequeBounce), errors.Is(err, ErrBounceChargeNoInvoice),
+		errors.Is(err, ErrInvalidWalletTopUp), errors.Is(err, ErrInvalidWalletDebit),
+		errors.Is(err, ErrInvalidWalletSettings), errors.Is(err, ErrInvalidRefund),
+		errors.Is(err, ErrInvalidRefundDecision), errors.Is(err, ErrInvalidRefundPayout),
+		errors.Is(err, ErrInvalidRefundSettings), errors.Is(err, ErrRefundExceedsWallet),
+		errors.Is(err, ErrInvalidBankAccount), errors.Is(err, ErrInvalidStatementImport),
+		errors.Is(err, helper.ErrUnreadableStatement), errors.Is(err, ErrInvalidStatementMatch),
+		errors.Is(err, ErrMatchAmountMismatch), errors.Is(err, ErrInvalidBudget),
+		errors.Is(err, ErrInvalidBudgetLimits), errors.Is(err, ErrExpenseAccountType),
+		errors.Is(err, ErrInvalidPurchaseDecision), errors.Is(err, ErrInvalidTax),
+		errors.Is(err, ErrInvalidTaxSettings), errors.Is(err, helper.ErrInvalidGSTIN),
+		errors.Is(err, ErrGSTINStateMismatch), errors.Is(err, ErrInvalidTaxAssignment),
+		errors.Is(err, ErrInvalidRequisition), errors.Is(err, ErrInvalidRequisitionOrder),
+		errors.Is(err, ErrRequisitionItemMismatch), errors.Is(err, ErrInvalidRequisitionStatus),
+		errors.Is(err, ErrInvalidGoodsReceipt), errors.Is(err, ErrInvalidVendorBill),
+		errors.Is(err, ErrBillVendorMismatch), errors.Is(err, ErrInvalidVendorBillDecision),
+		errors.Is(err, ErrInvalidVendorPayment), errors.Is(err, ErrInvalidVendorBillStatus),
+		errors.Is(err, ErrInvalidDunningStep), errors.Is(err, ErrDuplicateDunningStep),
+		errors.Is(err, ErrInvalidPaymentPlan), errors.Is(err, ErrInvalidPaymentPlanDecision),
+		errors.Is(err, ErrInvalidPaymentPlanStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrSelfApproval), errors.Is(err, ErrPaymentCallbackInvalid),
+		errors.Is(err, ErrRepeatApprover):
+		return http.StatusForbidden
+	case errors.Is(err, ErrLedgerNotConfigured), errors.Is(err, ErrFeeHeadNotFound),
+		errors.Is(err, ErrInvoiceNotFound), errors.Is(err, ErrRefundNotFound),
+		errors.Is(err, ErrPurchaseOrderNotFound), errors.Is(err, ErrAcademicSessionNotFound),
+		errors.Is(err, ErrConcessionNotFound), errors.Is(err, ErrStudentConcessionNotFound),
+		errors.Is(err, ErrFineRuleNotFound), errors.Is(err, ErrGatewayNotConfigured),
+		errors.Is(err, ErrOnlinePaymentNotFound), errors.Is(err, ErrReceiptNotFound),
+		errors.Is(err, ErrBankAccountNotFound), errors.Is(err, ErrStatementEntryNotFound),
+		errors.Is(err, ErrBudgetNotFound), errors.Is(err, ErrTaxNotFound),
+		errors.Is(err, ErrTaxNotConfigured), errors.Is(err, ErrStateNotFound),
+		errors.Is(err, ErrVendorNotFound), errors.Is(err, ErrInventoryItemNotFound),
+		errors.Is(err, ErrRequisitionNotFound), errors.Is(err, ErrRequisitionItemNotFound),
+		errors.Is(err, ErrLocationNotFound), errors.Is(err, ErrPurchaseItemNotFound),
+		errors.Is(err, ErrVendorBillNotFound), errors.Is(err, ErrPaymentPlanNotFound),
+		errors.Is(err, ErrWalletStudentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAdvancesNotConfigured), errors.Is(err, ErrSourcePosted),
+		errors.Is(err, ErrInvoiceNoTaken), errors.Is(err, ErrRefundNotApproved),
+		errors.Is(err, ErrRefundInProgress),
+		errors.Is(err, ErrPurchaseOrderClosed), errors.Is(err, ErrPurchaseTransition),
+		errors.Is(err, ErrPeriodBilled), errors.Is(err, ErrConcessionDecided),
+		errors.Is(err, ErrInvoiceSettled), errors.Is(err, ErrChequeNotPending),
+		errors.Is(err, ErrInsufficientWallet), errors.Is(err, ErrRefundDecided),
+		errors.Is(err, ErrNoRefundableCheckout), errors.Is(err, ErrGatewayRefundUnsupported),
+		errors.Is(err, ErrStatementEntryReconciled), errors.Is(err, ErrStatementEntryOpen),
+		errors.Is(err, ErrTransactionNotBankable), errors.Is(err, ErrBudgetExists),
+		errors.Is(err, ErrBudgetHardLimit), errors.Is(err, ErrPurchaseNotPending),
+		errors.Is(err, ErrRequisitionDecided), errors.Is(err, ErrRequisitionClosed),
+		errors.Is(err, ErrRequisitionOverOrdered), errors.Is(err, ErrPurchaseNotReceivable),
+		errors.Is(err, ErrOverReceipt), errors.Is(err, ErrVendorBillExists),
+		errors.Is(err, ErrPurchaseNotBillable), errors.Is(err, ErrVendorBillDecided),
+		errors.Is(err, ErrVendorBillNotPayable), errors.Is(err, ErrVendorOverpayment),
+		errors.Is(err, ErrBillExceedsReceipt), errors.Is(err, ErrVendorStateUnknown),
+		errors.Is(err, ErrPaymentPlanDecided):
+		return http.StatusConflict
+	case errors.Is(err, ErrGatewayUnavailable):
+		return http.StatusBadGateway
+	case errors.Is(err, helper.ErrPaymentSecretsNotConfigured):
+		return http.StatusServiceUnavailable
+	default:
+		return accountingErrorStatus(err)
+	}
+}
+
+// ========================= LEDGER SETTINGS =========================
+
+// SERVICE
+func (s *Service) GetLedgerSettings(ctx context.Context, instituteID uuid.UUID) (*domain.LedgerSettings, error) {
+	return s.repo.GetLedgerSettings(ctx, instituteID)
+}
+
+// UpdateLedgerSettings replaces the control accounts automatic postings use.
+// Entries already posted keep the accounts they were posted to.
+func (s *Service) UpdateLedgerSettings(ctx context.Context, arg domain.LedgerSettings) (*domain.LedgerSettings, error) {
+	for _, id := range []uuid.UUID{arg.ReceivablesAccountID, arg.CashAccountID, arg.BankAccountID, arg.PayablesAccountID, arg.PurchasesAccountID} {
+		if id == uuid.Nil {
+			return nil, ErrInvalidLedgerSettings
+		}
+	}
+
+	settings, err := s.repo.UpdateLedgerSettings(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("ledger settings of institute %s updated", arg.InstituteID)
+	return settings, nil
+}
+
+// REPOSITORY
+func (r *Repository) GetLedgerSettings(ctx context.Context, instituteID uuid.UUID) (*domain.LedgerSettings, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := loadLedgerSettings(ctx, q, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := mapper.MapLedgerSettingsRowToDomain(row)
+	return &result, nil
+}
+
+func (r *Repository) UpdateLedgerSettings(ctx context.Context, arg domain.LedgerSettings) (*domain.LedgerSettings, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	asset := []domain.AccountType{domain.AccAsset}
+	liability := []domain.AccountType{domain.AccLiability}
+	roles := []struct {
+		name  string
+		id    *uuid.UUID
+		types []domain.AccountType
+	}{
+		{"receivables", &arg.ReceivablesAccountID, asset},
+		{"cash", &arg.CashAccountID, asset},
+		{"bank", &arg.BankAccountID, asset},
+		{"payables", &arg.PayablesAccountID, liability},
+		{"purchases", &arg.PurchasesAccountID, []domain.AccountType{domain.AccExpense, domain.AccAsset}},
+		{"concessions", arg.ConcessionsAccountID, []domain.AccountType{domain.AccExpense, domain.AccIncome}},
+		{"student advances", arg.StudentAdvancesAccountID, liability},
+	}
+
+	var items []domain.JournalItem
+	for _, role := range roles {
+		if role.id == nil {
+			continue
+		}
+		acc, err := q.GetAccount(ctx, db.GetAccountParams{ID: *role.id, InstituteID: arg.InstituteID})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: %s account %s", ErrAccountNotFound, role.name, *role.id)
+			}
+			return nil, err
+		}
+		if !helper.Contains(role.types, domain.AccountType(acc.Type)) {
+			return nil, fmt.Errorf("%w: %s account %s is %s", ErrLedgerAccountType, role.name, acc.Code, acc.Type)
+		}
+		items = append(items, domain.JournalItem{AccountID: acc.ID})
+	}
+
+	// Postings will go straight to these accounts, so they must accept them
+	if err := checkPostingAccounts(ctx, q, arg.InstituteID, items); err != nil {
+		return nil, err
+	}
+
+	row, err := q.UpsertLedgerSettings(ctx, mapper.MapLedgerSettingsDomainToParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	result := mapper.MapLedgerSettingsRowToDomain(row)
+	return &result, tx.Commit()
+}
+
+// ========================= SOURCE JOURNALS =========================
+
+// SERVICE
+// ListSourceJournals returns the entries posted for a document, including
+// reversals of them, with their items
+func (s *Service) ListSourceJournals(ctx context.Context, instituteID uuid.UUID, sourceType domain.JournalSource, sourceID uuid.UUID) ([]*domain.JournalEntry, error) {
+	if !helper.Contains(journalSources, sourceType) {
+		return nil, ErrInvalidJournalSource
+	}
+	return s.repo.ListSourceJournals(ctx, instituteID, sourceType, sourceID)
+}
+
+// REPOSITORY
+func (r *Repository) ListSourceJournals(ctx context.Context, instituteID uuid.UUID, sourceType domain.JournalSource, sourceID uuid.UUID) ([]*domain.JournalEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListJournalEntriesBySource(ctx, db.ListJournalEntriesBySourceParams{
+		InstituteID: instituteID,
+		SourceType:  helper.ToNullString(string(sourceType)),
+		SourceID:    helper.ToNullUUID(sourceID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journals: %w", err)
+	}
+
+	entries := make([]*domain.JournalEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := mapper.MapJournalEntryRowToDomain(row)
+		if entry.Items, err = listJournalItems(ctx, q, instituteID, entry.ID); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// ========================= POSTING HELPERS =========================
+
+// journalLines collects the amounts of a generated journal per account, in
+// paise with debits positive. Amounts for the same account net off into a
+// single item.
+type journalLines struct {
+	order []uuid.UUID
+	net   map[uuid.UUID]int64
+}
+
+func newJournalLines() *journalLines {
+	return &journalLines{net: make(map[uuid.UUID]int64)}
+}
+
+func (l *journalLines) debit(account uuid.UUID, paise int64) {
+	if _, ok := l.net[account]; !ok {
+		l.order = append(l.order, account)
+	}
+	l.net[account] += paise
+}
+
+func (l *journalLines) credit(account uuid.UUID, paise int64) {
+	l.debit(account, -paise)
+}
+
+func (l *journalLines) items() []domain.JournalItem {
+	items := make([]domain.JournalItem, 0, len(l.order))
+	for _, account := range l.order {
+		net := l.net[account]
+		switch {
+		case net > 0:
+			items = append(items, domain.JournalItem{AccountID: account, Debit: fromPaise(net)})
+		case net < 0:
+			items = append(items, domain.JournalItem{AccountID: account, Credit: fromPaise(-net)})
+		}
+	}
+	return items
+}
+
+// sourceEntry starts a journal entry linked to the document it posts
+func sourceEntry(instituteID uuid.UUID, source domain.JournalSource, sourceID uuid.UUID, referenceNo, description string, createdBy *uuid.UUID) domain.JournalEntry {
+	entry := domain.JournalEntry{
+		ReferenceNo:     referenceNo,
+		TransactionDate: time.Now(),
+		Description:     &description,
+		SourceType:      &source,
+		SourceID:        &sourceID,
+	}
+	entry.InstituteID = instituteID
+	entry.CreatedBy = createdBy
+	return entry
+}
+
+// postSourceJournal posts the lines of a source document inside the
+// caller's transaction. When the lines net to nothing no entry is posted
+// and the result is nil.
+func postSourceJournal(ctx context.Context, q *db.Queries, entry domain.JournalEntry, lines *journalLines) (*domain.JournalEntry, error) {
+	entry.Items = lines.items()
+	if len(entry.Items) == 0 {
+		return nil, nil
+	}
+	if err := checkBalanced(entry.Items); err != nil {
+		return nil, err
+	}
+	if err := checkOpenPeriod(ctx, q, entry.InstituteID, entry.TransactionDate); err != nil {
+		return nil, err
+	}
+	if err := checkPostingAccounts(ctx, q, entry.InstituteID, entry.Items); err != nil {
+		return nil, err
+	}
+
+	posted, err := insertJournal(ctx, q, entry)
+	if err != nil {
+		if helper.IsPgUniqueViolation(err) {
+			return nil, ErrSourcePosted
+		}
+		return nil, err
+	}
+	if err := markPosted(ctx, q, posted, entry.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("%s %s posted as journal %s", *entry.SourceType, *entry.SourceID, posted.ID)
+	return posted, nil
+}
+
+func loadLedgerSettings(ctx context.Context, q *db.Queries, instituteID uuid.UUID) (db.FinanceLedgerSetting, error) {
+	row, err := q.GetLedgerSettings(ctx, instituteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return row, ErrLedgerNotConfigured
+	}
+	return row, err
+}
+
+// loadFeeHeads fetches the fee heads of items that have one and requires
+// each of them to be linked to a GL account
+func loadFeeHeads(ctx context.Context, q *db.Queries, instituteID uuid.UUID, items []domain.InvoiceItem) (map[uuid.UUID]db.FinanceFeeHead, error) {
+	var ids []uuid.UUID
+	for _, item := range items {
+		if item.FeeHeadID != nil && !helper.Contains(ids, *item.FeeHeadID) {
+			ids = append(ids, *item.FeeHeadID)
+		}
+	}
+
+	rows, err := q.GetFeeHeadsByIDs(ctx, db.GetFeeHeadsByIDsParams{InstituteID: instituteID, Ids: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	heads := make(map[uuid.UUID]db.FinanceFeeHead, len(rows))
+	for _, row := range rows {
+		if !row.LinkedGlAccountID.Valid {
+			return nil, fmt.Errorf("%w: %s", ErrFeeHeadNotLinked, row.Name)
+		}
+		heads[row.ID] = row
+	}
+	for _, id := range ids {
+		if _, ok := heads[id]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrFeeHeadNotFound, id)
+		}
+	}
+	return heads, nil
+}
+
+// invoiceLines debits receivables with what the student owes and credits
+// each fee head's account with the billed amount. Concessions are debited
+// to the concessions account, or netted off the fee head's account when
+// none is set.
+func invoiceLines(settings db.FinanceLedgerSetting, heads map[uuid.UUID]db.FinanceFeeHead, items []domain.InvoiceItem) *journalLines {
+	lines := newJournalLines()
+	for _, item := range items {
+		amount, discount := toPaise(item.Amount), toPaise(item.DiscountApplied)
+		income := heads[*item.FeeHeadID].LinkedGlAccountID.UUID
+
+		lines.debit(settings.ReceivablesAccountID, amount-discount)
+		lines.credit(income, amount)
+		if settings.ConcessionsAccountID.Valid {
+			lines.debit(settings.ConcessionsAccountID.UUID, discount)
+		} else {
+			lines.debit(income, discount)
+		}
+	}
+	return lines
+}
+
+// paymentAccount is where money received by txn goes: the student advances
+// account for wallet payments, cash for cash and the bank for the rest
+func paymentAccount(settings db.FinanceLedgerSetting, txn domain.Transaction) (uuid.UUID, error) {
+	switch {
+	case txn.IsWalletUsage:
+		if !settings.StudentAdvancesAccountID.Valid {
+			return uuid.Nil, ErrAdvancesNotConfigured
+		}
+		return settings.StudentAdvancesAccountID.UUID, nil
+	case txn.PaymentMode == domain.PaymentCash:
+		return settings.CashAccountID, nil
+	default:
+		return settings.BankAccountID, nil
+	}
+}
+
+// refundLines pays a refund out of payout, the cash or bank account. A
+// refund against an invoice takes back income from its refundable fee
+// heads, item by item, starting where the invoice's earlier refunds left
+// off; the GST contained in what is refunded of a taxed item comes back
+// out of the output tax accounts. Any other refund comes out of the
+// student's advances.
+func refundLines(settings db.FinanceLedgerSetting, heads map[uuid.UUID]db.FinanceFeeHead, items []domain.InvoiceItem, taxes refundTaxes, refunded int64, refund domain.Refund, payout uuid.UUID) (*journalLines, error) {
+	lines := newJournalLines()
+	amount := toPaise(refund.Amount)
+	lines.credit(payout, amount)
+
+	if refund.InvoiceID == nil {
+		if !settings.StudentAdvancesAccountID.Valid {
+			return nil, ErrAdvancesNotConfigured
+		}
+		lines.debit(settings.StudentAdvancesAccountID.UUID, amount)
+		return lines, nil
+	}
+
+	left := amount
+	for _, item := range items {
+		if left == 0 {
+			break
+		}
+		if item.FeeHeadID == nil || !heads[*item.FeeHeadID].IsRefundable.Bool {
+			continue
+		}
+
+		gross := toPaise(item.Amount) - toPaise(item.DiscountApplied)
+		done := min(refunded, gross)
+		refunded -= done
+		share := min(left, gross-done)
+		if share <= 0 {
+			continue
+		}
+
+		tax := taxes.items[item.ID].share(gross, done, done+share)
+		lines.debit(heads[*item.FeeHeadID].LinkedGlAccountID.UUID, share-tax.tax())
+		if tax.tax() > 0 {
+			lines.debit(taxes.settings.OutputCgstAccountID, tax.cgst)
+			lines.debit(taxes.settings.OutputSgstAccountID, tax.sgst)
+			lines.debit(taxes.settings.OutputIgstAccountID, tax.igst)
+		}
+		left -= share
+	}
+	if left > 0 {
+		return nil, fmt.Errorf("%w: %.2f of %.2f is not refundable", ErrRefundExceedsRefundable, fromPaise(left), fromPaise(amount))
+	}
+	return lines, nil
+}
+
+// referenceOr returns ref, or fallback when ref is blank
+func referenceOr(ref *string, fallback string) string {
+	if ref != nil && strings.TrimSpace(*ref) != "" {
+		return strings.TrimSpace(*ref)
+	}
+	return fallback
+}
@@ -2,14 +2,43 @@ package finance
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"swiftschool/domain"
 	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidVendor           = errors.New("vendor name is required")
+	ErrInvalidPurchaseOrder    = errors.New("a purchase order needs a vendor")
+	ErrInvalidPurchaseItem     = errors.New("a purchase item needs an item, a quantity and a unit price greater than zero")
+	ErrInvalidPurchaseStatus   = errors.New("purchase status must be draft, ordered, closed or cancelled")
+	ErrPurchaseOrderNotFound   = errors.New("purchase order not found")
+	ErrPurchaseOrderClosed     = errors.New("items can only be added to draft or ordered purchase orders")
+	ErrPurchaseTransition      = errors.New("purchase order cannot move to this status")
+	ErrPurchaseNotPending      = errors.New("purchase order is not waiting for budget approval")
+	ErrInvalidPurchaseDecision = errors.New("decision must be approved or rejected")
+)
+
+// purchaseTransitions lists the statuses a purchase order can be moved to
+// by hand. Ordering commits it to its budget, or leaves it pending approval
+// past the soft limit. Goods receipts move it on to partially_received and
+// received; an order that will not be delivered in full can then be closed.
+var purchaseTransitions = map[domain.PurchaseStatus][]domain.PurchaseStatus{
+	domain.PurchaseDraft:             {domain.PurchaseOrdered, domain.PurchaseCancelled},
+	domain.PurchasePendingApproval:   {domain.PurchaseCancelled},
+	domain.PurchaseOrdered:           {domain.PurchaseCancelled},
+	domain.PurchasePartiallyReceived: {domain.PurchaseClosed},
+}
+
 // =================================================================================
 // HANDLERS
 // =================================================================================
@@ -26,9 +55,17 @@ func (h *Handler) CreateVendor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
 	data, err := h.service.CreateVendor(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create vendor: "+err.Error())
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create vendor: "+err.Error())
 		return
 	}
 
@@ -41,15 +78,9 @@ func (h *Handler) ListVendors(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	idStr := r.URL.Query().Get("institute_id")
-	if idStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "institute_id is required")
-		return
-	}
-
-	id, err := uuid.Parse(idStr)
+	id, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -74,9 +105,17 @@ func (h *Handler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
 	data, err := h.service.CreatePurchaseOrder(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create purchase order: "+err.Error())
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create purchase order: "+err.Error())
 		return
 	}
 
@@ -95,9 +134,16 @@ func (h *Handler) AddPurchaseItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+
 	data, err := h.service.AddPurchaseItem(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to add purchase item: "+err.Error())
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to add purchase item: "+err.Error())
 		return
 	}
 
@@ -111,7 +157,6 @@ func (h *Handler) UpdatePurchaseStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	idStr := r.URL.Query().Get("id")
-	instStr := r.URL.Query().Get("institute_id")
 
 	type StatusReq struct {
 		Status domain.PurchaseStatus `json:"status"`
@@ -122,29 +167,108 @@ func (h *Handler) UpdatePurchaseStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, _ := uuid.Parse(idStr)
-	inst, _ := uuid.Parse(instStr)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	err := h.service.UpdatePurchaseStatus(r.Context(), id, inst, req.Status)
+	data, err := h.service.UpdatePurchaseStatus(r.Context(), id, inst, req.Status, helper.GetSessionUserID(r))
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to update status: "+err.Error())
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to update status: "+err.Error())
 		return
 	}
 
-	helper.NewSuccessResponse(w, http.StatusOK, "purchase status updated successfully", nil)
+	helper.NewSuccessResponse(w, http.StatusOK, "purchase order is "+string(data.Status), data)
+}
+
+func (h *Handler) DecidePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid id: "+err.Error())
+		return
+	}
+
+	var req struct {
+		Status  domain.ApprovalStatus `json:"status"`
+		Remarks *string               `json:"remarks,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.DecidePurchaseOrder(r.Context(), inst, id, req.Status, req.Remarks, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to decide purchase order: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "purchase order is "+string(data.Status), data)
 }
 
 // ========================= CREATE VENDOR =========================
 
 // SERVICE
+// CreateVendor records a supplier. A GSTIN, when given, must be valid and
+// match the vendor's state; it decides how GST on purchases is split.
 func (s *Service) CreateVendor(ctx context.Context, arg domain.Vendor) (*domain.Vendor, error) {
+	if arg.Name == "" {
+		return nil, ErrInvalidVendor
+	}
+	if arg.GSTNumber != nil {
+		gstin := helper.NormalizeGSTIN(*arg.GSTNumber)
+		arg.GSTNumber = nil
+		if gstin != "" {
+			if err := helper.ValidateGSTIN(gstin); err != nil {
+				return nil, err
+			}
+			arg.GSTNumber = &gstin
+		}
+	}
 	return s.repo.CreateVendor(ctx, arg)
 }
 
 // REPOSITORY
 func (r *Repository) CreateVendor(ctx context.Context, arg domain.Vendor) (*domain.Vendor, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
 
-	return &arg, nil
+	if arg.GSTNumber != nil && arg.StateID != nil {
+		if err := checkGSTINState(ctx, q, *arg.GSTNumber, *arg.StateID); err != nil {
+			return nil, err
+		}
+	}
+
+	row, err := q.CreateVendor(ctx, mapper.MapVendorDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vendor: %w", err)
+	}
+
+	result := mapper.MapVendorRowToDomain(row)
+	return &result, nil
 }
 
 // ========================= LIST VENDORS =========================
@@ -156,44 +280,323 @@ func (s *Service) ListVendors(ctx context.Context, instituteID uuid.UUID) ([]*do
 
 // REPOSITORY
 func (r *Repository) ListVendors(ctx context.Context, instituteID uuid.UUID) ([]*domain.Vendor, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListVendors(ctx, instituteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vendors: %w", err)
+	}
+
+	var vendors []*domain.Vendor
+	for _, row := range rows {
+		v := mapper.MapVendorRowToDomain(row)
+		vendors = append(vendors, &v)
+	}
 
-	return nil, nil
+	return vendors, nil
 }
 
 // ========================= CREATE PURCHASE ORDER =========================
 
 // SERVICE
 func (s *Service) CreatePurchaseOrder(ctx context.Context, arg domain.PurchaseOrder) (*domain.PurchaseOrder, error) {
+	if arg.VendorID == uuid.Nil {
+		return nil, ErrInvalidPurchaseOrder
+	}
+	if arg.OrderDate.IsZero() {
+		arg.OrderDate = time.Now()
+	}
+	if arg.ExpenseAccountID != nil {
+		if err := s.checkExpenseAccount(ctx, arg.InstituteID, *arg.ExpenseAccountID); err != nil {
+			return nil, err
+		}
+	}
 	return s.repo.CreatePurchaseOrder(ctx, arg)
 }
 
 // REPOSITORY
 func (r *Repository) CreatePurchaseOrder(ctx context.Context, arg domain.PurchaseOrder) (*domain.PurchaseOrder, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreatePurchaseOrder(ctx, mapper.MapPurchaseOrderDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create purchase order: %w", err)
+	}
 
-	return &arg, nil
+	result := mapper.MapPurchaseOrderRowToDomain(row)
+	return &result, nil
 }
 
 // ========================= ADD PURCHASE ITEM =========================
 
 // SERVICE
 func (s *Service) AddPurchaseItem(ctx context.Context, arg domain.PurchaseItem) (*domain.PurchaseItem, error) {
+	if arg.ItemID == uuid.Nil || arg.Quantity <= 0 || toPaise(arg.UnitPrice) <= 0 {
+		return nil, ErrInvalidPurchaseItem
+	}
 	return s.repo.AddPurchaseItem(ctx, arg)
 }
 
 // REPOSITORY
+// AddPurchaseItem adds an item to a draft or placed order
 func (r *Repository) AddPurchaseItem(ctx context.Context, arg domain.PurchaseItem) (*domain.PurchaseItem, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	po, err := lockPurchaseOrder(ctx, q, arg.InstituteID, arg.PurchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if po.Status != domain.PurchaseDraft && po.Status != domain.PurchaseOrdered {
+		return nil, ErrPurchaseOrderClosed
+	}
+
+	result, err := addPurchaseItem(ctx, q, po, arg)
+	if err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+// addPurchaseItem adds an item to a locked order, with the GST it is
+// charged, and refreshes the order's total. Items added once the order is
+// placed are committed to its budget too.
+func addPurchaseItem(ctx context.Context, q *db.Queries, po domain.PurchaseOrder, arg domain.PurchaseItem) (*domain.PurchaseItem, error) {
+	if err := purchaseItemTax(ctx, q, &arg); err != nil {
+		return nil, err
+	}
+
+	row, err := q.AddPurchaseItem(ctx, mapper.MapPurchaseItemDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add purchase item: %w", err)
+	}
+
+	if po.Status == domain.PurchaseOrdered {
+		if err := commitPurchaseItem(ctx, q, po, toPaise(helper.NullNumericToValue(row.TotalAmount))); err != nil {
+			return nil, err
+		}
+	}
 
-	return &arg, nil
+	if _, err := q.RefreshPurchaseOrderTotal(ctx, db.RefreshPurchaseOrderTotalParams{
+		ID:          po.ID,
+		InstituteID: po.InstituteID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update purchase order total: %w", err)
+	}
+
+	result := mapper.MapPurchaseItemRowToDomain(row)
+	return &result, nil
 }
 
 // ========================= UPDATE PURCHASE STATUS =========================
 
 // SERVICE
-func (s *Service) UpdatePurchaseStatus(ctx context.Context, id, instituteID uuid.UUID, status domain.PurchaseStatus) error {
-	return s.repo.UpdatePurchaseStatus(ctx, id, instituteID, status)
+// UpdatePurchaseStatus moves an order along purchaseTransitions. An order
+// asked to move to ordered may end up pending approval instead, when it
+// takes its budget past the soft limit. Cancelling or closing an order
+// releases what it still holds committed.
+func (s *Service) UpdatePurchaseStatus(ctx context.Context, id, instituteID uuid.UUID, status domain.PurchaseStatus, updatedBy *uuid.UUID) (*domain.PurchaseOrder, error) {
+	switch status {
+	case domain.PurchaseDraft, domain.PurchaseOrdered, domain.PurchaseClosed, domain.PurchaseCancelled:
+	default:
+		return nil, ErrInvalidPurchaseStatus
+	}
+
+	po, err := s.repo.UpdatePurchaseStatus(ctx, id, instituteID, status, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("purchase order %s is now %s", id, po.Status)
+	return po, nil
 }
 
 // REPOSITORY
-func (r *Repository) UpdatePurchaseStatus(ctx context.Context, id, instituteID uuid.UUID, status domain.PurchaseStatus) error {
+func (r *Repository) UpdatePurchaseStatus(ctx context.Context, id, instituteID uuid.UUID, status domain.PurchaseStatus, updatedBy *uuid.UUID) (*domain.PurchaseOrder, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	po, err := lockPurchaseOrder(ctx, q, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !helper.Contains(purchaseTransitions[po.Status], status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrPurchaseTransition, po.Status, status)
+	}
+
+	switch status {
+	case domain.PurchaseOrdered:
+		if status, err = commitPurchase(ctx, q, po, false); err != nil {
+			return nil, err
+		}
+	case domain.PurchaseCancelled:
+		if po.Status == domain.PurchasePendingApproval {
+			if err := closePurchaseApproval(ctx, q, po, updatedBy); err != nil {
+				return nil, err
+			}
+		}
+		if err := releasePurchase(ctx, q, po); err != nil {
+			return nil, err
+		}
+	case domain.PurchaseClosed:
+		if err := releasePurchase(ctx, q, po); err != nil {
+			return nil, err
+		}
+	}
+
+	row, err := q.UpdatePurchaseOrderStatus(ctx, db.UpdatePurchaseOrderStatusParams{
+		Status:      helper.ToNullString(string(status)),
+		UpdatedBy:   helper.ToNullUUID(helper.DerefUUID(updatedBy)),
+		ID:          id,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update purchase status: %w", err)
+	}
+
+	result := mapper.MapPurchaseOrderRowToDomain(row)
+	return &result, tx.Commit()
+}
+
+// closePurchaseApproval withdraws the pending approval of an order cancelled
+// before it was decided
+func closePurchaseApproval(ctx context.Context, q *db.Queries, po domain.PurchaseOrder, cancelledBy *uuid.UUID) error {
+	remarks := "purchase order cancelled"
+	_, err := q.DecideApproval(ctx, db.DecideApprovalParams{
+		Status:      helper.ToNullString(string(domain.ApprovalRejected)),
+		ApproverID:  helper.ToNullUUID(helper.DerefUUID(cancelledBy)),
+		Remarks:     helper.ToNullString(remarks),
+		InstituteID: po.InstituteID,
+		Module:      helper.ToNullString(purchaseApprovalModule),
+		ReferenceID: po.ID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to close approval: %w", err)
+	}
 	return nil
 }
+
+// ========================= DECIDE PURCHASE ORDER =========================
+
+// SERVICE
+// DecidePurchaseOrder approves or rejects an order waiting for approval past
+// its budget's soft limit. Approval places the order and commits it, still
+// within the hard limit; rejection returns it to draft. The user who placed
+// the order cannot decide it.
+func (s *Service) DecidePurchaseOrder(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.PurchaseOrder, error) {
+	if status != domain.ApprovalApproved && status != domain.ApprovalRejected {
+		return nil, ErrInvalidPurchaseDecision
+	}
+
+	po, err := s.repo.DecidePurchaseOrder(ctx, instituteID, id, status, remarks, decidedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("purchase order %s %s, now %s", po.ID, status, po.Status)
+	return po, nil
+}
+
+// REPOSITORY
+func (r *Repository) DecidePurchaseOrder(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.PurchaseOrder, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	po, err := lockPurchaseOrder(ctx, q, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	if po.Status != domain.PurchasePendingApproval {
+		return nil, ErrPurchaseNotPending
+	}
+	if decidedBy != nil && po.UpdatedBy != nil && *po.UpdatedBy == *decidedBy {
+		return nil, ErrSelfApproval
+	}
+
+	if _, err := q.DecideApproval(ctx, db.DecideApprovalParams{
+		Status:      helper.ToNullString(string(status)),
+		ApproverID:  helper.ToNullUUID(helper.DerefUUID(decidedBy)),
+		Remarks:     helper.ToNullString(helper.StrOrEmpty(remarks)),
+		InstituteID: instituteID,
+		Module:      helper.ToNullString(purchaseApprovalModule),
+		ReferenceID: id,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPurchaseNotPending
+		}
+		return nil, fmt.Errorf("failed to record decision: %w", err)
+	}
+
+	next := domain.PurchaseDraft
+	if status == domain.ApprovalApproved {
+		if next, err = commitPurchase(ctx, q, po, true); err != nil {
+			return nil, err
+		}
+	}
+
+	row, err := q.UpdatePurchaseOrderStatus(ctx, db.UpdatePurchaseOrderStatusParams{
+		Status:      helper.ToNullString(string(next)),
+		UpdatedBy:   helper.ToNullUUID(helper.DerefUUID(decidedBy)),
+		ID:          id,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update purchase status: %w", err)
+	}
+
+	result := mapper.MapPurchaseOrderRowToDomain(row)
+	return &result, tx.Commit()
+}
+
+// lockPurchaseOrder reads an order for update; orders without a status are drafts
+func lockPurchaseOrder(ctx context.Context, q *db.Queries, instituteID, id uuid.UUID) (domain.PurchaseOrder, error) {
+	row, err := q.GetPurchaseOrderForUpdate(ctx, db.GetPurchaseOrderForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PurchaseOrder{}, ErrPurchaseOrderNotFound
+		}
+		return domain.PurchaseOrder{}, err
+	}
+
+	po := mapper.MapPurchaseOrderRowToDomain(row)
+	if po.Status == "" {
+		po.Status = domain.PurchaseDraft
+	}
+	return po, nil
+}
@@ -0,0 +1,647 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidReceipt        = errors.New("a receipt needs a student, a payment mode and at least one allocation greater than zero, with each invoice listed once")
+	ErrChequeDetailsRequired = errors.New("cheque payments need the cheque number and date")
+	ErrReceiptNotFound       = errors.New("receipt not found")
+	ErrChequeNotPending      = errors.New("receipt has no cheque awaiting clearance")
+	ErrInvalidChequeBounce   = errors.New("a bounce needs a reason, and a bounce charge needs a fee head")
+	ErrBounceChargeNoInvoice = errors.New("the receipt paid no invoice to add the bounce charge to")
+)
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) CollectFees(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.Receipt
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+	if req.CollectedBy == nil {
+		req.CollectedBy = req.CreatedBy
+	}
+
+	data, err := h.service.CollectFees(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to collect fees: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "receipt "+data.ReceiptNo+" issued", data)
+}
+
+func (h *Handler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetReceipt(r.Context(), inst, id)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to fetch receipt: "+err.Error())
+		return
+	}
+
+	if r.URL.Query().Get("format") == "pdf" {
+		title, subtitle, header, rows := receiptTable(data)
+		helper.NewFileResponse(w, "application/pdf", "receipt-"+strings.ReplaceAll(data.ReceiptNo, "/", "-")+".pdf",
+			helper.RenderTablePDF(title, subtitle, header, rows))
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "receipt fetched successfully", data)
+}
+
+func (h *Handler) ListStudentReceipts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	student, err := helper.ParseRequiredUUIDFromQuery(r, "student_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid student_id: "+err.Error())
+		return
+	}
+
+	data, err := h.service.ListStudentReceipts(r.Context(), inst, student)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch receipts: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "receipts fetched successfully", data)
+}
+
+func (h *Handler) ListPendingCheques(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListPendingCheques(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch pending cheques: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "pending cheques fetched successfully", data)
+}
+
+func (h *Handler) ClearCheque(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "receipt_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid receipt_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ClearCheque(r.Context(), inst, id, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to clear cheque: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "cheque cleared", data)
+}
+
+func (h *Handler) BounceCheque(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.ChequeBounce
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.RecordedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.BounceCheque(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to record bounced cheque: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "cheque marked bounced", data)
+}
+
+// ========================= COLLECT FEES =========================
+
+// SERVICE
+// CollectFees takes one payment at the counter and spreads it over the
+// student's invoices as allocated. Any invoice may be paid in part. The
+// receipt is numbered and every allocation posted in one transaction.
+func (s *Service) CollectFees(ctx context.Context, arg domain.Receipt) (*domain.Receipt, error) {
+	if arg.StudentID == uuid.Nil || !helper.Contains(paymentModes, arg.PaymentMode) || len(arg.Allocations) == 0 {
+		return nil, ErrInvalidReceipt
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, a := range arg.Allocations {
+		if toPaise(a.Amount) <= 0 {
+			return nil, ErrInvalidReceipt
+		}
+		if a.InvoiceID != nil {
+			if seen[*a.InvoiceID] {
+				return nil, ErrInvalidReceipt
+			}
+			seen[*a.InvoiceID] = true
+		}
+	}
+	if arg.PaymentMode == domain.PaymentCheque &&
+		(arg.ChequeNo == nil || strings.TrimSpace(*arg.ChequeNo) == "" || arg.ChequeDate == nil) {
+		return nil, ErrChequeDetailsRequired
+	}
+
+	receipt, err := s.repo.CollectFees(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("receipt %s of %.2f issued over %d allocations", receipt.ReceiptNo, receipt.Amount, len(receipt.Allocations))
+	return receipt, nil
+}
+
+// REPOSITORY
+func (r *Repository) CollectFees(ctx context.Context, arg domain.Receipt) (*domain.Receipt, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	receipt, _, err := issueReceipt(ctx, q, arg)
+	if err != nil {
+		return nil, err
+	}
+	return receipt, tx.Commit()
+}
+
+// issueReceipt numbers a receipt and records each of its allocations as a
+// payment, all within the caller's transaction. The number is taken from
+// the institute's sequence for the fiscal year of the receipt date, so a
+// rolled back receipt leaves no gap.
+func issueReceipt(ctx context.Context, q *db.Queries, arg domain.Receipt) (*domain.Receipt, []domain.Transaction, error) {
+	if arg.ReceiptDate.IsZero() {
+		arg.ReceiptDate = time.Now()
+	}
+	arg.ReceiptDate = dateOnly(arg.ReceiptDate)
+
+	startMonth, err := fiscalYearStartMonth(ctx, q, arg.InstituteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	start, end := fiscalYearOf(arg.ReceiptDate, startMonth)
+	arg.FiscalYear = start.Year()
+
+	n, err := q.NextReceiptNumber(ctx, db.NextReceiptNumberParams{
+		InstituteID: arg.InstituteID,
+		FiscalYear:  int32(arg.FiscalYear),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to number receipt: %w", err)
+	}
+	arg.ReceiptNo = fmt.Sprintf("RCT/%s/%06d", fiscalYearLabel(start, end), n)
+
+	var total int64
+	for _, a := range arg.Allocations {
+		total += toPaise(a.Amount)
+	}
+	arg.Amount = fromPaise(total)
+
+	var chequeStatus sql.NullString
+	if arg.PaymentMode == domain.PaymentCheque {
+		pending := domain.ChequePending
+		arg.ChequeStatus = &pending
+		chequeStatus = helper.ToNullString(string(pending))
+	}
+
+	row, err := q.CreateReceipt(ctx, mapper.MapReceiptDomainToParams(arg))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create receipt: %w", err)
+	}
+	receipt := mapper.MapReceiptRowToDomain(row)
+
+	txns := make([]domain.Transaction, 0, len(arg.Allocations))
+	for _, a := range arg.Allocations {
+		payment := domain.Transaction{
+			InvoiceID:        a.InvoiceID,
+			StudentID:        &receipt.StudentID,
+			TransactionRefNo: arg.TransactionRefNo,
+			PaymentMode:      arg.PaymentMode,
+			ChequeNo:         arg.ChequeNo,
+			ChequeDate:       arg.ChequeDate,
+			BankName:         arg.BankName,
+			Amount:           a.Amount,
+			CollectedBy:      arg.CollectedBy,
+		}
+		payment.InstituteID = receipt.InstituteID
+		payment.CreatedBy = arg.CreatedBy
+
+		txn, err := recordPayment(ctx, q, payment)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		txnRow, err := q.AttachTransactionReceipt(ctx, db.AttachTransactionReceiptParams{
+			ReceiptID:    helper.ToNullUUID(receipt.ID),
+			ChequeStatus: chequeStatus,
+			ID:           txn.ID,
+			InstituteID:  receipt.InstituteID,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to attach payment to receipt: %w", err)
+		}
+		txns = append(txns, mapper.MapTransactionRowToDomain(txnRow))
+
+		receipt.Allocations = append(receipt.Allocations, domain.ReceiptAllocation{
+			InvoiceID:     a.InvoiceID,
+			Amount:        a.Amount,
+			TransactionID: txn.ID,
+		})
+	}
+
+	return &receipt, txns, nil
+}
+
+// ========================= GET RECEIPTS =========================
+
+// SERVICE
+func (s *Service) GetReceipt(ctx context.Context, instituteID, id uuid.UUID) (*domain.Receipt, error) {
+	return s.repo.GetReceipt(ctx, instituteID, id)
+}
+
+func (s *Service) ListStudentReceipts(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.Receipt, error) {
+	return s.repo.ListStudentReceipts(ctx, instituteID, studentID)
+}
+
+func (s *Service) ListPendingCheques(ctx context.Context, instituteID uuid.UUID) ([]*domain.Receipt, error) {
+	return s.repo.ListPendingCheques(ctx, instituteID)
+}
+
+// REPOSITORY
+func (r *Repository) GetReceipt(ctx context.Context, instituteID, id uuid.UUID) (*domain.Receipt, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetReceipt(ctx, db.GetReceiptParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReceiptNotFound
+		}
+		return nil, err
+	}
+	receipt := mapper.MapReceiptRowToDomain(row)
+
+	txns, err := q.ListReceiptTransactions(ctx, db.ListReceiptTransactionsParams{ReceiptID: helper.ToNullUUID(id), InstituteID: instituteID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list receipt payments: %w", err)
+	}
+	for _, t := range txns {
+		txn := mapper.MapTransactionRowToDomain(t)
+		receipt.Allocations = append(receipt.Allocations, domain.ReceiptAllocation{
+			InvoiceID:     txn.InvoiceID,
+			Amount:        txn.Amount,
+			TransactionID: txn.ID,
+		})
+	}
+	return &receipt, nil
+}
+
+func (r *Repository) ListStudentReceipts(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.Receipt, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListStudentReceipts(ctx, db.ListStudentReceiptsParams{InstituteID: instituteID, StudentID: studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list receipts: %w", err)
+	}
+	return mapReceipts(rows), nil
+}
+
+func (r *Repository) ListPendingCheques(ctx context.Context, instituteID uuid.UUID) ([]*domain.Receipt, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListPendingCheques(ctx, instituteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending cheques: %w", err)
+	}
+	return mapReceipts(rows), nil
+}
+
+func mapReceipts(rows []db.FinanceReceipt) []*domain.Receipt {
+	receipts := make([]*domain.Receipt, 0, len(rows))
+	for _, row := range rows {
+		rc := mapper.MapReceiptRowToDomain(row)
+		receipts = append(receipts, &rc)
+	}
+	return receipts
+}
+
+// receiptTable lays a receipt out for printing
+func receiptTable(rc *domain.Receipt) (string, string, []string, [][]string) {
+	subtitle := fmt.Sprintf("%s  |  %s  |  %s", rc.ReceiptNo, rc.ReceiptDate.Format("02 Jan 2006"), strings.ToUpper(string(rc.PaymentMode)))
+	if rc.ChequeNo != nil {
+		subtitle += "  |  Cheque " + *rc.ChequeNo
+		if rc.BankName != nil {
+			subtitle += ", " + *rc.BankName
+		}
+	}
+
+	rows := make([][]string, 0, len(rc.Allocations)+1)
+	for _, a := range rc.Allocations {
+		against := "Credit on account"
+		if a.InvoiceID != nil {
+			against = "Invoice " + a.InvoiceID.String()
+		}
+		rows = append(rows, []string{against, fmt.Sprintf("%.2f", a.Amount)})
+	}
+	rows = append(rows, []string{"Total", fmt.Sprintf("%.2f", rc.Amount)})
+
+	return "Fee Receipt", subtitle, []string{"Paid against", "Amount"}, rows
+}
+
+// ========================= CHEQUE CLEARANCE =========================
+
+// SERVICE
+func (s *Service) ClearCheque(ctx context.Context, instituteID, receiptID uuid.UUID, clearedBy *uuid.UUID) (*domain.Receipt, error) {
+	receipt, err := s.repo.ClearCheque(ctx, instituteID, receiptID, clearedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("cheque on receipt %s cleared", receipt.ReceiptNo)
+	return receipt, nil
+}
+
+// BounceCheque undoes every payment on the receipt, which re-opens the
+// invoices they paid, and bills the bounce charge if there is one.
+func (s *Service) BounceCheque(ctx context.Context, arg domain.ChequeBounce) (*domain.Receipt, error) {
+	arg.Reason = strings.TrimSpace(arg.Reason)
+	charge := toPaise(arg.BounceCharge)
+	if arg.Reason == "" || charge < 0 || (charge > 0 && arg.FeeHeadID == nil) {
+		return nil, ErrInvalidChequeBounce
+	}
+
+	receipt, err := s.repo.BounceCheque(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("cheque on receipt %s bounced, %.2f reversed", receipt.ReceiptNo, receipt.Amount)
+	return receipt, nil
+}
+
+// REPOSITORY
+func (r *Repository) ClearCheque(ctx context.Context, instituteID, receiptID uuid.UUID, clearedBy *uuid.UUID) (*domain.Receipt, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if _, err := lockPendingCheque(ctx, q, instituteID, receiptID); err != nil {
+		return nil, err
+	}
+	receipt, err := settleCheque(ctx, q, instituteID, receiptID, domain.ChequeCleared, "", clearedBy)
+	if err != nil {
+		return nil, err
+	}
+	return receipt, tx.Commit()
+}
+
+func (r *Repository) BounceCheque(ctx context.Context, arg domain.ChequeBounce) (*domain.Receipt, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	settings, err := loadLedgerSettings(ctx, q, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+	row, err := lockPendingCheque(ctx, q, arg.InstituteID, arg.ReceiptID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListReceiptTransactions(ctx, db.ListReceiptTransactionsParams{
+		ReceiptID:   helper.ToNullUUID(arg.ReceiptID),
+		InstituteID: arg.InstituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list receipt payments: %w", err)
+	}
+
+	var chargeInvoice *uuid.UUID
+	for _, t := range rows {
+		txn := mapper.MapTransactionRowToDomain(t)
+		amount := toPaise(txn.Amount)
+
+		if txn.InvoiceID != nil {
+			invoice, err := lockInvoice(ctx, q, arg.InstituteID, *txn.InvoiceID)
+			if err != nil {
+				return nil, err
+			}
+			invoice.PaidAmount = fromPaise(max(toPaise(invoice.PaidAmount)-amount, 0))
+			if _, err := saveInvoiceAmounts(ctx, q, invoice, arg.RecordedBy); err != nil {
+				return nil, err
+			}
+			if chargeInvoice == nil {
+				chargeInvoice = txn.InvoiceID
+			}
+		}
+
+		account, err := paymentAccount(settings, txn)
+		if err != nil {
+			return nil, err
+		}
+		lines := newJournalLines()
+		lines.debit(settings.ReceivablesAccountID, amount)
+		lines.credit(account, amount)
+
+		entry := sourceEntry(arg.InstituteID, domain.JournalSourceChequeBounce, txn.ID, row.ReceiptNo,
+			"Cheque "+helper.NullStringToValue(row.ChequeNo)+" bounced: "+arg.Reason, arg.RecordedBy)
+		if _, err := postSourceJournal(ctx, q, entry, lines); err != nil {
+			return nil, err
+		}
+	}
+
+	if toPaise(arg.BounceCharge) > 0 {
+		if chargeInvoice == nil {
+			return nil, ErrBounceChargeNoInvoice
+		}
+		description := "Cheque bounce charge, receipt " + row.ReceiptNo
+		item := domain.InvoiceItem{
+			InvoiceID:   *chargeInvoice,
+			FeeHeadID:   arg.FeeHeadID,
+			Amount:      arg.BounceCharge,
+			Description: &description,
+		}
+		item.InstituteID = arg.InstituteID
+		item.CreatedBy = arg.RecordedBy
+		if _, err := addInvoiceItem(ctx, q, item); err != nil {
+			return nil, err
+		}
+	}
+
+	receipt, err := settleCheque(ctx, q, arg.InstituteID, arg.ReceiptID, domain.ChequeBounced, arg.Reason, arg.RecordedBy)
+	if err != nil {
+		return nil, err
+	}
+	return receipt, tx.Commit()
+}
+
+func lockPendingCheque(ctx context.Context, q *db.Queries, instituteID, receiptID uuid.UUID) (db.FinanceReceipt, error) {
+	row, err := q.GetReceiptForUpdate(ctx, db.GetReceiptForUpdateParams{ID: receiptID, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return row, ErrReceiptNotFound
+		}
+		return row, err
+	}
+	if row.ChequeStatus.String != string(domain.ChequePending) {
+		return row, ErrChequeNotPending
+	}
+	return row, nil
+}
+
+// settleCheque moves a pending cheque and its payments to cleared or bounced
+func settleCheque(ctx context.Context, q *db.Queries, instituteID, receiptID uuid.UUID, status domain.ChequeStatus, reason string, by *uuid.UUID) (*domain.Receipt, error) {
+	row, err := q.SetReceiptChequeStatus(ctx, db.SetReceiptChequeStatusParams{
+		ChequeStatus: helper.ToNullString(string(status)),
+		BounceReason: helper.ToNullString(reason),
+		ID:           receiptID,
+		InstituteID:  instituteID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrChequeNotPending
+		}
+		return nil, err
+	}
+
+	var txnStatus sql.NullString
+	if status == domain.ChequeBounced {
+		txnStatus = helper.ToNullString(string(domain.ChequeBounced))
+	}
+	err = q.SetReceiptTransactionsChequeStatus(ctx, db.SetReceiptTransactionsChequeStatusParams{
+		ChequeStatus: helper.ToNullString(string(status)),
+		Status:       txnStatus,
+		UpdatedBy:    helper.ToNullUUID(helper.DerefUUID(by)),
+		ReceiptID:    helper.ToNullUUID(receiptID),
+		InstituteID:  instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update receipt payments: %w", err)
+	}
+
+	receipt := mapper.MapReceiptRowToDomain(row)
+	return &receipt, nil
+}
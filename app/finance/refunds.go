@@ -0,0 +1,893 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefund            = errors.New("a refund needs an invoice or a student and an amount greater than zero")
+	ErrRefundNotFound           = errors.New("refund not found")
+	ErrRefundNotApproved        = errors.New("only approved refunds can be processed")
+	ErrRefundInProgress         = errors.New("the refund is already being paid out through the gateway")
+	ErrRefundExceedsRefundable  = errors.New("refund exceeds the refundable fees of the invoice")
+	ErrRefundExceedsWallet      = errors.New("refund exceeds the wallet balance not already claimed by other refunds")
+	ErrInvalidRefundDecision    = errors.New("decision must be approved or rejected")
+	ErrRefundDecided            = errors.New("the refund request has already been decided")
+	ErrRepeatApprover           = errors.New("each approval step needs a different approver")
+	ErrInvalidRefundPayout      = errors.New("payout mode must be cash, bank_transfer or gateway, and gateway payouts need an invoice refund")
+	ErrNoRefundableCheckout     = errors.New("no online payment of the invoice has enough left to refund through the gateway")
+	ErrGatewayRefundUnsupported = errors.New("the institute's payment gateway cannot refund payments")
+	ErrInvalidRefundSettings    = errors.New("approval steps must be between 1 and 5 and the second step threshold cannot be negative")
+)
+
+// refundApprovalModule identifies refunds in core.approvals
+const refundApprovalModule = "finance/refunds"
+
+var refundPayoutModes = []domain.RefundPayoutMode{
+	domain.RefundByCash,
+	domain.RefundByBankTransfer,
+	domain.RefundByGateway,
+}
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) RequestRefund(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.Refund
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.RequestRefund(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to request refund: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "refund requested", data)
+}
+
+func (h *Handler) DecideRefund(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "refund_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid refund_id: "+err.Error())
+		return
+	}
+
+	var req struct {
+		Status  domain.ApprovalStatus `json:"status"`
+		Remarks *string               `json:"remarks,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.DecideRefund(r.Context(), inst, id, req.Status, req.Remarks, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to decide refund: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "refund "+string(data.Status), data)
+}
+
+func (h *Handler) ProcessRefund(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "refund_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid refund_id: "+err.Error())
+		return
+	}
+
+	var req domain.RefundPayout
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ProcessedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.ProcessRefund(r.Context(), inst, id, req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to process refund: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "refund processed successfully", data)
+}
+
+func (h *Handler) GetRefund(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetRefund(r.Context(), inst, id)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to fetch refund: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "refund fetched successfully", data)
+}
+
+func (h *Handler) ListRefunds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var status *domain.RefundStatus
+	if v := r.URL.Query().Get("status"); v != "" {
+		st := domain.RefundStatus(v)
+		status = &st
+	}
+
+	data, err := h.service.ListRefunds(r.Context(), inst, status)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to list refunds: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "refunds fetched successfully", data)
+}
+
+func (h *Handler) GetRefundSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetRefundSettings(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch refund settings: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "refund settings fetched successfully", data)
+}
+
+func (h *Handler) UpdateRefundSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.RefundSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.UpdatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.UpdateRefundSettings(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to update refund settings: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "refund settings updated", data)
+}
+
+// ========================= REQUEST REFUND =========================
+
+// SERVICE
+// RequestRefund opens a refund against an invoice or a wallet balance and
+// the first of its approval steps. An invoice refund is limited to what was
+// paid for its refundable fee heads, less earlier refunds; a wallet refund
+// to the balance not already claimed.
+func (s *Service) RequestRefund(ctx context.Context, arg domain.Refund) (*domain.Refund, error) {
+	if toPaise(arg.Amount) <= 0 || (arg.InvoiceID == nil && arg.StudentID == nil) {
+		return nil, ErrInvalidRefund
+	}
+
+	refund, err := s.repo.RequestRefund(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("refund %s of %.2f requested, %d approvals needed", refund.ID, refund.Amount, refund.ApprovalsRequired)
+	return refund, nil
+}
+
+// REPOSITORY
+func (r *Repository) RequestRefund(ctx context.Context, arg domain.Refund) (*domain.Refund, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if arg.InvoiceID != nil {
+		err = checkInvoiceRefund(ctx, q, &arg)
+	} else {
+		err = checkWalletRefund(ctx, q, arg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	arg.ApprovalsRequired = 1
+	settings, err := q.GetRefundSettings(ctx, arg.InstituteID)
+	switch {
+	case err == nil:
+		if policy := mapper.MapRefundSettingsRowToDomain(settings); toPaise(arg.Amount) > toPaise(policy.SecondStepAbove) {
+			arg.ApprovalsRequired = policy.ApprovalSteps
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	row, err := q.CreateRefund(ctx, mapper.MapRefundDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	if _, err := q.CreateApprovalStep(ctx, db.CreateApprovalStepParams{
+		InstituteID: row.InstituteID,
+		Module:      helper.ToNullString(refundApprovalModule),
+		ReferenceID: row.ID,
+		Step:        1,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to request approval: %w", err)
+	}
+
+	refund := mapper.MapRefundRowToDomain(row)
+	return &refund, tx.Commit()
+}
+
+// checkInvoiceRefund fills in the invoice's student and checks the amount
+// against what is left to refund. The invoice stays locked so concurrent
+// requests are checked one after the other.
+func checkInvoiceRefund(ctx context.Context, q *db.Queries, arg *domain.Refund) error {
+	invoice, err := lockInvoice(ctx, q, arg.InstituteID, *arg.InvoiceID)
+	if err != nil {
+		return err
+	}
+	if arg.StudentID != nil && *arg.StudentID != invoice.StudentID {
+		return ErrPaymentStudentMismatch
+	}
+	arg.StudentID = &invoice.StudentID
+
+	items, err := listInvoiceItems(ctx, q, arg.InstituteID, invoice.ID)
+	if err != nil {
+		return err
+	}
+	heads, err := loadFeeHeads(ctx, q, arg.InstituteID, items)
+	if err != nil {
+		return err
+	}
+	var refundable int64
+	for _, item := range items {
+		if item.FeeHeadID != nil && heads[*item.FeeHeadID].IsRefundable.Bool {
+			refundable += toPaise(item.Amount) - toPaise(item.DiscountApplied)
+		}
+	}
+
+	open, err := q.SumOpenInvoiceRefunds(ctx, db.SumOpenInvoiceRefundsParams{InvoiceID: helper.ToNullUUID(invoice.ID), InstituteID: arg.InstituteID})
+	if err != nil {
+		return err
+	}
+
+	requested, err := sumPaise(open)
+	if err != nil {
+		return err
+	}
+	left := min(refundable, toPaise(invoice.PaidAmount)) - requested
+	if toPaise(arg.Amount) > left {
+		return fmt.Errorf("%w: %.2f can still be refunded", ErrRefundExceedsRefundable, fromPaise(max(left, 0)))
+	}
+	return nil
+}
+
+// checkWalletRefund checks the amount against the wallet balance less the
+// refunds already requested from it. The wallet stays locked meanwhile.
+func checkWalletRefund(ctx context.Context, q *db.Queries, arg domain.Refund) error {
+	row, err := q.LockStudentWallet(ctx, db.LockStudentWalletParams{InstituteID: arg.InstituteID, StudentID: *arg.StudentID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWalletStudentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock wallet: %w", err)
+	}
+	wallet := mapper.MapStudentWalletRowToDomain(row)
+
+	open, err := q.SumOpenWalletRefunds(ctx, db.SumOpenWalletRefundsParams{StudentID: helper.ToNullUUID(*arg.StudentID), InstituteID: arg.InstituteID})
+	if err != nil {
+		return err
+	}
+
+	requested, err := sumPaise(open)
+	if err != nil {
+		return err
+	}
+	left := toPaise(wallet.Balance) - requested
+	if toPaise(arg.Amount) > left {
+		return fmt.Errorf("%w: %.2f can still be refunded", ErrRefundExceedsWallet, fromPaise(max(left, 0)))
+	}
+	return nil
+}
+
+// ========================= DECIDE REFUND =========================
+
+// SERVICE
+// DecideRefund records the decision of the refund's current approval step.
+// A rejection ends the request; an approval opens the next step until all
+// steps are approved. Nobody may approve their own request or two steps.
+func (s *Service) DecideRefund(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.Refund, error) {
+	if status != domain.ApprovalApproved && status != domain.ApprovalRejected {
+		return nil, ErrInvalidRefundDecision
+	}
+
+	refund, err := s.repo.DecideRefund(ctx, instituteID, id, status, remarks, decidedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("refund %s %s after %d of %d approvals", refund.ID, refund.Status, refund.ApprovalsReceived, refund.ApprovalsRequired)
+	return refund, nil
+}
+
+// REPOSITORY
+func (r *Repository) DecideRefund(ctx context.Context, instituteID, id uuid.UUID, status domain.ApprovalStatus, remarks *string, decidedBy *uuid.UUID) (*domain.Refund, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	row, err := q.GetRefundForUpdate(ctx, db.GetRefundForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, err
+	}
+	if domain.RefundStatus(row.Status.String) != domain.RefundRequested {
+		return nil, ErrRefundDecided
+	}
+	if decidedBy != nil && row.CreatedBy.Valid && row.CreatedBy.UUID == *decidedBy {
+		return nil, ErrSelfApproval
+	}
+
+	steps, err := listRefundApprovals(ctx, q, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		if decidedBy != nil && step.ApproverID != nil && *step.ApproverID == *decidedBy {
+			return nil, ErrRepeatApprover
+		}
+	}
+
+	_, err = q.DecideApproval(ctx, db.DecideApprovalParams{
+		Status:      helper.ToNullString(string(status)),
+		ApproverID:  helper.ToNullUUID(helper.DerefUUID(decidedBy)),
+		Remarks:     helper.ToNullString(helper.StrOrEmpty(remarks)),
+		InstituteID: instituteID,
+		Module:      helper.ToNullString(refundApprovalModule),
+		ReferenceID: id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRefundDecided
+		}
+		return nil, fmt.Errorf("failed to record decision: %w", err)
+	}
+
+	if status == domain.ApprovalRejected {
+		row, err = q.RejectRefund(ctx, db.RejectRefundParams{ID: id, InstituteID: instituteID})
+	} else {
+		row, err = q.RecordRefundApproval(ctx, db.RecordRefundApprovalParams{ID: id, InstituteID: instituteID})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update refund: %w", err)
+	}
+
+	if domain.RefundStatus(row.Status.String) == domain.RefundRequested {
+		if _, err := q.CreateApprovalStep(ctx, db.CreateApprovalStepParams{
+			InstituteID: instituteID,
+			Module:      helper.ToNullString(refundApprovalModule),
+			ReferenceID: id,
+			Step:        row.ApprovalsReceived + 1,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to request approval: %w", err)
+		}
+	}
+
+	refund := mapper.MapRefundRowToDomain(row)
+	if refund.Approvals, err = listRefundApprovals(ctx, q, instituteID, id); err != nil {
+		return nil, err
+	}
+	return &refund, tx.Commit()
+}
+
+func listRefundApprovals(ctx context.Context, q *db.Queries, instituteID, refundID uuid.UUID) ([]domain.ApprovalStep, error) {
+	rows, err := q.ListApprovals(ctx, db.ListApprovalsParams{
+		InstituteID: instituteID,
+		Module:      helper.ToNullString(refundApprovalModule),
+		ReferenceID: refundID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvals: %w", err)
+	}
+
+	steps := make([]domain.ApprovalStep, 0, len(rows))
+	for _, row := range rows {
+		steps = append(steps, mapper.MapApprovalRowToDomain(row))
+	}
+	return steps, nil
+}
+
+// ========================= PROCESS REFUND =========================
+
+// SERVICE
+// ProcessRefund pays out an approved refund and posts it. A refund against
+// an invoice reverses income of the invoice's refundable fee heads and is
+// added to the invoice's refunded amount; a wallet refund comes out of the
+// student's advances and the wallet. A gateway payout first claims the
+// refund so no other request can pay it, then asks the gateway to refund
+// the invoice's online payment keyed by the refund id, and records the
+// refund only once the gateway has paid it.
+func (s *Service) ProcessRefund(ctx context.Context, instituteID, id uuid.UUID, payout domain.RefundPayout) (*domain.Refund, error) {
+	if !helper.Contains(refundPayoutModes, payout.Mode) {
+		return nil, ErrInvalidRefundPayout
+	}
+
+	var checkout *uuid.UUID
+	if payout.Mode == domain.RefundByGateway {
+		gw, _, _, err := s.gateway(ctx, instituteID, s.paymentCallbackURL)
+		if err != nil {
+			return nil, err
+		}
+		refunder, ok := gw.(helper.PaymentRefunder)
+		if !ok {
+			return nil, ErrGatewayRefundUnsupported
+		}
+
+		refund, op, err := s.repo.ClaimGatewayRefund(ctx, instituteID, id)
+		if err != nil {
+			return nil, err
+		}
+
+		ref, err := refunder.RefundPayment(ctx, helper.StrOrEmpty(op.GatewayPaymentID), refund.Amount, refund.ID.String())
+		if err != nil {
+			if rerr := s.repo.ReleaseGatewayRefund(ctx, instituteID, id); rerr != nil {
+				logger.Errorf("refund %s stays in processing after the gateway failed: %v", id, rerr)
+			}
+			return nil, fmt.Errorf("%w: %v", ErrGatewayUnavailable, err)
+		}
+		payout.Reference = &ref
+		checkout = &op.ID
+	}
+
+	refund, err := s.repo.ProcessRefund(ctx, instituteID, id, payout, checkout)
+	if err != nil {
+		if checkout != nil {
+			logger.Errorf("refund %s was paid by the gateway as %s but could not be recorded: %v", id, *payout.Reference, err)
+		}
+		return nil, err
+	}
+
+	logger.Infof("refund %s of %.2f processed by %s", refund.ID, refund.Amount, payout.Mode)
+	return refund, nil
+}
+
+// REPOSITORY
+func (r *Repository) ProcessRefund(ctx context.Context, instituteID, id uuid.UUID, payout domain.RefundPayout, onlinePaymentID *uuid.UUID) (*domain.Refund, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	settings, err := loadLedgerSettings(ctx, q, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetRefundForUpdate(ctx, db.GetRefundForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, err
+	}
+	// gateway payouts finish the refund they claimed, the others need it approved
+	want := domain.RefundApproved
+	if onlinePaymentID != nil {
+		want = domain.RefundProcessing
+	}
+	if status := domain.RefundStatus(row.Status.String); status != want {
+		if status == domain.RefundProcessing {
+			return nil, ErrRefundInProgress
+		}
+		return nil, ErrRefundNotApproved
+	}
+	refund := mapper.MapRefundRowToDomain(row)
+
+	var (
+		items    []domain.InvoiceItem
+		taxes    refundTaxes
+		refunded int64
+	)
+	if refund.InvoiceID != nil {
+		invoice, err := lockInvoice(ctx, q, instituteID, *refund.InvoiceID)
+		if err != nil {
+			return nil, err
+		}
+		refunded = toPaise(invoice.RefundedAmount)
+		if items, err = listInvoiceItems(ctx, q, instituteID, invoice.ID); err != nil {
+			return nil, err
+		}
+		if taxes, err = loadRefundTaxes(ctx, q, instituteID, invoice.ID); err != nil {
+			return nil, err
+		}
+	}
+	heads, err := loadFeeHeads(ctx, q, instituteID, items)
+	if err != nil {
+		return nil, err
+	}
+
+	account, mode := settings.BankAccountID, domain.PaymentBankTransfer
+	if payout.Mode == domain.RefundByCash {
+		account, mode = settings.CashAccountID, domain.PaymentCash
+	}
+	lines, err := refundLines(settings, heads, items, taxes, refunded, refund, account)
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.InvoiceID != nil {
+		err = q.AddInvoiceRefund(ctx, db.AddInvoiceRefundParams{
+			Amount:      fmt.Sprintf("%.2f", refund.Amount),
+			ID:          *refund.InvoiceID,
+			InstituteID: instituteID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update invoice: %w", err)
+		}
+	} else {
+		remarks := "Refund " + refund.ID.String()
+		if _, err := writeWalletEntry(ctx, q, domain.WalletEntry{
+			InstituteID: instituteID,
+			StudentID:   helper.DerefUUID(refund.StudentID),
+			EntryType:   domain.WalletRefund,
+			Purpose:     domain.WalletForRefund,
+			Amount:      refund.Amount,
+			PaymentMode: &mode,
+			ReferenceNo: payout.Reference,
+			Remarks:     &remarks,
+			CreatedBy:   payout.ProcessedBy,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := q.SetRefundPayout(ctx, db.SetRefundPayoutParams{
+		PayoutMode:      helper.ToNullString(string(payout.Mode)),
+		PayoutReference: helper.ToNullString(helper.StrOrEmpty(payout.Reference)),
+		OnlinePaymentID: helper.ToNullUUID(helper.DerefUUID(onlinePaymentID)),
+		ID:              id,
+		InstituteID:     instituteID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record payout: %w", err)
+	}
+
+	row, err = q.MarkRefundProcessed(ctx, db.MarkRefundProcessedParams{
+		ProcessedBy: helper.ToNullUUID(helper.DerefUUID(payout.ProcessedBy)),
+		ID:          id,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	refund = mapper.MapRefundRowToDomain(row)
+
+	description := "Refund"
+	if refund.Reason != nil {
+		description += ": " + *refund.Reason
+	}
+
+	entry := sourceEntry(instituteID, domain.JournalSourceRefund, refund.ID,
+		referenceOr(payout.Reference, "RFD-"+refund.ID.String()[:8]), description, payout.ProcessedBy)
+	if _, err := postSourceJournal(ctx, q, entry, lines); err != nil {
+		return nil, err
+	}
+
+	return &refund, tx.Commit()
+}
+
+// ClaimGatewayRefund moves an approved invoice refund to processing against
+// the invoice's latest online payment with enough left to refund. A refund
+// left in processing by an earlier attempt is claimed again with the same
+// payment, since the gateway call is keyed by the refund id.
+func (r *Repository) ClaimGatewayRefund(ctx context.Context, instituteID, id uuid.UUID) (*domain.Refund, *domain.OnlinePayment, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	row, err := q.GetRefundForUpdate(ctx, db.GetRefundForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrRefundNotFound
+		}
+		return nil, nil, err
+	}
+	if !row.InvoiceID.Valid {
+		return nil, nil, ErrInvalidRefundPayout
+	}
+
+	var checkout db.FinanceOnlinePayment
+	switch domain.RefundStatus(row.Status.String) {
+	case domain.RefundProcessing:
+		if checkout, err = q.GetOnlinePayment(ctx, db.GetOnlinePaymentParams{
+			ID:          row.OnlinePaymentID.UUID,
+			InstituteID: instituteID,
+		}); err != nil {
+			return nil, nil, err
+		}
+	case domain.RefundApproved:
+		checkout, err = q.GetRefundableOnlinePayment(ctx, db.GetRefundableOnlinePaymentParams{
+			InvoiceID:   row.InvoiceID.UUID,
+			InstituteID: instituteID,
+			Amount:      row.Amount,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil, ErrNoRefundableCheckout
+			}
+			return nil, nil, err
+		}
+		if row, err = q.ClaimGatewayRefund(ctx, db.ClaimGatewayRefundParams{
+			OnlinePaymentID: helper.ToNullUUID(checkout.ID),
+			ID:              id,
+			InstituteID:     instituteID,
+		}); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, ErrRefundNotApproved
+	}
+
+	refund := mapper.MapRefundRowToDomain(row)
+	op := mapper.MapOnlinePaymentRowToDomain(checkout)
+	return &refund, &op, tx.Commit()
+}
+
+// ReleaseGatewayRefund returns a claimed refund to approved after the
+// gateway failed to pay it
+func (r *Repository) ReleaseGatewayRefund(ctx context.Context, instituteID, id uuid.UUID) error {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return err
+	}
+
+	return q.ReleaseGatewayRefund(ctx, db.ReleaseGatewayRefundParams{ID: id, InstituteID: instituteID})
+}
+
+// ========================= LIST REFUNDS =========================
+
+// SERVICE
+func (s *Service) GetRefund(ctx context.Context, instituteID, id uuid.UUID) (*domain.Refund, error) {
+	return s.repo.GetRefund(ctx, instituteID, id)
+}
+
+func (s *Service) ListRefunds(ctx context.Context, instituteID uuid.UUID, status *domain.RefundStatus) ([]*domain.Refund, error) {
+	return s.repo.ListRefunds(ctx, instituteID, status)
+}
+
+// REPOSITORY
+func (r *Repository) GetRefund(ctx context.Context, instituteID, id uuid.UUID) (*domain.Refund, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetRefund(ctx, db.GetRefundParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, err
+	}
+
+	refund := mapper.MapRefundRowToDomain(row)
+	if refund.Approvals, err = listRefundApprovals(ctx, q, instituteID, id); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *Repository) ListRefunds(ctx context.Context, instituteID uuid.UUID, status *domain.RefundStatus) ([]*domain.Refund, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	var st sql.NullString
+	if status != nil {
+		st = helper.ToNullString(string(*status))
+	}
+	rows, err := q.ListRefunds(ctx, db.ListRefundsParams{InstituteID: instituteID, Status: st})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	refunds := make([]*domain.Refund, 0, len(rows))
+	for _, row := range rows {
+		rf := mapper.MapRefundRowToDomain(row)
+		refunds = append(refunds, &rf)
+	}
+	return refunds, nil
+}
+
+// ========================= REFUND SETTINGS =========================
+
+// SERVICE
+// GetRefundSettings returns the institute's approval policy, one approval
+// for every refund when none is set
+func (s *Service) GetRefundSettings(ctx context.Context, instituteID uuid.UUID) (*domain.RefundSettings, error) {
+	return s.repo.GetRefundSettings(ctx, instituteID)
+}
+
+func (s *Service) UpdateRefundSettings(ctx context.Context, arg domain.RefundSettings) (*domain.RefundSettings, error) {
+	if arg.ApprovalSteps < 1 || arg.ApprovalSteps > 5 || arg.SecondStepAbove < 0 {
+		return nil, ErrInvalidRefundSettings
+	}
+	return s.repo.UpsertRefundSettings(ctx, arg)
+}
+
+// REPOSITORY
+func (r *Repository) GetRefundSettings(ctx context.Context, instituteID uuid.UUID) (*domain.RefundSettings, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetRefundSettings(ctx, instituteID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &domain.RefundSettings{InstituteID: instituteID, ApprovalSteps: 1}, nil
+		}
+		return nil, err
+	}
+
+	settings := mapper.MapRefundSettingsRowToDomain(row)
+	return &settings, nil
+}
+
+func (r *Repository) UpsertRefundSettings(ctx context.Context, arg domain.RefundSettings) (*domain.RefundSettings, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.UpsertRefundSettings(ctx, db.UpsertRefundSettingsParams{
+		InstituteID:     arg.InstituteID,
+		ApprovalSteps:   int32(arg.ApprovalSteps),
+		SecondStepAbove: fmt.Sprintf("%.2f", arg.SecondStepAbove),
+		UpdatedBy:       helper.ToNullUUID(helper.DerefUUID(arg.UpdatedBy)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save refund settings: %w", err)
+	}
+
+	settings := mapper.MapRefundSettingsRowToDomain(row)
+	return &settings, nil
+}
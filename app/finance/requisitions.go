@@ -0,0 +1,545 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRequisition       = errors.New("a requisition needs at least one item, each with an item or a name and a quantity greater than zero")
+	ErrRequisitionNotFound      = errors.New("requisition not found")
+	ErrRequisitionItemNotFound  = errors.New("requisition item not found")
+	ErrRequisitionDecided       = errors.New("the requisition has already been decided")
+	ErrRequisitionClosed        = errors.New("only pending or partly ordered requisitions can be ordered")
+	ErrInvalidRequisitionOrder  = errors.New("each order line needs a requisition item, a vendor, the item ordered and a unit price greater than zero")
+	ErrRequisitionOverOrdered   = errors.New("order quantity exceeds what is left to order on the requisition item")
+	ErrRequisitionItemMismatch  = errors.New("order line is for a different item than the requisition asks for")
+	ErrInvalidRequisitionStatus = errors.New("requisition status must be pending, approved, ordered or rejected")
+)
+
+var requisitionStatuses = []domain.RequisitionStatus{
+	domain.RequisitionPending,
+	domain.RequisitionApproved,
+	domain.RequisitionOrdered,
+	domain.RequisitionRejected,
+}
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) CreateRequisition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.Requisition
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+	req.RequestedBy = helper.DerefUUID(req.CreatedBy)
+
+	data, err := h.service.CreateRequisition(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create requisition: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "requisition created successfully", data)
+}
+
+func (h *Handler) ListRequisitions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	departmentID, err := helper.ParseUUIDFromQuery(r, "department_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid department_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := domain.RequisitionStatus(r.URL.Query().Get("status"))
+	data, err := h.service.ListRequisitions(r.Context(), inst, status, departmentID)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to list requisitions: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "requisitions fetched successfully", data)
+}
+
+func (h *Handler) RejectRequisition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "requisition_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid requisition_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.RejectRequisition(r.Context(), inst, id, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to reject requisition: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "requisition rejected", data)
+}
+
+func (h *Handler) ConvertRequisition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "requisition_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid requisition_id: "+err.Error())
+		return
+	}
+
+	var req struct {
+		ExpenseAccountID *uuid.UUID                    `json:"expense_account_id,omitempty"`
+		Lines            []domain.RequisitionOrderLine `json:"lines"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ConvertRequisition(r.Context(), inst, id, req.ExpenseAccountID, req.Lines, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to order requisition: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, fmt.Sprintf("%d purchase orders created", len(data)), data)
+}
+
+// ========================= CREATE REQUISITION =========================
+
+// SERVICE
+// CreateRequisition records a department's request for goods, raised by the
+// session user. Items may name an inventory item or only describe what is
+// wanted; the item is then chosen when the requisition is ordered.
+func (s *Service) CreateRequisition(ctx context.Context, arg domain.Requisition) (*domain.Requisition, error) {
+	if arg.RequestedBy == uuid.Nil || len(arg.Items) == 0 {
+		return nil, ErrInvalidRequisition
+	}
+	for _, item := range arg.Items {
+		if item.ItemID == nil && helper.StrOrEmpty(item.ItemName) == "" {
+			return nil, ErrInvalidRequisition
+		}
+		if item.Quantity == nil || *item.Quantity <= 0 {
+			return nil, ErrInvalidRequisition
+		}
+	}
+	if arg.RequestDate == nil {
+		today := dateOnly(time.Now())
+		arg.RequestDate = &today
+	}
+
+	req, err := s.repo.CreateRequisition(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("requisition %s raised with %d items", req.ID, len(req.Items))
+	return req, nil
+}
+
+// REPOSITORY
+func (r *Repository) CreateRequisition(ctx context.Context, arg domain.Requisition) (*domain.Requisition, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	for _, item := range arg.Items {
+		if item.ItemID == nil {
+			continue
+		}
+		if _, err := q.GetInventoryItem(ctx, db.GetInventoryItemParams{ID: *item.ItemID, InstituteID: arg.InstituteID}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: %s", ErrInventoryItemNotFound, *item.ItemID)
+			}
+			return nil, err
+		}
+	}
+
+	row, err := q.CreateRequisition(ctx, mapper.MapRequisitionDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create requisition: %w", err)
+	}
+	result := mapper.MapRequisitionRowToDomain(row)
+
+	for _, item := range arg.Items {
+		item.InstituteID = arg.InstituteID
+		item.RequisitionID = result.ID
+		itemRow, err := q.CreateRequisitionItem(ctx, mapper.MapRequisitionItemDomainToParams(item))
+		if err != nil {
+			return nil, fmt.Errorf("failed to add requisition item: %w", err)
+		}
+		result.Items = append(result.Items, mapper.MapRequisitionItemRowToDomain(itemRow))
+	}
+
+	return &result, tx.Commit()
+}
+
+// ========================= LIST REQUISITIONS =========================
+
+// SERVICE
+func (s *Service) ListRequisitions(ctx context.Context, instituteID uuid.UUID, status domain.RequisitionStatus, departmentID uuid.UUID) ([]*domain.Requisition, error) {
+	if status != "" && !helper.Contains(requisitionStatuses, status) {
+		return nil, ErrInvalidRequisitionStatus
+	}
+	return s.repo.ListRequisitions(ctx, instituteID, status, departmentID)
+}
+
+// REPOSITORY
+func (r *Repository) ListRequisitions(ctx context.Context, instituteID uuid.UUID, status domain.RequisitionStatus, departmentID uuid.UUID) ([]*domain.Requisition, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListRequisitions(ctx, db.ListRequisitionsParams{
+		InstituteID:  instituteID,
+		Status:       helper.ToNullString(string(status)),
+		DepartmentID: helper.ToNullUUID(departmentID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list requisitions: %w", err)
+	}
+
+	var requisitions []*domain.Requisition
+	for _, row := range rows {
+		req := mapper.MapRequisitionRowToDomain(row)
+		if req.Items, err = listRequisitionItems(ctx, q, instituteID, req.ID); err != nil {
+			return nil, err
+		}
+		requisitions = append(requisitions, &req)
+	}
+
+	return requisitions, nil
+}
+
+func listRequisitionItems(ctx context.Context, q *db.Queries, instituteID, requisitionID uuid.UUID) ([]domain.RequisitionItem, error) {
+	rows, err := q.ListRequisitionItems(ctx, db.ListRequisitionItemsParams{
+		RequisitionID: requisitionID,
+		InstituteID:   instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list requisition items: %w", err)
+	}
+
+	items := make([]domain.RequisitionItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, mapper.MapRequisitionItemRowToDomain(row))
+	}
+	return items, nil
+}
+
+// ========================= REJECT REQUISITION =========================
+
+// SERVICE
+// RejectRequisition turns down a requisition nothing has been ordered on
+// yet. The user who raised it cannot reject it.
+func (s *Service) RejectRequisition(ctx context.Context, instituteID, id uuid.UUID, rejectedBy *uuid.UUID) (*domain.Requisition, error) {
+	req, err := s.repo.RejectRequisition(ctx, instituteID, id, rejectedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("requisition %s rejected", req.ID)
+	return req, nil
+}
+
+// REPOSITORY
+func (r *Repository) RejectRequisition(ctx context.Context, instituteID, id uuid.UUID, rejectedBy *uuid.UUID) (*domain.Requisition, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	req, err := lockRequisition(ctx, q, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != domain.RequisitionPending {
+		return nil, ErrRequisitionDecided
+	}
+	if rejectedBy != nil && *rejectedBy == req.RequestedBy {
+		return nil, ErrSelfApproval
+	}
+
+	row, err := q.UpdateRequisitionStatus(ctx, db.UpdateRequisitionStatusParams{
+		Status:      helper.ToNullString(string(domain.RequisitionRejected)),
+		DecidedBy:   helper.ToNullUUID(helper.DerefUUID(rejectedBy)),
+		ID:          id,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject requisition: %w", err)
+	}
+
+	result := mapper.MapRequisitionRowToDomain(row)
+	return &result, tx.Commit()
+}
+
+// ========================= CONVERT REQUISITION =========================
+
+// SERVICE
+// ConvertRequisition puts the items of a requisition on draft purchase
+// orders, one per vendor named on the lines, for the requisition's
+// department. Items can be split across vendors and ordered over several
+// calls; the requisition is approved until every item is fully ordered.
+// The user who raised it cannot order it.
+func (s *Service) ConvertRequisition(ctx context.Context, instituteID, id uuid.UUID, expenseAccountID *uuid.UUID, lines []domain.RequisitionOrderLine, convertedBy *uuid.UUID) ([]*domain.PurchaseOrder, error) {
+	if len(lines) == 0 {
+		return nil, ErrInvalidRequisitionOrder
+	}
+	for _, l := range lines {
+		if l.RequisitionItemID == uuid.Nil || l.VendorID == uuid.Nil || l.Quantity < 0 || toPaise(l.UnitPrice) <= 0 {
+			return nil, ErrInvalidRequisitionOrder
+		}
+	}
+	if expenseAccountID != nil {
+		if err := s.checkExpenseAccount(ctx, instituteID, *expenseAccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	orders, err := s.repo.ConvertRequisition(ctx, instituteID, id, expenseAccountID, lines, convertedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("requisition %s ordered on %d purchase orders", id, len(orders))
+	return orders, nil
+}
+
+// REPOSITORY
+func (r *Repository) ConvertRequisition(ctx context.Context, instituteID, id uuid.UUID, expenseAccountID *uuid.UUID, lines []domain.RequisitionOrderLine, convertedBy *uuid.UUID) ([]*domain.PurchaseOrder, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	req, err := lockRequisition(ctx, q, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != domain.RequisitionPending && req.Status != domain.RequisitionApproved {
+		return nil, ErrRequisitionClosed
+	}
+	if convertedBy != nil && *convertedBy == req.RequestedBy {
+		return nil, ErrSelfApproval
+	}
+
+	items, err := listRequisitionItems(ctx, q, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*domain.RequisitionItem, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+
+	// Check every line against what is left to order, then group them by
+	// vendor in the order vendors first appear
+	var vendors []uuid.UUID
+	byVendor := make(map[uuid.UUID][]domain.RequisitionOrderLine)
+	for _, l := range lines {
+		item, ok := byID[l.RequisitionItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrRequisitionItemNotFound, l.RequisitionItemID)
+		}
+
+		left := helper.DerefInt(item.Quantity) - item.OrderedQuantity
+		if l.Quantity == 0 {
+			l.Quantity = left
+		}
+		if l.Quantity <= 0 || l.Quantity > left {
+			return nil, fmt.Errorf("%w: %d of %d left on %s", ErrRequisitionOverOrdered, l.Quantity, left, item.ID)
+		}
+		item.OrderedQuantity += l.Quantity
+
+		if l.ItemID == nil {
+			l.ItemID = item.ItemID
+		}
+		if l.ItemID == nil {
+			return nil, ErrInvalidRequisitionOrder
+		}
+		if item.ItemID != nil && *item.ItemID != *l.ItemID {
+			return nil, fmt.Errorf("%w: %s", ErrRequisitionItemMismatch, item.ID)
+		}
+
+		if _, ok := byVendor[l.VendorID]; !ok {
+			vendors = append(vendors, l.VendorID)
+		}
+		byVendor[l.VendorID] = append(byVendor[l.VendorID], l)
+	}
+
+	var orders []*domain.PurchaseOrder
+	for _, vendorID := range vendors {
+		if _, err := q.GetVendor(ctx, db.GetVendorParams{ID: vendorID, InstituteID: instituteID}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: %s", ErrVendorNotFound, vendorID)
+			}
+			return nil, err
+		}
+
+		po := domain.PurchaseOrder{
+			VendorID:         vendorID,
+			OrderDate:        dateOnly(time.Now()),
+			Status:           domain.PurchaseDraft,
+			DepartmentID:     req.DepartmentID,
+			ExpenseAccountID: expenseAccountID,
+			RequisitionID:    &req.ID,
+		}
+		po.InstituteID = instituteID
+		po.CreatedBy = convertedBy
+
+		row, err := q.CreatePurchaseOrder(ctx, mapper.MapPurchaseOrderDomainToParams(po))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create purchase order: %w", err)
+		}
+		po.ID = row.ID
+
+		var poItems []domain.PurchaseItem
+		for _, l := range byVendor[vendorID] {
+			item := domain.PurchaseItem{
+				PurchaseOrderID:   po.ID,
+				ItemID:            *l.ItemID,
+				Quantity:          l.Quantity,
+				UnitPrice:         l.UnitPrice,
+				TaxID:             l.TaxID,
+				RequisitionItemID: &l.RequisitionItemID,
+			}
+			item.InstituteID = instituteID
+
+			added, err := addPurchaseItem(ctx, q, po, item)
+			if err != nil {
+				return nil, err
+			}
+			poItems = append(poItems, *added)
+
+			if _, err := q.AddRequisitionItemOrdered(ctx, db.AddRequisitionItemOrderedParams{
+				Quantity:    int32(l.Quantity),
+				ItemID:      helper.ToNullUUID(*l.ItemID),
+				ID:          l.RequisitionItemID,
+				InstituteID: instituteID,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to update requisition item: %w", err)
+			}
+		}
+
+		created, err := lockPurchaseOrder(ctx, q, instituteID, po.ID)
+		if err != nil {
+			return nil, err
+		}
+		created.Items = poItems
+		orders = append(orders, &created)
+	}
+
+	status := domain.RequisitionOrdered
+	for _, item := range items {
+		if item.OrderedQuantity < helper.DerefInt(item.Quantity) {
+			status = domain.RequisitionApproved
+		}
+	}
+
+	if _, err := q.UpdateRequisitionStatus(ctx, db.UpdateRequisitionStatusParams{
+		Status:      helper.ToNullString(string(status)),
+		DecidedBy:   helper.ToNullUUID(helper.DerefUUID(convertedBy)),
+		ID:          id,
+		InstituteID: instituteID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update requisition: %w", err)
+	}
+
+	return orders, tx.Commit()
+}
+
+// lockRequisition reads a requisition for update
+func lockRequisition(ctx context.Context, q *db.Queries, instituteID, id uuid.UUID) (domain.Requisition, error) {
+	row, err := q.GetRequisitionForUpdate(ctx, db.GetRequisitionForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Requisition{}, ErrRequisitionNotFound
+		}
+		return domain.Requisition{}, err
+	}
+
+	req := mapper.MapRequisitionRowToDomain(row)
+	if req.Status == "" {
+		req.Status = domain.RequisitionPending
+	}
+	return req, nil
+}
@@ -0,0 +1,1004 @@
+package finance
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/dto"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultFiscalYearStart applies to institutes that have not set a fiscal
+// year start month; the Indian financial year starts in April
+const defaultFiscalYearStart = time.April
+
+// StatementPeriod selects the dates a statement covers. FiscalYear, the
+// calendar year a fiscal year starts in, takes precedence over From and To.
+// Without To the period ends today; without From it starts with To's
+// fiscal year. Balance sheets only use the end of the period.
+type StatementPeriod struct {
+	From       *time.Time
+	To         *time.Time
+	FiscalYear int
+}
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) TrialBalance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	format, period, err := statementRequest(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.TrialBalance(r.Context(), inst, period)
+	if err != nil {
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to build trial balance: "+err.Error())
+		return
+	}
+
+	header, rows := trialBalanceTable(data)
+	writeStatement(w, format, statement{
+		name:     "trial_balance_" + periodSlug(data.From, data.To),
+		title:    "Trial balance",
+		subtitle: periodTitle(data.From, data.To, data.PriorFrom, data.PriorTo),
+		header:   header,
+		rows:     rows,
+		data:     data,
+	})
+}
+
+func (h *Handler) IncomeStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	format, period, err := statementRequest(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.IncomeStatement(r.Context(), inst, period)
+	if err != nil {
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to build income statement: "+err.Error())
+		return
+	}
+
+	header, rows := incomeStatementTable(data)
+	writeStatement(w, format, statement{
+		name:     "income_statement_" + periodSlug(data.From, data.To),
+		title:    "Income statement",
+		subtitle: periodTitle(data.From, data.To, data.PriorFrom, data.PriorTo),
+		header:   header,
+		rows:     rows,
+		data:     data,
+	})
+}
+
+func (h *Handler) BalanceSheet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	format, period, err := statementRequest(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.BalanceSheet(r.Context(), inst, period)
+	if err != nil {
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to build balance sheet: "+err.Error())
+		return
+	}
+
+	header, rows := balanceSheetTable(data)
+	writeStatement(w, format, statement{
+		name:     "balance_sheet_" + data.AsOf.Format(helper.DateLayout),
+		title:    "Balance sheet",
+		subtitle: fmt.Sprintf("As of %s, compared with %s", data.AsOf.Format(helper.DateLayout), data.PriorAsOf.Format(helper.DateLayout)),
+		header:   header,
+		rows:     rows,
+		data:     data,
+	})
+}
+
+func (h *Handler) CloseFiscalYear(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req dto.CloseFiscalYearRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if req.FiscalYear < 1900 || req.FiscalYear > 9999 {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "fiscal_year must be the year the fiscal year starts in, such as 2024")
+		return
+	}
+	if req.RetainedEarningsAccountID == uuid.Nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "retained_earnings_account_id is required")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.CloseFiscalYear(r.Context(), inst, req.FiscalYear, req.RetainedEarningsAccountID, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, accountingErrorStatus(err), "failed to close fiscal year: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "fiscal year closed successfully", data)
+}
+
+func (h *Handler) ListFiscalYearClosings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListFiscalYearClosings(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch fiscal year closings: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "fiscal year closings fetched successfully", data)
+}
+
+// statementRequest reads the export format and period shared by the statements
+func statementRequest(r *http.Request) (string, StatementPeriod, error) {
+	var p StatementPeriod
+
+	format := helper.GetQueryParam(r, "format", "json")
+	if format != "json" && format != "csv" && format != "pdf" {
+		return "", p, errors.New("format must be json, csv or pdf")
+	}
+
+	if fy := r.URL.Query().Get("fiscal_year"); fy != "" {
+		year, err := strconv.Atoi(fy)
+		if err != nil || year < 1900 || year > 9999 {
+			return "", p, errors.New("fiscal_year must be the year the fiscal year starts in, such as 2024")
+		}
+		p.FiscalYear = year
+	}
+
+	var err error
+	if p.From, err = helper.ParseDateFromQuery(r, "from"); err != nil {
+		return "", p, fmt.Errorf("invalid from date: %w", err)
+	}
+	if p.To, err = helper.ParseDateFromQuery(r, "to"); err != nil {
+		return "", p, fmt.Errorf("invalid to date: %w", err)
+	}
+	return format, p, nil
+}
+
+// ========================= EXPORT =========================
+
+// statement is a report ready to be sent as JSON or exported as a table
+type statement struct {
+	name     string
+	title    string
+	subtitle string
+	header   []string
+	rows     [][]string
+	data     any
+}
+
+func writeStatement(w http.ResponseWriter, format string, st statement) {
+	switch format {
+	case "csv":
+		var buf bytes.Buffer
+		cw := csv.NewWriter(&buf)
+		cw.Write(st.header)
+		if err := cw.WriteAll(st.rows); err != nil {
+			helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to export csv: "+err.Error())
+			return
+		}
+		helper.NewFileResponse(w, "text/csv", st.name+".csv", buf.Bytes())
+	case "pdf":
+		body := helper.RenderTablePDF(st.title, st.subtitle, st.header, st.rows)
+		helper.NewFileResponse(w, "application/pdf", st.name+".pdf", body)
+	default:
+		helper.NewSuccessResponse(w, http.StatusOK, strings.ToLower(st.title)+" retrieved successfully", st.data)
+	}
+}
+
+func trialBalanceTable(tb *domain.TrialBalance) ([]string, [][]string) {
+	header := []string{"Code", "Account", "Opening Dr", "Opening Cr", "Period Dr", "Period Cr",
+		"Closing Dr", "Closing Cr", "Prior Dr", "Prior Cr"}
+
+	amounts := func(a domain.TrialBalanceAmounts) []string {
+		return []string{
+			money(a.OpeningDebit), money(a.OpeningCredit), money(a.PeriodDebit), money(a.PeriodCredit),
+			money(a.ClosingDebit), money(a.ClosingCredit), money(a.PriorClosingDebit), money(a.PriorClosingCredit),
+		}
+	}
+
+	var rows [][]string
+	var walk func(lines []*domain.TrialBalanceLine)
+	walk = func(lines []*domain.TrialBalanceLine) {
+		for _, l := range lines {
+			rows = append(rows, append([]string{l.Code, indent(l.Depth) + l.Name}, amounts(l.TrialBalanceAmounts)...))
+			walk(l.Children)
+		}
+	}
+	walk(tb.Lines)
+
+	rows = append(rows, append([]string{"", "Total"}, amounts(tb.Totals)...))
+	return header, rows
+}
+
+func incomeStatementTable(is *domain.IncomeStatement) ([]string, [][]string) {
+	header := []string{"Code", "Account", "Current", "Prior"}
+
+	var rows [][]string
+	rows = appendSection(rows, "Income", is.Income)
+	rows = appendSection(rows, "Expenses", is.Expenses)
+	rows = append(rows, []string{"", "Net income", money(is.NetIncome), money(is.PriorNetIncome)})
+	return header, rows
+}
+
+func balanceSheetTable(bs *domain.BalanceSheet) ([]string, [][]string) {
+	header := []string{"Code", "Account", "Current", "Prior"}
+
+	var rows [][]string
+	rows = appendSection(rows, "Assets", bs.Assets)
+	rows = appendSection(rows, "Liabilities", bs.Liabilities)
+	rows = appendSection(rows, "Equity", bs.Equity)
+	rows = append(rows,
+		[]string{"", "Current earnings", money(bs.CurrentEarnings), money(bs.PriorCurrentEarnings)},
+		[]string{"", "Total liabilities and equity", money(bs.TotalLiabilitiesAndEquity), money(bs.PriorTotalLiabilitiesAndEquity)},
+	)
+	return header, rows
+}
+
+func appendSection(rows [][]string, title string, sec domain.StatementSection) [][]string {
+	rows = append(rows, []string{"", title, "", ""})
+
+	var walk func(lines []*domain.StatementLine)
+	walk = func(lines []*domain.StatementLine) {
+		for _, l := range lines {
+			rows = append(rows, []string{l.Code, indent(l.Depth+1) + l.Name, money(l.Amount), money(l.PriorAmount)})
+			walk(l.Children)
+		}
+	}
+	walk(sec.Lines)
+
+	return append(rows, []string{"", "Total " + strings.ToLower(title), money(sec.Total), money(sec.PriorTotal)})
+}
+
+func periodTitle(from, to, priorFrom, priorTo time.Time) string {
+	return fmt.Sprintf("%s to %s, compared with %s to %s",
+		from.Format(helper.DateLayout), to.Format(helper.DateLayout),
+		priorFrom.Format(helper.DateLayout), priorTo.Format(helper.DateLayout))
+}
+
+func periodSlug(from, to time.Time) string {
+	return from.Format(helper.DateLayout) + "_" + to.Format(helper.DateLayout)
+}
+
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// ========================= TRIAL BALANCE =========================
+
+// SERVICE
+// TrialBalance lists every account's opening balance, the period's debits
+// and credits and the closing balance, with the closing balance at the end
+// of the same period a year earlier for comparison. Deleted accounts are
+// listed while they still carry postings.
+func (s *Service) TrialBalance(ctx context.Context, instituteID uuid.UUID, p StatementPeriod) (*domain.TrialBalance, error) {
+	from, to, err := s.resolvePeriod(ctx, instituteID, p)
+	if err != nil {
+		return nil, err
+	}
+	priorFrom, priorTo := from.AddDate(-1, 0, 0), to.AddDate(-1, 0, 0)
+
+	accounts, err := s.repo.ListStatementAccounts(ctx, instituteID, nil, to)
+	if err != nil {
+		return nil, err
+	}
+	tree := newAccountTree(accounts)
+
+	opening, err := s.ledgerTotals(ctx, instituteID, nil, from.AddDate(0, 0, -1), true)
+	if err != nil {
+		return nil, err
+	}
+	period, err := s.ledgerTotals(ctx, instituteID, &from, to, true)
+	if err != nil {
+		return nil, err
+	}
+	prior, err := s.ledgerTotals(ctx, instituteID, nil, priorTo, true)
+	if err != nil {
+		return nil, err
+	}
+	opening, period, prior = tree.rollup(opening), tree.rollup(period), tree.rollup(prior)
+
+	amounts := func(id uuid.UUID) domain.TrialBalanceAmounts {
+		o, p, pr := opening[id], period[id], prior[id]
+		var a domain.TrialBalanceAmounts
+		a.OpeningDebit, a.OpeningCredit = sides(o.net())
+		a.PeriodDebit, a.PeriodCredit = fromPaise(p.debit), fromPaise(p.credit)
+		a.ClosingDebit, a.ClosingCredit = sides(o.net() + p.net())
+		a.PriorClosingDebit, a.PriorClosingCredit = sides(pr.net())
+		return a
+	}
+
+	var build func(accs []*domain.Account, depth int) []*domain.TrialBalanceLine
+	build = func(accs []*domain.Account, depth int) []*domain.TrialBalanceLine {
+		lines := make([]*domain.TrialBalanceLine, 0, len(accs))
+		for _, acc := range accs {
+			lines = append(lines, &domain.TrialBalanceLine{
+				AccountID:           acc.ID,
+				Code:                acc.Code,
+				Name:                acc.Name,
+				Type:                acc.Type,
+				Depth:               depth,
+				TrialBalanceAmounts: amounts(acc.ID),
+				Children:            build(tree.children[acc.ID], depth+1),
+			})
+		}
+		return lines
+	}
+
+	// Top-level accounts carry all balances, so they add up to the totals
+	var open, periodTotal, closing, priorClosing paiseTotals
+	for _, root := range tree.roots {
+		o, p := opening[root.ID], period[root.ID]
+		open.addNet(o.net())
+		closing.addNet(o.net() + p.net())
+		priorClosing.addNet(prior[root.ID].net())
+		periodTotal.debit += p.debit
+		periodTotal.credit += p.credit
+	}
+
+	return &domain.TrialBalance{
+		From:      from,
+		To:        to,
+		PriorFrom: priorFrom,
+		PriorTo:   priorTo,
+		Lines:     build(tree.roots, 0),
+		Totals: domain.TrialBalanceAmounts{
+			OpeningDebit:       fromPaise(open.debit),
+			OpeningCredit:      fromPaise(open.credit),
+			PeriodDebit:        fromPaise(periodTotal.debit),
+			PeriodCredit:       fromPaise(periodTotal.credit),
+			ClosingDebit:       fromPaise(closing.debit),
+			ClosingCredit:      fromPaise(closing.credit),
+			PriorClosingDebit:  fromPaise(priorClosing.debit),
+			PriorClosingCredit: fromPaise(priorClosing.credit),
+		},
+	}, nil
+}
+
+// ========================= INCOME STATEMENT =========================
+
+// SERVICE
+// IncomeStatement reports income and expenses for the period against the
+// same period a year earlier. Year-end closing entries are left out.
+func (s *Service) IncomeStatement(ctx context.Context, instituteID uuid.UUID, p StatementPeriod) (*domain.IncomeStatement, error) {
+	from, to, err := s.resolvePeriod(ctx, instituteID, p)
+	if err != nil {
+		return nil, err
+	}
+	priorFrom, priorTo := from.AddDate(-1, 0, 0), to.AddDate(-1, 0, 0)
+
+	accounts, err := s.repo.ListStatementAccounts(ctx, instituteID, &priorFrom, to)
+	if err != nil {
+		return nil, err
+	}
+	tree := newAccountTree(accounts)
+
+	cur, err := s.ledgerTotals(ctx, instituteID, &from, to, false)
+	if err != nil {
+		return nil, err
+	}
+	prior, err := s.ledgerTotals(ctx, instituteID, &priorFrom, priorTo, false)
+	if err != nil {
+		return nil, err
+	}
+	cur, prior = tree.rollup(cur), tree.rollup(prior)
+
+	income, incomeCur, incomePrior := tree.section(domain.AccIncome, cur, prior)
+	expenses, expenseCur, expensePrior := tree.section(domain.AccExpense, cur, prior)
+
+	return &domain.IncomeStatement{
+		From:           from,
+		To:             to,
+		PriorFrom:      priorFrom,
+		PriorTo:        priorTo,
+		Income:         income,
+		Expenses:       expenses,
+		NetIncome:      fromPaise(incomeCur - expenseCur),
+		PriorNetIncome: fromPaise(incomePrior - expensePrior),
+	}, nil
+}
+
+// ========================= BALANCE SHEET =========================
+
+// SERVICE
+// BalanceSheet reports balances at the end of the period against the same
+// date a year earlier. Income and expenses not yet closed into retained
+// earnings appear as current earnings, so the two sides always agree.
+func (s *Service) BalanceSheet(ctx context.Context, instituteID uuid.UUID, p StatementPeriod) (*domain.BalanceSheet, error) {
+	_, asOf, err := s.resolvePeriod(ctx, instituteID, p)
+	if err != nil {
+		return nil, err
+	}
+	priorAsOf := asOf.AddDate(-1, 0, 0)
+
+	accounts, err := s.repo.ListStatementAccounts(ctx, instituteID, nil, asOf)
+	if err != nil {
+		return nil, err
+	}
+	tree := newAccountTree(accounts)
+
+	cur, err := s.ledgerTotals(ctx, instituteID, nil, asOf, true)
+	if err != nil {
+		return nil, err
+	}
+	prior, err := s.ledgerTotals(ctx, instituteID, nil, priorAsOf, true)
+	if err != nil {
+		return nil, err
+	}
+	cur, prior = tree.rollup(cur), tree.rollup(prior)
+
+	assets, _, _ := tree.section(domain.AccAsset, cur, prior)
+	liabilities, liabilityCur, liabilityPrior := tree.section(domain.AccLiability, cur, prior)
+	equity, equityCur, equityPrior := tree.section(domain.AccEquity, cur, prior)
+	_, incomeCur, incomePrior := tree.section(domain.AccIncome, cur, prior)
+	_, expenseCur, expensePrior := tree.section(domain.AccExpense, cur, prior)
+
+	earnings, priorEarnings := incomeCur-expenseCur, incomePrior-expensePrior
+
+	return &domain.BalanceSheet{
+		AsOf:                           asOf,
+		PriorAsOf:                      priorAsOf,
+		Assets:                         assets,
+		Liabilities:                    liabilities,
+		Equity:                         equity,
+		CurrentEarnings:                fromPaise(earnings),
+		PriorCurrentEarnings:           fromPaise(priorEarnings),
+		TotalLiabilitiesAndEquity:      fromPaise(liabilityCur + equityCur + earnings),
+		PriorTotalLiabilitiesAndEquity: fromPaise(liabilityPrior + equityPrior + priorEarnings),
+	}, nil
+}
+
+// ========================= CLOSE FISCAL YEAR =========================
+
+// SERVICE
+// CloseFiscalYear posts a closing journal on the last day of the fiscal year
+// that zeroes every income and expense account against retained earnings.
+// Years close in order, and nothing can be posted into a closed year.
+func (s *Service) CloseFiscalYear(ctx context.Context, instituteID uuid.UUID, fiscalYear int, retainedEarningsID uuid.UUID, closedBy *uuid.UUID) (*domain.FiscalYearClosing, error) {
+	startMonth, err := s.repo.GetFiscalYearStartMonth(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Date(fiscalYear, startMonth, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, -1)
+
+	if !dateOnly(time.Now()).After(end) {
+		return nil, ErrYearNotEnded
+	}
+
+	re, err := s.repo.GetAccount(ctx, instituteID, retainedEarningsID)
+	if err != nil {
+		return nil, err
+	}
+	if re.Type != domain.AccEquity {
+		return nil, ErrInvalidRetainedEarnings
+	}
+
+	closing, err := s.repo.CloseFiscalYear(ctx, domain.FiscalYearClosing{
+		InstituteID:               instituteID,
+		FiscalYearStart:           start,
+		FiscalYearEnd:             end,
+		RetainedEarningsAccountID: retainedEarningsID,
+		ClosedBy:                  closedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("fiscal year %s closed for institute %s with net income %.2f",
+		fiscalYearLabel(start, end), instituteID, closing.NetIncome)
+	return closing, nil
+}
+
+// closingItems zeroes the income and expense balances in totals, in chart
+// order, and books the difference to retained earnings. It also returns
+// the year's net income in paise.
+func closingItems(accounts []db.FinanceAccount, totals []db.SumPostedJournalItemsInRangeRow, retainedEarningsID uuid.UUID) ([]domain.JournalItem, int64) {
+	byAccount := make(map[uuid.UUID]domain.AccountBalance, len(totals))
+	for _, row := range totals {
+		byAccount[row.AccountID] = mapper.MapRangeTotalsRowToDomain(row)
+	}
+
+	var items []domain.JournalItem
+	var netIncome int64
+	for _, acc := range accounts {
+		t, ok := byAccount[acc.ID]
+		typ := domain.AccountType(acc.Type)
+		if !ok || (typ != domain.AccIncome && typ != domain.AccExpense) {
+			continue
+		}
+
+		bal := toPaise(t.Debit) - toPaise(t.Credit)
+		if bal == 0 {
+			continue
+		}
+		netIncome -= bal
+
+		item := domain.JournalItem{AccountID: acc.ID}
+		if bal > 0 {
+			item.Credit = fromPaise(bal)
+		} else {
+			item.Debit = fromPaise(-bal)
+		}
+		items = append(items, item)
+	}
+
+	switch {
+	case netIncome > 0:
+		items = append(items, domain.JournalItem{AccountID: retainedEarningsID, Credit: fromPaise(netIncome)})
+	case netIncome < 0:
+		items = append(items, domain.JournalItem{AccountID: retainedEarningsID, Debit: fromPaise(-netIncome)})
+	}
+	return items, netIncome
+}
+
+// REPOSITORY
+func (r *Repository) CloseFiscalYear(ctx context.Context, arg domain.FiscalYearClosing) (*domain.FiscalYearClosing, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	// Holds off postings until the closing is committed
+	if err := q.LockLedgerForClosing(ctx, arg.InstituteID); err != nil {
+		return nil, err
+	}
+
+	last, err := q.GetLastClosedFiscalYearEnd(ctx, arg.InstituteID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil && !arg.FiscalYearEnd.After(last) {
+		return nil, ErrYearClosed
+	}
+
+	re := []domain.JournalItem{{AccountID: arg.RetainedEarningsAccountID}}
+	if err := checkPostingAccounts(ctx, q, arg.InstituteID, re); err != nil {
+		return nil, err
+	}
+
+	accounts, err := q.ListStatementAccounts(ctx, db.ListStatementAccountsParams{
+		InstituteID: arg.InstituteID,
+		ToDate:      arg.FiscalYearEnd,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Balances since the first entry, so that any earlier year left open
+	// is swept into retained earnings as well
+	totals, err := q.SumPostedJournalItemsInRange(ctx, db.SumPostedJournalItemsInRangeParams{
+		InstituteID:    arg.InstituteID,
+		ToDate:         arg.FiscalYearEnd,
+		IncludeClosing: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items, netIncome := closingItems(accounts, totals, arg.RetainedEarningsAccountID)
+
+	var entryID uuid.NullUUID
+	if len(items) > 0 {
+		label := fiscalYearLabel(arg.FiscalYearStart, arg.FiscalYearEnd)
+		desc := "Year-end close of fiscal year " + label
+
+		journal := domain.JournalEntry{
+			ReferenceNo:     "CLOSE-" + label,
+			TransactionDate: arg.FiscalYearEnd,
+			Description:     &desc,
+			Items:           items,
+		}
+		journal.InstituteID = arg.InstituteID
+		journal.CreatedBy = arg.ClosedBy
+
+		entry, err := insertJournal(ctx, q, journal)
+		if err != nil {
+			return nil, err
+		}
+		if err := markPosted(ctx, q, entry, arg.ClosedBy); err != nil {
+			return nil, err
+		}
+		entryID = helper.ToNullUUID(entry.ID)
+	}
+
+	row, err := q.CreateFiscalYearClosing(ctx, db.CreateFiscalYearClosingParams{
+		InstituteID:               arg.InstituteID,
+		FiscalYearStart:           arg.FiscalYearStart,
+		FiscalYearEnd:             arg.FiscalYearEnd,
+		JournalEntryID:            entryID,
+		RetainedEarningsAccountID: arg.RetainedEarningsAccountID,
+		NetIncome:                 fmt.Sprintf("%.2f", fromPaise(netIncome)),
+		ClosedBy:                  helper.ToNullUUID(helper.DerefUUID(arg.ClosedBy)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	closing := mapper.MapFiscalYearClosingRowToDomain(row)
+	return &closing, tx.Commit()
+}
+
+// ========================= LIST FISCAL YEAR CLOSINGS =========================
+
+// SERVICE
+func (s *Service) ListFiscalYearClosings(ctx context.Context, instituteID uuid.UUID) ([]*domain.FiscalYearClosing, error) {
+	return s.repo.ListFiscalYearClosings(ctx, instituteID)
+}
+
+// REPOSITORY
+func (r *Repository) ListFiscalYearClosings(ctx context.Context, instituteID uuid.UUID) ([]*domain.FiscalYearClosing, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListFiscalYearClosings(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	closings := make([]*domain.FiscalYearClosing, 0, len(rows))
+	for _, row := range rows {
+		c := mapper.MapFiscalYearClosingRowToDomain(row)
+		closings = append(closings, &c)
+	}
+	return closings, nil
+}
+
+// ========================= FISCAL YEAR =========================
+
+// REPOSITORY
+func (r *Repository) GetFiscalYearStartMonth(ctx context.Context, instituteID uuid.UUID) (time.Month, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return 0, err
+	}
+
+	return fiscalYearStartMonth(ctx, q, instituteID)
+}
+
+func fiscalYearStartMonth(ctx context.Context, q *db.Queries, instituteID uuid.UUID) (time.Month, error) {
+	month, err := q.GetInstituteFiscalYearStart(ctx, instituteID)
+	if err != nil {
+		return 0, err
+	}
+	if !month.Valid || month.Int32 < 1 || month.Int32 > 12 {
+		return defaultFiscalYearStart, nil
+	}
+	return time.Month(month.Int32), nil
+}
+
+// fiscalYearOf returns the first and last day of the fiscal year holding day
+func fiscalYearOf(day time.Time, startMonth time.Month) (time.Time, time.Time) {
+	year := day.Year()
+	if day.Month() < startMonth {
+		year--
+	}
+	start := time.Date(year, startMonth, 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(1, 0, -1)
+}
+
+// REPOSITORY
+func (r *Repository) ListStatementAccounts(ctx context.Context, instituteID uuid.UUID, from *time.Time, to time.Time) ([]*domain.Account, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListStatementAccounts(ctx, db.ListStatementAccountsParams{
+		InstituteID: instituteID,
+		FromDate:    helper.ToNullTime(helper.TimeOrZero(from)),
+		ToDate:      to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*domain.Account, 0, len(rows))
+	for _, row := range rows {
+		acc := mapper.MapAccountRowToDomain(row)
+		accounts = append(accounts, &acc)
+	}
+	return accounts, nil
+}
+
+func (r *Repository) SumPostedTotals(ctx context.Context, instituteID uuid.UUID, from *time.Time, to time.Time, includeClosing bool) ([]*domain.AccountBalance, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.SumPostedJournalItemsInRange(ctx, db.SumPostedJournalItemsInRangeParams{
+		InstituteID:    instituteID,
+		FromDate:       helper.ToNullTime(helper.TimeOrZero(from)),
+		ToDate:         to,
+		IncludeClosing: includeClosing,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make([]*domain.AccountBalance, 0, len(rows))
+	for _, row := range rows {
+		t := mapper.MapRangeTotalsRowToDomain(row)
+		totals = append(totals, &t)
+	}
+	return totals, nil
+}
+
+// resolvePeriod turns p into calendar dates using the institute's fiscal year
+func (s *Service) resolvePeriod(ctx context.Context, instituteID uuid.UUID, p StatementPeriod) (time.Time, time.Time, error) {
+	startMonth, err := s.repo.GetFiscalYearStartMonth(ctx, instituteID)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if p.FiscalYear > 0 {
+		from := time.Date(p.FiscalYear, startMonth, 1, 0, 0, 0, 0, time.UTC)
+		return from, from.AddDate(1, 0, -1), nil
+	}
+
+	to := dateOnly(time.Now())
+	if p.To != nil {
+		to = dateOnly(*p.To)
+	}
+
+	from, _ := fiscalYearOf(to, startMonth)
+	if p.From != nil {
+		from = dateOnly(*p.From)
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, ErrInvalidPeriod
+	}
+	return from, to, nil
+}
+
+func (s *Service) ledgerTotals(ctx context.Context, instituteID uuid.UUID, from *time.Time, to time.Time, includeClosing bool) (ledger, error) {
+	totals, err := s.repo.SumPostedTotals(ctx, instituteID, from, to, includeClosing)
+	if err != nil {
+		return nil, err
+	}
+	return newLedger(totals), nil
+}
+
+// fiscalYearLabel names a fiscal year such as 2024-25, or 2024 when it
+// follows the calendar year
+func fiscalYearLabel(start, end time.Time) string {
+	if start.Year() == end.Year() {
+		return strconv.Itoa(start.Year())
+	}
+	return fmt.Sprintf("%d-%02d", start.Year(), end.Year()%100)
+}
+
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ========================= LEDGER TREE =========================
+
+// paiseTotals are posted debits and credits in paise
+type paiseTotals struct {
+	debit  int64
+	credit int64
+}
+
+func (t paiseTotals) net() int64 {
+	return t.debit - t.credit
+}
+
+// ledger holds posted totals by account
+type ledger map[uuid.UUID]paiseTotals
+
+func newLedger(totals []*domain.AccountBalance) ledger {
+	l := make(ledger, len(totals))
+	for _, t := range totals {
+		l[t.AccountID] = paiseTotals{debit: toPaise(t.Debit), credit: toPaise(t.Credit)}
+	}
+	return l
+}
+
+// accountTree is the chart of accounts, keeping the order accounts were
+// listed in at every level
+type accountTree struct {
+	roots    []*domain.Account
+	children map[uuid.UUID][]*domain.Account
+}
+
+func newAccountTree(accounts []*domain.Account) *accountTree {
+	known := make(map[uuid.UUID]bool, len(accounts))
+	for _, acc := range accounts {
+		known[acc.ID] = true
+	}
+
+	t := &accountTree{children: make(map[uuid.UUID][]*domain.Account)}
+	for _, acc := range accounts {
+		if acc.ParentAccountID != nil && known[*acc.ParentAccountID] {
+			t.children[*acc.ParentAccountID] = append(t.children[*acc.ParentAccountID], acc)
+		} else {
+			t.roots = append(t.roots, acc)
+		}
+	}
+	return t
+}
+
+// rollup returns every account's totals including all of its sub-accounts
+func (t *accountTree) rollup(l ledger) ledger {
+	out := make(ledger, len(l))
+
+	var walk func(acc *domain.Account) paiseTotals
+	walk = func(acc *domain.Account) paiseTotals {
+		sum := l[acc.ID]
+		for _, child := range t.children[acc.ID] {
+			c := walk(child)
+			sum.debit += c.debit
+			sum.credit += c.credit
+		}
+		out[acc.ID] = sum
+		return sum
+	}
+	for _, root := range t.roots {
+		walk(root)
+	}
+	return out
+}
+
+// section builds the statement lines of the top-level accounts of one type
+// from rolled-up ledgers and returns its totals in paise
+func (t *accountTree) section(typ domain.AccountType, cur, prior ledger) (domain.StatementSection, int64, int64) {
+	var build func(accs []*domain.Account, depth int) []*domain.StatementLine
+	build = func(accs []*domain.Account, depth int) []*domain.StatementLine {
+		lines := make([]*domain.StatementLine, 0, len(accs))
+		for _, acc := range accs {
+			lines = append(lines, &domain.StatementLine{
+				AccountID:   acc.ID,
+				Code:        acc.Code,
+				Name:        acc.Name,
+				Depth:       depth,
+				Amount:      fromPaise(normalBalance(acc.Type, cur[acc.ID])),
+				PriorAmount: fromPaise(normalBalance(acc.Type, prior[acc.ID])),
+				Children:    build(t.children[acc.ID], depth+1),
+			})
+		}
+		return lines
+	}
+
+	var roots []*domain.Account
+	var total, priorTotal int64
+	for _, root := range t.roots {
+		if root.Type != typ {
+			continue
+		}
+		roots = append(roots, root)
+		total += normalBalance(typ, cur[root.ID])
+		priorTotal += normalBalance(typ, prior[root.ID])
+	}
+
+	return domain.StatementSection{
+		Lines:      build(roots, 0),
+		Total:      fromPaise(total),
+		PriorTotal: fromPaise(priorTotal),
+	}, total, priorTotal
+}
+
+// normalBalance signs t towards the normal side of accounts of type typ
+func normalBalance(typ domain.AccountType, t paiseTotals) int64 {
+	if typ == domain.AccAsset || typ == domain.AccExpense {
+		return t.net()
+	}
+	return -t.net()
+}
+
+// addNet adds a net debit balance to the side it falls on
+func (t *paiseTotals) addNet(net int64) {
+	if net > 0 {
+		t.debit += net
+	} else {
+		t.credit -= net
+	}
+}
+
+// sides splits a net debit balance into debit and credit columns
+func sides(net int64) (float64, float64) {
+	if net > 0 {
+		return fromPaise(net), 0
+	}
+	return 0, fromPaise(-net)
+}
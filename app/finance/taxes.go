@@ -0,0 +1,1032 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidTax            = errors.New("a tax needs a name and a rate between 0 and 100 percent")
+	ErrTaxNotFound           = errors.New("tax not found")
+	ErrTaxNotConfigured      = errors.New("GST registration and tax accounts are not set up")
+	ErrInvalidTaxSettings    = errors.New("tax settings need a GSTIN, a state and all six tax accounts")
+	ErrGSTINStateMismatch    = errors.New("the GSTIN is not registered in the given state")
+	ErrStateNotFound         = errors.New("state not found")
+	ErrVendorNotFound        = errors.New("vendor not found")
+	ErrVendorStateUnknown    = errors.New("the vendor's state is not known; set its state or a valid GSTIN")
+	ErrInventoryItemNotFound = errors.New("inventory item not found")
+	ErrInvalidTaxAssignment  = errors.New("a fee head or inventory item is required")
+)
+
+// Documents finance.tax_lines rows belong to
+const (
+	taxDocInvoice      = "invoice"
+	taxDocGoodsReceipt = "goods_receipt"
+)
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) CreateTax(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.Tax
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+
+	data, err := h.service.CreateTax(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create tax: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "tax created successfully", data)
+}
+
+func (h *Handler) ListTaxes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListTaxes(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to list taxes: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "taxes fetched successfully", data)
+}
+
+func (h *Handler) GetTaxSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetTaxSettings(r.Context(), inst)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to fetch tax settings: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "tax settings fetched successfully", data)
+}
+
+func (h *Handler) UpdateTaxSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.TaxSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.UpdatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.UpdateTaxSettings(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to update tax settings: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "tax settings updated successfully", data)
+}
+
+func (h *Handler) AssignFeeHeadTax(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req struct {
+		FeeHeadID uuid.UUID  `json:"fee_head_id"`
+		TaxID     *uuid.UUID `json:"tax_id,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.AssignFeeHeadTax(r.Context(), inst, req.FeeHeadID, req.TaxID, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to set fee head tax: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "fee head tax updated successfully", data)
+}
+
+func (h *Handler) AssignItemTax(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req struct {
+		ItemID uuid.UUID  `json:"item_id"`
+		TaxID  *uuid.UUID `json:"tax_id,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.AssignItemTax(r.Context(), inst, req.ItemID, req.TaxID, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to set item tax: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "item tax updated successfully", data)
+}
+
+func (h *Handler) ListTaxLines(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	invoiceID, err := helper.ParseUUIDFromQuery(r, "invoice_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid invoice_id: "+err.Error())
+		return
+	}
+	receiptID, err := helper.ParseUUIDFromQuery(r, "goods_receipt_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid goods_receipt_id: "+err.Error())
+		return
+	}
+
+	if (invoiceID == uuid.Nil) == (receiptID == uuid.Nil) {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "exactly one of invoice_id or goods_receipt_id is required")
+		return
+	}
+	documentType, documentID := taxDocInvoice, invoiceID
+	if receiptID != uuid.Nil {
+		documentType, documentID = taxDocGoodsReceipt, receiptID
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.ListTaxLines(r.Context(), inst, documentType, documentID)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to list tax lines: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "tax lines fetched successfully", data)
+}
+
+func (h *Handler) TaxSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	from, err := helper.ParseRequiredDateFromQuery(r, "from")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid from: "+err.Error())
+		return
+	}
+	to, err := helper.ParseRequiredDateFromQuery(r, "to")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid to: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.TaxSummary(r.Context(), inst, from, to)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to build tax summary: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "tax summary generated successfully", data)
+}
+
+// ========================= TAXES =========================
+
+// SERVICE
+func (s *Service) CreateTax(ctx context.Context, arg domain.Tax) (*domain.Tax, error) {
+	if arg.Name == nil || *arg.Name == "" || arg.Percentage < 0 || arg.Percentage > 100 {
+		return nil, ErrInvalidTax
+	}
+	return s.repo.CreateTax(ctx, arg)
+}
+
+func (s *Service) ListTaxes(ctx context.Context, instituteID uuid.UUID) ([]*domain.Tax, error) {
+	return s.repo.ListTaxes(ctx, instituteID)
+}
+
+// REPOSITORY
+func (r *Repository) CreateTax(ctx context.Context, arg domain.Tax) (*domain.Tax, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreateTax(ctx, db.CreateTaxParams{
+		InstituteID: arg.InstituteID,
+		Name:        helper.ToNullString(*arg.Name),
+		Percentage:  fmt.Sprintf("%.2f", arg.Percentage),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tax: %w", err)
+	}
+
+	result := mapper.MapTaxRowToDomain(row)
+	return &result, nil
+}
+
+func (r *Repository) ListTaxes(ctx context.Context, instituteID uuid.UUID) ([]*domain.Tax, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListTaxes(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	taxes := make([]*domain.Tax, 0, len(rows))
+	for _, row := range rows {
+		t := mapper.MapTaxRowToDomain(row)
+		taxes = append(taxes, &t)
+	}
+	return taxes, nil
+}
+
+// ========================= TAX SETTINGS =========================
+
+// SERVICE
+func (s *Service) GetTaxSettings(ctx context.Context, instituteID uuid.UUID) (*domain.TaxSettings, error) {
+	return s.repo.GetTaxSettings(ctx, instituteID)
+}
+
+// UpdateTaxSettings records the institute's GSTIN, the state it is registered
+// in and the accounts output and input CGST, SGST and IGST are posted to.
+// Tax already posted stays where it was posted.
+func (s *Service) UpdateTaxSettings(ctx context.Context, arg domain.TaxSettings) (*domain.TaxSettings, error) {
+	arg.GSTIN = helper.NormalizeGSTIN(arg.GSTIN)
+	if arg.GSTIN == "" || arg.StateID == uuid.Nil {
+		return nil, ErrInvalidTaxSettings
+	}
+	for _, id := range []uuid.UUID{
+		arg.OutputCGSTAccountID, arg.OutputSGSTAccountID, arg.OutputIGSTAccountID,
+		arg.InputCGSTAccountID, arg.InputSGSTAccountID, arg.InputIGSTAccountID,
+	} {
+		if id == uuid.Nil {
+			return nil, ErrInvalidTaxSettings
+		}
+	}
+	if err := helper.ValidateGSTIN(arg.GSTIN); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.repo.UpdateTaxSettings(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("tax settings of institute %s updated", arg.InstituteID)
+	return settings, nil
+}
+
+// REPOSITORY
+func (r *Repository) GetTaxSettings(ctx context.Context, instituteID uuid.UUID) (*domain.TaxSettings, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := loadTaxSettings(ctx, q, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := mapper.MapTaxSettingsRowToDomain(row)
+	return &result, nil
+}
+
+func (r *Repository) UpdateTaxSettings(ctx context.Context, arg domain.TaxSettings) (*domain.TaxSettings, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if err := checkGSTINState(ctx, q, arg.GSTIN, arg.StateID); err != nil {
+		return nil, err
+	}
+
+	// Tax charged is owed to the government; tax paid is recoverable from it
+	asset := []domain.AccountType{domain.AccAsset}
+	liability := []domain.AccountType{domain.AccLiability}
+	roles := []struct {
+		name  string
+		id    uuid.UUID
+		types []domain.AccountType
+	}{
+		{"output CGST", arg.OutputCGSTAccountID, liability},
+		{"output SGST", arg.OutputSGSTAccountID, liability},
+		{"output IGST", arg.OutputIGSTAccountID, liability},
+		{"input CGST", arg.InputCGSTAccountID, asset},
+		{"input SGST", arg.InputSGSTAccountID, asset},
+		{"input IGST", arg.InputIGSTAccountID, asset},
+	}
+
+	var items []domain.JournalItem
+	for _, role := range roles {
+		acc, err := q.GetAccount(ctx, db.GetAccountParams{ID: role.id, InstituteID: arg.InstituteID})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: %s account %s", ErrAccountNotFound, role.name, role.id)
+			}
+			return nil, err
+		}
+		if !helper.Contains(role.types, domain.AccountType(acc.Type)) {
+			return nil, fmt.Errorf("%w: %s account %s is %s", ErrLedgerAccountType, role.name, acc.Code, acc.Type)
+		}
+		items = append(items, domain.JournalItem{AccountID: acc.ID})
+	}
+
+	if err := checkPostingAccounts(ctx, q, arg.InstituteID, items); err != nil {
+		return nil, err
+	}
+
+	row, err := q.UpsertTaxSettings(ctx, mapper.MapTaxSettingsDomainToParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	result := mapper.MapTaxSettingsRowToDomain(row)
+	return &result, tx.Commit()
+}
+
+// loadTaxSettings fetches the institute's tax settings, which GST postings need
+func loadTaxSettings(ctx context.Context, q *db.Queries, instituteID uuid.UUID) (db.FinanceTaxSetting, error) {
+	settings, err := q.GetTaxSettings(ctx, instituteID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return settings, ErrTaxNotConfigured
+		}
+		return settings, err
+	}
+	return settings, nil
+}
+
+// checkGSTINState requires a GSTIN to carry the GST code of the state it is
+// said to be registered in. States without a code cannot be checked.
+func checkGSTINState(ctx context.Context, q *db.Queries, gstin string, stateID uuid.UUID) error {
+	state, err := q.GetState(ctx, stateID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrStateNotFound, stateID)
+		}
+		return err
+	}
+	if state.GstCode.Valid && state.GstCode.String != helper.GSTINStateCode(gstin) {
+		return fmt.Errorf("%w: %s is not a %s GSTIN", ErrGSTINStateMismatch, gstin, state.Name)
+	}
+	return nil
+}
+
+// ========================= TAX ASSIGNMENT =========================
+
+// SERVICE
+// AssignFeeHeadTax sets the GST included in a fee head's amounts, or clears
+// it when taxID is nil. Invoices raised earlier keep the tax they carried.
+func (s *Service) AssignFeeHeadTax(ctx context.Context, instituteID, feeHeadID uuid.UUID, taxID, updatedBy *uuid.UUID) (*domain.FeeHead, error) {
+	if feeHeadID == uuid.Nil {
+		return nil, ErrInvalidTaxAssignment
+	}
+	return s.repo.AssignFeeHeadTax(ctx, instituteID, feeHeadID, taxID, updatedBy)
+}
+
+// AssignItemTax sets the GST purchases of an inventory item are charged by
+// default, or clears it when taxID is nil
+func (s *Service) AssignItemTax(ctx context.Context, instituteID, itemID uuid.UUID, taxID, updatedBy *uuid.UUID) (*domain.InventoryItem, error) {
+	if itemID == uuid.Nil {
+		return nil, ErrInvalidTaxAssignment
+	}
+	return s.repo.AssignItemTax(ctx, instituteID, itemID, taxID, updatedBy)
+}
+
+// REPOSITORY
+func (r *Repository) AssignFeeHeadTax(ctx context.Context, instituteID, feeHeadID uuid.UUID, taxID, updatedBy *uuid.UUID) (*domain.FeeHead, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	if taxID != nil {
+		if _, err := getTax(ctx, q, instituteID, *taxID); err != nil {
+			return nil, err
+		}
+	}
+
+	row, err := q.SetFeeHeadTax(ctx, db.SetFeeHeadTaxParams{
+		TaxID:       helper.ToNullUUID(helper.DerefUUID(taxID)),
+		UpdatedBy:   helper.ToNullUUID(helper.DerefUUID(updatedBy)),
+		ID:          feeHeadID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFeeHeadNotFound
+		}
+		return nil, err
+	}
+
+	result := mapper.MapFeeHeadRowToDomain(row)
+	return &result, nil
+}
+
+func (r *Repository) AssignItemTax(ctx context.Context, instituteID, itemID uuid.UUID, taxID, updatedBy *uuid.UUID) (*domain.InventoryItem, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	if taxID != nil {
+		if _, err := getTax(ctx, q, instituteID, *taxID); err != nil {
+			return nil, err
+		}
+	}
+
+	row, err := q.SetInventoryItemTax(ctx, db.SetInventoryItemTaxParams{
+		TaxID:       helper.ToNullUUID(helper.DerefUUID(taxID)),
+		UpdatedBy:   helper.ToNullUUID(helper.DerefUUID(updatedBy)),
+		ID:          itemID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInventoryItemNotFound
+		}
+		return nil, err
+	}
+
+	result := mapper.MapInventoryItemRowToDomain(row)
+	return &result, nil
+}
+
+func getTax(ctx context.Context, q *db.Queries, instituteID, id uuid.UUID) (db.FinanceTax, error) {
+	tax, err := q.GetTax(ctx, db.GetTaxParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return tax, fmt.Errorf("%w: %s", ErrTaxNotFound, id)
+		}
+		return tax, err
+	}
+	return tax, nil
+}
+
+// ========================= TAX LINES =========================
+
+// SERVICE
+func (s *Service) ListTaxLines(ctx context.Context, instituteID uuid.UUID, documentType string, documentID uuid.UUID) ([]*domain.TaxLine, error) {
+	return s.repo.ListTaxLines(ctx, instituteID, documentType, documentID)
+}
+
+// REPOSITORY
+func (r *Repository) ListTaxLines(ctx context.Context, instituteID uuid.UUID, documentType string, documentID uuid.UUID) ([]*domain.TaxLine, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListDocumentTaxLines(ctx, db.ListDocumentTaxLinesParams{
+		InstituteID:  instituteID,
+		DocumentType: documentType,
+		DocumentID:   documentID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]*domain.TaxLine, 0, len(rows))
+	for _, row := range rows {
+		l := mapper.MapTaxLineRowToDomain(row)
+		lines = append(lines, &l)
+	}
+	return lines, nil
+}
+
+// gstSplit is the GST on a taxable value in paise, split into its components
+type gstSplit struct {
+	taxable, cgst, sgst, igst int64
+}
+
+func (g gstSplit) tax() int64 {
+	return g.cgst + g.sgst + g.igst
+}
+
+func (g gstSplit) amounts() domain.TaxAmounts {
+	return domain.TaxAmounts{
+		Taxable: fromPaise(g.taxable),
+		CGST:    fromPaise(g.cgst),
+		SGST:    fromPaise(g.sgst),
+		IGST:    fromPaise(g.igst),
+		Total:   fromPaise(g.tax()),
+	}
+}
+
+// share is the part of g contained in paise from to to of a gross amount,
+// in proportion, so that refunding a gross amount in parts takes back all
+// of its tax and no more
+func (g gstSplit) share(gross, from, to int64) gstSplit {
+	if gross <= 0 {
+		return gstSplit{}
+	}
+	part := func(v, upTo int64) int64 {
+		return (v*upTo + gross/2) / gross
+	}
+	return gstSplit{
+		taxable: part(g.taxable, to) - part(g.taxable, from),
+		cgst:    part(g.cgst, to) - part(g.cgst, from),
+		sgst:    part(g.sgst, to) - part(g.sgst, from),
+		igst:    part(g.igst, to) - part(g.igst, from),
+	}
+}
+
+// refundTaxes is the GST charged on the items of an invoice being refunded,
+// with the accounts it was credited to
+type refundTaxes struct {
+	settings db.FinanceTaxSetting
+	items    map[uuid.UUID]gstSplit
+}
+
+// loadRefundTaxes reads the output tax lines of an invoice. The tax
+// settings are only loaded when the invoice was taxed.
+func loadRefundTaxes(ctx context.Context, q *db.Queries, instituteID, invoiceID uuid.UUID) (refundTaxes, error) {
+	rows, err := q.ListDocumentTaxLines(ctx, db.ListDocumentTaxLinesParams{
+		InstituteID:  instituteID,
+		DocumentType: taxDocInvoice,
+		DocumentID:   invoiceID,
+	})
+	if err != nil {
+		return refundTaxes{}, fmt.Errorf("failed to list invoice tax lines: %w", err)
+	}
+
+	taxes := refundTaxes{items: make(map[uuid.UUID]gstSplit, len(rows))}
+	var amounts paiseReader
+	for _, row := range rows {
+		taxes.items[row.ItemID] = gstSplit{
+			taxable: amounts.read(row.TaxableAmount),
+			cgst:    amounts.read(row.Cgst),
+			sgst:    amounts.read(row.Sgst),
+			igst:    amounts.read(row.Igst),
+		}
+	}
+	if amounts.err != nil {
+		return refundTaxes{}, amounts.err
+	}
+	if len(rows) > 0 {
+		if taxes.settings, err = loadTaxSettings(ctx, q, instituteID); err != nil {
+			return refundTaxes{}, err
+		}
+	}
+	return taxes, nil
+}
+
+// splitGST divides tax between the centre and the state for supplies within
+// a state, the odd paisa going to the state, and charges it all as IGST for
+// supplies across states
+func splitGST(taxable, tax int64, supply domain.SupplyType) gstSplit {
+	if supply == domain.SupplyInterState {
+		return gstSplit{taxable: taxable, igst: tax}
+	}
+	return gstSplit{taxable: taxable, cgst: tax / 2, sgst: tax - tax/2}
+}
+
+// taxOn is the tax at rate percent on a taxable value in paise
+func taxOn(taxable int64, rate float64) int64 {
+	return int64(math.Round(float64(taxable) * rate / 100))
+}
+
+// taxIncluded is the tax at rate percent contained in a gross amount in paise
+func taxIncluded(gross int64, rate float64) int64 {
+	return gross - int64(math.Round(float64(gross)*100/(100+rate)))
+}
+
+func parseRate(v string) float64 {
+	var rate float64
+	fmt.Sscanf(v, "%f", &rate)
+	return rate
+}
+
+// createTaxLine records the GST on one item of a document
+func createTaxLine(ctx context.Context, q *db.Queries, line domain.TaxLine, split gstSplit) error {
+	var taxDate sql.NullTime
+	if line.TaxDate != nil {
+		taxDate = helper.ToNullTime(*line.TaxDate)
+	}
+
+	_, err := q.CreateTaxLine(ctx, db.CreateTaxLineParams{
+		InstituteID:       line.InstituteID,
+		Direction:         string(line.Direction),
+		DocumentType:      line.DocumentType,
+		DocumentID:        line.DocumentID,
+		ItemID:            line.ItemID,
+		TaxID:             line.TaxID,
+		Rate:              fmt.Sprintf("%.2f", line.Rate),
+		Supply:            string(line.Supply),
+		TaxableAmount:     fmt.Sprintf("%.2f", fromPaise(split.taxable)),
+		Cgst:              fmt.Sprintf("%.2f", fromPaise(split.cgst)),
+		Sgst:              fmt.Sprintf("%.2f", fromPaise(split.sgst)),
+		Igst:              fmt.Sprintf("%.2f", fromPaise(split.igst)),
+		CounterpartyGstin: helper.ToNullString(helper.StrOrEmpty(line.CounterpartyGSTIN)),
+		TaxDate:           taxDate,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record tax line: %w", err)
+	}
+	return nil
+}
+
+// taxInvoiceItems carves the GST out of invoice items whose fee heads are
+// taxable. Fees are charged inclusive of tax, which moves from the fee
+// head's income account to the output CGST and SGST accounts, or IGST for
+// a student from another state, and a tax line dated today is kept for
+// each item.
+func taxInvoiceItems(ctx context.Context, q *db.Queries, invoice domain.Invoice, items []domain.InvoiceItem, heads map[uuid.UUID]db.FinanceFeeHead, lines *journalLines) error {
+	var taxIDs []uuid.UUID
+	for _, item := range items {
+		if head := heads[*item.FeeHeadID]; head.TaxID.Valid && !helper.Contains(taxIDs, head.TaxID.UUID) {
+			taxIDs = append(taxIDs, head.TaxID.UUID)
+		}
+	}
+	if len(taxIDs) == 0 {
+		return nil
+	}
+
+	settings, err := loadTaxSettings(ctx, q, invoice.InstituteID)
+	if err != nil {
+		return err
+	}
+	rows, err := q.GetTaxesByIDs(ctx, db.GetTaxesByIDsParams{InstituteID: invoice.InstituteID, Ids: taxIDs})
+	if err != nil {
+		return err
+	}
+	rates := make(map[uuid.UUID]float64, len(rows))
+	for _, row := range rows {
+		rates[row.ID] = parseRate(row.Percentage)
+	}
+	supply, err := studentSupply(ctx, q, invoice, settings)
+	if err != nil {
+		return err
+	}
+
+	today := dateOnly(time.Now())
+	for _, item := range items {
+		head := heads[*item.FeeHeadID]
+		if !head.TaxID.Valid {
+			continue
+		}
+		rate, ok := rates[head.TaxID.UUID]
+		if !ok {
+			return fmt.Errorf("%w: %s on fee head %s", ErrTaxNotFound, head.TaxID.UUID, head.Name)
+		}
+
+		gross := toPaise(item.Amount) - toPaise(item.DiscountApplied)
+		if gross <= 0 {
+			continue
+		}
+		tax := taxIncluded(gross, rate)
+		split := splitGST(gross-tax, tax, supply)
+
+		if err := createTaxLine(ctx, q, domain.TaxLine{
+			InstituteID:  invoice.InstituteID,
+			Direction:    domain.TaxOutput,
+			DocumentType: taxDocInvoice,
+			DocumentID:   invoice.ID,
+			ItemID:       item.ID,
+			TaxID:        head.TaxID.UUID,
+			Rate:         rate,
+			Supply:       supply,
+			TaxDate:      &today,
+		}, split); err != nil {
+			return err
+		}
+
+		lines.debit(head.LinkedGlAccountID.UUID, tax)
+		lines.credit(settings.OutputCgstAccountID, split.cgst)
+		lines.credit(settings.OutputSgstAccountID, split.sgst)
+		lines.credit(settings.OutputIgstAccountID, split.igst)
+	}
+	return nil
+}
+
+// purchaseItemTax sets the GST charged on an item added to a purchase order,
+// at the rate given on the order line or else the item's own rate. The tax is
+// only recorded, and split by where the vendor is, as the goods are received.
+func purchaseItemTax(ctx context.Context, q *db.Queries, item *domain.PurchaseItem) error {
+	item.TaxAmount = 0
+
+	taxID := item.TaxID
+	if taxID == nil {
+		inv, err := q.GetInventoryItem(ctx, db.GetInventoryItemParams{ID: item.ItemID, InstituteID: item.InstituteID})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s", ErrInventoryItemNotFound, item.ItemID)
+			}
+			return err
+		}
+		taxID = helper.NullUUIDToPtr(inv.TaxID)
+	}
+	if taxID == nil {
+		return nil
+	}
+
+	tax, err := getTax(ctx, q, item.InstituteID, *taxID)
+	if err != nil {
+		return err
+	}
+
+	taxable := int64(item.Quantity) * toPaise(item.UnitPrice)
+	item.TaxID = taxID
+	item.TaxAmount = fromPaise(taxOn(taxable, parseRate(tax.Percentage)))
+	return nil
+}
+
+// vendorSupply tells whether buying from the vendor of an order is a supply
+// within the institute's state or across states, going by the vendor's state
+// or else the state code of its GSTIN, and returns the vendor's GSTIN. A
+// vendor in no known state cannot be taxed.
+func vendorSupply(ctx context.Context, q *db.Queries, po domain.PurchaseOrder, settings db.FinanceTaxSetting) (domain.SupplyType, *string, error) {
+	vendor, err := q.GetVendor(ctx, db.GetVendorParams{ID: po.VendorID, InstituteID: po.InstituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil, fmt.Errorf("%w: %s", ErrVendorNotFound, po.VendorID)
+		}
+		return "", nil, err
+	}
+
+	gstin := helper.NullStringToPtr(vendor.GstNumber)
+	if gstin != nil && helper.ValidateGSTIN(*gstin) != nil {
+		gstin = nil
+	}
+
+	stateID := vendor.StateID
+	if !stateID.Valid && gstin != nil {
+		state, err := q.GetStateByGSTCode(ctx, helper.ToNullString(helper.GSTINStateCode(*gstin)))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return "", nil, err
+		}
+		if err == nil {
+			stateID = helper.ToNullUUID(state.ID)
+		}
+	}
+
+	if !stateID.Valid {
+		return "", nil, fmt.Errorf("%w: %s", ErrVendorStateUnknown, vendor.Name)
+	}
+	if stateID.UUID != settings.StateID {
+		return domain.SupplyInterState, gstin, nil
+	}
+	return domain.SupplyIntraState, gstin, nil
+}
+
+// studentSupply tells whether the fees of an invoice are supplied within the
+// institute's state or across states. The place of supply is the state of
+// the student's address, or the institute's own when the student has none.
+func studentSupply(ctx context.Context, q *db.Queries, invoice domain.Invoice, settings db.FinanceTaxSetting) (domain.SupplyType, error) {
+	stateID, err := q.GetStudentSupplyState(ctx, invoice.StudentID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	if stateID.Valid && stateID.UUID != settings.StateID {
+		return domain.SupplyInterState, nil
+	}
+	return domain.SupplyIntraState, nil
+}
+
+// receiptTaxes records the GST on goods as they are received against an
+// order. The tax settings and the vendor's supply are loaded with the first
+// taxed item.
+type receiptTaxes struct {
+	po       domain.PurchaseOrder
+	receipt  domain.GoodsReceipt
+	settings *db.FinanceTaxSetting
+	supply   domain.SupplyType
+	gstin    *string
+}
+
+// add records the tax line of a received item and moves its GST out of the
+// account the goods were debited to, into the input tax accounts it can be
+// claimed back from
+func (t *receiptTaxes) add(ctx context.Context, q *db.Queries, item domain.GoodsReceiptItem, taxID, expense uuid.UUID, lines *journalLines) error {
+	if t.settings == nil {
+		settings, err := loadTaxSettings(ctx, q, t.po.InstituteID)
+		if err != nil {
+			return err
+		}
+		t.supply, t.gstin, err = vendorSupply(ctx, q, t.po, settings)
+		if err != nil {
+			return err
+		}
+		t.settings = &settings
+	}
+
+	tax, err := getTax(ctx, q, t.po.InstituteID, taxID)
+	if err != nil {
+		return err
+	}
+
+	taxable := int64(item.Quantity) * toPaise(item.UnitPrice)
+	split := splitGST(taxable, toPaise(item.TaxAmount), t.supply)
+	line := domain.TaxLine{
+		InstituteID:       t.po.InstituteID,
+		Direction:         domain.TaxInput,
+		DocumentType:      taxDocGoodsReceipt,
+		DocumentID:        t.receipt.ID,
+		ItemID:            item.ID,
+		TaxID:             taxID,
+		Rate:              parseRate(tax.Percentage),
+		Supply:            t.supply,
+		CounterpartyGSTIN: t.gstin,
+		TaxDate:           &t.receipt.ReceivedOn,
+	}
+	if err := createTaxLine(ctx, q, line, split); err != nil {
+		return err
+	}
+
+	lines.credit(expense, split.tax())
+	lines.debit(t.settings.InputCgstAccountID, split.cgst)
+	lines.debit(t.settings.InputSgstAccountID, split.sgst)
+	lines.debit(t.settings.InputIgstAccountID, split.igst)
+	return nil
+}
+
+// ========================= TAX SUMMARY =========================
+
+// SERVICE
+// TaxSummary totals the tax lines dated in a filing period by direction,
+// supply type and rate, and sets tax paid on purchases against tax charged
+// on fees, component by component
+func (s *Service) TaxSummary(ctx context.Context, instituteID uuid.UUID, from, to time.Time) (*domain.TaxSummary, error) {
+	from, to = dateOnly(from), dateOnly(to)
+	if to.Before(from) {
+		return nil, ErrInvalidPeriod
+	}
+	return s.repo.TaxSummary(ctx, instituteID, from, to)
+}
+
+// REPOSITORY
+func (r *Repository) TaxSummary(ctx context.Context, instituteID uuid.UUID, from, to time.Time) (*domain.TaxSummary, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := loadTaxSettings(ctx, q, instituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.SummariseTaxLines(ctx, db.SummariseTaxLinesParams{
+		InstituteID: instituteID,
+		FromDate:    helper.ToNullTime(from),
+		ToDate:      helper.ToNullTime(to),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &domain.TaxSummary{GSTIN: settings.Gstin, From: from, To: to, Lines: []domain.TaxSummaryLine{}}
+	var output, input gstSplit
+	for _, row := range rows {
+		var amounts paiseReader
+		split := gstSplit{
+			taxable: amounts.read(row.TaxableAmount),
+			cgst:    amounts.read(row.Cgst),
+			sgst:    amounts.read(row.Sgst),
+			igst:    amounts.read(row.Igst),
+		}
+		if amounts.err != nil {
+			return nil, amounts.err
+		}
+
+		total := &output
+		if domain.TaxDirection(row.Direction) == domain.TaxInput {
+			total = &input
+		}
+		total.taxable += split.taxable
+		total.cgst += split.cgst
+		total.sgst += split.sgst
+		total.igst += split.igst
+
+		summary.Lines = append(summary.Lines, domain.TaxSummaryLine{
+			Direction:  domain.TaxDirection(row.Direction),
+			Supply:     domain.SupplyType(row.Supply),
+			Rate:       parseRate(row.Rate),
+			Lines:      int(row.Lines),
+			TaxAmounts: split.amounts(),
+		})
+	}
+
+	summary.Output = output.amounts()
+	summary.Input = input.amounts()
+	summary.NetPayable = gstSplit{
+		cgst: output.cgst - input.cgst,
+		sgst: output.sgst - input.sgst,
+		igst: output.igst - input.igst,
+	}.amounts()
+	return summary, nil
+}
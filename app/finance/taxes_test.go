@@ -0,0 +1,56 @@
+package finance
+
+import (
+	"testing"
+
+	"swiftschool/domain"
+)
+
+func TestSplitGST(t *testing.T) {
+	tests := []struct {
+		name    string
+		taxable int64
+		tax     int64
+		supply  domain.SupplyType
+		want    gstSplit
+	}{
+		{"intra-state even", 100000, 18000, domain.SupplyIntraState, gstSplit{taxable: 100000, cgst: 9000, sgst: 9000}},
+		{"intra-state odd paisa to state", 10005, 1801, domain.SupplyIntraState, gstSplit{taxable: 10005, cgst: 900, sgst: 901}},
+		{"intra-state single paisa", 5, 1, domain.SupplyIntraState, gstSplit{taxable: 5, sgst: 1}},
+		{"inter-state", 100000, 18000, domain.SupplyInterState, gstSplit{taxable: 100000, igst: 18000}},
+		{"inter-state odd paisa", 10005, 1801, domain.SupplyInterState, gstSplit{taxable: 10005, igst: 1801}},
+		{"exempt", 50000, 0, domain.SupplyIntraState, gstSplit{taxable: 50000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitGST(tt.taxable, tt.tax, tt.supply)
+			if got != tt.want {
+				t.Fatalf("splitGST(%d, %d, %s) = %+v, want %+v", tt.taxable, tt.tax, tt.supply, got, tt.want)
+			}
+			if got.tax() != tt.tax {
+				t.Fatalf("split tax %d, want %d", got.tax(), tt.tax)
+			}
+		})
+	}
+}
+
+func TestGSTSplitShare(t *testing.T) {
+	g := gstSplit{taxable: 10000, cgst: 900, sgst: 901}
+	gross := g.taxable + g.tax()
+
+	// Refunding in uneven parts takes back exactly the tax charged
+	var total gstSplit
+	for _, cut := range [][2]int64{{0, 3333}, {3333, 7000}, {7000, gross}} {
+		part := g.share(gross, cut[0], cut[1])
+		total.taxable += part.taxable
+		total.cgst += part.cgst
+		total.sgst += part.sgst
+		total.igst += part.igst
+	}
+	if total != g {
+		t.Fatalf("parts sum to %+v, want %+v", total, g)
+	}
+	if got := g.share(0, 0, 100); got != (gstSplit{}) {
+		t.Fatalf("share of nothing = %+v", got)
+	}
+}
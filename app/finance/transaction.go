@@ -2,14 +2,48 @@ package finance
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"swiftschool/domain"
 	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidInvoice         = errors.New("an invoice needs a student and at least one item")
+	ErrInvalidInvoiceItem     = errors.New("each invoice item needs a fee head and an amount greater than zero, and its discount cannot exceed the amount")
+	ErrInvoiceNotFound        = errors.New("invoice not found")
+	ErrInvoiceNoTaken         = errors.New("invoice number is already in use")
+	ErrPeriodBilled           = errors.New("the student has already been invoiced for this billing period")
+	ErrInvalidPayment         = errors.New("a payment needs an invoice or a student and an amount greater than zero")
+	ErrInvalidPaymentMode     = errors.New("payment mode must be cash, cheque, online, upi or bank_transfer")
+	ErrPaymentStudentMismatch = errors.New("the invoice belongs to a different student")
+	ErrOverpayment            = errors.New("payment exceeds the balance due on the invoice")
+)
+
+// Invoice statuses, derived from the amount due and the amount paid
+const (
+	invoicePending = "pending"
+	invoicePartial = "partial"
+	invoicePaid    = "paid"
+)
+
+var paymentModes = []domain.PaymentMode{
+	domain.PaymentCash,
+	domain.PaymentCheque,
+	domain.PaymentOnline,
+	domain.PaymentUPI,
+	domain.PaymentBankTransfer,
+}
+
 // =================================================================================
 // HANDLERS
 // =================================================================================
@@ -26,15 +60,52 @@ func (h *Handler) CreateInvoice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
 	data, err := h.service.CreateInvoice(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create invoice: "+err.Error())
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create invoice: "+err.Error())
 		return
 	}
 
 	helper.NewSuccessResponse(w, http.StatusCreated, "invoice created successfully", data)
 }
 
+func (h *Handler) CreateInvoiceItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.InvoiceItem
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.CreateInvoiceItem(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to add invoice item: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "invoice item added successfully", data)
+}
+
 func (h *Handler) GetInvoice(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -42,10 +113,8 @@ func (h *Handler) GetInvoice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	idStr := r.URL.Query().Get("id")
-	instStr := r.URL.Query().Get("institute_id")
-
-	if idStr == "" || instStr == "" {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "id and institute_id are required")
+	if idStr == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "id is required")
 		return
 	}
 
@@ -54,17 +123,20 @@ func (h *Handler) GetInvoice(w http.ResponseWriter, r *http.Request) {
 		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid id: "+err.Error())
 		return
 	}
-	inst, err := uuid.Parse(instStr)
+	inst, err := helper.GetInstituteID(r)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid institute_id: "+err.Error())
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	res, err := h.service.GetInvoiceById(r.Context(), id, inst)
+	res, items, err := h.service.GetInvoiceWithItems(r.Context(), id, inst)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusNotFound, "invoice not found: "+err.Error())
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to fetch invoice: "+err.Error())
 		return
 	}
+	for _, item := range items {
+		res.Items = append(res.Items, *item)
+	}
 	helper.NewSuccessResponse(w, http.StatusOK, "invoice fetched successfully", res)
 }
 
@@ -74,11 +146,17 @@ func (h *Handler) ListStudentInvoices(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	instStr := r.URL.Query().Get("institute_id")
-	studentStr := r.URL.Query().Get("student_id")
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	inst, _ := uuid.Parse(instStr)
-	student, _ := uuid.Parse(studentStr)
+	student, err := helper.ParseRequiredUUIDFromQuery(r, "student_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid student_id: "+err.Error())
+		return
+	}
 
 	data, err := h.service.ListStudentInvoices(r.Context(), inst, student)
 	if err != nil {
@@ -100,9 +178,20 @@ func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+	if req.CollectedBy == nil {
+		req.CollectedBy = req.CreatedBy
+	}
+
 	data, err := h.service.CreateTransaction(r.Context(), req)
 	if err != nil {
-		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to create transaction: "+err.Error())
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to create transaction: "+err.Error())
 		return
 	}
 
@@ -112,27 +201,195 @@ func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 // ========================= CREATE INVOICE =========================
 
 // SERVICE
+// CreateInvoice raises an invoice with its items and posts it: receivables
+// are debited with the amount due and each fee head's account is credited,
+// less the GST included in the fees of taxable heads. Discounts come from
+// the student's concessions and totals are computed from the items.
+// Without an invoice number the next number of the institute's sequence is
+// used. A student whose wallet is set to auto-apply has the invoice paid
+// from it as far as the balance goes.
 func (s *Service) CreateInvoice(ctx context.Context, arg domain.Invoice) (*domain.Invoice, error) {
-	return s.repo.CreateInvoice(ctx, arg)
+	arg.InvoiceNo = strings.TrimSpace(arg.InvoiceNo)
+	if arg.StudentID == uuid.Nil || len(arg.Items) == 0 {
+		return nil, ErrInvalidInvoice
+	}
+
+	from, to := concessionPeriod(arg.BillingPeriod)
+	concessions, err := s.studentConcessions(ctx, arg.InstituteID, []uuid.UUID{arg.StudentID}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	applyConcessions(arg.Items, concessions[arg.StudentID])
+	if err := priceInvoice(&arg); err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.repo.CreateInvoice(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("invoice %s raised for student %s", invoice.InvoiceNo, invoice.StudentID)
+	return invoice, nil
 }
 
 // REPOSITORY
 func (r *Repository) CreateInvoice(ctx context.Context, arg domain.Invoice) (*domain.Invoice, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	settings, err := loadLedgerSettings(ctx, q, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+	heads, err := loadFeeHeads(ctx, q, arg.InstituteID, arg.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	if arg.InvoiceNo == "" {
+		n, err := q.NextInvoiceNumber(ctx, arg.InstituteID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to number invoice: %w", err)
+		}
+		arg.InvoiceNo = fmt.Sprintf("INV-%06d", n)
+	}
 
-	return &arg, nil
+	row, err := q.CreateInvoice(ctx, mapper.MapInvoiceDomainToParams(arg))
+	if err != nil {
+		if helper.IsPgUniqueViolation(err) {
+			if strings.Contains(err.Error(), "uq_invoices_billing_period") {
+				return nil, ErrPeriodBilled
+			}
+			return nil, ErrInvoiceNoTaken
+		}
+		return nil, fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	invoice := mapper.MapInvoiceRowToDomain(row)
+	for _, item := range arg.Items {
+		item.InstituteID = invoice.InstituteID
+		item.InvoiceID = invoice.ID
+		item.CreatedBy = arg.CreatedBy
+
+		itemRow, err := q.CreateInvoiceItem(ctx, mapper.MapInvoiceItemDomainToParams(item))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create invoice item: %w", err)
+		}
+		invoice.Items = append(invoice.Items, mapper.MapInvoiceItemRowToDomain(itemRow))
+	}
+
+	lines := invoiceLines(settings, heads, invoice.Items)
+	if err := taxInvoiceItems(ctx, q, invoice, invoice.Items, heads, lines); err != nil {
+		return nil, err
+	}
+
+	entry := sourceEntry(invoice.InstituteID, domain.JournalSourceInvoice, invoice.ID,
+		invoice.InvoiceNo, "Invoice "+invoice.InvoiceNo, arg.CreatedBy)
+	if _, err := postSourceJournal(ctx, q, entry, lines); err != nil {
+		return nil, err
+	}
+	if err := autoApplyWallet(ctx, q, &invoice, arg.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	return &invoice, tx.Commit()
 }
 
 // ========================= CREATE INVOICE ITEM =========================
 
 // SERVICE
+// CreateInvoiceItem adds an item to a raised invoice, discounted by the
+// student's concessions, updates its totals and posts the item the same
+// way the invoice was posted
 func (s *Service) CreateInvoiceItem(ctx context.Context, arg domain.InvoiceItem) (*domain.InvoiceItem, error) {
-	return s.repo.CreateInvoiceItem(ctx, arg)
+	invoice, err := s.repo.GetInvoiceById(ctx, arg.InvoiceID, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := concessionPeriod(invoice.BillingPeriod)
+	concessions, err := s.studentConcessions(ctx, arg.InstituteID, []uuid.UUID{invoice.StudentID}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	items := []domain.InvoiceItem{arg}
+	applyConcessions(items, concessions[invoice.StudentID])
+
+	if err := checkInvoiceItem(items[0]); err != nil {
+		return nil, err
+	}
+	return s.repo.CreateInvoiceItem(ctx, items[0])
 }
 
 // REPOSITORY
 func (r *Repository) CreateInvoiceItem(ctx context.Context, arg domain.InvoiceItem) (*domain.InvoiceItem, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
 
-	return &arg, nil
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	item, err := addInvoiceItem(ctx, q, arg)
+	if err != nil {
+		return nil, err
+	}
+	return item, tx.Commit()
+}
+
+// addInvoiceItem adds an item to an invoice, updates its totals and posts
+// the item, all within the caller's transaction
+func addInvoiceItem(ctx context.Context, q *db.Queries, arg domain.InvoiceItem) (*domain.InvoiceItem, error) {
+	settings, err := loadLedgerSettings(ctx, q, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := lockInvoice(ctx, q, arg.InstituteID, arg.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	heads, err := loadFeeHeads(ctx, q, arg.InstituteID, []domain.InvoiceItem{arg})
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreateInvoiceItem(ctx, mapper.MapInvoiceItemDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invoice item: %w", err)
+	}
+	item := mapper.MapInvoiceItemRowToDomain(row)
+
+	invoice.TotalAmount = fromPaise(toPaise(invoice.TotalAmount) + toPaise(item.Amount))
+	invoice.DiscountAmount = fromPaise(toPaise(invoice.DiscountAmount) + toPaise(item.DiscountApplied))
+	if _, err := saveInvoiceAmounts(ctx, q, invoice, arg.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	lines := invoiceLines(settings, heads, []domain.InvoiceItem{item})
+	if err := taxInvoiceItems(ctx, q, invoice, []domain.InvoiceItem{item}, heads, lines); err != nil {
+		return nil, err
+	}
+
+	entry := sourceEntry(item.InstituteID, domain.JournalSourceInvoiceItem, item.ID,
+		invoice.InvoiceNo, "Item added to invoice "+invoice.InvoiceNo, arg.CreatedBy)
+	if _, err := postSourceJournal(ctx, q, entry, lines); err != nil {
+		return nil, err
+	}
+
+	return &item, nil
 }
 
 // ========================= GET INVOICE BY ID =========================
@@ -144,8 +401,24 @@ func (s *Service) GetInvoiceById(ctx context.Context, id, instituteID uuid.UUID)
 
 // REPOSITORY
 func (r *Repository) GetInvoiceById(ctx context.Context, id, instituteID uuid.UUID) (*domain.Invoice, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
 
-	return nil, nil
+	row, err := q.GetInvoice(ctx, db.GetInvoiceParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+
+	result := mapper.MapInvoiceRowToDomain(row)
+	return &result, nil
 }
 
 // ========================= GET INVOICE WITH ITEMS =========================
@@ -157,8 +430,34 @@ func (s *Service) GetInvoiceWithItems(ctx context.Context, id, instituteID uuid.
 
 // REPOSITORY
 func (r *Repository) GetInvoiceWithItems(ctx context.Context, id, instituteID uuid.UUID) (*domain.Invoice, []*domain.InvoiceItem, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return nil, nil, nil
+	row, err := q.GetInvoice(ctx, db.GetInvoiceParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrInvoiceNotFound
+		}
+		return nil, nil, err
+	}
+	invoice := mapper.MapInvoiceRowToDomain(row)
+
+	rows, err := q.ListInvoiceItems(ctx, db.ListInvoiceItemsParams{InvoiceID: id, InstituteID: instituteID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list invoice items: %w", err)
+	}
+
+	items := make([]*domain.InvoiceItem, 0, len(rows))
+	for _, row := range rows {
+		item := mapper.MapInvoiceItemRowToDomain(row)
+		items = append(items, &item)
+	}
+	return &invoice, items, nil
 }
 
 // ========================= LIST STUDENT INVOICES =========================
@@ -170,8 +469,28 @@ func (s *Service) ListStudentInvoices(ctx context.Context, instituteID, studentI
 
 // REPOSITORY
 func (r *Repository) ListStudentInvoices(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.Invoice, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListStudentInvoices(ctx, db.ListStudentInvoicesParams{
+		InstituteID: instituteID,
+		StudentID:   studentID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoices: %w", err)
+	}
 
-	return nil, nil
+	invoices := make([]*domain.Invoice, 0, len(rows))
+	for _, row := range rows {
+		inv := mapper.MapInvoiceRowToDomain(row)
+		invoices = append(invoices, &inv)
+	}
+	return invoices, nil
 }
 
 // ========================= UPDATE INVOICE STATUS =========================
@@ -194,21 +513,260 @@ func (s *Service) GetOverdueInvoices(ctx context.Context, instituteID uuid.UUID)
 }
 
 // REPOSITORY
+// GetOverdueInvoices lists unpaid invoices whose due date has passed
 func (r *Repository) GetOverdueInvoices(ctx context.Context, instituteID uuid.UUID) ([]*domain.Invoice, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
 
-	return nil, nil
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListOverdueInvoices(ctx, db.ListOverdueInvoicesParams{
+		InstituteID: instituteID,
+		AsOf:        dateOnly(time.Now()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue invoices: %w", err)
+	}
+
+	invoices := make([]*domain.Invoice, 0, len(rows))
+	for _, row := range rows {
+		inv := mapper.MapInvoiceRowToDomain(row)
+		invoices = append(invoices, &inv)
+	}
+	return invoices, nil
 }
 
 // ========================= CREATE TRANSACTION =========================
 
 // SERVICE
+// CreateTransaction records a payment and posts it: cash or bank is
+// debited, or the student advances account for wallet payments, and
+// receivables are credited. A payment against an invoice also updates the
+// invoice's paid amount and status. Wallet payments draw down the
+// student's wallet.
 func (s *Service) CreateTransaction(ctx context.Context, arg domain.Transaction) (*domain.Transaction, error) {
-	// Add business logic to update invoice status if payment is complete
-	return s.repo.CreateTransaction(ctx, arg)
+	if toPaise(arg.Amount) <= 0 || (arg.InvoiceID == nil && arg.StudentID == nil) {
+		return nil, ErrInvalidPayment
+	}
+	if arg.IsWalletUsage && arg.InvoiceID == nil {
+		return nil, ErrInvalidWalletDebit
+	}
+	if !arg.IsWalletUsage && !helper.Contains(paymentModes, arg.PaymentMode) {
+		return nil, ErrInvalidPaymentMode
+	}
+
+	txn, err := s.repo.CreateTransaction(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("payment %s of %.2f recorded", txn.ID, txn.Amount)
+	return txn, nil
 }
 
 // REPOSITORY
 func (r *Repository) CreateTransaction(ctx context.Context, arg domain.Transaction) (*domain.Transaction, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	return &arg, nil
+	q := r.db.QueriesWithTx(tx)
+
+	if arg.StudentID == nil {
+		invoice, err := lockInvoice(ctx, q, arg.InstituteID, *arg.InvoiceID)
+		if err != nil {
+			return nil, err
+		}
+		arg.StudentID = &invoice.StudentID
+	}
+
+	if arg.IsWalletUsage {
+		txn, _, err := payFromWallet(ctx, q, arg, nil)
+		if err != nil {
+			return nil, err
+		}
+		return txn, tx.Commit()
+	}
+
+	// money actually received is always receipted
+	_, txns, err := issueReceipt(ctx, q, receiptForPayment(arg))
+	if err != nil {
+		return nil, err
+	}
+	return &txns[0], tx.Commit()
+}
+
+// receiptForPayment is the single-allocation receipt for one payment
+func receiptForPayment(arg domain.Transaction) domain.Receipt {
+	rc := domain.Receipt{
+		InstituteID:      arg.InstituteID,
+		StudentID:        helper.DerefUUID(arg.StudentID),
+		PaymentMode:      arg.PaymentMode,
+		TransactionRefNo: arg.TransactionRefNo,
+		ChequeNo:         arg.ChequeNo,
+		ChequeDate:       arg.ChequeDate,
+		BankName:         arg.BankName,
+		CollectedBy:      arg.CollectedBy,
+		CreatedBy:        arg.CreatedBy,
+		Allocations:      []domain.ReceiptAllocation{{InvoiceID: arg.InvoiceID, Amount: arg.Amount}},
+	}
+	if arg.PaymentDate != nil {
+		rc.ReceiptDate = *arg.PaymentDate
+	}
+	return rc
+}
+
+// recordPayment saves a payment, applies it to its invoice and posts it,
+// all within the caller's transaction
+func recordPayment(ctx context.Context, q *db.Queries, arg domain.Transaction) (*domain.Transaction, error) {
+	settings, err := loadLedgerSettings(ctx, q, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+	account, err := paymentAccount(settings, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	description := "Fee payment"
+	if arg.InvoiceID != nil {
+		invoice, err := lockInvoice(ctx, q, arg.InstituteID, *arg.InvoiceID)
+		if err != nil {
+			return nil, err
+		}
+		if arg.StudentID != nil && *arg.StudentID != invoice.StudentID {
+			return nil, ErrPaymentStudentMismatch
+		}
+		arg.StudentID = &invoice.StudentID
+
+		balance := invoiceDue(invoice) - toPaise(invoice.PaidAmount)
+		if toPaise(arg.Amount) > balance {
+			return nil, fmt.Errorf("%w: balance due is %.2f", ErrOverpayment, fromPaise(balance))
+		}
+
+		invoice.PaidAmount = fromPaise(toPaise(invoice.PaidAmount) + toPaise(arg.Amount))
+		if _, err := saveInvoiceAmounts(ctx, q, invoice, arg.CreatedBy); err != nil {
+			return nil, err
+		}
+		description = "Payment for invoice " + invoice.InvoiceNo
+	}
+
+	row, err := q.CreateTransaction(ctx, mapper.MapTransactionDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	txn := mapper.MapTransactionRowToDomain(row)
+
+	lines := newJournalLines()
+	lines.debit(account, toPaise(txn.Amount))
+	lines.credit(settings.ReceivablesAccountID, toPaise(txn.Amount))
+
+	entry := sourceEntry(txn.InstituteID, domain.JournalSourceTransaction, txn.ID,
+		referenceOr(txn.TransactionRefNo, "PAY-"+txn.ID.String()[:8]), description, arg.CreatedBy)
+	if _, err := postSourceJournal(ctx, q, entry, lines); err != nil {
+		return nil, err
+	}
+
+	return &txn, nil
+}
+
+// ========================= INVOICE HELPERS =========================
+
+// checkInvoiceItem requires a fee head, a positive amount and a discount
+// no larger than the amount
+func checkInvoiceItem(item domain.InvoiceItem) error {
+	amount, discount := toPaise(item.Amount), toPaise(item.DiscountApplied)
+	if item.FeeHeadID == nil || amount <= 0 || discount < 0 || discount > amount {
+		return ErrInvalidInvoiceItem
+	}
+	return nil
+}
+
+// priceInvoice validates the items of a new invoice and sets its totals
+// and status from them
+func priceInvoice(inv *domain.Invoice) error {
+	var total, discount int64
+	for i, item := range inv.Items {
+		if err := checkInvoiceItem(item); err != nil {
+			return fmt.Errorf("%w (item %d)", err, i+1)
+		}
+		total += toPaise(item.Amount)
+		discount += toPaise(item.DiscountApplied)
+	}
+	inv.TotalAmount = fromPaise(total)
+	inv.DiscountAmount = fromPaise(discount)
+	inv.FineAmount = 0
+	inv.PaidAmount = 0
+	inv.Status = invoiceStatus(*inv)
+	return nil
+}
+
+// invoiceDue is what the student owes on an invoice in paise before payments
+func invoiceDue(inv domain.Invoice) int64 {
+	return toPaise(inv.TotalAmount) - toPaise(inv.DiscountAmount) + toPaise(inv.FineAmount)
+}
+
+func invoiceStatus(inv domain.Invoice) string {
+	paid := toPaise(inv.PaidAmount)
+	switch {
+	case paid >= invoiceDue(inv):
+		return invoicePaid
+	case paid > 0:
+		return invoicePartial
+	default:
+		return invoicePending
+	}
+}
+
+func lockInvoice(ctx context.Context, q *db.Queries, instituteID, id uuid.UUID) (domain.Invoice, error) {
+	row, err := q.GetInvoiceForUpdate(ctx, db.GetInvoiceForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Invoice{}, ErrInvoiceNotFound
+		}
+		return domain.Invoice{}, err
+	}
+	return mapper.MapInvoiceRowToDomain(row), nil
+}
+
+// saveInvoiceAmounts writes the totals, fine and paid amount of inv
+// together with the status they imply
+func saveInvoiceAmounts(ctx context.Context, q *db.Queries, inv domain.Invoice, updatedBy *uuid.UUID) (*domain.Invoice, error) {
+	row, err := q.UpdateInvoiceAmounts(ctx, db.UpdateInvoiceAmountsParams{
+		TotalAmount:    fmt.Sprintf("%.2f", inv.TotalAmount),
+		DiscountAmount: helper.ToNullString(fmt.Sprintf("%.2f", inv.DiscountAmount)),
+		FineAmount:     helper.ToNullString(fmt.Sprintf("%.2f", inv.FineAmount)),
+		PaidAmount:     helper.ToNullString(fmt.Sprintf("%.2f", inv.PaidAmount)),
+		Status:         helper.ToNullString(invoiceStatus(inv)),
+		UpdatedBy:      helper.ToNullUUID(helper.DerefUUID(updatedBy)),
+		ID:             inv.ID,
+		InstituteID:    inv.InstituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update invoice: %w", err)
+	}
+
+	result := mapper.MapInvoiceRowToDomain(row)
+	return &result, nil
+}
+
+func listInvoiceItems(ctx context.Context, q *db.Queries, instituteID, invoiceID uuid.UUID) ([]domain.InvoiceItem, error) {
+	rows, err := q.ListInvoiceItems(ctx, db.ListInvoiceItemsParams{InvoiceID: invoiceID, InstituteID: instituteID})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]domain.InvoiceItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, mapper.MapInvoiceItemRowToDomain(row))
+	}
+	return items, nil
 }
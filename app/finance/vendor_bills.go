@@ -0,0 +1,774 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidVendorBill         = errors.New("a vendor bill needs a purchase order, a bill number and items with a quantity and a unit price greater than zero")
+	ErrVendorBillNotFound        = errors.New("vendor bill not found")
+	ErrVendorBillExists          = errors.New("the vendor's bill with this number is already recorded")
+	ErrBillVendorMismatch        = errors.New("the bill is from a different vendor than the purchase order")
+	ErrPurchaseNotBillable       = errors.New("bills can only be recorded against placed purchase orders")
+	ErrVendorBillDecided         = errors.New("only bills on hold can be approved or rejected")
+	ErrInvalidVendorBillDecision = errors.New("decision must be approved or rejected")
+	ErrVendorBillNotPayable      = errors.New("only matched or approved bills can be paid")
+	ErrInvalidVendorPayment      = errors.New("a vendor payment needs a bill, an amount greater than zero and a payment mode")
+	ErrVendorOverpayment         = errors.New("payment exceeds what is left to pay on the bill")
+	ErrBillExceedsReceipt        = errors.New("the bill covers more than has been received and not yet billed; approve it once the goods arrive")
+	ErrInvalidVendorBillStatus   = errors.New("bill status must be matched, on_hold, approved, rejected or paid")
+)
+
+// billTaxTolerance is how far, in paise, the tax on a bill line may be from
+// the order's before the bill is held
+const billTaxTolerance = 100
+
+// vendorBillDueDays is the credit period assumed when a bill has no due date
+const vendorBillDueDays = 30
+
+var vendorBillStatuses = []domain.VendorBillStatus{
+	domain.VendorBillMatched,
+	domain.VendorBillOnHold,
+	domain.VendorBillApproved,
+	domain.VendorBillRejected,
+	domain.VendorBillPaid,
+}
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) CreateVendorBill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.VendorBill
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.CreateVendorBill(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to record vendor bill: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "vendor bill "+string(data.Status), data)
+}
+
+func (h *Handler) ListVendorBills(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	vendorID, err := helper.ParseUUIDFromQuery(r, "vendor_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid vendor_id: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := domain.VendorBillStatus(r.URL.Query().Get("status"))
+	data, err := h.service.ListVendorBills(r.Context(), inst, vendorID, status)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to list vendor bills: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "vendor bills fetched successfully", data)
+}
+
+func (h *Handler) DecideVendorBill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := helper.ParseRequiredUUIDFromQuery(r, "vendor_bill_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid vendor_bill_id: "+err.Error())
+		return
+	}
+
+	var req struct {
+		Status  domain.VendorBillStatus `json:"status"`
+		Remarks *string                 `json:"remarks,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.DecideVendorBill(r.Context(), inst, id, req.Status, req.Remarks, helper.GetSessionUserID(r))
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to decide vendor bill: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "vendor bill "+string(data.Status), data)
+}
+
+func (h *Handler) PayVendorBill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.VendorPayment
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := h.service.PayVendorBill(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to pay vendor bill: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, "vendor payment recorded", data)
+}
+
+func (h *Handler) PayablesAgeing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	asOf, err := helper.ParseDateFromQuery(r, "as_of")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid as_of: "+err.Error())
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.PayablesAgeing(r.Context(), inst, asOf)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to generate payables ageing: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "payables ageing generated", data)
+}
+
+// ========================= CREATE VENDOR BILL =========================
+
+// SERVICE
+// CreateVendorBill records a vendor's bill for goods on a purchase order and
+// matches it three ways: each line must be for goods received and not yet
+// billed, at the order's unit price, with tax within billTaxTolerance of the
+// order's share. A bill that matches counts its lines as billed; any small
+// difference between the bill and the value of goods received is posted to
+// the order's expense account against payables. A bill that does not match
+// is held, with notes saying why, until it is decided.
+func (s *Service) CreateVendorBill(ctx context.Context, arg domain.VendorBill) (*domain.VendorBill, error) {
+	arg.BillNo = strings.TrimSpace(arg.BillNo)
+	if arg.PurchaseOrderID == uuid.Nil || arg.BillNo == "" || len(arg.Items) == 0 {
+		return nil, ErrInvalidVendorBill
+	}
+
+	var amount, tax int64
+	for i, item := range arg.Items {
+		if item.PurchaseOrderItemID == uuid.Nil || item.Quantity <= 0 || toPaise(item.UnitPrice) <= 0 || item.TaxAmount < 0 {
+			return nil, ErrInvalidVendorBill
+		}
+		line := int64(item.Quantity)*toPaise(item.UnitPrice) + toPaise(item.TaxAmount)
+		arg.Items[i].Amount = fromPaise(line)
+		amount += line
+		tax += toPaise(item.TaxAmount)
+	}
+	arg.Amount = fromPaise(amount)
+	arg.TaxAmount = fromPaise(tax)
+
+	if arg.BillDate.IsZero() {
+		arg.BillDate = time.Now()
+	}
+	arg.BillDate = dateOnly(arg.BillDate)
+	if arg.DueDate.IsZero() {
+		arg.DueDate = arg.BillDate.AddDate(0, 0, vendorBillDueDays)
+	}
+	if arg.DueDate.Before(arg.BillDate) {
+		return nil, ErrInvalidVendorBill
+	}
+	arg.DueDate = dateOnly(arg.DueDate)
+
+	bill, err := s.repo.CreateVendorBill(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("vendor bill %s on purchase order %s is %s", bill.BillNo, bill.PurchaseOrderID, bill.Status)
+	return bill, nil
+}
+
+// REPOSITORY
+func (r *Repository) CreateVendorBill(ctx context.Context, arg domain.VendorBill) (*domain.VendorBill, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	po, err := lockPurchaseOrder(ctx, q, arg.InstituteID, arg.PurchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	switch po.Status {
+	case domain.PurchaseOrdered, domain.PurchasePartiallyReceived, domain.PurchaseReceived, domain.PurchaseClosed:
+	default:
+		return nil, ErrPurchaseNotBillable
+	}
+	if arg.VendorID == uuid.Nil {
+		arg.VendorID = po.VendorID
+	}
+	if arg.VendorID != po.VendorID {
+		return nil, ErrBillVendorMismatch
+	}
+
+	items, err := purchaseItemsByID(ctx, q, po)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := matchBill(items, arg.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	arg.Status = domain.VendorBillMatched
+	if len(notes) > 0 {
+		arg.Status = domain.VendorBillOnHold
+	}
+
+	row, err := q.CreateVendorBill(ctx, db.CreateVendorBillParams{
+		InstituteID:     arg.InstituteID,
+		VendorID:        arg.VendorID,
+		PurchaseOrderID: po.ID,
+		BillNo:          arg.BillNo,
+		BillDate:        arg.BillDate,
+		DueDate:         arg.DueDate,
+		Amount:          fmt.Sprintf("%.2f", arg.Amount),
+		TaxAmount:       fmt.Sprintf("%.2f", arg.TaxAmount),
+		Status:          string(arg.Status),
+		MatchNotes:      helper.ToNullString(strings.Join(notes, "\n")),
+		Remarks:         helper.ToNullString(helper.StrOrEmpty(arg.Remarks)),
+		CreatedBy:       helper.ToNullUUID(helper.DerefUUID(arg.CreatedBy)),
+	})
+	if err != nil {
+		if helper.IsPgUniqueViolation(err) {
+			return nil, ErrVendorBillExists
+		}
+		return nil, fmt.Errorf("failed to create vendor bill: %w", err)
+	}
+	bill := mapper.MapVendorBillRowToDomain(row)
+
+	for _, item := range arg.Items {
+		itemRow, err := q.CreateVendorBillItem(ctx, db.CreateVendorBillItemParams{
+			InstituteID:         bill.InstituteID,
+			VendorBillID:        bill.ID,
+			PurchaseOrderItemID: item.PurchaseOrderItemID,
+			Quantity:            int32(item.Quantity),
+			UnitPrice:           fmt.Sprintf("%.2f", item.UnitPrice),
+			TaxAmount:           fmt.Sprintf("%.2f", item.TaxAmount),
+			Amount:              fmt.Sprintf("%.2f", item.Amount),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add vendor bill item: %w", err)
+		}
+		bill.Items = append(bill.Items, mapper.MapVendorBillItemRowToDomain(itemRow))
+	}
+
+	if bill.Status == domain.VendorBillMatched {
+		if err := settleBill(ctx, q, po, bill, items, arg.CreatedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	return &bill, tx.Commit()
+}
+
+// purchaseItemsByID locks the items of an order, keyed by id
+func purchaseItemsByID(ctx context.Context, q *db.Queries, po domain.PurchaseOrder) (map[uuid.UUID]*domain.PurchaseItem, error) {
+	items, err := lockPurchaseItems(ctx, q, po)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*domain.PurchaseItem, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+	return byID, nil
+}
+
+// matchBill checks each bill line against the order item it bills and the
+// goods received on it, and says what does not match
+func matchBill(items map[uuid.UUID]*domain.PurchaseItem, lines []domain.VendorBillItem) ([]string, error) {
+	billed := make(map[uuid.UUID]int)
+	var notes []string
+	for i, l := range lines {
+		item, ok := items[l.PurchaseOrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPurchaseItemNotFound, l.PurchaseOrderItemID)
+		}
+
+		done := item.BilledQuantity + billed[item.ID]
+		billed[item.ID] += l.Quantity
+
+		if open := item.ReceivedQuantity - done; l.Quantity > open {
+			notes = append(notes, fmt.Sprintf("line %d: %d billed but %d received and not yet billed", i+1, l.Quantity, max(open, 0)))
+		}
+		if toPaise(l.UnitPrice) != toPaise(item.UnitPrice) {
+			notes = append(notes, fmt.Sprintf("line %d: unit price %.2f against %.2f on the order", i+1, l.UnitPrice, item.UnitPrice))
+		}
+		expected := lineShare(*item, min(done+l.Quantity, item.Quantity)) - lineShare(*item, min(done, item.Quantity))
+		if diff := toPaise(l.TaxAmount) - expected; diff > billTaxTolerance || diff < -billTaxTolerance {
+			notes = append(notes, fmt.Sprintf("line %d: tax %.2f against %.2f on the order", i+1, l.TaxAmount, fromPaise(expected)))
+		}
+	}
+	return notes, nil
+}
+
+// settleBill counts the lines of an accepted bill as billed on its order.
+// A bill can only cover goods received and not yet billed, which were
+// credited to payables at the order's prices, so the difference between
+// the bill and the value of the received goods it covers is posted to the
+// order's expense account, or purchases, against payables.
+func settleBill(ctx context.Context, q *db.Queries, po domain.PurchaseOrder, bill domain.VendorBill, items map[uuid.UUID]*domain.PurchaseItem, settledBy *uuid.UUID) error {
+	var covered int64
+	for _, l := range bill.Items {
+		item := items[l.PurchaseOrderItemID]
+		if l.Quantity > item.ReceivedQuantity-item.BilledQuantity {
+			return ErrBillExceedsReceipt
+		}
+		covered += int64(l.Quantity)*toPaise(item.UnitPrice) + lineShare(*item, item.BilledQuantity+l.Quantity) - lineShare(*item, item.BilledQuantity)
+		item.BilledQuantity += l.Quantity
+
+		if _, err := q.AddPurchaseItemBilled(ctx, db.AddPurchaseItemBilledParams{
+			Quantity:    int32(l.Quantity),
+			ID:          item.ID,
+			InstituteID: helper.ToNullUUID(bill.InstituteID),
+		}); err != nil {
+			return fmt.Errorf("failed to update billed quantity: %w", err)
+		}
+	}
+
+	variance := toPaise(bill.Amount) - covered
+	if variance == 0 {
+		return nil
+	}
+
+	settings, err := loadLedgerSettings(ctx, q, bill.InstituteID)
+	if err != nil {
+		return err
+	}
+	expense := settings.PurchasesAccountID
+	if po.ExpenseAccountID != nil {
+		expense = *po.ExpenseAccountID
+	}
+
+	lines := newJournalLines()
+	lines.debit(expense, variance)
+	lines.credit(settings.PayablesAccountID, variance)
+
+	entry := sourceEntry(bill.InstituteID, domain.JournalSourceVendorBill, bill.ID, bill.BillNo, "Difference on vendor bill "+bill.BillNo, settledBy)
+	_, err = postSourceJournal(ctx, q, entry, lines)
+	return err
+}
+
+// ========================= LIST VENDOR BILLS =========================
+
+// SERVICE
+func (s *Service) ListVendorBills(ctx context.Context, instituteID, vendorID uuid.UUID, status domain.VendorBillStatus) ([]*domain.VendorBill, error) {
+	if status != "" && !helper.Contains(vendorBillStatuses, status) {
+		return nil, ErrInvalidVendorBillStatus
+	}
+	return s.repo.ListVendorBills(ctx, instituteID, vendorID, status)
+}
+
+// REPOSITORY
+func (r *Repository) ListVendorBills(ctx context.Context, instituteID, vendorID uuid.UUID, status domain.VendorBillStatus) ([]*domain.VendorBill, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListVendorBills(ctx, db.ListVendorBillsParams{
+		InstituteID: instituteID,
+		VendorID:    helper.ToNullUUID(vendorID),
+		Status:      helper.ToNullString(string(status)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vendor bills: %w", err)
+	}
+
+	bills := make([]*domain.VendorBill, 0, len(rows))
+	for _, row := range rows {
+		bill := mapper.MapVendorBillRowToDomain(row)
+		if bill.Items, err = listVendorBillItems(ctx, q, bill); err != nil {
+			return nil, err
+		}
+		bills = append(bills, &bill)
+	}
+
+	return bills, nil
+}
+
+func listVendorBillItems(ctx context.Context, q *db.Queries, bill domain.VendorBill) ([]domain.VendorBillItem, error) {
+	rows, err := q.ListVendorBillItems(ctx, db.ListVendorBillItemsParams{
+		VendorBillID: bill.ID,
+		InstituteID:  bill.InstituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vendor bill items: %w", err)
+	}
+
+	items := make([]domain.VendorBillItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, mapper.MapVendorBillItemRowToDomain(row))
+	}
+	return items, nil
+}
+
+// ========================= DECIDE VENDOR BILL =========================
+
+// SERVICE
+// DecideVendorBill approves or rejects a bill held by matching. Approval
+// accepts the bill's prices and taxes and settles it like a matched one,
+// but only for goods already received; the user who recorded the bill
+// cannot decide it.
+func (s *Service) DecideVendorBill(ctx context.Context, instituteID, id uuid.UUID, status domain.VendorBillStatus, remarks *string, decidedBy *uuid.UUID) (*domain.VendorBill, error) {
+	if status != domain.VendorBillApproved && status != domain.VendorBillRejected {
+		return nil, ErrInvalidVendorBillDecision
+	}
+
+	bill, err := s.repo.DecideVendorBill(ctx, instituteID, id, status, remarks, decidedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("vendor bill %s %s", bill.BillNo, bill.Status)
+	return bill, nil
+}
+
+// REPOSITORY
+func (r *Repository) DecideVendorBill(ctx context.Context, instituteID, id uuid.UUID, status domain.VendorBillStatus, remarks *string, decidedBy *uuid.UUID) (*domain.VendorBill, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	bill, err := lockVendorBill(ctx, q, instituteID, id)
+	if err != nil {
+		return nil, err
+	}
+	if bill.Status != domain.VendorBillOnHold {
+		return nil, ErrVendorBillDecided
+	}
+	if decidedBy != nil && bill.CreatedBy != nil && *decidedBy == *bill.CreatedBy {
+		return nil, ErrSelfApproval
+	}
+
+	row, err := q.DecideVendorBill(ctx, db.DecideVendorBillParams{
+		Status:      string(status),
+		DecidedBy:   helper.ToNullUUID(helper.DerefUUID(decidedBy)),
+		Remarks:     helper.ToNullString(helper.StrOrEmpty(remarks)),
+		ID:          id,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decide vendor bill: %w", err)
+	}
+	result := mapper.MapVendorBillRowToDomain(row)
+
+	if result.Items, err = listVendorBillItems(ctx, q, result); err != nil {
+		return nil, err
+	}
+
+	if status == domain.VendorBillApproved {
+		po, err := lockPurchaseOrder(ctx, q, instituteID, result.PurchaseOrderID)
+		if err != nil {
+			return nil, err
+		}
+		items, err := purchaseItemsByID(ctx, q, po)
+		if err != nil {
+			return nil, err
+		}
+		if err := settleBill(ctx, q, po, result, items, decidedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	return &result, tx.Commit()
+}
+
+// lockVendorBill reads a vendor bill for update
+func lockVendorBill(ctx context.Context, q *db.Queries, instituteID, id uuid.UUID) (domain.VendorBill, error) {
+	row, err := q.GetVendorBillForUpdate(ctx, db.GetVendorBillForUpdateParams{ID: id, InstituteID: instituteID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.VendorBill{}, ErrVendorBillNotFound
+		}
+		return domain.VendorBill{}, err
+	}
+	return mapper.MapVendorBillRowToDomain(row), nil
+}
+
+// ========================= PAY VENDOR BILL =========================
+
+// SERVICE
+// PayVendorBill pays some or all of what is left on a matched or approved
+// bill from cash, or the bank for other modes, debiting payables. The bill
+// is paid once nothing is left on it.
+func (s *Service) PayVendorBill(ctx context.Context, arg domain.VendorPayment) (*domain.VendorPayment, error) {
+	if arg.VendorBillID == uuid.Nil || toPaise(arg.Amount) <= 0 || !helper.Contains(paymentModes, arg.PaymentMode) {
+		return nil, ErrInvalidVendorPayment
+	}
+	if arg.PaidOn.IsZero() {
+		arg.PaidOn = time.Now()
+	}
+	arg.PaidOn = dateOnly(arg.PaidOn)
+
+	payment, err := s.repo.PayVendorBill(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("paid %.2f on vendor bill %s", payment.Amount, payment.VendorBillID)
+	return payment, nil
+}
+
+// REPOSITORY
+func (r *Repository) PayVendorBill(ctx context.Context, arg domain.VendorPayment) (*domain.VendorPayment, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	bill, err := lockVendorBill(ctx, q, arg.InstituteID, arg.VendorBillID)
+	if err != nil {
+		return nil, err
+	}
+	if bill.Status != domain.VendorBillMatched && bill.Status != domain.VendorBillApproved {
+		return nil, ErrVendorBillNotPayable
+	}
+	amount := toPaise(arg.Amount)
+	if left := toPaise(bill.Amount) - toPaise(bill.PaidAmount); amount > left {
+		return nil, fmt.Errorf("%w: %.2f left", ErrVendorOverpayment, fromPaise(left))
+	}
+
+	settings, err := loadLedgerSettings(ctx, q, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.CreateVendorPayment(ctx, db.CreateVendorPaymentParams{
+		InstituteID:  arg.InstituteID,
+		VendorBillID: bill.ID,
+		VendorID:     bill.VendorID,
+		Amount:       fmt.Sprintf("%.2f", fromPaise(amount)),
+		PaymentMode:  string(arg.PaymentMode),
+		PaidOn:       arg.PaidOn,
+		ReferenceNo:  helper.ToNullString(helper.StrOrEmpty(arg.ReferenceNo)),
+		CreatedBy:    helper.ToNullUUID(helper.DerefUUID(arg.CreatedBy)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record vendor payment: %w", err)
+	}
+	payment := mapper.MapVendorPaymentRowToDomain(row)
+
+	if _, err := q.AddVendorBillPaid(ctx, db.AddVendorBillPaidParams{
+		Amount:      fmt.Sprintf("%.2f", fromPaise(amount)),
+		ID:          bill.ID,
+		InstituteID: bill.InstituteID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update vendor bill: %w", err)
+	}
+
+	lines := newJournalLines()
+	lines.debit(settings.PayablesAccountID, amount)
+	lines.credit(paymentAccountFor(settings, payment.PaymentMode), amount)
+
+	ref := referenceOr(payment.ReferenceNo, "VP-"+payment.ID.String()[:8])
+	entry := sourceEntry(payment.InstituteID, domain.JournalSourceVendorPayment, payment.ID, ref, "Payment of vendor bill "+bill.BillNo, arg.CreatedBy)
+	entry.TransactionDate = payment.PaidOn
+	if _, err := postSourceJournal(ctx, q, entry, lines); err != nil {
+		return nil, err
+	}
+
+	return &payment, tx.Commit()
+}
+
+// ========================= PAYABLES AGEING =========================
+
+// SERVICE
+// PayablesAgeing buckets what is left to pay on bills dated by asOf, today
+// by default, per vendor by days past due. Bills on hold are totalled apart.
+func (s *Service) PayablesAgeing(ctx context.Context, instituteID uuid.UUID, asOf *time.Time) (*domain.PayablesAgeing, error) {
+	date := dateOnly(time.Now())
+	if asOf != nil {
+		date = dateOnly(*asOf)
+	}
+	return s.repo.PayablesAgeing(ctx, instituteID, date)
+}
+
+// REPOSITORY
+func (r *Repository) PayablesAgeing(ctx context.Context, instituteID uuid.UUID, asOf time.Time) (*domain.PayablesAgeing, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListOpenVendorBills(ctx, db.ListOpenVendorBillsParams{
+		InstituteID: instituteID,
+		AsOf:        asOf,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open vendor bills: %w", err)
+	}
+
+	// Rows come grouped by vendor
+	type vendorTotals struct {
+		ageing  domain.VendorAgeing
+		buckets ageingPaise
+		onHold  int64
+	}
+	var vendors []*vendorTotals
+	var totals ageingPaise
+	var onHold int64
+	for _, row := range rows {
+		if len(vendors) == 0 || vendors[len(vendors)-1].ageing.VendorID != row.VendorID {
+			vendors = append(vendors, &vendorTotals{ageing: domain.VendorAgeing{VendorID: row.VendorID, VendorName: row.VendorName}})
+		}
+		v := vendors[len(vendors)-1]
+		v.ageing.Bills++
+
+		outstanding, err := sumPaise(row.Outstanding)
+		if err != nil {
+			return nil, err
+		}
+		if domain.VendorBillStatus(row.Status) == domain.VendorBillOnHold {
+			v.onHold += outstanding
+			onHold += outstanding
+			continue
+		}
+		days := int(asOf.Sub(dateOnly(row.DueDate)).Hours() / 24)
+		v.buckets.add(days, outstanding)
+		totals.add(days, outstanding)
+	}
+
+	report := &domain.PayablesAgeing{
+		AsOf:    asOf,
+		Vendors: make([]domain.VendorAgeing, 0, len(vendors)),
+		OnHold:  fromPaise(onHold),
+		Totals:  totals.buckets(),
+	}
+	for _, v := range vendors {
+		v.ageing.OnHold = fromPaise(v.onHold)
+		v.ageing.AgeingBuckets = v.buckets.buckets()
+		report.Vendors = append(report.Vendors, v.ageing)
+	}
+
+	return report, nil
+}
+
+// ageingPaise sums amounts in paise by how many days past due they are:
+// not yet due, 1-30, 31-60, 61-90 and over 90 days
+type ageingPaise [5]int64
+
+func (a *ageingPaise) add(daysPastDue int, amount int64) {
+	switch {
+	case daysPastDue <= 0:
+		a[0] += amount
+	case daysPastDue <= 30:
+		a[1] += amount
+	case daysPastDue <= 60:
+		a[2] += amount
+	case daysPastDue <= 90:
+		a[3] += amount
+	default:
+		a[4] += amount
+	}
+}
+
+func (a ageingPaise) buckets() domain.AgeingBuckets {
+	return domain.AgeingBuckets{
+		Current:    fromPaise(a[0]),
+		Days1To30:  fromPaise(a[1]),
+		Days31To60: fromPaise(a[2]),
+		Days61To90: fromPaise(a[3]),
+		Over90:     fromPaise(a[4]),
+		Total:      fromPaise(a[0] + a[1] + a[2] + a[3] + a[4]),
+	}
+}
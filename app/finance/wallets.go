@@ -0,0 +1,479 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidWalletTopUp    = errors.New("a wallet top-up needs a student, a payment mode and an amount greater than zero")
+	ErrInvalidWalletDebit    = errors.New("a wallet debit needs a student, an amount greater than zero and either an invoice, or a fee head with purpose cafeteria, library_fine or other")
+	ErrInsufficientWallet    = errors.New("wallet balance is too low")
+	ErrInvalidWalletSettings = errors.New("wallet settings need a student")
+	ErrWalletStudentNotFound = errors.New("student not found in this institute")
+)
+
+// walletCharges are what a wallet may pay for besides invoices
+var walletCharges = []domain.WalletPurpose{
+	domain.WalletForCafeteria,
+	domain.WalletForLibraryFine,
+	domain.WalletForOther,
+}
+
+// =================================================================================
+// HANDLERS
+// =================================================================================
+
+func (h *Handler) TopUpWallet(w http.ResponseWriter, r *http.Request) {
+	h.walletEntry(w, r, h.service.TopUpWallet, "wallet topped up")
+}
+
+func (h *Handler) DebitWallet(w http.ResponseWriter, r *http.Request) {
+	h.walletEntry(w, r, h.service.DebitWallet, "wallet debited")
+}
+
+// walletEntry decodes a wallet movement and records it with record
+func (h *Handler) walletEntry(w http.ResponseWriter, r *http.Request, record func(context.Context, domain.WalletEntry) (*domain.WalletEntry, error), message string) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.WalletEntry
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+	req.CreatedBy = helper.GetSessionUserID(r)
+
+	data, err := record(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to update wallet: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusCreated, message, data)
+}
+
+func (h *Handler) GetStudentWallet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	student, err := helper.ParseRequiredUUIDFromQuery(r, "student_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid student_id: "+err.Error())
+		return
+	}
+
+	data, err := h.service.GetStudentWallet(r.Context(), inst, student)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch wallet: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "wallet fetched successfully", data)
+}
+
+func (h *Handler) ListWalletEntries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	inst, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	student, err := helper.ParseRequiredUUIDFromQuery(r, "student_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid student_id: "+err.Error())
+		return
+	}
+
+	data, err := h.service.ListWalletEntries(r.Context(), inst, student)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to fetch wallet entries: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "wallet entries fetched successfully", data)
+}
+
+func (h *Handler) SetWalletAutoApply(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req domain.StudentWallet
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	instID, err := helper.BindInstituteID(r, req.InstituteID)
+	if err != nil {
+		helper.NewErrorResponse(w, helper.TenantErrorStatus(err), err.Error())
+		return
+	}
+	req.InstituteID = instID
+
+	data, err := h.service.SetWalletAutoApply(r.Context(), req)
+	if err != nil {
+		helper.NewErrorResponse(w, financeErrorStatus(err), "failed to update wallet: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "wallet updated", data)
+}
+
+// ========================= WALLET MOVEMENTS =========================
+
+// SERVICE
+// TopUpWallet deposits an advance: cash or bank is debited and the student
+// advances account credited
+func (s *Service) TopUpWallet(ctx context.Context, arg domain.WalletEntry) (*domain.WalletEntry, error) {
+	if arg.StudentID == uuid.Nil || toPaise(arg.Amount) <= 0 ||
+		arg.PaymentMode == nil || !helper.Contains(paymentModes, *arg.PaymentMode) {
+		return nil, ErrInvalidWalletTopUp
+	}
+	arg.EntryType = domain.WalletTopUp
+	arg.Purpose = domain.WalletForDeposit
+	arg.InvoiceID, arg.FeeHeadID = nil, nil
+
+	return s.moveWallet(ctx, arg)
+}
+
+// DebitWallet draws on the wallet. With an invoice the debit is a payment
+// of that invoice; otherwise it is a charge such as a cafeteria bill or a
+// library fine, credited to the fee head's income account.
+func (s *Service) DebitWallet(ctx context.Context, arg domain.WalletEntry) (*domain.WalletEntry, error) {
+	if arg.StudentID == uuid.Nil || toPaise(arg.Amount) <= 0 {
+		return nil, ErrInvalidWalletDebit
+	}
+	if arg.InvoiceID != nil {
+		arg.Purpose = domain.WalletForFees
+		arg.FeeHeadID = nil
+	} else if arg.FeeHeadID == nil || !helper.Contains(walletCharges, arg.Purpose) {
+		return nil, ErrInvalidWalletDebit
+	}
+	arg.EntryType = domain.WalletDebit
+	arg.PaymentMode = nil
+
+	return s.moveWallet(ctx, arg)
+}
+
+func (s *Service) moveWallet(ctx context.Context, arg domain.WalletEntry) (*domain.WalletEntry, error) {
+	entry, err := s.repo.MoveWallet(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("wallet %s of %.2f for student %s, balance %.2f", entry.EntryType, entry.Amount, entry.StudentID, entry.BalanceAfter)
+	return entry, nil
+}
+
+// REPOSITORY
+func (r *Repository) MoveWallet(ctx context.Context, arg domain.WalletEntry) (*domain.WalletEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.db.QueriesWithTx(tx)
+
+	if arg.EntryType == domain.WalletDebit && arg.InvoiceID != nil {
+		payment := domain.Transaction{
+			InvoiceID:        arg.InvoiceID,
+			StudentID:        &arg.StudentID,
+			TransactionRefNo: arg.ReferenceNo,
+			Amount:           arg.Amount,
+			IsWalletUsage:    true,
+		}
+		payment.InstituteID = arg.InstituteID
+		payment.CreatedBy = arg.CreatedBy
+
+		_, entry, err := payFromWallet(ctx, q, payment, arg.Remarks)
+		if err != nil {
+			return nil, err
+		}
+		return entry, tx.Commit()
+	}
+
+	settings, err := loadLedgerSettings(ctx, q, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.StudentAdvancesAccountID.Valid {
+		return nil, ErrAdvancesNotConfigured
+	}
+	advances := settings.StudentAdvancesAccountID.UUID
+
+	entry, err := writeWalletEntry(ctx, q, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := toPaise(entry.Amount)
+	lines := newJournalLines()
+	var description string
+	switch entry.EntryType {
+	case domain.WalletTopUp:
+		lines.debit(paymentAccountFor(settings, *entry.PaymentMode), amount)
+		lines.credit(advances, amount)
+		description = "Wallet top-up"
+	default:
+		heads, err := loadFeeHeads(ctx, q, arg.InstituteID, []domain.InvoiceItem{{FeeHeadID: entry.FeeHeadID}})
+		if err != nil {
+			return nil, err
+		}
+		lines.debit(advances, amount)
+		lines.credit(heads[*entry.FeeHeadID].LinkedGlAccountID.UUID, amount)
+		description = "Wallet debit for " + string(entry.Purpose)
+	}
+
+	ref := referenceOr(entry.ReferenceNo, "WAL-"+entry.ID.String()[:8])
+	if _, err := postSourceJournal(ctx, q, sourceEntry(entry.InstituteID, domain.JournalSourceWalletEntry, entry.ID, ref, description, arg.CreatedBy), lines); err != nil {
+		return nil, err
+	}
+
+	return entry, tx.Commit()
+}
+
+// payFromWallet pays an invoice from the student's wallet: the payment is
+// recorded as wallet usage and the wallet debited by the same amount, all
+// within the caller's transaction
+func payFromWallet(ctx context.Context, q *db.Queries, arg domain.Transaction, remarks *string) (*domain.Transaction, *domain.WalletEntry, error) {
+	txn, err := recordPayment(ctx, q, arg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry, err := writeWalletEntry(ctx, q, domain.WalletEntry{
+		InstituteID:   txn.InstituteID,
+		StudentID:     *txn.StudentID,
+		EntryType:     domain.WalletDebit,
+		Purpose:       domain.WalletForFees,
+		Amount:        txn.Amount,
+		ReferenceNo:   txn.TransactionRefNo,
+		InvoiceID:     txn.InvoiceID,
+		TransactionID: &txn.ID,
+		Remarks:       remarks,
+		CreatedBy:     arg.CreatedBy,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return txn, entry, nil
+}
+
+// writeWalletEntry locks the student's wallet, moves its balance and keeps
+// the movement as a ledger row. A debit or refund beyond the balance fails.
+func writeWalletEntry(ctx context.Context, q *db.Queries, arg domain.WalletEntry) (*domain.WalletEntry, error) {
+	row, err := q.LockStudentWallet(ctx, db.LockStudentWalletParams{
+		InstituteID: arg.InstituteID,
+		StudentID:   arg.StudentID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWalletStudentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock wallet: %w", err)
+	}
+	wallet := mapper.MapStudentWalletRowToDomain(row)
+
+	balance := toPaise(wallet.Balance)
+	if arg.EntryType == domain.WalletTopUp {
+		balance += toPaise(arg.Amount)
+	} else {
+		balance -= toPaise(arg.Amount)
+	}
+	if balance < 0 {
+		return nil, fmt.Errorf("%w: balance is %.2f", ErrInsufficientWallet, wallet.Balance)
+	}
+
+	if _, err := q.SetWalletBalance(ctx, db.SetWalletBalanceParams{
+		Balance: helper.ToNullString(fmt.Sprintf("%.2f", fromPaise(balance))),
+		ID:      wallet.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update wallet balance: %w", err)
+	}
+
+	arg.WalletID = wallet.ID
+	arg.BalanceAfter = fromPaise(balance)
+	entryRow, err := q.CreateWalletEntry(ctx, mapper.MapWalletEntryDomainToParams(arg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet entry: %w", err)
+	}
+
+	entry := mapper.MapWalletEntryRowToDomain(entryRow)
+	return &entry, nil
+}
+
+// paymentAccountFor is the cash or bank account money moves through in mode
+func paymentAccountFor(settings db.FinanceLedgerSetting, mode domain.PaymentMode) uuid.UUID {
+	account, _ := paymentAccount(settings, domain.Transaction{PaymentMode: mode})
+	return account
+}
+
+// autoApplyWallet pays as much of a new invoice as the student's wallet
+// covers when the wallet is set to auto-apply, updating inv in place
+func autoApplyWallet(ctx context.Context, q *db.Queries, inv *domain.Invoice, createdBy *uuid.UUID) error {
+	row, err := q.GetAutoApplyWalletForUpdate(ctx, db.GetAutoApplyWalletForUpdateParams{
+		InstituteID: inv.InstituteID,
+		StudentID:   inv.StudentID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	wallet := mapper.MapStudentWalletRowToDomain(row)
+
+	amount := min(toPaise(wallet.Balance), invoiceDue(*inv)-toPaise(inv.PaidAmount))
+	if amount <= 0 {
+		return nil
+	}
+
+	payment := domain.Transaction{
+		InvoiceID:     &inv.ID,
+		StudentID:     &inv.StudentID,
+		Amount:        fromPaise(amount),
+		IsWalletUsage: true,
+	}
+	payment.InstituteID = inv.InstituteID
+	payment.CreatedBy = createdBy
+
+	remarks := "Applied automatically to invoice " + inv.InvoiceNo
+	if _, _, err := payFromWallet(ctx, q, payment, &remarks); err != nil {
+		return err
+	}
+
+	paid, err := lockInvoice(ctx, q, inv.InstituteID, inv.ID)
+	if err != nil {
+		return err
+	}
+	inv.PaidAmount, inv.Status = paid.PaidAmount, paid.Status
+	return nil
+}
+
+// ========================= WALLET QUERIES =========================
+
+// SERVICE
+func (s *Service) GetStudentWallet(ctx context.Context, instituteID, studentID uuid.UUID) (*domain.StudentWallet, error) {
+	return s.repo.GetStudentWallet(ctx, instituteID, studentID)
+}
+
+func (s *Service) ListWalletEntries(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.WalletEntry, error) {
+	return s.repo.ListWalletEntries(ctx, instituteID, studentID)
+}
+
+func (s *Service) SetWalletAutoApply(ctx context.Context, arg domain.StudentWallet) (*domain.StudentWallet, error) {
+	if arg.StudentID == uuid.Nil {
+		return nil, ErrInvalidWalletSettings
+	}
+	return s.repo.SetWalletAutoApply(ctx, arg)
+}
+
+// REPOSITORY
+// GetStudentWallet returns an empty wallet for a student who has never
+// deposited anything
+func (r *Repository) GetStudentWallet(ctx context.Context, instituteID, studentID uuid.UUID) (*domain.StudentWallet, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetStudentWallet(ctx, db.GetStudentWalletParams{InstituteID: instituteID, StudentID: studentID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &domain.StudentWallet{InstituteID: instituteID, StudentID: studentID}, nil
+		}
+		return nil, err
+	}
+
+	wallet := mapper.MapStudentWalletRowToDomain(row)
+	return &wallet, nil
+}
+
+func (r *Repository) ListWalletEntries(ctx context.Context, instituteID, studentID uuid.UUID) ([]*domain.WalletEntry, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListWalletEntries(ctx, db.ListWalletEntriesParams{InstituteID: instituteID, StudentID: studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallet entries: %w", err)
+	}
+
+	entries := make([]*domain.WalletEntry, 0, len(rows))
+	for _, row := range rows {
+		e := mapper.MapWalletEntryRowToDomain(row)
+		entries = append(entries, &e)
+	}
+	return entries, nil
+}
+
+func (r *Repository) SetWalletAutoApply(ctx context.Context, arg domain.StudentWallet) (*domain.StudentWallet, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.SetWalletAutoApply(ctx, db.SetWalletAutoApplyParams{
+		InstituteID: arg.InstituteID,
+		StudentID:   arg.StudentID,
+		AutoApply:   arg.AutoApply,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWalletStudentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update wallet: %w", err)
+	}
+
+	wallet := mapper.MapStudentWalletRowToDomain(row)
+	return &wallet, nil
+}
@@ -0,0 +1,282 @@
+package parent
+
+import (
+	"errors"
+	"net/http"
+
+	"swiftschool/helper"
+
+	"github.com/google/uuid"
+)
+
+// ListChildren godoc
+// @Summary List my children
+// @Description Retrieve the students linked to the signed-in guardian
+// @Tags Parent Portal
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.ChildResponse}
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /parent/children [get]
+func (h *Handler) ListChildren(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	instID, userID, ok := guardianRequest(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.ListChildren(r.Context(), instID, userID)
+	if err != nil {
+		helper.NewErrorResponse(w, parentErrorStatus(err), "failed to list children: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "children retrieved successfully", data)
+}
+
+// GetChild godoc
+// @Summary Get a child's profile
+// @Description Retrieve the profile of a student linked to the signed-in guardian
+// @Tags Parent Portal
+// @Produce json
+// @Param student_id query string true "Student ID"
+// @Success 200 {object} dto.SuccessResponse{data=dto.ChildResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /parent/children/profile [get]
+func (h *Handler) GetChild(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	instID, userID, studentID, ok := childRequest(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.GetChild(r.Context(), instID, userID, studentID)
+	if err != nil {
+		helper.NewErrorResponse(w, parentErrorStatus(err), "failed to get child profile: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "child profile retrieved successfully", data)
+}
+
+// ListChildTimetable godoc
+// @Summary Get a child's timetable
+// @Description Retrieve the weekly timetable of a linked student's class for the active session
+// @Tags Parent Portal
+// @Produce json
+// @Param student_id query string true "Student ID"
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.TimetableSlotResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /parent/children/timetable [get]
+func (h *Handler) ListChildTimetable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	instID, userID, studentID, ok := childRequest(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.ListChildTimetable(r.Context(), instID, userID, studentID)
+	if err != nil {
+		helper.NewErrorResponse(w, parentErrorStatus(err), "failed to get timetable: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "timetable retrieved successfully", data)
+}
+
+// ListChildInvoices godoc
+// @Summary List a child's invoices
+// @Description Retrieve the fee invoices raised for a linked student
+// @Tags Parent Portal
+// @Produce json
+// @Param student_id query string true "Student ID"
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.InvoiceResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /parent/children/invoices [get]
+func (h *Handler) ListChildInvoices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	instID, userID, studentID, ok := childRequest(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.ListChildInvoices(r.Context(), instID, userID, studentID)
+	if err != nil {
+		helper.NewErrorResponse(w, parentErrorStatus(err), "failed to list invoices: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "invoices retrieved successfully", data)
+}
+
+// ListChildExamResults godoc
+// @Summary List a child's exam marks
+// @Description Retrieve a linked student's marks in published exams
+// @Tags Parent Portal
+// @Produce json
+// @Param student_id query string true "Student ID"
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.ExamResultResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /parent/children/exam_results [get]
+func (h *Handler) ListChildExamResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	instID, userID, studentID, ok := childRequest(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.ListChildExamResults(r.Context(), instID, userID, studentID)
+	if err != nil {
+		helper.NewErrorResponse(w, parentErrorStatus(err), "failed to list exam results: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "exam results retrieved successfully", data)
+}
+
+// ListChildAssignments godoc
+// @Summary List a child's assignments
+// @Description Retrieve the active assignments of a linked student's class
+// @Tags Parent Portal
+// @Produce json
+// @Param student_id query string true "Student ID"
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.AssignmentResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /parent/children/assignments [get]
+func (h *Handler) ListChildAssignments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	instID, userID, studentID, ok := childRequest(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.ListChildAssignments(r.Context(), instID, userID, studentID)
+	if err != nil {
+		helper.NewErrorResponse(w, parentErrorStatus(err), "failed to list assignments: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "assignments retrieved successfully", data)
+}
+
+// ListChildNotifications godoc
+// @Summary List a child's notifications
+// @Description Retrieve the latest notifications sent to a linked student
+// @Tags Parent Portal
+// @Produce json
+// @Param student_id query string true "Student ID"
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.NotificationResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /parent/children/notifications [get]
+func (h *Handler) ListChildNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	instID, userID, studentID, ok := childRequest(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.ListChildNotifications(r.Context(), instID, userID, studentID)
+	if err != nil {
+		helper.NewErrorResponse(w, parentErrorStatus(err), "failed to list notifications: "+err.Error())
+		return
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "notifications retrieved successfully", data)
+}
+
+// guardianRequest reads the session's institute and login, writing the error response when either is missing
+func guardianRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	instID, err := helper.GetInstituteID(r)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID := helper.GetSessionUserID(r)
+	if userID == nil {
+		helper.NewErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return instID, *userID, true
+}
+
+// childRequest is guardianRequest plus the required student_id query parameter
+func childRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	instID, userID, ok := guardianRequest(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	studentID, err := helper.ParseRequiredUUIDFromQuery(r, "student_id")
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid student id: "+err.Error())
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	return instID, userID, studentID, true
+}
+
+func parentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotGuardian):
+		return http.StatusForbidden
+	case errors.Is(err, ErrChildNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,80 @@
+package parent
+
+import (
+	"context"
+	"swiftschool/domain"
+	"swiftschool/internal/database"
+
+	"github.com/google/uuid"
+)
+
+//////////////////////////////////////////////////////
+//                     HANDLER                      //
+//////////////////////////////////////////////////////
+
+type Handler struct {
+	service ServiceInterface
+}
+
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+//////////////////////////////////////////////////////
+//                    REPOSITORY                    //
+//////////////////////////////////////////////////////
+
+type Repository struct {
+	db *database.Database
+}
+
+func NewRepository(db *database.Database) *Repository {
+	return &Repository{db: db}
+}
+
+//////////////////////////////////////////////////////
+//                     SERVICE                      //
+//////////////////////////////////////////////////////
+
+type Service struct {
+	repo RepositoryInterface
+}
+
+func NewService(db *database.Database) *Service {
+	return &Service{
+		repo: NewRepository(db),
+	}
+}
+
+//////////////////////////////////////////////////////
+//               REPOSITORY INTERFACE               //
+//////////////////////////////////////////////////////
+
+type RepositoryInterface interface {
+	// ========================= GUARDIAN =========================
+	GetGuardianLogin(ctx context.Context, userID uuid.UUID) (*domain.User, error)
+
+	// ========================= CHILDREN =========================
+	ListChildren(ctx context.Context, instituteID, guardianID uuid.UUID) ([]*domain.GuardianChild, error)
+	GetChild(ctx context.Context, instituteID, guardianID, studentID uuid.UUID) (*domain.GuardianChild, error)
+	ListChildTimetable(ctx context.Context, instituteID, guardianID, studentID uuid.UUID) ([]*domain.TimetableSlot, error)
+	ListChildInvoices(ctx context.Context, instituteID, guardianID, studentID uuid.UUID) ([]*domain.Invoice, error)
+	ListChildExamResults(ctx context.Context, instituteID, guardianID, studentID uuid.UUID) ([]*domain.ExamResult, error)
+	ListChildAssignments(ctx context.Context, instituteID, guardianID, studentID uuid.UUID) ([]*domain.Assignment, error)
+	ListChildNotifications(ctx context.Context, instituteID, guardianID, studentID uuid.UUID, limit int32) ([]*domain.Notification, error)
+}
+
+//////////////////////////////////////////////////////
+//                 SERVICE INTERFACE                //
+//////////////////////////////////////////////////////
+
+type ServiceInterface interface {
+	// ========================= CHILDREN =========================
+	ListChildren(ctx context.Context, instituteID, userID uuid.UUID) ([]*domain.GuardianChild, error)
+	GetChild(ctx context.Context, instituteID, userID, studentID uuid.UUID) (*domain.GuardianChild, error)
+	ListChildTimetable(ctx context.Context, instituteID, userID, studentID uuid.UUID) ([]*domain.TimetableSlot, error)
+	ListChildInvoices(ctx context.Context, instituteID, userID, studentID uuid.UUID) ([]*domain.Invoice, error)
+	ListChildExamResults(ctx context.Context, instituteID, userID, studentID uuid.UUID) ([]*domain.ExamResult, error)
+	ListChildAssignments(ctx context.Context, instituteID, userID, studentID uuid.UUID) ([]*domain.Assignment, error)
+	ListChildNotifications(ctx context.Context, instituteID, userID, studentID uuid.UUID) ([]*domain.Notification, error)
+}
@@ -0,0 +1,222 @@
+package parent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"swiftschool/domain"
+	"swiftschool/internal/db"
+	"swiftschool/mapper"
+
+	"github.com/google/uuid"
+)
+
+// GetGuardianLogin returns the signed-in login so its guardian record can be resolved
+func (r *Repository) GetGuardianLogin(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotGuardian
+		}
+		return nil, err
+	}
+
+	return mapper.MapDBUserToDomain(row), nil
+}
+
+// ListChildren retrieves the institute's students linked to a guardian
+func (r *Repository) ListChildren(ctx context.Context, instituteID, guardianID uuid.UUID) ([]*domain.GuardianChild, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListGuardianChildren(ctx, db.ListGuardianChildrenParams{
+		GuardianID:  guardianID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]*domain.GuardianChild, 0, len(rows))
+	for _, row := range rows {
+		c := mapper.MapGuardianChildToDomain(row.CoreStudent, row.Relationship, row.IsPrimaryContact)
+		children = append(children, &c)
+	}
+	return children, nil
+}
+
+// GetChild retrieves one student, provided it is linked to the guardian
+func (r *Repository) GetChild(ctx context.Context, instituteID, guardianID, studentID uuid.UUID) (*domain.GuardianChild, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := q.GetGuardianChild(ctx, db.GetGuardianChildParams{
+		GuardianID:  guardianID,
+		StudentID:   studentID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrChildNotFound
+		}
+		return nil, err
+	}
+
+	c := mapper.MapGuardianChildToDomain(row.CoreStudent, row.Relationship, row.IsPrimaryContact)
+	return &c, nil
+}
+
+// ListChildTimetable retrieves the weekly timetable of the child's class
+func (r *Repository) ListChildTimetable(ctx context.Context, instituteID, guardianID, studentID uuid.UUID) ([]*domain.TimetableSlot, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListGuardianChildTimetable(ctx, db.ListGuardianChildTimetableParams{
+		GuardianID:  guardianID,
+		StudentID:   studentID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slots := make([]*domain.TimetableSlot, 0, len(rows))
+	for _, row := range rows {
+		s := mapper.MapTimetableSlotRowToDomain(row)
+		slots = append(slots, &s)
+	}
+	return slots, nil
+}
+
+// ListChildInvoices retrieves the fee invoices raised for the child
+func (r *Repository) ListChildInvoices(ctx context.Context, instituteID, guardianID, studentID uuid.UUID) ([]*domain.Invoice, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListGuardianChildInvoices(ctx, db.ListGuardianChildInvoicesParams{
+		GuardianID:  guardianID,
+		StudentID:   studentID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invoices := make([]*domain.Invoice, 0, len(rows))
+	for _, row := range rows {
+		inv := mapper.MapInvoiceRowToDomain(row)
+		invoices = append(invoices, &inv)
+	}
+	return invoices, nil
+}
+
+// ListChildExamResults retrieves the child's marks in published exams
+func (r *Repository) ListChildExamResults(ctx context.Context, instituteID, guardianID, studentID uuid.UUID) ([]*domain.ExamResult, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListGuardianChildExamResults(ctx, db.ListGuardianChildExamResultsParams{
+		GuardianID:  guardianID,
+		StudentID:   studentID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*domain.ExamResult, 0, len(rows))
+	for _, row := range rows {
+		res := mapper.MapExamResultRowToDomain(row)
+		results = append(results, &res)
+	}
+	return results, nil
+}
+
+// ListChildAssignments retrieves the active assignments of the child's class
+func (r *Repository) ListChildAssignments(ctx context.Context, instituteID, guardianID, studentID uuid.UUID) ([]*domain.Assignment, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListGuardianChildAssignments(ctx, db.ListGuardianChildAssignmentsParams{
+		GuardianID:  guardianID,
+		StudentID:   studentID,
+		InstituteID: instituteID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]*domain.Assignment, 0, len(rows))
+	for _, row := range rows {
+		a := mapper.MapDBAssignmentToDomain(row)
+		assignments = append(assignments, &a)
+	}
+	return assignments, nil
+}
+
+// ListChildNotifications retrieves the latest notifications sent to the child's login
+func (r *Repository) ListChildNotifications(ctx context.Context, instituteID, guardianID, studentID uuid.UUID, limit int32) ([]*domain.Notification, error) {
+	ctx, cancel := r.db.WithTimeout(ctx)
+	defer cancel()
+
+	q, err := r.db.Queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.ListGuardianChildNotifications(ctx, db.ListGuardianChildNotificationsParams{
+		GuardianID:  guardianID,
+		StudentID:   studentID,
+		InstituteID: instituteID,
+		RowLimit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]*domain.Notification, 0, len(rows))
+	for _, row := range rows {
+		n := mapper.MapDBNotificationToDomain(row)
+		notifications = append(notifications, &n)
+	}
+	return notifications, nil
+}
@@ -0,0 +1,108 @@
+package parent
+
+import (
+	"context"
+	"errors"
+
+	"swiftschool/domain"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotGuardian   = errors.New("the parent portal is only available to guardian logins")
+	ErrChildNotFound = errors.New("student is not linked to this guardian")
+)
+
+// notificationLimit caps the notifications returned per child
+const notificationLimit = 100
+
+// ListChildren returns the students linked to the signed-in guardian
+func (s *Service) ListChildren(ctx context.Context, instituteID, userID uuid.UUID) ([]*domain.GuardianChild, error) {
+	guardianID, err := s.guardianID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListChildren(ctx, instituteID, guardianID)
+}
+
+// GetChild returns the profile of one of the guardian's children
+func (s *Service) GetChild(ctx context.Context, instituteID, userID, studentID uuid.UUID) (*domain.GuardianChild, error) {
+	guardianID, err := s.guardianID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetChild(ctx, instituteID, guardianID, studentID)
+}
+
+// ListChildTimetable returns the weekly timetable of the child's class
+func (s *Service) ListChildTimetable(ctx context.Context, instituteID, userID, studentID uuid.UUID) ([]*domain.TimetableSlot, error) {
+	guardianID, err := s.childAccess(ctx, instituteID, userID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListChildTimetable(ctx, instituteID, guardianID, studentID)
+}
+
+// ListChildInvoices returns the fee invoices raised for the child
+func (s *Service) ListChildInvoices(ctx context.Context, instituteID, userID, studentID uuid.UUID) ([]*domain.Invoice, error) {
+	guardianID, err := s.childAccess(ctx, instituteID, userID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListChildInvoices(ctx, instituteID, guardianID, studentID)
+}
+
+// ListChildExamResults returns the child's marks in published exams
+func (s *Service) ListChildExamResults(ctx context.Context, instituteID, userID, studentID uuid.UUID) ([]*domain.ExamResult, error) {
+	guardianID, err := s.childAccess(ctx, instituteID, userID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListChildExamResults(ctx, instituteID, guardianID, studentID)
+}
+
+// ListChildAssignments returns the assignments set for the child's class
+func (s *Service) ListChildAssignments(ctx context.Context, instituteID, userID, studentID uuid.UUID) ([]*domain.Assignment, error) {
+	guardianID, err := s.childAccess(ctx, instituteID, userID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListChildAssignments(ctx, instituteID, guardianID, studentID)
+}
+
+// ListChildNotifications returns the latest notifications sent to the child
+func (s *Service) ListChildNotifications(ctx context.Context, instituteID, userID, studentID uuid.UUID) ([]*domain.Notification, error) {
+	guardianID, err := s.childAccess(ctx, instituteID, userID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListChildNotifications(ctx, instituteID, guardianID, studentID, notificationLimit)
+}
+
+// guardianID resolves the guardian record behind the signed-in login. The
+// role is checked against the stored login, not only the session.
+func (s *Service) guardianID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	user, err := s.repo.GetGuardianLogin(ctx, userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if user.RoleType != domain.RoleGuardian || !user.IsActive || user.LinkedEntityID == uuid.Nil {
+		return uuid.Nil, ErrNotGuardian
+	}
+	return user.LinkedEntityID, nil
+}
+
+// childAccess checks the student is linked to the guardian, so an unlinked
+// student is reported as not found rather than as an empty list. The list
+// queries still filter through the guardian-student map themselves.
+func (s *Service) childAccess(ctx context.Context, instituteID, userID, studentID uuid.UUID) (uuid.UUID, error) {
+	guardianID, err := s.guardianID(ctx, userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := s.repo.GetChild(ctx, instituteID, guardianID, studentID); err != nil {
+		return uuid.Nil, err
+	}
+	return guardianID, nil
+}
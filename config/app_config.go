@@ -8,6 +8,7 @@ import (
 // It maps environment variables to struct fields using tags.
 type AppConfig struct {
 	// Server & Logging
+	Environment  string        `env:"APP_ENV" default:"production"` // production, staging or development; test-only integrations are off in production
 	LogLevel     string        `env:"LOG_LEVEL" default:"debug"`
 	ServerPort   string        `env:"SERVER_PORT" default:":8080"`
 	ReadTimeout  time.Duration `env:"READ_TIMEOUT" default:"15s"`
@@ -20,6 +21,54 @@ type AppConfig struct {
 	VaultCACert  string `env:"VAULT_CA_CERT"`        // Path to CA Certificate for TLS (Added for Prod)
 	KVEnginePath string `env:"VAULT_KV_ENGINE_PATH"` // Vault KV secret engine mount path
 
+	// Sessions
+	SessionStore           string        `env:"SESSION_STORE" default:"postgres"`       // postgres or memory
+	SessionIdleTimeout     time.Duration `env:"SESSION_IDLE_TIMEOUT" default:"30m"`     // Logout after inactivity
+	SessionAbsoluteTimeout time.Duration `env:"SESSION_ABSOLUTE_TIMEOUT" default:"24h"` // Maximum session lifetime
+	SessionCleanupInterval time.Duration `env:"SESSION_CLEANUP_INTERVAL" default:"10m"` // Expired session purge interval
+
+	// Authorization
+	RBACModelPath      string        `env:"RBAC_MODEL_PATH" default:"rbac_with_domains_model.conf"` // Casbin model file
+	RBACReloadInterval time.Duration `env:"RBAC_RELOAD_INTERVAL" default:"1m"`                      // Policy refresh interval
+
+	// Login OTPs
+	OTPStore           string        `env:"OTP_STORE" default:"postgres"`       // postgres or memory
+	OTPHashKey         string        `env:"OTP_HASH_KEY"`                       // HMAC key for stored codes, shared by all replicas
+	OTPTTL             time.Duration `env:"OTP_TTL" default:"5m"`               // Code lifetime
+	OTPMaxAttempts     int           `env:"OTP_MAX_ATTEMPTS" default:"5"`       // Wrong guesses before lockout
+	OTPLockout         time.Duration `env:"OTP_LOCKOUT" default:"15m"`          // Lockout duration
+	OTPResendCooldown  time.Duration `env:"OTP_RESEND_COOLDOWN" default:"60s"`  // Minimum gap between sends
+	OTPDailyLimit      int           `env:"OTP_DAILY_LIMIT" default:"10"`       // Sends per identifier per 24h
+	OTPCleanupInterval time.Duration `env:"OTP_CLEANUP_INTERVAL" default:"10m"` // Expired OTP purge interval
+
+	// Password login and two-factor
+	LoginMaxAttempts int           `env:"LOGIN_MAX_ATTEMPTS" default:"5"`               // Wrong passwords or TOTP codes before lockout
+	LoginLockout     time.Duration `env:"LOGIN_LOCKOUT" default:"15m"`                  // Account lockout duration
+	PasswordResetTTL time.Duration `env:"PASSWORD_RESET_TTL" default:"30m"`             // Forgot-password link lifetime
+	PasswordResetURL string        `env:"PASSWORD_RESET_URL" default:"/reset-password"` // Page that receives ?token=
+	MFAEncryptionKey string        `env:"MFA_ENCRYPTION_KEY"`                           // Encrypts stored TOTP secrets; TOTP is disabled when empty
+
+	// Notifications
+	SMTPHost     string `env:"SMTP_HOST"`               // SMTP server for outbound email
+	SMTPPort     int    `env:"SMTP_PORT" default:"587"` // SMTP server port
+	SMTPUsername string `env:"SMTP_USERNAME"`           // SMTP login
+	SMTPPassword string `env:"SMTP_PASSWORD"`           // SMTP password
+	MailFrom     string `env:"MAIL_FROM"`               // From header, defaults to SMTP_USERNAME
+	SMSProvider  string `env:"SMS_PROVIDER"`            // http, or fake for development; SMS is disabled when empty
+	SMSAPIURL    string `env:"SMS_API_URL"`             // HTTP SMS gateway endpoint
+	SMSAPIKey    string `env:"SMS_API_KEY"`             // HTTP SMS gateway API key
+	SMSSenderID  string `env:"SMS_SENDER_ID"`           // Registered sender ID
+
+	// Attendance
+	AttendanceEditWindow time.Duration `env:"ATTENDANCE_EDIT_WINDOW" default:"48h"` // How far back teachers may mark or correct attendance
+
+	// Finance
+	LateFeeInterval       time.Duration `env:"LATE_FEE_INTERVAL" default:"6h"`                                                     // How often overdue invoices are checked for late fees
+	DisableBackgroundJobs bool          `env:"DISABLE_BACKGROUND_JOBS"`                                                            // Skip the late fee and dunning jobs on this replica
+	DunningInterval       time.Duration `env:"DUNNING_INTERVAL" default:"6h"`                                                      // How often overdue invoices are checked for fee reminders
+	PaymentEncryptionKey  string        `env:"PAYMENT_ENCRYPTION_KEY"`                                                             // Encrypts stored gateway credentials; online payments are disabled when empty
+	PaymentCallbackURL    string        `env:"PAYMENT_CALLBACK_URL" default:"http://localhost:8080/api/finance/payments/callback"` // Public URL gateways send payment results to
+
 	// Cryptography
 	AESKeyLength int `env:"AES_KEY_LENGTH" default:"32"` // AES-256 key length (32 bytes)
 }
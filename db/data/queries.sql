@@ -0,0 +1,2021 @@
+-- =========================================================
+-- AUTH: CREDENTIALS
+-- =========================================================
+
+-- name: GetUserCredentials :one
+SELECT * FROM auth.user_credentials
+WHERE user_id = $1;
+
+-- name: RecordLoginFailure :one
+-- Counts a failed password or TOTP attempt; reaching max_attempts locks
+-- the account and restarts the counter.
+INSERT INTO auth.user_credentials (user_id, failed_attempts)
+VALUES (@user_id, 1)
+ON CONFLICT (user_id) DO UPDATE SET
+    failed_attempts = CASE
+        WHEN auth.user_credentials.failed_attempts + 1 >= @max_attempts::int THEN 0
+        ELSE auth.user_credentials.failed_attempts + 1
+    END,
+    locked_until = CASE
+        WHEN auth.user_credentials.failed_attempts + 1 >= @max_attempts::int THEN @locked_until::timestamptz
+        ELSE auth.user_credentials.locked_until
+    END,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: ResetLoginFailures :exec
+UPDATE auth.user_credentials
+SET failed_attempts = 0, locked_until = NULL, updated_at = NOW()
+WHERE user_id = $1;
+
+-- name: MarkPasswordChanged :exec
+INSERT INTO auth.user_credentials (user_id, password_changed_at)
+VALUES ($1, NOW())
+ON CONFLICT (user_id) DO UPDATE SET
+    password_changed_at = NOW(),
+    failed_attempts = 0,
+    locked_until = NULL,
+    updated_at = NOW();
+
+-- name: SetPendingTOTPSecret :exec
+INSERT INTO auth.user_credentials (user_id, totp_secret, totp_enabled)
+VALUES (@user_id, @totp_secret, FALSE)
+ON CONFLICT (user_id) DO UPDATE SET
+    totp_secret = EXCLUDED.totp_secret,
+    totp_enabled = FALSE,
+    totp_enabled_at = NULL,
+    totp_last_step = 0,
+    updated_at = NOW();
+
+-- name: EnableTOTP :execrows
+UPDATE auth.user_credentials
+SET totp_enabled = TRUE, totp_enabled_at = NOW(), totp_last_step = @totp_last_step, updated_at = NOW()
+WHERE user_id = @user_id AND totp_secret IS NOT NULL AND totp_enabled = FALSE;
+
+-- name: DisableTOTP :exec
+UPDATE auth.user_credentials
+SET totp_secret = NULL, totp_enabled = FALSE, totp_enabled_at = NULL, totp_last_step = 0, updated_at = NOW()
+WHERE user_id = $1;
+
+-- name: AdvanceTOTPStep :execrows
+-- Accepts a TOTP time step only once so codes cannot be replayed.
+UPDATE auth.user_credentials
+SET totp_last_step = @step::bigint, updated_at = NOW()
+WHERE user_id = @user_id AND totp_last_step < @step::bigint;
+
+-- =========================================================
+-- AUTH: RECOVERY CODES
+-- =========================================================
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO auth.mfa_recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM auth.mfa_recovery_codes
+WHERE user_id = $1;
+
+-- name: ConsumeRecoveryCode :execrows
+UPDATE auth.mfa_recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM auth.mfa_recovery_codes
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- =========================================================
+-- AUTH: MFA CHALLENGES
+-- =========================================================
+
+-- name: CreateMFAChallenge :exec
+INSERT INTO auth.mfa_challenges (token_hash, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: GetMFAChallengeUser :one
+SELECT user_id FROM auth.mfa_challenges
+WHERE token_hash = $1 AND expires_at > NOW();
+
+-- name: DeleteMFAChallenge :exec
+DELETE FROM auth.mfa_challenges
+WHERE token_hash = $1;
+
+-- =========================================================
+-- AUTH: PASSWORD RESET TOKENS
+-- =========================================================
+
+-- name: CreatePasswordResetToken :exec
+INSERT INTO auth.password_reset_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: InvalidatePasswordResetTokens :exec
+UPDATE auth.password_reset_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: ConsumePasswordResetToken :one
+UPDATE auth.password_reset_tokens
+SET used_at = NOW()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING user_id;
+
+-- =========================================================
+-- AUTH: USERS
+-- username_hash, email_hash and phone_number_hash hold
+-- helper.LookupHash values and serve every identifier lookup.
+-- =========================================================
+
+-- name: CreateUser :one
+INSERT INTO auth.users (
+    username, email, phone_number,
+    username_hash, email_hash, phone_number_hash,
+    password_hash, role_type, linked_entity_id, institute_id,
+    is_active, created_by
+) VALUES (
+    $1, $2, $3,
+    $4, $5, $6,
+    $7, $8, $9, $10,
+    $11, $12
+)
+RETURNING *;
+
+-- name: GetUserByLoginIdentifier :one
+-- Matches the column of the identifier's login type: the email for an
+-- email address, the phone number for a phone number and the username
+-- for anything else.
+SELECT * FROM auth.users
+WHERE deleted_at IS NULL
+  AND CASE @login_type::text
+        WHEN 'email' THEN email_hash = @lookup_hash
+        WHEN 'phone' THEN phone_number_hash = @lookup_hash
+        ELSE username_hash = @lookup_hash
+      END
+ORDER BY created_at, id
+LIMIT 1;
+
+-- name: GetUserByID :one
+SELECT * FROM auth.users
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: ActiveUserExistsForLogin :one
+SELECT EXISTS (
+    SELECT 1 FROM auth.users
+    WHERE deleted_at IS NULL
+      AND is_active = TRUE
+      AND role_type = @role_type
+      AND CASE @login_type::text
+            WHEN 'email' THEN email_hash = @lookup_hash
+            WHEN 'phone' THEN phone_number_hash = @lookup_hash
+            ELSE username_hash = @lookup_hash
+          END
+);
+
+-- name: UpdateUserPassword :execrows
+UPDATE auth.users
+SET password_hash = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: UpdateUserStatus :execrows
+UPDATE auth.users
+SET is_active = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: ListUsersByRole :many
+SELECT * FROM auth.users
+WHERE institute_id = $1 AND role_type = $2 AND deleted_at IS NULL
+ORDER BY created_at;
+
+-- =========================================================
+-- CORE: LOGIN PROVISIONING
+-- auth.users rows linked to students, guardians and employees
+-- through linked_entity_id.
+-- =========================================================
+
+-- name: GetUserByLinkedEntity :one
+SELECT * FROM auth.users
+WHERE linked_entity_id = $1 AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: DeactivateLinkedUsers :many
+UPDATE auth.users
+SET is_active = FALSE, updated_at = NOW(), updated_by = $3
+WHERE linked_entity_id = $1
+  AND institute_id = $2
+  AND is_active = TRUE
+  AND deleted_at IS NULL
+RETURNING id;
+
+-- name: SetStudentActive :execrows
+UPDATE core.students
+SET is_active = $3, updated_at = NOW(), updated_by = $4
+WHERE id = $1 AND institute_id = $2 AND deleted_at IS NULL;
+
+-- name: SetEmployeeActive :execrows
+UPDATE core.employees
+SET is_active = $3, updated_at = NOW(), updated_by = $4
+WHERE id = $1 AND institute_id = $2 AND deleted_at IS NULL;
+
+-- name: GetInstituteGuardian :one
+-- Guardians are shared across institutes; only those linked to a
+-- student of the institute are visible to it.
+SELECT g.* FROM core.guardians g
+WHERE g.id = @guardian_id
+  AND g.deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM core.student_guardian_map m
+      JOIN core.students s ON s.id = m.student_id
+      WHERE m.guardian_id = g.id
+        AND s.institute_id = @institute_id
+        AND s.deleted_at IS NULL
+  );
+
+-- name: ListClassGuardians :many
+SELECT DISTINCT g.* FROM core.guardians g
+JOIN core.student_guardian_map m ON m.guardian_id = g.id
+JOIN core.students s ON s.id = m.student_id
+WHERE s.institute_id = $1
+  AND s.current_class_id = $2
+  AND s.deleted_at IS NULL
+  AND g.deleted_at IS NULL
+ORDER BY g.first_name, g.id;
+
+-- =========================================================
+-- PARENT PORTAL
+-- Every query joins core.student_guardian_map so a guardian only
+-- ever reads students they are linked to.
+-- =========================================================
+
+-- name: ListGuardianChildren :many
+SELECT sqlc.embed(s), m.relationship, m.is_primary_contact
+FROM core.students s
+JOIN core.student_guardian_map m ON m.student_id = s.id
+WHERE m.guardian_id = @guardian_id
+  AND s.institute_id = @institute_id
+  AND m.deleted_at IS NULL
+  AND s.deleted_at IS NULL
+ORDER BY s.first_name, s.id;
+
+-- name: GetGuardianChild :one
+SELECT sqlc.embed(s), m.relationship, m.is_primary_contact
+FROM core.students s
+JOIN core.student_guardian_map m ON m.student_id = s.id
+WHERE m.guardian_id = @guardian_id
+  AND s.id = @student_id
+  AND s.institute_id = @institute_id
+  AND m.deleted_at IS NULL
+  AND s.deleted_at IS NULL;
+
+-- name: ListGuardianChildTimetable :many
+-- The timetable of the child's current class in the active session.
+SELECT t.id, t.day_of_week, t.period_id,
+       p.name AS period_name, p.start_time, p.end_time,
+       t.subject_id, sub.name AS subject_name,
+       t.teacher_id, e.first_name AS teacher_first_name, e.last_name AS teacher_last_name
+FROM core.students s
+JOIN core.student_guardian_map m ON m.student_id = s.id
+JOIN academics.timetable_entries t ON t.class_id = s.current_class_id AND t.institute_id = s.institute_id
+JOIN core.academic_sessions a ON a.id = t.academic_session_id AND a.is_active = TRUE
+LEFT JOIN academics.class_periods p ON p.id = t.period_id
+LEFT JOIN academics.subjects sub ON sub.id = t.subject_id
+LEFT JOIN core.employees e ON e.id = t.teacher_id
+WHERE m.guardian_id = @guardian_id
+  AND s.id = @student_id
+  AND s.institute_id = @institute_id
+  AND m.deleted_at IS NULL
+  AND s.deleted_at IS NULL
+  AND t.deleted_at IS NULL
+ORDER BY array_position(ARRAY['mon','tue','wed','thu','fri','sat','sun'], t.day_of_week::text), p.start_time;
+
+-- name: ListGuardianChildInvoices :many
+SELECT i.* FROM finance.invoices i
+JOIN core.student_guardian_map m ON m.student_id = i.student_id
+WHERE m.guardian_id = @guardian_id
+  AND i.student_id = @student_id
+  AND i.institute_id = @institute_id
+  AND m.deleted_at IS NULL
+  AND i.deleted_at IS NULL
+ORDER BY i.due_date DESC NULLS LAST, i.created_at DESC;
+
+-- name: ListGuardianChildExamResults :many
+-- Marks are only visible once their exam is published.
+SELECT mk.id, mk.schedule_id, mk.marks_obtained, mk.is_absent, mk.remarks,
+       ex.id AS exam_id, ex.name AS exam_name,
+       sc.exam_date, sc.max_marks, sc.min_pass_marks,
+       sc.subject_id, sub.name AS subject_name
+FROM exam.marks mk
+JOIN core.student_guardian_map m ON m.student_id = mk.student_id
+JOIN exam.schedules sc ON sc.id = mk.schedule_id
+JOIN exam.exams ex ON ex.id = sc.exam_id
+JOIN academics.subjects sub ON sub.id = sc.subject_id
+WHERE m.guardian_id = @guardian_id
+  AND mk.student_id = @student_id
+  AND mk.institute_id = @institute_id
+  AND ex.is_published = TRUE
+  AND m.deleted_at IS NULL
+  AND mk.deleted_at IS NULL
+  AND sc.deleted_at IS NULL
+  AND ex.deleted_at IS NULL
+ORDER BY sc.exam_date DESC, sub.name;
+
+-- name: ListGuardianChildAssignments :many
+SELECT a.* FROM academics.assignments a
+JOIN core.students s ON s.current_class_id = a.class_id AND s.institute_id = a.institute_id
+JOIN core.student_guardian_map m ON m.student_id = s.id
+WHERE m.guardian_id = @guardian_id
+  AND s.id = @student_id
+  AND s.institute_id = @institute_id
+  AND a.is_active = TRUE
+  AND m.deleted_at IS NULL
+  AND s.deleted_at IS NULL
+  AND a.deleted_at IS NULL
+ORDER BY a.due_date DESC NULLS LAST, a.created_at DESC;
+
+-- name: ListGuardianChildNotifications :many
+-- Notifications addressed to the child's login, newest first.
+SELECT n.* FROM comms.notifications n
+JOIN auth.users u ON u.id = n.user_id
+JOIN core.student_guardian_map m ON m.student_id = u.linked_entity_id
+WHERE m.guardian_id = @guardian_id
+  AND u.linked_entity_id = @student_id
+  AND n.institute_id = @institute_id
+  AND m.deleted_at IS NULL
+  AND n.deleted_at IS NULL
+ORDER BY n.created_at DESC
+LIMIT @row_limit;
+
+-- =========================================================
+-- ATTENDANCE
+-- Daily rows have period_id NULL; the partial unique indexes
+-- make re-marking a day or period an update.
+-- =========================================================
+
+-- name: UpsertDailyAttendance :one
+INSERT INTO academics.student_attendance (
+    institute_id, class_id, student_id, attendance_date, status, remarks, created_by
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (student_id, attendance_date) WHERE period_id IS NULL
+DO UPDATE SET
+    class_id   = EXCLUDED.class_id,
+    status     = EXCLUDED.status,
+    remarks    = EXCLUDED.remarks,
+    updated_at = NOW(),
+    updated_by = EXCLUDED.created_by
+RETURNING *;
+
+-- name: UpsertPeriodAttendance :one
+INSERT INTO academics.student_attendance (
+    institute_id, class_id, student_id, attendance_date, period_id, status, remarks, created_by
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (student_id, attendance_date, period_id) WHERE period_id IS NOT NULL
+DO UPDATE SET
+    class_id   = EXCLUDED.class_id,
+    status     = EXCLUDED.status,
+    remarks    = EXCLUDED.remarks,
+    updated_at = NOW(),
+    updated_by = EXCLUDED.created_by
+RETURNING *;
+
+-- name: ListClassAttendance :many
+SELECT * FROM academics.student_attendance
+WHERE institute_id = @institute_id
+  AND class_id = @class_id
+  AND attendance_date = @attendance_date
+  AND period_id IS NOT DISTINCT FROM sqlc.narg(period_id);
+
+-- name: ListStudentDailyAttendance :many
+SELECT * FROM academics.student_attendance
+WHERE institute_id = @institute_id
+  AND student_id = @student_id
+  AND period_id IS NULL
+  AND attendance_date BETWEEN @from_date AND @to_date
+ORDER BY attendance_date;
+
+-- name: SummariseStudentAttendance :one
+SELECT
+    COUNT(*) FILTER (WHERE status = 'present')  AS present,
+    COUNT(*) FILTER (WHERE status = 'absent')   AS absent,
+    COUNT(*) FILTER (WHERE status = 'late')     AS late,
+    COUNT(*) FILTER (WHERE status = 'half_day') AS half_day,
+    COUNT(*) FILTER (WHERE status = 'on_leave') AS on_leave
+FROM academics.student_attendance
+WHERE institute_id = @institute_id
+  AND student_id = @student_id
+  AND period_id IS NULL
+  AND attendance_date BETWEEN @from_date AND @to_date;
+
+-- name: SummariseClassAttendance :many
+SELECT student_id,
+    COUNT(*) FILTER (WHERE status = 'present')  AS present,
+    COUNT(*) FILTER (WHERE status = 'absent')   AS absent,
+    COUNT(*) FILTER (WHERE status = 'late')     AS late,
+    COUNT(*) FILTER (WHERE status = 'half_day') AS half_day,
+    COUNT(*) FILTER (WHERE status = 'on_leave') AS on_leave
+FROM academics.student_attendance
+WHERE institute_id = @institute_id
+  AND class_id = @class_id
+  AND period_id IS NULL
+  AND attendance_date BETWEEN @from_date AND @to_date
+GROUP BY student_id;
+
+-- name: CountClassAttendanceDays :one
+-- Days on which the class register was taken.
+SELECT COUNT(DISTINCT attendance_date) FROM academics.student_attendance
+WHERE institute_id = @institute_id
+  AND class_id = @class_id
+  AND period_id IS NULL
+  AND attendance_date BETWEEN @from_date AND @to_date;
+
+-- name: ListStudentHolidays :many
+-- Holidays from the institute calendar that apply to students.
+SELECT * FROM core.calendar_events
+WHERE institute_id = @institute_id
+  AND is_holiday = TRUE
+  AND deleted_at IS NULL
+  AND start_date <= @to_date
+  AND end_date >= @from_date
+  AND (target_audience IS NULL OR target_audience IN ('all', 'student'))
+ORDER BY start_date;
+
+-- =========================================================
+-- FINANCE: GENERAL LEDGER
+-- Balances only count items of posted entries. Entries are
+-- locked with FOR UPDATE before they are posted or reversed.
+-- =========================================================
+
+-- name: CreateAccount :one
+INSERT INTO finance.accounts (
+    institute_id, name, code, parent_account_id, type, is_system, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetAccount :one
+SELECT * FROM finance.accounts
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: ListAccounts :many
+SELECT * FROM finance.accounts
+WHERE institute_id = $1 AND deleted_at IS NULL
+ORDER BY code;
+
+-- name: GetPostingAccounts :many
+-- Locks the accounts a journal posts to; has_children marks parent
+-- accounts, which only carry the balances of their sub-accounts.
+SELECT sqlc.embed(a),
+    EXISTS (
+        SELECT 1 FROM finance.accounts c
+        WHERE c.parent_account_id = a.id AND c.deleted_at IS NULL
+    ) AS has_children
+FROM finance.accounts a
+WHERE a.institute_id = @institute_id
+  AND a.id = ANY(@account_ids::uuid[])
+  AND a.deleted_at IS NULL
+FOR SHARE OF a;
+
+-- name: CreateJournalEntry :one
+INSERT INTO finance.journal_entries (
+    institute_id, reference_no, transaction_date, description, is_posted, created_by, reversal_of_id,
+    source_type, source_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetJournalEntry :one
+SELECT * FROM finance.journal_entries
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: GetJournalEntryForUpdate :one
+SELECT * FROM finance.journal_entries
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: JournalEntryIsReversed :one
+SELECT EXISTS (
+    SELECT 1 FROM finance.journal_entries
+    WHERE reversal_of_id = @id AND deleted_at IS NULL
+);
+
+-- name: MarkJournalEntryPosted :one
+UPDATE finance.journal_entries
+SET is_posted = TRUE, posted_at = NOW(), updated_at = NOW(), updated_by = @posted_by
+WHERE id = @id AND institute_id = @institute_id AND is_posted = FALSE
+RETURNING *;
+
+-- name: CreateJournalItem :one
+INSERT INTO finance.journal_items (
+    institute_id, journal_entry_id, account_id, debit, credit, description, created_by, department_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: ListJournalItems :many
+SELECT * FROM finance.journal_items
+WHERE journal_entry_id = @journal_entry_id AND institute_id = @institute_id AND deleted_at IS NULL
+ORDER BY created_at, id;
+
+-- name: SumPostedJournalItemsByAccount :many
+SELECT i.account_id,
+    COALESCE(SUM(i.debit), 0)::numeric AS total_debit,
+    COALESCE(SUM(i.credit), 0)::numeric AS total_credit
+FROM finance.journal_items i
+JOIN finance.journal_entries e ON e.id = i.journal_entry_id
+WHERE i.institute_id = @institute_id
+  AND i.deleted_at IS NULL
+  AND e.is_posted = TRUE
+  AND e.deleted_at IS NULL
+GROUP BY i.account_id;
+
+-- =========================================================
+-- FINANCE: STATEMENTS AND YEAR-END CLOSE
+-- Posting takes a share lock on the institute row and closing
+-- a year takes a conflicting one, so nothing can be posted
+-- into a year while it is being closed.
+-- =========================================================
+
+-- name: GetInstituteFiscalYearStart :one
+SELECT fiscal_year_start_month FROM core.institutes
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: SumPostedJournalItemsInRange :many
+-- A NULL from_date sums from the first entry. Closing entries are
+-- left out of income statements so that closed years still report.
+SELECT i.account_id,
+    COALESCE(SUM(i.debit), 0)::numeric AS total_debit,
+    COALESCE(SUM(i.credit), 0)::numeric AS total_credit
+FROM finance.journal_items i
+JOIN finance.journal_entries e ON e.id = i.journal_entry_id
+WHERE i.institute_id = @institute_id
+  AND i.deleted_at IS NULL
+  AND e.is_posted = TRUE
+  AND e.deleted_at IS NULL
+  AND (sqlc.narg(from_date)::date IS NULL OR e.transaction_date::date >= sqlc.narg(from_date)::date)
+  AND e.transaction_date::date <= @to_date::date
+  AND (@include_closing::boolean OR NOT EXISTS (
+      SELECT 1 FROM finance.fiscal_year_closings c WHERE c.journal_entry_id = e.id
+  ))
+GROUP BY i.account_id;
+
+-- name: ListStatementAccounts :many
+-- The chart of accounts as statements need it: live accounts, and deleted
+-- ones that still have posted items in the range. A NULL from_date reads
+-- from the first entry.
+SELECT * FROM finance.accounts a
+WHERE a.institute_id = @institute_id
+  AND (a.deleted_at IS NULL OR EXISTS (
+      SELECT 1
+      FROM finance.journal_items i
+      JOIN finance.journal_entries e ON e.id = i.journal_entry_id
+      WHERE i.account_id = a.id
+        AND i.deleted_at IS NULL
+        AND e.is_posted = TRUE
+        AND e.deleted_at IS NULL
+        AND (sqlc.narg(from_date)::date IS NULL OR e.transaction_date::date >= sqlc.narg(from_date)::date)
+        AND e.transaction_date::date <= @to_date::date
+  ))
+ORDER BY a.code;
+
+-- name: LockLedgerForPosting :exec
+SELECT id FROM core.institutes WHERE id = $1 FOR SHARE;
+
+-- name: LockLedgerForClosing :exec
+SELECT id FROM core.institutes WHERE id = $1 FOR NO KEY UPDATE;
+
+-- name: GetLastClosedFiscalYearEnd :one
+SELECT fiscal_year_end FROM finance.fiscal_year_closings
+WHERE institute_id = $1
+ORDER BY fiscal_year_end DESC
+LIMIT 1;
+
+-- name: CreateFiscalYearClosing :one
+INSERT INTO finance.fiscal_year_closings (
+    institute_id, fiscal_year_start, fiscal_year_end, journal_entry_id,
+    retained_earnings_account_id, net_income, closed_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: ListFiscalYearClosings :many
+SELECT * FROM finance.fiscal_year_closings
+WHERE institute_id = $1
+ORDER BY fiscal_year_start DESC;
+
+-- =========================================================
+-- FINANCE: AUTOMATIC POSTINGS
+-- Billing, payments, refunds and purchase receipts write their
+-- documents and post the matching journal in one transaction.
+-- =========================================================
+
+-- name: GetLedgerSettings :one
+SELECT * FROM finance.ledger_settings
+WHERE institute_id = $1;
+
+-- name: UpsertLedgerSettings :one
+INSERT INTO finance.ledger_settings (
+    institute_id, receivables_account_id, cash_account_id, bank_account_id,
+    payables_account_id, purchases_account_id, concessions_account_id,
+    student_advances_account_id, updated_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (institute_id) DO UPDATE SET
+    receivables_account_id = EXCLUDED.receivables_account_id,
+    cash_account_id = EXCLUDED.cash_account_id,
+    bank_account_id = EXCLUDED.bank_account_id,
+    payables_account_id = EXCLUDED.payables_account_id,
+    purchases_account_id = EXCLUDED.purchases_account_id,
+    concessions_account_id = EXCLUDED.concessions_account_id,
+    student_advances_account_id = EXCLUDED.student_advances_account_id,
+    updated_at = NOW(),
+    updated_by = EXCLUDED.updated_by
+RETURNING *;
+
+-- name: ListJournalEntriesBySource :many
+SELECT * FROM finance.journal_entries
+WHERE institute_id = @institute_id
+  AND source_type = @source_type
+  AND source_id = @source_id
+  AND deleted_at IS NULL
+ORDER BY created_at, id;
+
+-- name: GetFeeHeadsByIDs :many
+SELECT * FROM finance.fee_heads
+WHERE institute_id = @institute_id
+  AND id = ANY(@ids::uuid[])
+  AND deleted_at IS NULL;
+
+-- name: CreateInvoice :one
+INSERT INTO finance.invoices (
+    institute_id, invoice_no, student_id, academic_session_id, total_amount,
+    discount_amount, due_date, status, created_by, billing_period
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+RETURNING *;
+
+-- name: CreateInvoiceItem :one
+INSERT INTO finance.invoice_items (
+    institute_id, invoice_id, fee_head_id, amount, concession_id, discount_applied,
+    description, created_by, fee_structure_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetInvoice :one
+SELECT * FROM finance.invoices
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: GetInvoiceForUpdate :one
+SELECT * FROM finance.invoices
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: UpdateInvoiceAmounts :one
+UPDATE finance.invoices
+SET total_amount = @total_amount,
+    discount_amount = @discount_amount,
+    fine_amount = @fine_amount,
+    paid_amount = @paid_amount,
+    status = @status,
+    updated_at = NOW(),
+    updated_by = @updated_by
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: ListInvoiceItems :many
+SELECT * FROM finance.invoice_items
+WHERE invoice_id = @invoice_id AND institute_id = @institute_id AND deleted_at IS NULL
+ORDER BY created_at, id;
+
+-- name: CreateTransaction :one
+INSERT INTO finance.transactions (
+    institute_id, invoice_id, student_id, transaction_ref_no, payment_mode, amount,
+    cheque_no, cheque_date, bank_name, is_wallet_usage, collected_by, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+RETURNING *;
+
+-- name: GetRefundForUpdate :one
+SELECT * FROM finance.refunds
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: MarkRefundProcessed :one
+UPDATE finance.refunds
+SET status = 'processed', refund_date = NOW(), processed_by = @processed_by, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id AND status IN ('approved', 'processing')
+RETURNING *;
+
+-- name: CreateVendor :one
+INSERT INTO finance.vendors (
+    institute_id, name, contact_name, phone, email, address, gst_number, created_by, state_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: ListVendors :many
+SELECT * FROM finance.vendors
+WHERE institute_id = $1 AND deleted_at IS NULL
+ORDER BY name;
+
+-- name: CreatePurchaseOrder :one
+INSERT INTO finance.purchase_orders (
+    institute_id, vendor_id, order_date, created_by, department_id, expense_account_id, requisition_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: AddPurchaseItem :one
+INSERT INTO finance.purchase_order_items (
+    institute_id, purchase_order_id, item_id, quantity, unit_price, tax_id, tax_amount, total_amount,
+    requisition_item_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetPurchaseOrderForUpdate :one
+SELECT * FROM finance.purchase_orders
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: RefreshPurchaseOrderTotal :one
+UPDATE finance.purchase_orders
+SET total_amount = (
+        SELECT COALESCE(SUM(i.total_amount), 0) FROM finance.purchase_order_items i
+        WHERE i.purchase_order_id = @id AND i.deleted_at IS NULL
+    ),
+    updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: UpdatePurchaseOrderStatus :one
+UPDATE finance.purchase_orders
+SET status = @status, updated_at = NOW(), updated_by = @updated_by
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- =========================================================
+-- FINANCE: INVOICE GENERATION
+-- =========================================================
+
+-- name: NextInvoiceNumber :one
+-- Locks the institute's sequence row until the transaction ends.
+INSERT INTO finance.invoice_sequences (institute_id, last_no)
+VALUES (@institute_id, 1)
+ON CONFLICT (institute_id) DO UPDATE
+SET last_no = finance.invoice_sequences.last_no + 1
+RETURNING last_no;
+
+-- name: GetAcademicSession :one
+SELECT * FROM core.academic_sessions
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: ListStudentsForBilling :many
+SELECT * FROM core.students
+WHERE institute_id = @institute_id
+  AND is_active = TRUE
+  AND deleted_at IS NULL
+  AND (sqlc.narg(class_id)::uuid IS NULL OR current_class_id = sqlc.narg(class_id))
+ORDER BY admission_no;
+
+-- name: ListBilledFeeStructures :many
+-- Generated items of the given students, with the period they billed.
+SELECT i.student_id, i.billing_period, it.fee_structure_id, it.fee_head_id
+FROM finance.invoice_items it
+JOIN finance.invoices i ON i.id = it.invoice_id
+WHERE i.institute_id = @institute_id
+  AND i.student_id = ANY(@student_ids::uuid[])
+  AND i.billing_period IS NOT NULL
+  AND it.fee_structure_id IS NOT NULL
+  AND i.deleted_at IS NULL
+  AND it.deleted_at IS NULL;
+
+-- name: ListStudentInvoices :many
+SELECT * FROM finance.invoices
+WHERE institute_id = @institute_id AND student_id = @student_id AND deleted_at IS NULL
+ORDER BY billing_period DESC NULLS LAST, created_at DESC;
+
+-- =========================================================
+-- FINANCE: CONCESSIONS
+-- =========================================================
+
+-- name: CreateConcession :one
+INSERT INTO finance.concessions (
+    institute_id, name, type, value, fee_head_ids, sibling_rank, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetConcession :one
+SELECT * FROM finance.concessions
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: ListConcessions :many
+SELECT * FROM finance.concessions
+WHERE institute_id = $1 AND deleted_at IS NULL
+ORDER BY name;
+
+-- name: ListSiblingConcessions :many
+SELECT * FROM finance.concessions
+WHERE institute_id = $1
+  AND sibling_rank IS NOT NULL
+  AND is_active = TRUE
+  AND deleted_at IS NULL;
+
+-- name: CreateStudentConcession :one
+INSERT INTO finance.student_concessions (
+    institute_id, student_id, concession_id, valid_from, valid_to, remarks, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetStudentConcessionForUpdate :one
+SELECT * FROM finance.student_concessions
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: UpdateStudentConcessionStatus :one
+UPDATE finance.student_concessions
+SET status = @status, updated_at = NOW(), updated_by = @updated_by
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: ListStudentConcessions :many
+SELECT * FROM finance.student_concessions
+WHERE institute_id = @institute_id AND student_id = @student_id AND deleted_at IS NULL
+ORDER BY valid_from DESC, created_at DESC;
+
+-- name: ListApprovedStudentConcessions :many
+-- Approved concessions of the students whose validity overlaps the period.
+SELECT sc.student_id, sqlc.embed(c)
+FROM finance.student_concessions sc
+JOIN finance.concessions c ON c.id = sc.concession_id
+WHERE sc.institute_id = @institute_id
+  AND sc.student_id = ANY(@student_ids::uuid[])
+  AND sc.status = 'approved'
+  AND sc.valid_from <= @period_end::date
+  AND (sc.valid_to IS NULL OR sc.valid_to >= @period_start::date)
+  AND sc.deleted_at IS NULL
+  AND c.is_active = TRUE
+  AND c.deleted_at IS NULL;
+
+-- name: ListSiblingRanks :many
+-- A student's rank is one more than the number of active students sharing
+-- an active guardian with them who were admitted before them.
+SELECT s.id AS student_id, (1 + COUNT(DISTINCT sib.id))::int AS sibling_rank
+FROM core.students s
+LEFT JOIN core.student_guardian_map m
+       ON m.student_id = s.id AND m.is_active = TRUE AND m.deleted_at IS NULL
+LEFT JOIN core.student_guardian_map sm
+       ON sm.guardian_id = m.guardian_id AND sm.student_id <> s.id
+      AND sm.is_active = TRUE AND sm.deleted_at IS NULL
+LEFT JOIN core.students sib
+       ON sib.id = sm.student_id AND sib.institute_id = s.institute_id
+      AND sib.is_active = TRUE AND sib.deleted_at IS NULL
+      AND (sib.created_at, sib.id) < (s.created_at, s.id)
+WHERE s.institute_id = @institute_id
+  AND s.id = ANY(@student_ids::uuid[])
+GROUP BY s.id;
+
+-- name: CreateApproval :one
+INSERT INTO core.approvals (institute_id, module, reference_id, status)
+VALUES (@institute_id, @module, @reference_id, 'pending')
+RETURNING *;
+
+-- name: DecideApproval :one
+UPDATE core.approvals
+SET status = @status, approver_id = @approver_id, remarks = @remarks,
+    approved_at = NOW(), updated_at = NOW()
+WHERE institute_id = @institute_id
+  AND module = @module
+  AND reference_id = @reference_id
+  AND status = 'pending'
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- =========================================================
+-- FINANCE: LATE FEES
+-- =========================================================
+
+-- name: SetFineRuleTerms :one
+UPDATE finance.fine_rules
+SET fee_head_id = @fee_head_id, max_fine_amount = @max_fine_amount, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: GetFineRule :one
+SELECT * FROM finance.fine_rules
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: ListActiveFineRules :many
+SELECT * FROM finance.fine_rules
+WHERE institute_id = $1
+  AND is_active = TRUE
+  AND fee_head_id IS NOT NULL
+  AND deleted_at IS NULL
+ORDER BY created_at;
+
+-- name: ListInstitutesWithFineRules :many
+SELECT DISTINCT institute_id FROM finance.fine_rules
+WHERE is_active = TRUE AND fee_head_id IS NOT NULL AND deleted_at IS NULL;
+
+-- name: ListOverdueInvoices :many
+SELECT * FROM finance.invoices
+WHERE institute_id = @institute_id
+  AND due_date < @as_of::date
+  AND COALESCE(status, 'pending') <> 'paid'
+  AND deleted_at IS NULL
+ORDER BY due_date, invoice_no;
+
+-- name: SumInvoiceFines :many
+SELECT fine_rule_id, SUM(amount)::text AS amount
+FROM finance.invoice_fines
+WHERE invoice_id = @invoice_id AND institute_id = @institute_id
+GROUP BY fine_rule_id;
+
+-- name: SumInvoiceFineWaivers :many
+SELECT fine_rule_id, SUM(amount)::text AS amount
+FROM finance.invoice_fine_waivers
+WHERE invoice_id = @invoice_id AND institute_id = @institute_id
+GROUP BY fine_rule_id;
+
+-- name: CreateInvoiceFine :one
+INSERT INTO finance.invoice_fines (
+    institute_id, invoice_id, fine_rule_id, assessed_on, days_overdue, amount, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: CreateInvoiceFineWaiver :one
+INSERT INTO finance.invoice_fine_waivers (
+    institute_id, invoice_id, fine_rule_id, amount, reason, approved_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: ListInvoiceFines :many
+SELECT * FROM finance.invoice_fines
+WHERE invoice_id = @invoice_id AND institute_id = @institute_id
+ORDER BY assessed_on, created_at;
+
+-- name: ListInvoiceFineWaivers :many
+SELECT * FROM finance.invoice_fine_waivers
+WHERE invoice_id = @invoice_id AND institute_id = @institute_id
+ORDER BY created_at;
+
+-- =========================================================
+-- FINANCE: ONLINE PAYMENTS
+-- =========================================================
+
+-- name: GetPaymentGateway :one
+SELECT * FROM finance.payment_gateways
+WHERE institute_id = $1;
+
+-- name: UpsertPaymentGateway :one
+INSERT INTO finance.payment_gateways (
+    institute_id, gateway, key_id, secret_sealed, merchant_id,
+    merchant_key_sealed, environment, is_active, updated_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (institute_id) DO UPDATE SET
+    gateway = EXCLUDED.gateway,
+    key_id = EXCLUDED.key_id,
+    secret_sealed = EXCLUDED.secret_sealed,
+    merchant_id = EXCLUDED.merchant_id,
+    merchant_key_sealed = EXCLUDED.merchant_key_sealed,
+    environment = EXCLUDED.environment,
+    is_active = EXCLUDED.is_active,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetGuardianInvoice :one
+-- The invoice if the login is an active guardian of its student.
+SELECT i.* FROM finance.invoices i
+JOIN core.student_guardian_map m ON m.student_id = i.student_id
+JOIN auth.users u ON u.linked_entity_id = m.guardian_id
+WHERE u.id = @user_id
+  AND u.role_type = 'guardian'
+  AND u.is_active = TRUE
+  AND u.deleted_at IS NULL
+  AND i.id = @invoice_id
+  AND i.institute_id = @institute_id
+  AND m.deleted_at IS NULL
+  AND i.deleted_at IS NULL;
+
+-- name: CreateOnlinePayment :one
+INSERT INTO finance.online_payments (
+    institute_id, invoice_id, student_id, gateway, order_ref, amount, initiated_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: SetOnlinePaymentGatewayOrder :one
+UPDATE finance.online_payments
+SET gateway_order_id = @gateway_order_id, updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- name: GetOnlinePayment :one
+SELECT * FROM finance.online_payments
+WHERE id = @id AND institute_id = @institute_id;
+
+-- name: GetOnlinePaymentByRef :one
+SELECT * FROM finance.online_payments
+WHERE order_ref = $1;
+
+-- name: GetOnlinePaymentByRefForUpdate :one
+SELECT * FROM finance.online_payments
+WHERE order_ref = $1
+FOR UPDATE;
+
+-- name: SettleOnlinePayment :one
+UPDATE finance.online_payments
+SET status = @status,
+    gateway_payment_id = @gateway_payment_id,
+    transaction_id = @transaction_id,
+    updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- =========================================================
+-- FINANCE: RECEIPTS AND CHEQUES
+-- =========================================================
+
+-- name: NextReceiptNumber :one
+-- Locks the institute's sequence row for the year until the transaction ends.
+INSERT INTO finance.receipt_sequences (institute_id, fiscal_year, last_no)
+VALUES (@institute_id, @fiscal_year, 1)
+ON CONFLICT (institute_id, fiscal_year) DO UPDATE
+SET last_no = finance.receipt_sequences.last_no + 1
+RETURNING last_no;
+
+-- name: CreateReceipt :one
+INSERT INTO finance.receipts (
+    institute_id, receipt_no, fiscal_year, receipt_date, student_id, payment_mode, amount,
+    transaction_ref_no, cheque_no, cheque_date, bank_name, cheque_status, remarks,
+    collected_by, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+)
+RETURNING *;
+
+-- name: GetReceipt :one
+SELECT * FROM finance.receipts
+WHERE id = @id AND institute_id = @institute_id;
+
+-- name: GetReceiptForUpdate :one
+SELECT * FROM finance.receipts
+WHERE id = @id AND institute_id = @institute_id
+FOR UPDATE;
+
+-- name: ListStudentReceipts :many
+SELECT * FROM finance.receipts
+WHERE institute_id = @institute_id AND student_id = @student_id
+ORDER BY receipt_date DESC, receipt_no DESC;
+
+-- name: ListPendingCheques :many
+SELECT * FROM finance.receipts
+WHERE institute_id = $1 AND cheque_status = 'pending'
+ORDER BY cheque_date, receipt_no;
+
+-- name: SetReceiptChequeStatus :one
+UPDATE finance.receipts
+SET cheque_status = @cheque_status, bounce_reason = @bounce_reason, cheque_settled_at = NOW()
+WHERE id = @id AND institute_id = @institute_id AND cheque_status = 'pending'
+RETURNING *;
+
+-- name: AttachTransactionReceipt :one
+UPDATE finance.transactions
+SET receipt_id = @receipt_id, cheque_status = @cheque_status
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: ListReceiptTransactions :many
+SELECT * FROM finance.transactions
+WHERE receipt_id = @receipt_id AND institute_id = @institute_id AND deleted_at IS NULL
+ORDER BY created_at, id;
+
+-- name: SetReceiptTransactionsChequeStatus :exec
+UPDATE finance.transactions
+SET cheque_status = @cheque_status,
+    status = COALESCE(sqlc.narg(status), status),
+    updated_at = NOW(),
+    updated_by = @updated_by
+WHERE receipt_id = @receipt_id AND institute_id = @institute_id;
+
+-- =========================================================
+-- FINANCE: STUDENT WALLETS
+-- =========================================================
+
+-- name: LockStudentWallet :one
+-- Opens the wallet on first use. Either way the row stays locked until the
+-- transaction ends. No row comes back for a student of another institute.
+INSERT INTO finance.student_wallets (institute_id, student_id, balance)
+SELECT s.institute_id, s.id, 0
+FROM core.students s
+WHERE s.id = @student_id AND s.institute_id = @institute_id
+ON CONFLICT (institute_id, student_id) DO UPDATE
+SET updated_at = finance.student_wallets.updated_at
+RETURNING *;
+
+-- name: GetStudentWallet :one
+SELECT * FROM finance.student_wallets
+WHERE institute_id = @institute_id AND student_id = @student_id;
+
+-- name: GetAutoApplyWalletForUpdate :one
+SELECT * FROM finance.student_wallets
+WHERE institute_id = @institute_id AND student_id = @student_id
+  AND auto_apply AND balance > 0 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: SetWalletBalance :one
+UPDATE finance.student_wallets
+SET balance = @balance, updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- name: SetWalletAutoApply :one
+INSERT INTO finance.student_wallets (institute_id, student_id, balance, auto_apply)
+SELECT s.institute_id, s.id, 0, @auto_apply::boolean
+FROM core.students s
+WHERE s.id = @student_id AND s.institute_id = @institute_id
+ON CONFLICT (institute_id, student_id) DO UPDATE
+SET auto_apply = EXCLUDED.auto_apply, updated_at = NOW()
+RETURNING *;
+
+-- name: CreateWalletEntry :one
+INSERT INTO finance.wallet_entries (
+    institute_id, wallet_id, student_id, entry_type, purpose, amount, balance_after,
+    payment_mode, reference_no, invoice_id, transaction_id, fee_head_id, remarks, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+)
+RETURNING *;
+
+-- name: ListWalletEntries :many
+SELECT * FROM finance.wallet_entries
+WHERE institute_id = @institute_id AND student_id = @student_id
+ORDER BY created_at DESC, id DESC;
+
+-- =========================================================
+-- FINANCE: REFUND WORKFLOW
+-- =========================================================
+
+-- name: GetRefundSettings :one
+SELECT * FROM finance.refund_settings
+WHERE institute_id = $1;
+
+-- name: UpsertRefundSettings :one
+INSERT INTO finance.refund_settings (institute_id, approval_steps, second_step_above, updated_by)
+VALUES (@institute_id, @approval_steps, @second_step_above, @updated_by)
+ON CONFLICT (institute_id) DO UPDATE
+SET approval_steps = EXCLUDED.approval_steps,
+    second_step_above = EXCLUDED.second_step_above,
+    updated_at = NOW(),
+    updated_by = EXCLUDED.updated_by
+RETURNING *;
+
+-- name: CreateRefund :one
+INSERT INTO finance.refunds (
+    institute_id, student_id, invoice_id, amount, reason, status, approvals_required, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, 'requested', $6, $7
+)
+RETURNING *;
+
+-- name: GetRefund :one
+SELECT * FROM finance.refunds
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: ListRefunds :many
+SELECT * FROM finance.refunds
+WHERE institute_id = @institute_id
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+  AND deleted_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: SumOpenInvoiceRefunds :one
+-- Refunds of an invoice that are processed or still on their way.
+SELECT COALESCE(SUM(amount), 0)::text AS amount
+FROM finance.refunds
+WHERE invoice_id = @invoice_id AND institute_id = @institute_id
+  AND status IN ('requested', 'approved', 'processing', 'processed')
+  AND deleted_at IS NULL;
+
+-- name: SumOpenWalletRefunds :one
+-- Wallet refunds of a student that are not yet paid out.
+SELECT COALESCE(SUM(amount), 0)::text AS amount
+FROM finance.refunds
+WHERE student_id = @student_id AND institute_id = @institute_id
+  AND invoice_id IS NULL
+  AND status IN ('requested', 'approved', 'processing')
+  AND deleted_at IS NULL;
+
+-- name: RecordRefundApproval :one
+UPDATE finance.refunds
+SET approvals_received = approvals_received + 1,
+    status = CASE WHEN approvals_received + 1 >= approvals_required THEN 'approved' ELSE status END,
+    updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id AND status = 'requested'
+RETURNING *;
+
+-- name: RejectRefund :one
+UPDATE finance.refunds
+SET status = 'rejected', updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id AND status = 'requested'
+RETURNING *;
+
+-- name: SetRefundPayout :one
+UPDATE finance.refunds
+SET payout_mode = @payout_mode, payout_reference = @payout_reference,
+    online_payment_id = @online_payment_id, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: ClaimGatewayRefund :one
+-- Holds an approved refund while the gateway pays it out.
+UPDATE finance.refunds
+SET status = 'processing', payout_mode = 'gateway',
+    online_payment_id = @online_payment_id, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id AND status = 'approved'
+RETURNING *;
+
+-- name: ReleaseGatewayRefund :exec
+UPDATE finance.refunds
+SET status = 'approved', payout_mode = NULL, online_payment_id = NULL, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id AND status = 'processing';
+
+-- name: CreateApprovalStep :one
+INSERT INTO core.approvals (institute_id, module, reference_id, status, step)
+VALUES (@institute_id, @module, @reference_id, 'pending', @step)
+RETURNING *;
+
+-- name: ListApprovals :many
+SELECT * FROM core.approvals
+WHERE institute_id = @institute_id AND module = @module AND reference_id = @reference_id
+  AND deleted_at IS NULL
+ORDER BY step, created_at;
+
+-- name: GetRefundableOnlinePayment :one
+-- The latest paid checkout of an invoice with enough left to refund.
+SELECT op.* FROM finance.online_payments op
+WHERE op.invoice_id = @invoice_id AND op.institute_id = @institute_id
+  AND op.status = 'paid'
+  AND op.amount - COALESCE((
+        SELECT SUM(r.amount) FROM finance.refunds r
+        WHERE r.online_payment_id = op.id AND r.status IN ('processing', 'processed')
+      ), 0) >= @amount::numeric
+ORDER BY op.created_at DESC
+LIMIT 1
+FOR UPDATE OF op;
+
+-- name: AddInvoiceRefund :exec
+UPDATE finance.invoices
+SET refunded_amount = refunded_amount + @amount::numeric, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id;
+
+-- =========================================================
+-- FINANCE: BANK STATEMENT IMPORT AND RECONCILIATION
+-- Fee transactions reach the bank unless they were paid in
+-- cash, out of a wallet, or by a cheque that bounced.
+-- =========================================================
+
+-- name: CreateBankAccount :one
+INSERT INTO finance.bank_accounts (
+    institute_id, account_name, account_number, bank_name, ifsc_code, branch_name,
+    opening_balance, current_balance, ledger_account_id, is_active, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $7, $8, TRUE, $9
+)
+RETURNING *;
+
+-- name: GetBankAccount :one
+SELECT * FROM finance.bank_accounts
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: LockBankAccount :one
+SELECT * FROM finance.bank_accounts
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: ListBankAccounts :many
+SELECT * FROM finance.bank_accounts
+WHERE institute_id = $1 AND deleted_at IS NULL
+ORDER BY account_name;
+
+-- name: SetBankAccountBalance :exec
+UPDATE finance.bank_accounts
+SET current_balance = @current_balance, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id;
+
+-- name: CreateBankStatementImport :one
+INSERT INTO finance.bank_statement_imports (
+    institute_id, bank_account_id, format, file_name, lines_total, created_by
+) VALUES (
+    @institute_id, @bank_account_id, @format, @file_name, @lines_total, @created_by
+)
+RETURNING *;
+
+-- name: SetBankStatementImportCounts :one
+UPDATE finance.bank_statement_imports
+SET lines_imported = @lines_imported, lines_duplicate = @lines_duplicate, lines_matched = @lines_matched
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: ListBankStatementImports :many
+SELECT * FROM finance.bank_statement_imports
+WHERE bank_account_id = @bank_account_id AND institute_id = @institute_id
+ORDER BY created_at DESC;
+
+-- name: CreateBankStatementEntry :one
+-- Returns no row when the line is already in.
+INSERT INTO finance.bank_statement_entries (
+    institute_id, bank_account_id, import_id, line_no, transaction_date, value_date,
+    description, reference, withdrawal_amount, deposit_amount, balance, line_hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+ON CONFLICT (bank_account_id, line_hash) WHERE line_hash IS NOT NULL AND deleted_at IS NULL
+DO NOTHING
+RETURNING *;
+
+-- name: ListBankStatementEntries :many
+-- In statement order. NULL dates leave the range open.
+SELECT e.* FROM finance.bank_statement_entries e
+LEFT JOIN finance.bank_statement_imports i ON i.id = e.import_id
+WHERE e.bank_account_id = @bank_account_id AND e.institute_id = @institute_id
+  AND (sqlc.narg(from_date)::date IS NULL OR e.transaction_date::date >= sqlc.narg(from_date)::date)
+  AND (sqlc.narg(to_date)::date IS NULL OR e.transaction_date::date <= sqlc.narg(to_date)::date)
+  AND (sqlc.narg(is_reconciled)::boolean IS NULL OR COALESCE(e.is_reconciled, FALSE) = sqlc.narg(is_reconciled))
+  AND e.deleted_at IS NULL
+ORDER BY e.transaction_date, i.created_at, e.line_no;
+
+-- name: GetBankStatementEntryForUpdate :one
+SELECT * FROM finance.bank_statement_entries
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: ReconcileBankStatementEntry :one
+UPDATE finance.bank_statement_entries
+SET is_reconciled = TRUE, reconciled_transaction_id = @transaction_id,
+    match_method = @match_method, match_remarks = @match_remarks,
+    matched_at = NOW(), matched_by = @matched_by, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+  AND COALESCE(is_reconciled, FALSE) = FALSE
+RETURNING *;
+
+-- name: UnreconcileBankStatementEntry :one
+UPDATE finance.bank_statement_entries
+SET is_reconciled = FALSE, reconciled_transaction_id = NULL,
+    match_method = NULL, match_remarks = NULL,
+    matched_at = NULL, matched_by = NULL, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+  AND is_reconciled = TRUE
+RETURNING *;
+
+-- name: ListUnreconciledBankTransactions :many
+-- Fee transactions paid between the dates that no statement line
+-- has been matched to yet.
+SELECT t.* FROM finance.transactions t
+WHERE t.institute_id = @institute_id
+  AND COALESCE(t.payment_date, t.created_at)::date BETWEEN @from_date::date AND @to_date::date
+  AND COALESCE(t.is_wallet_usage, FALSE) = FALSE
+  AND t.payment_mode <> 'cash'
+  AND COALESCE(t.cheque_status, '') <> 'bounced'
+  AND t.deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM finance.bank_statement_entries e
+      WHERE e.reconciled_transaction_id = t.id AND e.deleted_at IS NULL
+  )
+ORDER BY COALESCE(t.payment_date, t.created_at), t.id;
+
+-- name: GetBankTransactionForMatch :one
+-- The transaction when it reaches the bank and is not matched yet.
+SELECT t.* FROM finance.transactions t
+WHERE t.id = @id AND t.institute_id = @institute_id
+  AND COALESCE(t.is_wallet_usage, FALSE) = FALSE
+  AND t.payment_mode <> 'cash'
+  AND COALESCE(t.cheque_status, '') <> 'bounced'
+  AND t.deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM finance.bank_statement_entries e
+      WHERE e.reconciled_transaction_id = t.id AND e.deleted_at IS NULL
+  );
+
+-- name: ListBankTransactionsInTransit :many
+-- Fee transactions paid by the date that no statement line up to
+-- that date has been matched to.
+SELECT t.* FROM finance.transactions t
+WHERE t.institute_id = @institute_id
+  AND COALESCE(t.payment_date, t.created_at)::date <= @as_of::date
+  AND COALESCE(t.is_wallet_usage, FALSE) = FALSE
+  AND t.payment_mode <> 'cash'
+  AND COALESCE(t.cheque_status, '') <> 'bounced'
+  AND t.deleted_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM finance.bank_statement_entries e
+      WHERE e.reconciled_transaction_id = t.id
+        AND e.transaction_date::date <= @as_of::date
+        AND e.deleted_at IS NULL
+  )
+ORDER BY COALESCE(t.payment_date, t.created_at), t.id;
+
+-- name: ListOutstandingStatementEntries :many
+-- Statement lines up to the date that the books had not caught up
+-- with by then: unreconciled, or matched to a later payment.
+SELECT e.* FROM finance.bank_statement_entries e
+LEFT JOIN finance.transactions t ON t.id = e.reconciled_transaction_id
+WHERE e.bank_account_id = @bank_account_id AND e.institute_id = @institute_id
+  AND e.transaction_date::date <= @as_of::date
+  AND (COALESCE(e.is_reconciled, FALSE) = FALSE
+       OR COALESCE(t.payment_date, t.created_at)::date > @as_of::date)
+  AND e.deleted_at IS NULL
+ORDER BY e.transaction_date, e.line_no;
+
+-- =========================================================
+-- FINANCE: BUDGETS
+-- =========================================================
+
+-- name: CreateBudget :one
+INSERT INTO finance.budgets (
+    institute_id, academic_session_id, department_id, expense_category_id,
+    allocated_amount, soft_limit_percent, hard_limit_percent, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetBudgetForUpdate :one
+SELECT * FROM finance.budgets
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: UpdateBudgetLimits :one
+UPDATE finance.budgets
+SET allocated_amount = @allocated_amount, soft_limit_percent = @soft_limit_percent,
+    hard_limit_percent = sqlc.narg(hard_limit_percent), updated_by = @updated_by, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: ListBudgets :many
+SELECT * FROM finance.budgets
+WHERE institute_id = @institute_id AND academic_session_id = @academic_session_id
+  AND (sqlc.narg(department_id)::uuid IS NULL OR department_id = sqlc.narg(department_id))
+  AND deleted_at IS NULL
+ORDER BY department_id, expense_category_id;
+
+-- name: FindBudgetForSpend :one
+-- The budget of a department and expense account in the session
+-- covering a date, locked while utilisation is added to it.
+SELECT b.* FROM finance.budgets b
+JOIN core.academic_sessions a ON a.id = b.academic_session_id AND a.deleted_at IS NULL
+WHERE b.institute_id = @institute_id
+  AND b.department_id = @department_id
+  AND b.expense_category_id = @expense_category_id
+  AND @spent_on::date BETWEEN a.start_date AND a.end_date
+  AND b.deleted_at IS NULL
+FOR UPDATE OF b;
+
+-- name: CreateBudgetUtilisation :one
+INSERT INTO finance.budget_utilisations (
+    institute_id, budget_id, kind, source_type, source_id, amount, occurred_on
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: AddBudgetUtilised :one
+UPDATE finance.budgets
+SET utilized_amount = COALESCE(utilized_amount, 0) + @amount::numeric, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: SumOpenCommitments :many
+-- What a source still holds committed, per budget.
+SELECT budget_id, SUM(amount)::text AS amount
+FROM finance.budget_utilisations
+WHERE institute_id = @institute_id AND source_type = @source_type AND source_id = @source_id
+  AND kind = 'committed'
+GROUP BY budget_id
+HAVING SUM(amount) <> 0;
+
+-- name: SumBudgetUtilisations :many
+SELECT u.budget_id,
+       COALESCE(SUM(u.amount) FILTER (WHERE u.kind = 'committed'), 0)::text AS committed,
+       COALESCE(SUM(u.amount) FILTER (WHERE u.kind = 'actual'), 0)::text AS actual
+FROM finance.budget_utilisations u
+JOIN finance.budgets b ON b.id = u.budget_id
+WHERE b.institute_id = @institute_id AND b.academic_session_id = @academic_session_id
+  AND (sqlc.narg(department_id)::uuid IS NULL OR b.department_id = sqlc.narg(department_id))
+  AND b.deleted_at IS NULL
+GROUP BY u.budget_id;
+
+-- name: SumBudgetUtilisationsByMonth :many
+SELECT date_trunc('month', u.occurred_on)::date AS month,
+       COALESCE(SUM(u.amount) FILTER (WHERE u.kind = 'committed'), 0)::text AS committed,
+       COALESCE(SUM(u.amount) FILTER (WHERE u.kind = 'actual'), 0)::text AS actual
+FROM finance.budget_utilisations u
+JOIN finance.budgets b ON b.id = u.budget_id
+WHERE b.institute_id = @institute_id AND b.academic_session_id = @academic_session_id
+  AND (sqlc.narg(department_id)::uuid IS NULL OR b.department_id = sqlc.narg(department_id))
+  AND b.deleted_at IS NULL
+GROUP BY 1
+ORDER BY 1;
+
+-- =========================================================
+-- FINANCE: GST
+-- =========================================================
+
+-- name: CreateTax :one
+INSERT INTO finance.taxes (institute_id, name, percentage)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListTaxes :many
+SELECT * FROM finance.taxes
+WHERE institute_id = $1 AND deleted_at IS NULL
+ORDER BY percentage, name;
+
+-- name: GetTax :one
+SELECT * FROM finance.taxes
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: GetTaxesByIDs :many
+SELECT * FROM finance.taxes
+WHERE institute_id = @institute_id
+  AND id = ANY(@ids::uuid[])
+  AND deleted_at IS NULL;
+
+-- name: GetTaxSettings :one
+SELECT * FROM finance.tax_settings
+WHERE institute_id = $1;
+
+-- name: UpsertTaxSettings :one
+INSERT INTO finance.tax_settings (
+    institute_id, gstin, state_id,
+    output_cgst_account_id, output_sgst_account_id, output_igst_account_id,
+    input_cgst_account_id, input_sgst_account_id, input_igst_account_id, updated_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (institute_id) DO UPDATE
+SET gstin = EXCLUDED.gstin,
+    state_id = EXCLUDED.state_id,
+    output_cgst_account_id = EXCLUDED.output_cgst_account_id,
+    output_sgst_account_id = EXCLUDED.output_sgst_account_id,
+    output_igst_account_id = EXCLUDED.output_igst_account_id,
+    input_cgst_account_id = EXCLUDED.input_cgst_account_id,
+    input_sgst_account_id = EXCLUDED.input_sgst_account_id,
+    input_igst_account_id = EXCLUDED.input_igst_account_id,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetState :one
+SELECT * FROM geo.states
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetStateByGSTCode :one
+SELECT * FROM geo.states
+WHERE gst_code = $1 AND deleted_at IS NULL;
+
+-- name: GetStudentSupplyState :one
+-- The state of the student's current address, or else of another one.
+SELECT state_id FROM core.addresses
+WHERE owner_id = @student_id AND owner_type = 'student'
+  AND state_id IS NOT NULL AND deleted_at IS NULL
+ORDER BY CASE address_type WHEN 'current' THEN 0 WHEN 'permanent' THEN 1 ELSE 2 END, created_at
+LIMIT 1;
+
+-- name: SetFeeHeadTax :one
+UPDATE finance.fee_heads
+SET tax_id = sqlc.narg(tax_id), updated_by = @updated_by, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SetInventoryItemTax :one
+UPDATE inventory.items
+SET tax_id = sqlc.narg(tax_id), updated_by = @updated_by, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetInventoryItem :one
+SELECT * FROM inventory.items
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: GetVendor :one
+SELECT * FROM finance.vendors
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: CreateTaxLine :one
+INSERT INTO finance.tax_lines (
+    institute_id, direction, document_type, document_id, item_id, tax_id, rate, supply,
+    taxable_amount, cgst, sgst, igst, counterparty_gstin, tax_date
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+)
+RETURNING *;
+
+-- name: ListDocumentTaxLines :many
+SELECT * FROM finance.tax_lines
+WHERE institute_id = @institute_id AND document_type = @document_type AND document_id = @document_id
+ORDER BY created_at;
+
+-- name: SummariseTaxLines :many
+SELECT direction, supply, rate::text AS rate,
+       COUNT(*)::int AS lines,
+       SUM(taxable_amount)::text AS taxable_amount,
+       SUM(cgst)::text AS cgst,
+       SUM(sgst)::text AS sgst,
+       SUM(igst)::text AS igst
+FROM finance.tax_lines
+WHERE institute_id = @institute_id
+  AND tax_date BETWEEN @from_date AND @to_date
+GROUP BY direction, supply, rate
+ORDER BY direction DESC, supply, rate;
+
+-- =========================================================
+-- FINANCE: PROCUREMENT CYCLE
+-- =========================================================
+
+-- name: CreateRequisition :one
+INSERT INTO inventory.requisitions (
+    institute_id, requested_by, department_id, status, request_date, remarks, created_by
+) VALUES (
+    $1, $2, $3, 'pending', $4, $5, $6
+)
+RETURNING *;
+
+-- name: CreateRequisitionItem :one
+INSERT INTO inventory.requisition_items (
+    institute_id, requisition_id, item_id, item_name, quantity, remarks
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetRequisitionForUpdate :one
+SELECT * FROM inventory.requisitions
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: ListRequisitions :many
+SELECT * FROM inventory.requisitions
+WHERE institute_id = @institute_id AND deleted_at IS NULL
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(department_id)::uuid IS NULL OR department_id = sqlc.narg(department_id))
+ORDER BY request_date DESC, created_at DESC;
+
+-- name: ListRequisitionItems :many
+SELECT * FROM inventory.requisition_items
+WHERE requisition_id = @requisition_id AND institute_id = @institute_id AND deleted_at IS NULL
+ORDER BY created_at;
+
+-- name: UpdateRequisitionStatus :one
+-- The first decision on a requisition records who made it.
+UPDATE inventory.requisitions
+SET status = @status,
+    decided_by = COALESCE(decided_by, @decided_by),
+    decided_at = COALESCE(decided_at, NOW()),
+    updated_by = @decided_by,
+    updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: AddRequisitionItemOrdered :one
+UPDATE inventory.requisition_items
+SET ordered_quantity = ordered_quantity + @quantity,
+    item_id = COALESCE(item_id, @item_id),
+    updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: ListPurchaseOrderItemsForUpdate :many
+SELECT * FROM finance.purchase_order_items
+WHERE purchase_order_id = @purchase_order_id AND institute_id = @institute_id AND deleted_at IS NULL
+ORDER BY created_at
+FOR UPDATE;
+
+-- name: AddPurchaseItemReceived :one
+UPDATE finance.purchase_order_items
+SET received_quantity = received_quantity + @quantity, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: AddPurchaseItemBilled :one
+UPDATE finance.purchase_order_items
+SET billed_quantity = billed_quantity + @quantity, updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: GetLocation :one
+SELECT * FROM inventory.locations
+WHERE id = @id AND institute_id = @institute_id AND deleted_at IS NULL;
+
+-- name: CreateGoodsReceipt :one
+INSERT INTO finance.goods_receipts (
+    institute_id, purchase_order_id, location_id, received_on, reference_no, remarks, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: CreateGoodsReceiptItem :one
+INSERT INTO finance.goods_receipt_items (
+    institute_id, goods_receipt_id, purchase_order_item_id, item_id, quantity, unit_price, tax_amount, amount
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: SetGoodsReceiptAmount :one
+UPDATE finance.goods_receipts
+SET amount = @amount
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: ListGoodsReceipts :many
+SELECT * FROM finance.goods_receipts
+WHERE purchase_order_id = @purchase_order_id AND institute_id = @institute_id
+ORDER BY received_on, created_at;
+
+-- name: ListGoodsReceiptItems :many
+SELECT * FROM finance.goods_receipt_items
+WHERE goods_receipt_id = ANY(@goods_receipt_ids::uuid[]) AND institute_id = @institute_id;
+
+-- name: AddStockLevel :one
+INSERT INTO inventory.stock_levels (institute_id, item_id, location_id, quantity)
+VALUES (@institute_id, @item_id, @location_id, @quantity)
+ON CONFLICT (institute_id, item_id, location_id) WHERE deleted_at IS NULL DO UPDATE
+SET quantity = COALESCE(inventory.stock_levels.quantity, 0) + EXCLUDED.quantity,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: CreatePurchaseStockTransaction :one
+INSERT INTO inventory.transactions (
+    institute_id, item_id, transaction_type, quantity, unit_price, remarks, transaction_date, created_by
+) VALUES (
+    $1, $2, 'purchase', $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: CreateVendorBill :one
+INSERT INTO finance.vendor_bills (
+    institute_id, vendor_id, purchase_order_id, bill_no, bill_date, due_date,
+    amount, tax_amount, status, match_notes, remarks, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+RETURNING *;
+
+-- name: CreateVendorBillItem :one
+INSERT INTO finance.vendor_bill_items (
+    institute_id, vendor_bill_id, purchase_order_item_id, quantity, unit_price, tax_amount, amount
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetVendorBillForUpdate :one
+SELECT * FROM finance.vendor_bills
+WHERE id = @id AND institute_id = @institute_id
+FOR UPDATE;
+
+-- name: ListVendorBills :many
+SELECT * FROM finance.vendor_bills
+WHERE institute_id = @institute_id
+  AND (sqlc.narg(vendor_id)::uuid IS NULL OR vendor_id = sqlc.narg(vendor_id))
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+ORDER BY bill_date DESC, created_at DESC;
+
+-- name: ListVendorBillItems :many
+SELECT * FROM finance.vendor_bill_items
+WHERE vendor_bill_id = @vendor_bill_id AND institute_id = @institute_id;
+
+-- name: DecideVendorBill :one
+UPDATE finance.vendor_bills
+SET status = @status, decided_by = @decided_by, decided_at = NOW(),
+    remarks = COALESCE(sqlc.narg(remarks), remarks), updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id AND status = 'on_hold'
+RETURNING *;
+
+-- name: AddVendorBillPaid :one
+UPDATE finance.vendor_bills
+SET paid_amount = paid_amount + @amount,
+    status = CASE WHEN paid_amount + @amount >= amount THEN 'paid' ELSE status END,
+    updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: CreateVendorPayment :one
+INSERT INTO finance.vendor_payments (
+    institute_id, vendor_bill_id, vendor_id, amount, payment_mode, paid_on, reference_no, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: ListOpenVendorBills :many
+-- Bills dated by as_of with something left to pay, oldest due first.
+SELECT b.id, b.vendor_id, v.name AS vendor_name, b.bill_no, b.due_date, b.status,
+       (b.amount - b.paid_amount)::text AS outstanding
+FROM finance.vendor_bills b
+JOIN finance.vendors v ON v.id = b.vendor_id
+WHERE b.institute_id = @institute_id
+  AND b.status IN ('matched', 'on_hold', 'approved')
+  AND b.bill_date <= @as_of
+  AND b.paid_amount < b.amount
+ORDER BY v.name, b.vendor_id, b.due_date;
+
+-- =========================================================
+-- FINANCE: FEE DUNNING
+-- =========================================================
+
+-- name: ListOverdueFeeItems :many
+-- Items of the invoices overdue as of a date with the student's class,
+-- grouped by invoice, for the defaulter ageing report. Invoices without
+-- items come back as one row with no fee head.
+SELECT i.id AS invoice_id, i.student_id, i.due_date,
+       i.total_amount, i.discount_amount, i.fine_amount, i.paid_amount,
+       s.current_class_id AS class_id,
+       COALESCE(c.name || ' ' || c.section, '')::text AS class_name,
+       it.fee_head_id,
+       COALESCE(h.name, '')::text AS fee_head_name,
+       COALESCE(it.amount - COALESCE(it.discount_applied, 0), 0)::text AS net_amount,
+       EXISTS (
+           SELECT 1 FROM finance.payment_plans p
+           WHERE p.institute_id = i.institute_id
+             AND p.student_id = i.student_id
+             AND p.status = 'approved'
+             AND @as_of::date BETWEEN p.start_date AND p.end_date
+       ) AS on_payment_plan
+FROM finance.invoices i
+JOIN core.students s ON s.id = i.student_id
+LEFT JOIN core.classes c ON c.id = s.current_class_id
+LEFT JOIN finance.invoice_items it ON it.invoice_id = i.id AND it.deleted_at IS NULL
+LEFT JOIN finance.fee_heads h ON h.id = it.fee_head_id
+WHERE i.institute_id = @institute_id
+  AND i.due_date < @as_of::date
+  AND COALESCE(i.status, 'pending') <> 'paid'
+  AND i.deleted_at IS NULL
+  AND (sqlc.narg(class_id)::uuid IS NULL OR s.current_class_id = sqlc.narg(class_id))
+ORDER BY class_name, class_id, i.due_date, i.id, it.created_at;
+
+-- name: ListDunningSteps :many
+SELECT * FROM finance.dunning_steps
+WHERE institute_id = @institute_id AND is_active = TRUE
+ORDER BY days_overdue;
+
+-- name: DeactivateDunningSteps :exec
+UPDATE finance.dunning_steps
+SET is_active = FALSE, updated_by = @updated_by, updated_at = NOW()
+WHERE institute_id = @institute_id AND is_active = TRUE;
+
+-- name: UpsertDunningStep :one
+-- Steps are keyed by days_overdue, so a step that is saved again keeps
+-- its id and is not sent a second time for the same invoice.
+INSERT INTO finance.dunning_steps (
+    institute_id, days_overdue, subject, message, send_email, send_sms, send_in_app, created_by, updated_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $8
+)
+ON CONFLICT (institute_id, days_overdue) DO UPDATE SET
+    subject = EXCLUDED.subject,
+    message = EXCLUDED.message,
+    send_email = EXCLUDED.send_email,
+    send_sms = EXCLUDED.send_sms,
+    send_in_app = EXCLUDED.send_in_app,
+    is_active = TRUE,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: ListInstitutesWithDunningSteps :many
+SELECT DISTINCT institute_id FROM finance.dunning_steps
+WHERE is_active = TRUE;
+
+-- name: ListDunningDue :many
+-- Overdue invoices with the latest active step they have reached, when
+-- that step has not been sent for the invoice yet. Students on an
+-- approved payment plan that covers as_of are left out.
+SELECT sqlc.embed(i), sqlc.embed(d), s.first_name, s.last_name,
+       (@as_of::date - i.due_date::date)::int AS days_overdue
+FROM finance.invoices i
+JOIN core.students s ON s.id = i.student_id
+JOIN finance.dunning_steps d ON d.institute_id = i.institute_id
+WHERE i.institute_id = @institute_id
+  AND i.due_date < @as_of::date
+  AND COALESCE(i.status, 'pending') <> 'paid'
+  AND i.deleted_at IS NULL
+  AND s.deleted_at IS NULL
+  AND d.is_active = TRUE
+  AND d.days_overdue = (
+      SELECT MAX(x.days_overdue) FROM finance.dunning_steps x
+      WHERE x.institute_id = i.institute_id
+        AND x.is_active = TRUE
+        AND x.days_overdue <= @as_of::date - i.due_date::date
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM finance.dunning_reminders r
+      WHERE r.invoice_id = i.id AND r.dunning_step_id = d.id
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM finance.payment_plans p
+      WHERE p.institute_id = i.institute_id
+        AND p.student_id = i.student_id
+        AND p.status = 'approved'
+        AND @as_of::date BETWEEN p.start_date AND p.end_date
+  )
+ORDER BY i.student_id, i.due_date;
+
+-- name: ListPrimaryGuardianContacts :many
+-- The student's primary contact guardians with their login, if any,
+-- for in-app notifications.
+SELECT g.id, g.first_name, g.last_name, g.email, g.phone, u.id AS user_id
+FROM core.student_guardian_map m
+JOIN core.students s ON s.id = m.student_id
+JOIN core.guardians g ON g.id = m.guardian_id
+LEFT JOIN auth.users u ON u.linked_entity_id = g.id
+    AND u.role_type = 'guardian'
+    AND u.is_active = TRUE
+    AND u.deleted_at IS NULL
+WHERE m.student_id = @student_id
+  AND s.institute_id = @institute_id
+  AND m.is_primary_contact = TRUE
+  AND m.deleted_at IS NULL
+  AND g.deleted_at IS NULL
+ORDER BY g.first_name, g.id;
+
+-- name: CreateDunningReminder :one
+-- Claims the step for the invoice; no row comes back when another run
+-- has already sent it.
+INSERT INTO finance.dunning_reminders (
+    institute_id, invoice_id, student_id, dunning_step_id, days_overdue, amount_due
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (invoice_id, dunning_step_id) DO NOTHING
+RETURNING *;
+
+-- name: SetDunningReminderDelivery :one
+UPDATE finance.dunning_reminders
+SET recipients = @recipients, failures = @failures
+WHERE id = @id AND institute_id = @institute_id
+RETURNING *;
+
+-- name: ListDunningReminders :many
+SELECT * FROM finance.dunning_reminders
+WHERE institute_id = @institute_id
+  AND (sqlc.narg(student_id)::uuid IS NULL OR student_id = sqlc.narg(student_id))
+  AND (sqlc.narg(invoice_id)::uuid IS NULL OR invoice_id = sqlc.narg(invoice_id))
+ORDER BY sent_at DESC;
+
+-- name: CreateNotification :one
+INSERT INTO comms.notifications (
+    institute_id, user_id, title, message, created_by
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: CreatePaymentPlan :one
+INSERT INTO finance.payment_plans (
+    institute_id, student_id, amount, installments, start_date, end_date, reason, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: ListPaymentPlans :many
+SELECT * FROM finance.payment_plans
+WHERE institute_id = @institute_id
+  AND (sqlc.narg(student_id)::uuid IS NULL OR student_id = sqlc.narg(student_id))
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+ORDER BY created_at DESC;
+
+-- name: GetPaymentPlanForUpdate :one
+SELECT * FROM finance.payment_plans
+WHERE id = @id AND institute_id = @institute_id
+FOR UPDATE;
+
+-- name: DecidePaymentPlan :one
+UPDATE finance.payment_plans
+SET status = @status, decided_by = @decided_by, decided_at = NOW(),
+    remarks = COALESCE(sqlc.narg(remarks), remarks), updated_at = NOW()
+WHERE id = @id AND institute_id = @institute_id AND status = 'pending'
+RETURNING *;
@@ -0,0 +1,1013 @@
+-- =========================================================
+-- AUTH: SESSIONS
+-- Server-side login sessions shared by all app replicas.
+-- =========================================================
+CREATE TABLE IF NOT EXISTS auth.sessions (
+    id                  TEXT PRIMARY KEY,
+    user_id             UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    username            TEXT NOT NULL,
+    role_type           TEXT NOT NULL,
+    user_agent          TEXT,
+    ip_address          TEXT,
+    institute_ids       TEXT[] NOT NULL DEFAULT '{}',
+    active_institute_id TEXT,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at          TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON auth.sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON auth.sessions(expires_at);
+
+-- =========================================================
+-- AUTH: LOGIN IDENTIFIERS
+-- A login is looked up by the column its identifier's type names,
+-- so each of them must name one live user.
+-- =========================================================
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_username_hash
+    ON auth.users(username_hash) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_email_hash
+    ON auth.users(email_hash) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_phone_number_hash
+    ON auth.users(phone_number_hash) WHERE deleted_at IS NULL AND phone_number_hash IS NOT NULL;
+
+-- =========================================================
+-- AUTH: OTP CODES
+-- One row per login identifier. code_hash is an HMAC of the code;
+-- attempt and send counters outlive the code for throttling.
+-- =========================================================
+CREATE TABLE IF NOT EXISTS auth.otp_codes (
+    identifier        TEXT PRIMARY KEY,
+    code_hash         TEXT,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at        TIMESTAMPTZ NOT NULL,
+    attempts          INT NOT NULL DEFAULT 0,
+    locked_until      TIMESTAMPTZ,
+    send_count        INT NOT NULL DEFAULT 0,
+    window_started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_sent_at      TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_otp_codes_expires_at ON auth.otp_codes(expires_at);
+
+-- =========================================================
+-- AUTH: CREDENTIALS
+-- Password lockout state and optional TOTP second factor.
+-- totp_secret is AES-GCM sealed; totp_last_step blocks code replay.
+-- =========================================================
+CREATE TABLE IF NOT EXISTS auth.user_credentials (
+    user_id             UUID PRIMARY KEY REFERENCES auth.users(id) ON DELETE CASCADE,
+    failed_attempts     INT NOT NULL DEFAULT 0,
+    locked_until        TIMESTAMPTZ,
+    password_changed_at TIMESTAMPTZ,
+    totp_secret         TEXT,
+    totp_enabled        BOOLEAN NOT NULL DEFAULT FALSE,
+    totp_enabled_at     TIMESTAMPTZ,
+    totp_last_step      BIGINT NOT NULL DEFAULT 0,
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS auth.mfa_recovery_codes (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    code_hash  TEXT NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, code_hash)
+);
+
+-- Pending second step of a password login
+CREATE TABLE IF NOT EXISTS auth.mfa_challenges (
+    token_hash TEXT PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS auth.password_reset_tokens (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON auth.password_reset_tokens(user_id);
+
+-- =========================================================
+-- AUTH: RBAC RULES
+-- Casbin policies for rbac_with_domains_model.conf.
+-- p rules: v0=role, v1=institute, v2=object, v3=action
+-- g rules: v0=user/role, v1=role, v2=institute
+-- =========================================================
+CREATE TABLE IF NOT EXISTS auth.rbac_rules (
+    id    BIGSERIAL PRIMARY KEY,
+    ptype TEXT NOT NULL,
+    v0    TEXT NOT NULL DEFAULT '',
+    v1    TEXT NOT NULL DEFAULT '',
+    v2    TEXT NOT NULL DEFAULT '',
+    v3    TEXT NOT NULL DEFAULT '',
+    CONSTRAINT uq_rbac_rules UNIQUE (ptype, v0, v1, v2, v3)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rbac_rules_domain ON auth.rbac_rules(ptype, v1);
+
+-- Default role permissions every institute starts with. Admins refine them
+-- afterwards through the policy APIs; /auth/policies/seed_defaults re-applies
+-- them without touching rules added since.
+CREATE TABLE IF NOT EXISTS auth.rbac_default_rules (
+    role   TEXT NOT NULL,
+    object TEXT NOT NULL,
+    action TEXT NOT NULL,
+    PRIMARY KEY (role, object, action)
+);
+
+INSERT INTO auth.rbac_default_rules (role, object, action) VALUES
+    ('admin', 'auth/*', '(read|create|update|delete)'),
+    ('admin', 'core/institutes', '(read|update)'),
+    ('admin', 'core/classes', '(read|create|update|delete)'),
+    ('admin', 'core/departments', '(read|create|update|delete)'),
+    ('admin', 'core/students', '(read|create|update|delete)'),
+    ('admin', 'core/employees', '(read|create|update|delete)'),
+    ('admin', 'core/guardians', '(read|create|update|delete)'),
+    ('admin', 'core/academic_sessions', '(read|create|update|delete)'),
+    ('admin', 'core/addresses', '(read|create|update|delete)'),
+    ('admin', 'academics/*', '(read|create|update|delete)'),
+    ('admin', 'admissions/*', '(read|create|update|delete)'),
+    ('admin', 'common/*', '(read|create|update|delete)'),
+    ('admin', 'finance/*', '(read|create|update|delete)'),
+    ('admin', 'library/*', '(read|create|update|delete)'),
+    ('admin', 'fleet/*', '(read|create|update|delete)'),
+    ('admin', 'hostel/*', '(read|create|update|delete)'),
+    ('admin', 'hr/*', '(read|create|update|delete)'),
+    ('admin', 'inventory/*', '(read|create|update|delete)'),
+    ('admin', 'payroll/*', '(read|create|update|delete)'),
+    ('admin', 'health/*', '(read|create|update|delete)'),
+    ('teacher', 'core/students', 'read'),
+    ('teacher', 'core/guardians', 'read'),
+    ('teacher', 'core/classes', 'read'),
+    ('teacher', 'core/academic_sessions', 'read'),
+    ('teacher', 'academics/*', '(read|create|update|delete)'),
+    ('teacher', 'common/documents', '(read|create|update|delete)'),
+    ('teacher', 'common/notifications', 'create'),
+    ('teacher', 'inventory/requisitions', '(create|read)'),
+    ('accountant', 'core/students', 'read'),
+    ('accountant', 'core/guardians', 'read'),
+    ('accountant', 'core/classes', 'read'),
+    ('accountant', 'core/academic_sessions', 'read'),
+    ('accountant', 'finance/*', '(read|create|update|delete)'),
+    ('accountant', 'inventory/requisitions', '(read|create|update|delete)'),
+    ('accountant', 'common/documents', 'read'),
+    ('librarian', 'core/students', 'read'),
+    ('librarian', 'library/*', '(read|create|update|delete)'),
+    ('driver', 'fleet/*', 'read'),
+    ('nurse', 'core/students', 'read'),
+    ('nurse', 'health/*', '(read|create|update|delete)'),
+    ('employee', 'common/documents', 'read'),
+    ('employee', 'inventory/requisitions', '(create|read)'),
+    ('student', 'academics/timetable', 'read'),
+    ('student', 'academics/subjects', 'read'),
+    ('guardian', 'parent/portal', 'read'),
+    ('guardian', 'parent/checkout', '(create|read)')
+ON CONFLICT DO NOTHING;
+
+-- Copies the default rules into an institute, skipping ones it already has.
+CREATE OR REPLACE FUNCTION auth.seed_institute_rbac(institute UUID) RETURNS void AS $$
+BEGIN
+    INSERT INTO auth.rbac_rules (ptype, v0, v1, v2, v3)
+    SELECT 'p', d.role, institute::text, d.object, d.action
+    FROM auth.rbac_default_rules d
+    ON CONFLICT (ptype, v0, v1, v2, v3) DO NOTHING;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION auth.seed_new_institute_rbac() RETURNS trigger AS $$
+BEGIN
+    PERFORM auth.seed_institute_rbac(NEW.id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_seed_institute_rbac ON core.institutes;
+CREATE TRIGGER trg_seed_institute_rbac
+    AFTER INSERT ON core.institutes
+    FOR EACH ROW EXECUTE FUNCTION auth.seed_new_institute_rbac();
+
+-- Existing institutes without any policies get the defaults once. Institutes
+-- that already have rules are left alone so revoked permissions stay revoked.
+SELECT auth.seed_institute_rbac(i.id)
+FROM core.institutes i
+WHERE NOT EXISTS (
+    SELECT 1 FROM auth.rbac_rules r WHERE r.ptype = 'p' AND r.v1 = i.id::text
+);
+
+-- =========================================================
+-- ACADEMICS: STUDENT ATTENDANCE
+-- One row per student per day (period_id NULL) or per period.
+-- created_by is the teacher who first marked the row.
+-- =========================================================
+CREATE TABLE IF NOT EXISTS academics.student_attendance (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id    UUID NOT NULL REFERENCES core.institutes(id),
+    class_id        UUID NOT NULL REFERENCES core.classes(id),
+    student_id      UUID NOT NULL REFERENCES core.students(id),
+    attendance_date DATE NOT NULL,
+    period_id       UUID REFERENCES academics.class_periods(id),
+    status          TEXT NOT NULL REFERENCES enums.attendance_status(code),
+    remarks         TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ,
+    created_by      UUID REFERENCES auth.users(id),
+    updated_by      UUID REFERENCES auth.users(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_student_attendance_daily
+    ON academics.student_attendance(student_id, attendance_date) WHERE period_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_student_attendance_period
+    ON academics.student_attendance(student_id, attendance_date, period_id) WHERE period_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_student_attendance_class_date
+    ON academics.student_attendance(institute_id, class_id, attendance_date);
+
+-- =========================================================
+-- FINANCE: GENERAL LEDGER
+-- Journals are inserted as drafts and then marked posted. A
+-- posted entry and its items can no longer change; mistakes
+-- are undone by a reversal entry pointing at the original.
+-- =========================================================
+ALTER TABLE finance.journal_entries
+    ADD COLUMN IF NOT EXISTS reversal_of_id UUID REFERENCES finance.journal_entries(id);
+
+-- An entry can be reversed only once
+CREATE UNIQUE INDEX IF NOT EXISTS uq_journal_entries_reversal_of
+    ON finance.journal_entries(reversal_of_id) WHERE reversal_of_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_journal_items_entry ON finance.journal_items(journal_entry_id);
+CREATE INDEX IF NOT EXISTS idx_journal_items_account ON finance.journal_items(account_id);
+
+CREATE OR REPLACE FUNCTION finance.lock_posted_journal_entry() RETURNS trigger AS $$
+BEGIN
+    IF OLD.is_posted THEN
+        RAISE EXCEPTION 'journal entry % is posted and cannot be changed', OLD.id;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_lock_posted_journal_entry ON finance.journal_entries;
+CREATE TRIGGER trg_lock_posted_journal_entry
+    BEFORE UPDATE OR DELETE ON finance.journal_entries
+    FOR EACH ROW EXECUTE FUNCTION finance.lock_posted_journal_entry();
+
+CREATE OR REPLACE FUNCTION finance.lock_posted_journal_items() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' AND EXISTS (
+        SELECT 1 FROM finance.journal_entries WHERE id = OLD.journal_entry_id AND is_posted
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is posted and its items cannot be changed', OLD.journal_entry_id;
+    END IF;
+    IF TG_OP <> 'DELETE' AND EXISTS (
+        SELECT 1 FROM finance.journal_entries WHERE id = NEW.journal_entry_id AND is_posted
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is posted and its items cannot be changed', NEW.journal_entry_id;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_lock_posted_journal_items ON finance.journal_items;
+CREATE TRIGGER trg_lock_posted_journal_items
+    BEFORE INSERT OR UPDATE OR DELETE ON finance.journal_items
+    FOR EACH ROW EXECUTE FUNCTION finance.lock_posted_journal_items();
+
+-- =========================================================
+-- FINANCE: FISCAL YEAR CLOSINGS
+-- One row per closed fiscal year. The closing journal moves
+-- income and expense balances into retained earnings; it is
+-- NULL when the year had nothing to close. Journals dated on
+-- or before the latest fiscal_year_end can no longer be posted.
+-- =========================================================
+CREATE TABLE IF NOT EXISTS finance.fiscal_year_closings (
+    id                           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id                 UUID NOT NULL REFERENCES core.institutes(id),
+    fiscal_year_start            DATE NOT NULL,
+    fiscal_year_end              DATE NOT NULL,
+    journal_entry_id             UUID REFERENCES finance.journal_entries(id),
+    retained_earnings_account_id UUID NOT NULL REFERENCES finance.accounts(id),
+    net_income                   NUMERIC(15,2) NOT NULL,
+    closed_at                    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_by                    UUID REFERENCES auth.users(id),
+    UNIQUE (institute_id, fiscal_year_start)
+);
+
+-- =========================================================
+-- FINANCE: AUTOMATIC POSTINGS
+-- Invoices, payments, refunds and purchase receipts post their
+-- own journals. source_type/source_id link each entry back to
+-- its document; a reversal keeps the link of the entry it undoes.
+-- =========================================================
+ALTER TABLE finance.journal_entries
+    ADD COLUMN IF NOT EXISTS source_type TEXT,
+    ADD COLUMN IF NOT EXISTS source_id UUID;
+
+-- A source document is posted at most once
+CREATE UNIQUE INDEX IF NOT EXISTS uq_journal_entries_source
+    ON finance.journal_entries(institute_id, source_type, source_id)
+    WHERE source_type IS NOT NULL AND reversal_of_id IS NULL AND deleted_at IS NULL;
+
+-- Control accounts used by automatic postings. Concessions are
+-- charged against the fee head's own income account when no
+-- concessions account is set; wallet payments and refunds need
+-- the student advances account.
+CREATE TABLE IF NOT EXISTS finance.ledger_settings (
+    institute_id                UUID PRIMARY KEY REFERENCES core.institutes(id),
+    receivables_account_id      UUID NOT NULL REFERENCES finance.accounts(id),
+    cash_account_id             UUID NOT NULL REFERENCES finance.accounts(id),
+    bank_account_id             UUID NOT NULL REFERENCES finance.accounts(id),
+    payables_account_id         UUID NOT NULL REFERENCES finance.accounts(id),
+    purchases_account_id        UUID NOT NULL REFERENCES finance.accounts(id),
+    concessions_account_id      UUID REFERENCES finance.accounts(id),
+    student_advances_account_id UUID REFERENCES finance.accounts(id),
+    updated_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by                  UUID REFERENCES auth.users(id)
+);
+
+-- =========================================================
+-- FINANCE: INVOICE GENERATION
+-- Generated invoices record the month they bill and each item
+-- the fee structure it came from, so a run can tell what a
+-- student has already been billed for. A student gets at most
+-- one generated invoice per billing period.
+-- =========================================================
+ALTER TABLE finance.invoices
+    ADD COLUMN IF NOT EXISTS billing_period DATE;
+
+ALTER TABLE finance.invoice_items
+    ADD COLUMN IF NOT EXISTS fee_structure_id UUID REFERENCES finance.fee_structures(id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_invoices_billing_period
+    ON finance.invoices(institute_id, student_id, billing_period)
+    WHERE billing_period IS NOT NULL AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_invoice_items_fee_structure
+    ON finance.invoice_items(fee_structure_id)
+    WHERE fee_structure_id IS NOT NULL;
+
+-- Last invoice number issued per institute. Numbers are taken
+-- inside the invoice's transaction, so they are gap-free.
+CREATE TABLE IF NOT EXISTS finance.invoice_sequences (
+    institute_id UUID PRIMARY KEY REFERENCES core.institutes(id),
+    last_no      BIGINT NOT NULL
+);
+
+-- =========================================================
+-- FINANCE: CONCESSIONS
+-- fee_head_ids limits a concession to some fee heads (NULL
+-- for all). A concession with a sibling_rank applies by
+-- itself to students with at least sibling_rank-1 elder
+-- siblings, students sharing an active guardian who were
+-- admitted earlier. Other concessions are granted to students
+-- and need approval, tracked in core.approvals.
+-- =========================================================
+ALTER TABLE finance.concessions
+    ADD COLUMN IF NOT EXISTS fee_head_ids UUID[],
+    ADD COLUMN IF NOT EXISTS sibling_rank INT CHECK (sibling_rank >= 2);
+
+CREATE TABLE IF NOT EXISTS finance.student_concessions (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id  UUID NOT NULL REFERENCES core.institutes(id),
+    student_id    UUID NOT NULL REFERENCES core.students(id),
+    concession_id UUID NOT NULL REFERENCES finance.concessions(id),
+    valid_from    DATE NOT NULL,
+    valid_to      DATE,
+    status        TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    remarks       TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at    TIMESTAMPTZ,
+    created_by    UUID REFERENCES auth.users(id),
+    updated_by    UUID REFERENCES auth.users(id),
+    CHECK (valid_to IS NULL OR valid_to >= valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_student_concessions_student
+    ON finance.student_concessions(institute_id, student_id)
+    WHERE deleted_at IS NULL;
+
+-- =========================================================
+-- FINANCE: LATE FEES
+-- Fines are credited to the rule's fee head. All fines on an
+-- invoice are limited to the lowest max_fine_amount of the
+-- active rules. Each charge and each waiver is kept as a row;
+-- invoices.fine_amount is their net total.
+-- =========================================================
+ALTER TABLE finance.fine_rules
+    ADD COLUMN IF NOT EXISTS fee_head_id UUID REFERENCES finance.fee_heads(id),
+    ADD COLUMN IF NOT EXISTS max_fine_amount NUMERIC(12,2) CHECK (max_fine_amount >= 0);
+
+CREATE TABLE IF NOT EXISTS finance.invoice_fines (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id UUID NOT NULL REFERENCES core.institutes(id),
+    invoice_id   UUID NOT NULL REFERENCES finance.invoices(id),
+    fine_rule_id UUID NOT NULL REFERENCES finance.fine_rules(id),
+    assessed_on  DATE NOT NULL,
+    days_overdue INT NOT NULL,
+    amount       NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by   UUID REFERENCES auth.users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_fines_invoice
+    ON finance.invoice_fines(invoice_id, fine_rule_id);
+
+CREATE TABLE IF NOT EXISTS finance.invoice_fine_waivers (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id UUID NOT NULL REFERENCES core.institutes(id),
+    invoice_id   UUID NOT NULL REFERENCES finance.invoices(id),
+    fine_rule_id UUID NOT NULL REFERENCES finance.fine_rules(id),
+    amount       NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    reason       TEXT NOT NULL,
+    approved_by  UUID NOT NULL REFERENCES auth.users(id),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_fine_waivers_invoice
+    ON finance.invoice_fine_waivers(invoice_id, fine_rule_id);
+
+-- =========================================================
+-- FINANCE: ONLINE PAYMENTS
+-- Gateway credentials are stored per institute with secrets
+-- AES-GCM sealed. Each checkout is an online_payments row; its
+-- order_ref is sent to the gateway and comes back on the
+-- callback, and the unique gateway payment id makes repeated
+-- callbacks settle the payment only once.
+-- =========================================================
+CREATE TABLE IF NOT EXISTS finance.payment_gateways (
+    institute_id        UUID PRIMARY KEY REFERENCES core.institutes(id),
+    gateway             VARCHAR(20) NOT NULL,
+    key_id              TEXT NOT NULL,
+    secret_sealed       TEXT NOT NULL,
+    merchant_id         TEXT,
+    merchant_key_sealed TEXT,
+    environment         VARCHAR(20) NOT NULL DEFAULT 'production',
+    is_active           BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by          UUID REFERENCES auth.users(id)
+);
+
+CREATE TABLE IF NOT EXISTS finance.online_payments (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id       UUID NOT NULL REFERENCES core.institutes(id),
+    invoice_id         UUID NOT NULL REFERENCES finance.invoices(id),
+    student_id         UUID NOT NULL REFERENCES core.students(id),
+    gateway            VARCHAR(20) NOT NULL,
+    order_ref          VARCHAR(40) NOT NULL UNIQUE,
+    gateway_order_id   TEXT,
+    gateway_payment_id TEXT,
+    amount             NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    status             VARCHAR(20) NOT NULL DEFAULT 'created'
+                       CHECK (status IN ('created', 'paid', 'failed')),
+    transaction_id     UUID REFERENCES finance.transactions(id),
+    initiated_by       UUID REFERENCES auth.users(id),
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (gateway, gateway_payment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_online_payments_invoice
+    ON finance.online_payments(invoice_id);
+
+-- =========================================================
+-- FINANCE: RECEIPTS AND CHEQUES
+-- A receipt is one collection at the counter or online; each
+-- invoice it pays is a finance.transactions row. Receipt numbers
+-- run per institute and fiscal year and are taken inside the
+-- receipt's transaction, so they are gap-free. A bounced cheque
+-- reverses every payment on its receipt.
+-- =========================================================
+INSERT INTO enums.cheque_status (code) VALUES ('pending'), ('cleared'), ('bounced')
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS finance.receipt_sequences (
+    institute_id UUID NOT NULL REFERENCES core.institutes(id),
+    fiscal_year  INT NOT NULL,
+    last_no      BIGINT NOT NULL,
+    PRIMARY KEY (institute_id, fiscal_year)
+);
+
+CREATE TABLE IF NOT EXISTS finance.receipts (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id       UUID NOT NULL REFERENCES core.institutes(id),
+    receipt_no         VARCHAR(40) NOT NULL,
+    fiscal_year        INT NOT NULL,
+    receipt_date       DATE NOT NULL,
+    student_id         UUID NOT NULL REFERENCES core.students(id),
+    payment_mode       VARCHAR(20) NOT NULL,
+    amount             NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    transaction_ref_no TEXT,
+    cheque_no          TEXT,
+    cheque_date        DATE,
+    bank_name          TEXT,
+    cheque_status      TEXT REFERENCES enums.cheque_status(code),
+    cheque_settled_at  TIMESTAMPTZ,
+    bounce_reason      TEXT,
+    remarks            TEXT,
+    collected_by       UUID REFERENCES auth.users(id),
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by         UUID REFERENCES auth.users(id),
+    UNIQUE (institute_id, receipt_no)
+);
+
+CREATE INDEX IF NOT EXISTS idx_receipts_student
+    ON finance.receipts(institute_id, student_id, receipt_date);
+
+ALTER TABLE finance.transactions
+    ADD COLUMN IF NOT EXISTS receipt_id UUID REFERENCES finance.receipts(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_receipt
+    ON finance.transactions(receipt_id);
+
+-- =========================================================
+-- FINANCE: STUDENT WALLETS
+-- Advances deposited by families. Every top-up, debit and
+-- refund is a wallet_entries row carrying the balance after it;
+-- rows are never changed. Writers lock the wallet row first so
+-- concurrent debits cannot overdraw it. With auto_apply set,
+-- new invoices are paid from the wallet as far as it goes.
+-- =========================================================
+ALTER TABLE finance.student_wallets
+    ADD COLUMN IF NOT EXISTS auto_apply BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_student_wallets_student
+    ON finance.student_wallets(institute_id, student_id);
+
+CREATE TABLE IF NOT EXISTS finance.wallet_entries (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id   UUID NOT NULL REFERENCES core.institutes(id),
+    wallet_id      UUID NOT NULL REFERENCES finance.student_wallets(id),
+    student_id     UUID NOT NULL REFERENCES core.students(id),
+    entry_type     VARCHAR(10) NOT NULL CHECK (entry_type IN ('topup', 'debit', 'refund')),
+    purpose        VARCHAR(20) NOT NULL,
+    amount         NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    balance_after  NUMERIC(12,2) NOT NULL CHECK (balance_after >= 0),
+    payment_mode   VARCHAR(20),
+    reference_no   TEXT,
+    invoice_id     UUID REFERENCES finance.invoices(id),
+    transaction_id UUID REFERENCES finance.transactions(id),
+    fee_head_id    UUID REFERENCES finance.fee_heads(id),
+    remarks        TEXT,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by     UUID REFERENCES auth.users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_entries_wallet
+    ON finance.wallet_entries(wallet_id, created_at);
+
+CREATE OR REPLACE FUNCTION finance.lock_wallet_entries() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'wallet entries cannot be changed or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_lock_wallet_entries ON finance.wallet_entries;
+CREATE TRIGGER trg_lock_wallet_entries
+    BEFORE UPDATE OR DELETE ON finance.wallet_entries
+    FOR EACH ROW EXECUTE FUNCTION finance.lock_wallet_entries();
+
+-- =========================================================
+-- FINANCE: REFUND WORKFLOW
+-- A refund is requested against an invoice, for its refundable
+-- fee heads, or against a wallet balance. It needs
+-- approvals_required approvals in turn, one core.approvals row
+-- per step, each by a different user than the requester and
+-- the earlier approvers. Processing records how it was paid out.
+-- =========================================================
+ALTER TABLE core.approvals
+    ADD COLUMN IF NOT EXISTS step INT NOT NULL DEFAULT 1;
+
+ALTER TABLE finance.refunds
+    ADD COLUMN IF NOT EXISTS approvals_required INT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS approvals_received INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS payout_mode VARCHAR(20) CHECK (payout_mode IN ('cash', 'bank_transfer', 'gateway')),
+    ADD COLUMN IF NOT EXISTS payout_reference TEXT,
+    ADD COLUMN IF NOT EXISTS online_payment_id UUID REFERENCES finance.online_payments(id),
+    ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES auth.users(id);
+
+CREATE INDEX IF NOT EXISTS idx_refunds_invoice
+    ON finance.refunds(invoice_id) WHERE invoice_id IS NOT NULL;
+
+-- A gateway payout holds the refund in processing until the
+-- gateway has answered.
+INSERT INTO enums.refund_status (code) VALUES ('processing')
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE finance.invoices
+    ADD COLUMN IF NOT EXISTS refunded_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+-- Refunds above second_step_above need approval_steps approvals;
+-- the rest need one. Without a row every refund needs one.
+CREATE TABLE IF NOT EXISTS finance.refund_settings (
+    institute_id      UUID PRIMARY KEY REFERENCES core.institutes(id),
+    approval_steps    INT NOT NULL DEFAULT 1 CHECK (approval_steps BETWEEN 1 AND 5),
+    second_step_above NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (second_step_above >= 0),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by        UUID REFERENCES auth.users(id)
+);
+
+-- =========================================================
+-- FINANCE: BANK STATEMENT IMPORT AND RECONCILIATION
+-- Statement lines are imported per bank account. Each line
+-- carries a hash of its contents and of how many identical
+-- lines came before it in the file, so importing the same or
+-- an overlapping statement again skips the lines already in.
+-- A line is reconciled against at most one fee transaction,
+-- or on its own when it is accounted for elsewhere.
+-- =========================================================
+-- The ledger account the bank account posts to; without one
+-- it is the bank account of the ledger settings.
+ALTER TABLE finance.bank_accounts
+    ADD COLUMN IF NOT EXISTS ledger_account_id UUID REFERENCES finance.accounts(id);
+
+CREATE TABLE IF NOT EXISTS finance.bank_statement_imports (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id    UUID NOT NULL REFERENCES core.institutes(id),
+    bank_account_id UUID NOT NULL REFERENCES finance.bank_accounts(id),
+    format          VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'mt940', 'camt053')),
+    file_name       TEXT,
+    lines_total     INT NOT NULL DEFAULT 0,
+    lines_imported  INT NOT NULL DEFAULT 0,
+    lines_duplicate INT NOT NULL DEFAULT 0,
+    lines_matched   INT NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by      UUID REFERENCES auth.users(id)
+);
+
+ALTER TABLE finance.bank_statement_entries
+    ADD COLUMN IF NOT EXISTS import_id UUID REFERENCES finance.bank_statement_imports(id),
+    ADD COLUMN IF NOT EXISTS line_no INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS reference TEXT,
+    ADD COLUMN IF NOT EXISTS line_hash TEXT,
+    ADD COLUMN IF NOT EXISTS match_method VARCHAR(10) CHECK (match_method IN ('auto', 'manual')),
+    ADD COLUMN IF NOT EXISTS match_remarks TEXT,
+    ADD COLUMN IF NOT EXISTS matched_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS matched_by UUID REFERENCES auth.users(id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_bank_statement_entries_line
+    ON finance.bank_statement_entries(bank_account_id, line_hash)
+    WHERE line_hash IS NOT NULL AND deleted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_bank_statement_entries_transaction
+    ON finance.bank_statement_entries(reconciled_transaction_id)
+    WHERE reconciled_transaction_id IS NOT NULL AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_bank_statement_entries_account
+    ON finance.bank_statement_entries(bank_account_id, transaction_date);
+
+-- =========================================================
+-- FINANCE: BUDGETS
+-- A budget allocates an amount to a department for one
+-- expense account (its expense category) in an academic
+-- session. Every change to what it has used is a row in
+-- budget_utilisations: approved purchase orders commit an
+-- amount, and receiving or cancelling the order releases it,
+-- while expense journals carrying the department add to the
+-- actual spend. utilized_amount is the running sum of them.
+-- =========================================================
+-- Spending past soft_limit_percent of the allocation needs
+-- approval; past hard_limit_percent it is refused. Without a
+-- hard limit nothing is refused.
+ALTER TABLE finance.budgets
+    ADD COLUMN IF NOT EXISTS soft_limit_percent NUMERIC(5,2) NOT NULL DEFAULT 100 CHECK (soft_limit_percent > 0),
+    ADD COLUMN IF NOT EXISTS hard_limit_percent NUMERIC(5,2) CHECK (hard_limit_percent IS NULL OR hard_limit_percent > 0),
+    ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES auth.users(id),
+    ADD COLUMN IF NOT EXISTS updated_by UUID REFERENCES auth.users(id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_budgets_line
+    ON finance.budgets(institute_id, academic_session_id, department_id, expense_category_id)
+    WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS finance.budget_utilisations (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id UUID NOT NULL REFERENCES core.institutes(id),
+    budget_id    UUID NOT NULL REFERENCES finance.budgets(id),
+    kind         VARCHAR(10) NOT NULL CHECK (kind IN ('committed', 'actual')),
+    source_type  VARCHAR(20) NOT NULL CHECK (source_type IN ('purchase_order', 'journal')),
+    source_id    UUID NOT NULL,
+    amount       NUMERIC(12,2) NOT NULL, -- negative when a commitment is released or a journal reversed
+    occurred_on  DATE NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_utilisations_budget
+    ON finance.budget_utilisations(budget_id, occurred_on);
+
+CREATE INDEX IF NOT EXISTS idx_budget_utilisations_source
+    ON finance.budget_utilisations(source_type, source_id);
+
+-- Purchase orders are budgeted against the department and
+-- expense account they are raised for; pending_approval holds
+-- an order waiting on core.approvals past the soft limit.
+ALTER TABLE finance.purchase_orders
+    ADD COLUMN IF NOT EXISTS department_id UUID REFERENCES core.departments(id),
+    ADD COLUMN IF NOT EXISTS expense_account_id UUID REFERENCES finance.accounts(id);
+
+ALTER TABLE finance.journal_items
+    ADD COLUMN IF NOT EXISTS department_id UUID REFERENCES core.departments(id);
+
+-- =========================================================
+-- FINANCE: GST
+-- Tax rates are attached to fee heads and inventory items.
+-- Fee amounts include the tax, which is carved out of them
+-- when an invoice is raised; purchase order items are priced
+-- before tax, which is added on top. Supplies within the
+-- institute's state are taxed as CGST and SGST in equal
+-- halves, supplies from another state as IGST. Each taxed
+-- line is kept in tax_lines for the filing summary.
+-- =========================================================
+-- GST state codes, the first two digits of a GSTIN
+ALTER TABLE geo.states
+    ADD COLUMN IF NOT EXISTS gst_code CHAR(2);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_states_gst_code
+    ON geo.states(gst_code)
+    WHERE gst_code IS NOT NULL AND deleted_at IS NULL;
+
+UPDATE geo.states s
+SET gst_code = v.code
+FROM (VALUES
+    ('Jammu and Kashmir', '01'), ('Himachal Pradesh', '02'), ('Punjab', '03'),
+    ('Chandigarh', '04'), ('Uttarakhand', '05'), ('Haryana', '06'), ('Delhi', '07'),
+    ('Rajasthan', '08'), ('Uttar Pradesh', '09'), ('Bihar', '10'), ('Sikkim', '11'),
+    ('Arunachal Pradesh', '12'), ('Nagaland', '13'), ('Manipur', '14'), ('Mizoram', '15'),
+    ('Tripura', '16'), ('Meghalaya', '17'), ('Assam', '18'), ('West Bengal', '19'),
+    ('Jharkhand', '20'), ('Odisha', '21'), ('Chhattisgarh', '22'), ('Madhya Pradesh', '23'),
+    ('Gujarat', '24'), ('Dadra and Nagar Haveli and Daman and Diu', '26'),
+    ('Maharashtra', '27'), ('Karnataka', '29'), ('Goa', '30'), ('Lakshadweep', '31'),
+    ('Kerala', '32'), ('Tamil Nadu', '33'), ('Puducherry', '34'),
+    ('Andaman and Nicobar Islands', '35'), ('Telangana', '36'), ('Andhra Pradesh', '37'),
+    ('Ladakh', '38')
+) AS v(name, code)
+WHERE s.name = v.name AND s.gst_code IS NULL;
+
+-- The institute's GST registration and the accounts tax is
+-- posted to. Output tax is what is charged on fees, input tax
+-- what is paid on purchases.
+CREATE TABLE IF NOT EXISTS finance.tax_settings (
+    institute_id           UUID PRIMARY KEY REFERENCES core.institutes(id),
+    gstin                  VARCHAR(15) NOT NULL,
+    state_id               UUID NOT NULL REFERENCES geo.states(id),
+    output_cgst_account_id UUID NOT NULL REFERENCES finance.accounts(id),
+    output_sgst_account_id UUID NOT NULL REFERENCES finance.accounts(id),
+    output_igst_account_id UUID NOT NULL REFERENCES finance.accounts(id),
+    input_cgst_account_id  UUID NOT NULL REFERENCES finance.accounts(id),
+    input_sgst_account_id  UUID NOT NULL REFERENCES finance.accounts(id),
+    input_igst_account_id  UUID NOT NULL REFERENCES finance.accounts(id),
+    updated_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by             UUID REFERENCES auth.users(id)
+);
+
+ALTER TABLE finance.fee_heads
+    ADD COLUMN IF NOT EXISTS tax_id UUID REFERENCES finance.taxes(id);
+
+ALTER TABLE inventory.items
+    ADD COLUMN IF NOT EXISTS tax_id UUID REFERENCES finance.taxes(id);
+
+-- Without a state a vendor's state is read from its GSTIN
+ALTER TABLE finance.vendors
+    ADD COLUMN IF NOT EXISTS state_id UUID REFERENCES geo.states(id);
+
+-- One row per taxed invoice or purchase order item. Purchase
+-- lines are dated when the goods are received; until then
+-- they stay out of the summary.
+CREATE TABLE IF NOT EXISTS finance.tax_lines (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id       UUID NOT NULL REFERENCES core.institutes(id),
+    direction          VARCHAR(6) NOT NULL CHECK (direction IN ('output', 'input')),
+    document_type      VARCHAR(20) NOT NULL CHECK (document_type IN ('invoice', 'purchase_order')),
+    document_id        UUID NOT NULL,
+    item_id            UUID NOT NULL,
+    tax_id             UUID NOT NULL REFERENCES finance.taxes(id),
+    rate               NUMERIC(5,2) NOT NULL,
+    supply             VARCHAR(12) NOT NULL CHECK (supply IN ('intra_state', 'inter_state')),
+    taxable_amount     NUMERIC(12,2) NOT NULL,
+    cgst               NUMERIC(12,2) NOT NULL DEFAULT 0,
+    sgst               NUMERIC(12,2) NOT NULL DEFAULT 0,
+    igst               NUMERIC(12,2) NOT NULL DEFAULT 0,
+    counterparty_gstin VARCHAR(15),
+    tax_date           DATE,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (document_type, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_lines_document
+    ON finance.tax_lines(document_type, document_id);
+
+CREATE INDEX IF NOT EXISTS idx_tax_lines_date
+    ON finance.tax_lines(institute_id, tax_date)
+    WHERE tax_date IS NOT NULL;
+
+-- =========================================================
+-- FINANCE: PROCUREMENT CYCLE
+-- Departments raise requisitions, which approvers turn into
+-- purchase orders, one per vendor. Goods arrive in one or more
+-- goods receipts that add to stock and post what was received
+-- to payables. Vendor bills are matched against the order's
+-- prices and the quantities received before they can be paid;
+-- bills that do not match are held for a decision.
+-- =========================================================
+ALTER TABLE inventory.requisitions
+    ADD COLUMN IF NOT EXISTS remarks TEXT,
+    ADD COLUMN IF NOT EXISTS decided_by UUID REFERENCES auth.users(id),
+    ADD COLUMN IF NOT EXISTS decided_at TIMESTAMPTZ;
+
+-- ordered_quantity is what has been put on purchase orders so far
+ALTER TABLE inventory.requisition_items
+    ADD COLUMN IF NOT EXISTS item_id UUID REFERENCES inventory.items(id),
+    ADD COLUMN IF NOT EXISTS ordered_quantity INT NOT NULL DEFAULT 0;
+
+ALTER TABLE finance.purchase_orders
+    ADD COLUMN IF NOT EXISTS requisition_id UUID REFERENCES inventory.requisitions(id);
+
+ALTER TABLE finance.purchase_order_items
+    ADD COLUMN IF NOT EXISTS requisition_item_id UUID REFERENCES inventory.requisition_items(id),
+    ADD COLUMN IF NOT EXISTS received_quantity INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS billed_quantity INT NOT NULL DEFAULT 0;
+
+-- Bills are only accepted for goods that have been received
+ALTER TABLE finance.purchase_order_items
+    DROP CONSTRAINT IF EXISTS purchase_order_items_billed_check,
+    ADD CONSTRAINT purchase_order_items_billed_check
+        CHECK (billed_quantity <= received_quantity AND received_quantity <= quantity);
+
+-- Stock is kept per item and location, so receipts can add to it
+CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_levels_item_location
+    ON inventory.stock_levels(institute_id, item_id, location_id)
+    WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS finance.goods_receipts (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id      UUID NOT NULL REFERENCES core.institutes(id),
+    purchase_order_id UUID NOT NULL REFERENCES finance.purchase_orders(id),
+    location_id       UUID NOT NULL REFERENCES inventory.locations(id),
+    received_on       DATE NOT NULL,
+    reference_no      VARCHAR(50), -- the vendor's delivery challan
+    remarks           TEXT,
+    amount            NUMERIC(12,2) NOT NULL DEFAULT 0,
+    created_by        UUID REFERENCES auth.users(id),
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_goods_receipts_order
+    ON finance.goods_receipts(purchase_order_id);
+
+CREATE TABLE IF NOT EXISTS finance.goods_receipt_items (
+    id                     UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id           UUID NOT NULL REFERENCES core.institutes(id),
+    goods_receipt_id       UUID NOT NULL REFERENCES finance.goods_receipts(id),
+    purchase_order_item_id UUID NOT NULL REFERENCES finance.purchase_order_items(id),
+    item_id                UUID NOT NULL REFERENCES inventory.items(id),
+    quantity               INT NOT NULL CHECK (quantity > 0),
+    unit_price             NUMERIC(12,2) NOT NULL,
+    tax_amount             NUMERIC(12,2) NOT NULL DEFAULT 0,
+    amount                 NUMERIC(12,2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_goods_receipt_items_receipt
+    ON finance.goods_receipt_items(goods_receipt_id);
+
+-- GST on purchases is recorded as the goods are received, one
+-- line per goods receipt item
+ALTER TABLE finance.tax_lines
+    DROP CONSTRAINT IF EXISTS tax_lines_document_type_check,
+    ADD CONSTRAINT tax_lines_document_type_check
+        CHECK (document_type IN ('invoice', 'purchase_order', 'goods_receipt'));
+
+-- match_notes lists why a held bill did not match its order
+CREATE TABLE IF NOT EXISTS finance.vendor_bills (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id      UUID NOT NULL REFERENCES core.institutes(id),
+    vendor_id         UUID NOT NULL REFERENCES finance.vendors(id),
+    purchase_order_id UUID NOT NULL REFERENCES finance.purchase_orders(id),
+    bill_no           VARCHAR(50) NOT NULL,
+    bill_date         DATE NOT NULL,
+    due_date          DATE NOT NULL,
+    amount            NUMERIC(12,2) NOT NULL,
+    tax_amount        NUMERIC(12,2) NOT NULL DEFAULT 0,
+    paid_amount       NUMERIC(12,2) NOT NULL DEFAULT 0,
+    status            VARCHAR(10) NOT NULL
+                      CHECK (status IN ('matched', 'on_hold', 'approved', 'rejected', 'paid')),
+    match_notes       TEXT,
+    decided_by        UUID REFERENCES auth.users(id),
+    decided_at        TIMESTAMPTZ,
+    remarks           TEXT,
+    created_by        UUID REFERENCES auth.users(id),
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (institute_id, vendor_id, bill_no)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vendor_bills_open
+    ON finance.vendor_bills(institute_id, vendor_id, due_date)
+    WHERE status IN ('matched', 'on_hold', 'approved');
+
+CREATE TABLE IF NOT EXISTS finance.vendor_bill_items (
+    id                     UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id           UUID NOT NULL REFERENCES core.institutes(id),
+    vendor_bill_id         UUID NOT NULL REFERENCES finance.vendor_bills(id),
+    purchase_order_item_id UUID NOT NULL REFERENCES finance.purchase_order_items(id),
+    quantity               INT NOT NULL CHECK (quantity > 0),
+    unit_price             NUMERIC(12,2) NOT NULL,
+    tax_amount             NUMERIC(12,2) NOT NULL DEFAULT 0,
+    amount                 NUMERIC(12,2) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS finance.vendor_payments (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id   UUID NOT NULL REFERENCES core.institutes(id),
+    vendor_bill_id UUID NOT NULL REFERENCES finance.vendor_bills(id),
+    vendor_id      UUID NOT NULL REFERENCES finance.vendors(id),
+    amount         NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    payment_mode   VARCHAR(20) NOT NULL,
+    paid_on        DATE NOT NULL,
+    reference_no   VARCHAR(50),
+    created_by     UUID REFERENCES auth.users(id),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vendor_payments_bill
+    ON finance.vendor_payments(vendor_bill_id);
+
+-- =========================================================
+-- FINANCE: FEE DUNNING
+-- Dunning steps are an institute's reminder schedule. Once an
+-- invoice is days_overdue days past due the step reminds the
+-- student's primary guardians on the channels it names; each
+-- step is sent at most once per invoice, and later steps carry
+-- firmer wording. Students on an approved payment plan are not
+-- reminded while the plan runs.
+-- =========================================================
+CREATE TABLE IF NOT EXISTS finance.dunning_steps (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id UUID NOT NULL REFERENCES core.institutes(id),
+    days_overdue INT NOT NULL CHECK (days_overdue > 0),
+    subject      VARCHAR(200) NOT NULL,
+    message      TEXT NOT NULL, -- may use {student}, {invoice_no}, {amount}, {due_date} and {days}
+    send_email   BOOLEAN NOT NULL DEFAULT FALSE,
+    send_sms     BOOLEAN NOT NULL DEFAULT FALSE,
+    send_in_app  BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active    BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by   UUID REFERENCES auth.users(id),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by   UUID REFERENCES auth.users(id),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (institute_id, days_overdue),
+    CHECK (send_email OR send_sms OR send_in_app)
+);
+
+-- recipients counts the guardians reminded, failures the
+-- deliveries that did not go through
+CREATE TABLE IF NOT EXISTS finance.dunning_reminders (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id    UUID NOT NULL REFERENCES core.institutes(id),
+    invoice_id      UUID NOT NULL REFERENCES finance.invoices(id),
+    student_id      UUID NOT NULL REFERENCES core.students(id),
+    dunning_step_id UUID NOT NULL REFERENCES finance.dunning_steps(id),
+    days_overdue    INT NOT NULL,
+    amount_due      NUMERIC(12,2) NOT NULL,
+    recipients      INT NOT NULL DEFAULT 0,
+    failures        INT NOT NULL DEFAULT 0,
+    sent_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (invoice_id, dunning_step_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dunning_reminders_student
+    ON finance.dunning_reminders(institute_id, student_id);
+
+CREATE TABLE IF NOT EXISTS finance.payment_plans (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    institute_id UUID NOT NULL REFERENCES core.institutes(id),
+    student_id   UUID NOT NULL REFERENCES core.students(id),
+    amount       NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    installments INT NOT NULL CHECK (installments > 0),
+    start_date   DATE NOT NULL,
+    end_date     DATE NOT NULL,
+    reason       TEXT NOT NULL,
+    status       VARCHAR(10) NOT NULL DEFAULT 'pending'
+                 CHECK (status IN ('pending', 'approved', 'rejected')),
+    remarks      TEXT,
+    decided_by   UUID REFERENCES auth.users(id),
+    decided_at   TIMESTAMPTZ,
+    created_by   UUID REFERENCES auth.users(id),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_plans_approved
+    ON finance.payment_plans(institute_id, student_id, start_date, end_date)
+    WHERE status = 'approved';
@@ -92,3 +92,84 @@ type StudentSubmission struct {
 	Feedback      *string    `json:"feedback,omitempty" db:"feedback"`
 	Status        string     `json:"status" db:"status"` // submitted, graded
 }
+
+// TimetableSlot is a timetable entry with its period, subject and teacher
+// resolved for display
+type TimetableSlot struct {
+	EntryID     uuid.UUID  `json:"entry_id"`
+	DayOfWeek   DayOfWeek  `json:"day_of_week"`
+	PeriodID    *uuid.UUID `json:"period_id,omitempty"`
+	PeriodName  *string    `json:"period_name,omitempty"`
+	StartTime   *time.Time `json:"start_time,omitempty"`
+	EndTime     *time.Time `json:"end_time,omitempty"`
+	SubjectID   *uuid.UUID `json:"subject_id,omitempty"`
+	SubjectName *string    `json:"subject_name,omitempty"`
+	TeacherID   *uuid.UUID `json:"teacher_id,omitempty"`
+	TeacherName *string    `json:"teacher_name,omitempty"`
+}
+
+// Corresponds to schema: academics.student_attendance
+// PeriodID is nil for the daily register.
+type StudentAttendance struct {
+	TenantUUIDModel
+	ClassID        uuid.UUID        `json:"class_id" db:"class_id"`
+	StudentID      uuid.UUID        `json:"student_id" db:"student_id"`
+	AttendanceDate time.Time        `json:"attendance_date" db:"attendance_date"`
+	PeriodID       *uuid.UUID       `json:"period_id,omitempty" db:"period_id"`
+	Status         AttendanceStatus `json:"status" db:"status"`
+	Remarks        *string          `json:"remarks,omitempty" db:"remarks"`
+}
+
+// AttendanceRegisterEntry is one row of a class register: a student and the
+// status marked for them, if any
+type AttendanceRegisterEntry struct {
+	StudentID   uuid.UUID         `json:"student_id"`
+	AdmissionNo string            `json:"admission_no"`
+	StudentName string            `json:"student_name"`
+	Status      *AttendanceStatus `json:"status,omitempty"`
+	Remarks     *string           `json:"remarks,omitempty"`
+}
+
+// AttendanceSummary counts a student's daily statuses over a date range
+type AttendanceSummary struct {
+	StudentID   uuid.UUID `json:"student_id"`
+	AdmissionNo string    `json:"admission_no,omitempty"`
+	StudentName string    `json:"student_name,omitempty"`
+	Present     int       `json:"present"`
+	Absent      int       `json:"absent"`
+	Late        int       `json:"late"`
+	HalfDay     int       `json:"half_day"`
+	OnLeave     int       `json:"on_leave"`
+	MarkedDays  int       `json:"marked_days"`
+	Percentage  float64   `json:"percentage"`
+}
+
+// ClassRegister is the attendance sheet of a class for one day or period
+type ClassRegister struct {
+	ClassID  uuid.UUID                  `json:"class_id"`
+	Date     time.Time                  `json:"date"`
+	PeriodID *uuid.UUID                 `json:"period_id,omitempty"`
+	Holiday  *string                    `json:"holiday,omitempty"`
+	Entries  []*AttendanceRegisterEntry `json:"entries"`
+}
+
+// StudentAttendanceReport is a student's daily attendance over a date range
+type StudentAttendanceReport struct {
+	StudentID uuid.UUID            `json:"student_id"`
+	From      time.Time            `json:"from"`
+	To        time.Time            `json:"to"`
+	Summary   AttendanceSummary    `json:"summary"`
+	Days      []*StudentAttendance `json:"days"`
+	Holidays  []*CalendarEvent     `json:"holidays"`
+}
+
+// ClassAttendanceReport summarises every student of a class over a date range
+type ClassAttendanceReport struct {
+	ClassID           uuid.UUID            `json:"class_id"`
+	From              time.Time            `json:"from"`
+	To                time.Time            `json:"to"`
+	RegisterDays      int                  `json:"register_days"`
+	Holidays          []*CalendarEvent     `json:"holidays"`
+	AveragePercentage float64              `json:"average_percentage"`
+	Students          []*AttendanceSummary `json:"students"`
+}
@@ -1,14 +1,37 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // Corresponds to schema: auth.users
 type User struct {
 	BaseUUIDModel
 	Username       string     `json:"username" db:"username"`
+	Email          *string    `json:"email,omitempty" db:"email"`
+	PhoneNumber    *string    `json:"phone_number,omitempty" db:"phone_number"`
 	PasswordHash   string     `json:"-" db:"password_hash"` // Never expose
 	RoleType       UserRole   `json:"role_type" db:"role_type"`
 	LinkedEntityID uuid.UUID  `json:"linked_entity_id" db:"linked_entity_id"`   // Links to Student/Employee
 	InstituteID    *uuid.UUID `json:"institute_id,omitempty" db:"institute_id"` // Nullable for SuperAdmin
 	IsActive       bool       `json:"is_active" db:"is_active"`
 }
+
+// Corresponds to schema: auth.user_credentials
+type UserCredentials struct {
+	UserID            uuid.UUID  `json:"user_id" db:"user_id"`
+	FailedAttempts    int        `json:"failed_attempts" db:"failed_attempts"`
+	LockedUntil       *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty" db:"password_changed_at"`
+	TOTPSecret        string     `json:"-" db:"totp_secret"` // AES-GCM sealed, never expose
+	TOTPEnabled       bool       `json:"totp_enabled" db:"totp_enabled"`
+	TOTPEnabledAt     *time.Time `json:"totp_enabled_at,omitempty" db:"totp_enabled_at"`
+	TOTPLastStep      int64      `json:"-" db:"totp_last_step"`
+}
+
+// IsLocked reports whether password and TOTP logins are currently refused.
+func (c *UserCredentials) IsLocked(now time.Time) bool {
+	return c.LockedUntil != nil && now.Before(*c.LockedUntil)
+}
@@ -210,19 +210,167 @@ const (
 type PurchaseStatus string
 
 const (
-	PurchaseDraft     PurchaseStatus = "draft"
-	PurchaseOrdered   PurchaseStatus = "ordered"
-	PurchaseReceived  PurchaseStatus = "received"
-	PurchaseCancelled PurchaseStatus = "cancelled"
+	PurchaseDraft             PurchaseStatus = "draft"
+	PurchasePendingApproval   PurchaseStatus = "pending_approval" // over the budget's soft limit
+	PurchaseOrdered           PurchaseStatus = "ordered"
+	PurchasePartiallyReceived PurchaseStatus = "partially_received"
+	PurchaseReceived          PurchaseStatus = "received"
+	PurchaseClosed            PurchaseStatus = "closed" // partially received, the rest no longer expected
+	PurchaseCancelled         PurchaseStatus = "cancelled"
+)
+
+type RequisitionStatus string
+
+const (
+	RequisitionPending  RequisitionStatus = "pending"
+	RequisitionApproved RequisitionStatus = "approved" // partly put on purchase orders
+	RequisitionOrdered  RequisitionStatus = "ordered"
+	RequisitionRejected RequisitionStatus = "rejected"
+)
+
+// VendorBillStatus is the outcome of matching a bill with its order and receipts
+type VendorBillStatus string
+
+const (
+	VendorBillMatched  VendorBillStatus = "matched"
+	VendorBillOnHold   VendorBillStatus = "on_hold" // did not match, waiting on a decision
+	VendorBillApproved VendorBillStatus = "approved"
+	VendorBillRejected VendorBillStatus = "rejected"
+	VendorBillPaid     VendorBillStatus = "paid"
+)
+
+// TaxDirection tells tax charged on fees from tax paid on purchases
+type TaxDirection string
+
+const (
+	TaxOutput TaxDirection = "output"
+	TaxInput  TaxDirection = "input"
+)
+
+// SupplyType decides how GST is split
+type SupplyType string
+
+const (
+	SupplyIntraState SupplyType = "intra_state" // CGST and SGST
+	SupplyInterState SupplyType = "inter_state" // IGST
+)
+
+// BudgetUsage is what a row of finance.budget_utilisations records
+type BudgetUsage string
+
+const (
+	BudgetCommitted BudgetUsage = "committed" // approved purchase orders not yet received
+	BudgetActual    BudgetUsage = "actual"    // received orders and expense journals
 )
 
 type RefundStatus string
 
 const (
-	RefundRequested RefundStatus = "requested"
-	RefundApproved  RefundStatus = "approved"
-	RefundProcessed RefundStatus = "processed"
-	RefundRejected  RefundStatus = "rejected"
+	RefundRequested  RefundStatus = "requested"
+	RefundApproved   RefundStatus = "approved"
+	RefundProcessing RefundStatus = "processing" // being paid out by the gateway
+	RefundProcessed  RefundStatus = "processed"
+	RefundRejected   RefundStatus = "rejected"
+)
+
+// RefundPayoutMode is how a refund reaches the family
+type RefundPayoutMode string
+
+const (
+	RefundByCash         RefundPayoutMode = "cash"
+	RefundByBankTransfer RefundPayoutMode = "bank_transfer"
+	RefundByGateway      RefundPayoutMode = "gateway" // back through the online payment
+)
+
+// DunningChannel is a way a fee reminder reaches a guardian
+type DunningChannel string
+
+const (
+	DunningEmail DunningChannel = "email"
+	DunningSMS   DunningChannel = "sms"
+	DunningInApp DunningChannel = "in_app" // a notification on the guardian's login
+)
+
+type ConcessionType string
+
+const (
+	ConcessionFlat       ConcessionType = "flat"
+	ConcessionPercentage ConcessionType = "percentage"
+)
+
+// ApprovalStatus is the state of a request in core.approvals
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "pending"
+	ApprovalApproved ApprovalStatus = "approved"
+	ApprovalRejected ApprovalStatus = "rejected"
+)
+
+// ChequeStatus follows a cheque from the counter to the bank (enums.cheque_status)
+type ChequeStatus string
+
+const (
+	ChequePending ChequeStatus = "pending"
+	ChequeCleared ChequeStatus = "cleared"
+	ChequeBounced ChequeStatus = "bounced"
+)
+
+// StatementMatchMethod says how a statement line was reconciled
+type StatementMatchMethod string
+
+const (
+	MatchAuto   StatementMatchMethod = "auto"
+	MatchManual StatementMatchMethod = "manual"
+)
+
+// OnlinePaymentStatus is the state of a gateway checkout
+type OnlinePaymentStatus string
+
+const (
+	OnlinePaymentCreated OnlinePaymentStatus = "created"
+	OnlinePaymentPaid    OnlinePaymentStatus = "paid"
+	OnlinePaymentFailed  OnlinePaymentStatus = "failed"
+)
+
+// WalletEntryType is the direction of a student wallet movement
+type WalletEntryType string
+
+const (
+	WalletTopUp  WalletEntryType = "topup"
+	WalletDebit  WalletEntryType = "debit"
+	WalletRefund WalletEntryType = "refund"
+)
+
+// WalletPurpose says what a wallet movement was for
+type WalletPurpose string
+
+const (
+	WalletForDeposit     WalletPurpose = "deposit"
+	WalletForFees        WalletPurpose = "fees"
+	WalletForCafeteria   WalletPurpose = "cafeteria"
+	WalletForLibraryFine WalletPurpose = "library_fine"
+	WalletForOther       WalletPurpose = "other"
+	WalletForRefund      WalletPurpose = "refund"
+)
+
+// JournalSource is the kind of document an automatically posted journal
+// entry came from
+type JournalSource string
+
+const (
+	JournalSourceInvoice       JournalSource = "invoice"
+	JournalSourceInvoiceItem   JournalSource = "invoice_item"
+	JournalSourceTransaction   JournalSource = "transaction"
+	JournalSourceRefund        JournalSource = "refund"
+	JournalSourcePurchaseOrder JournalSource = "purchase_order"
+	JournalSourceInvoiceFine   JournalSource = "invoice_fine"
+	JournalSourceFineWaiver    JournalSource = "fine_waiver"
+	JournalSourceChequeBounce  JournalSource = "cheque_bounce"
+	JournalSourceWalletEntry   JournalSource = "wallet_entry"
+	JournalSourceGoodsReceipt  JournalSource = "goods_receipt"
+	JournalSourceVendorBill    JournalSource = "vendor_bill"
+	JournalSourceVendorPayment JournalSource = "vendor_payment"
 )
 
 // --- HR & OPERATIONS ---
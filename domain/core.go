@@ -113,6 +113,13 @@ type StudentGuardianMap struct {
 	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
 }
 
+// GuardianChild is a student as seen by one of their linked guardians
+type GuardianChild struct {
+	Student
+	Relationship     RelationshipType `json:"relationship"`
+	IsPrimaryContact bool             `json:"is_primary_contact"`
+}
+
 // Corresponds to schema: core.addresses
 type Address struct {
 	BaseUUIDModel
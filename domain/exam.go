@@ -58,3 +58,20 @@ type ExamMark struct {
 	IsAbsent      bool      `json:"is_absent" db:"is_absent"`
 	Remarks       *string   `json:"remarks,omitempty" db:"remarks"`
 }
+
+// ExamResult is a student's mark joined with its exam and subject, as shown
+// on report cards
+type ExamResult struct {
+	MarkID        uuid.UUID `json:"mark_id"`
+	ExamID        uuid.UUID `json:"exam_id"`
+	ExamName      string    `json:"exam_name"`
+	ScheduleID    uuid.UUID `json:"schedule_id"`
+	SubjectID     uuid.UUID `json:"subject_id"`
+	SubjectName   string    `json:"subject_name"`
+	ExamDate      time.Time `json:"exam_date"`
+	MaxMarks      float64   `json:"max_marks"`
+	MinPassMarks  float64   `json:"min_pass_marks"`
+	MarksObtained *float64  `json:"marks_obtained,omitempty"`
+	IsAbsent      bool      `json:"is_absent"`
+	Remarks       *string   `json:"remarks,omitempty"`
+}
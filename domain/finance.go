@@ -17,6 +17,9 @@ type BankAccount struct {
 	OpeningBalance *float64 `json:"opening_balance,omitempty" db:"opening_balance"`
 	CurrentBalance *float64 `json:"current_balance,omitempty" db:"current_balance"`
 	IsActive       bool     `json:"is_active" db:"is_active"`
+	// Ledger account the bank account posts to, the ledger settings' bank
+	// account when not set
+	LedgerAccountID *uuid.UUID `json:"ledger_account_id,omitempty" db:"ledger_account_id"`
 }
 
 // Corresponds to schema: finance.accounts
@@ -27,6 +30,125 @@ type Account struct {
 	ParentAccountID *uuid.UUID  `json:"parent_account_id,omitempty" db:"parent_account_id"`
 	Type            AccountType `json:"type" db:"type"` // asset, liability, income...
 	IsSystem        bool        `json:"is_system" db:"is_system"`
+	IsActive        bool        `json:"is_active" db:"is_active"`
+}
+
+// AccountBalance is derived from posted journal items. Balance is signed
+// towards the account type's normal side, so a debit balance on an asset
+// or expense account is positive, as is a credit balance on the others.
+type AccountBalance struct {
+	AccountID uuid.UUID   `json:"account_id"`
+	Code      string      `json:"code"`
+	Name      string      `json:"name"`
+	Type      AccountType `json:"type"`
+	Debit     float64     `json:"debit"`
+	Credit    float64     `json:"credit"`
+	Balance   float64     `json:"balance"`
+}
+
+// Corresponds to schema: finance.fiscal_year_closings
+type FiscalYearClosing struct {
+	ID                        uuid.UUID  `json:"id" db:"id"`
+	InstituteID               uuid.UUID  `json:"institute_id" db:"institute_id"`
+	FiscalYearStart           time.Time  `json:"fiscal_year_start" db:"fiscal_year_start"`
+	FiscalYearEnd             time.Time  `json:"fiscal_year_end" db:"fiscal_year_end"`
+	JournalEntryID            *uuid.UUID `json:"journal_entry_id,omitempty" db:"journal_entry_id"`
+	RetainedEarningsAccountID uuid.UUID  `json:"retained_earnings_account_id" db:"retained_earnings_account_id"`
+	NetIncome                 float64    `json:"net_income" db:"net_income"`
+	ClosedAt                  time.Time  `json:"closed_at" db:"closed_at"`
+	ClosedBy                  *uuid.UUID `json:"closed_by,omitempty" db:"closed_by"`
+}
+
+// StatementLine is an account in a financial statement with its
+// sub-accounts nested below it. Amounts include the sub-accounts and are
+// signed towards the account's normal side.
+type StatementLine struct {
+	AccountID   uuid.UUID        `json:"account_id"`
+	Code        string           `json:"code"`
+	Name        string           `json:"name"`
+	Depth       int              `json:"depth"`
+	Amount      float64          `json:"amount"`
+	PriorAmount float64          `json:"prior_amount"`
+	Children    []*StatementLine `json:"children,omitempty"`
+}
+
+type StatementSection struct {
+	Lines      []*StatementLine `json:"lines"`
+	Total      float64          `json:"total"`
+	PriorTotal float64          `json:"prior_total"`
+}
+
+type IncomeStatement struct {
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	PriorFrom      time.Time        `json:"prior_from"`
+	PriorTo        time.Time        `json:"prior_to"`
+	Income         StatementSection `json:"income"`
+	Expenses       StatementSection `json:"expenses"`
+	NetIncome      float64          `json:"net_income"`
+	PriorNetIncome float64          `json:"prior_net_income"`
+}
+
+// BalanceSheet reports balances as of a date. CurrentEarnings is income
+// less expenses not yet closed into retained earnings.
+type BalanceSheet struct {
+	AsOf                           time.Time        `json:"as_of"`
+	PriorAsOf                      time.Time        `json:"prior_as_of"`
+	Assets                         StatementSection `json:"assets"`
+	Liabilities                    StatementSection `json:"liabilities"`
+	Equity                         StatementSection `json:"equity"`
+	CurrentEarnings                float64          `json:"current_earnings"`
+	PriorCurrentEarnings           float64          `json:"prior_current_earnings"`
+	TotalLiabilitiesAndEquity      float64          `json:"total_liabilities_and_equity"`
+	PriorTotalLiabilitiesAndEquity float64          `json:"prior_total_liabilities_and_equity"`
+}
+
+// TrialBalanceAmounts are debit and credit balances; only one side of each
+// pair is non-zero. Prior is the closing balance at the end of the prior period.
+type TrialBalanceAmounts struct {
+	OpeningDebit       float64 `json:"opening_debit"`
+	OpeningCredit      float64 `json:"opening_credit"`
+	PeriodDebit        float64 `json:"period_debit"`
+	PeriodCredit       float64 `json:"period_credit"`
+	ClosingDebit       float64 `json:"closing_debit"`
+	ClosingCredit      float64 `json:"closing_credit"`
+	PriorClosingDebit  float64 `json:"prior_closing_debit"`
+	PriorClosingCredit float64 `json:"prior_closing_credit"`
+}
+
+type TrialBalanceLine struct {
+	AccountID uuid.UUID   `json:"account_id"`
+	Code      string      `json:"code"`
+	Name      string      `json:"name"`
+	Type      AccountType `json:"type"`
+	Depth     int         `json:"depth"`
+	TrialBalanceAmounts
+	Children []*TrialBalanceLine `json:"children,omitempty"`
+}
+
+type TrialBalance struct {
+	From      time.Time           `json:"from"`
+	To        time.Time           `json:"to"`
+	PriorFrom time.Time           `json:"prior_from"`
+	PriorTo   time.Time           `json:"prior_to"`
+	Lines     []*TrialBalanceLine `json:"lines"`
+	Totals    TrialBalanceAmounts `json:"totals"`
+}
+
+// Corresponds to schema: finance.ledger_settings
+// The control accounts automatic postings use. A nil ConcessionsAccountID
+// charges concessions to the fee head's own income account.
+type LedgerSettings struct {
+	InstituteID              uuid.UUID  `json:"institute_id" db:"institute_id"`
+	ReceivablesAccountID     uuid.UUID  `json:"receivables_account_id" db:"receivables_account_id"`
+	CashAccountID            uuid.UUID  `json:"cash_account_id" db:"cash_account_id"`
+	BankAccountID            uuid.UUID  `json:"bank_account_id" db:"bank_account_id"`
+	PayablesAccountID        uuid.UUID  `json:"payables_account_id" db:"payables_account_id"`
+	PurchasesAccountID       uuid.UUID  `json:"purchases_account_id" db:"purchases_account_id"`
+	ConcessionsAccountID     *uuid.UUID `json:"concessions_account_id,omitempty" db:"concessions_account_id"`
+	StudentAdvancesAccountID *uuid.UUID `json:"student_advances_account_id,omitempty" db:"student_advances_account_id"`
+	UpdatedAt                time.Time  `json:"updated_at" db:"updated_at"`
+	UpdatedBy                *uuid.UUID `json:"updated_by,omitempty" db:"updated_by"`
 }
 
 // Corresponds to schema: finance.taxes
@@ -38,30 +160,204 @@ type Tax struct {
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
+// Corresponds to schema: finance.tax_settings
+// The institute's GST registration and the accounts tax is posted to
+type TaxSettings struct {
+	InstituteID         uuid.UUID  `json:"institute_id" db:"institute_id"`
+	GSTIN               string     `json:"gstin" db:"gstin"`
+	StateID             uuid.UUID  `json:"state_id" db:"state_id"`
+	OutputCGSTAccountID uuid.UUID  `json:"output_cgst_account_id" db:"output_cgst_account_id"`
+	OutputSGSTAccountID uuid.UUID  `json:"output_sgst_account_id" db:"output_sgst_account_id"`
+	OutputIGSTAccountID uuid.UUID  `json:"output_igst_account_id" db:"output_igst_account_id"`
+	InputCGSTAccountID  uuid.UUID  `json:"input_cgst_account_id" db:"input_cgst_account_id"`
+	InputSGSTAccountID  uuid.UUID  `json:"input_sgst_account_id" db:"input_sgst_account_id"`
+	InputIGSTAccountID  uuid.UUID  `json:"input_igst_account_id" db:"input_igst_account_id"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+	UpdatedBy           *uuid.UUID `json:"updated_by,omitempty" db:"updated_by"`
+}
+
+// Corresponds to schema: finance.tax_lines
+// The tax on one invoice or goods receipt item
+type TaxLine struct {
+	ID                uuid.UUID    `json:"id" db:"id"`
+	InstituteID       uuid.UUID    `json:"institute_id" db:"institute_id"`
+	Direction         TaxDirection `json:"direction" db:"direction"`
+	DocumentType      string       `json:"document_type" db:"document_type"`
+	DocumentID        uuid.UUID    `json:"document_id" db:"document_id"`
+	ItemID            uuid.UUID    `json:"item_id" db:"item_id"`
+	TaxID             uuid.UUID    `json:"tax_id" db:"tax_id"`
+	Rate              float64      `json:"rate" db:"rate"`
+	Supply            SupplyType   `json:"supply" db:"supply"`
+	TaxableAmount     float64      `json:"taxable_amount" db:"taxable_amount"`
+	CGST              float64      `json:"cgst" db:"cgst"`
+	SGST              float64      `json:"sgst" db:"sgst"`
+	IGST              float64      `json:"igst" db:"igst"`
+	CounterpartyGSTIN *string      `json:"counterparty_gstin,omitempty" db:"counterparty_gstin"`
+	TaxDate           *time.Time   `json:"tax_date,omitempty" db:"tax_date"`
+	CreatedAt         time.Time    `json:"created_at" db:"created_at"`
+}
+
+// TaxAmounts totals taxable value and tax by component
+type TaxAmounts struct {
+	Taxable float64 `json:"taxable_amount"`
+	CGST    float64 `json:"cgst"`
+	SGST    float64 `json:"sgst"`
+	IGST    float64 `json:"igst"`
+	Total   float64 `json:"total_tax"`
+}
+
+// TaxSummaryLine totals the tax lines of one direction, supply type and rate
+type TaxSummaryLine struct {
+	Direction TaxDirection `json:"direction"`
+	Supply    SupplyType   `json:"supply"`
+	Rate      float64      `json:"rate"`
+	Lines     int          `json:"lines"`
+	TaxAmounts
+}
+
+// TaxSummary is the GST of a filing period: tax charged on fees, tax paid
+// on purchases received, and what is left to pay per component after
+// setting one against the other. Negative NetPayable amounts carry forward
+// as credit.
+type TaxSummary struct {
+	GSTIN      string           `json:"gstin"`
+	From       time.Time        `json:"from"`
+	To         time.Time        `json:"to"`
+	Lines      []TaxSummaryLine `json:"lines"`
+	Output     TaxAmounts       `json:"output"`
+	Input      TaxAmounts       `json:"input"`
+	NetPayable TaxAmounts       `json:"net_payable"`
+}
+
 // Corresponds to schema: finance.fee_heads
 type FeeHead struct {
 	TenantUUIDModel
-	Name              string    `json:"name" db:"name"`
-	IsRefundable      bool      `json:"is_refundable" db:"is_refundable"`
-	LinkedGLAccountID uuid.UUID `json:"linked_gl_account_id" db:"linked_gl_account_id"`
+	Name              string     `json:"name" db:"name"`
+	IsRefundable      bool       `json:"is_refundable" db:"is_refundable"`
+	LinkedGLAccountID uuid.UUID  `json:"linked_gl_account_id" db:"linked_gl_account_id"`
+	TaxID             *uuid.UUID `json:"tax_id,omitempty" db:"tax_id"` // GST included in the fee
 }
 
 // Corresponds to schema: finance.fine_rules
+// FineAmount is a flat amount for fixed fines, an amount per day for daily
+// fines and a percent of the balance due for percentage fines. Fines are
+// credited to FeeHeadID. The fines of all rules on one invoice are limited
+// to the lowest MaxFineAmount among the active rules.
 type FineRule struct {
 	TenantUUIDModel
-	Name       string   `json:"name" db:"name"`
-	GraceDays  int      `json:"grace_days" db:"grace_days"`
-	FineType   FineType `json:"fine_type" db:"fine_type"`
-	FineAmount float64  `json:"fine_amount" db:"fine_amount"`
-	IsActive   bool     `json:"is_active" db:"is_active"`
+	Name          string    `json:"name" db:"name"`
+	GraceDays     int       `json:"grace_days" db:"grace_days"`
+	FineType      FineType  `json:"fine_type" db:"fine_type"`
+	FineAmount    float64   `json:"fine_amount" db:"fine_amount"`
+	IsActive      bool      `json:"is_active" db:"is_active"`
+	FeeHeadID     uuid.UUID `json:"fee_head_id" db:"fee_head_id"`
+	MaxFineAmount *float64  `json:"max_fine_amount,omitempty" db:"max_fine_amount"`
+}
+
+// Corresponds to schema: finance.invoice_fines
+// A late fee charged on an invoice by a fine rule. Daily fines add a row
+// each time they grow.
+type InvoiceFine struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	InstituteID uuid.UUID  `json:"institute_id" db:"institute_id"`
+	InvoiceID   uuid.UUID  `json:"invoice_id" db:"invoice_id"`
+	FineRuleID  uuid.UUID  `json:"fine_rule_id" db:"fine_rule_id"`
+	AssessedOn  time.Time  `json:"assessed_on" db:"assessed_on"`
+	DaysOverdue int        `json:"days_overdue" db:"days_overdue"`
+	Amount      float64    `json:"amount" db:"amount"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+}
+
+// Corresponds to schema: finance.invoice_fine_waivers
+type FineWaiver struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	InstituteID uuid.UUID  `json:"institute_id" db:"institute_id"`
+	InvoiceID   uuid.UUID  `json:"invoice_id" db:"invoice_id"`
+	FineRuleID  uuid.UUID  `json:"fine_rule_id" db:"fine_rule_id"`
+	Amount      float64    `json:"amount" db:"amount"`
+	Reason      string     `json:"reason" db:"reason"`
+	ApprovedBy  *uuid.UUID `json:"approved_by,omitempty" db:"approved_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// InvoiceFines is the fine history of an invoice
+type InvoiceFines struct {
+	InvoiceID uuid.UUID     `json:"invoice_id"`
+	Fines     []InvoiceFine `json:"fines"`
+	Waivers   []FineWaiver  `json:"waivers"`
+}
+
+// LateFeeRun reports the fines charged by one pass over overdue invoices
+type LateFeeRun struct {
+	AsOf          time.Time     `json:"as_of"`
+	InvoicesFined int           `json:"invoices_fined"`
+	TotalFined    float64       `json:"total_fined"`
+	Fines         []InvoiceFine `json:"fines"`
+}
+
+// Corresponds to schema: finance.payment_gateways
+// Secret and MerchantKey are write-only: they are sealed before storage
+// and never returned.
+type PaymentGatewaySettings struct {
+	InstituteID uuid.UUID  `json:"institute_id" db:"institute_id"`
+	Gateway     string     `json:"gateway" db:"gateway"`
+	KeyID       string     `json:"key_id" db:"key_id"`
+	Secret      string     `json:"secret,omitempty" db:"-"`
+	MerchantID  *string    `json:"merchant_id,omitempty" db:"merchant_id"`
+	MerchantKey string     `json:"merchant_key,omitempty" db:"-"`
+	Environment string     `json:"environment" db:"environment"`
+	IsActive    bool       `json:"is_active" db:"is_active"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	UpdatedBy   *uuid.UUID `json:"updated_by,omitempty" db:"updated_by"`
+}
+
+// Corresponds to schema: finance.online_payments
+// RedirectURL is where the payer continues the checkout; gateways that
+// open their checkout in the page return GatewayOrderID instead.
+type OnlinePayment struct {
+	ID               uuid.UUID           `json:"id" db:"id"`
+	InstituteID      uuid.UUID           `json:"institute_id" db:"institute_id"`
+	InvoiceID        uuid.UUID           `json:"invoice_id" db:"invoice_id"`
+	StudentID        uuid.UUID           `json:"student_id" db:"student_id"`
+	Gateway          string              `json:"gateway" db:"gateway"`
+	OrderRef         string              `json:"order_ref" db:"order_ref"`
+	GatewayOrderID   *string             `json:"gateway_order_id,omitempty" db:"gateway_order_id"`
+	GatewayPaymentID *string             `json:"gateway_payment_id,omitempty" db:"gateway_payment_id"`
+	Amount           float64             `json:"amount" db:"amount"`
+	Status           OnlinePaymentStatus `json:"status" db:"status"`
+	TransactionID    *uuid.UUID          `json:"transaction_id,omitempty" db:"transaction_id"`
+	InitiatedBy      *uuid.UUID          `json:"initiated_by,omitempty" db:"initiated_by"`
+	RedirectURL      string              `json:"redirect_url,omitempty" db:"-"`
+	GatewayKeyID     string              `json:"gateway_key_id,omitempty" db:"-"`
+	CreatedAt        time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at" db:"updated_at"`
 }
 
 // Corresponds to schema: finance.concessions
+// FeeHeadIDs limits the concession to those fee heads; empty means all.
+// A concession with a SiblingRank is not assigned to students: it applies
+// by itself to every student with at least SiblingRank-1 elder siblings.
 type Concession struct {
 	TenantUUIDModel
-	Name  *string  `json:"name,omitempty" db:"name"`
-	Type  string   `json:"type" db:"type"` // flat, percentage
-	Value *float64 `json:"value,omitempty" db:"value"`
+	Name        *string        `json:"name,omitempty" db:"name"`
+	Type        ConcessionType `json:"type" db:"type"` // flat, percentage
+	Value       *float64       `json:"value,omitempty" db:"value"`
+	FeeHeadIDs  []uuid.UUID    `json:"fee_head_ids,omitempty" db:"fee_head_ids"`
+	SiblingRank *int32         `json:"sibling_rank,omitempty" db:"sibling_rank"`
+}
+
+// Corresponds to schema: finance.student_concessions
+// A concession granted to a student. It is applied to invoices once
+// approved, for billing periods that overlap ValidFrom..ValidTo.
+type StudentConcession struct {
+	TenantUUIDModel
+	StudentID    uuid.UUID      `json:"student_id" db:"student_id"`
+	ConcessionID uuid.UUID      `json:"concession_id" db:"concession_id"`
+	ValidFrom    time.Time      `json:"valid_from" db:"valid_from"`
+	ValidTo      *time.Time     `json:"valid_to,omitempty" db:"valid_to"`
+	Status       ApprovalStatus `json:"status" db:"status"`
+	Remarks      *string        `json:"remarks,omitempty" db:"remarks"`
 }
 
 // Corresponds to schema: finance.fee_structures
@@ -82,8 +378,47 @@ type Budget struct {
 	DepartmentID      *uuid.UUID `json:"department_id,omitempty" db:"department_id"`
 	ExpenseCategoryID *uuid.UUID `json:"expense_category_id,omitempty" db:"expense_category_id"`
 	AllocatedAmount   float64    `json:"allocated_amount" db:"allocated_amount"`
-	UtilizedAmount    float64    `json:"utilized_amount" db:"utilized_amount"`
+	UtilizedAmount    float64    `json:"utilized_amount" db:"utilized_amount"` // committed plus actual
+	SoftLimitPercent  float64    `json:"soft_limit_percent" db:"soft_limit_percent"`
+	HardLimitPercent  *float64   `json:"hard_limit_percent,omitempty" db:"hard_limit_percent"`
 	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	CreatedBy         *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+}
+
+// BudgetLine is a budget with its utilisation split into what is
+// committed to open purchase orders and what has been spent.
+type BudgetLine struct {
+	Budget
+	Committed   float64 `json:"committed"`
+	Actual      float64 `json:"actual"`
+	Available   float64 `json:"available"`
+	UtilisedPct float64 `json:"utilised_percent"`
+}
+
+// BudgetMonth is one month of a burn-down. Remaining is the allocation
+// less the actual spend to the end of the month; IdealRemaining is where
+// it would be if the allocation were spent evenly across the session.
+type BudgetMonth struct {
+	Month            time.Time `json:"month"`
+	Committed        float64   `json:"committed"`
+	Actual           float64   `json:"actual"`
+	CumulativeActual float64   `json:"cumulative_actual"`
+	Remaining        float64   `json:"remaining"`
+	IdealRemaining   float64   `json:"ideal_remaining"`
+}
+
+// BudgetReport compares the budgets of a session, or of one department
+// in it, with what has been committed and spent against them.
+type BudgetReport struct {
+	AcademicSessionID uuid.UUID     `json:"academic_session_id"`
+	DepartmentID      *uuid.UUID    `json:"department_id,omitempty"`
+	Allocated         float64       `json:"allocated"`
+	Committed         float64       `json:"committed"`
+	Actual            float64       `json:"actual"`
+	Available         float64       `json:"available"`
+	UtilisedPct       float64       `json:"utilised_percent"`
+	Lines             []BudgetLine  `json:"lines"`
+	BurnDown          []BudgetMonth `json:"burn_down"`
 }
 
 // Corresponds to schema: finance.student_wallets
@@ -92,20 +427,48 @@ type StudentWallet struct {
 	InstituteID uuid.UUID `json:"institute_id" db:"institute_id"`
 	StudentID   uuid.UUID `json:"student_id" db:"student_id"`
 	Balance     float64   `json:"balance" db:"balance"`
+	AutoApply   bool      `json:"auto_apply" db:"auto_apply"` // pay new invoices from the wallet
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Corresponds to schema: finance.wallet_entries
+// A debit pays InvoiceID when set; any other debit is a charge credited to
+// FeeHeadID's income account.
+type WalletEntry struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	InstituteID   uuid.UUID       `json:"institute_id" db:"institute_id"`
+	WalletID      uuid.UUID       `json:"wallet_id" db:"wallet_id"`
+	StudentID     uuid.UUID       `json:"student_id" db:"student_id"`
+	EntryType     WalletEntryType `json:"entry_type" db:"entry_type"`
+	Purpose       WalletPurpose   `json:"purpose" db:"purpose"`
+	Amount        float64         `json:"amount" db:"amount"`
+	BalanceAfter  float64         `json:"balance_after" db:"balance_after"`
+	PaymentMode   *PaymentMode    `json:"payment_mode,omitempty" db:"payment_mode"`
+	ReferenceNo   *string         `json:"reference_no,omitempty" db:"reference_no"`
+	InvoiceID     *uuid.UUID      `json:"invoice_id,omitempty" db:"invoice_id"`
+	TransactionID *uuid.UUID      `json:"transaction_id,omitempty" db:"transaction_id"`
+	FeeHeadID     *uuid.UUID      `json:"fee_head_id,omitempty" db:"fee_head_id"`
+	Remarks       *string         `json:"remarks,omitempty" db:"remarks"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	CreatedBy     *uuid.UUID      `json:"created_by,omitempty" db:"created_by"`
+}
+
 // Corresponds to schema: finance.invoices
 type Invoice struct {
 	TenantUUIDModel
-	InvoiceNo      string     `json:"invoice_no" db:"invoice_no"`
-	StudentID      uuid.UUID  `json:"student_id" db:"student_id"`
-	TotalAmount    float64    `json:"total_amount" db:"total_amount"`
-	DiscountAmount float64    `json:"discount_amount" db:"discount_amount"`
-	FineAmount     float64    `json:"fine_amount" db:"fine_amount"`
-	PaidAmount     float64    `json:"paid_amount" db:"paid_amount"`
-	Status         string     `json:"status" db:"status"` // pending, partial, paid
-	DueDate        *time.Time `json:"due_date,omitempty" db:"due_date"`
+	InvoiceNo         string     `json:"invoice_no" db:"invoice_no"`
+	StudentID         uuid.UUID  `json:"student_id" db:"student_id"`
+	AcademicSessionID *uuid.UUID `json:"academic_session_id,omitempty" db:"academic_session_id"`
+	TotalAmount       float64    `json:"total_amount" db:"total_amount"`
+	DiscountAmount    float64    `json:"discount_amount" db:"discount_amount"`
+	FineAmount        float64    `json:"fine_amount" db:"fine_amount"`
+	PaidAmount        float64    `json:"paid_amount" db:"paid_amount"`
+	RefundedAmount    float64    `json:"refunded_amount" db:"refunded_amount"`
+	Status            string     `json:"status" db:"status"` // pending, partial, paid
+	DueDate           *time.Time `json:"due_date,omitempty" db:"due_date"`
+	BillingPeriod     *time.Time `json:"billing_period,omitempty" db:"billing_period"` // first day of the billed month, generated invoices only
+
+	Items []InvoiceItem `json:"items,omitempty"`
 }
 
 // Corresponds to schema: finance.invoice_items
@@ -117,6 +480,38 @@ type InvoiceItem struct {
 	DiscountApplied float64    `json:"discount_applied" db:"discount_applied"`
 	ConcessionID    *uuid.UUID `json:"concession_id,omitempty" db:"concession_id"`
 	Description     *string    `json:"description,omitempty" db:"description"`
+	FeeStructureID  *uuid.UUID `json:"fee_structure_id,omitempty" db:"fee_structure_id"`
+}
+
+// InvoiceRun asks for every student of an academic session to be billed
+// for one month. BillingPeriod is "YYYY-MM"; DueDate defaults to the first
+// day of that month.
+type InvoiceRun struct {
+	InstituteID       uuid.UUID  `json:"institute_id"`
+	AcademicSessionID uuid.UUID  `json:"academic_session_id"`
+	BillingPeriod     string     `json:"billing_period"`
+	ClassID           *uuid.UUID `json:"class_id,omitempty"`
+	DueDate           *time.Time `json:"due_date,omitempty"`
+	DryRun            bool       `json:"dry_run"`
+	CreatedBy         *uuid.UUID `json:"-"`
+}
+
+// InvoiceRunSkip is a student the run raised no invoice for
+type InvoiceRunSkip struct {
+	StudentID uuid.UUID `json:"student_id"`
+	Reason    string    `json:"reason"`
+}
+
+// InvoiceRunResult lists the invoices of a run. In a dry run they are not
+// saved and have no invoice numbers.
+type InvoiceRunResult struct {
+	AcademicSessionID uuid.UUID        `json:"academic_session_id"`
+	BillingPeriod     time.Time        `json:"billing_period"`
+	DryRun            bool             `json:"dry_run"`
+	InvoiceCount      int              `json:"invoice_count"`
+	TotalAmount       float64          `json:"total_amount"`
+	Invoices          []*Invoice       `json:"invoices"`
+	Skipped           []InvoiceRunSkip `json:"skipped"`
 }
 
 // Corresponds to schema: finance.transactions
@@ -135,68 +530,210 @@ type Transaction struct {
 	PaymentDate      *time.Time  `json:"payment_date,omitempty" db:"payment_date"`
 	Status           string      `json:"status" db:"status"`
 	CollectedBy      *uuid.UUID  `json:"collected_by,omitempty" db:"collected_by"`
+	ReceiptID        *uuid.UUID  `json:"receipt_id,omitempty" db:"receipt_id"`
+}
+
+// Corresponds to schema: finance.receipts
+// One collection from a student, paying one or more invoices. Each
+// allocation becomes a Transaction. An allocation without an invoice is
+// kept as credit on the student's account.
+type Receipt struct {
+	ID               uuid.UUID           `json:"id" db:"id"`
+	InstituteID      uuid.UUID           `json:"institute_id" db:"institute_id"`
+	ReceiptNo        string              `json:"receipt_no" db:"receipt_no"`
+	FiscalYear       int                 `json:"fiscal_year" db:"fiscal_year"` // calendar year the fiscal year starts in
+	ReceiptDate      time.Time           `json:"receipt_date" db:"receipt_date"`
+	StudentID        uuid.UUID           `json:"student_id" db:"student_id"`
+	PaymentMode      PaymentMode         `json:"payment_mode" db:"payment_mode"`
+	Amount           float64             `json:"amount" db:"amount"`
+	TransactionRefNo *string             `json:"transaction_ref_no,omitempty" db:"transaction_ref_no"`
+	ChequeNo         *string             `json:"cheque_no,omitempty" db:"cheque_no"`
+	ChequeDate       *time.Time          `json:"cheque_date,omitempty" db:"cheque_date"`
+	BankName         *string             `json:"bank_name,omitempty" db:"bank_name"`
+	ChequeStatus     *ChequeStatus       `json:"cheque_status,omitempty" db:"cheque_status"`
+	ChequeSettledAt  *time.Time          `json:"cheque_settled_at,omitempty" db:"cheque_settled_at"`
+	BounceReason     *string             `json:"bounce_reason,omitempty" db:"bounce_reason"`
+	Remarks          *string             `json:"remarks,omitempty" db:"remarks"`
+	CollectedBy      *uuid.UUID          `json:"collected_by,omitempty" db:"collected_by"`
+	CreatedAt        time.Time           `json:"created_at" db:"created_at"`
+	CreatedBy        *uuid.UUID          `json:"created_by,omitempty" db:"created_by"`
+	Allocations      []ReceiptAllocation `json:"allocations"`
+}
+
+// ReceiptAllocation is the part of a receipt paid against one invoice
+type ReceiptAllocation struct {
+	InvoiceID     *uuid.UUID `json:"invoice_id,omitempty"`
+	Amount        float64    `json:"amount"`
+	TransactionID uuid.UUID  `json:"transaction_id,omitempty"`
+}
+
+// ChequeBounce records a returned cheque. A BounceCharge above zero is
+// billed on the receipt's first invoice under FeeHeadID.
+type ChequeBounce struct {
+	InstituteID  uuid.UUID  `json:"institute_id"`
+	ReceiptID    uuid.UUID  `json:"receipt_id"`
+	Reason       string     `json:"reason"`
+	BounceCharge float64    `json:"bounce_charge"`
+	FeeHeadID    *uuid.UUID `json:"fee_head_id,omitempty"`
+	RecordedBy   *uuid.UUID `json:"-"`
 }
 
 // Corresponds to schema: finance.refunds
 type Refund struct {
-	ID          uuid.UUID    `json:"id" db:"id"`
-	InstituteID uuid.UUID    `json:"institute_id" db:"institute_id"`
-	StudentID   *uuid.UUID   `json:"student_id,omitempty" db:"student_id"`
-	InvoiceID   *uuid.UUID   `json:"invoice_id,omitempty" db:"invoice_id"`
-	Amount      float64      `json:"amount" db:"amount"`
-	Reason      *string      `json:"reason,omitempty" db:"reason"`
-	Status      RefundStatus `json:"status" db:"status"`
-	RefundDate  *time.Time   `json:"refund_date,omitempty" db:"refund_date"`
-	ProcessedBy *uuid.UUID   `json:"processed_by,omitempty" db:"processed_by"`
-	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
+	ID                uuid.UUID         `json:"id" db:"id"`
+	InstituteID       uuid.UUID         `json:"institute_id" db:"institute_id"`
+	StudentID         *uuid.UUID        `json:"student_id,omitempty" db:"student_id"`
+	InvoiceID         *uuid.UUID        `json:"invoice_id,omitempty" db:"invoice_id"` // nil for a wallet refund
+	Amount            float64           `json:"amount" db:"amount"`
+	Reason            *string           `json:"reason,omitempty" db:"reason"`
+	Status            RefundStatus      `json:"status" db:"status"`
+	ApprovalsRequired int               `json:"approvals_required" db:"approvals_required"`
+	ApprovalsReceived int               `json:"approvals_received" db:"approvals_received"`
+	PayoutMode        *RefundPayoutMode `json:"payout_mode,omitempty" db:"payout_mode"`
+	PayoutReference   *string           `json:"payout_reference,omitempty" db:"payout_reference"`
+	OnlinePaymentID   *uuid.UUID        `json:"online_payment_id,omitempty" db:"online_payment_id"` // checkout refunded through the gateway
+	RefundDate        *time.Time        `json:"refund_date,omitempty" db:"refund_date"`
+	ProcessedBy       *uuid.UUID        `json:"processed_by,omitempty" db:"processed_by"`
+	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
+	CreatedBy         *uuid.UUID        `json:"created_by,omitempty" db:"created_by"`
+	UpdatedAt         time.Time         `json:"updated_at" db:"updated_at"`
+
+	Approvals []ApprovalStep `json:"approvals,omitempty"`
+}
+
+// RefundPayout is how an approved refund is paid out. Reference is the
+// bank or cash voucher reference; gateway refunds fill it themselves.
+type RefundPayout struct {
+	Mode        RefundPayoutMode `json:"mode"`
+	Reference   *string          `json:"reference,omitempty"`
+	ProcessedBy *uuid.UUID       `json:"-"`
+}
+
+// Corresponds to schema: finance.refund_settings
+type RefundSettings struct {
+	InstituteID     uuid.UUID  `json:"institute_id" db:"institute_id"`
+	ApprovalSteps   int        `json:"approval_steps" db:"approval_steps"`
+	SecondStepAbove float64    `json:"second_step_above" db:"second_step_above"` // refunds up to this need one approval
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	UpdatedBy       *uuid.UUID `json:"updated_by,omitempty" db:"updated_by"`
+}
+
+// ApprovalStep is one step of a request tracked in core.approvals
+type ApprovalStep struct {
+	Step       int            `json:"step"`
+	Status     ApprovalStatus `json:"status"`
+	ApproverID *uuid.UUID     `json:"approver_id,omitempty"`
+	Remarks    *string        `json:"remarks,omitempty"`
+	DecidedAt  *time.Time     `json:"decided_at,omitempty"`
 }
 
 // Corresponds to schema: finance.journal_entries
 type JournalEntry struct {
 	TenantUUIDModel
-	ReferenceNo     string     `json:"reference_no" db:"reference_no"`
-	TransactionDate time.Time  `json:"transaction_date" db:"transaction_date"`
-	Description     *string    `json:"description,omitempty" db:"description"`
-	IsPosted        bool       `json:"is_posted" db:"is_posted"`
-	PostedAt        *time.Time `json:"posted_at,omitempty" db:"posted_at"`
+	ReferenceNo     string         `json:"reference_no" db:"reference_no"`
+	TransactionDate time.Time      `json:"transaction_date" db:"transaction_date"`
+	Description     *string        `json:"description,omitempty" db:"description"`
+	IsPosted        bool           `json:"is_posted" db:"is_posted"`
+	PostedAt        *time.Time     `json:"posted_at,omitempty" db:"posted_at"`
+	ReversalOfID    *uuid.UUID     `json:"reversal_of_id,omitempty" db:"reversal_of_id"`
+	SourceType      *JournalSource `json:"source_type,omitempty" db:"source_type"`
+	SourceID        *uuid.UUID     `json:"source_id,omitempty" db:"source_id"`
+
+	Items []JournalItem `json:"items,omitempty"`
 }
 
 // Corresponds to schema: finance.journal_items
 type JournalItem struct {
 	TenantUUIDModel
-	JournalEntryID uuid.UUID `json:"journal_entry_id" db:"journal_entry_id"`
-	AccountID      uuid.UUID `json:"account_id" db:"account_id"`
-	Debit          float64   `json:"debit" db:"debit"`
-	Credit         float64   `json:"credit" db:"credit"`
-	Description    *string   `json:"description,omitempty" db:"description"`
+	JournalEntryID uuid.UUID  `json:"journal_entry_id" db:"journal_entry_id"`
+	AccountID      uuid.UUID  `json:"account_id" db:"account_id"`
+	Debit          float64    `json:"debit" db:"debit"`
+	Credit         float64    `json:"credit" db:"credit"`
+	Description    *string    `json:"description,omitempty" db:"description"`
+	DepartmentID   *uuid.UUID `json:"department_id,omitempty" db:"department_id"` // counts expense lines against the department's budget
 }
 
 // Corresponds to schema: finance.bank_statement_entries
 type BankStatementEntry struct {
-	ID                      uuid.UUID  `json:"id" db:"id"`
-	InstituteID             uuid.UUID  `json:"institute_id" db:"institute_id"`
-	BankAccountID           *uuid.UUID `json:"bank_account_id,omitempty" db:"bank_account_id"`
-	TransactionDate         *time.Time `json:"transaction_date,omitempty" db:"transaction_date"`
-	ValueDate               *time.Time `json:"value_date,omitempty" db:"value_date"`
-	Description             *string    `json:"description,omitempty" db:"description"`
-	WithdrawalAmount        float64    `json:"withdrawal_amount" db:"withdrawal_amount"`
-	DepositAmount           float64    `json:"deposit_amount" db:"deposit_amount"`
-	Balance                 *float64   `json:"balance,omitempty" db:"balance"`
-	ReconciledTransactionID *uuid.UUID `json:"reconciled_transaction_id,omitempty" db:"reconciled_transaction_id"`
-	IsReconciled            bool       `json:"is_reconciled" db:"is_reconciled"`
-	CreatedAt               time.Time  `json:"created_at" db:"created_at"`
+	ID                      uuid.UUID             `json:"id" db:"id"`
+	InstituteID             uuid.UUID             `json:"institute_id" db:"institute_id"`
+	BankAccountID           *uuid.UUID            `json:"bank_account_id,omitempty" db:"bank_account_id"`
+	TransactionDate         *time.Time            `json:"transaction_date,omitempty" db:"transaction_date"`
+	ValueDate               *time.Time            `json:"value_date,omitempty" db:"value_date"`
+	Description             *string               `json:"description,omitempty" db:"description"`
+	WithdrawalAmount        float64               `json:"withdrawal_amount" db:"withdrawal_amount"`
+	DepositAmount           float64               `json:"deposit_amount" db:"deposit_amount"`
+	Balance                 *float64              `json:"balance,omitempty" db:"balance"`
+	ReconciledTransactionID *uuid.UUID            `json:"reconciled_transaction_id,omitempty" db:"reconciled_transaction_id"`
+	IsReconciled            bool                  `json:"is_reconciled" db:"is_reconciled"`
+	CreatedAt               time.Time             `json:"created_at" db:"created_at"`
+	ImportID                *uuid.UUID            `json:"import_id,omitempty" db:"import_id"`
+	LineNo                  int                   `json:"line_no" db:"line_no"`
+	Reference               *string               `json:"reference,omitempty" db:"reference"`
+	MatchMethod             *StatementMatchMethod `json:"match_method,omitempty" db:"match_method"`
+	MatchRemarks            *string               `json:"match_remarks,omitempty" db:"match_remarks"`
+	MatchedAt               *time.Time            `json:"matched_at,omitempty" db:"matched_at"`
+	MatchedBy               *uuid.UUID            `json:"matched_by,omitempty" db:"matched_by"`
+}
+
+// Corresponds to schema: finance.bank_statement_imports
+// Content is the statement file as uploaded and is not stored.
+type BankStatementImport struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	InstituteID    uuid.UUID  `json:"institute_id" db:"institute_id"`
+	BankAccountID  uuid.UUID  `json:"bank_account_id" db:"bank_account_id"`
+	Format         string     `json:"format" db:"format"` // csv, mt940, camt053
+	FileName       *string    `json:"file_name,omitempty" db:"file_name"`
+	Content        string     `json:"content,omitempty" db:"-"`
+	LinesTotal     int        `json:"lines_total" db:"lines_total"`
+	LinesImported  int        `json:"lines_imported" db:"lines_imported"`
+	LinesDuplicate int        `json:"lines_duplicate" db:"lines_duplicate"`
+	LinesMatched   int        `json:"lines_matched" db:"lines_matched"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+}
+
+// StatementMatch pairs a statement line with a fee transaction. Without a
+// transaction the line is reconciled on its own, for items such as bank
+// charges or vendor payments that the books record elsewhere.
+type StatementMatch struct {
+	InstituteID   uuid.UUID  `json:"institute_id"`
+	EntryID       uuid.UUID  `json:"entry_id"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	Remarks       *string    `json:"remarks,omitempty"`
+	MatchedBy     *uuid.UUID `json:"-"`
+}
+
+// BankReconciliation compares a bank account's statement balance with its
+// ledger balance on a date. Adjusting the statement for fee payments the
+// bank has not shown yet, and the books for lines they have not recorded
+// yet, should leave no difference.
+type BankReconciliation struct {
+	BankAccountID         uuid.UUID            `json:"bank_account_id"`
+	LedgerAccountID       uuid.UUID            `json:"ledger_account_id"`
+	AsOf                  time.Time            `json:"as_of"`
+	StatementBalance      float64              `json:"statement_balance"`
+	BookBalance           float64              `json:"book_balance"`
+	DepositsInTransit     []Transaction        `json:"deposits_in_transit"`
+	UnrecordedLines       []BankStatementEntry `json:"unrecorded_lines"`
+	TotalInTransit        float64              `json:"total_in_transit"`
+	UnrecordedDeposits    float64              `json:"unrecorded_deposits"`
+	UnrecordedWithdrawals float64              `json:"unrecorded_withdrawals"`
+	AdjustedStatement     float64              `json:"adjusted_statement_balance"`
+	AdjustedBook          float64              `json:"adjusted_book_balance"`
+	Difference            float64              `json:"difference"`
 }
 
 // Corresponds to schema: finance.vendors
 type Vendor struct {
 	TenantUUIDModel
-	Name        string  `json:"name" db:"name"`
-	ContactName *string `json:"contact_name,omitempty" db:"contact_name"`
-	Phone       *string `json:"phone,omitempty" db:"phone"`
-	Email       *string `json:"email,omitempty" db:"email"`
-	Address     *string `json:"address,omitempty" db:"address"`
-	GSTNumber   *string `json:"gst_number,omitempty" db:"gst_number"`
+	Name        string     `json:"name" db:"name"`
+	ContactName *string    `json:"contact_name,omitempty" db:"contact_name"`
+	Phone       *string    `json:"phone,omitempty" db:"phone"`
+	Email       *string    `json:"email,omitempty" db:"email"`
+	Address     *string    `json:"address,omitempty" db:"address"`
+	GSTNumber   *string    `json:"gst_number,omitempty" db:"gst_number"`
+	StateID     *uuid.UUID `json:"state_id,omitempty" db:"state_id"` // read from GSTNumber when nil
 }
 
 // Corresponds to schema: finance.purchase_orders
@@ -207,6 +744,12 @@ type PurchaseOrder struct {
 	TotalAmount float64        `json:"total_amount" db:"total_amount"`
 	Status      PurchaseStatus `json:"status" db:"status"`
 	ReferenceNo *string        `json:"reference_no,omitempty" db:"reference_no"`
+
+	DepartmentID     *uuid.UUID `json:"department_id,omitempty" db:"department_id"`
+	ExpenseAccountID *uuid.UUID `json:"expense_account_id,omitempty" db:"expense_account_id"` // debited on receipt instead of purchases
+	RequisitionID    *uuid.UUID `json:"requisition_id,omitempty" db:"requisition_id"`
+
+	Items []PurchaseItem `json:"items,omitempty"`
 }
 
 // Corresponds to schema: finance.purchase_order_items
@@ -226,8 +769,227 @@ type PurchaseOrderItem struct {
 // Corresponds to schema: finance.purchase_items
 type PurchaseItem struct {
 	TenantUUIDModel
-	PurchaseOrderID uuid.UUID `json:"purchase_order_id" db:"purchase_order_id"`
-	ItemID          uuid.UUID `json:"item_id" db:"item_id"`
-	Quantity        int       `json:"quantity" db:"quantity"`
-	UnitPrice       float64   `json:"unit_price" db:"unit_price"`
+	PurchaseOrderID uuid.UUID  `json:"purchase_order_id" db:"purchase_order_id"`
+	ItemID          uuid.UUID  `json:"item_id" db:"item_id"`
+	Quantity        int        `json:"quantity" db:"quantity"`
+	UnitPrice       float64    `json:"unit_price" db:"unit_price"`
+	TaxID           *uuid.UUID `json:"tax_id,omitempty" db:"tax_id"` // defaults to the item's tax
+	TaxAmount       float64    `json:"tax_amount" db:"tax_amount"`
+	TotalAmount     float64    `json:"total_amount" db:"total_amount"`
+
+	RequisitionItemID *uuid.UUID `json:"requisition_item_id,omitempty" db:"requisition_item_id"`
+	ReceivedQuantity  int        `json:"received_quantity" db:"received_quantity"`
+	BilledQuantity    int        `json:"billed_quantity" db:"billed_quantity"`
+}
+
+// Corresponds to schema: finance.goods_receipts
+// Goods delivered against a purchase order into a stock location
+type GoodsReceipt struct {
+	ID              uuid.UUID          `json:"id" db:"id"`
+	InstituteID     uuid.UUID          `json:"institute_id" db:"institute_id"`
+	PurchaseOrderID uuid.UUID          `json:"purchase_order_id" db:"purchase_order_id"`
+	LocationID      uuid.UUID          `json:"location_id" db:"location_id"`
+	ReceivedOn      time.Time          `json:"received_on" db:"received_on"`
+	ReferenceNo     *string            `json:"reference_no,omitempty" db:"reference_no"`
+	Remarks         *string            `json:"remarks,omitempty" db:"remarks"`
+	Amount          float64            `json:"amount" db:"amount"` // received value including tax
+	CreatedBy       *uuid.UUID         `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt       time.Time          `json:"created_at" db:"created_at"`
+	Items           []GoodsReceiptItem `json:"items,omitempty"`
+}
+
+// Corresponds to schema: finance.goods_receipt_items
+type GoodsReceiptItem struct {
+	ID                  uuid.UUID `json:"id" db:"id"`
+	InstituteID         uuid.UUID `json:"institute_id" db:"institute_id"`
+	GoodsReceiptID      uuid.UUID `json:"goods_receipt_id" db:"goods_receipt_id"`
+	PurchaseOrderItemID uuid.UUID `json:"purchase_order_item_id" db:"purchase_order_item_id"`
+	ItemID              uuid.UUID `json:"item_id" db:"item_id"`
+	Quantity            int       `json:"quantity" db:"quantity"`
+	UnitPrice           float64   `json:"unit_price" db:"unit_price"`
+	TaxAmount           float64   `json:"tax_amount" db:"tax_amount"`
+	Amount              float64   `json:"amount" db:"amount"`
+}
+
+// Corresponds to schema: finance.vendor_bills
+// A vendor's invoice for goods on a purchase order. MatchNotes explains
+// why a bill is on hold.
+type VendorBill struct {
+	ID              uuid.UUID        `json:"id" db:"id"`
+	InstituteID     uuid.UUID        `json:"institute_id" db:"institute_id"`
+	VendorID        uuid.UUID        `json:"vendor_id" db:"vendor_id"`
+	PurchaseOrderID uuid.UUID        `json:"purchase_order_id" db:"purchase_order_id"`
+	BillNo          string           `json:"bill_no" db:"bill_no"`
+	BillDate        time.Time        `json:"bill_date" db:"bill_date"`
+	DueDate         time.Time        `json:"due_date" db:"due_date"`
+	Amount          float64          `json:"amount" db:"amount"`
+	TaxAmount       float64          `json:"tax_amount" db:"tax_amount"`
+	PaidAmount      float64          `json:"paid_amount" db:"paid_amount"`
+	Status          VendorBillStatus `json:"status" db:"status"`
+	MatchNotes      []string         `json:"match_notes,omitempty" db:"match_notes"`
+	DecidedBy       *uuid.UUID       `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt       *time.Time       `json:"decided_at,omitempty" db:"decided_at"`
+	Remarks         *string          `json:"remarks,omitempty" db:"remarks"`
+	CreatedBy       *uuid.UUID       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at" db:"updated_at"`
+	Items           []VendorBillItem `json:"items,omitempty"`
+}
+
+// Corresponds to schema: finance.vendor_bill_items
+type VendorBillItem struct {
+	ID                  uuid.UUID `json:"id" db:"id"`
+	InstituteID         uuid.UUID `json:"institute_id" db:"institute_id"`
+	VendorBillID        uuid.UUID `json:"vendor_bill_id" db:"vendor_bill_id"`
+	PurchaseOrderItemID uuid.UUID `json:"purchase_order_item_id" db:"purchase_order_item_id"`
+	Quantity            int       `json:"quantity" db:"quantity"`
+	UnitPrice           float64   `json:"unit_price" db:"unit_price"`
+	TaxAmount           float64   `json:"tax_amount" db:"tax_amount"`
+	Amount              float64   `json:"amount" db:"amount"`
+}
+
+// Corresponds to schema: finance.vendor_payments
+type VendorPayment struct {
+	ID           uuid.UUID   `json:"id" db:"id"`
+	InstituteID  uuid.UUID   `json:"institute_id" db:"institute_id"`
+	VendorBillID uuid.UUID   `json:"vendor_bill_id" db:"vendor_bill_id"`
+	VendorID     uuid.UUID   `json:"vendor_id" db:"vendor_id"`
+	Amount       float64     `json:"amount" db:"amount"`
+	PaymentMode  PaymentMode `json:"payment_mode" db:"payment_mode"`
+	PaidOn       time.Time   `json:"paid_on" db:"paid_on"`
+	ReferenceNo  *string     `json:"reference_no,omitempty" db:"reference_no"`
+	CreatedBy    *uuid.UUID  `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt    time.Time   `json:"created_at" db:"created_at"`
+}
+
+// AgeingBuckets splits amounts by how many days past due they are
+type AgeingBuckets struct {
+	Current    float64 `json:"current"` // not yet due
+	Days1To30  float64 `json:"days_1_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Over90     float64 `json:"over_90"`
+	Total      float64 `json:"total"`
+}
+
+// VendorAgeing is what is owed to one vendor on bills cleared for payment.
+// Bills on hold are shown apart, as they may not be owed in full.
+type VendorAgeing struct {
+	VendorID   uuid.UUID `json:"vendor_id"`
+	VendorName string    `json:"vendor_name"`
+	Bills      int       `json:"bills"`
+	OnHold     float64   `json:"on_hold"`
+	AgeingBuckets
+}
+
+// PayablesAgeing is the payables ageing report as of a date
+type PayablesAgeing struct {
+	AsOf    time.Time      `json:"as_of"`
+	Vendors []VendorAgeing `json:"vendors"`
+	OnHold  float64        `json:"on_hold"`
+	Totals  AgeingBuckets  `json:"totals"`
+}
+
+// Corresponds to schema: finance.dunning_steps
+// One step of an institute's fee reminder schedule. Subject and Message
+// may use {student}, {invoice_no}, {amount}, {due_date} and {days}.
+type DunningStep struct {
+	ID          uuid.UUID        `json:"id" db:"id"`
+	InstituteID uuid.UUID        `json:"institute_id" db:"institute_id"`
+	DaysOverdue int              `json:"days_overdue" db:"days_overdue"`
+	Subject     string           `json:"subject" db:"subject"`
+	Message     string           `json:"message" db:"message"`
+	Channels    []DunningChannel `json:"channels"`
+	IsActive    bool             `json:"is_active" db:"is_active"`
+	UpdatedBy   *uuid.UUID       `json:"updated_by,omitempty" db:"updated_by"`
+	UpdatedAt   time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// Corresponds to schema: finance.dunning_reminders
+// A step sent for an overdue invoice
+type DunningReminder struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	InstituteID   uuid.UUID `json:"institute_id" db:"institute_id"`
+	InvoiceID     uuid.UUID `json:"invoice_id" db:"invoice_id"`
+	StudentID     uuid.UUID `json:"student_id" db:"student_id"`
+	DunningStepID uuid.UUID `json:"dunning_step_id" db:"dunning_step_id"`
+	DaysOverdue   int       `json:"days_overdue" db:"days_overdue"`
+	AmountDue     float64   `json:"amount_due" db:"amount_due"`
+	Recipients    int       `json:"recipients" db:"recipients"` // primary guardians reminded
+	Failures      int       `json:"failures" db:"failures"`     // deliveries that did not go through
+	SentAt        time.Time `json:"sent_at" db:"sent_at"`
+}
+
+// DunningRun is the outcome of one pass over an institute's overdue invoices
+type DunningRun struct {
+	AsOf      time.Time         `json:"as_of"`
+	Reminders []DunningReminder `json:"reminders"`
+	NoContact int               `json:"no_contact"` // reminders with no primary guardian to send to
+}
+
+// GuardianContact is how a primary guardian can be reached. UserID is the
+// guardian's login, for in-app notifications.
+type GuardianContact struct {
+	GuardianID uuid.UUID  `json:"guardian_id"`
+	Name       string     `json:"name"`
+	Email      *string    `json:"email,omitempty"`
+	Phone      *string    `json:"phone,omitempty"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+}
+
+// DunningNotice is a step an overdue invoice has reached but not been sent
+type DunningNotice struct {
+	Invoice     Invoice           `json:"invoice"`
+	Step        DunningStep       `json:"step"`
+	StudentName string            `json:"student_name"`
+	DaysOverdue int               `json:"days_overdue"`
+	Guardians   []GuardianContact `json:"guardians"`
+}
+
+// Corresponds to schema: finance.payment_plans
+// An arrangement for a student to clear fees in instalments. While an
+// approved plan runs the student's guardians are not sent fee reminders.
+type PaymentPlan struct {
+	ID           uuid.UUID      `json:"id" db:"id"`
+	InstituteID  uuid.UUID      `json:"institute_id" db:"institute_id"`
+	StudentID    uuid.UUID      `json:"student_id" db:"student_id"`
+	Amount       float64        `json:"amount" db:"amount"`
+	Installments int            `json:"installments" db:"installments"`
+	StartDate    time.Time      `json:"start_date" db:"start_date"`
+	EndDate      time.Time      `json:"end_date" db:"end_date"`
+	Reason       string         `json:"reason" db:"reason"`
+	Status       ApprovalStatus `json:"status" db:"status"`
+	Remarks      *string        `json:"remarks,omitempty" db:"remarks"`
+	DecidedBy    *uuid.UUID     `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt    *time.Time     `json:"decided_at,omitempty" db:"decided_at"`
+	CreatedBy    *uuid.UUID     `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// FeeHeadAgeing is what is overdue under one fee head. Unpaid late fines
+// are shown under a head with no id.
+type FeeHeadAgeing struct {
+	FeeHeadID   *uuid.UUID `json:"fee_head_id,omitempty"`
+	FeeHeadName string     `json:"fee_head_name"`
+	AgeingBuckets
+}
+
+// ClassAgeing is what is overdue from the students of one class
+type ClassAgeing struct {
+	ClassID       *uuid.UUID      `json:"class_id,omitempty"`
+	ClassName     string          `json:"class_name"`
+	Defaulters    int             `json:"defaulters"`
+	OnPaymentPlan int             `json:"on_payment_plan"` // defaulters whose reminders are paused
+	FeeHeads      []FeeHeadAgeing `json:"fee_heads"`
+	AgeingBuckets
+}
+
+// FeeDefaulterAgeing is the fee defaulter report as of a date
+type FeeDefaulterAgeing struct {
+	AsOf       time.Time       `json:"as_of"`
+	Classes    []ClassAgeing   `json:"classes"`
+	FeeHeads   []FeeHeadAgeing `json:"fee_heads"`
+	Defaulters int             `json:"defaulters"`
+	Totals     AgeingBuckets   `json:"totals"`
 }
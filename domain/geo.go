@@ -18,6 +18,7 @@ type State struct {
 	CountryID uuid.UUID `json:"country_id" db:"country_id"`
 	Name      string    `json:"name" db:"name"`
 	Code      *string   `json:"code,omitempty" db:"code"`
+	GSTCode   *string   `json:"gst_code,omitempty" db:"gst_code"` // first two digits of GSTINs registered in the state
 }
 
 // Corresponds to schema: geo.districts
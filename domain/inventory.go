@@ -19,6 +19,7 @@ type InventoryItem struct {
 	CategoryID   *uuid.UUID `json:"category_id,omitempty" db:"category_id"`
 	IsFixedAsset bool       `json:"is_fixed_asset" db:"is_fixed_asset"`
 	ReorderLevel int        `json:"reorder_level" db:"reorder_level"`
+	TaxID        *uuid.UUID `json:"tax_id,omitempty" db:"tax_id"` // GST charged on purchases of the item
 }
 
 // Corresponds to schema: inventory.locations
@@ -67,20 +68,38 @@ type FixedAsset struct {
 // Corresponds to schema: inventory.requisitions
 type Requisition struct {
 	TenantUUIDModel
-	RequestedBy  uuid.UUID  `json:"requested_by" db:"requested_by"`
-	DepartmentID *uuid.UUID `json:"department_id,omitempty" db:"department_id"`
-	Status       string     `json:"status" db:"status"`
-	RequestDate  *time.Time `json:"request_date,omitempty" db:"request_date"`
+	RequestedBy  uuid.UUID         `json:"requested_by" db:"requested_by"`
+	DepartmentID *uuid.UUID        `json:"department_id,omitempty" db:"department_id"`
+	Status       RequisitionStatus `json:"status" db:"status"`
+	RequestDate  *time.Time        `json:"request_date,omitempty" db:"request_date"`
+	Remarks      *string           `json:"remarks,omitempty" db:"remarks"`
+	DecidedBy    *uuid.UUID        `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt    *time.Time        `json:"decided_at,omitempty" db:"decided_at"`
+
+	Items []RequisitionItem `json:"items,omitempty"`
 }
 
 // Corresponds to schema: inventory.requisition_items
 type RequisitionItem struct {
-	ID            uuid.UUID `json:"id" db:"id"`
-	InstituteID   uuid.UUID `json:"institute_id" db:"institute_id"`
-	RequisitionID uuid.UUID `json:"requisition_id" db:"requisition_id"`
-	ItemName      *string   `json:"item_name,omitempty" db:"item_name"`
-	Quantity      *int      `json:"quantity,omitempty" db:"quantity"`
-	Remarks       *string   `json:"remarks,omitempty" db:"remarks"`
+	ID              uuid.UUID  `json:"id" db:"id"`
+	InstituteID     uuid.UUID  `json:"institute_id" db:"institute_id"`
+	RequisitionID   uuid.UUID  `json:"requisition_id" db:"requisition_id"`
+	ItemID          *uuid.UUID `json:"item_id,omitempty" db:"item_id"` // may be chosen when the item is ordered
+	ItemName        *string    `json:"item_name,omitempty" db:"item_name"`
+	Quantity        *int       `json:"quantity,omitempty" db:"quantity"`
+	OrderedQuantity int        `json:"ordered_quantity" db:"ordered_quantity"`
+	Remarks         *string    `json:"remarks,omitempty" db:"remarks"`
+}
+
+// RequisitionOrderLine puts some of a requisition item on a purchase order
+// with a vendor. Lines for the same vendor share one order.
+type RequisitionOrderLine struct {
+	RequisitionItemID uuid.UUID  `json:"requisition_item_id"`
+	VendorID          uuid.UUID  `json:"vendor_id"`
+	ItemID            *uuid.UUID `json:"item_id,omitempty"` // required when the requisition item has none
+	Quantity          int        `json:"quantity"`          // defaults to what is left to order
+	UnitPrice         float64    `json:"unit_price"`
+	TaxID             *uuid.UUID `json:"tax_id,omitempty"`
 }
 
 // Corresponds to schema: inventory.transactions
@@ -0,0 +1,103 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ==================== MARKING ====================
+
+// AttendanceEntryRequest is the status marked for one student
+type AttendanceEntryRequest struct {
+	StudentID uuid.UUID `json:"student_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Status    string    `json:"status" example:"absent"`
+	Remarks   *string   `json:"remarks,omitempty" example:"Informed by parent"`
+}
+
+// MarkAttendanceRequest represents a class register for a day, or for one
+// period when period_id is set. Students not listed in entries receive
+// default_status; leave it empty to mark only the listed students.
+type MarkAttendanceRequest struct {
+	ClassID       uuid.UUID                `json:"class_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Date          string                   `json:"date" example:"2024-09-02"`
+	PeriodID      *uuid.UUID               `json:"period_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440002"`
+	DefaultStatus string                   `json:"default_status,omitempty" example:"present"`
+	Entries       []AttendanceEntryRequest `json:"entries"`
+}
+
+// AttendanceResponse represents a saved attendance entry
+type AttendanceResponse struct {
+	ID             uuid.UUID  `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	InstituteID    uuid.UUID  `json:"institute_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ClassID        uuid.UUID  `json:"class_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	StudentID      uuid.UUID  `json:"student_id" example:"550e8400-e29b-41d4-a716-446655440003"`
+	AttendanceDate time.Time  `json:"attendance_date" example:"2024-09-02T00:00:00Z"`
+	PeriodID       *uuid.UUID `json:"period_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440002"`
+	Status         string     `json:"status" example:"present"`
+	Remarks        *string    `json:"remarks,omitempty" example:"Informed by parent"`
+}
+
+// AttendanceRegisterEntryResponse is one student row of a class register
+type AttendanceRegisterEntryResponse struct {
+	StudentID   uuid.UUID `json:"student_id" example:"550e8400-e29b-41d4-a716-446655440003"`
+	AdmissionNo string    `json:"admission_no" example:"STU2024001"`
+	StudentName string    `json:"student_name" example:"John Doe"`
+	Status      *string   `json:"status,omitempty" example:"present"`
+	Remarks     *string   `json:"remarks,omitempty" example:"Informed by parent"`
+}
+
+// ClassRegisterResponse represents a class register for a day or period
+type ClassRegisterResponse struct {
+	ClassID  uuid.UUID                         `json:"class_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Date     time.Time                         `json:"date" example:"2024-09-02T00:00:00Z"`
+	PeriodID *uuid.UUID                        `json:"period_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440002"`
+	Holiday  *string                           `json:"holiday,omitempty" example:"Ganesh Chaturthi"`
+	Entries  []AttendanceRegisterEntryResponse `json:"entries"`
+}
+
+// ==================== REPORTS ====================
+
+// AttendanceSummaryResponse counts a student's daily statuses over a range
+type AttendanceSummaryResponse struct {
+	StudentID   uuid.UUID `json:"student_id" example:"550e8400-e29b-41d4-a716-446655440003"`
+	AdmissionNo string    `json:"admission_no,omitempty" example:"STU2024001"`
+	StudentName string    `json:"student_name,omitempty" example:"John Doe"`
+	Present     int       `json:"present" example:"18"`
+	Absent      int       `json:"absent" example:"1"`
+	Late        int       `json:"late" example:"2"`
+	HalfDay     int       `json:"half_day" example:"1"`
+	OnLeave     int       `json:"on_leave" example:"0"`
+	MarkedDays  int       `json:"marked_days" example:"22"`
+	Percentage  float64   `json:"percentage" example:"93.18"`
+}
+
+// StudentAttendanceReportResponse represents a student's monthly attendance
+type StudentAttendanceReportResponse struct {
+	StudentID uuid.UUID                 `json:"student_id" example:"550e8400-e29b-41d4-a716-446655440003"`
+	From      time.Time                 `json:"from" example:"2024-09-01T00:00:00Z"`
+	To        time.Time                 `json:"to" example:"2024-09-30T00:00:00Z"`
+	Summary   AttendanceSummaryResponse `json:"summary"`
+	Days      []AttendanceResponse      `json:"days"`
+	Holidays  []CalendarEventResponse   `json:"holidays"`
+}
+
+// ClassAttendanceReportResponse represents a class's attendance over a range
+type ClassAttendanceReportResponse struct {
+	ClassID           uuid.UUID                   `json:"class_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	From              time.Time                   `json:"from" example:"2024-09-01T00:00:00Z"`
+	To                time.Time                   `json:"to" example:"2024-09-30T00:00:00Z"`
+	RegisterDays      int                         `json:"register_days" example:"22"`
+	Holidays          []CalendarEventResponse     `json:"holidays"`
+	AveragePercentage float64                     `json:"average_percentage" example:"91.4"`
+	Students          []AttendanceSummaryResponse `json:"students"`
+}
+
+// CalendarEventResponse represents an institute calendar event
+type CalendarEventResponse struct {
+	ID        uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440004"`
+	Title     string    `json:"title" example:"Ganesh Chaturthi"`
+	StartDate time.Time `json:"start_date" example:"2024-09-07T00:00:00Z"`
+	EndDate   time.Time `json:"end_date" example:"2024-09-07T00:00:00Z"`
+	IsHoliday bool      `json:"is_holiday" example:"true"`
+}
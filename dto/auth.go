@@ -35,12 +35,68 @@ type VerifyOTPResponse struct {
 	Token   string       `json:"token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 }
 
+// PasswordLoginResponse is returned when a password login needs a second factor
+type PasswordLoginResponse struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token,omitempty" example:"kq3Yb0f2..."`
+}
+
+// ChangePasswordRequest represents the request body for changing one's own password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" example:"OldPassword123!"`
+	NewPassword     string `json:"new_password" example:"NewSecurePassword123!"`
+}
+
+// TOTPEnrollmentResponse carries the secret to add to an authenticator app
+type TOTPEnrollmentResponse struct {
+	Secret          string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	ProvisioningURI string `json:"provisioning_uri" example:"otpauth://totp/SwiftSchool:john.doe?secret=JBSWY3DPEHPK3PXP&issuer=SwiftSchool"`
+}
+
+// TOTPCodeRequest represents a request confirmed with a current TOTP code
+type TOTPCodeRequest struct {
+	Code string `json:"code" example:"123456"`
+}
+
+// DisableTOTPRequest represents the request body for turning off TOTP
+type DisableTOTPRequest struct {
+	Password string `json:"password" example:"SecurePassword123!"`
+}
+
+// RecoveryCodesResponse lists single-use recovery codes, shown only once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"a7k2m-9xq4p,h3n8r-t2w6z"`
+}
+
+// LogoutAllResponse represents the response for revoking a user's sessions
+type LogoutAllResponse struct {
+	RevokedSessions int64  `json:"revoked_sessions" example:"3"`
+	Redirect        string `json:"redirect,omitempty" example:"/"`
+}
+
+// ForceLogoutRequest represents the request body for an admin force logout
+type ForceLogoutRequest struct {
+	UserID uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// SwitchInstituteRequest represents the request body for selecting the session's institute
+type SwitchInstituteRequest struct {
+	InstituteID uuid.UUID `json:"institute_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+}
+
+// SwitchInstituteResponse represents the institute the session now operates on
+type SwitchInstituteResponse struct {
+	InstituteID uuid.UUID `json:"institute_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+}
+
 // ==================== USER ====================
 
 // CreateUserRequest represents the request body for creating a user
 type CreateUserRequest struct {
 	Username       string     `json:"username" example:"john.doe"`
-	Password       string     `json:"password" example:"SecurePassword123!"`
+	Email          *string    `json:"email,omitempty" example:"john.doe@example.com"`
+	PhoneNumber    *string    `json:"phone_number,omitempty" example:"+919876543210"`
+	Password       string     `json:"password,omitempty" example:"SecurePassword123!"`
 	RoleType       string     `json:"role_type" example:"admin"`
 	LinkedEntityID uuid.UUID  `json:"linked_entity_id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	InstituteID    *uuid.UUID `json:"institute_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"`
@@ -51,6 +107,8 @@ type CreateUserRequest struct {
 type UserResponse struct {
 	ID             uuid.UUID  `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	Username       string     `json:"username" example:"john.doe"`
+	Email          *string    `json:"email,omitempty" example:"john.doe@example.com"`
+	PhoneNumber    *string    `json:"phone_number,omitempty" example:"+919876543210"`
 	RoleType       string     `json:"role_type" example:"admin"`
 	LinkedEntityID uuid.UUID  `json:"linked_entity_id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	InstituteID    *uuid.UUID `json:"institute_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"`
@@ -70,3 +128,18 @@ type UpdateUserStatusRequest struct {
 	ID       uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	IsActive bool      `json:"is_active" example:"false"`
 }
+
+// PolicyRequest represents the request body for granting or revoking a role permission
+type PolicyRequest struct {
+	Role   string `json:"role" example:"teacher"`
+	Object string `json:"object" example:"academics/timetable"`
+	Action string `json:"action" example:"(read|update)"`
+}
+
+// PolicyResponse represents a role permission within an institute
+type PolicyResponse struct {
+	Role        string `json:"role" example:"teacher"`
+	InstituteID string `json:"institute_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Object      string `json:"object" example:"academics/timetable"`
+	Action      string `json:"action" example:"(read|update)"`
+}
@@ -121,6 +121,8 @@ type CreateStudentRequest struct {
 	CurrentClassID    *uuid.UUID `json:"current_class_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"`
 	Nationality       *string    `json:"nationality,omitempty" example:"Indian"`
 	PreferredLanguage *string    `json:"preferred_language,omitempty" example:"English"`
+
+	ProvisionLogin *ProvisionLoginRequest `json:"provision_login,omitempty"`
 }
 
 // UpdateStudentRequest represents the request body for updating a student
@@ -181,6 +183,8 @@ type CreateGuardianRequest struct {
 	Phone        *string  `json:"phone,omitempty" example:"+91-9876543210"`
 	Profession   *string  `json:"profession,omitempty" example:"Engineer"`
 	AnnualIncome *float64 `json:"annual_income,omitempty" example:"1200000"`
+
+	ProvisionLogin *ProvisionLoginRequest `json:"provision_login,omitempty"`
 }
 
 // GuardianResponse represents the response for guardian operations
@@ -204,6 +208,73 @@ type LinkStudentGuardianRequest struct {
 	IsPrimaryContact bool      `json:"is_primary_contact" example:"true"`
 }
 
+// ==================== LOGIN PROVISIONING ====================
+
+// ProvisionLoginRequest asks for a login to be created together with a
+// student, guardian or employee. Guardians always use their own email or phone.
+type ProvisionLoginRequest struct {
+	Email       *string `json:"email,omitempty" example:"john.doe@example.com"`
+	PhoneNumber *string `json:"phone_number,omitempty" example:"+919876543210"`
+	RoleType    string  `json:"role_type,omitempty" example:"teacher"` // Employees only; defaults to employee
+}
+
+// ProvisionStudentLoginRequest represents the request for creating a student's login
+type ProvisionStudentLoginRequest struct {
+	StudentID   uuid.UUID `json:"student_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Email       *string   `json:"email,omitempty" example:"john.doe@example.com"`
+	PhoneNumber *string   `json:"phone_number,omitempty" example:"+919876543210"`
+}
+
+// ProvisionEmployeeLoginRequest represents the request for creating an employee's login
+type ProvisionEmployeeLoginRequest struct {
+	EmployeeID  uuid.UUID `json:"employee_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	RoleType    string    `json:"role_type,omitempty" example:"teacher"`
+	Email       *string   `json:"email,omitempty" example:"jane.smith@example.com"`
+	PhoneNumber *string   `json:"phone_number,omitempty" example:"+919876543210"`
+}
+
+// ProvisionGuardianLoginRequest represents the request for creating a guardian's login
+type ProvisionGuardianLoginRequest struct {
+	GuardianID uuid.UUID `json:"guardian_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// ProvisionClassLoginsRequest represents the request for creating logins for a whole class
+type ProvisionClassLoginsRequest struct {
+	ClassID          uuid.UUID `json:"class_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	IncludeGuardians bool      `json:"include_guardians" example:"true"`
+}
+
+// ProvisionedLoginResponse represents a login created for a student, guardian or employee.
+// InitialPassword is only set for logins without an email or phone.
+type ProvisionedLoginResponse struct {
+	UserID          uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Username        string    `json:"username" example:"gvis-blr.stu2024001"`
+	RoleType        string    `json:"role_type" example:"student"`
+	LinkedEntityID  uuid.UUID `json:"linked_entity_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	WelcomeSent     bool      `json:"welcome_sent" example:"true"`
+	InitialPassword string    `json:"initial_password,omitempty" example:"Xk4#pQ9m!Lz2"`
+}
+
+// ProvisioningFailureResponse describes a record whose login could not be created
+type ProvisioningFailureResponse struct {
+	EntityID uuid.UUID `json:"entity_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	RoleType string    `json:"role_type" example:"guardian"`
+	Error    string    `json:"error" example:"the guardian needs an email or phone number to receive a login"`
+}
+
+// ProvisionClassLoginsResponse summarises a bulk provisioning run
+type ProvisionClassLoginsResponse struct {
+	Created []ProvisionedLoginResponse    `json:"created"`
+	Skipped int                           `json:"skipped" example:"12"`
+	Failed  []ProvisioningFailureResponse `json:"failed,omitempty"`
+}
+
+// UpdateActiveStatusRequest represents the request for activating or deactivating a record
+type UpdateActiveStatusRequest struct {
+	ID       uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	IsActive bool      `json:"is_active" example:"false"`
+}
+
 // ==================== ACADEMIC SESSION ====================
 
 // CreateAcademicSessionRequest represents the request body for creating an academic session
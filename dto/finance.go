@@ -0,0 +1,12 @@
+package dto
+
+import "github.com/google/uuid"
+
+// ==================== YEAR-END CLOSE ====================
+
+// CloseFiscalYearRequest closes the fiscal year starting in fiscal_year,
+// moving its income and expense balances into the retained earnings account
+type CloseFiscalYearRequest struct {
+	FiscalYear                int       `json:"fiscal_year" example:"2024"`
+	RetainedEarningsAccountID uuid.UUID `json:"retained_earnings_account_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
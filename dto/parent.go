@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ==================== PARENT PORTAL ====================
+
+// ChildResponse represents a student linked to the signed-in guardian
+type ChildResponse struct {
+	StudentResponse
+	Relationship     string `json:"relationship" example:"father"`
+	IsPrimaryContact bool   `json:"is_primary_contact" example:"true"`
+}
+
+// TimetableSlotResponse represents one period of a child's weekly timetable
+type TimetableSlotResponse struct {
+	EntryID     uuid.UUID  `json:"entry_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	DayOfWeek   string     `json:"day_of_week" example:"mon"`
+	PeriodID    *uuid.UUID `json:"period_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"`
+	PeriodName  *string    `json:"period_name,omitempty" example:"Period 1"`
+	StartTime   *time.Time `json:"start_time,omitempty" example:"0000-01-01T08:30:00Z"`
+	EndTime     *time.Time `json:"end_time,omitempty" example:"0000-01-01T09:15:00Z"`
+	SubjectID   *uuid.UUID `json:"subject_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440002"`
+	SubjectName *string    `json:"subject_name,omitempty" example:"Mathematics"`
+	TeacherID   *uuid.UUID `json:"teacher_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440003"`
+	TeacherName *string    `json:"teacher_name,omitempty" example:"Anita Rao"`
+}
+
+// InvoiceResponse represents a fee invoice raised for a child
+type InvoiceResponse struct {
+	ID             uuid.UUID  `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	InstituteID    uuid.UUID  `json:"institute_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	InvoiceNo      string     `json:"invoice_no" example:"INV-2024-00042"`
+	StudentID      uuid.UUID  `json:"student_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	TotalAmount    float64    `json:"total_amount" example:"15000"`
+	DiscountAmount float64    `json:"discount_amount" example:"0"`
+	FineAmount     float64    `json:"fine_amount" example:"0"`
+	PaidAmount     float64    `json:"paid_amount" example:"5000"`
+	Status         string     `json:"status" example:"partial"`
+	DueDate        *time.Time `json:"due_date,omitempty" example:"2024-04-10T00:00:00Z"`
+}
+
+// ExamResultResponse represents a child's mark in a published exam
+type ExamResultResponse struct {
+	MarkID        uuid.UUID `json:"mark_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ExamID        uuid.UUID `json:"exam_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	ExamName      string    `json:"exam_name" example:"Half Yearly"`
+	ScheduleID    uuid.UUID `json:"schedule_id" example:"550e8400-e29b-41d4-a716-446655440002"`
+	SubjectID     uuid.UUID `json:"subject_id" example:"550e8400-e29b-41d4-a716-446655440003"`
+	SubjectName   string    `json:"subject_name" example:"Science"`
+	ExamDate      time.Time `json:"exam_date" example:"2024-09-20T00:00:00Z"`
+	MaxMarks      float64   `json:"max_marks" example:"100"`
+	MinPassMarks  float64   `json:"min_pass_marks" example:"35"`
+	MarksObtained *float64  `json:"marks_obtained,omitempty" example:"78"`
+	IsAbsent      bool      `json:"is_absent" example:"false"`
+	Remarks       *string   `json:"remarks,omitempty" example:"Good improvement"`
+}
+
+// AssignmentResponse represents homework set for a child's class
+type AssignmentResponse struct {
+	ID          uuid.UUID  `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ClassID     uuid.UUID  `json:"class_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	SubjectID   uuid.UUID  `json:"subject_id" example:"550e8400-e29b-41d4-a716-446655440002"`
+	TeacherID   uuid.UUID  `json:"teacher_id" example:"550e8400-e29b-41d4-a716-446655440003"`
+	Title       *string    `json:"title,omitempty" example:"Fractions worksheet"`
+	Description *string    `json:"description,omitempty" example:"Complete exercises 4.1 to 4.3"`
+	DueDate     *time.Time `json:"due_date,omitempty" example:"2024-09-25T00:00:00Z"`
+	MaxMarks    *float64   `json:"max_marks,omitempty" example:"20"`
+}
@@ -0,0 +1,495 @@
+package helper
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ------------------------ Statement Formats ------------------------
+type StatementFormat string
+
+const (
+	StatementCSV     StatementFormat = "csv"
+	StatementMT940   StatementFormat = "mt940"
+	StatementCAMT053 StatementFormat = "camt053"
+)
+
+var ErrUnreadableStatement = errors.New("bank statement could not be read")
+
+// StatementLine is one booking on a bank statement, whatever the file
+// format. Balance is the running balance after the line when the bank gives
+// one, directly or through an opening balance.
+type StatementLine struct {
+	Date        time.Time
+	ValueDate   *time.Time
+	Description string
+	Reference   string
+	Withdrawal  float64
+	Deposit     float64
+	Balance     *float64
+}
+
+// ParseBankStatement reads the lines of a statement file
+func ParseBankStatement(format StatementFormat, content string) ([]StatementLine, error) {
+	var (
+		lines []StatementLine
+		err   error
+	)
+	switch format {
+	case StatementCSV:
+		lines, err = parseStatementCSV(content)
+	case StatementMT940:
+		lines, err = parseMT940(content)
+	case StatementCAMT053:
+		lines, err = parseCAMT053(content)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrUnreadableStatement, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: no transactions found", ErrUnreadableStatement)
+	}
+	return lines, nil
+}
+
+// ------------------------ CSV ------------------------
+
+// Header names used by Indian banks' statement downloads, lower-cased
+var csvColumns = map[string][]string{
+	"date":        {"date", "txn date", "transaction date", "tran date", "posting date", "booking date"},
+	"value_date":  {"value date", "value dt"},
+	"description": {"description", "narration", "particulars", "remarks", "details"},
+	"reference":   {"reference", "ref no", "ref no.", "reference no", "reference no.", "chq no", "chq./ref.no.", "cheque no", "cheque no.", "utr"},
+	"withdrawal":  {"withdrawal", "withdrawals", "withdrawal amt", "withdrawal amt.", "debit", "debit amount", "dr"},
+	"deposit":     {"deposit", "deposits", "deposit amt", "deposit amt.", "credit", "credit amount", "cr"},
+	"amount":      {"amount", "transaction amount"},
+	"balance":     {"balance", "closing balance", "running balance"},
+}
+
+var statementDateLayouts = []string{
+	"2006-01-02", "02/01/2006", "02-01-2006", "02.01.2006", "02/01/06", "02-01-06",
+	"02 Jan 2006", "02-Jan-2006", "02-Jan-06", "2 Jan 2006", "Jan 2, 2006",
+}
+
+// parseStatementCSV reads a CSV with a header row. Amounts come either as
+// separate withdrawal and deposit columns or as one signed amount column.
+// Rows without a readable date, such as opening and closing balance rows,
+// are skipped.
+func parseStatementCSV(content string) ([]StatementLine, error) {
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(content, "\ufeff")))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnreadableStatement, err)
+	}
+
+	// Banks often put account details above the table, so the header is
+	// the first row naming a date column
+	header := -1
+	cols := map[string]int{}
+	for i, rec := range records {
+		cols = csvHeader(rec)
+		if _, ok := cols["date"]; ok {
+			header = i
+			break
+		}
+	}
+	if header < 0 {
+		return nil, fmt.Errorf("%w: no header row with a date column", ErrUnreadableStatement)
+	}
+	_, split := cols["deposit"]
+	if _, ok := cols["amount"]; !ok && !split {
+		return nil, fmt.Errorf("%w: no amount or deposit column", ErrUnreadableStatement)
+	}
+
+	field := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var lines []StatementLine
+	for _, rec := range records[header+1:] {
+		date, ok := parseStatementDate(field(rec, "date"))
+		if !ok {
+			continue
+		}
+
+		line := StatementLine{
+			Date:        date,
+			Description: field(rec, "description"),
+			Reference:   field(rec, "reference"),
+		}
+		if vd, ok := parseStatementDate(field(rec, "value_date")); ok {
+			line.ValueDate = &vd
+		}
+
+		if split {
+			if line.Withdrawal, err = parseStatementAmount(field(rec, "withdrawal")); err != nil {
+				return nil, err
+			}
+			if line.Deposit, err = parseStatementAmount(field(rec, "deposit")); err != nil {
+				return nil, err
+			}
+		} else {
+			amount, err := parseStatementAmount(field(rec, "amount"))
+			if err != nil {
+				return nil, err
+			}
+			if amount < 0 {
+				line.Withdrawal = -amount
+			} else {
+				line.Deposit = amount
+			}
+		}
+
+		if b := field(rec, "balance"); b != "" {
+			bal, err := parseStatementAmount(b)
+			if err != nil {
+				return nil, err
+			}
+			line.Balance = &bal
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func csvHeader(rec []string) map[string]int {
+	cols := map[string]int{}
+	for i, cell := range rec {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		for col, aliases := range csvColumns {
+			if _, seen := cols[col]; !seen && Contains(aliases, name) {
+				cols[col] = i
+			}
+		}
+	}
+	return cols
+}
+
+func parseStatementDate(v string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range statementDateLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseStatementAmount reads amounts such as "1,250.00", "-500", "500.00 Cr"
+// or "500.00 Dr". An empty cell is zero.
+func parseStatementAmount(v string) (float64, error) {
+	v = strings.ToUpper(strings.TrimSpace(v))
+	sign := 1.0
+	switch {
+	case strings.HasSuffix(v, "DR"):
+		sign, v = -1, strings.TrimSuffix(v, "DR")
+	case strings.HasSuffix(v, "CR"):
+		v = strings.TrimSuffix(v, "CR")
+	}
+	v = strings.NewReplacer(",", "", " ", "", "₹", "").Replace(v)
+	if v == "" || v == "-" {
+		return 0, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid amount %q", ErrUnreadableStatement, v)
+	}
+	return sign * f, nil
+}
+
+// ------------------------ MT940 ------------------------
+
+// :61: statement line, e.g. 2404050405C1500,00NTRFCHQ004512//HDFC8812
+var mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([NFS][A-Z0-9]{3})(.*)$`)
+
+// :60F: and :62F: balances, e.g. C240401INR125000,00
+var mt940Balance = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)$`)
+
+// parseMT940 reads SWIFT MT940 statements. The running balance is carried
+// from each statement's opening balance.
+func parseMT940(content string) ([]StatementLine, error) {
+	var (
+		lines   []StatementLine
+		balance *float64
+		tag     string
+		value   strings.Builder
+	)
+
+	flush := func() error {
+		v := strings.TrimSpace(value.String())
+		value.Reset()
+
+		switch tag {
+		case "60F", "60M":
+			m := mt940Balance.FindStringSubmatch(strings.ReplaceAll(v, "\n", ""))
+			if m == nil {
+				return fmt.Errorf("%w: invalid opening balance %q", ErrUnreadableStatement, v)
+			}
+			bal, _ := parseSwiftAmount(m[4])
+			if m[1] == "D" {
+				bal = -bal
+			}
+			balance = &bal
+
+		case "61":
+			first, rest, _ := strings.Cut(v, "\n")
+			m := mt940Line.FindStringSubmatch(first)
+			if m == nil {
+				return fmt.Errorf("%w: invalid statement line %q", ErrUnreadableStatement, first)
+			}
+			date, err := time.Parse("060102", m[1])
+			if err != nil {
+				return fmt.Errorf("%w: invalid date in %q", ErrUnreadableStatement, first)
+			}
+			amount, err := parseSwiftAmount(m[5])
+			if err != nil {
+				return err
+			}
+
+			line := StatementLine{Date: date}
+			if m[2] != "" {
+				// The line carries a booking date as MMDD, so the first
+				// date is the value date. The booking date may fall in the
+				// year before or after it around the new year.
+				if booked, err := time.Parse("060102", m[1][:2]+m[2]); err == nil {
+					switch {
+					case booked.Before(date.AddDate(0, -6, 0)):
+						booked = booked.AddDate(1, 0, 0)
+					case booked.After(date.AddDate(0, 6, 0)):
+						booked = booked.AddDate(-1, 0, 0)
+					}
+					valueDate := date
+					line.Date, line.ValueDate = booked, &valueDate
+				}
+			}
+			if m[3] == "C" || m[3] == "RD" {
+				line.Deposit = amount
+			} else {
+				line.Withdrawal = amount
+			}
+
+			custRef, bankRef, _ := strings.Cut(m[7], "//")
+			line.Reference = strings.TrimSpace(custRef)
+			if line.Reference == "" || line.Reference == "NONREF" {
+				line.Reference = strings.TrimSpace(bankRef)
+			}
+			line.Description = strings.TrimSpace(rest)
+
+			if balance != nil {
+				bal := *balance + line.Deposit - line.Withdrawal
+				balance = &bal
+				line.Balance = &bal
+			}
+			lines = append(lines, line)
+
+		case "86":
+			if len(lines) > 0 {
+				desc := strings.Join(strings.Fields(strings.ReplaceAll(v, "\n", " ")), " ")
+				last := &lines[len(lines)-1]
+				last.Description = strings.TrimSpace(strings.Join([]string{last.Description, desc}, " "))
+			}
+		}
+		return nil
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		text := strings.TrimRight(sc.Text(), "\r")
+		if strings.HasPrefix(text, ":") {
+			if end := strings.Index(text[1:], ":"); end > 0 {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+				tag = text[1 : end+1]
+				value.WriteString(text[end+2:])
+				continue
+			}
+		}
+		if text == "-" || strings.HasPrefix(text, "-}") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			tag, balance = "", nil
+			continue
+		}
+		value.WriteString("\n" + text)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnreadableStatement, err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseSwiftAmount reads amounts with a decimal comma, e.g. 1500,00
+func parseSwiftAmount(v string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid amount %q", ErrUnreadableStatement, v)
+	}
+	return f, nil
+}
+
+// ------------------------ CAMT.053 ------------------------
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	Balances []struct {
+		Type      string     `xml:"Tp>CdOrPrtry>Cd"`
+		Amount    camtAmount `xml:"Amt"`
+		Indicator string     `xml:"CdtDbtInd"`
+	} `xml:"Bal"`
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtAmount struct {
+	Value string `xml:",chardata"`
+}
+
+type camtEntry struct {
+	Amount       camtAmount `xml:"Amt"`
+	Indicator    string     `xml:"CdtDbtInd"`
+	Reversal     bool       `xml:"RvslInd"`
+	Status       camtStatus `xml:"Sts"`
+	BookingDate  camtDate   `xml:"BookgDt"`
+	ValueDate    camtDate   `xml:"ValDt"`
+	ServicerRef  string     `xml:"AcctSvcrRef"`
+	AdditionalIn string     `xml:"AddtlNtryInf"`
+	Details      []struct {
+		EndToEndID  string   `xml:"Refs>EndToEndId"`
+		InstrID     string   `xml:"Refs>InstrId"`
+		ChequeNo    string   `xml:"Refs>ChqNb"`
+		Unstructure []string `xml:"RmtInf>Ustrd"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+// camtStatus holds the entry status, given as text before camt.053.001.08
+// and as a Cd element from then on.
+type camtStatus struct {
+	Value string `xml:",chardata"`
+	Code  string `xml:"Cd"`
+}
+
+// booked reports whether the entry is final. Pending (PDNG) and
+// informational (INFO) entries may still change or never be booked.
+func (s camtStatus) booked() bool {
+	code := strings.TrimSpace(s.Code)
+	if code == "" {
+		code = strings.TrimSpace(s.Value)
+	}
+	return code == "" || code == "BOOK"
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) parse() (time.Time, bool) {
+	if d.Date != "" {
+		t, err := time.Parse("2006-01-02", d.Date)
+		return t, err == nil
+	}
+	if d.DateTime != "" {
+		t, err := time.Parse("2006-01-02T15:04:05", d.DateTime[:min(len(d.DateTime), 19)])
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), err == nil
+	}
+	return time.Time{}, false
+}
+
+// parseCAMT053 reads ISO 20022 camt.053 statements. The running balance is
+// carried from each statement's opening booked balance (OPBD), or from the
+// previous day's closing balance (PRCD). Entries that are not booked yet
+// are skipped.
+func parseCAMT053(content string) ([]StatementLine, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnreadableStatement, err)
+	}
+
+	var lines []StatementLine
+	for _, stmt := range doc.Statements {
+		var balance *float64
+		for _, b := range stmt.Balances {
+			if b.Type != "OPBD" && b.Type != "PRCD" {
+				continue
+			}
+			bal, err := parseStatementAmount(b.Amount.Value)
+			if err != nil {
+				return nil, err
+			}
+			if b.Indicator == "DBIT" {
+				bal = -bal
+			}
+			balance = &bal
+			break
+		}
+
+		for _, e := range stmt.Entries {
+			if !e.Status.booked() {
+				continue
+			}
+			date, ok := e.BookingDate.parse()
+			if !ok {
+				return nil, fmt.Errorf("%w: entry %q has no booking date", ErrUnreadableStatement, e.ServicerRef)
+			}
+			amount, err := parseStatementAmount(e.Amount.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			line := StatementLine{Date: date, Reference: e.ServicerRef}
+			if vd, ok := e.ValueDate.parse(); ok {
+				line.ValueDate = &vd
+			}
+			if (e.Indicator == "CRDT") != e.Reversal {
+				line.Deposit = amount
+			} else {
+				line.Withdrawal = amount
+			}
+
+			desc := []string{e.AdditionalIn}
+			for _, d := range e.Details {
+				// The payer's reference says more than the bank's own
+				for _, ref := range []string{d.ChequeNo, d.EndToEndID, d.InstrID} {
+					if ref != "" && ref != "NOTPROVIDED" {
+						line.Reference = ref
+						break
+					}
+				}
+				desc = append(desc, d.Unstructure...)
+			}
+			line.Description = strings.Join(strings.Fields(strings.Join(desc, " ")), " ")
+
+			if balance != nil {
+				bal := *balance + line.Deposit - line.Withdrawal
+				balance = &bal
+				line.Balance = &bal
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,224 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func datePtr(y int, m time.Month, d int) *time.Time {
+	t := date(y, m, d)
+	return &t
+}
+
+func TestParseMT940(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantDate  time.Time
+		wantValue *time.Time
+		deposit   float64
+		withdraw  float64
+		reference string
+	}{
+		{
+			name:      "value date only",
+			line:      ":61:240405C1500,00NTRFCHQ004512//HDFC8812",
+			wantDate:  date(2024, 4, 5),
+			deposit:   1500,
+			reference: "CHQ004512",
+		},
+		{
+			name:      "booking date same year",
+			line:      ":61:2404050406D250,50NTRFNONREF//HDFC8813",
+			wantDate:  date(2024, 4, 6),
+			wantValue: datePtr(2024, 4, 5),
+			withdraw:  250.5,
+			reference: "HDFC8813",
+		},
+		{
+			name:      "booked in the new year",
+			line:      ":61:2412310102C100,00NTRFREF1",
+			wantDate:  date(2025, 1, 2),
+			wantValue: datePtr(2024, 12, 31),
+			deposit:   100,
+			reference: "REF1",
+		},
+		{
+			name:      "booked in the old year",
+			line:      ":61:2501021231D100,00NTRFREF2",
+			wantDate:  date(2024, 12, 31),
+			wantValue: datePtr(2025, 1, 2),
+			withdraw:  100,
+			reference: "REF2",
+		},
+		{
+			name:      "reversal of debit",
+			line:      ":61:240405RD75,00NTRFREF3",
+			wantDate:  date(2024, 4, 5),
+			deposit:   75,
+			reference: "REF3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := ":20:STMT\n:60F:C240401INR1000,00\n" + tt.line + "\n:86:FEE PAYMENT\n:62F:C240430INR0,00\n-"
+			lines, err := ParseBankStatement(StatementMT940, content)
+			if err != nil {
+				t.Fatalf("ParseBankStatement: %v", err)
+			}
+			if len(lines) != 1 {
+				t.Fatalf("got %d lines, want 1", len(lines))
+			}
+			l := lines[0]
+			if !l.Date.Equal(tt.wantDate) {
+				t.Errorf("Date = %s, want %s", l.Date.Format(time.DateOnly), tt.wantDate.Format(time.DateOnly))
+			}
+			switch {
+			case tt.wantValue == nil && l.ValueDate != nil:
+				t.Errorf("ValueDate = %s, want none", l.ValueDate.Format(time.DateOnly))
+			case tt.wantValue != nil && (l.ValueDate == nil || !l.ValueDate.Equal(*tt.wantValue)):
+				t.Errorf("ValueDate = %v, want %s", l.ValueDate, tt.wantValue.Format(time.DateOnly))
+			}
+			if l.Deposit != tt.deposit || l.Withdrawal != tt.withdraw {
+				t.Errorf("Deposit, Withdrawal = %.2f, %.2f, want %.2f, %.2f", l.Deposit, l.Withdrawal, tt.deposit, tt.withdraw)
+			}
+			if l.Reference != tt.reference {
+				t.Errorf("Reference = %q, want %q", l.Reference, tt.reference)
+			}
+			if l.Description != "FEE PAYMENT" {
+				t.Errorf("Description = %q", l.Description)
+			}
+			if want := 1000 + tt.deposit - tt.withdraw; l.Balance == nil || *l.Balance != want {
+				t.Errorf("Balance = %v, want %.2f", l.Balance, want)
+			}
+		})
+	}
+}
+
+func TestParseMT940Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"bad statement line", ":20:STMT\n:61:2404C1500,00NTRF\n-"},
+		{"bad opening balance", ":20:STMT\n:60F:X240401INR1000,00\n-"},
+		{"no transactions", ":20:STMT\n:60F:C240401INR1000,00\n:62F:C240430INR1000,00\n-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseBankStatement(StatementMT940, tt.content); !errors.Is(err, ErrUnreadableStatement) {
+				t.Fatalf("err = %v, want ErrUnreadableStatement", err)
+			}
+		})
+	}
+}
+
+const camtFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt><Stmt>
+<Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="INR">9999.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+<Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="INR">1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+%s
+</Stmt></BkToCstmrStmt>
+</Document>`
+
+func camtEntryXML(status, amount, indicator, reversal, ref string) string {
+	return `<Ntry><Amt Ccy="INR">` + amount + `</Amt><CdtDbtInd>` + indicator + `</CdtDbtInd>` + reversal + status +
+		`<BookgDt><Dt>2024-04-05</Dt></BookgDt><ValDt><DtTm>2024-04-04T10:30:00</DtTm></ValDt>` +
+		`<AcctSvcrRef>` + ref + `</AcctSvcrRef><AddtlNtryInf>FEE  PAYMENT</AddtlNtryInf></Ntry>`
+}
+
+func TestParseCAMT053(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []string
+		want     []string
+		deposits []float64
+		balances []float64
+	}{
+		{
+			name: "booked text status",
+			entries: []string{
+				camtEntryXML("<Sts>BOOK</Sts>", "500.00", "CRDT", "", "A1"),
+				camtEntryXML("<Sts>BOOK</Sts>", "200.00", "DBIT", "", "A2"),
+			},
+			want:     []string{"A1", "A2"},
+			deposits: []float64{500, -200},
+			balances: []float64{1500, 1300},
+		},
+		{
+			name: "pending entries skipped",
+			entries: []string{
+				camtEntryXML("<Sts>PDNG</Sts>", "500.00", "CRDT", "", "P1"),
+				camtEntryXML("<Sts>BOOK</Sts>", "300.00", "CRDT", "", "B1"),
+				camtEntryXML("<Sts><Cd>PDNG</Cd></Sts>", "700.00", "DBIT", "", "P2"),
+				camtEntryXML("<Sts>INFO</Sts>", "10.00", "DBIT", "", "I1"),
+			},
+			want:     []string{"B1"},
+			deposits: []float64{300},
+			balances: []float64{1300},
+		},
+		{
+			name: "code status and missing status",
+			entries: []string{
+				camtEntryXML("<Sts><Cd>BOOK</Cd></Sts>", "50.00", "DBIT", "", "C1"),
+				camtEntryXML("", "25.00", "CRDT", "", "C2"),
+			},
+			want:     []string{"C1", "C2"},
+			deposits: []float64{-50, 25},
+			balances: []float64{950, 975},
+		},
+		{
+			name: "reversal flips direction",
+			entries: []string{
+				camtEntryXML("<Sts>BOOK</Sts>", "80.00", "DBIT", "<RvslInd>true</RvslInd>", "R1"),
+			},
+			want:     []string{"R1"},
+			deposits: []float64{80},
+			balances: []float64{1080},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := fmt.Sprintf(camtFixture, strings.Join(tt.entries, "\n"))
+			lines, err := ParseBankStatement(StatementCAMT053, content)
+			if err != nil {
+				t.Fatalf("ParseBankStatement: %v", err)
+			}
+			if len(lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d", len(lines), len(tt.want))
+			}
+			for i, l := range lines {
+				if l.Reference != tt.want[i] {
+					t.Errorf("line %d Reference = %q, want %q", i, l.Reference, tt.want[i])
+				}
+				if got := l.Deposit - l.Withdrawal; got != tt.deposits[i] {
+					t.Errorf("line %d net = %.2f, want %.2f", i, got, tt.deposits[i])
+				}
+				if l.Balance == nil || *l.Balance != tt.balances[i] {
+					t.Errorf("line %d Balance = %v, want %.2f", i, l.Balance, tt.balances[i])
+				}
+				if !l.Date.Equal(date(2024, 4, 5)) || l.ValueDate == nil || !l.ValueDate.Equal(date(2024, 4, 4)) {
+					t.Errorf("line %d dates = %s, %v", i, l.Date.Format(time.DateOnly), l.ValueDate)
+				}
+				if l.Description != "FEE PAYMENT" {
+					t.Errorf("line %d Description = %q", i, l.Description)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCAMT053OnlyPending(t *testing.T) {
+	content := fmt.Sprintf(camtFixture, camtEntryXML("<Sts>PDNG</Sts>", "500.00", "CRDT", "", "P1"))
+	if _, err := ParseBankStatement(StatementCAMT053, content); !errors.Is(err, ErrUnreadableStatement) {
+		t.Fatalf("err = %v, want ErrUnreadableStatement", err)
+	}
+}
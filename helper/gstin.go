@@ -0,0 +1,51 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ------------------------ GSTIN ------------------------
+
+var ErrInvalidGSTIN = errors.New("GSTIN must be a 2-digit state code, a PAN, an entity number, Z and a check character")
+
+// A GSTIN is the state code, the holder's PAN, the entity number within the
+// PAN, the letter Z and a check character
+var gstinPattern = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+
+const gstinCharset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// NormalizeGSTIN upper-cases a GSTIN and drops the spaces people type into it
+func NormalizeGSTIN(gstin string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(gstin), ""))
+}
+
+// ValidateGSTIN checks the format and the check character of a normalised GSTIN
+func ValidateGSTIN(gstin string) error {
+	if !gstinPattern.MatchString(gstin) {
+		return ErrInvalidGSTIN
+	}
+	if gstinCheckChar(gstin[:14]) != gstin[14] {
+		return fmt.Errorf("%w: check character of %s does not match", ErrInvalidGSTIN, gstin)
+	}
+	return nil
+}
+
+// GSTINStateCode is the GST code of the state a valid GSTIN is registered in
+func GSTINStateCode(gstin string) string {
+	return gstin[:2]
+}
+
+// gstinCheckChar computes the check character over the first 14 characters:
+// their values in base 36 are weighted 1 and 2 in turn, each product's base
+// 36 digits summed, and the check is what brings the sum to a multiple of 36
+func gstinCheckChar(body string) byte {
+	sum := 0
+	for i := 0; i < len(body); i++ {
+		p := strings.IndexByte(gstinCharset, body[i]) * (i%2 + 1)
+		sum += p/36 + p%36
+	}
+	return gstinCharset[(36-sum%36)%36]
+}
@@ -0,0 +1,61 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateGSTIN(t *testing.T) {
+	tests := []struct {
+		name  string
+		gstin string
+		valid bool
+	}{
+		{"valid", "27AAPFU0939F1ZV", true},
+		{"valid with letter entity", "29AAGCB7383JAZV", true},
+		{"wrong check character", "27AAPFU0939F1ZW", false},
+		{"transposed digits", "27AAPFU9039F1ZV", false},
+		{"entity number zero", "27AAPFU0939F0ZV", false},
+		{"missing Z", "27AAPFU0939F1AV", false},
+		{"lower case", "27aapfu0939f1zv", false},
+		{"too short", "27AAPFU0939F1Z", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGSTIN(tt.gstin)
+			if tt.valid && err != nil {
+				t.Fatalf("ValidateGSTIN(%q) = %v, want nil", tt.gstin, err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidGSTIN) {
+				t.Fatalf("ValidateGSTIN(%q) = %v, want ErrInvalidGSTIN", tt.gstin, err)
+			}
+		})
+	}
+}
+
+func TestGSTINCheckChar(t *testing.T) {
+	tests := []struct {
+		body string
+		want byte
+	}{
+		{"27AAPFU0939F1Z", 'V'},
+		{"00000000000000", '0'},
+		{"10000000000000", 'Z'},
+		{"01000000000000", 'Y'},
+	}
+	for _, tt := range tests {
+		if got := gstinCheckChar(tt.body); got != tt.want {
+			t.Errorf("gstinCheckChar(%q) = %c, want %c", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeGSTIN(t *testing.T) {
+	if got := NormalizeGSTIN(" 27aapfu0939f1zv "); got != "27AAPFU0939F1ZV" {
+		t.Fatalf("NormalizeGSTIN = %q", got)
+	}
+	if got := NormalizeGSTIN("27 AAPFU 0939 F1ZV"); got != "27AAPFU0939F1ZV" {
+		t.Fatalf("NormalizeGSTIN = %q", got)
+	}
+}
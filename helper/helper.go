@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"slices"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -141,6 +142,15 @@ func NullInt32ToValue(v sql.NullInt32) int32 {
 	return v.Int32
 }
 
+// NullNumericToValue parses a NUMERIC column, which sqlc reads as a string
+func NullNumericToValue(v sql.NullString) float64 {
+	if !v.Valid {
+		return 0
+	}
+	f, _ := strconv.ParseFloat(v.String, 64)
+	return f
+}
+
 func TimeOrZero(t *time.Time) time.Time {
 	if t == nil {
 		return time.Time{}
@@ -192,6 +202,17 @@ func NullFloatToPtr(v sql.NullFloat64) *float64 {
 	return &v.Float64
 }
 
+func NullNumericToPtr(v sql.NullString) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	f, err := strconv.ParseFloat(v.String, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
 func NullTimeToPtr(v sql.NullTime) *time.Time {
 	if !v.Valid {
 		return nil
@@ -263,3 +284,10 @@ func DerefUUID(u *uuid.UUID) uuid.UUID {
 	}
 	return *u
 }
+
+func DerefInt(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
@@ -19,8 +19,10 @@ type EmailConfig struct {
 type EmailTemplateType string
 
 const (
-	OTPEmail          EmailTemplateType = "otp"
-	NotificationEmail EmailTemplateType = "notification"
+	OTPEmail           EmailTemplateType = "otp"
+	NotificationEmail  EmailTemplateType = "notification"
+	PasswordResetEmail EmailTemplateType = "password_reset"
+	WelcomeEmail       EmailTemplateType = "welcome"
 )
 
 // SendEmail sends an email using the given configuration and template
@@ -61,6 +63,35 @@ func SendEmail(cfg EmailConfig, to string, subject string, templateType EmailTem
         </html>
         `, message)
 
+	case PasswordResetEmail:
+		link := data["link"]
+		body = fmt.Sprintf(`
+        <html>
+        <body>
+            <div style="font-family: sans-serif; max-width: 600px; margin: auto; padding: 20px; border: 1px solid #ddd; border-radius: 10px;">
+                <h2 style="color: #4f46e5;">Reset your password</h2>
+                <p>Use the link below to choose a new password. It expires in %s minutes.</p>
+                <p><a href="%s" style="color: #4f46e5;">Reset password</a></p>
+                <p>If you didn't request this, please ignore this email.</p>
+            </div>
+        </body>
+        </html>
+        `, data["minutes"], link)
+
+	case WelcomeEmail:
+		body = fmt.Sprintf(`
+        <html>
+        <body>
+            <div style="font-family: sans-serif; max-width: 600px; margin: auto; padding: 20px; border: 1px solid #ddd; border-radius: 10px;">
+                <h2 style="color: #4f46e5;">Welcome to %s</h2>
+                <p>Hello %s, a SwiftSchool account has been created for you.</p>
+                <p>Your username is <strong>%s</strong>.</p>
+                <p>To sign in for the first time, enter this email address on the login page and use the one-time code we send you. You can also choose "Forgot password" to set a password.</p>
+            </div>
+        </body>
+        </html>
+        `, data["institute"], data["name"], data["username"])
+
 	default:
 		body = "Hello, this is a default message."
 	}
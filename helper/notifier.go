@@ -0,0 +1,315 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"swiftschool/domain"
+
+	"github.com/google/uuid"
+)
+
+// ------------------------ Channels ------------------------
+type NotificationChannel string
+
+const (
+	ChannelEmail NotificationChannel = "email"
+	ChannelSMS   NotificationChannel = "sms"
+)
+
+const (
+	DeliveryStatusSent   = "sent"
+	DeliveryStatusFailed = "failed"
+)
+
+var ErrNotifierNotConfigured = errors.New("notification channel is not configured")
+
+// Notification is a single outbound message.
+type Notification struct {
+	InstituteID uuid.UUID // Owner of the delivery log row; uuid.Nil for platform messages
+	To          string
+	Subject     string            // Email only
+	Template    EmailTemplateType // Email only
+	Data        map[string]string // Email template data
+	Body        string            // SMS text
+	LogBody     string            // Stored in delivery logs instead of Body when the body is secret
+}
+
+// ------------------------ Notifier Interface ------------------------
+type Notifier interface {
+	Channel() NotificationChannel
+	// Send delivers the notification and returns the provider's message ID, if any.
+	Send(ctx context.Context, n Notification) (string, error)
+}
+
+var (
+	notifiersMu sync.RWMutex
+	notifiers   = make(map[NotificationChannel]Notifier)
+)
+
+// RegisterNotifier installs the notifier for its channel, replacing any previous one.
+func RegisterNotifier(n Notifier) {
+	notifiersMu.Lock()
+	notifiers[n.Channel()] = n
+	notifiersMu.Unlock()
+}
+
+// Notify sends a notification over the given channel.
+func Notify(ctx context.Context, channel NotificationChannel, n Notification) error {
+	notifiersMu.RLock()
+	notifier, ok := notifiers[channel]
+	notifiersMu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotifierNotConfigured, channel)
+	}
+
+	_, err := notifier.Send(ctx, n)
+	return err
+}
+
+// ------------------------ SMTP ------------------------
+
+// SMTPNotifier delivers email through SendEmail.
+type SMTPNotifier struct {
+	Config EmailConfig
+}
+
+func NewSMTPNotifier(cfg EmailConfig) *SMTPNotifier {
+	return &SMTPNotifier{Config: cfg}
+}
+
+func (s *SMTPNotifier) Channel() NotificationChannel { return ChannelEmail }
+
+func (s *SMTPNotifier) Send(ctx context.Context, n Notification) (string, error) {
+	if err := SendEmail(s.Config, n.To, n.Subject, n.Template, n.Data); err != nil {
+		return "", fmt.Errorf("smtp delivery failed: %w", err)
+	}
+	return "", nil
+}
+
+// ------------------------ SMS ------------------------
+
+// SMSProvider is an SMS gateway.
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, body string) (providerID string, err error)
+}
+
+// SMSNotifier delivers text messages through an SMSProvider.
+type SMSNotifier struct {
+	Provider SMSProvider
+}
+
+func NewSMSNotifier(provider SMSProvider) *SMSNotifier {
+	return &SMSNotifier{Provider: provider}
+}
+
+func (s *SMSNotifier) Channel() NotificationChannel { return ChannelSMS }
+
+func (s *SMSNotifier) Send(ctx context.Context, n Notification) (string, error) {
+	return s.Provider.SendSMS(ctx, n.To, n.Body)
+}
+
+// HTTPSMSProvider posts messages to a JSON SMS gateway:
+// POST {BaseURL} {"to", "message", "sender_id"} with a bearer API key,
+// answering {"id": "..."} or {"message_id": "..."}.
+type HTTPSMSProvider struct {
+	BaseURL  string
+	APIKey   string
+	SenderID string
+	Client   *http.Client
+}
+
+func NewHTTPSMSProvider(baseURL, apiKey, senderID string, timeout time.Duration) *HTTPSMSProvider {
+	if timeout <= 0 {
+		timeout = time.Duration(DefaultTimeoutSec) * time.Second
+	}
+	return &HTTPSMSProvider{
+		BaseURL:  baseURL,
+		APIKey:   apiKey,
+		SenderID: senderID,
+		Client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *HTTPSMSProvider) SendSMS(ctx context.Context, to, body string) (string, error) {
+	payload, _ := json.Marshal(map[string]string{
+		"to":        to,
+		"message":   body,
+		"sender_id": p.SenderID,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("sms request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sms request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("sms provider returned %d", resp.StatusCode)
+	}
+
+	var result struct {
+		ID        string `json:"id"`
+		MessageID string `json:"message_id"`
+	}
+	_ = json.Unmarshal(respBody, &result)
+
+	if result.ID != "" {
+		return result.ID, nil
+	}
+	return result.MessageID, nil
+}
+
+// FakeSMSProvider records messages in memory instead of sending them.
+// Intended for tests and local development.
+type FakeSMSProvider struct {
+	mu       sync.Mutex
+	messages []FakeSMS
+}
+
+type FakeSMS struct {
+	ID   string
+	To   string
+	Body string
+}
+
+func NewFakeSMSProvider() *FakeSMSProvider {
+	return &FakeSMSProvider{}
+}
+
+func (f *FakeSMSProvider) SendSMS(ctx context.Context, to, body string) (string, error) {
+	id := "fake-" + uuid.NewString()
+
+	f.mu.Lock()
+	f.messages = append(f.messages, FakeSMS{ID: id, To: to, Body: body})
+	f.mu.Unlock()
+	return id, nil
+}
+
+// Messages returns the messages sent so far.
+func (f *FakeSMSProvider) Messages() []FakeSMS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeSMS(nil), f.messages...)
+}
+
+// ------------------------ Delivery Logs ------------------------
+
+// DeliveryLog records the outcome of every notification.
+type DeliveryLog interface {
+	RecordEmail(ctx context.Context, entry domain.EmailLog) error
+	RecordSMS(ctx context.Context, entry domain.SMSLog) error
+}
+
+// LoggedNotifier wraps a Notifier and writes each delivery result to a DeliveryLog.
+type LoggedNotifier struct {
+	Next Notifier
+	Log  DeliveryLog
+}
+
+func NewLoggedNotifier(next Notifier, log DeliveryLog) *LoggedNotifier {
+	return &LoggedNotifier{Next: next, Log: log}
+}
+
+func (l *LoggedNotifier) Channel() NotificationChannel { return l.Next.Channel() }
+
+func (l *LoggedNotifier) Send(ctx context.Context, n Notification) (string, error) {
+	providerID, sendErr := l.Next.Send(ctx, n)
+
+	status := DeliveryStatusSent
+	if sendErr != nil {
+		status = DeliveryStatusFailed
+	}
+	now := time.Now()
+
+	var logErr error
+	switch l.Next.Channel() {
+	case ChannelEmail:
+		logErr = l.Log.RecordEmail(ctx, domain.EmailLog{
+			ID:             uuid.New(),
+			InstituteID:    n.InstituteID,
+			RecipientEmail: &n.To,
+			Subject:        &n.Subject,
+			Status:         &status,
+			SentAt:         &now,
+		})
+	case ChannelSMS:
+		body := n.Body
+		if n.LogBody != "" {
+			body = n.LogBody
+		}
+		logErr = l.Log.RecordSMS(ctx, domain.SMSLog{
+			ID:             uuid.New(),
+			InstituteID:    n.InstituteID,
+			RecipientPhone: &n.To,
+			MessageBody:    &body,
+			Status:         &status,
+			ProviderID:     NullStringToPtr(ToNullString(providerID)),
+			SentAt:         &now,
+		})
+	}
+	if logErr != nil {
+		logger.Warnf("failed to record %s delivery to %s: %v", l.Next.Channel(), n.To, logErr)
+	}
+
+	return providerID, sendErr
+}
+
+// PostgresDeliveryLog writes delivery results to comms.email_logs and comms.sms_logs.
+type PostgresDeliveryLog struct {
+	db *sql.DB
+}
+
+func NewPostgresDeliveryLog(db *sql.DB) *PostgresDeliveryLog {
+	return &PostgresDeliveryLog{db: db}
+}
+
+func (p *PostgresDeliveryLog) RecordEmail(ctx context.Context, entry domain.EmailLog) error {
+	const query = `
+		INSERT INTO comms.email_logs (id, institute_id, recipient_email, subject, status, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := p.db.ExecContext(ctx, query,
+		entry.ID,
+		ToNullUUID(entry.InstituteID),
+		entry.RecipientEmail,
+		entry.Subject,
+		entry.Status,
+		entry.SentAt,
+	)
+	return err
+}
+
+func (p *PostgresDeliveryLog) RecordSMS(ctx context.Context, entry domain.SMSLog) error {
+	const query = `
+		INSERT INTO comms.sms_logs (id, institute_id, recipient_phone, message_body, status, provider_id, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := p.db.ExecContext(ctx, query,
+		entry.ID,
+		ToNullUUID(entry.InstituteID),
+		entry.RecipientPhone,
+		entry.MessageBody,
+		entry.Status,
+		entry.ProviderID,
+		entry.SentAt,
+	)
+	return err
+}
@@ -1,25 +1,113 @@
 package helper
 
 import (
+	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"sync"
 	"time"
 )
 
+// -------------------- OTP POLICY -------------------- //
+
+// OTPPolicy bounds how often codes may be sent and guessed per identifier.
+type OTPPolicy struct {
+	TTL            time.Duration // Lifetime of an issued code
+	MaxAttempts    int           // Wrong guesses allowed before lockout
+	Lockout        time.Duration // How long an identifier stays locked
+	ResendCooldown time.Duration // Minimum gap between two sends
+	DailyLimit     int           // Sends allowed per rolling 24 hours
+}
+
+// DefaultOTPPolicy is used for any zero field passed to SetOTPStore.
+var DefaultOTPPolicy = OTPPolicy{
+	TTL:            5 * time.Minute,
+	MaxAttempts:    5,
+	Lockout:        15 * time.Minute,
+	ResendCooldown: time.Minute,
+	DailyLimit:     10,
+}
+
+const otpSendWindow = 24 * time.Hour
+
+var (
+	ErrOTPInvalid    = errors.New("invalid or expired OTP")
+	ErrOTPLocked     = errors.New("too many failed attempts, try again later")
+	ErrOTPCooldown   = errors.New("please wait before requesting another OTP")
+	ErrOTPDailyLimit = errors.New("daily OTP limit reached, try again tomorrow")
+	ErrOTPNotFound   = errors.New("otp not found")
+)
+
 // -------------------- OTP STORAGE -------------------- //
 
-type OTPEntry struct {
-	Code      string
-	CreatedAt time.Time
-	ExpiresAt time.Time
+// OTPRecord is the per-identifier OTP state. Only a keyed hash of the code
+// is stored; send and attempt counters outlive the code itself.
+type OTPRecord struct {
+	Identifier      string
+	CodeHash        string // Empty once consumed or invalidated
+	CreatedAt       time.Time
+	ExpiresAt       time.Time
+	Attempts        int
+	LockedUntil     time.Time
+	SendCount       int
+	WindowStartedAt time.Time // Start of the rolling daily send window
+	LastSentAt      time.Time
+}
+
+// OTPStore persists OTP state. Get returns ErrOTPNotFound for unknown
+// identifiers. Update runs fn on the identifier's record, an empty one if
+// there is none, and saves what fn leaves in it whatever fn returns. The
+// record must stay locked for the whole call, so concurrent sends and
+// guesses for one identifier run one after another.
+type OTPStore interface {
+	Get(ctx context.Context, identifier string) (*OTPRecord, error)
+	Update(ctx context.Context, identifier string, fn func(rec *OTPRecord) error) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
 }
 
 var (
-	otpStore = make(map[string]OTPEntry)
-	mutex    sync.RWMutex
+	otpStore   OTPStore = NewMemoryOTPStore()
+	otpPolicy           = DefaultOTPPolicy
+	otpHashKey          = randomOTPKey()
 )
 
+// SetOTPStore replaces the OTP backend, limits and hashing key.
+// Zero policy fields and an empty key keep the defaults.
+func SetOTPStore(store OTPStore, policy OTPPolicy, hashKey string) {
+	if store != nil {
+		otpStore = store
+	}
+	if policy.TTL > 0 {
+		otpPolicy.TTL = policy.TTL
+	}
+	if policy.MaxAttempts > 0 {
+		otpPolicy.MaxAttempts = policy.MaxAttempts
+	}
+	if policy.Lockout > 0 {
+		otpPolicy.Lockout = policy.Lockout
+	}
+	if policy.ResendCooldown > 0 {
+		otpPolicy.ResendCooldown = policy.ResendCooldown
+	}
+	if policy.DailyLimit > 0 {
+		otpPolicy.DailyLimit = policy.DailyLimit
+	}
+	if hashKey != "" {
+		otpHashKey = []byte(hashKey)
+	} else {
+		logger.Warnf("OTP hash key not set, using a per-process key; OTPs will not verify across replicas")
+	}
+}
+
+// OTPValidity returns how long an issued code stays valid.
+func OTPValidity() time.Duration {
+	return otpPolicy.TTL
+}
+
 // -------------------- OTP FUNCTIONS -------------------- //
 
 // GenerateRandomOTP generates a secure numeric OTP of given length (default 6 digits)
@@ -43,61 +131,185 @@ func GenerateRandomOTP(length int) string {
 	return string(otp)
 }
 
-// StoreOTP stores an OTP for a username with TTL
-func StoreOTP(username, otp string, ttl time.Duration) error {
-	if username == "" || otp == "" {
-		return errors.New("username and otp cannot be empty")
+// IssueOTP generates and stores a new code for identifier, enforcing the
+// lockout, resend cooldown and daily send limit. The plain code is
+// returned for delivery and never stored.
+func IssueOTP(ctx context.Context, identifier string) (string, error) {
+	if identifier == "" {
+		return "", errors.New("identifier cannot be empty")
 	}
 
-	entry := OTPEntry{
-		Code:      otp,
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(ttl),
+	code := GenerateRandomOTP(6)
+	err := otpStore.Update(ctx, identifier, func(rec *OTPRecord) error {
+		now := time.Now()
+		if now.Before(rec.LockedUntil) {
+			return ErrOTPLocked
+		}
+		if !rec.LastSentAt.IsZero() && now.Sub(rec.LastSentAt) < otpPolicy.ResendCooldown {
+			return ErrOTPCooldown
+		}
+
+		if rec.WindowStartedAt.IsZero() || now.Sub(rec.WindowStartedAt) >= otpSendWindow {
+			rec.WindowStartedAt = now
+			rec.SendCount = 0
+		}
+		if rec.SendCount >= otpPolicy.DailyLimit {
+			return ErrOTPDailyLimit
+		}
+
+		rec.CodeHash = hashOTP(identifier, code)
+		rec.CreatedAt = now
+		rec.ExpiresAt = now.Add(otpPolicy.TTL)
+		rec.Attempts = 0
+		rec.SendCount++
+		rec.LastSentAt = now
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
+	return code, nil
+}
 
-	mutex.Lock()
-	otpStore[username] = entry
-	mutex.Unlock()
-	return nil
+// VerifyOTP checks code against the stored hash in constant time. A match
+// consumes the code; a miss counts towards the lockout. The check and its
+// outcome are one update of the record, so parallel guesses cannot exceed
+// the attempt limit and a code cannot be used twice.
+func VerifyOTP(ctx context.Context, identifier, code string) error {
+	if _, err := otpStore.Get(ctx, identifier); err != nil {
+		if errors.Is(err, ErrOTPNotFound) {
+			return ErrOTPInvalid
+		}
+		return err
+	}
+
+	actual := []byte(hashOTP(identifier, code))
+	return otpStore.Update(ctx, identifier, func(rec *OTPRecord) error {
+		now := time.Now()
+		if now.Before(rec.LockedUntil) {
+			return ErrOTPLocked
+		}
+		if rec.CodeHash == "" || now.After(rec.ExpiresAt) {
+			return ErrOTPInvalid
+		}
+
+		if subtle.ConstantTimeCompare([]byte(rec.CodeHash), actual) != 1 {
+			rec.Attempts++
+			if rec.Attempts >= otpPolicy.MaxAttempts {
+				rec.CodeHash = ""
+				rec.LockedUntil = now.Add(otpPolicy.Lockout)
+				logger.Warnf("OTP locked for %s after %d failed attempts", identifier, rec.Attempts)
+				return ErrOTPLocked
+			}
+			return ErrOTPInvalid
+		}
+
+		rec.CodeHash = ""
+		rec.Attempts = 0
+		return nil
+	})
+}
+
+// -------------------- CLEANUP -------------------- //
+
+// CleanupExpiredOTPs removes records whose code, lockout and send window
+// have all lapsed.
+func CleanupExpiredOTPs(ctx context.Context) (int64, error) {
+	return otpStore.DeleteExpired(ctx, time.Now())
+}
+
+// StartOTPJanitor runs CleanupExpiredOTPs every interval until ctx is done.
+func StartOTPJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := CleanupExpiredOTPs(ctx); err != nil {
+					logger.Errorf("otp cleanup failed: %v", err)
+				} else if n > 0 {
+					logger.Infof("otp cleanup removed %d records", n)
+				}
+			}
+		}
+	}()
+}
+
+func otpRecordExpired(rec *OTPRecord, now time.Time) bool {
+	return now.After(rec.ExpiresAt) &&
+		now.After(rec.LockedUntil) &&
+		now.Sub(rec.WindowStartedAt) >= otpSendWindow
 }
 
-// GetStoredOTP retrieves OTP code and creation time for a username
-// Returns error if not found or expired (automatically deletes expired OTP)
-func GetStoredOTP(username string) (code string, createdAt time.Time, err error) {
-	mutex.RLock()
-	entry, ok := otpStore[username]
-	mutex.RUnlock()
+// -------------------- HASHING -------------------- //
 
+// hashOTP binds the code to its identifier so equal codes hash differently.
+func hashOTP(identifier, code string) string {
+	mac := hmac.New(sha256.New, otpHashKey)
+	mac.Write([]byte(identifier))
+	mac.Write([]byte{0})
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomOTPKey() []byte {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
+}
+
+// -------------------- IN-MEMORY STORE -------------------- //
+
+// MemoryOTPStore keeps OTP state in process memory. Intended for tests
+// and single-instance development setups.
+type MemoryOTPStore struct {
+	mu      sync.Mutex
+	records map[string]OTPRecord
+}
+
+func NewMemoryOTPStore() *MemoryOTPStore {
+	return &MemoryOTPStore{records: make(map[string]OTPRecord)}
+}
+
+func (m *MemoryOTPStore) Get(ctx context.Context, identifier string) (*OTPRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.records[identifier]
 	if !ok {
-		return "", time.Time{}, errors.New("otp not found")
+		return nil, ErrOTPNotFound
 	}
+	return &rec, nil
+}
 
-	if time.Now().After(entry.ExpiresAt) {
-		// delete expired OTP
-		DeleteOTP(username)
-		return "", entry.CreatedAt, errors.New("otp expired")
-	}
+func (m *MemoryOTPStore) Update(ctx context.Context, identifier string, fn func(rec *OTPRecord) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	return entry.Code, entry.CreatedAt, nil
-}
+	rec, ok := m.records[identifier]
+	if !ok {
+		rec = OTPRecord{Identifier: identifier}
+	}
 
-// DeleteOTP removes an OTP for a username
-func DeleteOTP(username string) {
-	mutex.Lock()
-	delete(otpStore, username)
-	mutex.Unlock()
+	err := fn(&rec)
+	m.records[identifier] = rec
+	return err
 }
 
-// -------------------- OPTIONAL CLEANUP -------------------- //
+func (m *MemoryOTPStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-// CleanupExpiredOTPs can be run periodically to clean up expired OTPs
-func CleanupExpiredOTPs() {
-	now := time.Now()
-	mutex.Lock()
-	for username, entry := range otpStore {
-		if now.After(entry.ExpiresAt) {
-			delete(otpStore, username)
+	var n int64
+	for id, rec := range m.records {
+		if otpRecordExpired(&rec, now) {
+			delete(m.records, id)
+			n++
 		}
 	}
-	mutex.Unlock()
+	return n, nil
 }
@@ -0,0 +1,133 @@
+package helper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PostgresOTPStore persists OTP state in auth.otp_codes so attempt and send
+// limits survive restarts and apply across replicas.
+type PostgresOTPStore struct {
+	db *sql.DB
+}
+
+func NewPostgresOTPStore(db *sql.DB) *PostgresOTPStore {
+	return &PostgresOTPStore{db: db}
+}
+
+const otpColumns = `
+	identifier, code_hash, created_at, expires_at, attempts,
+	locked_until, send_count, window_started_at, last_sent_at`
+
+func (p *PostgresOTPStore) Get(ctx context.Context, identifier string) (*OTPRecord, error) {
+	query := `SELECT ` + otpColumns + ` FROM auth.otp_codes WHERE identifier = $1`
+
+	rec, err := scanOTPRecord(p.db.QueryRowContext(ctx, query, identifier))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOTPNotFound
+	}
+	return rec, err
+}
+
+// Update locks the identifier's row for the length of a transaction,
+// inserting an empty one first if there is none, so sends and guesses
+// from several replicas are applied one at a time.
+func (p *PostgresOTPStore) Update(ctx context.Context, identifier string, fn func(rec *OTPRecord) error) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const insert = `
+		INSERT INTO auth.otp_codes (identifier, created_at, expires_at, window_started_at)
+		VALUES ($1, to_timestamp(0), to_timestamp(0), to_timestamp(0))
+		ON CONFLICT (identifier) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, insert, identifier); err != nil {
+		return err
+	}
+
+	query := `SELECT ` + otpColumns + ` FROM auth.otp_codes WHERE identifier = $1 FOR UPDATE`
+	rec, err := scanOTPRecord(tx.QueryRowContext(ctx, query, identifier))
+	if err != nil {
+		return err
+	}
+
+	fnErr := fn(rec)
+
+	const update = `
+		UPDATE auth.otp_codes SET
+			code_hash         = $2,
+			created_at        = $3,
+			expires_at        = $4,
+			attempts          = $5,
+			locked_until      = $6,
+			send_count        = $7,
+			window_started_at = $8,
+			last_sent_at      = $9
+		WHERE identifier = $1`
+
+	_, err = tx.ExecContext(ctx, update,
+		rec.Identifier,
+		ToNullString(rec.CodeHash),
+		rec.CreatedAt,
+		rec.ExpiresAt,
+		rec.Attempts,
+		ToNullTime(rec.LockedUntil),
+		rec.SendCount,
+		rec.WindowStartedAt,
+		ToNullTime(rec.LastSentAt),
+	)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return fnErr
+}
+
+func (p *PostgresOTPStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	const query = `
+		DELETE FROM auth.otp_codes
+		WHERE expires_at < $1
+		  AND (locked_until IS NULL OR locked_until < $1)
+		  AND window_started_at < $2`
+
+	res, err := p.db.ExecContext(ctx, query, now, now.Add(-otpSendWindow))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanOTPRecord(row *sql.Row) (*OTPRecord, error) {
+	var (
+		rec         OTPRecord
+		codeHash    sql.NullString
+		lockedUntil sql.NullTime
+		lastSentAt  sql.NullTime
+	)
+
+	err := row.Scan(
+		&rec.Identifier,
+		&codeHash,
+		&rec.CreatedAt,
+		&rec.ExpiresAt,
+		&rec.Attempts,
+		&lockedUntil,
+		&rec.SendCount,
+		&rec.WindowStartedAt,
+		&lastSentAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rec.CodeHash = NullStringToValue(codeHash)
+	rec.LockedUntil = lockedUntil.Time
+	rec.LastSentAt = lastSentAt.Time
+	return &rec, nil
+}
@@ -0,0 +1,113 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// useMemoryOTPStore swaps in an empty store with the default policy.
+func useMemoryOTPStore(t *testing.T) *MemoryOTPStore {
+	store := NewMemoryOTPStore()
+	prevStore, prevPolicy := otpStore, otpPolicy
+	otpStore, otpPolicy = store, DefaultOTPPolicy
+	t.Cleanup(func() { otpStore, otpPolicy = prevStore, prevPolicy })
+	return store
+}
+
+func TestIssueOTPLimits(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		rec     OTPRecord
+		wantErr error
+	}{
+		{"first send", OTPRecord{}, nil},
+		{"within cooldown", OTPRecord{LastSentAt: now.Add(-10 * time.Second), SendCount: 1, WindowStartedAt: now}, ErrOTPCooldown},
+		{"after cooldown", OTPRecord{LastSentAt: now.Add(-2 * time.Minute), SendCount: 1, WindowStartedAt: now}, nil},
+		{"locked", OTPRecord{LockedUntil: now.Add(time.Minute)}, ErrOTPLocked},
+		{"lockout lapsed", OTPRecord{LockedUntil: now.Add(-time.Minute)}, nil},
+		{"daily limit", OTPRecord{LastSentAt: now.Add(-time.Hour), SendCount: 10, WindowStartedAt: now.Add(-2 * time.Hour)}, ErrOTPDailyLimit},
+		{"daily window rolled over", OTPRecord{LastSentAt: now.Add(-time.Hour), SendCount: 10, WindowStartedAt: now.Add(-25 * time.Hour)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useMemoryOTPStore(t)
+			ctx := context.Background()
+			_ = store.Update(ctx, "user@example.com", func(rec *OTPRecord) error {
+				*rec = tt.rec
+				return nil
+			})
+
+			code, err := IssueOTP(ctx, "user@example.com")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("IssueOTP error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(code) != 6 {
+				t.Errorf("IssueOTP code = %q, want 6 digits", code)
+			}
+		})
+	}
+}
+
+func TestVerifyOTPLockout(t *testing.T) {
+	useMemoryOTPStore(t)
+	ctx := context.Background()
+	const id = "9876543210"
+
+	code, err := IssueOTP(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	steps := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"miss 1", wrong, ErrOTPInvalid},
+		{"miss 2", wrong, ErrOTPInvalid},
+		{"miss 3", wrong, ErrOTPInvalid},
+		{"miss 4", wrong, ErrOTPInvalid},
+		{"miss 5 locks", wrong, ErrOTPLocked},
+		{"right code while locked", code, ErrOTPLocked},
+	}
+	for _, s := range steps {
+		if err := VerifyOTP(ctx, id, s.code); !errors.Is(err, s.wantErr) {
+			t.Fatalf("%s: VerifyOTP error = %v, want %v", s.name, err, s.wantErr)
+		}
+	}
+
+	if _, err := IssueOTP(ctx, id); !errors.Is(err, ErrOTPLocked) {
+		t.Errorf("IssueOTP while locked error = %v, want %v", err, ErrOTPLocked)
+	}
+}
+
+func TestVerifyOTPConsumesCode(t *testing.T) {
+	useMemoryOTPStore(t)
+	ctx := context.Background()
+
+	code, err := IssueOTP(ctx, "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		identifier string
+		wantErr    error
+	}{
+		{"other@example.com", ErrOTPInvalid},
+		{"user@example.com", nil},
+		{"user@example.com", ErrOTPInvalid},
+	}
+	for i, tt := range tests {
+		if err := VerifyOTP(ctx, tt.identifier, code); !errors.Is(err, tt.wantErr) {
+			t.Errorf("attempt %d: VerifyOTP(%s) error = %v, want %v", i+1, tt.identifier, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// -------------------- AUTH POLICY -------------------- //
+
+// AuthPolicy bounds password login and the password reset flow.
+type AuthPolicy struct {
+	MaxLoginAttempts int           // Wrong passwords or TOTP codes before lockout
+	LoginLockout     time.Duration // How long an account stays locked
+	ResetTokenTTL    time.Duration // Lifetime of a forgot-password token
+	ResetURL         string        // Page that receives ?token=...
+	MFAChallengeTTL  time.Duration // Time allowed between password and TOTP steps
+}
+
+// DefaultAuthPolicy is used for any zero field passed to SetAuthPolicy.
+var DefaultAuthPolicy = AuthPolicy{
+	MaxLoginAttempts: 5,
+	LoginLockout:     15 * time.Minute,
+	ResetTokenTTL:    30 * time.Minute,
+	ResetURL:         "/reset-password",
+	MFAChallengeTTL:  5 * time.Minute,
+}
+
+var (
+	ErrWeakPassword  = errors.New("password must be at least 8 characters and include upper and lower case letters, a digit and a symbol")
+	ErrAccountLocked = errors.New("account temporarily locked after too many failed attempts")
+)
+
+const passwordHashCost = 12
+
+var authPolicy = DefaultAuthPolicy
+
+// SetAuthPolicy replaces the password login settings and the key used to
+// encrypt TOTP secrets. Zero fields keep the defaults.
+func SetAuthPolicy(policy AuthPolicy, mfaKey string) {
+	if policy.MaxLoginAttempts > 0 {
+		authPolicy.MaxLoginAttempts = policy.MaxLoginAttempts
+	}
+	if policy.LoginLockout > 0 {
+		authPolicy.LoginLockout = policy.LoginLockout
+	}
+	if policy.ResetTokenTTL > 0 {
+		authPolicy.ResetTokenTTL = policy.ResetTokenTTL
+	}
+	if policy.ResetURL != "" {
+		authPolicy.ResetURL = policy.ResetURL
+	}
+	if policy.MFAChallengeTTL > 0 {
+		authPolicy.MFAChallengeTTL = policy.MFAChallengeTTL
+	}
+	setTOTPKey(mfaKey)
+}
+
+// GetAuthPolicy returns the active password login settings.
+func GetAuthPolicy() AuthPolicy {
+	return authPolicy
+}
+
+// -------------------- PASSWORDS -------------------- //
+
+// HashPassword checks strength and returns a bcrypt hash of password.
+func HashPassword(password string) (string, error) {
+	if !IsPasswordStrong(password) {
+		return "", ErrWeakPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordHashCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches a stored bcrypt hash.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// dummyPasswordHash keeps failed lookups as slow as a real comparison so
+// response times do not reveal which usernames exist.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("swiftschool-dummy"), passwordHashCost)
+
+// BurnPasswordCheck spends the same time as CheckPassword on a dummy hash.
+func BurnPasswordCheck(password string) {
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
+
+// -------------------- OPAQUE TOKENS -------------------- //
+
+// GenerateToken returns a random URL-safe token for reset links and
+// login challenges.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// LookupHash returns the value stored in *_hash columns for username,
+// email and phone lookups: SHA-256 of the trimmed, lower-cased input.
+// Returns "" for empty input.
+func LookupHash(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashToken returns the value stored for a high-entropy token. Tokens are
+// random, so a plain SHA-256 is enough to keep them unusable if leaked.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
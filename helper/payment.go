@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -26,6 +27,10 @@ const (
 	Paytm     PaymentGatewayType = "paytm"
 	PhonePe   PaymentGatewayType = "phonepe"
 	BharatPe  PaymentGatewayType = "bharatpe"
+	// FakePayment is a local gateway for development and tests. It signs
+	// callbacks with the configured secret like a real gateway would, so
+	// NewGateway only builds it once EnableFakePayment has been called.
+	FakePayment PaymentGatewayType = "fake"
 )
 
 // ------------------------ Global Configs ------------------------
@@ -39,8 +44,25 @@ var (
 	PaytmBaseURL     = getEnv("PAYTM_BASE_URL", "https://securegw.paytm.in/theia/processTransaction")
 	PhonePeBaseURL   = getEnv("PHONEPE_BASE_URL", "https://merchants.phonepe.com/upi/pay")
 	BharatPeBaseURL  = getEnv("BHARATPE_BASE_URL", "https://www.bharatpe.com/payment")
+	FakePaymentURL   = getEnv("FAKE_PAYMENT_URL", "http://localhost:8080/fake-gateway/checkout")
 )
 
+// fakePaymentEnabled gates FakePayment. Anyone who knows its flow can sign
+// their own callbacks, so it must never be offered in production.
+var fakePaymentEnabled bool
+
+// EnableFakePayment lets NewGateway build FakePayment gateways. Call it
+// only when the app runs outside production.
+func EnableFakePayment() {
+	fakePaymentEnabled = true
+	logger.Warnf("fake payment gateway enabled, payments can be marked paid without a real gateway")
+}
+
+// FakePaymentEnabled reports whether EnableFakePayment was called
+func FakePaymentEnabled() bool {
+	return fakePaymentEnabled
+}
+
 // ------------------------ Helpers ------------------------
 func getEnv(key, defaultVal string) string {
 	if val := os.Getenv(key); val != "" {
@@ -88,13 +110,26 @@ type PaymentGateway interface {
 	VerifyPayment(ctx context.Context, payload map[string]string) (bool, error)
 }
 
+// PaymentRefunder is implemented by gateways that can return a captured
+// payment through their API. RefundPayment returns the gateway's refund id;
+// calls repeated with the same idempotency key refund the payment only once.
+type PaymentRefunder interface {
+	RefundPayment(ctx context.Context, paymentID string, amount float64, idempotencyKey string) (string, error)
+}
+
+// PaidAmountReader is implemented by gateways that can confirm how much a
+// verified callback paid, in rupees.
+type PaidAmountReader interface {
+	PaidAmount(ctx context.Context, payload map[string]string) (float64, error)
+}
+
 // ------------------------ Razorpay Gateway ------------------------
 type RazorpayGateway struct{ Config PaymentConfig }
 
 func (r *RazorpayGateway) CreatePayment(ctx context.Context, req PaymentRequest) (string, error) {
 	url := fmt.Sprintf("%s/orders", RazorpayBaseURL)
 	payload := map[string]interface{}{
-		"amount":          int(req.Amount * 100),
+		"amount":          int64(math.Round(req.Amount * 100)),
 		"currency":        r.Config.Currency,
 		"receipt":         req.OrderID,
 		"payment_capture": 1,
@@ -143,12 +178,79 @@ func (r *RazorpayGateway) VerifyPayment(ctx context.Context, payload map[string]
 	h.Write([]byte(orderID + "|" + paymentID))
 	expected := hex.EncodeToString(h.Sum(nil))
 
-	if expected != signature {
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
 		return false, fmt.Errorf("razorpay signature mismatch")
 	}
 	return true, nil
 }
 
+// PaidAmount fetches the payment named by the callback and returns what
+// Razorpay captured for it, since the callback itself carries no amount.
+func (r *RazorpayGateway) PaidAmount(ctx context.Context, payload map[string]string) (float64, error) {
+	url := fmt.Sprintf("%s/payments/%s", RazorpayBaseURL, payload["razorpay_payment_id"])
+	request, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	request.SetBasicAuth(r.Config.Key, r.Config.Secret)
+
+	client := &http.Client{Timeout: r.Config.Timeout}
+	resp, err := client.Do(request)
+	if err != nil {
+		return 0, fmt.Errorf("razorpay payment request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return 0, fmt.Errorf("razorpay payment error: %s", string(respBody))
+	}
+
+	var data struct {
+		Amount  int64  `json:"amount"`
+		OrderID string `json:"order_id"`
+	}
+	if err := json.Unmarshal(respBody, &data); err != nil {
+		return 0, fmt.Errorf("razorpay payment response parse error: %w", err)
+	}
+	if data.OrderID != payload["razorpay_order_id"] {
+		return 0, fmt.Errorf("razorpay payment belongs to order %s", data.OrderID)
+	}
+	return float64(data.Amount) / 100, nil
+}
+
+func (r *RazorpayGateway) RefundPayment(ctx context.Context, paymentID string, amount float64, idempotencyKey string) (string, error) {
+	url := fmt.Sprintf("%s/payments/%s/refund", RazorpayBaseURL, paymentID)
+	body, _ := json.Marshal(map[string]interface{}{
+		"amount":  int64(math.Round(amount * 100)),
+		"receipt": idempotencyKey,
+	})
+	request, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(body)))
+	request.SetBasicAuth(r.Config.Key, r.Config.Secret)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Razorpay-Idempotency", idempotencyKey)
+
+	client := &http.Client{Timeout: r.Config.Timeout}
+	resp, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("razorpay refund request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("razorpay refund error: %s", string(respBody))
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(respBody, &data); err != nil {
+		return "", fmt.Errorf("razorpay refund response parse error: %w", err)
+	}
+
+	id, ok := data["id"].(string)
+	if !ok {
+		return "", fmt.Errorf("razorpay refund id missing")
+	}
+	return id, nil
+}
+
 // ------------------------ PayUMoney Gateway ------------------------
 type PayUMoneyGateway struct{ Config PaymentConfig }
 
@@ -164,18 +266,42 @@ func (p *PayUMoneyGateway) CreatePayment(ctx context.Context, req PaymentRequest
 	form.Add("surl", p.Config.CallbackURL)
 	form.Add("furl", p.Config.CallbackURL)
 
-	hashStr := fmt.Sprintf("%s|%s|%.2f|%s|%s|||||||||||%s",
-		p.Config.Key, req.OrderID, req.Amount, req.Product, req.CustomerID, p.Config.MerchantKey)
-	h := sha512.Sum512([]byte(hashStr))
-	form.Add("hash", hex.EncodeToString(h[:]))
+	// key|txnid|amount|productinfo|firstname|email|udf1..udf5||||||salt,
+	// with the user defined fields left empty
+	form.Add("hash", payuHash(p.Config.Key, form.Get("txnid"), form.Get("amount"), form.Get("productinfo"),
+		form.Get("firstname"), form.Get("email"), "", "", "", "", "", "", "", "", "", "", p.Config.MerchantKey))
 
 	return fmt.Sprintf("%s?%s", PayUMoneyBaseURL, form.Encode()), nil
 }
 
+// VerifyPayment checks PayU's reverse hash, which covers the status and
+// amount, using the merchant salt:
+// salt|status||||||udf5..udf1|email|firstname|productinfo|amount|txnid|key
 func (p *PayUMoneyGateway) VerifyPayment(ctx context.Context, payload map[string]string) (bool, error) {
-	status := payload["status"]
-	hash := payload["hash"]
-	return status == "success" && hash != "", nil
+	expected := payuHash(p.Config.MerchantKey, payload["status"], "", "", "", "", "",
+		payload["udf5"], payload["udf4"], payload["udf3"], payload["udf2"], payload["udf1"],
+		payload["email"], payload["firstname"], payload["productinfo"], payload["amount"], payload["txnid"], payload["key"])
+
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(payload["hash"]))) {
+		return false, fmt.Errorf("payu hash mismatch")
+	}
+	return payload["status"] == "success", nil
+}
+
+// payuHash is the hex SHA-512 of fields joined with pipes
+func payuHash(fields ...string) string {
+	h := sha512.Sum512([]byte(strings.Join(fields, "|")))
+	return hex.EncodeToString(h[:])
+}
+
+// PaidAmount returns the amount of the callback, which the reverse hash
+// checked by VerifyPayment covers.
+func (p *PayUMoneyGateway) PaidAmount(ctx context.Context, payload map[string]string) (float64, error) {
+	var amount float64
+	if _, err := fmt.Sscanf(payload["amount"], "%f", &amount); err != nil {
+		return 0, fmt.Errorf("payu amount %q: %w", payload["amount"], err)
+	}
+	return amount, nil
 }
 
 // ------------------------ Placeholder Gateways ------------------------
@@ -215,6 +341,38 @@ func (b *BharatPeGateway) VerifyPayment(ctx context.Context, payload map[string]
 	return true, nil
 }
 
+// ------------------------ Fake Gateway ------------------------
+// FakeGateway sends the payer to FakePaymentURL and accepts callbacks
+// carrying order_id, payment_id, status and a signature from Sign.
+type FakeGateway struct{ Config PaymentConfig }
+
+func (f *FakeGateway) CreatePayment(ctx context.Context, req PaymentRequest) (string, error) {
+	form := url.Values{}
+	form.Add("order_id", req.OrderID)
+	form.Add("amount", fmt.Sprintf("%.2f", req.Amount))
+	form.Add("callback_url", f.Config.CallbackURL)
+	return fmt.Sprintf("%s?%s", FakePaymentURL, form.Encode()), nil
+}
+
+func (f *FakeGateway) VerifyPayment(ctx context.Context, payload map[string]string) (bool, error) {
+	if !hmac.Equal([]byte(f.Sign(payload)), []byte(payload["signature"])) {
+		return false, fmt.Errorf("fake gateway signature mismatch")
+	}
+	return payload["status"] == "success", nil
+}
+
+// RefundPayment always succeeds with a refund id derived from the idempotency key
+func (f *FakeGateway) RefundPayment(ctx context.Context, paymentID string, amount float64, idempotencyKey string) (string, error) {
+	return "rfnd_" + idempotencyKey, nil
+}
+
+// Sign returns the signature the fake gateway puts on a callback payload
+func (f *FakeGateway) Sign(payload map[string]string) string {
+	h := hmac.New(sha256.New, []byte(f.Config.Secret))
+	h.Write([]byte(payload["order_id"] + "|" + payload["payment_id"] + "|" + payload["status"]))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // ------------------------ Factory ------------------------
 func NewGateway(cfg PaymentConfig) PaymentGateway {
 	switch cfg.Type {
@@ -230,11 +388,51 @@ func NewGateway(cfg PaymentConfig) PaymentGateway {
 		return &PhonePeGateway{Config: cfg}
 	case BharatPe:
 		return &BharatPeGateway{Config: cfg}
+	case FakePayment:
+		if !fakePaymentEnabled {
+			return nil
+		}
+		return &FakeGateway{Config: cfg}
 	default:
 		return nil
 	}
 }
 
+// ------------------------ Credential Storage ------------------------
+var (
+	paymentKey []byte
+
+	ErrPaymentSecretsNotConfigured = fmt.Errorf("payment gateway secret encryption is not configured")
+)
+
+// SetPaymentSecretKey sets the key gateway credentials are sealed with.
+// Online payments cannot be configured while it is empty.
+func SetPaymentSecretKey(key string) {
+	if key == "" {
+		paymentKey = nil
+		logger.Warnf("payment encryption key not set, online payments are disabled")
+		return
+	}
+	sum := sha256.Sum256([]byte(key))
+	paymentKey = sum[:]
+}
+
+// SealPaymentSecret encrypts a gateway secret for storage
+func SealPaymentSecret(secret string) (string, error) {
+	if paymentKey == nil {
+		return "", ErrPaymentSecretsNotConfigured
+	}
+	return sealSecret(paymentKey, secret)
+}
+
+// OpenPaymentSecret decrypts a value produced by SealPaymentSecret
+func OpenPaymentSecret(sealed string) (string, error) {
+	if paymentKey == nil {
+		return "", ErrPaymentSecretsNotConfigured
+	}
+	return openSecret(paymentKey, sealed)
+}
+
 // ------------------------ Helper Function ------------------------
 func CreatePaymentOrder(
 	gatewayType PaymentGatewayType,
@@ -0,0 +1,86 @@
+package helper
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func sha512Hex(s string) string {
+	h := sha512.Sum512([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+// The hash strings below are PayU's documented sequences written out for a
+// sample checkout:
+//
+//	request:  key|txnid|amount|productinfo|firstname|email|udf1|udf2|udf3|udf4|udf5||||||SALT
+//	response: SALT|status||||||udf5|udf4|udf3|udf2|udf1|email|firstname|productinfo|amount|txnid|key
+var payuTest = PaymentConfig{Type: PayUMoney, Key: "JP***g", MerchantKey: "sa***lt", CallbackURL: "https://school.example/cb"}
+
+func TestPayUCreatePaymentHash(t *testing.T) {
+	gw := &PayUMoneyGateway{Config: payuTest}
+	link, err := gw.CreatePayment(context.Background(), PaymentRequest{
+		OrderID:    "ypl938459435",
+		Amount:     10,
+		CustomerID: "Ashish",
+		Email:      "test@gmail.com",
+		Phone:      "9988776655",
+		Product:    "iPhone",
+	})
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("checkout link %q: %v", link, err)
+	}
+
+	want := sha512Hex("JP***g|ypl938459435|10.00|iPhone|Ashish|test@gmail.com|||||||||||sa***lt")
+	if got := u.Query().Get("hash"); got != want {
+		t.Fatalf("hash = %s, want %s", got, want)
+	}
+	if got := u.Query().Get("email"); got != "test@gmail.com" {
+		t.Fatalf("email = %q", got)
+	}
+}
+
+func TestPayUVerifyPayment(t *testing.T) {
+	gw := &PayUMoneyGateway{Config: payuTest}
+	callback := func(status, amount, udf1, hash string) map[string]string {
+		return map[string]string{
+			"key": "JP***g", "txnid": "ypl938459435", "amount": amount, "productinfo": "iPhone",
+			"firstname": "Ashish", "email": "test@gmail.com", "status": status, "udf1": udf1,
+			"mihpayid": "403993715521937565", "hash": hash,
+		}
+	}
+	success := sha512Hex("sa***lt|success|||||||||||test@gmail.com|Ashish|iPhone|10.00|ypl938459435|JP***g")
+	withUDF := sha512Hex("sa***lt|success||||||||||inv-42|test@gmail.com|Ashish|iPhone|10.00|ypl938459435|JP***g")
+	failure := sha512Hex("sa***lt|failure|||||||||||test@gmail.com|Ashish|iPhone|10.00|ypl938459435|JP***g")
+
+	tests := []struct {
+		name    string
+		payload map[string]string
+		paid    bool
+		wantErr bool
+	}{
+		{"success", callback("success", "10.00", "", success), true, false},
+		{"upper case hash", callback("success", "10.00", "", strings.ToUpper(success)), true, false},
+		{"user defined field", callback("success", "10.00", "inv-42", withUDF), true, false},
+		{"failed payment", callback("failure", "10.00", "", failure), false, false},
+		{"amount changed", callback("success", "1.00", "", success), false, true},
+		{"status changed", callback("success", "10.00", "", failure), false, true},
+		{"no hash", callback("success", "10.00", "", ""), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paid, err := gw.VerifyPayment(context.Background(), tt.payload)
+			if (err != nil) != tt.wantErr || paid != tt.paid {
+				t.Fatalf("VerifyPayment = (%v, %v), want paid %v, error %v", paid, err, tt.paid, tt.wantErr)
+			}
+		})
+	}
+}
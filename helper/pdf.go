@@ -0,0 +1,141 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ------------------------ Table PDF ------------------------
+// A small PDF writer for tabular reports. Text is set in the built-in
+// Courier font on landscape A4 pages, so columns line up by padding and no
+// font needs to be embedded.
+
+const (
+	pdfPageWidth  = 842
+	pdfPageHeight = 595
+	pdfMargin     = 36
+	pdfFontSize   = 8
+	pdfLeading    = 11
+	// Courier glyphs are 0.6em wide
+	pdfLineChars = (pdfPageWidth - 2*pdfMargin) * 10 / (pdfFontSize * 6)
+	pdfPageLines = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+	pdfMaxColumn = 48
+)
+
+// RenderTablePDF lays out a titled table over as many pages as it needs,
+// repeating the title and header on every page. Numeric cells are right
+// aligned. Characters outside printable ASCII are replaced with '?'.
+func RenderTablePDF(title, subtitle string, header []string, rows [][]string) []byte {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = len(h)
+	}
+	for _, row := range rows {
+		for i := range widths {
+			if i < len(row) && len(row[i]) > widths[i] {
+				widths[i] = min(len(row[i]), pdfMaxColumn)
+			}
+		}
+	}
+
+	format := func(cells []string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			var cell string
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			if len(cell) > w {
+				cell = cell[:w]
+			}
+			if _, err := strconv.ParseFloat(strings.TrimSpace(cell), 64); err == nil && i > 0 {
+				parts[i] = fmt.Sprintf("%*s", w, cell)
+			} else {
+				parts[i] = fmt.Sprintf("%-*s", w, cell)
+			}
+		}
+		return strings.Join(parts, "  ")
+	}
+
+	headerLine := format(header)
+	pageHead := []string{title, subtitle, "", headerLine, strings.Repeat("-", len(headerLine))}
+	perPage := pdfPageLines - len(pageHead)
+
+	var pages [][]string
+	for start := 0; start == 0 || start < len(rows); start += perPage {
+		lines := append([]string{}, pageHead...)
+		for _, row := range rows[start:min(start+perPage, len(rows))] {
+			lines = append(lines, format(row))
+		}
+		pages = append(pages, lines)
+	}
+
+	return writePDF(pages)
+}
+
+// writePDF writes one page per entry of pages, one text line per string
+func writePDF(pages [][]string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-3 are the catalog, page tree and font; each page then
+	// takes a page object followed by its content stream
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range lines {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", pdfEscape(line))
+		}
+		content.WriteString("ET")
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+func pdfEscape(s string) string {
+	if len(s) > pdfLineChars {
+		s = s[:pdfLineChars]
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
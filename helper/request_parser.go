@@ -2,8 +2,8 @@ package helper
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -37,6 +37,42 @@ func ParseUUIDFromPath(r *http.Request, key string) (uuid.UUID, error) {
 	return uuid.Parse(idStr)
 }
 
+// Layouts accepted for calendar dates and months in query parameters
+const (
+	DateLayout  = "2006-01-02"
+	MonthLayout = "2006-01"
+)
+
+// ParseRequiredDateFromQuery parses a required YYYY-MM-DD date from the query parameters
+func ParseRequiredDateFromQuery(r *http.Request, key string) (time.Time, error) {
+	return parseRequiredTime(r, key, DateLayout)
+}
+
+// ParseRequiredMonthFromQuery parses a required YYYY-MM month and returns its first day
+func ParseRequiredMonthFromQuery(r *http.Request, key string) (time.Time, error) {
+	return parseRequiredTime(r, key, MonthLayout)
+}
+
+// ParseDateFromQuery parses an optional YYYY-MM-DD date; it is nil when absent
+func ParseDateFromQuery(r *http.Request, key string) (*time.Time, error) {
+	if r.URL.Query().Get(key) == "" {
+		return nil, nil
+	}
+	t, err := parseRequiredTime(r, key, DateLayout)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func parseRequiredTime(r *http.Request, key, layout string) (time.Time, error) {
+	val := r.URL.Query().Get(key)
+	if val == "" {
+		return time.Time{}, ErrMissingParameter(key)
+	}
+	return time.Parse(layout, val)
+}
+
 // DecodeJSONBody decodes the JSON body into the target struct
 func DecodeJSONBody(r *http.Request, target interface{}) error {
 	if r.Body == nil {
@@ -63,22 +99,26 @@ func GetQueryParam(r *http.Request, key, defaultValue string) string {
 	return val
 }
 
-// GetInstituteID extracts the Institute ID from the request context or headers
-// Priority:
-// 1. Context (set by middleware) - TODO
-// 2. Header (X-Institute-ID) - For testing/API keys
+// GetInstituteID returns the institute bound to the request by the tenant
+// middleware. It never trusts client-supplied headers or parameters.
 func GetInstituteID(r *http.Request) (uuid.UUID, error) {
-	// 1. Try Header
-	idStr := r.Header.Get("X-Institute-ID")
-	if idStr != "" {
-		return uuid.Parse(idStr)
+	if id, ok := InstituteIDFromContext(r.Context()); ok {
+		return id, nil
 	}
+	return uuid.Nil, ErrNoInstitute
+}
 
-	// 2. Try Context (Placeholder for when we implement session middleware fully)
-	// val := r.Context().Value("institute_id")
-	// if id, ok := val.(uuid.UUID); ok {
-	// 	return id, nil
-	// }
+// GetSessionUserID returns the signed-in user's ID for audit columns such
+// as created_by, or nil when the request has no session.
+func GetSessionUserID(r *http.Request) *uuid.UUID {
+	session, ok := SessionFromContext(r.Context())
+	if !ok {
+		return nil
+	}
 
-	return uuid.Nil, errors.New("institute ID not found in request (missing X-Institute-ID header)")
+	id, err := uuid.Parse(session.UserID)
+	if err != nil {
+		return nil
+	}
+	return &id
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
@@ -16,62 +18,223 @@ import (
 //////////////////////////////////////////////////////
 
 type SessionData struct {
-	UserID   string
-	Username string
-	Role     string
-	Expiry   time.Time
+	ID                string
+	UserID            string
+	Username          string
+	Role              string
+	UserAgent         string
+	IPAddress         string
+	InstituteIDs      []string // Institutes the user belongs to
+	ActiveInstituteID string   // Institute explicitly selected for this session
+	CreatedAt         time.Time
+	LastSeenAt        time.Time
+	Expiry            time.Time // Absolute expiry, never extended
 }
 
+var (
+	ErrNoSessionCookie = errors.New("no session cookie")
+	ErrSessionInvalid  = errors.New("invalid session")
+	ErrSessionExpired  = errors.New("session expired")
+)
+
 //////////////////////////////////////////////////////
 //                SESSION STORE                   //
 //////////////////////////////////////////////////////
 
-type SessionStore struct {
-	mu       sync.RWMutex
-	sessions map[string]*SessionData
-}
-
-var store = &SessionStore{
-	sessions: make(map[string]*SessionData),
+// SessionStore persists sessions so they survive restarts and can be
+// shared between replicas. Get returns ErrSessionInvalid for unknown IDs.
+type SessionStore interface {
+	Save(ctx context.Context, session *SessionData) error
+	Get(ctx context.Context, sessionID string) (*SessionData, error)
+	Touch(ctx context.Context, sessionID string, lastSeenAt time.Time) error
+	SetActiveInstitute(ctx context.Context, sessionID, instituteID string) error
+	Delete(ctx context.Context, sessionID string) error
+	DeleteByUser(ctx context.Context, userID string) (int64, error)
+	DeleteOtherByUser(ctx context.Context, userID, keepSessionID string) (int64, error)
+	DeleteExpired(ctx context.Context, now time.Time, idleTimeout time.Duration) (int64, error)
 }
 
 const (
 	SessionCookieName = "swiftschool_session"
-	SessionDuration   = 24 * time.Hour
+
+	// SessionDuration is the absolute lifetime of a session.
+	SessionDuration = 24 * time.Hour
+	// SessionIdleTimeout logs a session out after this much inactivity.
+	SessionIdleTimeout = 30 * time.Minute
+	// sessionTouchInterval throttles last-seen writes to the store.
+	sessionTouchInterval = time.Minute
 )
 
+var (
+	sessionStore       SessionStore = NewMemorySessionStore()
+	sessionIdleTimeout              = SessionIdleTimeout
+	sessionAbsoluteTTL              = SessionDuration
+)
+
+// SetSessionStore replaces the session backend and expiry settings.
+// Zero durations keep the defaults.
+func SetSessionStore(store SessionStore, idleTimeout, absoluteTimeout time.Duration) {
+	if store != nil {
+		sessionStore = store
+	}
+	if idleTimeout > 0 {
+		sessionIdleTimeout = idleTimeout
+	}
+	if absoluteTimeout > 0 {
+		sessionAbsoluteTTL = absoluteTimeout
+	}
+}
+
+// Sessions returns the active session backend.
+func Sessions() SessionStore {
+	return sessionStore
+}
+
 //////////////////////////////////////////////////////
-//                 CONTEXT KEY                    //
+//              IN-MEMORY STORE                   //
 //////////////////////////////////////////////////////
 
-type sessionContextKeyType struct{}
+// MemorySessionStore keeps sessions in process memory. Intended for tests
+// and single-instance development setups.
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*SessionData
+}
 
-var sessionContextKey = sessionContextKeyType{}
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]*SessionData),
+	}
+}
+
+func (m *MemorySessionStore) Save(ctx context.Context, session *SessionData) error {
+	cp := *session
+	cp.InstituteIDs = slices.Clone(session.InstituteIDs)
+
+	m.mu.Lock()
+	m.sessions[session.ID] = &cp
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemorySessionStore) Get(ctx context.Context, sessionID string) (*SessionData, error) {
+	m.mu.RLock()
+	session, ok := m.sessions[sessionID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrSessionInvalid
+	}
+
+	cp := *session
+	cp.InstituteIDs = slices.Clone(session.InstituteIDs)
+	return &cp, nil
+}
+
+func (m *MemorySessionStore) Touch(ctx context.Context, sessionID string, lastSeenAt time.Time) error {
+	m.mu.Lock()
+	if session, ok := m.sessions[sessionID]; ok {
+		session.LastSeenAt = lastSeenAt
+	}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemorySessionStore) SetActiveInstitute(ctx context.Context, sessionID, instituteID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return ErrSessionInvalid
+	}
+	session.ActiveInstituteID = instituteID
+	return nil
+}
+
+func (m *MemorySessionStore) Delete(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	delete(m.sessions, sessionID)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemorySessionStore) DeleteByUser(ctx context.Context, userID string) (int64, error) {
+	var n int64
+
+	m.mu.Lock()
+	for id, session := range m.sessions {
+		if session.UserID == userID {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+	m.mu.Unlock()
+	return n, nil
+}
+
+func (m *MemorySessionStore) DeleteOtherByUser(ctx context.Context, userID, keepSessionID string) (int64, error) {
+	var n int64
+
+	m.mu.Lock()
+	for id, session := range m.sessions {
+		if session.UserID == userID && id != keepSessionID {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+	m.mu.Unlock()
+	return n, nil
+}
+
+func (m *MemorySessionStore) DeleteExpired(ctx context.Context, now time.Time, idleTimeout time.Duration) (int64, error) {
+	var n int64
+
+	m.mu.Lock()
+	for id, session := range m.sessions {
+		if sessionExpired(session, now, idleTimeout) {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+	m.mu.Unlock()
+	return n, nil
+}
 
 //////////////////////////////////////////////////////
 //              SESSION OPERATIONS                //
 //////////////////////////////////////////////////////
 
+// CreateSession starts a session for the user. instituteIDs are the tenants
+// the user may operate on; super admins pass none and select one explicitly.
 func CreateSession(
 	w http.ResponseWriter,
+	r *http.Request,
 	userID, username, role string,
+	instituteIDs []string,
 ) error {
-	sessionID := uuid.NewString()
+	now := time.Now()
 
 	session := &SessionData{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
-		Expiry:   time.Now().Add(SessionDuration),
+		ID:           uuid.NewString(),
+		UserID:       userID,
+		Username:     username,
+		Role:         role,
+		UserAgent:    r.UserAgent(),
+		IPAddress:    clientIP(r),
+		InstituteIDs: instituteIDs,
+		CreatedAt:    now,
+		LastSeenAt:   now,
+		Expiry:       now.Add(sessionAbsoluteTTL),
 	}
 
-	store.mu.Lock()
-	store.sessions[sessionID] = session
-	store.mu.Unlock()
+	if err := sessionStore.Save(r.Context(), session); err != nil {
+		return err
+	}
 
 	http.SetCookie(w, &http.Cookie{
 		Name:     SessionCookieName,
-		Value:    sessionID,
+		Value:    session.ID,
 		Path:     "/",
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
@@ -81,38 +244,118 @@ func CreateSession(
 	return nil
 }
 
+// GetSession loads the session for the request cookie, enforcing idle and
+// absolute expiry and sliding the idle window forward on use.
 func GetSession(r *http.Request) (*SessionData, error) {
 	cookie, err := r.Cookie(SessionCookieName)
 	if err != nil {
-		return nil, errors.New("no session cookie")
+		return nil, ErrNoSessionCookie
 	}
 
-	store.mu.RLock()
-	session, ok := store.sessions[cookie.Value]
-	store.mu.RUnlock()
+	ctx := r.Context()
 
-	if !ok {
-		return nil, errors.New("invalid session")
+	session, err := sessionStore.Get(ctx, cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if sessionExpired(session, now, sessionIdleTimeout) {
+		_ = DeleteSession(ctx, session.ID)
+		return nil, ErrSessionExpired
 	}
 
-	if time.Now().After(session.Expiry) {
-		DeleteSession(cookie.Value)
-		return nil, errors.New("session expired")
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := sessionStore.Touch(ctx, session.ID, now); err != nil {
+			logger.Warnf("failed to renew session for user %s: %v", session.UserID, err)
+		}
+		session.LastSeenAt = now
 	}
 
 	return session, nil
 }
 
-func DeleteSession(sessionID string) {
-	store.mu.Lock()
-	delete(store.sessions, sessionID)
-	store.mu.Unlock()
+func DeleteSession(ctx context.Context, sessionID string) error {
+	return sessionStore.Delete(ctx, sessionID)
+}
+
+// SetActiveInstitute switches the tenant a session operates on.
+func SetActiveInstitute(ctx context.Context, sessionID, instituteID string) error {
+	return sessionStore.SetActiveInstitute(ctx, sessionID, instituteID)
+}
+
+// DeleteUserSessions revokes every session belonging to a user.
+func DeleteUserSessions(ctx context.Context, userID string) (int64, error) {
+	return sessionStore.DeleteByUser(ctx, userID)
+}
+
+// DeleteOtherUserSessions revokes every session of a user except keepSessionID.
+func DeleteOtherUserSessions(ctx context.Context, userID, keepSessionID string) (int64, error) {
+	return sessionStore.DeleteOtherByUser(ctx, userID, keepSessionID)
+}
+
+// ClearSessionCookie instructs the browser to drop the session cookie.
+func ClearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+		Expires:  time.Unix(0, 0),
+	})
+}
+
+// PurgeExpiredSessions removes expired sessions from the store.
+func PurgeExpiredSessions(ctx context.Context) (int64, error) {
+	return sessionStore.DeleteExpired(ctx, time.Now(), sessionIdleTimeout)
+}
+
+// StartSessionJanitor purges expired sessions every interval until ctx is done.
+func StartSessionJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := PurgeExpiredSessions(ctx); err != nil {
+					logger.Errorf("session cleanup failed: %v", err)
+				} else if n > 0 {
+					logger.Infof("session cleanup removed %d expired sessions", n)
+				}
+			}
+		}
+	}()
+}
+
+func sessionExpired(session *SessionData, now time.Time, idleTimeout time.Duration) bool {
+	if now.After(session.Expiry) {
+		return true
+	}
+	return idleTimeout > 0 && now.Sub(session.LastSeenAt) > idleTimeout
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
 
 //////////////////////////////////////////////////////
 //                CONTEXT HELPERS                 //
 //////////////////////////////////////////////////////
 
+type sessionContextKeyType struct{}
+
+var sessionContextKey = sessionContextKeyType{}
+
 func WithSession(ctx context.Context, session *SessionData) context.Context {
 	return context.WithValue(ctx, sessionContextKey, session)
 }
@@ -130,6 +373,9 @@ func RequireSession(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		session, err := GetSession(r)
 		if err != nil {
+			if errors.Is(err, ErrSessionExpired) {
+				ClearSessionCookie(w)
+			}
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
@@ -0,0 +1,147 @@
+package helper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostgresSessionStore persists sessions in auth.sessions so logins survive
+// restarts and are shared across replicas.
+type PostgresSessionStore struct {
+	db *sql.DB
+}
+
+func NewPostgresSessionStore(db *sql.DB) *PostgresSessionStore {
+	return &PostgresSessionStore{db: db}
+}
+
+func (p *PostgresSessionStore) Save(ctx context.Context, session *SessionData) error {
+	const query = `
+		INSERT INTO auth.sessions (
+			id, user_id, username, role_type, user_agent, ip_address,
+			institute_ids, active_institute_id,
+			created_at, last_seen_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := p.db.ExecContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.Username,
+		session.Role,
+		ToNullString(session.UserAgent),
+		ToNullString(session.IPAddress),
+		pq.Array(session.InstituteIDs),
+		ToNullString(session.ActiveInstituteID),
+		session.CreatedAt,
+		session.LastSeenAt,
+		session.Expiry,
+	)
+	return err
+}
+
+func (p *PostgresSessionStore) Get(ctx context.Context, sessionID string) (*SessionData, error) {
+	const query = `
+		SELECT id, user_id, username, role_type, user_agent, ip_address,
+		       institute_ids, active_institute_id,
+		       created_at, last_seen_at, expires_at
+		FROM auth.sessions
+		WHERE id = $1`
+
+	var (
+		s               SessionData
+		userAgent       sql.NullString
+		ipAddress       sql.NullString
+		activeInstitute sql.NullString
+	)
+
+	err := p.db.QueryRowContext(ctx, query, sessionID).Scan(
+		&s.ID,
+		&s.UserID,
+		&s.Username,
+		&s.Role,
+		&userAgent,
+		&ipAddress,
+		pq.Array(&s.InstituteIDs),
+		&activeInstitute,
+		&s.CreatedAt,
+		&s.LastSeenAt,
+		&s.Expiry,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.UserAgent = NullStringToValue(userAgent)
+	s.IPAddress = NullStringToValue(ipAddress)
+	s.ActiveInstituteID = NullStringToValue(activeInstitute)
+	return &s, nil
+}
+
+func (p *PostgresSessionStore) Touch(ctx context.Context, sessionID string, lastSeenAt time.Time) error {
+	const query = `UPDATE auth.sessions SET last_seen_at = $2 WHERE id = $1`
+
+	_, err := p.db.ExecContext(ctx, query, sessionID, lastSeenAt)
+	return err
+}
+
+func (p *PostgresSessionStore) SetActiveInstitute(ctx context.Context, sessionID, instituteID string) error {
+	const query = `UPDATE auth.sessions SET active_institute_id = $2 WHERE id = $1`
+
+	res, err := p.db.ExecContext(ctx, query, sessionID, ToNullString(instituteID))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrSessionInvalid
+	}
+	return nil
+}
+
+func (p *PostgresSessionStore) Delete(ctx context.Context, sessionID string) error {
+	const query = `DELETE FROM auth.sessions WHERE id = $1`
+
+	_, err := p.db.ExecContext(ctx, query, sessionID)
+	return err
+}
+
+func (p *PostgresSessionStore) DeleteByUser(ctx context.Context, userID string) (int64, error) {
+	const query = `DELETE FROM auth.sessions WHERE user_id = $1`
+
+	res, err := p.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (p *PostgresSessionStore) DeleteOtherByUser(ctx context.Context, userID, keepSessionID string) (int64, error) {
+	const query = `DELETE FROM auth.sessions WHERE user_id = $1 AND id <> $2`
+
+	res, err := p.db.ExecContext(ctx, query, userID, keepSessionID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (p *PostgresSessionStore) DeleteExpired(ctx context.Context, now time.Time, idleTimeout time.Duration) (int64, error) {
+	const query = `DELETE FROM auth.sessions WHERE expires_at < $1 OR last_seen_at < $2`
+
+	var idleCutoff time.Time
+	if idleTimeout > 0 {
+		idleCutoff = now.Add(-idleTimeout)
+	}
+
+	res, err := p.db.ExecContext(ctx, query, now, idleCutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
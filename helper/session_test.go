@@ -0,0 +1,128 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionExpired(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		lastSeen time.Time
+		expiry   time.Time
+		idle     time.Duration
+		want     bool
+	}{
+		{"active", now.Add(-time.Minute), now.Add(time.Hour), 30 * time.Minute, false},
+		{"idle timeout", now.Add(-31 * time.Minute), now.Add(time.Hour), 30 * time.Minute, true},
+		{"absolute expiry while active", now, now.Add(-time.Second), 30 * time.Minute, true},
+		{"idle timeout disabled", now.Add(-48 * time.Hour), now.Add(time.Hour), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SessionData{LastSeenAt: tt.lastSeen, Expiry: tt.expiry}
+			if got := sessionExpired(s, now, tt.idle); got != tt.want {
+				t.Errorf("sessionExpired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSession(t *testing.T) {
+	store := NewMemorySessionStore()
+	prev := sessionStore
+	sessionStore = store
+	t.Cleanup(func() { sessionStore = prev })
+
+	ctx := context.Background()
+	now := time.Now()
+	sessions := []*SessionData{
+		{ID: "live", UserID: "u1", LastSeenAt: now, Expiry: now.Add(time.Hour)},
+		{ID: "idle", UserID: "u1", LastSeenAt: now.Add(-2 * sessionIdleTimeout), Expiry: now.Add(time.Hour)},
+		{ID: "expired", UserID: "u1", LastSeenAt: now, Expiry: now.Add(-time.Minute)},
+		{ID: "revoked", UserID: "u2", LastSeenAt: now, Expiry: now.Add(time.Hour)},
+		{ID: "other", UserID: "u3", LastSeenAt: now, Expiry: now.Add(time.Hour)},
+	}
+	for _, s := range sessions {
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := DeleteUserSessions(ctx, "u2"); err != nil || n != 1 {
+		t.Fatalf("DeleteUserSessions = %d, %v, want 1, nil", n, err)
+	}
+
+	tests := []struct {
+		cookie  string
+		wantErr error
+	}{
+		{"live", nil},
+		{"idle", ErrSessionExpired},
+		{"expired", ErrSessionExpired},
+		{"revoked", ErrSessionInvalid},
+		{"other", nil},
+		{"", ErrNoSessionCookie},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cookie, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: SessionCookieName, Value: tt.cookie})
+			}
+			_, err := GetSession(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetSession error = %v, want %v", err, tt.wantErr)
+			}
+			// Expired sessions are removed on first use
+			if errors.Is(tt.wantErr, ErrSessionExpired) {
+				if _, err := store.Get(ctx, tt.cookie); !errors.Is(err, ErrSessionInvalid) {
+					t.Errorf("expired session %q still stored", tt.cookie)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteOtherUserSessions(t *testing.T) {
+	store := NewMemorySessionStore()
+	prev := sessionStore
+	sessionStore = store
+	t.Cleanup(func() { sessionStore = prev })
+
+	ctx := context.Background()
+	for _, s := range []*SessionData{
+		{ID: "current", UserID: "u1"},
+		{ID: "laptop", UserID: "u1"},
+		{ID: "phone", UserID: "u1"},
+		{ID: "someone-else", UserID: "u2"},
+	} {
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := DeleteOtherUserSessions(ctx, "u1", "current"); err != nil || n != 2 {
+		t.Fatalf("DeleteOtherUserSessions = %d, %v, want 2, nil", n, err)
+	}
+
+	tests := []struct {
+		id   string
+		kept bool
+	}{
+		{"current", true},
+		{"laptop", false},
+		{"phone", false},
+		{"someone-else", true},
+	}
+	for _, tt := range tests {
+		_, err := store.Get(ctx, tt.id)
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("session %q kept = %v, want %v", tt.id, kept, tt.kept)
+		}
+	}
+}
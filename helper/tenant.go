@@ -0,0 +1,120 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"swiftschool/domain"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoInstitute  = errors.New("no institute selected for this session")
+	ErrCrossTenant  = errors.New("access to another institute is not allowed")
+	ErrNotPermitted = errors.New("institute is not available to this user")
+)
+
+//////////////////////////////////////////////////////
+//                CONTEXT HELPERS                 //
+//////////////////////////////////////////////////////
+
+type instituteContextKeyType struct{}
+
+var instituteContextKey = instituteContextKeyType{}
+
+// WithInstituteID stores the resolved tenant on the request context.
+func WithInstituteID(ctx context.Context, instituteID uuid.UUID) context.Context {
+	return context.WithValue(ctx, instituteContextKey, instituteID)
+}
+
+// InstituteIDFromContext returns the tenant resolved by the tenant middleware.
+func InstituteIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(instituteContextKey).(uuid.UUID)
+	return id, ok && id != uuid.Nil
+}
+
+//////////////////////////////////////////////////////
+//               SESSION TENANCY                  //
+//////////////////////////////////////////////////////
+
+// IsSuperAdmin reports whether the session belongs to a platform super admin.
+func IsSuperAdmin(session *SessionData) bool {
+	return session != nil && session.Role == string(domain.RoleSuperAdmin)
+}
+
+// SessionInstitute returns the institute the session currently operates on.
+// An explicitly selected institute wins; otherwise a user bound to exactly
+// one institute uses it. Returns "" when no tenant can be resolved.
+func SessionInstitute(session *SessionData) string {
+	if session.ActiveInstituteID != "" {
+		return session.ActiveInstituteID
+	}
+	if len(session.InstituteIDs) == 1 {
+		return session.InstituteIDs[0]
+	}
+	return ""
+}
+
+// SessionHasInstitute reports whether the session may operate on instituteID.
+// Super admins may select any institute.
+func SessionHasInstitute(session *SessionData, instituteID string) bool {
+	if IsSuperAdmin(session) {
+		return true
+	}
+	return slices.Contains(session.InstituteIDs, instituteID)
+}
+
+// BindInstituteID returns the request's tenant and rejects a client-supplied
+// institute that differs from it. A zero claimed ID is ignored.
+func BindInstituteID(r *http.Request, claimed uuid.UUID) (uuid.UUID, error) {
+	instID, err := GetInstituteID(r)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if claimed != uuid.Nil && claimed != instID {
+		logCrossTenant(r, instID, claimed)
+		return uuid.Nil, ErrCrossTenant
+	}
+
+	return instID, nil
+}
+
+// AuthorizeInstitute checks that the request may act on a record owned by
+// instituteID. Super admins may act on any institute.
+func AuthorizeInstitute(r *http.Request, instituteID uuid.UUID) error {
+	if session, ok := SessionFromContext(r.Context()); ok && IsSuperAdmin(session) {
+		return nil
+	}
+
+	instID, err := GetInstituteID(r)
+	if err != nil {
+		return err
+	}
+
+	if instituteID != instID {
+		logCrossTenant(r, instID, instituteID)
+		return ErrCrossTenant
+	}
+	return nil
+}
+
+func logCrossTenant(r *http.Request, tenant, requested uuid.UUID) {
+	userID := "unknown"
+	if session, ok := SessionFromContext(r.Context()); ok {
+		userID = session.UserID
+	}
+	logger.Warnf("cross-tenant access rejected: user=%s tenant=%s requested=%s path=%s",
+		userID, tenant, requested, r.URL.Path)
+}
+
+// TenantErrorStatus maps tenant resolution errors to an HTTP status code.
+func TenantErrorStatus(err error) int {
+	if errors.Is(err, ErrCrossTenant) || errors.Is(err, ErrNotPermitted) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
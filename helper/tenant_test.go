@@ -0,0 +1,114 @@
+package helper
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"swiftschool/domain"
+
+	"github.com/google/uuid"
+)
+
+func tenantRequest(session *SessionData, tenant uuid.UUID) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := r.Context()
+	if session != nil {
+		ctx = WithSession(ctx, session)
+	}
+	if tenant != uuid.Nil {
+		ctx = WithInstituteID(ctx, tenant)
+	}
+	return r.WithContext(ctx)
+}
+
+func TestBindInstituteID(t *testing.T) {
+	own, other := uuid.New(), uuid.New()
+	staff := &SessionData{UserID: "u1", Role: string(domain.RoleTeacher), InstituteIDs: []string{own.String()}}
+
+	tests := []struct {
+		name    string
+		tenant  uuid.UUID
+		claimed uuid.UUID
+		want    uuid.UUID
+		wantErr error
+	}{
+		{"no claim uses tenant", own, uuid.Nil, own, nil},
+		{"matching claim", own, own, own, nil},
+		{"other institute", own, other, uuid.Nil, ErrCrossTenant},
+		{"no tenant", uuid.Nil, own, uuid.Nil, ErrNoInstitute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BindInstituteID(tenantRequest(staff, tt.tenant), tt.claimed)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("BindInstituteID = %s, %v, want %s, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeInstitute(t *testing.T) {
+	own, other := uuid.New(), uuid.New()
+	staff := &SessionData{UserID: "u1", Role: string(domain.RoleAccountant), InstituteIDs: []string{own.String()}}
+	super := &SessionData{UserID: "u2", Role: string(domain.RoleSuperAdmin)}
+
+	tests := []struct {
+		name    string
+		session *SessionData
+		tenant  uuid.UUID
+		record  uuid.UUID
+		wantErr error
+	}{
+		{"own record", staff, own, own, nil},
+		{"record of another institute", staff, own, other, ErrCrossTenant},
+		{"no tenant", staff, uuid.Nil, own, ErrNoInstitute},
+		{"super admin on any institute", super, own, other, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthorizeInstitute(tenantRequest(tt.session, tt.tenant), tt.record)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AuthorizeInstitute error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSessionHasInstitute(t *testing.T) {
+	own, other := uuid.NewString(), uuid.NewString()
+	tests := []struct {
+		name    string
+		session *SessionData
+		inst    string
+		want    bool
+	}{
+		{"member", &SessionData{Role: string(domain.RoleAdmin), InstituteIDs: []string{own}}, own, true},
+		{"not a member", &SessionData{Role: string(domain.RoleAdmin), InstituteIDs: []string{own}}, other, false},
+		{"super admin", &SessionData{Role: string(domain.RoleSuperAdmin)}, other, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SessionHasInstitute(tt.session, tt.inst); got != tt.want {
+				t.Errorf("SessionHasInstitute = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{ErrCrossTenant, http.StatusForbidden},
+		{ErrNotPermitted, http.StatusForbidden},
+		{ErrNoInstitute, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if got := TenantErrorStatus(tt.err); got != tt.want {
+			t.Errorf("TenantErrorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// -------------------- TOTP (RFC 6238) -------------------- //
+
+const (
+	totpDigits = 6
+	totpPeriod = 30 * time.Second
+	// totpSkew accepts codes from one step either side of now for clock drift.
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrMFANotConfigured = errors.New("two-factor authentication is not configured")
+	ErrInvalidTOTP      = errors.New("invalid authentication code")
+)
+
+var totpBase32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new base32 encoded 160-bit secret.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpBase32.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI builds the otpauth:// URI rendered as a QR code by
+// authenticator apps.
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(totpDigits))
+	v.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// ValidateTOTP checks code against secret at now and returns the matched
+// time step. Callers must reject steps at or below the last accepted one
+// so a code cannot be replayed.
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpBase32.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(totpPeriod.Seconds())
+	for offset := -totpSkew; offset <= totpSkew; offset++ {
+		step := current + int64(offset)
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
+
+// -------------------- RECOVERY CODES -------------------- //
+
+// GenerateRecoveryCodes returns single-use backup codes formatted as
+// xxxxx-xxxxx. Only HashRecoveryCode output should be stored.
+func GenerateRecoveryCodes() ([]string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	codes := make([]string, recoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		for j, b := range buf {
+			buf[j] = alphabet[int(b)%len(alphabet)]
+		}
+		codes[i] = string(buf[:5]) + "-" + string(buf[5:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode normalises user input before hashing it.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	return HashToken(code)
+}
+
+// -------------------- SECRET ENCRYPTION -------------------- //
+
+var totpKey []byte
+
+func setTOTPKey(key string) {
+	if key == "" {
+		totpKey = nil
+		logger.Warnf("MFA encryption key not set, TOTP enrolment is disabled")
+		return
+	}
+	sum := sha256.Sum256([]byte(key))
+	totpKey = sum[:]
+}
+
+// SealTOTPSecret encrypts a TOTP secret with AES-GCM for storage.
+func SealTOTPSecret(secret string) (string, error) {
+	if totpKey == nil {
+		return "", ErrMFANotConfigured
+	}
+	return sealSecret(totpKey, secret)
+}
+
+// OpenTOTPSecret decrypts a value produced by SealTOTPSecret.
+func OpenTOTPSecret(sealed string) (string, error) {
+	if totpKey == nil {
+		return "", ErrMFANotConfigured
+	}
+	plain, err := openSecret(totpKey, sealed)
+	if err != nil {
+		return "", errors.New("failed to decrypt TOTP secret")
+	}
+	return plain, nil
+}
+
+// sealSecret encrypts a value with AES-GCM under a 32-byte key and returns
+// the nonce and ciphertext base64 encoded.
+func sealSecret(key []byte, plaintext string) (string, error) {
+	gcm, err := gcmCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openSecret decrypts a value produced by sealSecret.
+func openSecret(key []byte, sealed string) (string, error) {
+	gcm, err := gcmCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < gcm.NonceSize() {
+		return "", errors.New("malformed sealed secret")
+	}
+
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func gcmCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
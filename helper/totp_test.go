@@ -0,0 +1,58 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the RFC 6238 SHA-1 test key "12345678901234567890" in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	key := []byte("12345678901234567890")
+	// The RFC's 8-digit values truncated to the last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/30); got != tt.want {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		now      int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfc6238Secret, "287082", 59, 1, true},
+		{"previous step within skew", rfc6238Secret, "287082", 89, 1, true},
+		{"next step within skew", rfc6238Secret, "287082", 29, 1, true},
+		{"two steps late", rfc6238Secret, "287082", 119, 0, false},
+		{"two steps early", rfc6238Secret, "081804", 1111111109 - 60, 0, false},
+		{"lower case secret with spaces", " gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", "005924", 1234567890, 41152263, true},
+		{"wrong code", rfc6238Secret, "287083", 59, 0, false},
+		{"short code", rfc6238Secret, "28708", 59, 0, false},
+		{"invalid secret", "not base32!", "287082", 59, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.now, 0))
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Fatalf("ValidateTOTP = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
@@ -71,13 +71,19 @@ func IsPasswordStrong(password string) bool {
 	return hasUpperCase && hasLowerCase && hasDigit && hasSpecialChar
 }
 
+// GenerateRandomPassword returns a random password that always passes
+// IsPasswordStrong, so it can be hashed with HashPassword.
 func GenerateRandomPassword() string {
 	password := make([]byte, passwordLength)
-	for i := 0; i < passwordLength; i++ {
-		index, _ := rand.Int(rand.Reader, big.NewInt(int64(len(passwordChars))))
-		password[i] = passwordChars[index.Int64()]
+	for {
+		for i := 0; i < passwordLength; i++ {
+			index, _ := rand.Int(rand.Reader, big.NewInt(int64(len(passwordChars))))
+			password[i] = passwordChars[index.Int64()]
+		}
+		if IsPasswordStrong(string(password)) {
+			return string(password)
+		}
 	}
-	return string(password)
 }
 
 func IsValidGender(gender string) bool {
@@ -63,6 +63,21 @@ type AcademicsLessonPlan struct {
 	UpdatedBy      uuid.NullUUID
 }
 
+type AcademicsStudentAttendance struct {
+	ID             uuid.UUID
+	InstituteID    uuid.UUID
+	ClassID        uuid.UUID
+	StudentID      uuid.UUID
+	AttendanceDate time.Time
+	PeriodID       uuid.NullUUID
+	Status         string
+	Remarks        sql.NullString
+	CreatedAt      time.Time
+	UpdatedAt      sql.NullTime
+	CreatedBy      uuid.NullUUID
+	UpdatedBy      uuid.NullUUID
+}
+
 type AcademicsStudentSubject struct {
 	ID                uuid.UUID
 	InstituteID       uuid.UUID
@@ -184,6 +199,30 @@ type AlumniProfile struct {
 	DeletedAt           sql.NullTime
 }
 
+type AuthMfaChallenge struct {
+	TokenHash string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type AuthMfaRecoveryCode struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type AuthPasswordResetToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
 type AuthUser struct {
 	ID              uuid.UUID
 	Username        sql.NullString
@@ -204,6 +243,18 @@ type AuthUser struct {
 	UpdatedBy       uuid.NullUUID
 }
 
+type AuthUserCredential struct {
+	UserID            uuid.UUID
+	FailedAttempts    int32
+	LockedUntil       sql.NullTime
+	PasswordChangedAt sql.NullTime
+	TotpSecret        sql.NullString
+	TotpEnabled       bool
+	TotpEnabledAt     sql.NullTime
+	TotpLastStep      int64
+	UpdatedAt         time.Time
+}
+
 type CafeteriaDailyMenu struct {
 	ID          uuid.UUID
 	InstituteID uuid.UUID
@@ -328,6 +379,7 @@ type CoreApproval struct {
 	CreatedAt   sql.NullTime
 	UpdatedAt   sql.NullTime
 	DeletedAt   sql.NullTime
+	Step        int32
 }
 
 type CoreCalendarEvent struct {
@@ -799,21 +851,22 @@ type FinanceAccount struct {
 }
 
 type FinanceBankAccount struct {
-	ID             uuid.UUID
-	InstituteID    uuid.UUID
-	AccountName    sql.NullString
-	AccountNumber  sql.NullString
-	BankName       sql.NullString
-	IfscCode       sql.NullString
-	BranchName     sql.NullString
-	OpeningBalance sql.NullString
-	CurrentBalance sql.NullString
-	IsActive       sql.NullBool
-	CreatedAt      sql.NullTime
-	UpdatedAt      sql.NullTime
-	DeletedAt      sql.NullTime
-	CreatedBy      uuid.NullUUID
-	UpdatedBy      uuid.NullUUID
+	ID              uuid.UUID
+	InstituteID     uuid.UUID
+	AccountName     sql.NullString
+	AccountNumber   sql.NullString
+	BankName        sql.NullString
+	IfscCode        sql.NullString
+	BranchName      sql.NullString
+	OpeningBalance  sql.NullString
+	CurrentBalance  sql.NullString
+	IsActive        sql.NullBool
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+	DeletedAt       sql.NullTime
+	CreatedBy       uuid.NullUUID
+	UpdatedBy       uuid.NullUUID
+	LedgerAccountID uuid.NullUUID
 }
 
 type FinanceBankStatementEntry struct {
@@ -832,6 +885,28 @@ type FinanceBankStatementEntry struct {
 	CreatedAt               sql.NullTime
 	UpdatedAt               sql.NullTime
 	DeletedAt               sql.NullTime
+	ImportID                uuid.NullUUID
+	LineNo                  int32
+	Reference               sql.NullString
+	LineHash                sql.NullString
+	MatchMethod             sql.NullString
+	MatchRemarks            sql.NullString
+	MatchedAt               sql.NullTime
+	MatchedBy               uuid.NullUUID
+}
+
+type FinanceBankStatementImport struct {
+	ID             uuid.UUID
+	InstituteID    uuid.UUID
+	BankAccountID  uuid.UUID
+	Format         string
+	FileName       sql.NullString
+	LinesTotal     int32
+	LinesImported  int32
+	LinesDuplicate int32
+	LinesMatched   int32
+	CreatedAt      time.Time
+	CreatedBy      uuid.NullUUID
 }
 
 type FinanceBudget struct {
@@ -846,6 +921,22 @@ type FinanceBudget struct {
 	CreatedAt         sql.NullTime
 	UpdatedAt         sql.NullTime
 	DeletedAt         sql.NullTime
+	SoftLimitPercent  string
+	HardLimitPercent  sql.NullString
+	CreatedBy         uuid.NullUUID
+	UpdatedBy         uuid.NullUUID
+}
+
+type FinanceBudgetUtilisation struct {
+	ID          uuid.UUID
+	InstituteID uuid.UUID
+	BudgetID    uuid.UUID
+	Kind        string
+	SourceType  string
+	SourceID    uuid.UUID
+	Amount      string
+	OccurredOn  time.Time
+	CreatedAt   time.Time
 }
 
 type FinanceConcession struct {
@@ -860,6 +951,37 @@ type FinanceConcession struct {
 	DeletedAt   sql.NullTime
 	CreatedBy   uuid.NullUUID
 	UpdatedBy   uuid.NullUUID
+	FeeHeadIds  []uuid.UUID
+	SiblingRank sql.NullInt32
+}
+
+type FinanceDunningReminder struct {
+	ID            uuid.UUID
+	InstituteID   uuid.UUID
+	InvoiceID     uuid.UUID
+	StudentID     uuid.UUID
+	DunningStepID uuid.UUID
+	DaysOverdue   int32
+	AmountDue     string
+	Recipients    int32
+	Failures      int32
+	SentAt        time.Time
+}
+
+type FinanceDunningStep struct {
+	ID          uuid.UUID
+	InstituteID uuid.UUID
+	DaysOverdue int32
+	Subject     string
+	Message     string
+	SendEmail   bool
+	SendSms     bool
+	SendInApp   bool
+	IsActive    bool
+	CreatedBy   uuid.NullUUID
+	CreatedAt   time.Time
+	UpdatedBy   uuid.NullUUID
+	UpdatedAt   time.Time
 }
 
 type FinanceFeeHead struct {
@@ -874,6 +996,7 @@ type FinanceFeeHead struct {
 	DeletedAt         sql.NullTime
 	CreatedBy         uuid.NullUUID
 	UpdatedBy         uuid.NullUUID
+	TaxID             uuid.NullUUID
 }
 
 type FinanceFeeStructure struct {
@@ -893,18 +1016,57 @@ type FinanceFeeStructure struct {
 }
 
 type FinanceFineRule struct {
-	ID          uuid.UUID
-	InstituteID uuid.UUID
-	Name        sql.NullString
-	GraceDays   sql.NullInt32
-	FineType    sql.NullString
-	FineAmount  sql.NullString
-	IsActive    sql.NullBool
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
-	DeletedAt   sql.NullTime
-	CreatedBy   uuid.NullUUID
-	UpdatedBy   uuid.NullUUID
+	ID            uuid.UUID
+	InstituteID   uuid.UUID
+	Name          sql.NullString
+	GraceDays     sql.NullInt32
+	FineType      sql.NullString
+	FineAmount    sql.NullString
+	IsActive      sql.NullBool
+	CreatedAt     sql.NullTime
+	UpdatedAt     sql.NullTime
+	DeletedAt     sql.NullTime
+	CreatedBy     uuid.NullUUID
+	UpdatedBy     uuid.NullUUID
+	FeeHeadID     uuid.NullUUID
+	MaxFineAmount sql.NullString
+}
+
+type FinanceFiscalYearClosing struct {
+	ID                        uuid.UUID
+	InstituteID               uuid.UUID
+	FiscalYearStart           time.Time
+	FiscalYearEnd             time.Time
+	JournalEntryID            uuid.NullUUID
+	RetainedEarningsAccountID uuid.UUID
+	NetIncome                 string
+	ClosedAt                  time.Time
+	ClosedBy                  uuid.NullUUID
+}
+
+type FinanceGoodsReceipt struct {
+	ID              uuid.UUID
+	InstituteID     uuid.UUID
+	PurchaseOrderID uuid.UUID
+	LocationID      uuid.UUID
+	ReceivedOn      time.Time
+	ReferenceNo     sql.NullString
+	Remarks         sql.NullString
+	Amount          string
+	CreatedBy       uuid.NullUUID
+	CreatedAt       time.Time
+}
+
+type FinanceGoodsReceiptItem struct {
+	ID                  uuid.UUID
+	InstituteID         uuid.UUID
+	GoodsReceiptID      uuid.UUID
+	PurchaseOrderItemID uuid.UUID
+	ItemID              uuid.UUID
+	Quantity            int32
+	UnitPrice           string
+	TaxAmount           string
+	Amount              string
 }
 
 type FinanceInvoice struct {
@@ -925,6 +1087,31 @@ type FinanceInvoice struct {
 	DeletedAt         sql.NullTime
 	CreatedBy         uuid.NullUUID
 	UpdatedBy         uuid.NullUUID
+	BillingPeriod     sql.NullTime
+	RefundedAmount    string
+}
+
+type FinanceInvoiceFine struct {
+	ID          uuid.UUID
+	InstituteID uuid.UUID
+	InvoiceID   uuid.UUID
+	FineRuleID  uuid.UUID
+	AssessedOn  time.Time
+	DaysOverdue int32
+	Amount      string
+	CreatedAt   time.Time
+	CreatedBy   uuid.NullUUID
+}
+
+type FinanceInvoiceFineWaiver struct {
+	ID          uuid.UUID
+	InstituteID uuid.UUID
+	InvoiceID   uuid.UUID
+	FineRuleID  uuid.UUID
+	Amount      string
+	Reason      string
+	ApprovedBy  uuid.UUID
+	CreatedAt   time.Time
 }
 
 type FinanceInvoiceItem struct {
@@ -941,6 +1128,12 @@ type FinanceInvoiceItem struct {
 	UpdatedAt       sql.NullTime
 	DeletedAt       sql.NullTime
 	CreatedBy       uuid.NullUUID
+	FeeStructureID  uuid.NullUUID
+}
+
+type FinanceInvoiceSequence struct {
+	InstituteID uuid.UUID
+	LastNo      int64
 }
 
 type FinanceJournalEntry struct {
@@ -957,6 +1150,9 @@ type FinanceJournalEntry struct {
 	DeletedAt       sql.NullTime
 	CreatedBy       uuid.NullUUID
 	UpdatedBy       uuid.NullUUID
+	ReversalOfID    uuid.NullUUID
+	SourceType      sql.NullString
+	SourceID        uuid.NullUUID
 }
 
 type FinanceJournalItem struct {
@@ -972,6 +1168,68 @@ type FinanceJournalItem struct {
 	UpdatedAt      sql.NullTime
 	DeletedAt      sql.NullTime
 	CreatedBy      uuid.NullUUID
+	DepartmentID   uuid.NullUUID
+}
+
+type FinanceLedgerSetting struct {
+	InstituteID              uuid.UUID
+	ReceivablesAccountID     uuid.UUID
+	CashAccountID            uuid.UUID
+	BankAccountID            uuid.UUID
+	PayablesAccountID        uuid.UUID
+	PurchasesAccountID       uuid.UUID
+	ConcessionsAccountID     uuid.NullUUID
+	StudentAdvancesAccountID uuid.NullUUID
+	UpdatedAt                time.Time
+	UpdatedBy                uuid.NullUUID
+}
+
+type FinanceOnlinePayment struct {
+	ID               uuid.UUID
+	InstituteID      uuid.UUID
+	InvoiceID        uuid.UUID
+	StudentID        uuid.UUID
+	Gateway          string
+	OrderRef         string
+	GatewayOrderID   sql.NullString
+	GatewayPaymentID sql.NullString
+	Amount           string
+	Status           string
+	TransactionID    uuid.NullUUID
+	InitiatedBy      uuid.NullUUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type FinancePaymentGateway struct {
+	InstituteID       uuid.UUID
+	Gateway           string
+	KeyID             string
+	SecretSealed      string
+	MerchantID        sql.NullString
+	MerchantKeySealed sql.NullString
+	Environment       string
+	IsActive          bool
+	UpdatedAt         time.Time
+	UpdatedBy         uuid.NullUUID
+}
+
+type FinancePaymentPlan struct {
+	ID           uuid.UUID
+	InstituteID  uuid.UUID
+	StudentID    uuid.UUID
+	Amount       string
+	Installments int32
+	StartDate    time.Time
+	EndDate      time.Time
+	Reason       string
+	Status       string
+	Remarks      sql.NullString
+	DecidedBy    uuid.NullUUID
+	DecidedAt    sql.NullTime
+	CreatedBy    uuid.NullUUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 type FinancePurchaseItem struct {
@@ -989,51 +1247,115 @@ type FinancePurchaseItem struct {
 }
 
 type FinancePurchaseOrder struct {
-	ID          uuid.UUID
-	InstituteID uuid.UUID
-	VendorID    uuid.UUID
-	OrderDate   sql.NullTime
-	TotalAmount sql.NullString
-	Status      sql.NullString
-	ReferenceNo sql.NullString
-	IsActive    sql.NullBool
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
-	DeletedAt   sql.NullTime
-	CreatedBy   uuid.NullUUID
-	UpdatedBy   uuid.NullUUID
+	ID               uuid.UUID
+	InstituteID      uuid.UUID
+	VendorID         uuid.UUID
+	OrderDate        sql.NullTime
+	TotalAmount      sql.NullString
+	Status           sql.NullString
+	ReferenceNo      sql.NullString
+	IsActive         sql.NullBool
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	DeletedAt        sql.NullTime
+	CreatedBy        uuid.NullUUID
+	UpdatedBy        uuid.NullUUID
+	DepartmentID     uuid.NullUUID
+	ExpenseAccountID uuid.NullUUID
+	RequisitionID    uuid.NullUUID
 }
 
 type FinancePurchaseOrderItem struct {
-	ID              uuid.UUID
-	InstituteID     uuid.NullUUID
-	PurchaseOrderID uuid.NullUUID
-	ItemID          uuid.NullUUID
-	Quantity        sql.NullInt32
-	UnitPrice       sql.NullString
-	TaxID           uuid.NullUUID
-	TaxAmount       sql.NullString
-	TotalAmount     sql.NullString
-	IsActive        sql.NullBool
-	CreatedAt       sql.NullTime
-	UpdatedAt       sql.NullTime
-	DeletedAt       sql.NullTime
+	ID                uuid.UUID
+	InstituteID       uuid.NullUUID
+	PurchaseOrderID   uuid.NullUUID
+	ItemID            uuid.NullUUID
+	Quantity          sql.NullInt32
+	UnitPrice         sql.NullString
+	TaxID             uuid.NullUUID
+	TaxAmount         sql.NullString
+	TotalAmount       sql.NullString
+	IsActive          sql.NullBool
+	CreatedAt         sql.NullTime
+	UpdatedAt         sql.NullTime
+	DeletedAt         sql.NullTime
+	RequisitionItemID uuid.NullUUID
+	ReceivedQuantity  int32
+	BilledQuantity    int32
 }
 
-type FinanceRefund struct {
-	ID          uuid.UUID
+type FinanceReceipt struct {
+	ID               uuid.UUID
+	InstituteID      uuid.UUID
+	ReceiptNo        string
+	FiscalYear       int32
+	ReceiptDate      time.Time
+	StudentID        uuid.UUID
+	PaymentMode      string
+	Amount           string
+	TransactionRefNo sql.NullString
+	ChequeNo         sql.NullString
+	ChequeDate       sql.NullTime
+	BankName         sql.NullString
+	ChequeStatus     sql.NullString
+	ChequeSettledAt  sql.NullTime
+	BounceReason     sql.NullString
+	Remarks          sql.NullString
+	CollectedBy      uuid.NullUUID
+	CreatedAt        time.Time
+	CreatedBy        uuid.NullUUID
+}
+
+type FinanceReceiptSequence struct {
 	InstituteID uuid.UUID
-	StudentID   uuid.NullUUID
-	InvoiceID   uuid.NullUUID
-	Amount      string
-	Reason      sql.NullString
-	Status      sql.NullString
-	RefundDate  sql.NullTime
-	ProcessedBy uuid.NullUUID
-	IsActive    sql.NullBool
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
-	DeletedAt   sql.NullTime
+	FiscalYear  int32
+	LastNo      int64
+}
+
+type FinanceRefund struct {
+	ID                uuid.UUID
+	InstituteID       uuid.UUID
+	StudentID         uuid.NullUUID
+	InvoiceID         uuid.NullUUID
+	Amount            string
+	Reason            sql.NullString
+	Status            sql.NullString
+	RefundDate        sql.NullTime
+	ProcessedBy       uuid.NullUUID
+	IsActive          sql.NullBool
+	CreatedAt         sql.NullTime
+	UpdatedAt         sql.NullTime
+	DeletedAt         sql.NullTime
+	ApprovalsRequired int32
+	ApprovalsReceived int32
+	PayoutMode        sql.NullString
+	PayoutReference   sql.NullString
+	OnlinePaymentID   uuid.NullUUID
+	CreatedBy         uuid.NullUUID
+}
+
+type FinanceRefundSetting struct {
+	InstituteID     uuid.UUID
+	ApprovalSteps   int32
+	SecondStepAbove string
+	UpdatedAt       time.Time
+	UpdatedBy       uuid.NullUUID
+}
+
+type FinanceStudentConcession struct {
+	ID           uuid.UUID
+	InstituteID  uuid.UUID
+	StudentID    uuid.UUID
+	ConcessionID uuid.UUID
+	ValidFrom    time.Time
+	ValidTo      sql.NullTime
+	Status       string
+	Remarks      sql.NullString
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    sql.NullTime
+	CreatedBy    uuid.NullUUID
+	UpdatedBy    uuid.NullUUID
 }
 
 type FinanceStudentWallet struct {
@@ -1044,6 +1366,7 @@ type FinanceStudentWallet struct {
 	IsActive    sql.NullBool
 	UpdatedAt   sql.NullTime
 	DeletedAt   sql.NullTime
+	AutoApply   bool
 }
 
 type FinanceTax struct {
@@ -1057,6 +1380,39 @@ type FinanceTax struct {
 	DeletedAt   sql.NullTime
 }
 
+type FinanceTaxLine struct {
+	ID                uuid.UUID
+	InstituteID       uuid.UUID
+	Direction         string
+	DocumentType      string
+	DocumentID        uuid.UUID
+	ItemID            uuid.UUID
+	TaxID             uuid.UUID
+	Rate              string
+	Supply            string
+	TaxableAmount     string
+	Cgst              string
+	Sgst              string
+	Igst              string
+	CounterpartyGstin sql.NullString
+	TaxDate           sql.NullTime
+	CreatedAt         time.Time
+}
+
+type FinanceTaxSetting struct {
+	InstituteID         uuid.UUID
+	Gstin               string
+	StateID             uuid.UUID
+	OutputCgstAccountID uuid.UUID
+	OutputSgstAccountID uuid.UUID
+	OutputIgstAccountID uuid.UUID
+	InputCgstAccountID  uuid.UUID
+	InputSgstAccountID  uuid.UUID
+	InputIgstAccountID  uuid.UUID
+	UpdatedAt           time.Time
+	UpdatedBy           uuid.NullUUID
+}
+
 type FinanceTransaction struct {
 	ID               uuid.UUID
 	InstituteID      uuid.UUID
@@ -1079,6 +1435,7 @@ type FinanceTransaction struct {
 	DeletedAt        sql.NullTime
 	CreatedBy        uuid.NullUUID
 	UpdatedBy        uuid.NullUUID
+	ReceiptID        uuid.NullUUID
 }
 
 type FinanceVendor struct {
@@ -1096,6 +1453,71 @@ type FinanceVendor struct {
 	DeletedAt   sql.NullTime
 	CreatedBy   uuid.NullUUID
 	UpdatedBy   uuid.NullUUID
+	StateID     uuid.NullUUID
+}
+
+type FinanceVendorBill struct {
+	ID              uuid.UUID
+	InstituteID     uuid.UUID
+	VendorID        uuid.UUID
+	PurchaseOrderID uuid.UUID
+	BillNo          string
+	BillDate        time.Time
+	DueDate         time.Time
+	Amount          string
+	TaxAmount       string
+	PaidAmount      string
+	Status          string
+	MatchNotes      sql.NullString
+	DecidedBy       uuid.NullUUID
+	DecidedAt       sql.NullTime
+	Remarks         sql.NullString
+	CreatedBy       uuid.NullUUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+type FinanceVendorBillItem struct {
+	ID                  uuid.UUID
+	InstituteID         uuid.UUID
+	VendorBillID        uuid.UUID
+	PurchaseOrderItemID uuid.UUID
+	Quantity            int32
+	UnitPrice           string
+	TaxAmount           string
+	Amount              string
+}
+
+type FinanceVendorPayment struct {
+	ID           uuid.UUID
+	InstituteID  uuid.UUID
+	VendorBillID uuid.UUID
+	VendorID     uuid.UUID
+	Amount       string
+	PaymentMode  string
+	PaidOn       time.Time
+	ReferenceNo  sql.NullString
+	CreatedBy    uuid.NullUUID
+	CreatedAt    time.Time
+}
+
+type FinanceWalletEntry struct {
+	ID            uuid.UUID
+	InstituteID   uuid.UUID
+	WalletID      uuid.UUID
+	StudentID     uuid.UUID
+	EntryType     string
+	Purpose       string
+	Amount        string
+	BalanceAfter  string
+	PaymentMode   sql.NullString
+	ReferenceNo   sql.NullString
+	InvoiceID     uuid.NullUUID
+	TransactionID uuid.NullUUID
+	FeeHeadID     uuid.NullUUID
+	Remarks       sql.NullString
+	CreatedAt     time.Time
+	CreatedBy     uuid.NullUUID
 }
 
 type FleetDriverProfile struct {
@@ -1275,6 +1697,7 @@ type GeoState struct {
 	DeletedAt sql.NullTime
 	CreatedBy uuid.NullUUID
 	UpdatedBy uuid.NullUUID
+	GstCode   sql.NullString
 }
 
 type HealthInfirmaryVisit struct {
@@ -1452,6 +1875,7 @@ type InventoryItem struct {
 	DeletedAt    sql.NullTime
 	CreatedBy    uuid.NullUUID
 	UpdatedBy    uuid.NullUUID
+	TaxID        uuid.NullUUID
 }
 
 type InventoryItemCategory struct {
@@ -1490,19 +1914,24 @@ type InventoryRequisition struct {
 	DeletedAt    sql.NullTime
 	CreatedBy    uuid.NullUUID
 	UpdatedBy    uuid.NullUUID
+	Remarks      sql.NullString
+	DecidedBy    uuid.NullUUID
+	DecidedAt    sql.NullTime
 }
 
 type InventoryRequisitionItem struct {
-	ID            uuid.UUID
-	InstituteID   uuid.UUID
-	RequisitionID uuid.UUID
-	ItemName      sql.NullString
-	Quantity      sql.NullInt32
-	Remarks       sql.NullString
-	IsActive      sql.NullBool
-	CreatedAt     sql.NullTime
-	UpdatedAt     sql.NullTime
-	DeletedAt     sql.NullTime
+	ID              uuid.UUID
+	InstituteID     uuid.UUID
+	RequisitionID   uuid.UUID
+	ItemName        sql.NullString
+	Quantity        sql.NullInt32
+	Remarks         sql.NullString
+	IsActive        sql.NullBool
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+	DeletedAt       sql.NullTime
+	ItemID          uuid.NullUUID
+	OrderedQuantity int32
 }
 
 type InventoryStockLevel struct {
@@ -0,0 +1,102 @@
+package mapper
+
+import (
+	"strings"
+
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+)
+
+// ------------------ ASSIGNMENT ------------------
+
+func MapDBAssignmentToDomain(a db.AcademicsAssignment) domain.Assignment {
+	return domain.Assignment{
+		TenantUUIDModel: domain.TenantUUIDModel{
+			InstituteID: a.InstituteID,
+			BaseUUIDModel: domain.BaseUUIDModel{
+				ID:        a.ID,
+				CreatedAt: helper.NullTimeToValue(a.CreatedAt),
+				UpdatedAt: helper.NullTimeToValue(a.UpdatedAt),
+				CreatedBy: helper.NullUUIDToPtr(a.CreatedBy),
+				UpdatedBy: helper.NullUUIDToPtr(a.UpdatedBy),
+			},
+		},
+		ClassID:     a.ClassID,
+		SubjectID:   a.SubjectID,
+		TeacherID:   a.TeacherID,
+		Title:       helper.NullStringToPtr(a.Title),
+		Description: helper.NullStringToPtr(a.Description),
+		DueDate:     helper.NullTimeToPtr(a.DueDate),
+		MaxMarks:    helper.NullNumericToPtr(a.MaxMarks),
+	}
+}
+
+// ------------------ TIMETABLE ------------------
+
+func MapTimetableSlotRowToDomain(row db.ListGuardianChildTimetableRow) domain.TimetableSlot {
+	slot := domain.TimetableSlot{
+		EntryID:     row.ID,
+		DayOfWeek:   domain.DayOfWeek(helper.NullStringToValue(row.DayOfWeek)),
+		PeriodID:    helper.NullUUIDToPtr(row.PeriodID),
+		PeriodName:  helper.NullStringToPtr(row.PeriodName),
+		StartTime:   helper.NullTimeToPtr(row.StartTime),
+		EndTime:     helper.NullTimeToPtr(row.EndTime),
+		SubjectID:   helper.NullUUIDToPtr(row.SubjectID),
+		SubjectName: helper.NullStringToPtr(row.SubjectName),
+		TeacherID:   helper.NullUUIDToPtr(row.TeacherID),
+	}
+
+	if name := strings.TrimSpace(row.TeacherFirstName.String + " " + row.TeacherLastName.String); name != "" {
+		slot.TeacherName = &name
+	}
+	return slot
+}
+
+// ------------------ STUDENT ATTENDANCE ------------------
+
+func MapDBStudentAttendanceToDomain(a db.AcademicsStudentAttendance) domain.StudentAttendance {
+	return domain.StudentAttendance{
+		TenantUUIDModel: domain.TenantUUIDModel{
+			InstituteID: a.InstituteID,
+			BaseUUIDModel: domain.BaseUUIDModel{
+				ID:        a.ID,
+				CreatedAt: a.CreatedAt,
+				UpdatedAt: helper.NullTimeToValue(a.UpdatedAt),
+				CreatedBy: helper.NullUUIDToPtr(a.CreatedBy),
+				UpdatedBy: helper.NullUUIDToPtr(a.UpdatedBy),
+			},
+		},
+		ClassID:        a.ClassID,
+		StudentID:      a.StudentID,
+		AttendanceDate: a.AttendanceDate,
+		PeriodID:       helper.NullUUIDToPtr(a.PeriodID),
+		Status:         domain.AttendanceStatus(a.Status),
+		Remarks:        helper.NullStringToPtr(a.Remarks),
+	}
+}
+
+func MapDomainAttendanceToDailyParams(a domain.StudentAttendance) db.UpsertDailyAttendanceParams {
+	return db.UpsertDailyAttendanceParams{
+		InstituteID:    a.InstituteID,
+		ClassID:        a.ClassID,
+		StudentID:      a.StudentID,
+		AttendanceDate: a.AttendanceDate,
+		Status:         string(a.Status),
+		Remarks:        helper.ToNullString(helper.StrOrEmpty(a.Remarks)),
+		CreatedBy:      helper.ToNullUUID(helper.DerefUUID(a.CreatedBy)),
+	}
+}
+
+func MapDomainAttendanceToPeriodParams(a domain.StudentAttendance) db.UpsertPeriodAttendanceParams {
+	return db.UpsertPeriodAttendanceParams{
+		InstituteID:    a.InstituteID,
+		ClassID:        a.ClassID,
+		StudentID:      a.StudentID,
+		AttendanceDate: a.AttendanceDate,
+		PeriodID:       helper.ToNullUUID(helper.DerefUUID(a.PeriodID)),
+		Status:         string(a.Status),
+		Remarks:        helper.ToNullString(helper.StrOrEmpty(a.Remarks)),
+		CreatedBy:      helper.ToNullUUID(helper.DerefUUID(a.CreatedBy)),
+	}
+}
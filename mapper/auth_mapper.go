@@ -0,0 +1,71 @@
+package mapper
+
+import (
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+)
+
+// ------------------ USER CREDENTIALS ------------------
+
+func MapDBUserCredentialsToDomain(c db.AuthUserCredential) *domain.UserCredentials {
+	return &domain.UserCredentials{
+		UserID:            c.UserID,
+		FailedAttempts:    int(c.FailedAttempts),
+		LockedUntil:       helper.NullTimeToPtr(c.LockedUntil),
+		PasswordChangedAt: helper.NullTimeToPtr(c.PasswordChangedAt),
+		TOTPSecret:        helper.NullStringToValue(c.TotpSecret),
+		TOTPEnabled:       c.TotpEnabled,
+		TOTPEnabledAt:     helper.NullTimeToPtr(c.TotpEnabledAt),
+		TOTPLastStep:      c.TotpLastStep,
+	}
+}
+
+// ------------------ USER ------------------
+
+func MapDBUserToDomain(u db.AuthUser) *domain.User {
+	return &domain.User{
+		BaseUUIDModel: domain.BaseUUIDModel{
+			ID:        u.ID,
+			CreatedAt: helper.NullTimeToValue(u.CreatedAt),
+			UpdatedAt: helper.NullTimeToValue(u.UpdatedAt),
+			CreatedBy: helper.NullUUIDToPtr(u.CreatedBy),
+			UpdatedBy: helper.NullUUIDToPtr(u.UpdatedBy),
+		},
+		Username:       helper.NullStringToValue(u.Username),
+		Email:          helper.NullStringToPtr(u.Email),
+		PhoneNumber:    helper.NullStringToPtr(u.PhoneNumber),
+		PasswordHash:   u.PasswordHash,
+		RoleType:       domain.UserRole(helper.NullStringToValue(u.RoleType)),
+		LinkedEntityID: u.LinkedEntityID,
+		InstituteID:    helper.NullUUIDToPtr(u.InstituteID),
+		IsActive:       helper.NullBoolToValue(u.IsActive),
+	}
+}
+
+// MapDomainUserToDBParams fills the lookup hashes; a user without an email
+// is indexed by username so email_hash stays unique and non-empty.
+func MapDomainUserToDBParams(u domain.User) db.CreateUserParams {
+	email := helper.StrOrEmpty(u.Email)
+	phone := helper.StrOrEmpty(u.PhoneNumber)
+
+	emailHash := helper.LookupHash(email)
+	if emailHash == "" {
+		emailHash = helper.LookupHash(u.Username)
+	}
+
+	return db.CreateUserParams{
+		Username:        helper.ToNullString(u.Username),
+		Email:           helper.ToNullString(email),
+		PhoneNumber:     helper.ToNullString(phone),
+		UsernameHash:    helper.LookupHash(u.Username),
+		EmailHash:       emailHash,
+		PhoneNumberHash: helper.ToNullString(helper.LookupHash(phone)),
+		PasswordHash:    u.PasswordHash,
+		RoleType:        helper.ToNullString(string(u.RoleType)),
+		LinkedEntityID:  u.LinkedEntityID,
+		InstituteID:     helper.ToNullUUID(helper.DerefUUID(u.InstituteID)),
+		IsActive:        helper.ToNullBool(u.IsActive),
+		CreatedBy:       helper.ToNullUUID(helper.DerefUUID(u.CreatedBy)),
+	}
+}
@@ -0,0 +1,28 @@
+package mapper
+
+import (
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+)
+
+// ------------------ NOTIFICATION ------------------
+
+func MapDBNotificationToDomain(n db.CommsNotification) domain.Notification {
+	return domain.Notification{
+		TenantUUIDModel: domain.TenantUUIDModel{
+			InstituteID: n.InstituteID,
+			BaseUUIDModel: domain.BaseUUIDModel{
+				ID:        n.ID,
+				CreatedAt: helper.NullTimeToValue(n.CreatedAt),
+				UpdatedAt: helper.NullTimeToValue(n.UpdatedAt),
+				CreatedBy: helper.NullUUIDToPtr(n.CreatedBy),
+				UpdatedBy: helper.NullUUIDToPtr(n.UpdatedBy),
+			},
+		},
+		UserID:  helper.NullUUIDToPtr(n.UserID),
+		Title:   helper.NullStringToPtr(n.Title),
+		Message: helper.NullStringToPtr(n.Message),
+		IsRead:  helper.NullBoolToValue(n.IsRead),
+	}
+}
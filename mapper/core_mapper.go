@@ -380,3 +380,27 @@ func MapDomainAddressToDBParams(a domain.Address) db.CreateAddressParams {
 		PostalCode: helper.ToNullString(helper.StrOrEmpty(a.PostalCode)),
 	}
 }
+
+// ------------------ CALENDAR EVENT ------------------
+
+func MapDBCalendarEventToDomain(e db.CoreCalendarEvent) domain.CalendarEvent {
+	return domain.CalendarEvent{
+		TenantUUIDModel: domain.TenantUUIDModel{
+			InstituteID: e.InstituteID,
+			BaseUUIDModel: domain.BaseUUIDModel{
+				ID:        e.ID,
+				CreatedAt: helper.NullTimeToValue(e.CreatedAt),
+				UpdatedAt: helper.NullTimeToValue(e.UpdatedAt),
+				CreatedBy: helper.NullUUIDToPtr(e.CreatedBy),
+				UpdatedBy: helper.NullUUIDToPtr(e.UpdatedBy),
+			},
+		},
+		Title:          e.Title,
+		Description:    helper.NullStringToPtr(e.Description),
+		StartDate:      e.StartDate,
+		EndDate:        e.EndDate,
+		EventType:      helper.NullStringToPtr(e.EventType),
+		IsHoliday:      helper.NullBoolToValue(e.IsHoliday),
+		TargetAudience: helper.NullStringToPtr(e.TargetAudience),
+	}
+}
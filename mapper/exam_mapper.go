@@ -0,0 +1,26 @@
+package mapper
+
+import (
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+)
+
+// ------------------ EXAM RESULT ------------------
+
+func MapExamResultRowToDomain(row db.ListGuardianChildExamResultsRow) domain.ExamResult {
+	return domain.ExamResult{
+		MarkID:        row.ID,
+		ExamID:        row.ExamID,
+		ExamName:      row.ExamName,
+		ScheduleID:    row.ScheduleID,
+		SubjectID:     row.SubjectID,
+		SubjectName:   row.SubjectName,
+		ExamDate:      row.ExamDate,
+		MaxMarks:      helper.NullNumericToValue(row.MaxMarks),
+		MinPassMarks:  helper.NullNumericToValue(row.MinPassMarks),
+		MarksObtained: helper.NullNumericToPtr(row.MarksObtained),
+		IsAbsent:      helper.NullBoolToValue(row.IsAbsent),
+		Remarks:       helper.NullStringToPtr(row.Remarks),
+	}
+}
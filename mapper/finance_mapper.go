@@ -3,11 +3,10 @@ package mapper
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"swiftschool/domain"
 	"swiftschool/helper"
 	"swiftschool/internal/db"
-
-	"github.com/google/uuid"
 )
 
 // =========================================================
@@ -39,6 +38,7 @@ func MapFeeHeadRowToDomain(row db.FinanceFeeHead) domain.FeeHead {
 		Name:              row.Name,
 		IsRefundable:      row.IsRefundable.Bool,
 		LinkedGLAccountID: helper.NullUUIDToValue(row.LinkedGlAccountID),
+		TaxID:             helper.NullUUIDToPtr(row.TaxID),
 	}
 }
 
@@ -111,11 +111,133 @@ func MapFineRuleRowToDomain(row db.FinanceFineRule) domain.FineRule {
 			},
 			InstituteID: row.InstituteID,
 		},
-		Name:       row.Name.String,
-		GraceDays:  int(row.GraceDays.Int32),
-		FineType:   domain.FineType(row.FineType.String),
-		FineAmount: amount,
-		IsActive:   row.IsActive.Bool,
+		Name:          row.Name.String,
+		GraceDays:     int(row.GraceDays.Int32),
+		FineType:      domain.FineType(row.FineType.String),
+		FineAmount:    amount,
+		IsActive:      row.IsActive.Bool,
+		FeeHeadID:     row.FeeHeadID.UUID,
+		MaxFineAmount: helper.NullNumericToPtr(row.MaxFineAmount),
+	}
+}
+
+func MapInvoiceFineRowToDomain(row db.FinanceInvoiceFine) domain.InvoiceFine {
+	var amount float64
+	fmt.Sscanf(row.Amount, "%f", &amount)
+
+	return domain.InvoiceFine{
+		ID:          row.ID,
+		InstituteID: row.InstituteID,
+		InvoiceID:   row.InvoiceID,
+		FineRuleID:  row.FineRuleID,
+		AssessedOn:  row.AssessedOn,
+		DaysOverdue: int(row.DaysOverdue),
+		Amount:      amount,
+		CreatedAt:   row.CreatedAt,
+		CreatedBy:   helper.NullUUIDToPtr(row.CreatedBy),
+	}
+}
+
+func MapFineWaiverDomainToParams(w domain.FineWaiver) db.CreateInvoiceFineWaiverParams {
+	return db.CreateInvoiceFineWaiverParams{
+		InstituteID: w.InstituteID,
+		InvoiceID:   w.InvoiceID,
+		FineRuleID:  w.FineRuleID,
+		Amount:      fmt.Sprintf("%.2f", w.Amount),
+		Reason:      w.Reason,
+		ApprovedBy:  helper.DerefUUID(w.ApprovedBy),
+	}
+}
+
+func MapFineWaiverRowToDomain(row db.FinanceInvoiceFineWaiver) domain.FineWaiver {
+	var amount float64
+	fmt.Sscanf(row.Amount, "%f", &amount)
+
+	return domain.FineWaiver{
+		ID:          row.ID,
+		InstituteID: row.InstituteID,
+		InvoiceID:   row.InvoiceID,
+		FineRuleID:  row.FineRuleID,
+		Amount:      amount,
+		Reason:      row.Reason,
+		ApprovedBy:  helper.UUIDPtr(row.ApprovedBy),
+		CreatedAt:   row.CreatedAt,
+	}
+}
+
+// =========================================================
+// CONCESSION MAPPERS
+// =========================================================
+
+func MapConcessionDomainToParams(c domain.Concession) db.CreateConcessionParams {
+	params := db.CreateConcessionParams{
+		InstituteID: c.InstituteID,
+		Name:        helper.ToNullString(helper.StrOrEmpty(c.Name)),
+		Type:        helper.ToNullString(string(c.Type)),
+		FeeHeadIds:  c.FeeHeadIDs,
+		CreatedBy:   helper.ToNullUUID(helper.DerefUUID(c.CreatedBy)),
+	}
+	if c.Value != nil {
+		params.Value = helper.ToNullString(fmt.Sprintf("%.2f", *c.Value))
+	}
+	if c.SiblingRank != nil {
+		params.SiblingRank = helper.ToNullInt32(*c.SiblingRank)
+	}
+	return params
+}
+
+func MapConcessionRowToDomain(row db.FinanceConcession) domain.Concession {
+	c := domain.Concession{
+		TenantUUIDModel: domain.TenantUUIDModel{
+			BaseUUIDModel: domain.BaseUUIDModel{
+				ID:        row.ID,
+				CreatedAt: helper.NullTimeToValue(row.CreatedAt),
+				UpdatedAt: helper.NullTimeToValue(row.UpdatedAt),
+				CreatedBy: helper.NullUUIDToPtr(row.CreatedBy),
+			},
+			InstituteID: row.InstituteID,
+		},
+		Name:       helper.NullStringToPtr(row.Name),
+		Type:       domain.ConcessionType(row.Type.String),
+		Value:      helper.NullNumericToPtr(row.Value),
+		FeeHeadIDs: row.FeeHeadIds,
+	}
+	if row.SiblingRank.Valid {
+		c.SiblingRank = &row.SiblingRank.Int32
+	}
+	return c
+}
+
+func MapStudentConcessionDomainToParams(sc domain.StudentConcession) db.CreateStudentConcessionParams {
+	return db.CreateStudentConcessionParams{
+		InstituteID:  sc.InstituteID,
+		StudentID:    sc.StudentID,
+		ConcessionID: sc.ConcessionID,
+		ValidFrom:    sc.ValidFrom,
+		ValidTo:      helper.ToNullTime(helper.TimeOrZero(sc.ValidTo)),
+		Remarks:      helper.ToNullString(helper.StrOrEmpty(sc.Remarks)),
+		CreatedBy:    helper.ToNullUUID(helper.DerefUUID(sc.CreatedBy)),
+	}
+}
+
+func MapStudentConcessionRowToDomain(row db.FinanceStudentConcession) domain.StudentConcession {
+	return domain.StudentConcession{
+		TenantUUIDModel: domain.TenantUUIDModel{
+			BaseUUIDModel: domain.BaseUUIDModel{
+				ID:        row.ID,
+				CreatedAt: row.CreatedAt,
+				UpdatedAt: row.UpdatedAt,
+				CreatedBy: helper.NullUUIDToPtr(row.CreatedBy),
+				UpdatedBy: helper.NullUUIDToPtr(row.UpdatedBy),
+			},
+			InstituteID: row.InstituteID,
+		},
+		StudentID:    row.StudentID,
+		ConcessionID: row.ConcessionID,
+		ValidFrom:    row.ValidFrom,
+		ValidTo:      helper.NullTimeToPtr(row.ValidTo),
+		Status:       domain.ApprovalStatus(row.Status),
+		Remarks:      helper.NullStringToPtr(row.Remarks),
 	}
 }
 
@@ -128,19 +250,23 @@ func MapInvoiceDomainToParams(inv domain.Invoice) db.CreateInvoiceParams {
 		InstituteID:       inv.InstituteID,
 		InvoiceNo:         inv.InvoiceNo,
 		StudentID:         inv.StudentID,
-		AcademicSessionID: helper.ToNullUUID(uuid.Nil), // Domain doesn't have this field
+		AcademicSessionID: helper.ToNullUUID(helper.DerefUUID(inv.AcademicSessionID)),
 		TotalAmount:       fmt.Sprintf("%.2f", inv.TotalAmount),
+		DiscountAmount:    helper.ToNullString(fmt.Sprintf("%.2f", inv.DiscountAmount)),
 		DueDate:           helper.ToNullTime(helper.TimeOrZero(inv.DueDate)),
+		Status:            helper.ToNullString(inv.Status),
 		CreatedBy:         helper.ToNullUUID(helper.DerefUUID(inv.CreatedBy)),
+		BillingPeriod:     helper.ToNullTime(helper.TimeOrZero(inv.BillingPeriod)),
 	}
 }
 
 func MapInvoiceRowToDomain(row db.FinanceInvoice) domain.Invoice {
-	var totalAmount, paidAmount float64
+	var totalAmount, paidAmount, refundedAmount float64
 	fmt.Sscanf(row.TotalAmount, "%f", &totalAmount)
 	if row.PaidAmount.Valid {
 		fmt.Sscanf(row.PaidAmount.String, "%f", &paidAmount)
 	}
+	fmt.Sscanf(row.RefundedAmount, "%f", &refundedAmount)
 
 	return domain.Invoice{
 		TenantUUIDModel: domain.TenantUUIDModel{
@@ -152,14 +278,17 @@ func MapInvoiceRowToDomain(row db.FinanceInvoice) domain.Invoice {
 			},
 			InstituteID: row.InstituteID,
 		},
-		InvoiceNo:      row.InvoiceNo,
-		StudentID:      row.StudentID,
-		TotalAmount:    totalAmount,
-		DiscountAmount: 0, // Not in SQLC row
-		FineAmount:     0, // Not in SQLC row
-		PaidAmount:     paidAmount,
-		Status:         row.Status.String,
-		DueDate:        helper.NullTimeToPtr(row.DueDate),
+		InvoiceNo:         row.InvoiceNo,
+		StudentID:         row.StudentID,
+		AcademicSessionID: helper.NullUUIDToPtr(row.AcademicSessionID),
+		TotalAmount:       totalAmount,
+		DiscountAmount:    helper.NullNumericToValue(row.DiscountAmount),
+		FineAmount:        helper.NullNumericToValue(row.FineAmount),
+		PaidAmount:        paidAmount,
+		RefundedAmount:    refundedAmount,
+		Status:            row.Status.String,
+		DueDate:           helper.NullTimeToPtr(row.DueDate),
+		BillingPeriod:     helper.NullTimeToPtr(row.BillingPeriod),
 	}
 }
 
@@ -177,6 +306,7 @@ func MapInvoiceItemDomainToParams(item domain.InvoiceItem) db.CreateInvoiceItemP
 		DiscountApplied: helper.ToNullString(fmt.Sprintf("%.2f", item.DiscountApplied)),
 		Description:     helper.ToNullString(helper.StrOrEmpty(item.Description)),
 		CreatedBy:       helper.ToNullUUID(helper.DerefUUID(item.CreatedBy)),
+		FeeStructureID:  helper.ToNullUUID(helper.DerefUUID(item.FeeStructureID)),
 	}
 }
 
@@ -202,6 +332,7 @@ func MapInvoiceItemRowToDomain(row db.FinanceInvoiceItem) domain.InvoiceItem {
 		ConcessionID:    helper.NullUUIDToPtr(row.ConcessionID),
 		DiscountApplied: discount,
 		Description:     helper.NullStringToPtr(row.Description),
+		FeeStructureID:  helper.NullUUIDToPtr(row.FeeStructureID),
 	}
 }
 
@@ -220,6 +351,7 @@ func MapTransactionDomainToParams(txn domain.Transaction) db.CreateTransactionPa
 		ChequeNo:         helper.ToNullString(helper.StrOrEmpty(txn.ChequeNo)),
 		ChequeDate:       helper.ToNullTime(helper.TimeOrZero(txn.ChequeDate)),
 		BankName:         helper.ToNullString(helper.StrOrEmpty(txn.BankName)),
+		IsWalletUsage:    sql.NullBool{Bool: txn.IsWalletUsage, Valid: true},
 		CollectedBy:      helper.ToNullUUID(helper.DerefUUID(txn.CollectedBy)),
 		CreatedBy:        helper.ToNullUUID(helper.DerefUUID(txn.CreatedBy)),
 	}
@@ -246,11 +378,139 @@ func MapTransactionRowToDomain(row db.FinanceTransaction) domain.Transaction {
 		ChequeNo:         helper.NullStringToPtr(row.ChequeNo),
 		ChequeDate:       helper.NullTimeToPtr(row.ChequeDate),
 		BankName:         helper.NullStringToPtr(row.BankName),
+		ChequeStatus:     helper.NullStringToPtr(row.ChequeStatus),
+		IsWalletUsage:    row.IsWalletUsage.Bool,
+		PaymentDate:      helper.NullTimeToPtr(row.PaymentDate),
 		Status:           row.Status.String,
 		CollectedBy:      helper.NullUUIDToPtr(row.CollectedBy),
+		ReceiptID:        helper.NullUUIDToPtr(row.ReceiptID),
 	}
 }
 
+func MapReceiptDomainToParams(rc domain.Receipt) db.CreateReceiptParams {
+	params := db.CreateReceiptParams{
+		InstituteID:      rc.InstituteID,
+		ReceiptNo:        rc.ReceiptNo,
+		FiscalYear:       int32(rc.FiscalYear),
+		ReceiptDate:      rc.ReceiptDate,
+		StudentID:        rc.StudentID,
+		PaymentMode:      string(rc.PaymentMode),
+		Amount:           fmt.Sprintf("%.2f", rc.Amount),
+		TransactionRefNo: helper.ToNullString(helper.StrOrEmpty(rc.TransactionRefNo)),
+		ChequeNo:         helper.ToNullString(helper.StrOrEmpty(rc.ChequeNo)),
+		ChequeDate:       helper.ToNullTime(helper.TimeOrZero(rc.ChequeDate)),
+		BankName:         helper.ToNullString(helper.StrOrEmpty(rc.BankName)),
+		Remarks:          helper.ToNullString(helper.StrOrEmpty(rc.Remarks)),
+		CollectedBy:      helper.ToNullUUID(helper.DerefUUID(rc.CollectedBy)),
+		CreatedBy:        helper.ToNullUUID(helper.DerefUUID(rc.CreatedBy)),
+	}
+	if rc.ChequeStatus != nil {
+		params.ChequeStatus = helper.ToNullString(string(*rc.ChequeStatus))
+	}
+	return params
+}
+
+func MapReceiptRowToDomain(row db.FinanceReceipt) domain.Receipt {
+	var amount float64
+	fmt.Sscanf(row.Amount, "%f", &amount)
+
+	rc := domain.Receipt{
+		ID:               row.ID,
+		InstituteID:      row.InstituteID,
+		ReceiptNo:        row.ReceiptNo,
+		FiscalYear:       int(row.FiscalYear),
+		ReceiptDate:      row.ReceiptDate,
+		StudentID:        row.StudentID,
+		PaymentMode:      domain.PaymentMode(row.PaymentMode),
+		Amount:           amount,
+		TransactionRefNo: helper.NullStringToPtr(row.TransactionRefNo),
+		ChequeNo:         helper.NullStringToPtr(row.ChequeNo),
+		ChequeDate:       helper.NullTimeToPtr(row.ChequeDate),
+		BankName:         helper.NullStringToPtr(row.BankName),
+		ChequeSettledAt:  helper.NullTimeToPtr(row.ChequeSettledAt),
+		BounceReason:     helper.NullStringToPtr(row.BounceReason),
+		Remarks:          helper.NullStringToPtr(row.Remarks),
+		CollectedBy:      helper.NullUUIDToPtr(row.CollectedBy),
+		CreatedAt:        row.CreatedAt,
+		CreatedBy:        helper.NullUUIDToPtr(row.CreatedBy),
+		Allocations:      []domain.ReceiptAllocation{},
+	}
+	if row.ChequeStatus.Valid {
+		status := domain.ChequeStatus(row.ChequeStatus.String)
+		rc.ChequeStatus = &status
+	}
+	return rc
+}
+
+// =========================================================
+// STUDENT WALLET MAPPERS
+// =========================================================
+
+func MapStudentWalletRowToDomain(row db.FinanceStudentWallet) domain.StudentWallet {
+	var balance float64
+	fmt.Sscanf(row.Balance.String, "%f", &balance)
+
+	return domain.StudentWallet{
+		ID:          row.ID,
+		InstituteID: row.InstituteID,
+		StudentID:   row.StudentID,
+		Balance:     balance,
+		AutoApply:   row.AutoApply,
+		UpdatedAt:   helper.NullTimeToValue(row.UpdatedAt),
+	}
+}
+
+func MapWalletEntryDomainToParams(e domain.WalletEntry) db.CreateWalletEntryParams {
+	params := db.CreateWalletEntryParams{
+		InstituteID:   e.InstituteID,
+		WalletID:      e.WalletID,
+		StudentID:     e.StudentID,
+		EntryType:     string(e.EntryType),
+		Purpose:       string(e.Purpose),
+		Amount:        fmt.Sprintf("%.2f", e.Amount),
+		BalanceAfter:  fmt.Sprintf("%.2f", e.BalanceAfter),
+		ReferenceNo:   helper.ToNullString(helper.StrOrEmpty(e.ReferenceNo)),
+		InvoiceID:     helper.ToNullUUID(helper.DerefUUID(e.InvoiceID)),
+		TransactionID: helper.ToNullUUID(helper.DerefUUID(e.TransactionID)),
+		FeeHeadID:     helper.ToNullUUID(helper.DerefUUID(e.FeeHeadID)),
+		Remarks:       helper.ToNullString(helper.StrOrEmpty(e.Remarks)),
+		CreatedBy:     helper.ToNullUUID(helper.DerefUUID(e.CreatedBy)),
+	}
+	if e.PaymentMode != nil {
+		params.PaymentMode = helper.ToNullString(string(*e.PaymentMode))
+	}
+	return params
+}
+
+func MapWalletEntryRowToDomain(row db.FinanceWalletEntry) domain.WalletEntry {
+	var amount, balanceAfter float64
+	fmt.Sscanf(row.Amount, "%f", &amount)
+	fmt.Sscanf(row.BalanceAfter, "%f", &balanceAfter)
+
+	e := domain.WalletEntry{
+		ID:            row.ID,
+		InstituteID:   row.InstituteID,
+		WalletID:      row.WalletID,
+		StudentID:     row.StudentID,
+		EntryType:     domain.WalletEntryType(row.EntryType),
+		Purpose:       domain.WalletPurpose(row.Purpose),
+		Amount:        amount,
+		BalanceAfter:  balanceAfter,
+		ReferenceNo:   helper.NullStringToPtr(row.ReferenceNo),
+		InvoiceID:     helper.NullUUIDToPtr(row.InvoiceID),
+		TransactionID: helper.NullUUIDToPtr(row.TransactionID),
+		FeeHeadID:     helper.NullUUIDToPtr(row.FeeHeadID),
+		Remarks:       helper.NullStringToPtr(row.Remarks),
+		CreatedAt:     row.CreatedAt,
+		CreatedBy:     helper.NullUUIDToPtr(row.CreatedBy),
+	}
+	if row.PaymentMode.Valid {
+		mode := domain.PaymentMode(row.PaymentMode.String)
+		e.PaymentMode = &mode
+	}
+	return e
+}
+
 // =========================================================
 // ACCOUNT (GL) MAPPERS
 // =========================================================
@@ -282,6 +542,7 @@ func MapAccountRowToDomain(row db.FinanceAccount) domain.Account {
 		ParentAccountID: helper.NullUUIDToPtr(row.ParentAccountID),
 		Type:            domain.AccountType(row.Type),
 		IsSystem:        row.IsSystem.Bool,
+		IsActive:        row.IsActive.Bool,
 	}
 }
 
@@ -297,10 +558,19 @@ func MapJournalEntryDomainToParams(je domain.JournalEntry) db.CreateJournalEntry
 		Description:     helper.ToNullString(helper.StrOrEmpty(je.Description)),
 		IsPosted:        sql.NullBool{Bool: je.IsPosted, Valid: true},
 		CreatedBy:       helper.ToNullUUID(helper.DerefUUID(je.CreatedBy)),
+		ReversalOfID:    helper.ToNullUUID(helper.DerefUUID(je.ReversalOfID)),
+		SourceType:      helper.ToNullString(string(journalSourceOrEmpty(je.SourceType))),
+		SourceID:        helper.ToNullUUID(helper.DerefUUID(je.SourceID)),
 	}
 }
 
 func MapJournalEntryRowToDomain(row db.FinanceJournalEntry) domain.JournalEntry {
+	var source *domain.JournalSource
+	if row.SourceType.Valid {
+		st := domain.JournalSource(row.SourceType.String)
+		source = &st
+	}
+
 	return domain.JournalEntry{
 		TenantUUIDModel: domain.TenantUUIDModel{
 			BaseUUIDModel: domain.BaseUUIDModel{
@@ -315,9 +585,19 @@ func MapJournalEntryRowToDomain(row db.FinanceJournalEntry) domain.JournalEntry
 		Description:     helper.NullStringToPtr(row.Description),
 		IsPosted:        row.IsPosted.Bool,
 		PostedAt:        helper.NullTimeToPtr(row.PostedAt),
+		ReversalOfID:    helper.NullUUIDToPtr(row.ReversalOfID),
+		SourceType:      source,
+		SourceID:        helper.NullUUIDToPtr(row.SourceID),
 	}
 }
 
+func journalSourceOrEmpty(s *domain.JournalSource) domain.JournalSource {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 // =========================================================
 // JOURNAL ITEM MAPPERS
 // =========================================================
@@ -331,6 +611,7 @@ func MapJournalItemDomainToParams(ji domain.JournalItem) db.CreateJournalItemPar
 		Credit:         helper.ToNullString(fmt.Sprintf("%.2f", ji.Credit)),
 		Description:    helper.ToNullString(helper.StrOrEmpty(ji.Description)),
 		CreatedBy:      helper.ToNullUUID(helper.DerefUUID(ji.CreatedBy)),
+		DepartmentID:   helper.ToNullUUID(helper.DerefUUID(ji.DepartmentID)),
 	}
 }
 
@@ -357,6 +638,307 @@ func MapJournalItemRowToDomain(row db.FinanceJournalItem) domain.JournalItem {
 		Debit:          debit,
 		Credit:         credit,
 		Description:    helper.NullStringToPtr(row.Description),
+		DepartmentID:   helper.NullUUIDToPtr(row.DepartmentID),
+	}
+}
+
+// =========================================================
+// LEDGER BALANCE MAPPERS
+// =========================================================
+
+func MapPostedTotalsRowToDomain(row db.SumPostedJournalItemsByAccountRow) domain.AccountBalance {
+	var debit, credit float64
+	fmt.Sscanf(row.TotalDebit, "%f", &debit)
+	fmt.Sscanf(row.TotalCredit, "%f", &credit)
+
+	return domain.AccountBalance{
+		AccountID: row.AccountID,
+		Debit:     debit,
+		Credit:    credit,
+	}
+}
+
+func MapRangeTotalsRowToDomain(row db.SumPostedJournalItemsInRangeRow) domain.AccountBalance {
+	var debit, credit float64
+	fmt.Sscanf(row.TotalDebit, "%f", &debit)
+	fmt.Sscanf(row.TotalCredit, "%f", &credit)
+
+	return domain.AccountBalance{
+		AccountID: row.AccountID,
+		Debit:     debit,
+		Credit:    credit,
+	}
+}
+
+// =========================================================
+// FISCAL YEAR CLOSING MAPPERS
+// =========================================================
+
+func MapFiscalYearClosingRowToDomain(row db.FinanceFiscalYearClosing) domain.FiscalYearClosing {
+	var netIncome float64
+	fmt.Sscanf(row.NetIncome, "%f", &netIncome)
+
+	return domain.FiscalYearClosing{
+		ID:                        row.ID,
+		InstituteID:               row.InstituteID,
+		FiscalYearStart:           row.FiscalYearStart,
+		FiscalYearEnd:             row.FiscalYearEnd,
+		JournalEntryID:            helper.NullUUIDToPtr(row.JournalEntryID),
+		RetainedEarningsAccountID: row.RetainedEarningsAccountID,
+		NetIncome:                 netIncome,
+		ClosedAt:                  row.ClosedAt,
+		ClosedBy:                  helper.NullUUIDToPtr(row.ClosedBy),
+	}
+}
+
+// =========================================================
+// LEDGER SETTINGS MAPPERS
+// =========================================================
+
+func MapLedgerSettingsDomainToParams(ls domain.LedgerSettings) db.UpsertLedgerSettingsParams {
+	return db.UpsertLedgerSettingsParams{
+		InstituteID:              ls.InstituteID,
+		ReceivablesAccountID:     ls.ReceivablesAccountID,
+		CashAccountID:            ls.CashAccountID,
+		BankAccountID:            ls.BankAccountID,
+		PayablesAccountID:        ls.PayablesAccountID,
+		PurchasesAccountID:       ls.PurchasesAccountID,
+		ConcessionsAccountID:     helper.ToNullUUID(helper.DerefUUID(ls.ConcessionsAccountID)),
+		StudentAdvancesAccountID: helper.ToNullUUID(helper.DerefUUID(ls.StudentAdvancesAccountID)),
+		UpdatedBy:                helper.ToNullUUID(helper.DerefUUID(ls.UpdatedBy)),
+	}
+}
+
+func MapLedgerSettingsRowToDomain(row db.FinanceLedgerSetting) domain.LedgerSettings {
+	return domain.LedgerSettings{
+		InstituteID:              row.InstituteID,
+		ReceivablesAccountID:     row.ReceivablesAccountID,
+		CashAccountID:            row.CashAccountID,
+		BankAccountID:            row.BankAccountID,
+		PayablesAccountID:        row.PayablesAccountID,
+		PurchasesAccountID:       row.PurchasesAccountID,
+		ConcessionsAccountID:     helper.NullUUIDToPtr(row.ConcessionsAccountID),
+		StudentAdvancesAccountID: helper.NullUUIDToPtr(row.StudentAdvancesAccountID),
+		UpdatedAt:                row.UpdatedAt,
+		UpdatedBy:                helper.NullUUIDToPtr(row.UpdatedBy),
+	}
+}
+
+// =========================================================
+// ONLINE PAYMENT MAPPERS
+// =========================================================
+
+// MapPaymentGatewayDomainToParams takes the already sealed secrets
+func MapPaymentGatewayDomainToParams(g domain.PaymentGatewaySettings, secretSealed, merchantKeySealed string) db.UpsertPaymentGatewayParams {
+	params := db.UpsertPaymentGatewayParams{
+		InstituteID:       g.InstituteID,
+		Gateway:           g.Gateway,
+		KeyID:             g.KeyID,
+		SecretSealed:      secretSealed,
+		MerchantKeySealed: helper.ToNullString(merchantKeySealed),
+		Environment:       g.Environment,
+		IsActive:          g.IsActive,
+		UpdatedBy:         helper.ToNullUUID(helper.DerefUUID(g.UpdatedBy)),
+	}
+	if g.MerchantID != nil {
+		params.MerchantID = helper.ToNullString(*g.MerchantID)
+	}
+	return params
+}
+
+func MapPaymentGatewayRowToDomain(row db.FinancePaymentGateway) domain.PaymentGatewaySettings {
+	return domain.PaymentGatewaySettings{
+		InstituteID: row.InstituteID,
+		Gateway:     row.Gateway,
+		KeyID:       row.KeyID,
+		MerchantID:  helper.NullStringToPtr(row.MerchantID),
+		Environment: row.Environment,
+		IsActive:    row.IsActive,
+		UpdatedAt:   row.UpdatedAt,
+		UpdatedBy:   helper.NullUUIDToPtr(row.UpdatedBy),
+	}
+}
+
+func MapOnlinePaymentRowToDomain(row db.FinanceOnlinePayment) domain.OnlinePayment {
+	var amount float64
+	fmt.Sscanf(row.Amount, "%f", &amount)
+
+	return domain.OnlinePayment{
+		ID:               row.ID,
+		InstituteID:      row.InstituteID,
+		InvoiceID:        row.InvoiceID,
+		StudentID:        row.StudentID,
+		Gateway:          row.Gateway,
+		OrderRef:         row.OrderRef,
+		GatewayOrderID:   helper.NullStringToPtr(row.GatewayOrderID),
+		GatewayPaymentID: helper.NullStringToPtr(row.GatewayPaymentID),
+		Amount:           amount,
+		Status:           domain.OnlinePaymentStatus(row.Status),
+		TransactionID:    helper.NullUUIDToPtr(row.TransactionID),
+		InitiatedBy:      helper.NullUUIDToPtr(row.InitiatedBy),
+		CreatedAt:        row.CreatedAt,
+		UpdatedAt:        row.UpdatedAt,
+	}
+}
+
+// =========================================================
+// REFUND MAPPERS
+// =========================================================
+
+func MapRefundDomainToParams(rf domain.Refund) db.CreateRefundParams {
+	return db.CreateRefundParams{
+		InstituteID:       rf.InstituteID,
+		StudentID:         helper.ToNullUUID(helper.DerefUUID(rf.StudentID)),
+		InvoiceID:         helper.ToNullUUID(helper.DerefUUID(rf.InvoiceID)),
+		Amount:            fmt.Sprintf("%.2f", rf.Amount),
+		Reason:            helper.ToNullString(helper.StrOrEmpty(rf.Reason)),
+		ApprovalsRequired: int32(rf.ApprovalsRequired),
+		CreatedBy:         helper.ToNullUUID(helper.DerefUUID(rf.CreatedBy)),
+	}
+}
+
+func MapRefundRowToDomain(row db.FinanceRefund) domain.Refund {
+	var amount float64
+	fmt.Sscanf(row.Amount, "%f", &amount)
+
+	rf := domain.Refund{
+		ID:                row.ID,
+		InstituteID:       row.InstituteID,
+		StudentID:         helper.NullUUIDToPtr(row.StudentID),
+		InvoiceID:         helper.NullUUIDToPtr(row.InvoiceID),
+		Amount:            amount,
+		Reason:            helper.NullStringToPtr(row.Reason),
+		Status:            domain.RefundStatus(row.Status.String),
+		ApprovalsRequired: int(row.ApprovalsRequired),
+		ApprovalsReceived: int(row.ApprovalsReceived),
+		PayoutReference:   helper.NullStringToPtr(row.PayoutReference),
+		OnlinePaymentID:   helper.NullUUIDToPtr(row.OnlinePaymentID),
+		RefundDate:        helper.NullTimeToPtr(row.RefundDate),
+		ProcessedBy:       helper.NullUUIDToPtr(row.ProcessedBy),
+		CreatedAt:         helper.NullTimeToValue(row.CreatedAt),
+		CreatedBy:         helper.NullUUIDToPtr(row.CreatedBy),
+		UpdatedAt:         helper.NullTimeToValue(row.UpdatedAt),
+	}
+	if row.PayoutMode.Valid {
+		mode := domain.RefundPayoutMode(row.PayoutMode.String)
+		rf.PayoutMode = &mode
+	}
+	return rf
+}
+
+func MapRefundSettingsRowToDomain(row db.FinanceRefundSetting) domain.RefundSettings {
+	var above float64
+	fmt.Sscanf(row.SecondStepAbove, "%f", &above)
+
+	return domain.RefundSettings{
+		InstituteID:     row.InstituteID,
+		ApprovalSteps:   int(row.ApprovalSteps),
+		SecondStepAbove: above,
+		UpdatedAt:       row.UpdatedAt,
+		UpdatedBy:       helper.NullUUIDToPtr(row.UpdatedBy),
+	}
+}
+
+func MapApprovalRowToDomain(row db.CoreApproval) domain.ApprovalStep {
+	return domain.ApprovalStep{
+		Step:       int(row.Step),
+		Status:     domain.ApprovalStatus(row.Status.String),
+		ApproverID: helper.NullUUIDToPtr(row.ApproverID),
+		Remarks:    helper.NullStringToPtr(row.Remarks),
+		DecidedAt:  helper.NullTimeToPtr(row.ApprovedAt),
+	}
+}
+
+// =========================================================
+// BANK ACCOUNT MAPPERS
+// =========================================================
+
+func MapBankAccountDomainToParams(ba domain.BankAccount) db.CreateBankAccountParams {
+	var opening sql.NullString
+	if ba.OpeningBalance != nil {
+		opening = sql.NullString{String: fmt.Sprintf("%.2f", *ba.OpeningBalance), Valid: true}
+	}
+
+	return db.CreateBankAccountParams{
+		InstituteID:     ba.InstituteID,
+		AccountName:     helper.ToNullString(helper.StrOrEmpty(ba.AccountName)),
+		AccountNumber:   helper.ToNullString(helper.StrOrEmpty(ba.AccountNumber)),
+		BankName:        helper.ToNullString(helper.StrOrEmpty(ba.BankName)),
+		IfscCode:        helper.ToNullString(helper.StrOrEmpty(ba.IFSCCode)),
+		BranchName:      helper.ToNullString(helper.StrOrEmpty(ba.BranchName)),
+		OpeningBalance:  opening,
+		LedgerAccountID: helper.ToNullUUID(helper.DerefUUID(ba.LedgerAccountID)),
+		CreatedBy:       helper.ToNullUUID(helper.DerefUUID(ba.CreatedBy)),
+	}
+}
+
+func MapBankAccountRowToDomain(row db.FinanceBankAccount) domain.BankAccount {
+	return domain.BankAccount{
+		TenantUUIDModel: domain.TenantUUIDModel{
+			BaseUUIDModel: domain.BaseUUIDModel{
+				ID:        row.ID,
+				CreatedAt: helper.NullTimeToValue(row.CreatedAt),
+				UpdatedAt: helper.NullTimeToValue(row.UpdatedAt),
+				CreatedBy: helper.NullUUIDToPtr(row.CreatedBy),
+				UpdatedBy: helper.NullUUIDToPtr(row.UpdatedBy),
+			},
+			InstituteID: row.InstituteID,
+		},
+		AccountName:     helper.NullStringToPtr(row.AccountName),
+		AccountNumber:   helper.NullStringToPtr(row.AccountNumber),
+		BankName:        helper.NullStringToPtr(row.BankName),
+		IFSCCode:        helper.NullStringToPtr(row.IfscCode),
+		BranchName:      helper.NullStringToPtr(row.BranchName),
+		OpeningBalance:  helper.NullNumericToPtr(row.OpeningBalance),
+		CurrentBalance:  helper.NullNumericToPtr(row.CurrentBalance),
+		IsActive:        row.IsActive.Bool,
+		LedgerAccountID: helper.NullUUIDToPtr(row.LedgerAccountID),
+	}
+}
+
+func MapBankStatementEntryRowToDomain(row db.FinanceBankStatementEntry) domain.BankStatementEntry {
+	var method *domain.StatementMatchMethod
+	if row.MatchMethod.Valid {
+		m := domain.StatementMatchMethod(row.MatchMethod.String)
+		method = &m
+	}
+
+	return domain.BankStatementEntry{
+		ID:                      row.ID,
+		InstituteID:             row.InstituteID,
+		BankAccountID:           helper.NullUUIDToPtr(row.BankAccountID),
+		TransactionDate:         helper.NullTimeToPtr(row.TransactionDate),
+		ValueDate:               helper.NullTimeToPtr(row.ValueDate),
+		Description:             helper.NullStringToPtr(row.Description),
+		WithdrawalAmount:        helper.NullNumericToValue(row.WithdrawalAmount),
+		DepositAmount:           helper.NullNumericToValue(row.DepositAmount),
+		Balance:                 helper.NullNumericToPtr(row.Balance),
+		ReconciledTransactionID: helper.NullUUIDToPtr(row.ReconciledTransactionID),
+		IsReconciled:            row.IsReconciled.Bool,
+		CreatedAt:               helper.NullTimeToValue(row.CreatedAt),
+		ImportID:                helper.NullUUIDToPtr(row.ImportID),
+		LineNo:                  int(row.LineNo),
+		Reference:               helper.NullStringToPtr(row.Reference),
+		MatchMethod:             method,
+		MatchRemarks:            helper.NullStringToPtr(row.MatchRemarks),
+		MatchedAt:               helper.NullTimeToPtr(row.MatchedAt),
+		MatchedBy:               helper.NullUUIDToPtr(row.MatchedBy),
+	}
+}
+
+func MapBankStatementImportRowToDomain(row db.FinanceBankStatementImport) domain.BankStatementImport {
+	return domain.BankStatementImport{
+		ID:             row.ID,
+		InstituteID:    row.InstituteID,
+		BankAccountID:  row.BankAccountID,
+		Format:         row.Format,
+		FileName:       helper.NullStringToPtr(row.FileName),
+		LinesTotal:     int(row.LinesTotal),
+		LinesImported:  int(row.LinesImported),
+		LinesDuplicate: int(row.LinesDuplicate),
+		LinesMatched:   int(row.LinesMatched),
+		CreatedAt:      row.CreatedAt,
+		CreatedBy:      helper.NullUUIDToPtr(row.CreatedBy),
 	}
 }
 
@@ -374,6 +956,7 @@ func MapVendorDomainToParams(v domain.Vendor) db.CreateVendorParams {
 		Address:     helper.ToNullString(helper.StrOrEmpty(v.Address)),
 		GstNumber:   helper.ToNullString(helper.StrOrEmpty(v.GSTNumber)),
 		CreatedBy:   helper.ToNullUUID(helper.DerefUUID(v.CreatedBy)),
+		StateID:     helper.ToNullUUID(helper.DerefUUID(v.StateID)),
 	}
 }
 
@@ -393,6 +976,7 @@ func MapVendorRowToDomain(row db.FinanceVendor) domain.Vendor {
 		Email:       helper.NullStringToPtr(row.Email),
 		Address:     helper.NullStringToPtr(row.Address),
 		GSTNumber:   helper.NullStringToPtr(row.GstNumber),
+		StateID:     helper.NullUUIDToPtr(row.StateID),
 	}
 }
 
@@ -402,10 +986,13 @@ func MapVendorRowToDomain(row db.FinanceVendor) domain.Vendor {
 
 func MapPurchaseOrderDomainToParams(po domain.PurchaseOrder) db.CreatePurchaseOrderParams {
 	return db.CreatePurchaseOrderParams{
-		InstituteID: po.InstituteID,
-		VendorID:    po.VendorID,
-		OrderDate:   helper.ToNullTime(po.OrderDate),
-		CreatedBy:   helper.ToNullUUID(helper.DerefUUID(po.CreatedBy)),
+		InstituteID:      po.InstituteID,
+		VendorID:         po.VendorID,
+		OrderDate:        helper.ToNullTime(po.OrderDate),
+		CreatedBy:        helper.ToNullUUID(helper.DerefUUID(po.CreatedBy)),
+		DepartmentID:     helper.ToNullUUID(helper.DerefUUID(po.DepartmentID)),
+		ExpenseAccountID: helper.ToNullUUID(helper.DerefUUID(po.ExpenseAccountID)),
+		RequisitionID:    helper.ToNullUUID(helper.DerefUUID(po.RequisitionID)),
 	}
 }
 
@@ -420,15 +1007,20 @@ func MapPurchaseOrderRowToDomain(row db.FinancePurchaseOrder) domain.PurchaseOrd
 			BaseUUIDModel: domain.BaseUUIDModel{
 				ID:        row.ID,
 				CreatedAt: helper.NullTimeToValue(row.CreatedAt),
+				UpdatedAt: helper.NullTimeToValue(row.UpdatedAt),
 				CreatedBy: helper.NullUUIDToPtr(row.CreatedBy),
+				UpdatedBy: helper.NullUUIDToPtr(row.UpdatedBy),
 			},
 			InstituteID: row.InstituteID,
 		},
-		VendorID:    row.VendorID,
-		OrderDate:   helper.NullTimeToValue(row.OrderDate),
-		Status:      domain.PurchaseStatus(row.Status.String),
-		TotalAmount: totalAmount,
-		ReferenceNo: helper.NullStringToPtr(row.ReferenceNo),
+		VendorID:         row.VendorID,
+		OrderDate:        helper.NullTimeToValue(row.OrderDate),
+		Status:           domain.PurchaseStatus(row.Status.String),
+		TotalAmount:      totalAmount,
+		ReferenceNo:      helper.NullStringToPtr(row.ReferenceNo),
+		DepartmentID:     helper.NullUUIDToPtr(row.DepartmentID),
+		ExpenseAccountID: helper.NullUUIDToPtr(row.ExpenseAccountID),
+		RequisitionID:    helper.NullUUIDToPtr(row.RequisitionID),
 	}
 }
 
@@ -443,9 +1035,11 @@ func MapPurchaseItemDomainToParams(pi domain.PurchaseItem) db.AddPurchaseItemPar
 		ItemID:          helper.ToNullUUID(pi.ItemID),
 		Quantity:        sql.NullInt32{Int32: int32(pi.Quantity), Valid: true},
 		UnitPrice:       helper.ToNullString(fmt.Sprintf("%.2f", pi.UnitPrice)),
-		TaxID:           helper.ToNullUUID(uuid.Nil), // Placeholder
-		TaxAmount:       helper.ToNullString("0"),
-		TotalAmount:     helper.ToNullString(fmt.Sprintf("%.2f", float64(pi.Quantity)*pi.UnitPrice)),
+		TaxID:           helper.ToNullUUID(helper.DerefUUID(pi.TaxID)),
+		TaxAmount:       helper.ToNullString(fmt.Sprintf("%.2f", pi.TaxAmount)),
+		TotalAmount:     helper.ToNullString(fmt.Sprintf("%.2f", float64(pi.Quantity)*pi.UnitPrice+pi.TaxAmount)),
+
+		RequisitionItemID: helper.ToNullUUID(helper.DerefUUID(pi.RequisitionItemID)),
 	}
 }
 
@@ -467,5 +1061,338 @@ func MapPurchaseItemRowToDomain(row db.FinancePurchaseOrderItem) domain.Purchase
 		ItemID:          helper.NullUUIDToValue(row.ItemID),
 		Quantity:        int(row.Quantity.Int32),
 		UnitPrice:       unitPrice,
+		TaxID:           helper.NullUUIDToPtr(row.TaxID),
+		TaxAmount:       helper.NullNumericToValue(row.TaxAmount),
+		TotalAmount:     helper.NullNumericToValue(row.TotalAmount),
+
+		RequisitionItemID: helper.NullUUIDToPtr(row.RequisitionItemID),
+		ReceivedQuantity:  int(row.ReceivedQuantity),
+		BilledQuantity:    int(row.BilledQuantity),
+	}
+}
+
+// =========================================================
+// GOODS RECEIPT MAPPERS
+// =========================================================
+
+func MapGoodsReceiptRowToDomain(row db.FinanceGoodsReceipt) domain.GoodsReceipt {
+	var amount float64
+	fmt.Sscanf(row.Amount, "%f", &amount)
+
+	return domain.GoodsReceipt{
+		ID:              row.ID,
+		InstituteID:     row.InstituteID,
+		PurchaseOrderID: row.PurchaseOrderID,
+		LocationID:      row.LocationID,
+		ReceivedOn:      row.ReceivedOn,
+		ReferenceNo:     helper.NullStringToPtr(row.ReferenceNo),
+		Remarks:         helper.NullStringToPtr(row.Remarks),
+		Amount:          amount,
+		CreatedBy:       helper.NullUUIDToPtr(row.CreatedBy),
+		CreatedAt:       row.CreatedAt,
+	}
+}
+
+func MapGoodsReceiptItemRowToDomain(row db.FinanceGoodsReceiptItem) domain.GoodsReceiptItem {
+	var unitPrice, taxAmount, amount float64
+	fmt.Sscanf(row.UnitPrice, "%f", &unitPrice)
+	fmt.Sscanf(row.TaxAmount, "%f", &taxAmount)
+	fmt.Sscanf(row.Amount, "%f", &amount)
+
+	return domain.GoodsReceiptItem{
+		ID:                  row.ID,
+		InstituteID:         row.InstituteID,
+		GoodsReceiptID:      row.GoodsReceiptID,
+		PurchaseOrderItemID: row.PurchaseOrderItemID,
+		ItemID:              row.ItemID,
+		Quantity:            int(row.Quantity),
+		UnitPrice:           unitPrice,
+		TaxAmount:           taxAmount,
+		Amount:              amount,
+	}
+}
+
+// =========================================================
+// VENDOR BILL MAPPERS
+// =========================================================
+
+func MapVendorBillRowToDomain(row db.FinanceVendorBill) domain.VendorBill {
+	var amount, taxAmount, paidAmount float64
+	fmt.Sscanf(row.Amount, "%f", &amount)
+	fmt.Sscanf(row.TaxAmount, "%f", &taxAmount)
+	fmt.Sscanf(row.PaidAmount, "%f", &paidAmount)
+
+	var notes []string
+	if row.MatchNotes.Valid && row.MatchNotes.String != "" {
+		notes = strings.Split(row.MatchNotes.String, "\n")
+	}
+
+	return domain.VendorBill{
+		ID:              row.ID,
+		InstituteID:     row.InstituteID,
+		VendorID:        row.VendorID,
+		PurchaseOrderID: row.PurchaseOrderID,
+		BillNo:          row.BillNo,
+		BillDate:        row.BillDate,
+		DueDate:         row.DueDate,
+		Amount:          amount,
+		TaxAmount:       taxAmount,
+		PaidAmount:      paidAmount,
+		Status:          domain.VendorBillStatus(row.Status),
+		MatchNotes:      notes,
+		DecidedBy:       helper.NullUUIDToPtr(row.DecidedBy),
+		DecidedAt:       helper.NullTimeToPtr(row.DecidedAt),
+		Remarks:         helper.NullStringToPtr(row.Remarks),
+		CreatedBy:       helper.NullUUIDToPtr(row.CreatedBy),
+		CreatedAt:       row.CreatedAt,
+		UpdatedAt:       row.UpdatedAt,
+	}
+}
+
+func MapVendorBillItemRowToDomain(row db.FinanceVendorBillItem) domain.VendorBillItem {
+	var unitPrice, taxAmount, amount float64
+	fmt.Sscanf(row.UnitPrice, "%f", &unitPrice)
+	fmt.Sscanf(row.TaxAmount, "%f", &taxAmount)
+	fmt.Sscanf(row.Amount, "%f", &amount)
+
+	return domain.VendorBillItem{
+		ID:                  row.ID,
+		InstituteID:         row.InstituteID,
+		VendorBillID:        row.VendorBillID,
+		PurchaseOrderItemID: row.PurchaseOrderItemID,
+		Quantity:            int(row.Quantity),
+		UnitPrice:           unitPrice,
+		TaxAmount:           taxAmount,
+		Amount:              amount,
+	}
+}
+
+func MapVendorPaymentRowToDomain(row db.FinanceVendorPayment) domain.VendorPayment {
+	var amount float64
+	fmt.Sscanf(row.Amount, "%f", &amount)
+
+	return domain.VendorPayment{
+		ID:           row.ID,
+		InstituteID:  row.InstituteID,
+		VendorBillID: row.VendorBillID,
+		VendorID:     row.VendorID,
+		Amount:       amount,
+		PaymentMode:  domain.PaymentMode(row.PaymentMode),
+		PaidOn:       row.PaidOn,
+		ReferenceNo:  helper.NullStringToPtr(row.ReferenceNo),
+		CreatedBy:    helper.NullUUIDToPtr(row.CreatedBy),
+		CreatedAt:    row.CreatedAt,
+	}
+}
+
+// =========================================================
+// BUDGET MAPPERS
+// =========================================================
+
+func MapBudgetDomainToParams(b domain.Budget) db.CreateBudgetParams {
+	params := db.CreateBudgetParams{
+		InstituteID:       b.InstituteID,
+		AcademicSessionID: helper.ToNullUUID(helper.DerefUUID(b.AcademicSessionID)),
+		DepartmentID:      helper.ToNullUUID(helper.DerefUUID(b.DepartmentID)),
+		ExpenseCategoryID: helper.ToNullUUID(helper.DerefUUID(b.ExpenseCategoryID)),
+		AllocatedAmount:   fmt.Sprintf("%.2f", b.AllocatedAmount),
+		SoftLimitPercent:  fmt.Sprintf("%.2f", b.SoftLimitPercent),
+		CreatedBy:         helper.ToNullUUID(helper.DerefUUID(b.CreatedBy)),
+	}
+	if b.HardLimitPercent != nil {
+		params.HardLimitPercent = helper.ToNullString(fmt.Sprintf("%.2f", *b.HardLimitPercent))
+	}
+	return params
+}
+
+func MapBudgetRowToDomain(row db.FinanceBudget) domain.Budget {
+	var allocated, softLimit float64
+	fmt.Sscanf(row.AllocatedAmount, "%f", &allocated)
+	fmt.Sscanf(row.SoftLimitPercent, "%f", &softLimit)
+
+	return domain.Budget{
+		ID:                row.ID,
+		InstituteID:       row.InstituteID,
+		AcademicSessionID: helper.NullUUIDToPtr(row.AcademicSessionID),
+		DepartmentID:      helper.NullUUIDToPtr(row.DepartmentID),
+		ExpenseCategoryID: helper.NullUUIDToPtr(row.ExpenseCategoryID),
+		AllocatedAmount:   allocated,
+		UtilizedAmount:    helper.NullNumericToValue(row.UtilizedAmount),
+		SoftLimitPercent:  softLimit,
+		HardLimitPercent:  helper.NullNumericToPtr(row.HardLimitPercent),
+		CreatedAt:         helper.NullTimeToValue(row.CreatedAt),
+		CreatedBy:         helper.NullUUIDToPtr(row.CreatedBy),
+	}
+}
+
+// =========================================================
+// TAX MAPPERS
+// =========================================================
+
+func MapTaxRowToDomain(row db.FinanceTax) domain.Tax {
+	var percentage float64
+	fmt.Sscanf(row.Percentage, "%f", &percentage)
+
+	return domain.Tax{
+		ID:          row.ID,
+		InstituteID: row.InstituteID,
+		Name:        helper.NullStringToPtr(row.Name),
+		Percentage:  percentage,
+		CreatedAt:   helper.NullTimeToValue(row.CreatedAt),
+	}
+}
+
+func MapTaxSettingsDomainToParams(ts domain.TaxSettings) db.UpsertTaxSettingsParams {
+	return db.UpsertTaxSettingsParams{
+		InstituteID:         ts.InstituteID,
+		Gstin:               ts.GSTIN,
+		StateID:             ts.StateID,
+		OutputCgstAccountID: ts.OutputCGSTAccountID,
+		OutputSgstAccountID: ts.OutputSGSTAccountID,
+		OutputIgstAccountID: ts.OutputIGSTAccountID,
+		InputCgstAccountID:  ts.InputCGSTAccountID,
+		InputSgstAccountID:  ts.InputSGSTAccountID,
+		InputIgstAccountID:  ts.InputIGSTAccountID,
+		UpdatedBy:           helper.ToNullUUID(helper.DerefUUID(ts.UpdatedBy)),
+	}
+}
+
+func MapTaxSettingsRowToDomain(row db.FinanceTaxSetting) domain.TaxSettings {
+	return domain.TaxSettings{
+		InstituteID:         row.InstituteID,
+		GSTIN:               row.Gstin,
+		StateID:             row.StateID,
+		OutputCGSTAccountID: row.OutputCgstAccountID,
+		OutputSGSTAccountID: row.OutputSgstAccountID,
+		OutputIGSTAccountID: row.OutputIgstAccountID,
+		InputCGSTAccountID:  row.InputCgstAccountID,
+		InputSGSTAccountID:  row.InputSgstAccountID,
+		InputIGSTAccountID:  row.InputIgstAccountID,
+		UpdatedAt:           row.UpdatedAt,
+		UpdatedBy:           helper.NullUUIDToPtr(row.UpdatedBy),
+	}
+}
+
+func MapTaxLineRowToDomain(row db.FinanceTaxLine) domain.TaxLine {
+	var rate, taxable, cgst, sgst, igst float64
+	fmt.Sscanf(row.Rate, "%f", &rate)
+	fmt.Sscanf(row.TaxableAmount, "%f", &taxable)
+	fmt.Sscanf(row.Cgst, "%f", &cgst)
+	fmt.Sscanf(row.Sgst, "%f", &sgst)
+	fmt.Sscanf(row.Igst, "%f", &igst)
+
+	return domain.TaxLine{
+		ID:                row.ID,
+		InstituteID:       row.InstituteID,
+		Direction:         domain.TaxDirection(row.Direction),
+		DocumentType:      row.DocumentType,
+		DocumentID:        row.DocumentID,
+		ItemID:            row.ItemID,
+		TaxID:             row.TaxID,
+		Rate:              rate,
+		Supply:            domain.SupplyType(row.Supply),
+		TaxableAmount:     taxable,
+		CGST:              cgst,
+		SGST:              sgst,
+		IGST:              igst,
+		CounterpartyGSTIN: helper.NullStringToPtr(row.CounterpartyGstin),
+		TaxDate:           helper.NullTimeToPtr(row.TaxDate),
+		CreatedAt:         row.CreatedAt,
+	}
+}
+
+// =========================================================
+// DUNNING MAPPERS
+// =========================================================
+
+func MapDunningStepRowToDomain(row db.FinanceDunningStep) domain.DunningStep {
+	var channels []domain.DunningChannel
+	if row.SendEmail {
+		channels = append(channels, domain.DunningEmail)
+	}
+	if row.SendSms {
+		channels = append(channels, domain.DunningSMS)
+	}
+	if row.SendInApp {
+		channels = append(channels, domain.DunningInApp)
+	}
+
+	return domain.DunningStep{
+		ID:          row.ID,
+		InstituteID: row.InstituteID,
+		DaysOverdue: int(row.DaysOverdue),
+		Subject:     row.Subject,
+		Message:     row.Message,
+		Channels:    channels,
+		IsActive:    row.IsActive,
+		UpdatedBy:   helper.NullUUIDToPtr(row.UpdatedBy),
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+func MapDunningStepDomainToParams(s domain.DunningStep) db.UpsertDunningStepParams {
+	return db.UpsertDunningStepParams{
+		InstituteID: s.InstituteID,
+		DaysOverdue: int32(s.DaysOverdue),
+		Subject:     s.Subject,
+		Message:     s.Message,
+		SendEmail:   helper.Contains(s.Channels, domain.DunningEmail),
+		SendSms:     helper.Contains(s.Channels, domain.DunningSMS),
+		SendInApp:   helper.Contains(s.Channels, domain.DunningInApp),
+		CreatedBy:   helper.ToNullUUID(helper.DerefUUID(s.UpdatedBy)),
+	}
+}
+
+func MapDunningReminderRowToDomain(row db.FinanceDunningReminder) domain.DunningReminder {
+	var amountDue float64
+	fmt.Sscanf(row.AmountDue, "%f", &amountDue)
+
+	return domain.DunningReminder{
+		ID:            row.ID,
+		InstituteID:   row.InstituteID,
+		InvoiceID:     row.InvoiceID,
+		StudentID:     row.StudentID,
+		DunningStepID: row.DunningStepID,
+		DaysOverdue:   int(row.DaysOverdue),
+		AmountDue:     amountDue,
+		Recipients:    int(row.Recipients),
+		Failures:      int(row.Failures),
+		SentAt:        row.SentAt,
+	}
+}
+
+func MapPaymentPlanDomainToParams(p domain.PaymentPlan) db.CreatePaymentPlanParams {
+	return db.CreatePaymentPlanParams{
+		InstituteID:  p.InstituteID,
+		StudentID:    p.StudentID,
+		Amount:       fmt.Sprintf("%.2f", p.Amount),
+		Installments: int32(p.Installments),
+		StartDate:    p.StartDate,
+		EndDate:      p.EndDate,
+		Reason:       p.Reason,
+		CreatedBy:    helper.ToNullUUID(helper.DerefUUID(p.CreatedBy)),
+	}
+}
+
+func MapPaymentPlanRowToDomain(row db.FinancePaymentPlan) domain.PaymentPlan {
+	var amount float64
+	fmt.Sscanf(row.Amount, "%f", &amount)
+
+	return domain.PaymentPlan{
+		ID:           row.ID,
+		InstituteID:  row.InstituteID,
+		StudentID:    row.StudentID,
+		Amount:       amount,
+		Installments: int(row.Installments),
+		StartDate:    row.StartDate,
+		EndDate:      row.EndDate,
+		Reason:       row.Reason,
+		Status:       domain.ApprovalStatus(row.Status),
+		Remarks:      helper.NullStringToPtr(row.Remarks),
+		DecidedBy:    helper.NullUUIDToPtr(row.DecidedBy),
+		DecidedAt:    helper.NullTimeToPtr(row.DecidedAt),
+		CreatedBy:    helper.NullUUIDToPtr(row.CreatedBy),
+		CreatedAt:    row.CreatedAt,
+		UpdatedAt:    row.UpdatedAt,
 	}
 }
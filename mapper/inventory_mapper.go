@@ -0,0 +1,101 @@
+package mapper
+
+import (
+	"database/sql"
+	"swiftschool/domain"
+	"swiftschool/helper"
+	"swiftschool/internal/db"
+)
+
+// =========================================================
+// INVENTORY ITEM MAPPERS
+// =========================================================
+
+func MapInventoryItemRowToDomain(row db.InventoryItem) domain.InventoryItem {
+	return domain.InventoryItem{
+		TenantUUIDModel: domain.TenantUUIDModel{
+			BaseUUIDModel: domain.BaseUUIDModel{
+				ID:        row.ID,
+				CreatedAt: helper.NullTimeToValue(row.CreatedAt),
+				UpdatedAt: helper.NullTimeToValue(row.UpdatedAt),
+				DeletedAt: helper.NullTimeToPtr(row.DeletedAt),
+				CreatedBy: helper.NullUUIDToPtr(row.CreatedBy),
+				UpdatedBy: helper.NullUUIDToPtr(row.UpdatedBy),
+			},
+			InstituteID: row.InstituteID,
+		},
+		Name:         helper.NullStringToValue(row.Name),
+		CategoryID:   helper.NullUUIDToPtr(row.CategoryID),
+		IsFixedAsset: helper.NullBoolToValue(row.IsFixedAsset),
+		ReorderLevel: int(helper.NullInt32ToValue(row.ReorderLevel)),
+		TaxID:        helper.NullUUIDToPtr(row.TaxID),
+	}
+}
+
+// =========================================================
+// REQUISITION MAPPERS
+// =========================================================
+
+func MapRequisitionDomainToParams(r domain.Requisition) db.CreateRequisitionParams {
+	return db.CreateRequisitionParams{
+		InstituteID:  r.InstituteID,
+		RequestedBy:  r.RequestedBy,
+		DepartmentID: helper.ToNullUUID(helper.DerefUUID(r.DepartmentID)),
+		RequestDate:  helper.ToNullTime(helper.DerefTime(r.RequestDate)),
+		Remarks:      helper.ToNullString(helper.StrOrEmpty(r.Remarks)),
+		CreatedBy:    helper.ToNullUUID(helper.DerefUUID(r.CreatedBy)),
+	}
+}
+
+func MapRequisitionRowToDomain(row db.InventoryRequisition) domain.Requisition {
+	return domain.Requisition{
+		TenantUUIDModel: domain.TenantUUIDModel{
+			BaseUUIDModel: domain.BaseUUIDModel{
+				ID:        row.ID,
+				CreatedAt: helper.NullTimeToValue(row.CreatedAt),
+				UpdatedAt: helper.NullTimeToValue(row.UpdatedAt),
+				CreatedBy: helper.NullUUIDToPtr(row.CreatedBy),
+				UpdatedBy: helper.NullUUIDToPtr(row.UpdatedBy),
+			},
+			InstituteID: row.InstituteID,
+		},
+		RequestedBy:  row.RequestedBy,
+		DepartmentID: helper.NullUUIDToPtr(row.DepartmentID),
+		Status:       domain.RequisitionStatus(row.Status.String),
+		RequestDate:  helper.NullTimeToPtr(row.RequestDate),
+		Remarks:      helper.NullStringToPtr(row.Remarks),
+		DecidedBy:    helper.NullUUIDToPtr(row.DecidedBy),
+		DecidedAt:    helper.NullTimeToPtr(row.DecidedAt),
+	}
+}
+
+func MapRequisitionItemDomainToParams(ri domain.RequisitionItem) db.CreateRequisitionItemParams {
+	params := db.CreateRequisitionItemParams{
+		InstituteID:   ri.InstituteID,
+		RequisitionID: ri.RequisitionID,
+		ItemID:        helper.ToNullUUID(helper.DerefUUID(ri.ItemID)),
+		ItemName:      helper.ToNullString(helper.StrOrEmpty(ri.ItemName)),
+		Remarks:       helper.ToNullString(helper.StrOrEmpty(ri.Remarks)),
+	}
+	if ri.Quantity != nil {
+		params.Quantity = sql.NullInt32{Int32: int32(*ri.Quantity), Valid: true}
+	}
+	return params
+}
+
+func MapRequisitionItemRowToDomain(row db.InventoryRequisitionItem) domain.RequisitionItem {
+	item := domain.RequisitionItem{
+		ID:              row.ID,
+		InstituteID:     row.InstituteID,
+		RequisitionID:   row.RequisitionID,
+		ItemID:          helper.NullUUIDToPtr(row.ItemID),
+		ItemName:        helper.NullStringToPtr(row.ItemName),
+		OrderedQuantity: int(row.OrderedQuantity),
+		Remarks:         helper.NullStringToPtr(row.Remarks),
+	}
+	if row.Quantity.Valid {
+		quantity := int(row.Quantity.Int32)
+		item.Quantity = &quantity
+	}
+	return item
+}
@@ -1,6 +1,8 @@
 package mapper
 
 import (
+	"database/sql"
+
 	"swiftschool/domain"
 	"swiftschool/helper"
 	"swiftschool/internal/db"
@@ -97,3 +99,11 @@ func MapUpdateStudentParams(s domain.Student) db.UpdateStudentParams {
 		UpdatedBy:      helper.ToNullUUID(helper.DerefUUID(s.UpdatedBy)),
 	}
 }
+
+func MapGuardianChildToDomain(row db.CoreStudent, relationship sql.NullString, isPrimaryContact sql.NullBool) domain.GuardianChild {
+	return domain.GuardianChild{
+		Student:          MapStudentRowToDomain(row),
+		Relationship:     domain.RelationshipType(helper.NullStringToValue(relationship)),
+		IsPrimaryContact: helper.NullBoolToValue(isPrimaryContact),
+	}
+}
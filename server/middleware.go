@@ -2,38 +2,22 @@ package server
 
 import (
 	"net/http"
+
+	"swiftschool/helper"
 )
 
-// RequireRole checks if the logged-in user has the required role.
+// RequireRole checks that the logged-in user's server-side session has the
+// required role. The role is never read from a client-supplied cookie.
 func RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		userRole, ok := GetUserRoleFromSession(r)
-		if !ok || userRole != role {
+		session, err := helper.GetSession(r)
+		if err != nil || session.Role != role {
 			// Redirect unauthorized users to login page
 			http.Redirect(w, r, "/", http.StatusSeeOther)
 			return
 		}
-		next(w, r)
-	}
-}
-
-// Example: session cookie key
-const sessionRoleKey = "user_role"
 
-// GetUserRoleFromSession returns the logged-in user's role from the session.
-// Returns (role string, ok bool)
-func GetUserRoleFromSession(r *http.Request) (string, bool) {
-	cookie, err := r.Cookie(sessionRoleKey)
-	if err != nil {
-		// No cookie found
-		return "", false
+		ctx := helper.WithSession(r.Context(), session)
+		next(w, r.WithContext(ctx))
 	}
-
-	// In a real app, you might validate this value, decode JWT, or lookup DB
-	role := cookie.Value
-	if role == "" {
-		return "", false
-	}
-
-	return role, true
 }
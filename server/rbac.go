@@ -0,0 +1,336 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"swiftschool/domain"
+	"swiftschool/dto"
+	"swiftschool/helper"
+
+	"github.com/casbin/casbin/v2"
+)
+
+// Actions checked against the act column of a policy (matched with regexMatch).
+const (
+	actRead   = "read"
+	actCreate = "create"
+	actUpdate = "update"
+	actDelete = "delete"
+	actManage = "(read|create|update|delete)"
+)
+
+// Object namespaces checked against the obj column of a policy (matched with keyMatch).
+const (
+	objUsers           = "auth/users"
+	objPolicies        = "auth/policies"
+	objInstitutes      = "core/institutes"
+	objClasses         = "core/classes"
+	objDepartments     = "core/departments"
+	objStudents        = "core/students"
+	objEmployees       = "core/employees"
+	objGuardians       = "core/guardians"
+	objAcademicSession = "core/academic_sessions"
+	objAddresses       = "core/addresses"
+	objSubjects        = "academics/subjects"
+	objClassPeriods    = "academics/class_periods"
+	objTimetable       = "academics/timetable"
+	objAttendance      = "academics/attendance"
+	objAccounts        = "finance/accounts"
+	objJournals        = "finance/journals"
+	objStatements      = "finance/statements"
+	objFiscalYears     = "finance/fiscal_years"
+	objLedgerSettings  = "finance/ledger_settings"
+	objFeeHeads        = "finance/fee_heads"
+	objFeeStructures   = "finance/fee_structures"
+	objConcessions     = "finance/concessions"
+	objPaymentGateway  = "finance/payment_gateway"
+	objFines           = "finance/fines"
+	objInvoices        = "finance/invoices"
+	objPayments        = "finance/transactions"
+	objReceipts        = "finance/receipts"
+	objWallets         = "finance/wallets"
+	objRefunds         = "finance/refunds"
+	objBankAccounts    = "finance/bank_accounts"
+	objVendors         = "finance/vendors"
+	objPurchaseOrders  = "finance/purchase_orders"
+	objBudgets         = "finance/budgets"
+	objTaxes           = "finance/taxes"
+	objGoodsReceipts   = "finance/goods_receipts"
+	objVendorBills     = "finance/vendor_bills"
+	objDefaulters      = "finance/defaulters"
+	objDunning         = "finance/dunning"
+	objPaymentPlans    = "finance/payment_plans"
+	objRequisitions    = "inventory/requisitions"
+	objEnquiries       = "admissions/enquiries"
+	objDocuments       = "common/documents"
+	objNotifications   = "common/notifications"
+	objParentPortal    = "parent/portal"
+	objParentCheckout  = "parent/checkout"
+)
+
+//////////////////////////////////////////////////////
+//                   ENFORCER                      //
+//////////////////////////////////////////////////////
+
+// newEnforcer loads the casbin model file and the policies stored in the database.
+func newEnforcer(modelPath string, sqlDB *sql.DB, timeout, reloadInterval time.Duration) (*casbin.SyncedEnforcer, error) {
+	if modelPath == "" {
+		modelPath = "rbac_with_domains_model.conf"
+	}
+
+	e, err := casbin.NewSyncedEnforcer(modelPath, newPolicyAdapter(sqlDB, timeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise rbac enforcer: %w", err)
+	}
+
+	// Pick up policy changes made by other replicas
+	if reloadInterval <= 0 {
+		reloadInterval = time.Minute
+	}
+	e.StartAutoLoadPolicy(reloadInterval)
+
+	return e, nil
+}
+
+// RequirePermission authenticates the session and checks that its role may
+// perform act on obj inside the request's institute.
+func (s *Server) RequirePermission(obj, act string, next http.HandlerFunc) http.HandlerFunc {
+	return helper.RequireSession(s.RequireTenant(func(w http.ResponseWriter, r *http.Request) {
+		session, ok := helper.SessionFromContext(r.Context())
+		if !ok {
+			helper.NewErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		dom := s.requestDomain(r)
+
+		allowed, err := s.enforcer.Enforce(session.Role, dom, obj, act)
+		if err != nil {
+			log.Printf("rbac: enforce failed for user %s: %v", session.UserID, err)
+			helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to evaluate permissions")
+			return
+		}
+
+		if !allowed {
+			log.Printf("rbac: denied user=%s role=%s dom=%s obj=%s act=%s path=%s",
+				session.UserID, session.Role, dom, obj, act, r.URL.Path)
+			helper.NewErrorResponse(w, http.StatusForbidden, "you do not have permission to perform this action")
+			return
+		}
+
+		next(w, r)
+	}))
+}
+
+// requestDomain returns the tenant bound to the request by RequireTenant.
+func (s *Server) requestDomain(r *http.Request) string {
+	instituteID, err := helper.GetInstituteID(r)
+	if err != nil {
+		return ""
+	}
+	return instituteID.String()
+}
+
+//////////////////////////////////////////////////////
+//                POLICY HANDLERS                  //
+//////////////////////////////////////////////////////
+
+// handleListPolicies godoc
+// @Summary List role permissions
+// @Description List the role-permission pairs configured for the current institute
+// @Tags Auth - Policies
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse{data=[]dto.PolicyResponse}
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/policies/list [get]
+func (s *Server) handleListPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	dom := s.requestDomain(r)
+	if dom == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "institute is required")
+		return
+	}
+
+	rules, err := s.enforcer.GetFilteredPolicy(1, dom)
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to list policies: "+err.Error())
+		return
+	}
+
+	data := make([]dto.PolicyResponse, 0, len(rules))
+	for _, rule := range rules {
+		data = append(data, dto.PolicyResponse{
+			Role:        rule[0],
+			InstituteID: rule[1],
+			Object:      rule[2],
+			Action:      rule[3],
+		})
+	}
+
+	helper.NewSuccessResponse(w, http.StatusOK, "policies fetched successfully", data)
+}
+
+// handleGrantPolicy godoc
+// @Summary Grant a permission to a role
+// @Description Allow a role to perform an action on an object within the current institute
+// @Tags Auth - Policies
+// @Accept json
+// @Produce json
+// @Param request body dto.PolicyRequest true "Role permission"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/policies/grant [post]
+func (s *Server) handleGrantPolicy(w http.ResponseWriter, r *http.Request) {
+	rule, ok := s.parsePolicyRequest(w, r)
+	if !ok {
+		return
+	}
+
+	added, err := s.enforcer.AddPolicy(rule[0], rule[1], rule[2], rule[3])
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to grant permission: "+err.Error())
+		return
+	}
+
+	s.auditPolicyChange(r, "grant", rule)
+	helper.NewSuccessResponse(w, http.StatusOK, "permission granted successfully", map[string]bool{"changed": added})
+}
+
+// handleRevokePolicy godoc
+// @Summary Revoke a permission from a role
+// @Description Remove a role-permission pair from the current institute
+// @Tags Auth - Policies
+// @Accept json
+// @Produce json
+// @Param request body dto.PolicyRequest true "Role permission"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/policies/revoke [post]
+func (s *Server) handleRevokePolicy(w http.ResponseWriter, r *http.Request) {
+	rule, ok := s.parsePolicyRequest(w, r)
+	if !ok {
+		return
+	}
+
+	removed, err := s.enforcer.RemovePolicy(rule[0], rule[1], rule[2], rule[3])
+	if err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to revoke permission: "+err.Error())
+		return
+	}
+
+	s.auditPolicyChange(r, "revoke", rule)
+	helper.NewSuccessResponse(w, http.StatusOK, "permission revoked successfully", map[string]bool{"changed": removed})
+}
+
+// handleSeedDefaultPolicies godoc
+// @Summary Re-seed default role permissions
+// @Description Re-apply the default role-permission set to the current institute. New institutes receive it automatically on creation
+// @Tags Auth - Policies
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security SessionAuth
+// @Router /auth/policies/seed_defaults [post]
+func (s *Server) handleSeedDefaultPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	dom := s.requestDomain(r)
+	if dom == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "institute is required")
+		return
+	}
+
+	// Rules the institute already has are kept; revoked defaults come back
+	ctx, cancel := s.db.WithTimeout(r.Context())
+	defer cancel()
+	if err := seedDefaultPolicies(ctx, s.db.GetConnection(), dom); err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to seed policies: "+err.Error())
+		return
+	}
+	if err := s.enforcer.LoadPolicy(); err != nil {
+		helper.NewErrorResponse(w, http.StatusInternalServerError, "failed to reload policies: "+err.Error())
+		return
+	}
+
+	s.auditPolicyChange(r, "seed_defaults", []string{"*", dom, "*", "*"})
+	helper.NewSuccessResponse(w, http.StatusOK, "default policies re-seeded successfully", nil)
+}
+
+func (s *Server) parsePolicyRequest(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	if r.Method != http.MethodPost {
+		helper.NewErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil, false
+	}
+
+	var req dto.PolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return nil, false
+	}
+
+	dom := s.requestDomain(r)
+	if dom == "" {
+		helper.NewErrorResponse(w, http.StatusBadRequest, "institute is required")
+		return nil, false
+	}
+
+	if err := validatePolicy(req.Role, req.Object, req.Action); err != nil {
+		helper.NewErrorResponse(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	return []string{req.Role, dom, strings.TrimSpace(req.Object), req.Action}, true
+}
+
+func validatePolicy(role, object, action string) error {
+	switch domain.UserRole(role) {
+	case domain.RoleSuperAdmin:
+		return fmt.Errorf("super_admin permissions are global and cannot be changed")
+	case domain.RoleAdmin, domain.RoleTeacher, domain.RoleAccountant, domain.RoleLibrarian,
+		domain.RoleDriver, domain.RoleEmployee, domain.RoleStudent, domain.RoleGuardian, domain.RoleNurse:
+	default:
+		return fmt.Errorf("unknown role %q", role)
+	}
+
+	if strings.TrimSpace(object) == "" {
+		return fmt.Errorf("object is required")
+	}
+
+	if action == "" {
+		return fmt.Errorf("action is required")
+	}
+	if _, err := regexp.Compile(action); err != nil {
+		return fmt.Errorf("invalid action pattern: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Server) auditPolicyChange(r *http.Request, op string, rule []string) {
+	actor := "unknown"
+	if session, ok := helper.SessionFromContext(r.Context()); ok {
+		actor = session.UserID
+	}
+	log.Printf("rbac: %s by user=%s rule=%v", op, actor, rule)
+}
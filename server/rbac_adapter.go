@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+)
+
+// policyAdapter stores casbin rules in auth.rbac_rules.
+// Columns v0..v3 map to sub, dom, obj, act for "p" rules and
+// user/role, role, domain for "g" rules.
+type policyAdapter struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func newPolicyAdapter(db *sql.DB, timeout time.Duration) *policyAdapter {
+	return &policyAdapter{db: db, timeout: timeout}
+}
+
+func (a *policyAdapter) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), a.timeout)
+}
+
+// LoadPolicy loads all rules from the database into the model.
+func (a *policyAdapter) LoadPolicy(m model.Model) error {
+	ctx, cancel := a.ctx()
+	defer cancel()
+
+	rows, err := a.db.QueryContext(ctx, `SELECT ptype, v0, v1, v2, v3 FROM auth.rbac_rules ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ptype, v0, v1, v2, v3 string
+		if err := rows.Scan(&ptype, &v0, &v1, &v2, &v3); err != nil {
+			return err
+		}
+
+		rule := trimRule([]string{ptype, v0, v1, v2, v3})
+		if err := persist.LoadPolicyArray(rule, m); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// SavePolicy replaces the stored rules with the ones in the model.
+func (a *policyAdapter) SavePolicy(m model.Model) error {
+	ctx, cancel := a.ctx()
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM auth.rbac_rules`); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, sec := range []string{"p", "g"} {
+		for ptype, ast := range m[sec] {
+			for _, rule := range ast.Policy {
+				if err := insertRule(ctx, tx, ptype, rule); err != nil {
+					_ = tx.Rollback()
+					return err
+				}
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (a *policyAdapter) AddPolicy(sec string, ptype string, rule []string) error {
+	ctx, cancel := a.ctx()
+	defer cancel()
+
+	return insertRule(ctx, a.db, ptype, rule)
+}
+
+func (a *policyAdapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+	ctx, cancel := a.ctx()
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		if err := insertRule(ctx, tx, ptype, rule); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (a *policyAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
+	ctx, cancel := a.ctx()
+	defer cancel()
+
+	return deleteRule(ctx, a.db, ptype, rule)
+}
+
+func (a *policyAdapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+	ctx, cancel := a.ctx()
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		if err := deleteRule(ctx, tx, ptype, rule); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (a *policyAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	ctx, cancel := a.ctx()
+	defer cancel()
+
+	query := `DELETE FROM auth.rbac_rules WHERE ptype = $1`
+	args := []any{ptype}
+
+	for i, v := range fieldValues {
+		col := fieldIndex + i
+		if v == "" || col > 3 {
+			continue
+		}
+		args = append(args, v)
+		query += fmt.Sprintf(" AND v%d = $%d", col, len(args))
+	}
+
+	_, err := a.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+//////////////////////////////////////////////////////
+//                   HELPERS                       //
+//////////////////////////////////////////////////////
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertRule(ctx context.Context, db execer, ptype string, rule []string) error {
+	v := padRule(rule)
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO auth.rbac_rules (ptype, v0, v1, v2, v3) VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (ptype, v0, v1, v2, v3) DO NOTHING`,
+		ptype, v[0], v[1], v[2], v[3],
+	)
+	return err
+}
+
+func deleteRule(ctx context.Context, db execer, ptype string, rule []string) error {
+	v := padRule(rule)
+	_, err := db.ExecContext(ctx,
+		`DELETE FROM auth.rbac_rules WHERE ptype = $1 AND v0 = $2 AND v1 = $3 AND v2 = $4 AND v3 = $5`,
+		ptype, v[0], v[1], v[2], v[3],
+	)
+	return err
+}
+
+// seedDefaultPolicies copies auth.rbac_default_rules into an institute.
+func seedDefaultPolicies(ctx context.Context, db execer, dom string) error {
+	_, err := db.ExecContext(ctx, `SELECT auth.seed_institute_rbac($1)`, dom)
+	return err
+}
+
+func padRule(rule []string) [4]string {
+	var v [4]string
+	copy(v[:], rule)
+	return v
+}
+
+// trimRule drops empty trailing columns so "g" rules keep their arity.
+func trimRule(rule []string) []string {
+	end := len(rule)
+	for end > 1 && strings.TrimSpace(rule[end-1]) == "" {
+		end--
+	}
+	return rule[:end]
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+)
+
+func TestPolicyEnforcement(t *testing.T) {
+	e, err := casbin.NewEnforcer("../rbac_with_domains_model.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const inst, other = "inst-1", "inst-2"
+	rules := [][]string{
+		{"admin", inst, "finance/*", actManage},
+		{"admin", inst, objInstitutes, "(read|update)"},
+		{"teacher", inst, objStudents, actRead},
+		{"teacher", inst, objRequisitions, "(create|read)"},
+		{"guardian", inst, objParentCheckout, "(create|read)"},
+	}
+	if _, err := e.AddPolicies(rules); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		role, dom, obj, act string
+		want                bool
+	}{
+		{"admin", inst, objInvoices, actCreate, true},
+		{"admin", inst, objBudgets, actDelete, true},
+		{"admin", other, objInvoices, actRead, false},
+		{"admin", inst, objInstitutes, actUpdate, true},
+		{"admin", inst, objInstitutes, actDelete, false},
+		{"teacher", inst, objStudents, actRead, true},
+		{"teacher", inst, objStudents, actUpdate, false},
+		{"teacher", inst, objInvoices, actRead, false},
+		{"teacher", inst, objRequisitions, actCreate, true},
+		{"teacher", inst, objRequisitions, actDelete, false},
+		{"guardian", inst, objParentCheckout, actCreate, true},
+		{"guardian", inst, objParentPortal, actRead, false},
+		{"accountant", inst, objInvoices, actRead, false},
+		{"super_admin", other, objPolicies, actDelete, true},
+	}
+	for _, tt := range tests {
+		got, err := e.Enforce(tt.role, tt.dom, tt.obj, tt.act)
+		if err != nil {
+			t.Fatalf("Enforce(%s, %s, %s, %s): %v", tt.role, tt.dom, tt.obj, tt.act, err)
+		}
+		if got != tt.want {
+			t.Errorf("Enforce(%s, %s, %s, %s) = %v, want %v", tt.role, tt.dom, tt.obj, tt.act, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	tests := []struct {
+		name, role, object, action string
+		wantErr                    bool
+	}{
+		{"valid", "teacher", objStudents, actRead, false},
+		{"action pattern", "accountant", "finance/*", actManage, false},
+		{"super admin", "super_admin", objStudents, actRead, true},
+		{"unknown role", "janitor", objStudents, actRead, true},
+		{"missing object", "teacher", " ", actRead, true},
+		{"missing action", "teacher", objStudents, "", true},
+		{"bad pattern", "teacher", objStudents, "(read", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePolicy(tt.role, tt.object, tt.action); (err != nil) != tt.wantErr {
+				t.Errorf("validatePolicy error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -4,103 +4,296 @@ import (
 	"net/http"
 	"swiftschool/app/academics"
 	"swiftschool/app/admissions"
+	"swiftschool/app/attendance"
 	"swiftschool/app/auth"
 	"swiftschool/app/common"
 	"swiftschool/app/core"
+	"swiftschool/app/finance"
+	"swiftschool/app/parent"
 	"swiftschool/helper"
 )
 
 // registerAPIRoutes sets up all backend APIs
 func (s *Server) registerAPIRoutes() {
-	register := func(path string, handler func(http.ResponseWriter, *http.Request), authRequired bool) {
-		h := handler
-		if authRequired {
-			h = helper.RequireSession(handler)
-		}
-		s.mux.HandleFunc(path, h)
+	// register guards a route with the session's role-permission for obj/act
+	register := func(path string, handler http.HandlerFunc, obj, act string) {
+		s.mux.HandleFunc(path, s.RequirePermission(obj, act, handler))
+	}
+
+	// registerSession only requires a login; used for self-service session
+	// routes that must work before an institute is selected
+	registerSession := func(path string, handler http.HandlerFunc) {
+		s.mux.HandleFunc(path, helper.RequireSession(handler))
+	}
+
+	// registerPublic exposes a route without authentication
+	registerPublic := func(path string, handler http.HandlerFunc) {
+		s.mux.HandleFunc(path, handler)
 	}
 
 	// ================= HEALTH =================
-	register("/api/health", s.handleHealthCheck, false)
+	registerPublic("/api/health", s.handleHealthCheck)
 
 	// ================= CORE =================
 	coreSvc := core.NewService(s.db)
 	coreHandler := core.NewHandler(coreSvc)
 
-	register("/api/institutes/register", coreHandler.CreateInstitute, true)
-	register("/api/institutes/delete", coreHandler.DeleteInstitute, true)
-	register("/api/institutes/list", coreHandler.ListInstitutes, true)
-	register("/api/institutes/update", coreHandler.UpdateInstitute, true)
-	register("/api/institutes/get", coreHandler.GetInstituteById, true)
+	register("/api/institutes/register", coreHandler.CreateInstitute, objInstitutes, actCreate)
+	register("/api/institutes/delete", coreHandler.DeleteInstitute, objInstitutes, actDelete)
+	register("/api/institutes/list", coreHandler.ListInstitutes, objInstitutes, actRead)
+	register("/api/institutes/update", coreHandler.UpdateInstitute, objInstitutes, actUpdate)
+	register("/api/institutes/get", coreHandler.GetInstituteById, objInstitutes, actRead)
+
+	register("/api/classes/register", coreHandler.CreateClass, objClasses, actCreate)
+	register("/api/classes/delete", coreHandler.DeleteClass, objClasses, actDelete)
+	register("/api/classes/list", coreHandler.ListClasses, objClasses, actRead)
+	register("/api/classes/update", coreHandler.UpdateClass, objClasses, actUpdate)
+
+	register("/api/departments/register", coreHandler.CreateDepartment, objDepartments, actCreate)
+	register("/api/departments/delete", coreHandler.DeleteDepartment, objDepartments, actDelete)
+	register("/api/departments/list", coreHandler.ListDepartments, objDepartments, actRead)
+	register("/api/departments/update", coreHandler.UpdateDepartment, objDepartments, actUpdate)
 
-	register("/api/classes/register", coreHandler.CreateClass, true)
-	register("/api/classes/delete", coreHandler.DeleteClass, true)
-	register("/api/classes/list", coreHandler.ListClasses, true)
-	register("/api/classes/update", coreHandler.UpdateClass, true)
+	register("/api/students/register", coreHandler.CreateStudent, objStudents, actCreate)
+	register("/api/students/delete", coreHandler.DeleteStudent, objStudents, actDelete)
+	register("/api/students/update", coreHandler.UpdateStudent, objStudents, actUpdate)
+	register("/api/students/profile", coreHandler.GetStudentFullProfile, objStudents, actRead)
+	register("/api/students/list_by_class", coreHandler.ListStudentsByClass, objStudents, actRead)
+	register("/api/students/search", coreHandler.SearchStudents, objStudents, actRead)
+	register("/api/students/update_status", coreHandler.UpdateStudentStatus, objStudents, actUpdate)
+	register("/api/students/provision_login", coreHandler.ProvisionStudentLogin, objUsers, actCreate)
 
-	register("/api/departments/register", coreHandler.CreateDepartment, true)
-	register("/api/departments/delete", coreHandler.DeleteDepartment, true)
-	register("/api/departments/list", coreHandler.ListDepartments, true)
-	register("/api/departments/update", coreHandler.UpdateDepartment, true)
+	register("/api/employees/create", coreHandler.CreateEmployee, objEmployees, actCreate)
+	register("/api/employees/delete", coreHandler.DeleteEmployee, objEmployees, actDelete)
+	register("/api/employees/get", coreHandler.GetEmployeeById, objEmployees, actRead)
+	register("/api/employees/profile", coreHandler.GetEmployeeFullProfile, objEmployees, actRead)
+	register("/api/employees/list", coreHandler.ListEmployees, objEmployees, actRead)
+	register("/api/employees/update", coreHandler.UpdateEmployee, objEmployees, actUpdate)
+	register("/api/employees/update_status", coreHandler.UpdateEmployeeStatus, objEmployees, actUpdate)
+	register("/api/employees/provision_login", coreHandler.ProvisionEmployeeLogin, objUsers, actCreate)
 
-	register("/api/students/register", coreHandler.CreateStudent, true)
-	register("/api/students/delete", coreHandler.DeleteStudent, true)
-	register("/api/students/update", coreHandler.UpdateStudent, true)
-	register("/api/students/profile", coreHandler.GetStudentFullProfile, true)
-	register("/api/students/list_by_class", coreHandler.ListStudentsByClass, true)
-	register("/api/students/search", coreHandler.SearchStudents, true)
+	register("/api/guardians/register", coreHandler.CreateGuardian, objGuardians, actCreate)
+	register("/api/guardians/link_student", coreHandler.LinkStudentGuardian, objGuardians, actCreate)
+	register("/api/guardians/provision_login", coreHandler.ProvisionGuardianLogin, objUsers, actCreate)
 
-	register("/api/guardians/register", coreHandler.CreateGuardian, true)
-	register("/api/guardians/link_student", coreHandler.LinkStudentGuardian, true)
+	register("/api/classes/provision_logins", coreHandler.ProvisionClassLogins, objUsers, actCreate)
 
-	register("/api/academic_sessions/register", coreHandler.CreateAcademicSession, true)
-	register("/api/academic_sessions/list", coreHandler.ListAcademicSessions, true)
-	register("/api/academic_sessions/active", coreHandler.GetActiveSession, true)
-	register("/api/academic_sessions/update", coreHandler.UpdateAcademicSession, true)
+	register("/api/academic_sessions/register", coreHandler.CreateAcademicSession, objAcademicSession, actCreate)
+	register("/api/academic_sessions/list", coreHandler.ListAcademicSessions, objAcademicSession, actRead)
+	register("/api/academic_sessions/active", coreHandler.GetActiveSession, objAcademicSession, actRead)
+	register("/api/academic_sessions/update", coreHandler.UpdateAcademicSession, objAcademicSession, actUpdate)
 
-	register("/api/addresses/register", coreHandler.CreateAddress, true)
+	register("/api/addresses/register", coreHandler.CreateAddress, objAddresses, actCreate)
 
 	// ================= ACADEMICS =================
 	academicSvc := academics.NewService(s.db)
 	academicHandler := academics.NewHandler(academicSvc)
 
-	register("/api/subjects/register", academicHandler.CreateSubject, true)
-	register("/api/subjects/list", academicHandler.ListSubjects, true)
+	register("/api/subjects/register", academicHandler.CreateSubject, objSubjects, actCreate)
+	register("/api/subjects/list", academicHandler.ListSubjects, objSubjects, actRead)
 
-	register("/api/class_periods/register", academicHandler.CreateClassPeriod, true)
-	register("/api/class_periods/list", academicHandler.ListClassPeriods, true)
+	register("/api/class_periods/register", academicHandler.CreateClassPeriod, objClassPeriods, actCreate)
+	register("/api/class_periods/list", academicHandler.ListClassPeriods, objClassPeriods, actRead)
 
-	register("/api/timetable/register", academicHandler.CreateTimetableEntry, true)
-	register("/api/timetable/list", academicHandler.GetClassTimetable, true)
+	register("/api/timetable/register", academicHandler.CreateTimetableEntry, objTimetable, actCreate)
+	register("/api/timetable/list", academicHandler.GetClassTimetable, objTimetable, actRead)
+
+	// ================= ATTENDANCE =================
+	attendanceSvc := attendance.NewService(s.db, s.config.App.AttendanceEditWindow)
+	attendanceHandler := attendance.NewHandler(attendanceSvc)
+
+	register("/api/attendance/mark", attendanceHandler.MarkAttendance, objAttendance, actCreate)
+	register("/api/attendance/register", attendanceHandler.GetClassRegister, objAttendance, actRead)
+	register("/api/attendance/reports/student_monthly", attendanceHandler.StudentMonthlyReport, objAttendance, actRead)
+	register("/api/attendance/reports/class_monthly", attendanceHandler.ClassMonthlyReport, objAttendance, actRead)
+	register("/api/attendance/reports/class", attendanceHandler.ClassPercentageReport, objAttendance, actRead)
 
 	// ================= ADMISSIONS =================
 	admissionSvc := admissions.NewService(s.db)
 	admissionHandler := admissions.NewHandler(admissionSvc)
 
-	register("/api/admissions/enquiries/register", admissionHandler.CreateEnquiry, true)
-	register("/api/admissions/enquiries/list", admissionHandler.ListEnquiries, true)
-	register("/api/admissions/enquiries/update_status", admissionHandler.UpdateEnquiryStatus, true)
+	register("/api/admissions/enquiries/register", admissionHandler.CreateEnquiry, objEnquiries, actCreate)
+	register("/api/admissions/enquiries/list", admissionHandler.ListEnquiries, objEnquiries, actRead)
+	register("/api/admissions/enquiries/update_status", admissionHandler.UpdateEnquiryStatus, objEnquiries, actUpdate)
+
+	// ================= FINANCE =================
+	financeSvc := finance.NewService(s.db, s.config.App.PaymentCallbackURL)
+	financeHandler := finance.NewHandler(financeSvc)
+
+	register("/api/finance/accounts/register", financeHandler.CreateAccount, objAccounts, actCreate)
+	register("/api/finance/accounts/list", financeHandler.ListAccounts, objAccounts, actRead)
+	register("/api/finance/accounts/balance", financeHandler.GetAccountBalance, objAccounts, actRead)
+
+	register("/api/finance/journals/register", financeHandler.CreateJournalEntry, objJournals, actCreate)
+	register("/api/finance/journals/items/register", financeHandler.CreateJournalItem, objJournals, actCreate)
+	register("/api/finance/journals/post", financeHandler.PostJournal, objJournals, actCreate)
+	register("/api/finance/journals/post_draft", financeHandler.PostDraftJournal, objJournals, actUpdate)
+	register("/api/finance/journals/reverse", financeHandler.ReverseJournal, objJournals, actCreate)
+	register("/api/finance/journals/get", financeHandler.GetJournalEntry, objJournals, actRead)
+
+	register("/api/finance/statements/trial_balance", financeHandler.TrialBalance, objStatements, actRead)
+	register("/api/finance/statements/income_statement", financeHandler.IncomeStatement, objStatements, actRead)
+	register("/api/finance/statements/balance_sheet", financeHandler.BalanceSheet, objStatements, actRead)
+	register("/api/finance/fiscal_years/close", financeHandler.CloseFiscalYear, objFiscalYears, actCreate)
+	register("/api/finance/fiscal_years/closings", financeHandler.ListFiscalYearClosings, objFiscalYears, actRead)
+
+	// Billing, payments, refunds and purchase receipts post their own journals
+	register("/api/finance/ledger_settings/get", financeHandler.GetLedgerSettings, objLedgerSettings, actRead)
+	register("/api/finance/ledger_settings/update", financeHandler.UpdateLedgerSettings, objLedgerSettings, actUpdate)
+	register("/api/finance/journals/by_source", financeHandler.ListSourceJournals, objJournals, actRead)
+	register("/api/finance/payment_gateway/get", financeHandler.GetPaymentGateway, objPaymentGateway, actRead)
+	register("/api/finance/payment_gateway/update", financeHandler.UpdatePaymentGateway, objPaymentGateway, actUpdate)
+
+	register("/api/finance/fee_heads/register", financeHandler.CreateFeeHead, objFeeHeads, actCreate)
+	register("/api/finance/fee_heads/list", financeHandler.ListFeeHeads, objFeeHeads, actRead)
+	register("/api/finance/fee_structures/register", financeHandler.CreateFeeStructure, objFeeStructures, actCreate)
+	register("/api/finance/fee_structures/list", financeHandler.ListFeeStructures, objFeeStructures, actRead)
+	register("/api/finance/concessions/register", financeHandler.CreateConcession, objConcessions, actCreate)
+	register("/api/finance/concessions/list", financeHandler.ListConcessions, objConcessions, actRead)
+	register("/api/finance/concessions/grant", financeHandler.GrantStudentConcession, objConcessions, actCreate)
+	register("/api/finance/concessions/decide", financeHandler.DecideStudentConcession, objConcessions, actUpdate)
+	register("/api/finance/concessions/list_by_student", financeHandler.ListStudentConcessions, objConcessions, actRead)
+	register("/api/finance/fines/apply", financeHandler.ApplyLateFees, objFines, actCreate)
+	register("/api/finance/fines/waive", financeHandler.WaiveFine, objFines, actUpdate)
+	register("/api/finance/fines/list_by_invoice", financeHandler.ListInvoiceFines, objFines, actRead)
+
+	register("/api/finance/invoices/register", financeHandler.CreateInvoice, objInvoices, actCreate)
+	register("/api/finance/invoices/items/register", financeHandler.CreateInvoiceItem, objInvoices, actCreate)
+	register("/api/finance/invoices/get", financeHandler.GetInvoice, objInvoices, actRead)
+	register("/api/finance/invoices/list_by_student", financeHandler.ListStudentInvoices, objInvoices, actRead)
+	register("/api/finance/invoices/generate", financeHandler.GenerateInvoices, objInvoices, actCreate)
+	register("/api/finance/invoices/overdue", financeHandler.ListOverdueInvoices, objInvoices, actRead)
+	register("/api/finance/defaulters/ageing", financeHandler.FeeDefaulterAgeing, objDefaulters, actRead)
+	register("/api/finance/dunning/schedule", financeHandler.GetDunningSchedule, objDunning, actRead)
+	register("/api/finance/dunning/schedule/update", financeHandler.SetDunningSchedule, objDunning, actUpdate)
+	register("/api/finance/dunning/run", financeHandler.RunDunning, objDunning, actCreate)
+	register("/api/finance/dunning/reminders", financeHandler.ListDunningReminders, objDunning, actRead)
+	register("/api/finance/payment_plans/register", financeHandler.CreatePaymentPlan, objPaymentPlans, actCreate)
+	register("/api/finance/payment_plans/list", financeHandler.ListPaymentPlans, objPaymentPlans, actRead)
+	register("/api/finance/payment_plans/decide", financeHandler.DecidePaymentPlan, objPaymentPlans, actUpdate)
+	register("/api/finance/transactions/register", financeHandler.CreateTransaction, objPayments, actCreate)
+	register("/api/finance/receipts/register", financeHandler.CollectFees, objReceipts, actCreate)
+	register("/api/finance/receipts/get", financeHandler.GetReceipt, objReceipts, actRead)
+	register("/api/finance/receipts/list_by_student", financeHandler.ListStudentReceipts, objReceipts, actRead)
+	register("/api/finance/receipts/cheques/pending", financeHandler.ListPendingCheques, objReceipts, actRead)
+	register("/api/finance/receipts/cheques/clear", financeHandler.ClearCheque, objReceipts, actUpdate)
+	register("/api/finance/receipts/cheques/bounce", financeHandler.BounceCheque, objReceipts, actUpdate)
+	register("/api/finance/wallets/get", financeHandler.GetStudentWallet, objWallets, actRead)
+	register("/api/finance/wallets/entries", financeHandler.ListWalletEntries, objWallets, actRead)
+	register("/api/finance/wallets/topup", financeHandler.TopUpWallet, objWallets, actCreate)
+	register("/api/finance/wallets/debit", financeHandler.DebitWallet, objWallets, actCreate)
+	register("/api/finance/wallets/auto_apply", financeHandler.SetWalletAutoApply, objWallets, actUpdate)
+
+	// Guardians pay invoices online; the gateway reports back unauthenticated
+	// and is trusted only on its signature
+	register("/api/parent/children/invoices/checkout", financeHandler.StartCheckout, objParentCheckout, actCreate)
+	register("/api/parent/children/invoices/checkout/status", financeHandler.GetCheckout, objParentCheckout, actRead)
+	registerPublic("/api/finance/payments/callback", financeHandler.PaymentCallback)
+	register("/api/finance/refunds/request", financeHandler.RequestRefund, objRefunds, actCreate)
+	register("/api/finance/refunds/decide", financeHandler.DecideRefund, objRefunds, actUpdate)
+	register("/api/finance/refunds/process", financeHandler.ProcessRefund, objRefunds, actUpdate)
+	register("/api/finance/refunds/get", financeHandler.GetRefund, objRefunds, actRead)
+	register("/api/finance/refunds/list", financeHandler.ListRefunds, objRefunds, actRead)
+	register("/api/finance/refunds/settings/get", financeHandler.GetRefundSettings, objRefunds, actRead)
+	register("/api/finance/refunds/settings/update", financeHandler.UpdateRefundSettings, objRefunds, actUpdate)
+
+	// Bank statements are imported per bank account and reconciled
+	// against fee payments
+	register("/api/finance/bank_accounts/register", financeHandler.CreateBankAccount, objBankAccounts, actCreate)
+	register("/api/finance/bank_accounts/list", financeHandler.ListBankAccounts, objBankAccounts, actRead)
+	register("/api/finance/bank_accounts/statements/import", financeHandler.ImportBankStatement, objBankAccounts, actCreate)
+	register("/api/finance/bank_accounts/statements/imports", financeHandler.ListBankStatementImports, objBankAccounts, actRead)
+	register("/api/finance/bank_accounts/statements/lines", financeHandler.ListBankStatementEntries, objBankAccounts, actRead)
+	register("/api/finance/bank_accounts/statements/auto_match", financeHandler.AutoMatchBankStatement, objBankAccounts, actUpdate)
+	register("/api/finance/bank_accounts/statements/match", financeHandler.MatchStatementEntry, objBankAccounts, actUpdate)
+	register("/api/finance/bank_accounts/statements/unmatch", financeHandler.UnmatchStatementEntry, objBankAccounts, actUpdate)
+	register("/api/finance/bank_accounts/reconciliation", financeHandler.BankReconciliation, objBankAccounts, actRead)
+
+	register("/api/finance/vendors/register", financeHandler.CreateVendor, objVendors, actCreate)
+	register("/api/finance/vendors/list", financeHandler.ListVendors, objVendors, actRead)
+	register("/api/finance/purchase_orders/register", financeHandler.CreatePurchaseOrder, objPurchaseOrders, actCreate)
+	register("/api/finance/purchase_orders/items/register", financeHandler.AddPurchaseItem, objPurchaseOrders, actCreate)
+	register("/api/finance/purchase_orders/status", financeHandler.UpdatePurchaseStatus, objPurchaseOrders, actUpdate)
+	register("/api/finance/purchase_orders/decide", financeHandler.DecidePurchaseOrder, objPurchaseOrders, actUpdate)
+
+	// Requisitions become purchase orders, received through goods receipts
+	// and paid on vendor bills that match both
+	register("/api/inventory/requisitions/register", financeHandler.CreateRequisition, objRequisitions, actCreate)
+	register("/api/inventory/requisitions/list", financeHandler.ListRequisitions, objRequisitions, actRead)
+	register("/api/inventory/requisitions/reject", financeHandler.RejectRequisition, objRequisitions, actUpdate)
+	register("/api/inventory/requisitions/convert", financeHandler.ConvertRequisition, objRequisitions, actUpdate)
+	register("/api/finance/goods_receipts/register", financeHandler.RecordGoodsReceipt, objGoodsReceipts, actCreate)
+	register("/api/finance/goods_receipts/list", financeHandler.ListGoodsReceipts, objGoodsReceipts, actRead)
+	register("/api/finance/vendor_bills/register", financeHandler.CreateVendorBill, objVendorBills, actCreate)
+	register("/api/finance/vendor_bills/list", financeHandler.ListVendorBills, objVendorBills, actRead)
+	register("/api/finance/vendor_bills/decide", financeHandler.DecideVendorBill, objVendorBills, actUpdate)
+	register("/api/finance/vendor_bills/pay", financeHandler.PayVendorBill, objVendorBills, actCreate)
+	register("/api/finance/vendor_bills/ageing", financeHandler.PayablesAgeing, objVendorBills, actRead)
+
+	register("/api/finance/budgets/register", financeHandler.CreateBudget, objBudgets, actCreate)
+	register("/api/finance/budgets/update", financeHandler.UpdateBudget, objBudgets, actUpdate)
+	register("/api/finance/budgets/list", financeHandler.ListBudgets, objBudgets, actRead)
+	register("/api/finance/budgets/report", financeHandler.BudgetReport, objBudgets, actRead)
+
+	register("/api/finance/taxes/register", financeHandler.CreateTax, objTaxes, actCreate)
+	register("/api/finance/taxes/list", financeHandler.ListTaxes, objTaxes, actRead)
+	register("/api/finance/taxes/settings", financeHandler.GetTaxSettings, objTaxes, actRead)
+	register("/api/finance/taxes/settings/update", financeHandler.UpdateTaxSettings, objTaxes, actUpdate)
+	register("/api/finance/taxes/fee_heads", financeHandler.AssignFeeHeadTax, objTaxes, actUpdate)
+	register("/api/finance/taxes/items", financeHandler.AssignItemTax, objTaxes, actUpdate)
+	register("/api/finance/taxes/lines", financeHandler.ListTaxLines, objTaxes, actRead)
+	register("/api/finance/taxes/summary", financeHandler.TaxSummary, objTaxes, actRead)
 
 	// ================= AUTH =================
 	authSvc := auth.NewService(s.db)
 	authHandler := auth.NewHandler(authSvc)
 
-	register("/api/auth/login", authHandler.Login, false)            // 2-step HTMX login
-	register("/api/auth/verification", authHandler.VerifyOTP, false) // OTP verification
-	// register("/api/auth/logout", authHandler.Logout, true)
+	registerPublic("/api/auth/login", authHandler.Login)            // 2-step HTMX login
+	registerPublic("/api/auth/verification", authHandler.VerifyOTP) // OTP verification
+	registerPublic("/api/auth/password_login", authHandler.PasswordLogin)
+	registerPublic("/api/auth/totp_verification", authHandler.VerifyTOTPLogin)
+	registerPublic("/api/auth/password/forgot", authHandler.ForgotPassword)
+	registerPublic("/api/auth/password/reset", authHandler.ResetPassword)
+	registerSession("/api/auth/password/change", authHandler.ChangePassword)
+	registerSession("/api/auth/totp/enroll", authHandler.EnrollTOTP)
+	registerSession("/api/auth/totp/activate", authHandler.ActivateTOTP)
+	registerSession("/api/auth/totp/disable", authHandler.DisableTOTP)
+	registerSession("/api/auth/totp/recovery_codes", authHandler.RegenerateRecoveryCodes)
+	registerSession("/api/auth/logout", authHandler.Logout)
+	registerSession("/api/auth/logout_all", authHandler.LogoutAllDevices)
+	registerSession("/api/auth/switch_institute", authHandler.SwitchInstitute)
 
-	register("/api/auth/users/register", authHandler.CreateUser, true)
-	register("/api/auth/users/get_by_username", authHandler.GetUserByUsername, true)
-	register("/api/auth/users/get_by_id", authHandler.GetUserById, true)
-	register("/api/auth/users/update_password", authHandler.UpdateUserPassword, true)
-	register("/api/auth/users/update_status", authHandler.UpdateUserStatus, true)
-	register("/api/auth/users/list_by_role", authHandler.ListUsersByRole, true)
+	register("/api/auth/users/register", authHandler.CreateUser, objUsers, actCreate)
+	register("/api/auth/users/get_by_username", authHandler.GetUserByUsername, objUsers, actRead)
+	register("/api/auth/users/get_by_id", authHandler.GetUserById, objUsers, actRead)
+	register("/api/auth/users/update_password", authHandler.UpdateUserPassword, objUsers, actUpdate)
+	register("/api/auth/users/update_status", authHandler.UpdateUserStatus, objUsers, actUpdate)
+	register("/api/auth/users/list_by_role", authHandler.ListUsersByRole, objUsers, actRead)
+	register("/api/auth/users/force_logout", authHandler.ForceLogout, objUsers, actUpdate)
+
+	register("/api/auth/policies/list", s.handleListPolicies, objPolicies, actRead)
+	register("/api/auth/policies/grant", s.handleGrantPolicy, objPolicies, actCreate)
+	register("/api/auth/policies/revoke", s.handleRevokePolicy, objPolicies, actDelete)
+	register("/api/auth/policies/seed_defaults", s.handleSeedDefaultPolicies, objPolicies, actCreate)
 
 	// ================= COMMON =================
 	commonSvc := common.NewService(s.db)
 	commonHandler := common.NewHandler(commonSvc)
 
-	register("/api/common/documents/create", commonHandler.CreateDocument, true)
-	register("/api/common/documents/list", commonHandler.ListDocuments, true)
-	register("/api/common/notifications/create", commonHandler.CreateNotification, true)
+	register("/api/common/documents/create", commonHandler.CreateDocument, objDocuments, actCreate)
+	register("/api/common/documents/list", commonHandler.ListDocuments, objDocuments, actRead)
+	register("/api/common/notifications/create", commonHandler.CreateNotification, objNotifications, actCreate)
+
+	// ================= PARENT PORTAL =================
+	// Guardians only; each query is filtered through the guardian-student map
+	parentSvc := parent.NewService(s.db)
+	parentHandler := parent.NewHandler(parentSvc)
+
+	register("/api/parent/children", parentHandler.ListChildren, objParentPortal, actRead)
+	register("/api/parent/children/profile", parentHandler.GetChild, objParentPortal, actRead)
+	register("/api/parent/children/timetable", parentHandler.ListChildTimetable, objParentPortal, actRead)
+	register("/api/parent/children/invoices", parentHandler.ListChildInvoices, objParentPortal, actRead)
+	register("/api/parent/children/exam_results", parentHandler.ListChildExamResults, objParentPortal, actRead)
+	register("/api/parent/children/assignments", parentHandler.ListChildAssignments, objParentPortal, actRead)
+	register("/api/parent/children/notifications", parentHandler.ListChildNotifications, objParentPortal, actRead)
 }
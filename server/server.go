@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,8 +11,12 @@ import (
 	"syscall"
 	"time"
 
+	"swiftschool/app/finance"
 	"swiftschool/config"
+	"swiftschool/helper"
 	"swiftschool/internal/database"
+
+	"github.com/casbin/casbin/v2"
 )
 
 // @title SwiftSchool API
@@ -35,10 +40,12 @@ import (
 // @description Session-based authentication using HTTP-only cookies
 
 type Server struct {
-	server *http.Server
-	mux    *http.ServeMux
-	config *config.Config
-	db     *database.Database
+	server   *http.Server
+	mux      *http.ServeMux
+	config   *config.Config
+	db       *database.Database
+	enforcer *casbin.SyncedEnforcer
+	stopJobs context.CancelFunc
 }
 
 // NewServer creates and configures a new HTTP server instance
@@ -54,6 +61,43 @@ func NewServer(cfg *config.Config) *Server {
 	// Create database wrapper with query timeout
 	db := database.New(sqlDB, cfg.Postgres.QueryTimeout())
 
+	// Persist sessions so logins survive restarts and span replicas
+	configureSessions(cfg.App, sqlDB)
+
+	// OTP storage, throttling and expiry cleanup
+	configureOTP(cfg.App, sqlDB)
+
+	// Password lockout, reset links and TOTP secret encryption
+	helper.SetAuthPolicy(helper.AuthPolicy{
+		MaxLoginAttempts: cfg.App.LoginMaxAttempts,
+		LoginLockout:     cfg.App.LoginLockout,
+		ResetTokenTTL:    cfg.App.PasswordResetTTL,
+		ResetURL:         cfg.App.PasswordResetURL,
+	}, cfg.App.MFAEncryptionKey)
+
+	// Gateway credentials for online fee payments. The fake gateway lets
+	// anyone sign their own callbacks, so production never offers it.
+	helper.SetPaymentSecretKey(cfg.App.PaymentEncryptionKey)
+	if cfg.App.Environment != "production" {
+		helper.EnableFakePayment()
+	}
+
+	// Email and SMS delivery with results recorded in comms logs
+	configureNotifications(cfg.App, sqlDB)
+
+	// Load RBAC model and database policies
+	enforcer, err := newEnforcer(cfg.App.RBACModelPath, sqlDB, cfg.Postgres.QueryTimeout(), cfg.App.RBACReloadInterval)
+	if err != nil {
+		log.Fatalf("Failed to load RBAC policies: %v", err)
+	}
+
+	// Finance jobs run until Stop. Replicas beyond the first, and tests,
+	// set DISABLE_BACKGROUND_JOBS so each job runs once per deployment.
+	jobs, stopJobs := context.WithCancel(context.Background())
+	if !cfg.App.DisableBackgroundJobs {
+		startFinanceJobs(jobs, cfg.App, db)
+	}
+
 	server := &http.Server{
 		Addr:         cfg.App.ServerPort,
 		Handler:      mux,
@@ -64,16 +108,124 @@ func NewServer(cfg *config.Config) *Server {
 	}
 
 	s := &Server{
-		server: server,
-		mux:    mux,
-		config: cfg,
-		db:     db,
+		server:   server,
+		mux:      mux,
+		config:   cfg,
+		db:       db,
+		enforcer: enforcer,
+		stopJobs: stopJobs,
 	}
 	// Initialize routes
 	s.SetupRoutes()
 	return s
 }
 
+// configureSessions selects the session backend and starts expiry cleanup
+func configureSessions(cfg *config.AppConfig, sqlDB *sql.DB) {
+	var store helper.SessionStore
+	switch cfg.SessionStore {
+	case "memory":
+		store = helper.NewMemorySessionStore()
+	default:
+		store = helper.NewPostgresSessionStore(sqlDB)
+	}
+
+	helper.SetSessionStore(store, cfg.SessionIdleTimeout, cfg.SessionAbsoluteTimeout)
+
+	interval := cfg.SessionCleanupInterval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	helper.StartSessionJanitor(context.Background(), interval)
+}
+
+// configureOTP selects the OTP backend and limits and starts expiry cleanup
+func configureOTP(cfg *config.AppConfig, sqlDB *sql.DB) {
+	var store helper.OTPStore
+	switch cfg.OTPStore {
+	case "memory":
+		store = helper.NewMemoryOTPStore()
+	default:
+		store = helper.NewPostgresOTPStore(sqlDB)
+	}
+
+	helper.SetOTPStore(store, helper.OTPPolicy{
+		TTL:            cfg.OTPTTL,
+		MaxAttempts:    cfg.OTPMaxAttempts,
+		Lockout:        cfg.OTPLockout,
+		ResendCooldown: cfg.OTPResendCooldown,
+		DailyLimit:     cfg.OTPDailyLimit,
+	}, cfg.OTPHashKey)
+
+	interval := cfg.OTPCleanupInterval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	helper.StartOTPJanitor(context.Background(), interval)
+}
+
+// startFinanceJobs starts the scheduled finance jobs until ctx is done
+func startFinanceJobs(ctx context.Context, cfg *config.AppConfig, db *database.Database) {
+	svc := finance.NewService(db, cfg.PaymentCallbackURL)
+
+	lateFeeInterval := cfg.LateFeeInterval
+	if lateFeeInterval <= 0 {
+		lateFeeInterval = 6 * time.Hour
+	}
+	finance.StartLateFeeJob(ctx, svc, lateFeeInterval)
+
+	dunningInterval := cfg.DunningInterval
+	if dunningInterval <= 0 {
+		dunningInterval = 6 * time.Hour
+	}
+	finance.StartDunningJob(ctx, svc, dunningInterval)
+}
+
+// configureNotifications registers the email and SMS notifiers
+func configureNotifications(cfg *config.AppConfig, sqlDB *sql.DB) {
+	deliveryLog := helper.NewPostgresDeliveryLog(sqlDB)
+
+	if cfg.SMTPHost != "" {
+		port := cfg.SMTPPort
+		if port == 0 {
+			port = 587
+		}
+		from := cfg.MailFrom
+		if from == "" {
+			from = cfg.SMTPUsername
+		}
+
+		smtp := helper.NewSMTPNotifier(helper.EmailConfig{
+			SMTPHost:           cfg.SMTPHost,
+			SMTPPort:           port,
+			SenderMail:         cfg.SMTPUsername,
+			SenderMailPassword: cfg.SMTPPassword,
+			From:               from,
+		})
+		helper.RegisterNotifier(helper.NewLoggedNotifier(smtp, deliveryLog))
+	} else {
+		log.Printf("SMTP_HOST not set, email delivery is disabled")
+	}
+
+	var provider helper.SMSProvider
+	switch cfg.SMSProvider {
+	case "http":
+		if cfg.SMSAPIURL == "" {
+			log.Fatalf("SMS_PROVIDER is http but SMS_API_URL is not set")
+		}
+		provider = helper.NewHTTPSMSProvider(cfg.SMSAPIURL, cfg.SMSAPIKey, cfg.SMSSenderID, 0)
+	case "fake":
+		log.Printf("Using fake SMS provider, text messages will not be delivered")
+		provider = helper.NewFakeSMSProvider()
+	case "":
+		log.Printf("SMS_PROVIDER not set, SMS delivery is disabled")
+		return
+	default:
+		log.Fatalf("Unknown SMS_PROVIDER %q, use http or fake", cfg.SMSProvider)
+	}
+	helper.RegisterNotifier(helper.NewLoggedNotifier(helper.NewSMSNotifier(provider), deliveryLog))
+}
+
 // Start begins listening for HTTP requests
 func (s *Server) Start() error {
 	log.Printf("Starting server on %s", s.server.Addr)
@@ -108,7 +260,8 @@ func (s *Server) Start() error {
 	return nil
 }
 
-// Stop gracefully shuts down the server
+// Stop gracefully shuts down the server and its background jobs
 func (s *Server) Stop(ctx context.Context) error {
+	s.stopJobs()
 	return s.server.Shutdown(ctx)
 }
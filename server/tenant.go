@@ -0,0 +1,59 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"swiftschool/helper"
+
+	"github.com/google/uuid"
+)
+
+// RequireTenant binds the session's institute to the request context.
+// Clients may still send X-Institute-ID or ?institute_id= but only as an
+// assertion: a value that differs from the session tenant is rejected.
+// Must run inside helper.RequireSession.
+func (s *Server) RequireTenant(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := helper.SessionFromContext(r.Context())
+		if !ok {
+			helper.NewErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		tenant := helper.SessionInstitute(session)
+
+		if claimed := claimedInstitute(r); claimed != "" && !sameInstitute(claimed, tenant) {
+			log.Printf("tenant: cross-tenant access rejected user=%s role=%s tenant=%q requested=%q path=%s ip=%s",
+				session.UserID, session.Role, tenant, claimed, r.URL.Path, r.RemoteAddr)
+			helper.NewErrorResponse(w, http.StatusForbidden, helper.ErrCrossTenant.Error())
+			return
+		}
+
+		ctx := r.Context()
+		if tenant != "" {
+			instID, err := uuid.Parse(tenant)
+			if err != nil {
+				log.Printf("tenant: session %s has malformed institute %q", session.ID, tenant)
+				helper.NewErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			ctx = helper.WithInstituteID(ctx, instID)
+		}
+
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// claimedInstitute returns the institute the client asked for, if any.
+func claimedInstitute(r *http.Request) string {
+	if v := r.Header.Get("X-Institute-ID"); v != "" {
+		return v
+	}
+	return r.URL.Query().Get("institute_id")
+}
+
+func sameInstitute(claimed, tenant string) bool {
+	id, err := uuid.Parse(claimed)
+	return err == nil && id.String() == tenant
+}